@@ -0,0 +1,36 @@
+package config
+
+// AdsConfig 本地广告投放配置
+type AdsConfig struct {
+	RadiusKm           float64 `mapstructure:"radius_km" yaml:"radius_km" json:"radius_km"`                                  // 带位置请求时只返回该半径内的广告（公里），默认10
+	FrequencyCap       int     `mapstructure:"frequency_cap" yaml:"frequency_cap" json:"frequency_cap"`                      // 同一用户每天看到同一广告的最多次数，默认5，-1表示不限
+	MaxResults         int     `mapstructure:"max_results" yaml:"max_results" json:"max_results"`                            // 单次最多返回广告数，默认20
+	TripDestinationAds string  `mapstructure:"trip_destination_ads" yaml:"trip_destination_ads" json:"trip_destination_ads"` // on/off，行程中页面是否展示目的地附近的广告，默认on
+}
+
+// Validate 验证并设置广告配置默认值
+func (c *AdsConfig) Validate() {
+	if c.RadiusKm <= 0 {
+		c.RadiusKm = 10
+	}
+	if c.FrequencyCap == 0 {
+		c.FrequencyCap = 5
+	}
+	if c.MaxResults <= 0 {
+		c.MaxResults = 20
+	}
+	if c.TripDestinationAds == "" {
+		c.TripDestinationAds = StatusOn
+	}
+}
+
+// GetAdsConfig 获取广告配置（带默认值）
+func GetAdsConfig() *AdsConfig {
+	cfg := Get()
+	if cfg == nil || cfg.Ads == nil {
+		result := &AdsConfig{}
+		result.Validate()
+		return result
+	}
+	return cfg.Ads
+}
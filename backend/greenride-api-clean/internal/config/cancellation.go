@@ -0,0 +1,75 @@
+package config
+
+// CancellationConfig 取消费及乘客爽约费配置
+type CancellationConfig struct {
+	Enabled              string                 `mapstructure:"enabled" yaml:"enabled" json:"enabled"`                                                    // on/off，默认off，运营确认收费规则后开启
+	GracePeriodSeconds   int                    `mapstructure:"grace_period_seconds" yaml:"grace_period_seconds" json:"grace_period_seconds"`             // 司机接单后乘客免费取消时长（秒），默认120
+	TimeFeeTiers         []*CancellationFeeTier `mapstructure:"time_fee_tiers" yaml:"time_fee_tiers" json:"time_fee_tiers"`                               // 按接单后经过分钟数计算的阶梯取消费
+	DistanceFeeTiers     []*CancellationFeeTier `mapstructure:"distance_fee_tiers" yaml:"distance_fee_tiers" json:"distance_fee_tiers"`                   // 按司机接单后已行驶公里数计算的阶梯取消费
+	NoShowWaitMinutes    int                    `mapstructure:"no_show_wait_minutes" yaml:"no_show_wait_minutes" json:"no_show_wait_minutes"`             // 司机到达后至少等待N分钟才能报乘客未到场，默认5
+	NoShowRadiusMeters   float64                `mapstructure:"no_show_radius_meters" yaml:"no_show_radius_meters" json:"no_show_radius_meters"`          // 司机定位距上车点不超过N米才认定在上车点，默认200
+	NoShowLocationMaxAge int                    `mapstructure:"no_show_location_max_age" yaml:"no_show_location_max_age" json:"no_show_location_max_age"` // 司机定位不得早于N秒前，默认120
+	NoShowFee            float64                `mapstructure:"no_show_fee" yaml:"no_show_fee" json:"no_show_fee"`                                        // 乘客爽约费，默认1500
+	DriverSharePercent   float64                `mapstructure:"driver_share_percent" yaml:"driver_share_percent" json:"driver_share_percent"`             // 取消费中司机分成比例（%），其余归平台，默认80
+	CapAtFare            string                 `mapstructure:"cap_at_fare" yaml:"cap_at_fare" json:"cap_at_fare"`                                        // on/off，取消费不超过订单预估金额，默认on
+	MaxLocationJumpKm    float64                `mapstructure:"max_location_jump_km" yaml:"max_location_jump_km" json:"max_location_jump_km"`             // 计算司机行驶距离时忽略超过N公里的定位跳点，默认2
+}
+
+// CancellationFeeTier 阶梯取消费，From为分钟数或公里数，达到From后收取Fee
+type CancellationFeeTier struct {
+	From float64 `mapstructure:"from" yaml:"from" json:"from"`
+	Fee  float64 `mapstructure:"fee" yaml:"fee" json:"fee"`
+}
+
+// Validate 验证并设置取消费配置默认值
+func (c *CancellationConfig) Validate() {
+	if c.Enabled == "" {
+		c.Enabled = StatusOff
+	}
+	if c.GracePeriodSeconds <= 0 {
+		c.GracePeriodSeconds = 120
+	}
+	if len(c.TimeFeeTiers) == 0 {
+		c.TimeFeeTiers = []*CancellationFeeTier{{From: 2, Fee: 500}, {From: 5, Fee: 1000}}
+	}
+	if len(c.DistanceFeeTiers) == 0 {
+		c.DistanceFeeTiers = []*CancellationFeeTier{{From: 1, Fee: 500}, {From: 3, Fee: 1000}}
+	}
+	if c.NoShowWaitMinutes <= 0 {
+		c.NoShowWaitMinutes = 5
+	}
+	if c.NoShowRadiusMeters <= 0 {
+		c.NoShowRadiusMeters = 200
+	}
+	if c.NoShowLocationMaxAge <= 0 {
+		c.NoShowLocationMaxAge = 120
+	}
+	if c.NoShowFee <= 0 {
+		c.NoShowFee = 1500
+	}
+	if c.DriverSharePercent <= 0 || c.DriverSharePercent > 100 {
+		c.DriverSharePercent = 80
+	}
+	if c.CapAtFare == "" {
+		c.CapAtFare = StatusOn
+	}
+	if c.MaxLocationJumpKm <= 0 {
+		c.MaxLocationJumpKm = 2
+	}
+}
+
+// IsEnabled 是否开启取消费
+func (c *CancellationConfig) IsEnabled() bool {
+	return c != nil && c.Enabled == StatusOn
+}
+
+// GetCancellationConfig 获取取消费配置（带默认值）
+func GetCancellationConfig() *CancellationConfig {
+	cfg := Get()
+	if cfg == nil || cfg.Cancellation == nil {
+		result := &CancellationConfig{}
+		result.Validate()
+		return result
+	}
+	return cfg.Cancellation
+}
@@ -46,6 +46,18 @@ type Config struct {
 	Stripe     *StripeGlobalConfig  `mapstructure:"stripe"`      // Stripe支付配置
 	Order      *OrderConfig         `mapstructure:"order"`       // 订单配置
 	InnoPaaS   *InnoPaaSConfig   `mapstructure:"innopaas"`    // InnoPaaS SMS配置
+	RateLimit  *RateLimitConfig  `mapstructure:"rate_limit"`  // 接口限流配置
+	KYC        *KYCConfig        `mapstructure:"kyc"`         // 司机证件审核配置
+	Referral   *ReferralConfig   `mapstructure:"referral"`    // 邀请奖励配置
+	Ads        *AdsConfig        `mapstructure:"ads"`         // 本地广告投放配置
+	Cancellation *CancellationConfig `mapstructure:"cancellation"` // 取消费及爽约费配置
+	Pool         *PoolConfig         `mapstructure:"pool"`         // 拼车配置
+	Delivery     *DeliveryConfig     `mapstructure:"delivery"`     // 包裹配送配置
+	Tip          *TipConfig          `mapstructure:"tip"`          // 行程小费配置
+	SavedPlace   *SavedPlaceConfig   `mapstructure:"saved_place"`  // 常用地点配置
+	SavedPaymentMethod *SavedPaymentMethodConfig `mapstructure:"saved_payment_method"` // 已保存支付方式配置
+	Dunning            *DunningConfig            `mapstructure:"dunning"`              // 车费催收配置
+	Safety             *SafetyConfig             `mapstructure:"safety"`               // 行程安全配置
 }
 
 func (c *Config) IsSandbox() bool {
@@ -163,6 +175,54 @@ func (c *Config) Validate() {
 	if err := c.Order.Validate(); err != nil {
 		fmt.Printf("Order config validation error: %v\n", err)
 	}
+	if c.RateLimit == nil {
+		c.RateLimit = &RateLimitConfig{}
+	}
+	c.RateLimit.Validate()
+	if c.KYC == nil {
+		c.KYC = &KYCConfig{}
+	}
+	c.KYC.Validate()
+	if c.Referral == nil {
+		c.Referral = &ReferralConfig{}
+	}
+	c.Referral.Validate()
+	if c.Ads == nil {
+		c.Ads = &AdsConfig{}
+	}
+	c.Ads.Validate()
+	if c.Cancellation == nil {
+		c.Cancellation = &CancellationConfig{}
+	}
+	c.Cancellation.Validate()
+	if c.Pool == nil {
+		c.Pool = &PoolConfig{}
+	}
+	c.Pool.Validate()
+	if c.Delivery == nil {
+		c.Delivery = &DeliveryConfig{}
+	}
+	c.Delivery.Validate()
+	if c.Tip == nil {
+		c.Tip = &TipConfig{}
+	}
+	c.Tip.Validate()
+	if c.SavedPlace == nil {
+		c.SavedPlace = &SavedPlaceConfig{}
+	}
+	c.SavedPlace.Validate()
+	if c.SavedPaymentMethod == nil {
+		c.SavedPaymentMethod = &SavedPaymentMethodConfig{}
+	}
+	c.SavedPaymentMethod.Validate()
+	if c.Dunning == nil {
+		c.Dunning = &DunningConfig{}
+	}
+	c.Dunning.Validate()
+	if c.Safety == nil {
+		c.Safety = &SafetyConfig{}
+	}
+	c.Safety.Validate()
 }
 
 func (c *Config) validateDatabaseConfig() {
@@ -0,0 +1,72 @@
+package config
+
+import "slices"
+
+// DeliveryConfig 包裹配送配置
+type DeliveryConfig struct {
+	Enabled         string   `mapstructure:"enabled" yaml:"enabled" json:"enabled"`                               // on/off，默认on
+	ExpireMinutes   int      `mapstructure:"expire_minutes" yaml:"expire_minutes" json:"expire_minutes"`          // 配送订单无人接单的过期时间（分钟），默认60
+	MaxWeightKg     float64  `mapstructure:"max_weight_kg" yaml:"max_weight_kg" json:"max_weight_kg"`             // 单件包裹最大重量（公斤），默认30
+	MaxPhotos       int      `mapstructure:"max_photos" yaml:"max_photos" json:"max_photos"`                      // 下单时最多附带的包裹照片数，默认5
+	MaxPhotoSize    int64    `mapstructure:"max_photo_size" yaml:"max_photo_size" json:"max_photo_size"`          // 单张照片大小上限（MB），默认10
+	CodeLength      int      `mapstructure:"code_length" yaml:"code_length" json:"code_length"`                   // 取件码位数，默认4
+	MaxCodeAttempts int      `mapstructure:"max_code_attempts" yaml:"max_code_attempts" json:"max_code_attempts"` // 取件码最多输错次数，超过后只能拍照签收，默认5
+	MaxCodeResends  int      `mapstructure:"max_code_resends" yaml:"max_code_resends" json:"max_code_resends"`    // 每单最多重发取件码次数，默认3
+	ResendInterval  int      `mapstructure:"resend_interval" yaml:"resend_interval" json:"resend_interval"`       // 两次发送取件码的最小间隔（秒），默认60
+	ProofTypes      []string `mapstructure:"proof_types" yaml:"proof_types" json:"proof_types"`                   // 允许的签收方式 otp/photo，默认两者都允许
+}
+
+// Validate 验证并设置配送配置默认值
+func (c *DeliveryConfig) Validate() {
+	if c.Enabled == "" {
+		c.Enabled = StatusOn
+	}
+	if c.ExpireMinutes <= 0 {
+		c.ExpireMinutes = 60
+	}
+	if c.MaxWeightKg <= 0 {
+		c.MaxWeightKg = 30
+	}
+	if c.MaxPhotos <= 0 {
+		c.MaxPhotos = 5
+	}
+	if c.MaxPhotoSize <= 0 {
+		c.MaxPhotoSize = 10
+	}
+	if c.CodeLength < 4 || c.CodeLength > 8 {
+		c.CodeLength = 4
+	}
+	if c.MaxCodeAttempts <= 0 {
+		c.MaxCodeAttempts = 5
+	}
+	if c.MaxCodeResends <= 0 {
+		c.MaxCodeResends = 3
+	}
+	if c.ResendInterval <= 0 {
+		c.ResendInterval = 60
+	}
+	if len(c.ProofTypes) == 0 {
+		c.ProofTypes = []string{"otp", "photo"}
+	}
+}
+
+// IsEnabled 是否开启包裹配送
+func (c *DeliveryConfig) IsEnabled() bool {
+	return c != nil && c.Enabled == StatusOn
+}
+
+// IsProofAllowed 签收方式是否允许
+func (c *DeliveryConfig) IsProofAllowed(proofType string) bool {
+	return slices.Contains(c.ProofTypes, proofType)
+}
+
+// GetDeliveryConfig 获取配送配置（带默认值）
+func GetDeliveryConfig() *DeliveryConfig {
+	cfg := Get()
+	if cfg == nil || cfg.Delivery == nil {
+		result := &DeliveryConfig{}
+		result.Validate()
+		return result
+	}
+	return cfg.Delivery
+}
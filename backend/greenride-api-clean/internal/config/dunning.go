@@ -0,0 +1,40 @@
+package config
+
+// DunningConfig 行程车费催收配置：自动扣款失败后按退避间隔重试，欠款超过上限时限制下单
+type DunningConfig struct {
+	RetryIntervals []int   `mapstructure:"retry_intervals" yaml:"retry_intervals" json:"retry_intervals"` // 每次重试距上次扣款的间隔（分钟），次数即最大重试次数，默认30分钟、6小时、1天、3天
+	GraceMinutes   int     `mapstructure:"grace_minutes" yaml:"grace_minutes" json:"grace_minutes"`       // 行程结束后超过该时间仍未支付的订单记为车费欠款，默认30
+	BlockThreshold float64 `mapstructure:"block_threshold" yaml:"block_threshold" json:"block_threshold"` // 欠款超过该金额时限制下单，默认5000
+	Currency       string  `mapstructure:"currency" yaml:"currency" json:"currency"`                      // 欠款上限的币种，默认RWF
+	BatchSize      int     `mapstructure:"batch_size" yaml:"batch_size" json:"batch_size"`                // 每次任务处理的最大欠款数，默认100
+}
+
+// Validate 验证并设置催收配置默认值
+func (c *DunningConfig) Validate() {
+	if len(c.RetryIntervals) == 0 {
+		c.RetryIntervals = []int{30, 360, 1440, 4320}
+	}
+	if c.GraceMinutes <= 0 {
+		c.GraceMinutes = 30
+	}
+	if c.BlockThreshold <= 0 {
+		c.BlockThreshold = 5000
+	}
+	if c.Currency == "" {
+		c.Currency = "RWF"
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = 100
+	}
+}
+
+// GetDunningConfig 获取催收配置（带默认值）
+func GetDunningConfig() *DunningConfig {
+	cfg := Get()
+	if cfg == nil || cfg.Dunning == nil {
+		result := &DunningConfig{}
+		result.Validate()
+		return result
+	}
+	return cfg.Dunning
+}
@@ -1,5 +1,7 @@
 package config
 
+import "strings"
+
 type GoogleConfig struct {
 	MapsAPIKey       string `mapstructure:"maps_api_key"`
 	CloudAPIKey      string `mapstructure:"cloud_api_key"`
@@ -10,6 +12,8 @@ type GoogleConfig struct {
 	MaxRetries           int `mapstructure:"max_retries"`             // 最大重试次数
 	RateLimitWindow      int `mapstructure:"rate_limit_window"`       // 限流窗口(秒)
 	MaxRequestsPerWindow int `mapstructure:"max_requests_per_window"` // 窗口内最大请求数
+	// Places API 地址，测试或代理时可替换
+	PlacesBaseURL string `mapstructure:"places_base_url"`
 }
 
 // Validate 验证并设置Google配置默认值
@@ -26,4 +30,8 @@ func (g *GoogleConfig) Validate() {
 	if g.MaxRequestsPerWindow <= 0 {
 		g.MaxRequestsPerWindow = 50 // 默认每分钟50个请求
 	}
+	if g.PlacesBaseURL == "" {
+		g.PlacesBaseURL = "https://maps.googleapis.com/maps/api/place"
+	}
+	g.PlacesBaseURL = strings.TrimRight(g.PlacesBaseURL, "/")
 }
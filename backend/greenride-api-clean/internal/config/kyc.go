@@ -0,0 +1,73 @@
+package config
+
+import "sort"
+
+// 司机证件类型
+const (
+	DocumentTypeDriverLicense       = "driver_license"
+	DocumentTypeNationalID          = "national_id"
+	DocumentTypeInsurance           = "insurance"
+	DocumentTypeVehicleRegistration = "vehicle_registration"
+	DocumentTypeVehicleInspection   = "vehicle_inspection" // 车检，仅记录在车辆信息上
+)
+
+// KYCConfig 司机证件审核配置
+type KYCConfig struct {
+	Enforce           string   `mapstructure:"enforce" yaml:"enforce" json:"enforce"`                                     // on/off，开启后证件未齐全的司机不能上线，默认off（存量司机证件审核完成后再开启）
+	RequiredDocuments []string `mapstructure:"required_documents" yaml:"required_documents" json:"required_documents"`    // 上线必须审核通过的证件类型
+	MaxFileSize       int64    `mapstructure:"max_file_size" yaml:"max_file_size" json:"max_file_size"`                   // 单个证件图片大小上限(MB)
+	ExpiryWarningDays []int    `mapstructure:"expiry_warning_days" yaml:"expiry_warning_days" json:"expiry_warning_days"` // 证件到期前提醒天数，默认30/7/1
+	ExpirySuspend     string   `mapstructure:"expiry_suspend" yaml:"expiry_suspend" json:"expiry_suspend"`                // on/off，已通过的证件过期后停用司机，不受enforce影响，默认on
+}
+
+// Validate 验证并设置证件审核配置默认值
+func (c *KYCConfig) Validate() {
+	if c.Enforce == "" {
+		c.Enforce = StatusOff
+	}
+	if len(c.RequiredDocuments) == 0 {
+		c.RequiredDocuments = []string{
+			DocumentTypeDriverLicense,
+			DocumentTypeNationalID,
+			DocumentTypeInsurance,
+			DocumentTypeVehicleRegistration,
+		}
+	}
+	if c.MaxFileSize <= 0 {
+		c.MaxFileSize = 10
+	}
+	if len(c.ExpiryWarningDays) == 0 {
+		c.ExpiryWarningDays = []int{30, 7, 1}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(c.ExpiryWarningDays)))
+	if c.ExpirySuspend == "" {
+		c.ExpirySuspend = StatusOn
+	}
+}
+
+// IsEnforced 是否要求证件审核通过才能上线
+func (c *KYCConfig) IsEnforced() bool {
+	return c != nil && c.Enforce == StatusOn
+}
+
+// IsExpirySuspendEnabled 证件过期后是否停用司机
+func (c *KYCConfig) IsExpirySuspendEnabled() bool {
+	return c != nil && c.ExpirySuspend == StatusOn
+}
+
+// IsSupportedDocument 是否为支持上传的证件类型
+func (c *KYCConfig) IsSupportedDocument(docType string) bool {
+	switch docType {
+	case DocumentTypeDriverLicense, DocumentTypeNationalID, DocumentTypeInsurance, DocumentTypeVehicleRegistration:
+		return true
+	}
+	return false
+}
+
+// MaxExpiryWarningDays 最早的到期提醒天数
+func (c *KYCConfig) MaxExpiryWarningDays() int {
+	if c == nil || len(c.ExpiryWarningDays) == 0 {
+		return 0
+	}
+	return c.ExpiryWarningDays[0]
+}
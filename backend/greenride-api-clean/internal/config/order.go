@@ -3,6 +3,7 @@ package config
 const (
 	DefaultOrderExpireMinutes     = 60
 	DefaultRideOrderExpireMinutes = 60
+	DefaultRideOrderMaxStops      = 3
 )
 
 type OrderConfig struct {
@@ -12,6 +13,7 @@ type OrderConfig struct {
 
 type RideOrderConfig struct {
 	ExpireMinutes int `mapstructure:"expire_minutes"`
+	MaxStops      int `mapstructure:"max_stops"` // 上下车点之间最多途经点数量
 }
 
 func (c *OrderConfig) Validate() error {
@@ -30,5 +32,8 @@ func (c *RideOrderConfig) Validate() error {
 	if c.ExpireMinutes <= 0 {
 		c.ExpireMinutes = DefaultRideOrderExpireMinutes
 	}
+	if c.MaxStops <= 0 {
+		c.MaxStops = DefaultRideOrderMaxStops
+	}
 	return nil
 }
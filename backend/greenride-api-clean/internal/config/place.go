@@ -0,0 +1,36 @@
+package config
+
+// SavedPlaceConfig 常用地点和最近目的地配置
+type SavedPlaceConfig struct {
+	MaxCustomPlaces   int     `mapstructure:"max_custom_places" yaml:"max_custom_places" json:"max_custom_places"`       // 每个用户最多保存的自定义地点数，默认20
+	RecentLimit       int     `mapstructure:"recent_limit" yaml:"recent_limit" json:"recent_limit"`                      // 最近目的地最多返回条数，默认10
+	RecentScanOrders  int     `mapstructure:"recent_scan_orders" yaml:"recent_scan_orders" json:"recent_scan_orders"`    // 统计最近目的地时扫描的已完成订单数，默认50
+	MergeRadiusMeters float64 `mapstructure:"merge_radius_meters" yaml:"merge_radius_meters" json:"merge_radius_meters"` // 距离在此范围内的目的地视为同一地点，默认100米
+}
+
+// Validate 验证并设置常用地点配置默认值
+func (c *SavedPlaceConfig) Validate() {
+	if c.MaxCustomPlaces <= 0 {
+		c.MaxCustomPlaces = 20
+	}
+	if c.RecentLimit <= 0 {
+		c.RecentLimit = 10
+	}
+	if c.RecentScanOrders < c.RecentLimit {
+		c.RecentScanOrders = 50
+	}
+	if c.MergeRadiusMeters <= 0 {
+		c.MergeRadiusMeters = 100
+	}
+}
+
+// GetSavedPlaceConfig 获取常用地点配置（带默认值）
+func GetSavedPlaceConfig() *SavedPlaceConfig {
+	cfg := Get()
+	if cfg == nil || cfg.SavedPlace == nil {
+		result := &SavedPlaceConfig{}
+		result.Validate()
+		return result
+	}
+	return cfg.SavedPlace
+}
@@ -0,0 +1,49 @@
+package config
+
+// PoolConfig 拼车配置
+type PoolConfig struct {
+	Enabled             string  `mapstructure:"enabled" yaml:"enabled" json:"enabled"`                                              // on/off，默认on
+	SeatCapacity        int     `mapstructure:"seat_capacity" yaml:"seat_capacity" json:"seat_capacity"`                            // 拼车车辆可供乘客使用的座位数，默认3
+	MaxDetourRatio      float64 `mapstructure:"max_detour_ratio" yaml:"max_detour_ratio" json:"max_detour_ratio"`                   // 每位乘客实际行程距离不得超过直达距离的倍数，默认1.5
+	MaxDetourKm         float64 `mapstructure:"max_detour_km" yaml:"max_detour_km" json:"max_detour_km"`                            // 新乘客加入后路线总里程比原路线加新乘客直达距离最多多出的公里数，默认5
+	MaxBearingDiff      float64 `mapstructure:"max_bearing_diff" yaml:"max_bearing_diff" json:"max_bearing_diff"`                   // 同向判定：与拼车中乘客行程方向最大夹角（度），默认45
+	MaxPickupDistanceKm float64 `mapstructure:"max_pickup_distance_km" yaml:"max_pickup_distance_km" json:"max_pickup_distance_km"` // 新乘客上车点距拼车当前路线最远距离（公里），默认3
+}
+
+// Validate 验证并设置拼车配置默认值
+func (c *PoolConfig) Validate() {
+	if c.Enabled == "" {
+		c.Enabled = StatusOn
+	}
+	if c.SeatCapacity <= 0 {
+		c.SeatCapacity = 3
+	}
+	if c.MaxDetourRatio < 1 {
+		c.MaxDetourRatio = 1.5
+	}
+	if c.MaxDetourKm <= 0 {
+		c.MaxDetourKm = 5
+	}
+	if c.MaxBearingDiff <= 0 || c.MaxBearingDiff > 180 {
+		c.MaxBearingDiff = 45
+	}
+	if c.MaxPickupDistanceKm <= 0 {
+		c.MaxPickupDistanceKm = 3
+	}
+}
+
+// IsEnabled 是否开启拼车
+func (c *PoolConfig) IsEnabled() bool {
+	return c != nil && c.Enabled == StatusOn
+}
+
+// GetPoolConfig 获取拼车配置（带默认值）
+func GetPoolConfig() *PoolConfig {
+	cfg := Get()
+	if cfg == nil || cfg.Pool == nil {
+		result := &PoolConfig{}
+		result.Validate()
+		return result
+	}
+	return cfg.Pool
+}
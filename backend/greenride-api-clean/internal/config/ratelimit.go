@@ -0,0 +1,81 @@
+package config
+
+// 限流策略名称
+const (
+	RateLimitPolicyOTP       = "otp"       // 发送验证码
+	RateLimitPolicyLogin     = "login"     // 登录
+	RateLimitPolicyFeedback  = "feedback"  // 提交反馈
+	RateLimitPolicyWebhook   = "webhook"   // 第三方回调
+	RateLimitPolicyUser      = "user"      // 已认证用户（按用户）
+	RateLimitPolicyAnonymous = "anonymous" // 匿名访问（按IP）
+)
+
+// 限流维度
+const (
+	RateLimitByIP   = "ip"
+	RateLimitByUser = "user"
+)
+
+// RateLimitConfig 限流配置
+type RateLimitConfig struct {
+	Enabled  string                      `mapstructure:"enabled" yaml:"enabled" json:"enabled"`    // on/off，默认on
+	Policies map[string]*RateLimitPolicy `mapstructure:"policies" yaml:"policies" json:"policies"` // 按策略名覆盖默认值
+}
+
+// RateLimitPolicy 单个限流策略（滑动窗口）
+type RateLimitPolicy struct {
+	Limit  int    `mapstructure:"limit" yaml:"limit" json:"limit"`    // 窗口内最大请求数
+	Window int    `mapstructure:"window" yaml:"window" json:"window"` // 窗口长度(秒)
+	By     string `mapstructure:"by" yaml:"by" json:"by"`             // ip/user
+}
+
+// defaultRateLimitPolicies 默认限流策略
+func defaultRateLimitPolicies() map[string]*RateLimitPolicy {
+	return map[string]*RateLimitPolicy{
+		RateLimitPolicyOTP:       {Limit: 5, Window: 60, By: RateLimitByIP},
+		RateLimitPolicyLogin:     {Limit: 10, Window: 60, By: RateLimitByIP},
+		RateLimitPolicyFeedback:  {Limit: 3, Window: 60, By: RateLimitByIP},
+		RateLimitPolicyWebhook:   {Limit: 3000, Window: 60, By: RateLimitByIP}, // 支付回调来自少量渠道IP，高峰期不能被拦截
+		RateLimitPolicyUser:      {Limit: 120, Window: 60, By: RateLimitByUser},
+		RateLimitPolicyAnonymous: {Limit: 60, Window: 60, By: RateLimitByIP},
+	}
+}
+
+// Validate 验证并设置限流配置默认值
+func (c *RateLimitConfig) Validate() {
+	if c.Enabled == "" {
+		c.Enabled = StatusOn
+	}
+	if c.Policies == nil {
+		c.Policies = make(map[string]*RateLimitPolicy)
+	}
+	for name, def := range defaultRateLimitPolicies() {
+		policy, ok := c.Policies[name]
+		if !ok || policy == nil {
+			c.Policies[name] = def
+			continue
+		}
+		if policy.Limit <= 0 {
+			policy.Limit = def.Limit
+		}
+		if policy.Window <= 0 {
+			policy.Window = def.Window
+		}
+		if policy.By != RateLimitByIP && policy.By != RateLimitByUser {
+			policy.By = def.By
+		}
+	}
+}
+
+// IsEnabled 是否开启限流
+func (c *RateLimitConfig) IsEnabled() bool {
+	return c.Enabled == StatusOn
+}
+
+// GetPolicy 获取限流策略，不存在时返回nil
+func (c *RateLimitConfig) GetPolicy(name string) *RateLimitPolicy {
+	if c == nil || c.Policies == nil {
+		return nil
+	}
+	return c.Policies[name]
+}
@@ -0,0 +1,78 @@
+package config
+
+// ReferralConfig 邀请奖励配置
+type ReferralConfig struct {
+	Enabled                   string  `mapstructure:"enabled" yaml:"enabled" json:"enabled"`                                                                // on/off，默认on
+	RequiredRides             int     `mapstructure:"required_rides" yaml:"required_rides" json:"required_rides"`                                           // 被邀请乘客完成N单后发放奖励，默认1（首单）
+	MaxRewardsPerInviter      int     `mapstructure:"max_rewards_per_inviter" yaml:"max_rewards_per_inviter" json:"max_rewards_per_inviter"`                // 每个邀请人最多获得奖励次数，0表示不限
+	InviteeCouponCode         string  `mapstructure:"invitee_coupon_code" yaml:"invitee_coupon_code" json:"invitee_coupon_code"`                            // 被邀请人奖励券码，为空则不发放
+	InviteeCouponValidDays    int     `mapstructure:"invitee_coupon_valid_days" yaml:"invitee_coupon_valid_days" json:"invitee_coupon_valid_days"`          // 被邀请人奖励券有效天数
+	DriverBonusAmount         float64 `mapstructure:"driver_bonus_amount" yaml:"driver_bonus_amount" json:"driver_bonus_amount"`                            // 司机推荐司机奖励金额，入账钱包，0表示不发放
+	DriverBonusCurrency       string  `mapstructure:"driver_bonus_currency" yaml:"driver_bonus_currency" json:"driver_bonus_currency"`                      // 司机奖励币种，默认RWF
+	DriverRequiredRides       int     `mapstructure:"driver_required_rides" yaml:"driver_required_rides" json:"driver_required_rides"`                      // 被邀请司机完成N单后发放奖励，默认10
+	PhonePrefixLength         int     `mapstructure:"phone_prefix_length" yaml:"phone_prefix_length" json:"phone_prefix_length"`                            // 手机号前缀比对长度（不含国家码），0表示不检查
+	FraudAction               string  `mapstructure:"fraud_action" yaml:"fraud_action" json:"fraud_action"`                                                 // 命中风控后的处理：review（待人工审核）/reject（直接拒绝），默认review
+	MaxTreeDepth              int     `mapstructure:"max_tree_depth" yaml:"max_tree_depth" json:"max_tree_depth"`                                           // 管理后台邀请树最大展开层数，默认3
+	RewardExpireDays          int     `mapstructure:"reward_expire_days" yaml:"reward_expire_days" json:"reward_expire_days"`                               // 注册后超过N天未达成条件则不再奖励，0表示不限
+	CheckSharedDevice         string  `mapstructure:"check_shared_device" yaml:"check_shared_device" json:"check_shared_device"`                            // on/off，检查设备ID及FCM设备ID是否相同
+	CheckSharedPaymentAccount string  `mapstructure:"check_shared_payment_account" yaml:"check_shared_payment_account" json:"check_shared_payment_account"` // on/off，检查支付账号是否相同
+}
+
+// 命中风控后的处理方式
+const (
+	ReferralFraudActionReview = "review"
+	ReferralFraudActionReject = "reject"
+)
+
+// Validate 验证并设置邀请奖励配置默认值
+func (c *ReferralConfig) Validate() {
+	if c.Enabled == "" {
+		c.Enabled = StatusOn
+	}
+	if c.RequiredRides <= 0 {
+		c.RequiredRides = 1
+	}
+	if c.MaxRewardsPerInviter < 0 {
+		c.MaxRewardsPerInviter = 0
+	}
+	if c.InviteeCouponValidDays <= 0 {
+		c.InviteeCouponValidDays = 30
+	}
+	if c.DriverBonusCurrency == "" {
+		c.DriverBonusCurrency = "RWF"
+	}
+	if c.DriverRequiredRides <= 0 {
+		c.DriverRequiredRides = 10
+	}
+	if c.PhonePrefixLength < 0 {
+		c.PhonePrefixLength = 0
+	}
+	if c.FraudAction != ReferralFraudActionReject {
+		c.FraudAction = ReferralFraudActionReview
+	}
+	if c.MaxTreeDepth <= 0 {
+		c.MaxTreeDepth = 3
+	}
+	if c.CheckSharedDevice == "" {
+		c.CheckSharedDevice = StatusOn
+	}
+	if c.CheckSharedPaymentAccount == "" {
+		c.CheckSharedPaymentAccount = StatusOn
+	}
+}
+
+// IsEnabled 是否开启邀请奖励
+func (c *ReferralConfig) IsEnabled() bool {
+	return c != nil && c.Enabled == StatusOn
+}
+
+// GetReferralConfig 获取邀请奖励配置（带默认值）
+func GetReferralConfig() *ReferralConfig {
+	cfg := Get()
+	if cfg == nil || cfg.Referral == nil {
+		result := &ReferralConfig{}
+		result.Validate()
+		return result
+	}
+	return cfg.Referral
+}
@@ -0,0 +1,36 @@
+package config
+
+import "strings"
+
+// SafetyConfig 行程安全配置：紧急求助通知的响应人员及行程分享链接
+type SafetyConfig struct {
+	ResponderPhones    []string `mapstructure:"responder_phones" yaml:"responder_phones" json:"responder_phones"`             // 收到紧急求助时短信通知的响应人员号码
+	ShareBaseURL       string   `mapstructure:"share_base_url" yaml:"share_base_url" json:"share_base_url"`                   // 行程分享页地址，链接为 {share_base_url}/{token}
+	ShareTTLMinutes    int      `mapstructure:"share_ttl_minutes" yaml:"share_ttl_minutes" json:"share_ttl_minutes"`          // 分享链接有效期（分钟），默认240
+	MaxTrustedContacts int      `mapstructure:"max_trusted_contacts" yaml:"max_trusted_contacts" json:"max_trusted_contacts"` // 每个用户最多保存的紧急联系人数，默认5
+}
+
+// Validate 验证并设置安全配置默认值
+func (c *SafetyConfig) Validate() {
+	if c.ShareBaseURL == "" {
+		c.ShareBaseURL = "https://greenride.rw/trip"
+	}
+	c.ShareBaseURL = strings.TrimRight(c.ShareBaseURL, "/")
+	if c.ShareTTLMinutes <= 0 {
+		c.ShareTTLMinutes = 240
+	}
+	if c.MaxTrustedContacts <= 0 {
+		c.MaxTrustedContacts = 5
+	}
+}
+
+// GetSafetyConfig 获取安全配置（带默认值）
+func GetSafetyConfig() *SafetyConfig {
+	cfg := Get()
+	if cfg == nil || cfg.Safety == nil {
+		result := &SafetyConfig{}
+		result.Validate()
+		return result
+	}
+	return cfg.Safety
+}
@@ -0,0 +1,42 @@
+package config
+
+// SavedPaymentMethodConfig 已保存支付方式配置
+type SavedPaymentMethodConfig struct {
+	AutoCharge       string  `mapstructure:"auto_charge" yaml:"auto_charge" json:"auto_charge"`                      // 行程结束后是否自动使用默认支付方式扣款: on/off，默认on
+	MaxMethods       int     `mapstructure:"max_methods" yaml:"max_methods" json:"max_methods"`                      // 每个用户最多保存的支付方式数，默认5
+	MomoVerifyAmount float64 `mapstructure:"momo_verify_amount" yaml:"momo_verify_amount" json:"momo_verify_amount"` // MoMo小额验证扣款金额，验证成功后退回钱包，默认100
+	VerifyCurrency   string  `mapstructure:"verify_currency" yaml:"verify_currency" json:"verify_currency"`          // MoMo小额验证币种，默认RWF
+	StripeAccountID  string  `mapstructure:"stripe_account_id" yaml:"stripe_account_id" json:"stripe_account_id"`    // 保存银行卡使用的Stripe渠道账户，为空时使用第一个Stripe渠道
+}
+
+// Validate 验证并设置已保存支付方式配置默认值
+func (c *SavedPaymentMethodConfig) Validate() {
+	if c.AutoCharge == "" {
+		c.AutoCharge = "on"
+	}
+	if c.MaxMethods <= 0 {
+		c.MaxMethods = 5
+	}
+	if c.MomoVerifyAmount <= 0 {
+		c.MomoVerifyAmount = 100
+	}
+	if c.VerifyCurrency == "" {
+		c.VerifyCurrency = "RWF"
+	}
+}
+
+// IsAutoChargeEnabled 是否在行程结束后自动扣款
+func (c *SavedPaymentMethodConfig) IsAutoChargeEnabled() bool {
+	return c.AutoCharge == "on"
+}
+
+// GetSavedPaymentMethodConfig 获取已保存支付方式配置（带默认值）
+func GetSavedPaymentMethodConfig() *SavedPaymentMethodConfig {
+	cfg := Get()
+	if cfg == nil || cfg.SavedPaymentMethod == nil {
+		result := &SavedPaymentMethodConfig{}
+		result.Validate()
+		return result
+	}
+	return cfg.SavedPaymentMethod
+}
@@ -1,10 +1,24 @@
 package config
 
+const (
+	DefaultSMSReceiptTimeout = 90 // 默认等待送达回执的时间(秒)
+	SMSRouteDefaultCountry   = "*"
+)
+
 // SMS配置结构
 type SMSConfig struct {
-	ServiceName    string `mapstructure:"service_name"`    // 使用的短信服务提供商名称
-	DefaultAccount string `mapstructure:"default_account"` // 默认账号ID
-	DefaultNumber  string `mapstructure:"default_number"`  // 默认发送号码
+	ServiceName    string           `mapstructure:"service_name"`    // 使用的短信服务提供商名称
+	DefaultAccount string           `mapstructure:"default_account"` // 默认账号ID
+	DefaultNumber  string           `mapstructure:"default_number"`  // 默认发送号码
+	Routes         []SMSRouteConfig `mapstructure:"routes"`          // 按国家区号的服务商路由
+	ReceiptTimeout int              `mapstructure:"receipt_timeout"` // 未收到送达回执时切换服务商的超时(秒)
+	ReceiptTracked []string         `mapstructure:"receipt_tracked"` // 会回调送达回执的服务商
+}
+
+// SMSRouteConfig 单条路由：国家区号（不含+，*表示默认）及按优先级排列的服务商
+type SMSRouteConfig struct {
+	Country   string   `mapstructure:"country"`
+	Providers []string `mapstructure:"providers"`
 }
 
 // Validate 验证并设置SMS配置默认值
@@ -12,4 +26,42 @@ func (s *SMSConfig) Validate() {
 	if s.ServiceName == "" {
 		s.ServiceName = "twilio" // 默认使用Twilio服务
 	}
+	if s.ReceiptTimeout <= 0 {
+		s.ReceiptTimeout = DefaultSMSReceiptTimeout
+	}
+	if s.ReceiptTracked == nil {
+		s.ReceiptTracked = []string{"innopaas"}
+	}
+	if len(s.Routes) == 0 {
+		// 默认路由：配置的主服务商优先，另一家作为备用
+		providers := []string{"twilio", "innopaas"}
+		if s.ServiceName == "innopaas" {
+			providers = []string{"innopaas", "twilio"}
+		}
+		s.Routes = []SMSRouteConfig{{Country: SMSRouteDefaultCountry, Providers: providers}}
+	}
+}
+
+// GetRouteProviders 获取国家区号对应的服务商优先级列表，未配置时使用默认路由
+func (s *SMSConfig) GetRouteProviders(callingCode string) []string {
+	var fallback []string
+	for _, route := range s.Routes {
+		if route.Country == callingCode {
+			return route.Providers
+		}
+		if route.Country == SMSRouteDefaultCountry {
+			fallback = route.Providers
+		}
+	}
+	return fallback
+}
+
+// IsReceiptTracked 服务商是否会回调送达回执
+func (s *SMSConfig) IsReceiptTracked(provider string) bool {
+	for _, name := range s.ReceiptTracked {
+		if name == provider {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,45 @@
+package config
+
+// TipConfig 行程小费配置
+type TipConfig struct {
+	Enabled       string    `mapstructure:"enabled" yaml:"enabled" json:"enabled"`                      // on/off，默认on
+	WindowMinutes int       `mapstructure:"window_minutes" yaml:"window_minutes" json:"window_minutes"` // 行程结束后可给小费的时长（分钟），默认1440
+	MinAmount     float64   `mapstructure:"min_amount" yaml:"min_amount" json:"min_amount"`             // 单笔小费下限，默认100
+	MaxAmount     float64   `mapstructure:"max_amount" yaml:"max_amount" json:"max_amount"`             // 单笔小费上限，默认20000
+	PresetAmounts []float64 `mapstructure:"preset_amounts" yaml:"preset_amounts" json:"preset_amounts"` // 评价页展示的快捷金额，默认200/500/1000
+}
+
+// Validate 验证并设置小费配置默认值
+func (c *TipConfig) Validate() {
+	if c.Enabled == "" {
+		c.Enabled = StatusOn
+	}
+	if c.WindowMinutes <= 0 {
+		c.WindowMinutes = 1440
+	}
+	if c.MinAmount <= 0 {
+		c.MinAmount = 100
+	}
+	if c.MaxAmount < c.MinAmount {
+		c.MaxAmount = 20000
+	}
+	if len(c.PresetAmounts) == 0 {
+		c.PresetAmounts = []float64{200, 500, 1000}
+	}
+}
+
+// IsEnabled 是否开启小费
+func (c *TipConfig) IsEnabled() bool {
+	return c != nil && c.Enabled == StatusOn
+}
+
+// GetTipConfig 获取小费配置（带默认值）
+func GetTipConfig() *TipConfig {
+	cfg := Get()
+	if cfg == nil || cfg.Tip == nil {
+		result := &TipConfig{}
+		result.Validate()
+		return result
+	}
+	return cfg.Tip
+}
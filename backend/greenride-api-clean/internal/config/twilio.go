@@ -1,7 +1,8 @@
 package config
 
 type TwilioConfig struct {
-	Accounts []TwilioAccountConfig `mapstructure:"accounts"`
+	Accounts       []TwilioAccountConfig `mapstructure:"accounts"`
+	StatusCallback string                `mapstructure:"status_callback"` // 短信送达回执回调地址（可选）
 }
 
 // TwilioAccountConfig 代表单个Twilio账号的配置
@@ -6,6 +6,17 @@ const (
 	DefaultVerifyCodeExpiration = 5  // 默认验证码有效期(分钟)
 	DefaultVerifyCodeInterval   = 60 // 默认发送间隔(秒)
 	DefaultVerifyCodeMaxTimes   = 10 // 默认每天最大发送次数
+
+	// 短信轰炸/短信刷量防护默认配置
+	DefaultOTPPrefixLength        = 8    // 号段长度（不含+）
+	DefaultOTPPrefixDailyBudget   = 30   // 每个号段每天最大发送次数
+	DefaultOTPCountryDailyBudget  = 200  // 未单独配置的国家每天最大发送次数
+	DefaultOTPIPHourlyLimit       = 10   // 每个IP每小时最大发送次数
+	DefaultOTPDeviceHourlyLimit   = 5    // 每个设备每小时最大发送次数
+	DefaultOTPChallengeAfterSends = 3    // 同一号码/IP 24小时内发送N次后需要工作量证明
+	DefaultOTPChallengeDifficulty = 18   // 工作量证明难度（前导零比特数）
+	DefaultOTPChallengeTTL        = 300  // 挑战有效期(秒)
+	DefaultOTPRwandaDailyBudget   = 5000 // 卢旺达每天最大发送次数
 )
 
 // VerifyCodeConfig 验证码配置
@@ -16,6 +27,23 @@ type VerifyCodeConfig struct {
 	MaxSendTimes  int  `mapstructure:"max_send_times" yaml:"max_send_times" json:"max_send_times"` // 每天最大发送次数
 	LocalTemplate bool `mapstructure:"local_template" yaml:"local_template" json:"local_template"` // 是否使用本地模板
 	BypassOTP     bool `mapstructure:"bypass_otp" yaml:"bypass_otp" json:"bypass_otp"`             // 是否绕过OTP验证
+
+	Fraud *OTPFraudConfig `mapstructure:"fraud" yaml:"fraud" json:"fraud"` // 短信刷量防护配置
+}
+
+// OTPFraudConfig 短信验证码防刷配置
+type OTPFraudConfig struct {
+	BlockedPrefixes     []string           `mapstructure:"blocked_prefixes" yaml:"blocked_prefixes" json:"blocked_prefixes"`                // 禁止发送的号段（高资费/卫星号段等），如 +882
+	PrefixLength        int                `mapstructure:"prefix_length" yaml:"prefix_length" json:"prefix_length"`                         // 号段预算统计的前缀长度
+	PrefixDailyBudget   int                `mapstructure:"prefix_daily_budget" yaml:"prefix_daily_budget" json:"prefix_daily_budget"`       // 每个号段每天最大发送次数
+	CountryDailyBudgets map[string]int     `mapstructure:"country_daily_budgets" yaml:"country_daily_budgets" json:"country_daily_budgets"` // 按国家区号（不含+）的每天最大发送次数
+	CountryDailyBudget  int                `mapstructure:"country_daily_budget" yaml:"country_daily_budget" json:"country_daily_budget"`    // 未单独配置国家的每天最大发送次数
+	IPHourlyLimit       int                `mapstructure:"ip_hourly_limit" yaml:"ip_hourly_limit" json:"ip_hourly_limit"`                   // 每个IP每小时最大发送次数
+	DeviceHourlyLimit   int                `mapstructure:"device_hourly_limit" yaml:"device_hourly_limit" json:"device_hourly_limit"`       // 每个设备每小时最大发送次数
+	ChallengeAfterSends int                `mapstructure:"challenge_after_sends" yaml:"challenge_after_sends" json:"challenge_after_sends"` // 发送N次后需要工作量证明
+	ChallengeDifficulty int                `mapstructure:"challenge_difficulty" yaml:"challenge_difficulty" json:"challenge_difficulty"`    // 工作量证明难度
+	ChallengeTTL        int                `mapstructure:"challenge_ttl" yaml:"challenge_ttl" json:"challenge_ttl"`                         // 挑战有效期(秒)
+	ProviderCosts       map[string]float64 `mapstructure:"provider_costs" yaml:"provider_costs" json:"provider_costs"`                      // 各短信服务商单条成本(USD)
 }
 
 // Validate 验证验证码配置
@@ -32,4 +60,62 @@ func (c *VerifyCodeConfig) Validate() {
 	if c.MaxSendTimes <= 0 {
 		c.MaxSendTimes = DefaultVerifyCodeMaxTimes
 	}
+	if c.Fraud == nil {
+		c.Fraud = &OTPFraudConfig{}
+	}
+	c.Fraud.Validate()
+}
+
+// Validate 验证并设置防刷配置默认值
+func (c *OTPFraudConfig) Validate() {
+	if c.BlockedPrefixes == nil {
+		// 国际网络/卫星等高资费号段
+		c.BlockedPrefixes = []string{"+870", "+881", "+882", "+883", "+979", "+808"}
+	}
+	if c.PrefixLength <= 0 {
+		c.PrefixLength = DefaultOTPPrefixLength
+	}
+	if c.PrefixDailyBudget <= 0 {
+		c.PrefixDailyBudget = DefaultOTPPrefixDailyBudget
+	}
+	if c.CountryDailyBudgets == nil {
+		c.CountryDailyBudgets = map[string]int{"250": DefaultOTPRwandaDailyBudget}
+	}
+	if c.CountryDailyBudget <= 0 {
+		c.CountryDailyBudget = DefaultOTPCountryDailyBudget
+	}
+	if c.IPHourlyLimit <= 0 {
+		c.IPHourlyLimit = DefaultOTPIPHourlyLimit
+	}
+	if c.DeviceHourlyLimit <= 0 {
+		c.DeviceHourlyLimit = DefaultOTPDeviceHourlyLimit
+	}
+	if c.ChallengeAfterSends <= 0 {
+		c.ChallengeAfterSends = DefaultOTPChallengeAfterSends
+	}
+	if c.ChallengeDifficulty <= 0 {
+		c.ChallengeDifficulty = DefaultOTPChallengeDifficulty
+	}
+	if c.ChallengeTTL <= 0 {
+		c.ChallengeTTL = DefaultOTPChallengeTTL
+	}
+	if c.ProviderCosts == nil {
+		c.ProviderCosts = map[string]float64{"twilio": 0.05, "innopaas": 0.02}
+	}
+}
+
+// GetProviderCost 获取短信服务商单条成本
+func (c *OTPFraudConfig) GetProviderCost(provider string) float64 {
+	if c == nil || c.ProviderCosts == nil {
+		return 0
+	}
+	return c.ProviderCosts[provider]
+}
+
+// GetCountryDailyBudget 获取国家区号每日发送预算
+func (c *OTPFraudConfig) GetCountryDailyBudget(callingCode string) int {
+	if budget, ok := c.CountryDailyBudgets[callingCode]; ok && budget > 0 {
+		return budget
+	}
+	return c.CountryDailyBudget
 }
@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"net/http"
+
+	"greenride/internal/middleware"
+	"greenride/internal/protocol"
+	"greenride/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateLocalAdvertisement 创建本地广告
+// @Summary 创建本地广告
+// @Description 未指定状态时为待上线；rehost_images=true 时将图片和Logo转存到S3
+// @Tags Admin,管理员-广告
+// @Accept json
+// @Produce json
+// @Param request body protocol.CreateLocalAdvertisementRequest true "广告内容"
+// @Success 200 {object} protocol.Result{data=protocol.AdminLocalAdvertisement}
+// @Security BearerAuth
+// @Router /ads/create [post]
+func (t *Admin) CreateLocalAdvertisement(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	admin := t.GetUserFromContext(c)
+	if admin == nil {
+		c.JSON(http.StatusUnauthorized, protocol.NewAuthErrorResult())
+		return
+	}
+	var req protocol.CreateLocalAdvertisementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	req.UserID = admin.AdminID
+
+	ad, errCode := services.GetLocalAdvertisementService().CreateLocalAdvertisement(c.Request.Context(), &req)
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(ad))
+}
+
+// UpdateLocalAdvertisement 修改本地广告
+// @Summary 修改本地广告
+// @Description 整体替换可编辑字段，展示/点击统计和Google信息保持不变
+// @Tags Admin,管理员-广告
+// @Accept json
+// @Produce json
+// @Param request body protocol.UpdateLocalAdvertisementRequest true "广告内容"
+// @Success 200 {object} protocol.Result{data=protocol.AdminLocalAdvertisement}
+// @Security BearerAuth
+// @Router /ads/update [post]
+func (t *Admin) UpdateLocalAdvertisement(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	admin := t.GetUserFromContext(c)
+	if admin == nil {
+		c.JSON(http.StatusUnauthorized, protocol.NewAuthErrorResult())
+		return
+	}
+	var req protocol.UpdateLocalAdvertisementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	req.UserID = admin.AdminID
+
+	ad, errCode := services.GetLocalAdvertisementService().UpdateLocalAdvertisement(c.Request.Context(), &req)
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(ad))
+}
+
+// SearchLocalAdvertisements 搜索本地广告
+// @Summary 搜索本地广告
+// @Description 可按关键字、城市、类别、状态筛选，live=true 只看当前正在展示的广告
+// @Tags Admin,管理员-广告
+// @Accept json
+// @Produce json
+// @Param request body protocol.LocalAdvertisementSearchRequest true "查询请求"
+// @Success 200 {object} protocol.Result{data=protocol.PageResult}
+// @Security BearerAuth
+// @Router /ads/search [post]
+func (t *Admin) SearchLocalAdvertisements(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	var req protocol.LocalAdvertisementSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.Limit < 1 || req.Limit > 100 {
+		req.Limit = 10
+	}
+
+	list, total := services.GetLocalAdvertisementService().SearchLocalAdvertisements(&req)
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(protocol.NewPageResult(list, total, &protocol.Pagination{
+		Page: req.Page,
+		Size: req.Limit,
+	})))
+}
+
+// GetLocalAdvertisementDetail 获取本地广告详情
+// @Summary 获取本地广告详情
+// @Tags Admin,管理员-广告
+// @Accept json
+// @Produce json
+// @Param request body protocol.LocalAdvertisementActionRequest true "广告ID"
+// @Success 200 {object} protocol.Result{data=protocol.AdminLocalAdvertisement}
+// @Security BearerAuth
+// @Router /ads/detail [post]
+func (t *Admin) GetLocalAdvertisementDetail(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	var req protocol.LocalAdvertisementActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	ad, errCode := services.GetLocalAdvertisementService().GetAdminLocalAdvertisement(req.AdID)
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(ad))
+}
+
+// SetLocalAdvertisementStatus 上线/下线本地广告
+// @Summary 上线/下线本地广告
+// @Description status 取值 active 或 inactive
+// @Tags Admin,管理员-广告
+// @Accept json
+// @Produce json
+// @Param request body protocol.LocalAdvertisementActionRequest true "状态请求"
+// @Success 200 {object} protocol.Result
+// @Security BearerAuth
+// @Router /ads/status [post]
+func (t *Admin) SetLocalAdvertisementStatus(c *gin.Context) {
+	t.localAdvertisementAction(c, services.GetLocalAdvertisementService().SetLocalAdvertisementStatus)
+}
+
+// DeleteLocalAdvertisement 删除本地广告
+// @Summary 删除本地广告
+// @Description 软删除，历史统计数据保留
+// @Tags Admin,管理员-广告
+// @Accept json
+// @Produce json
+// @Param request body protocol.LocalAdvertisementActionRequest true "广告ID"
+// @Success 200 {object} protocol.Result
+// @Security BearerAuth
+// @Router /ads/delete [post]
+func (t *Admin) DeleteLocalAdvertisement(c *gin.Context) {
+	t.localAdvertisementAction(c, services.GetLocalAdvertisementService().DeleteLocalAdvertisement)
+}
+
+func (t *Admin) localAdvertisementAction(c *gin.Context, action func(*protocol.LocalAdvertisementActionRequest) protocol.ErrorCode) {
+	lang := middleware.GetLanguageFromContext(c)
+	admin := t.GetUserFromContext(c)
+	if admin == nil {
+		c.JSON(http.StatusUnauthorized, protocol.NewAuthErrorResult())
+		return
+	}
+	var req protocol.LocalAdvertisementActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	req.UserID = admin.AdminID
+
+	if errCode := action(&req); errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(nil))
+}
+
+// ImportLocalAdvertisements 从Google Places导入本地广告
+// @Summary 从Google Places导入本地广告
+// @Description 按地点名称逐个搜索并创建广告，已导入过的地点跳过；返回每个名称的导入结果
+// @Tags Admin,管理员-广告
+// @Accept json
+// @Produce json
+// @Param request body protocol.ImportLocalAdvertisementsRequest true "导入请求"
+// @Success 200 {object} protocol.Result{data=protocol.LocalAdvertisementImportResult}
+// @Security BearerAuth
+// @Router /ads/import [post]
+func (t *Admin) ImportLocalAdvertisements(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	admin := t.GetUserFromContext(c)
+	if admin == nil {
+		c.JSON(http.StatusUnauthorized, protocol.NewAuthErrorResult())
+		return
+	}
+	var req protocol.ImportLocalAdvertisementsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	req.UserID = admin.AdminID
+
+	result, errCode := services.GetLocalAdvertisementService().ImportLocalAdvertisements(c.Request.Context(), &req)
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(result))
+}
+
+// GetLocalAdvertisementReports 本地广告展示/点击报表
+// @Summary 本地广告展示/点击报表
+// @Description 每个广告的展示、点击、拨号次数及点击率，attach.summary 为筛选范围内的汇总
+// @Tags Admin,管理员-广告
+// @Accept json
+// @Produce json
+// @Param request body protocol.LocalAdvertisementReportRequest true "查询请求"
+// @Success 200 {object} protocol.Result{data=protocol.PageResult}
+// @Security BearerAuth
+// @Router /ads/report [post]
+func (t *Admin) GetLocalAdvertisementReports(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	var req protocol.LocalAdvertisementReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.Limit < 1 || req.Limit > 100 {
+		req.Limit = 10
+	}
+
+	list, total, summary := services.GetLocalAdvertisementService().GetLocalAdvertisementReports(&req)
+	result := protocol.NewPageResult(list, total, &protocol.Pagination{
+		Page: req.Page,
+		Size: req.Limit,
+	})
+	result.AddAttach("summary", summary)
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(result))
+}
@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"net/http"
+
+	"greenride/internal/middleware"
+	"greenride/internal/protocol"
+	"greenride/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateAnnouncement 创建公告
+// @Summary 创建公告
+// @Description 创建后为草稿，需另一名管理员审批后发布；设置了发布时间的审批后按时间定时发布
+// @Tags Admin,管理员-公告
+// @Accept json
+// @Produce json
+// @Param request body protocol.CreateAnnouncementRequest true "公告内容"
+// @Success 200 {object} protocol.Result{data=protocol.Announcement}
+// @Security BearerAuth
+// @Router /announcements/create [post]
+func (t *Admin) CreateAnnouncement(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	admin := t.GetUserFromContext(c)
+	if admin == nil {
+		c.JSON(http.StatusUnauthorized, protocol.NewAuthErrorResult())
+		return
+	}
+	var req protocol.CreateAnnouncementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	req.UserID = admin.AdminID
+
+	announcement, errCode := services.GetAnnouncementService().CreateAnnouncement(&req)
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(announcement))
+}
+
+// UpdateAnnouncement 修改公告
+// @Summary 修改公告
+// @Description 仅草稿和已暂停的公告可修改，修改后回到草稿并需重新审批
+// @Tags Admin,管理员-公告
+// @Accept json
+// @Produce json
+// @Param request body protocol.UpdateAnnouncementRequest true "公告内容"
+// @Success 200 {object} protocol.Result{data=protocol.Announcement}
+// @Security BearerAuth
+// @Router /announcements/update [post]
+func (t *Admin) UpdateAnnouncement(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	admin := t.GetUserFromContext(c)
+	if admin == nil {
+		c.JSON(http.StatusUnauthorized, protocol.NewAuthErrorResult())
+		return
+	}
+	var req protocol.UpdateAnnouncementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	req.UserID = admin.AdminID
+
+	announcement, errCode := services.GetAnnouncementService().UpdateAnnouncement(&req)
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(announcement))
+}
+
+// SearchAnnouncements 搜索公告
+// @Summary 搜索公告
+// @Description 可按关键字、类型、状态和审批状态筛选，approval_status=pending 即待审批队列
+// @Tags Admin,管理员-公告
+// @Accept json
+// @Produce json
+// @Param request body protocol.AnnouncementSearchRequest true "查询请求"
+// @Success 200 {object} protocol.Result{data=protocol.PageResult}
+// @Security BearerAuth
+// @Router /announcements/search [post]
+func (t *Admin) SearchAnnouncements(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	var req protocol.AnnouncementSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.Limit < 1 || req.Limit > 100 {
+		req.Limit = 10
+	}
+
+	list, total := services.GetAnnouncementService().SearchAnnouncements(&req)
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(protocol.NewPageResult(list, total, &protocol.Pagination{
+		Page: req.Page,
+		Size: req.Limit,
+	})))
+}
+
+// GetAnnouncementDetail 获取公告详情
+// @Summary 获取公告详情
+// @Description 包含多语言内容、阅读/确认人数和推送/邮件分发进度
+// @Tags Admin,管理员-公告
+// @Accept json
+// @Produce json
+// @Param request body protocol.AnnouncementActionRequest true "公告ID"
+// @Success 200 {object} protocol.Result{data=protocol.Announcement}
+// @Security BearerAuth
+// @Router /announcements/detail [post]
+func (t *Admin) GetAnnouncementDetail(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	var req protocol.AnnouncementActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	announcement, errCode := services.GetAnnouncementService().GetAnnouncement(req.AnnouncementID)
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(announcement))
+}
+
+// ApproveAnnouncement 审批通过公告
+// @Summary 审批通过公告
+// @Description 审批人不能是公告的创建人或最后修改人；审批后立即发布或进入定时发布
+// @Tags Admin,管理员-公告
+// @Accept json
+// @Produce json
+// @Param request body protocol.AnnouncementActionRequest true "审批请求"
+// @Success 200 {object} protocol.Result
+// @Security BearerAuth
+// @Router /announcements/approve [post]
+func (t *Admin) ApproveAnnouncement(c *gin.Context) {
+	t.announcementAction(c, services.GetAnnouncementService().ApproveAnnouncement)
+}
+
+// RejectAnnouncement 审批拒绝公告
+// @Summary 审批拒绝公告
+// @Tags Admin,管理员-公告
+// @Accept json
+// @Produce json
+// @Param request body protocol.AnnouncementActionRequest true "审批请求"
+// @Success 200 {object} protocol.Result
+// @Security BearerAuth
+// @Router /announcements/reject [post]
+func (t *Admin) RejectAnnouncement(c *gin.Context) {
+	t.announcementAction(c, services.GetAnnouncementService().RejectAnnouncement)
+}
+
+// PauseAnnouncement 暂停公告
+// @Summary 暂停公告
+// @Description 暂停后用户端不再展示，推送/邮件分发同时停止
+// @Tags Admin,管理员-公告
+// @Accept json
+// @Produce json
+// @Param request body protocol.AnnouncementActionRequest true "公告ID"
+// @Success 200 {object} protocol.Result
+// @Security BearerAuth
+// @Router /announcements/pause [post]
+func (t *Admin) PauseAnnouncement(c *gin.Context) {
+	t.announcementAction(c, services.GetAnnouncementService().PauseAnnouncement)
+}
+
+// DeleteAnnouncement 删除公告
+// @Summary 删除公告
+// @Tags Admin,管理员-公告
+// @Accept json
+// @Produce json
+// @Param request body protocol.AnnouncementActionRequest true "公告ID"
+// @Success 200 {object} protocol.Result
+// @Security BearerAuth
+// @Router /announcements/delete [post]
+func (t *Admin) DeleteAnnouncement(c *gin.Context) {
+	t.announcementAction(c, services.GetAnnouncementService().DeleteAnnouncement)
+}
+
+func (t *Admin) announcementAction(c *gin.Context, action func(*protocol.AnnouncementActionRequest) protocol.ErrorCode) {
+	lang := middleware.GetLanguageFromContext(c)
+	admin := t.GetUserFromContext(c)
+	if admin == nil {
+		c.JSON(http.StatusUnauthorized, protocol.NewAuthErrorResult())
+		return
+	}
+	var req protocol.AnnouncementActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	req.UserID = admin.AdminID
+
+	if errCode := action(&req); errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(nil))
+}
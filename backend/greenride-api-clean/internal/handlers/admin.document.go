@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"net/http"
+
+	"greenride/internal/middleware"
+	"greenride/internal/models"
+	"greenride/internal/protocol"
+	"greenride/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SearchDriverDocuments 司机证件审核队列
+// @Summary 司机证件审核队列
+// @Description 按状态、证件类型、司机查询证件，默认返回待审核证件（按提交时间先后）
+// @Tags Admin,管理员-司机证件
+// @Accept json
+// @Produce json
+// @Param request body protocol.DocumentSearchRequest true "查询请求"
+// @Success 200 {object} protocol.Result{data=protocol.PageResult}
+// @Security BearerAuth
+// @Router /documents/search [post]
+func (t *Admin) SearchDriverDocuments(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	var req protocol.DocumentSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.Limit < 1 || req.Limit > 100 {
+		req.Limit = 10
+	}
+	if req.Status == "" {
+		req.Status = models.IdentityStatusPending
+	}
+
+	list, total, errCode := services.GetDriverDocumentService().SearchDocuments(&req)
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(protocol.NewPageResult(list, total, &protocol.Pagination{
+		Page: req.Page,
+		Size: req.Limit,
+	})))
+}
+
+// GetDriverDocumentDetail 获取证件详情
+// @Summary 获取证件详情
+// @Tags Admin,管理员-司机证件
+// @Accept json
+// @Produce json
+// @Param request body protocol.DocumentDetailRequest true "证件ID"
+// @Success 200 {object} protocol.Result{data=protocol.DriverDocument}
+// @Security BearerAuth
+// @Router /documents/detail [post]
+func (t *Admin) GetDriverDocumentDetail(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	var req protocol.DocumentDetailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	doc, errCode := services.GetDriverDocumentService().GetDocument(req.IdentityID)
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(doc))
+}
+
+// ApproveDriverDocument 审核通过证件
+// @Summary 审核通过证件
+// @Description 审核通过待审核证件，可同时确认证件过期时间
+// @Tags Admin,管理员-司机证件
+// @Accept json
+// @Produce json
+// @Param request body protocol.DocumentApproveRequest true "审核请求"
+// @Success 200 {object} protocol.Result
+// @Security BearerAuth
+// @Router /documents/approve [post]
+func (t *Admin) ApproveDriverDocument(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	admin := t.GetUserFromContext(c)
+	if admin == nil {
+		c.JSON(http.StatusUnauthorized, protocol.NewAuthErrorResult())
+		return
+	}
+	var req protocol.DocumentApproveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	if errCode := services.GetDriverDocumentService().ApproveDocument(&req, admin.AdminID); errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(""))
+}
+
+// RejectDriverDocument 拒绝证件
+// @Summary 拒绝证件
+// @Description 拒绝待审核证件，拒绝原因会展示给司机
+// @Tags Admin,管理员-司机证件
+// @Accept json
+// @Produce json
+// @Param request body protocol.DocumentRejectRequest true "拒绝请求"
+// @Success 200 {object} protocol.Result
+// @Security BearerAuth
+// @Router /documents/reject [post]
+func (t *Admin) RejectDriverDocument(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	admin := t.GetUserFromContext(c)
+	if admin == nil {
+		c.JSON(http.StatusUnauthorized, protocol.NewAuthErrorResult())
+		return
+	}
+	var req protocol.DocumentRejectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	if errCode := services.GetDriverDocumentService().RejectDocument(&req, admin.AdminID); errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(""))
+}
+
+// GetExpiringDocuments 即将到期的证件
+// @Summary 即将到期的证件
+// @Description 查询未来N天内到期（含已过期未处理）的司机证件和车辆保险/行驶证/车检，按到期时间升序
+// @Tags Admin,管理员-司机证件
+// @Accept json
+// @Produce json
+// @Param request body protocol.ExpiringDocumentsRequest true "查询天数，默认30"
+// @Success 200 {object} protocol.Result{data=[]protocol.ExpiringDocument}
+// @Security BearerAuth
+// @Router /documents/expiring [post]
+func (t *Admin) GetExpiringDocuments(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	var req protocol.ExpiringDocumentsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	if req.Days < 0 || req.Days > 365 {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(protocol.InvalidParams, lang))
+		return
+	}
+
+	list, errCode := services.GetDriverDocumentService().ListExpiringDocuments(req.Days)
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(list))
+}
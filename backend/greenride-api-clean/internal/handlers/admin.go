@@ -12,6 +12,7 @@ import (
 	"greenride/internal/services"
 
 	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
@@ -114,9 +115,11 @@ func (t *Admin) registerAuthedRoutes(adminAPI *gin.RouterGroup) {
 		// Dashboard 统计相关
 		dashboardAPI := adminAPI.Group("/dashboard")
 		{
-			dashboardAPI.GET("/stats", t.GetDashboardStats)        // 获取仪表盘统计数据
-			dashboardAPI.GET("/revenue", t.GetRevenueChart)        // 获取收入图表数据
-			dashboardAPI.GET("/user-growth", t.GetUserGrowthChart) // 获取用户增长图表数据
+			dashboardAPI.GET("/stats", t.GetDashboardStats)           // 获取仪表盘统计数据
+			dashboardAPI.GET("/revenue", t.GetRevenueChart)           // 获取收入图表数据
+			dashboardAPI.GET("/user-growth", t.GetUserGrowthChart)    // 获取用户增长图表数据
+			dashboardAPI.GET("/otp-spend", t.GetOTPSpend)             // 获取验证码短信成本统计
+			dashboardAPI.GET("/sms-providers", t.GetSMSProviderStats) // 获取短信服务商投递成功率
 		}
 
 		// 用户管理相关
@@ -138,6 +141,123 @@ func (t *Admin) registerAuthedRoutes(adminAPI *gin.RouterGroup) {
 			driversAPI.GET("/nearby", t.GetNearbyDrivers) // 获取附近司机（带实时位置）
 		}
 
+		// 司机证件审核相关
+		documentAPI := adminAPI.Group("/documents")
+		{
+			documentAPI.POST("/search", t.SearchDriverDocuments)   // 证件审核队列
+			documentAPI.POST("/detail", t.GetDriverDocumentDetail) // 证件详情
+			documentAPI.POST("/approve", t.ApproveDriverDocument)  // 审核通过
+			documentAPI.POST("/reject", t.RejectDriverDocument)    // 审核拒绝
+			documentAPI.POST("/expiring", t.GetExpiringDocuments)  // 即将到期证件
+		}
+
+		// 优惠码管理相关（创建与审批分离）
+		promotionAPI := adminAPI.Group("/promotions")
+		{
+			promotionAPI.POST("/search", t.SearchPromotions)      // 搜索优惠码
+			promotionAPI.POST("/detail", t.GetPromotionDetail)    // 优惠码详情
+			promotionAPI.POST("/create", t.CreatePromotion)       // 创建优惠码（待审批）
+			promotionAPI.POST("/update", t.UpdatePromotion)       // 更新优惠码
+			promotionAPI.POST("/status", t.UpdatePromotionStatus) // 更新优惠码状态
+			promotionAPI.POST("/approve", t.ApprovePromotion)     // 审批通过
+			promotionAPI.POST("/reject", t.RejectPromotion)       // 审批拒绝
+			promotionAPI.POST("/usage", t.GetPromotionUsage)      // 使用统计
+			promotionAPI.POST("/delete", t.DeletePromotion)       // 删除优惠码
+
+			// 批量发券活动
+			promotionAPI.POST("/campaigns/create", t.CreatePromotionCampaign)    // 创建活动（支持CSV名单）
+			promotionAPI.POST("/campaigns/search", t.SearchPromotionCampaigns)   // 活动列表
+			promotionAPI.POST("/campaigns/detail", t.GetPromotionCampaignDetail) // 活动详情与进度
+			promotionAPI.POST("/campaigns/pause", t.PausePromotionCampaign)      // 暂停
+			promotionAPI.POST("/campaigns/resume", t.ResumePromotionCampaign)    // 继续
+			promotionAPI.POST("/campaigns/cancel", t.CancelPromotionCampaign)    // 取消
+
+			// 优惠A/B实验
+			promotionAPI.POST("/experiments/create", t.CreatePromotionExperiment)     // 创建实验（待审批）
+			promotionAPI.POST("/experiments/approve", t.ApprovePromotionExperiment)   // 审批通过，开始运行
+			promotionAPI.POST("/experiments/reject", t.RejectPromotionExperiment)     // 审批拒绝
+			promotionAPI.POST("/experiments/search", t.SearchPromotionExperiments)    // 实验列表
+			promotionAPI.POST("/experiments/detail", t.GetPromotionExperimentDetail)  // 实验详情
+			promotionAPI.POST("/experiments/report", t.GetPromotionExperimentReport)  // 效果报告
+			promotionAPI.POST("/experiments/complete", t.CompletePromotionExperiment) // 结束实验
+		}
+
+		// 邀请奖励管理相关
+		referralAPI := adminAPI.Group("/referrals")
+		{
+			referralAPI.POST("/search", t.SearchReferrals)   // 邀请记录列表（含风控待审核）
+			referralAPI.POST("/detail", t.GetReferralDetail) // 邀请记录详情
+			referralAPI.POST("/tree", t.GetReferralTree)     // 用户邀请树
+			referralAPI.POST("/approve", t.ApproveReferral)  // 审核通过并补发奖励
+			referralAPI.POST("/reject", t.RejectReferral)    // 拒绝奖励
+		}
+
+		// 应收欠款管理相关
+		receivableAPI := adminAPI.Group("/receivables")
+		{
+			receivableAPI.POST("/search", t.SearchReceivables)    // 应收欠款列表（附按币种汇总）
+			receivableAPI.GET("/summary", t.GetReceivableSummary) // 应收汇总
+			receivableAPI.POST("/waive", t.WaiveDebt)             // 减免欠款
+		}
+
+		// 安全事件管理相关
+		safetyAPI := adminAPI.Group("/safety-incidents")
+		{
+			safetyAPI.POST("/search", t.SearchSafetyIncidents)          // 紧急求助记录列表
+			safetyAPI.POST("/detail", t.GetSafetyIncidentDetail)        // 事件详情（含订单快照）
+			safetyAPI.POST("/acknowledge", t.AcknowledgeSafetyIncident) // 响应事件
+			safetyAPI.POST("/resolve", t.ResolveSafetyIncident)         // 关闭事件
+		}
+
+		announcementAPI := adminAPI.Group("/announcements")
+		{
+			announcementAPI.POST("/create", t.CreateAnnouncement)    // 创建公告（草稿）
+			announcementAPI.POST("/update", t.UpdateAnnouncement)    // 修改公告，需重新审批
+			announcementAPI.POST("/search", t.SearchAnnouncements)   // 公告列表（含待审批）
+			announcementAPI.POST("/detail", t.GetAnnouncementDetail) // 公告详情及分发进度
+			announcementAPI.POST("/approve", t.ApproveAnnouncement)  // 审批通过并发布/定时发布
+			announcementAPI.POST("/reject", t.RejectAnnouncement)    // 审批拒绝
+			announcementAPI.POST("/pause", t.PauseAnnouncement)      // 暂停
+			announcementAPI.POST("/delete", t.DeleteAnnouncement)    // 删除
+		}
+
+		// 本地广告管理
+		adsAPI := adminAPI.Group("/ads")
+		{
+			adsAPI.POST("/create", t.CreateLocalAdvertisement)     // 创建广告
+			adsAPI.POST("/update", t.UpdateLocalAdvertisement)     // 修改广告
+			adsAPI.POST("/search", t.SearchLocalAdvertisements)    // 广告列表
+			adsAPI.POST("/detail", t.GetLocalAdvertisementDetail)  // 广告详情
+			adsAPI.POST("/status", t.SetLocalAdvertisementStatus)  // 上线/下线
+			adsAPI.POST("/delete", t.DeleteLocalAdvertisement)     // 删除
+			adsAPI.POST("/import", t.ImportLocalAdvertisements)    // 从Google Places导入
+			adsAPI.POST("/report", t.GetLocalAdvertisementReports) // 展示/点击报表
+		}
+
+		// 消息模板管理
+		messageTemplateAPI := adminAPI.Group("/message-templates")
+		{
+			messageTemplateAPI.POST("/create", t.CreateMessageTemplate)    // 创建模板（第一个草稿）
+			messageTemplateAPI.POST("/draft", t.SaveMessageTemplateDraft)  // 保存草稿
+			messageTemplateAPI.POST("/search", t.SearchMessageTemplates)   // 模板列表
+			messageTemplateAPI.POST("/detail", t.GetMessageTemplateDetail) // 模板详情（含版本）
+			messageTemplateAPI.POST("/publish", t.PublishMessageTemplate)  // 发布草稿/回滚版本
+			messageTemplateAPI.POST("/status", t.SetMessageTemplateStatus) // 启用/停用
+			messageTemplateAPI.POST("/delete", t.DeleteMessageTemplate)    // 删除
+			messageTemplateAPI.POST("/preview", t.PreviewMessageTemplate)  // 示例参数预览
+		}
+
+		// 翻译管理
+		translationAPI := adminAPI.Group("/translations")
+		{
+			translationAPI.POST("/search", t.SearchTranslations)   // 翻译列表
+			translationAPI.POST("/save", t.SaveTranslation)        // 新增/修改
+			translationAPI.POST("/delete", t.DeleteTranslation)    // 删除（回退到文件）
+			translationAPI.POST("/import", t.ImportTranslations)   // 导入
+			translationAPI.POST("/export", t.ExportTranslations)   // 导出
+			translationAPI.POST("/report", t.GetTranslationReport) // 缺失/降级报表
+		}
+
 		// 车辆管理相关
 		vehicleAPI := adminAPI.Group("/vehicles")
 		{
@@ -185,6 +305,7 @@ func (t *Admin) registerAuthedRoutes(adminAPI *gin.RouterGroup) {
 			systemAPI.GET("/config", t.AdminGetSystemConfig)     // 获取系统配置
 			systemAPI.POST("/config", t.AdminUpdateSystemConfig) // 更新系统配置
 			systemAPI.POST("/purge-legacy-deleted", t.AdminPurgeLegacyDeleted)
+			systemAPI.GET("/rate-limit/offenders", t.AdminGetRateLimitOffenders) // 限流拦截排行
 		}
 
 		// 通知管理相关
@@ -327,6 +448,26 @@ func (a *Admin) GetUserGrowthChart(c *gin.Context) {
 	c.JSON(http.StatusOK, protocol.NewSuccessResult(data))
 }
 
+// GetOTPSpend 获取验证码短信成本统计
+// @Summary 获取验证码短信成本统计
+// @Description 按天、按服务商统计验证码短信发送量与成本，以及防刷拦截信号
+// @Tags Admin,Dashboard
+// @Produce json
+// @Security ApiKeyAuth
+// @Param days query int false "统计天数" default(7)
+// @Success 200 {object} protocol.Result{data=[]protocol.OTPSpendDay}
+// @Failure 401 {object} protocol.Result
+// @Router /dashboard/otp-spend [get]
+func (a *Admin) GetOTPSpend(c *gin.Context) {
+	days := cast.ToInt(c.DefaultQuery("days", "7"))
+	data, errCode := services.GetVerifyCodeService().GetOTPSpendStats(days)
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, ""))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(data))
+}
+
 // GetUserFromContext 从上下文获取完整的用户对象
 func (s *Admin) GetUserFromContext(c *gin.Context) *models.Admin {
 	value, exists := c.Get("user")
@@ -338,3 +479,24 @@ func (s *Admin) GetUserFromContext(c *gin.Context) *models.Admin {
 	}
 	return nil
 }
+
+// GetSMSProviderStats 获取短信服务商投递统计
+// @Summary 获取短信服务商投递统计
+// @Description 按服务商统计短信提交成功率与送达率
+// @Tags Admin,Dashboard
+// @Produce json
+// @Security ApiKeyAuth
+// @Param days query int false "统计天数" default(7)
+// @Success 200 {object} protocol.Result{data=[]protocol.SMSProviderStats}
+// @Failure 401 {object} protocol.Result
+// @Router /dashboard/sms-providers [get]
+func (a *Admin) GetSMSProviderStats(c *gin.Context) {
+	days := cast.ToInt(c.DefaultQuery("days", "7"))
+	data, err := services.GetSMSRouter().GetProviderStats(days)
+	if err != nil {
+		log.Printf("GetSMSProviderStats failed: %v", err)
+		c.JSON(http.StatusOK, protocol.NewErrorResult(protocol.DatabaseError, ""))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(data))
+}
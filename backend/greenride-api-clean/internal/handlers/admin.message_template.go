@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"greenride/internal/middleware"
+	"greenride/internal/protocol"
+	"greenride/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateMessageTemplate 创建消息模板
+// @Summary 创建消息模板
+// @Description 按类型、渠道、语言、地区创建模板，内容保存为第一个草稿版本，发布前继续使用系统模板
+// @Tags Admin,管理员-消息模板
+// @Accept json
+// @Produce json
+// @Param request body protocol.CreateMessageTemplateRequest true "模板内容"
+// @Success 200 {object} protocol.Result{data=protocol.AdminMessageTemplate}
+// @Security BearerAuth
+// @Router /message-templates/create [post]
+func (t *Admin) CreateMessageTemplate(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	admin := t.GetUserFromContext(c)
+	if admin == nil {
+		c.JSON(http.StatusUnauthorized, protocol.NewAuthErrorResult())
+		return
+	}
+	var req protocol.CreateMessageTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	req.UserID = admin.AdminID
+
+	tmpl, errCode := services.GetMessageTemplateService().CreateTemplate(&req)
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(tmpl))
+}
+
+// SaveMessageTemplateDraft 保存消息模板草稿
+// @Summary 保存消息模板草稿
+// @Description 已有草稿时覆盖，否则新建版本；草稿不影响正在发送的模板
+// @Tags Admin,管理员-消息模板
+// @Accept json
+// @Produce json
+// @Param request body protocol.SaveMessageTemplateDraftRequest true "草稿内容"
+// @Success 200 {object} protocol.Result{data=protocol.AdminMessageTemplate}
+// @Security BearerAuth
+// @Router /message-templates/draft [post]
+func (t *Admin) SaveMessageTemplateDraft(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	admin := t.GetUserFromContext(c)
+	if admin == nil {
+		c.JSON(http.StatusUnauthorized, protocol.NewAuthErrorResult())
+		return
+	}
+	var req protocol.SaveMessageTemplateDraftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	req.UserID = admin.AdminID
+
+	tmpl, errCode := services.GetMessageTemplateService().SaveDraft(&req)
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(tmpl))
+}
+
+// SearchMessageTemplates 搜索消息模板
+// @Summary 搜索消息模板
+// @Description 可按类型、渠道、语言、状态、关键字筛选
+// @Tags Admin,管理员-消息模板
+// @Accept json
+// @Produce json
+// @Param request body protocol.MessageTemplateSearchRequest true "查询请求"
+// @Success 200 {object} protocol.Result{data=protocol.PageResult}
+// @Security BearerAuth
+// @Router /message-templates/search [post]
+func (t *Admin) SearchMessageTemplates(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	var req protocol.MessageTemplateSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.Limit < 1 || req.Limit > 100 {
+		req.Limit = 10
+	}
+
+	list, total := services.GetMessageTemplateService().SearchTemplates(&req)
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(protocol.NewPageResult(list, total, &protocol.Pagination{
+		Page: req.Page,
+		Size: req.Limit,
+	})))
+}
+
+// GetMessageTemplateDetail 获取消息模板详情
+// @Summary 获取消息模板详情
+// @Description 返回当前发布内容、草稿、历史版本及该消息类型可用的变量
+// @Tags Admin,管理员-消息模板
+// @Accept json
+// @Produce json
+// @Param request body protocol.MessageTemplateActionRequest true "模板ID"
+// @Success 200 {object} protocol.Result{data=protocol.AdminMessageTemplate}
+// @Security BearerAuth
+// @Router /message-templates/detail [post]
+func (t *Admin) GetMessageTemplateDetail(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	var req protocol.MessageTemplateActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	tmpl, errCode := services.GetMessageTemplateService().GetTemplateDetail(req.TemplateID)
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(tmpl))
+}
+
+// PublishMessageTemplate 发布消息模板
+// @Summary 发布消息模板
+// @Description 发布当前草稿，或通过 version_id 重新发布历史版本（回滚）；校验语法和变量，不通过时返回问题列表。所有实例热加载
+// @Tags Admin,管理员-消息模板
+// @Accept json
+// @Produce json
+// @Param request body protocol.MessageTemplateActionRequest true "发布请求"
+// @Success 200 {object} protocol.Result
+// @Security BearerAuth
+// @Router /message-templates/publish [post]
+func (t *Admin) PublishMessageTemplate(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	admin := t.GetUserFromContext(c)
+	if admin == nil {
+		c.JSON(http.StatusUnauthorized, protocol.NewAuthErrorResult())
+		return
+	}
+	var req protocol.MessageTemplateActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	req.UserID = admin.AdminID
+
+	problems, errCode := services.GetMessageTemplateService().PublishTemplate(&req)
+	if errCode == protocol.MessageTemplateInvalid {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang, strings.Join(problems, "; ")))
+		return
+	}
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(nil))
+}
+
+// SetMessageTemplateStatus 启用/停用消息模板
+// @Summary 启用/停用消息模板
+// @Description status 取值 active 或 inactive；停用后该类型消息回退到系统模板
+// @Tags Admin,管理员-消息模板
+// @Accept json
+// @Produce json
+// @Param request body protocol.MessageTemplateActionRequest true "状态请求"
+// @Success 200 {object} protocol.Result
+// @Security BearerAuth
+// @Router /message-templates/status [post]
+func (t *Admin) SetMessageTemplateStatus(c *gin.Context) {
+	t.messageTemplateAction(c, services.GetMessageTemplateService().SetTemplateStatus)
+}
+
+// DeleteMessageTemplate 删除消息模板
+// @Summary 删除消息模板
+// @Description 软删除，版本记录保留；删除后该类型消息回退到系统模板
+// @Tags Admin,管理员-消息模板
+// @Accept json
+// @Produce json
+// @Param request body protocol.MessageTemplateActionRequest true "模板ID"
+// @Success 200 {object} protocol.Result
+// @Security BearerAuth
+// @Router /message-templates/delete [post]
+func (t *Admin) DeleteMessageTemplate(c *gin.Context) {
+	t.messageTemplateAction(c, services.GetMessageTemplateService().DeleteTemplate)
+}
+
+func (t *Admin) messageTemplateAction(c *gin.Context, action func(*protocol.MessageTemplateActionRequest) protocol.ErrorCode) {
+	lang := middleware.GetLanguageFromContext(c)
+	admin := t.GetUserFromContext(c)
+	if admin == nil {
+		c.JSON(http.StatusUnauthorized, protocol.NewAuthErrorResult())
+		return
+	}
+	var req protocol.MessageTemplateActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	req.UserID = admin.AdminID
+
+	if errCode := action(&req); errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(nil))
+}
+
+// PreviewMessageTemplate 预览消息模板
+// @Summary 预览消息模板
+// @Description 用示例参数渲染已保存的版本或提交的内容，未提供的变量以 [变量名] 占位，同时返回发布校验会拦截的问题
+// @Tags Admin,管理员-消息模板
+// @Accept json
+// @Produce json
+// @Param request body protocol.MessageTemplatePreviewRequest true "预览请求"
+// @Success 200 {object} protocol.Result{data=protocol.MessageTemplatePreview}
+// @Security BearerAuth
+// @Router /message-templates/preview [post]
+func (t *Admin) PreviewMessageTemplate(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	var req protocol.MessageTemplatePreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	preview, errCode := services.GetMessageTemplateService().PreviewTemplate(&req)
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(preview))
+}
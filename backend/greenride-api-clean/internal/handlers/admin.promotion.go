@@ -0,0 +1,637 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"greenride/internal/middleware"
+	"greenride/internal/protocol"
+	"greenride/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// SearchPromotions 搜索优惠码
+// @Summary 搜索优惠码
+// @Description 按关键字、类型、状态分页查询优惠码
+// @Tags Admin,管理员-优惠码
+// @Accept json
+// @Produce json
+// @Param request body protocol.PromotionSearchRequest true "查询请求"
+// @Success 200 {object} protocol.Result{data=protocol.PageResult}
+// @Security BearerAuth
+// @Router /promotions/search [post]
+func (t *Admin) SearchPromotions(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	var req protocol.PromotionSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.Limit < 1 || req.Limit > 100 {
+		req.Limit = 10
+	}
+
+	list, total := services.GetAdminService().SearchPromotions(&req)
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(protocol.NewPageResult(list, total, &protocol.Pagination{
+		Page: req.Page,
+		Size: req.Limit,
+	})))
+}
+
+// GetPromotionDetail 获取优惠码详情
+// @Summary 获取优惠码详情
+// @Tags Admin,管理员-优惠码
+// @Accept json
+// @Produce json
+// @Param request body protocol.PromotionDetailRequest true "优惠码ID"
+// @Success 200 {object} protocol.Result{data=protocol.Promotion}
+// @Security BearerAuth
+// @Router /promotions/detail [post]
+func (t *Admin) GetPromotionDetail(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	var req protocol.PromotionDetailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	promotion := services.GetAdminService().GetPromotionDetail(req.PromotionID)
+	if promotion == nil {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(protocol.PromotionNotFound, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(promotion))
+}
+
+// CreatePromotion 创建优惠码
+// @Summary 创建优惠码
+// @Description 新建的优惠码处于待审批状态，需由其他管理员审批通过后才生效
+// @Tags Admin,管理员-优惠码
+// @Accept json
+// @Produce json
+// @Param request body protocol.CreatePromotionRequest true "优惠码信息"
+// @Success 200 {object} protocol.Result{data=protocol.Promotion}
+// @Security BearerAuth
+// @Router /promotions/create [post]
+func (t *Admin) CreatePromotion(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	admin := t.GetUserFromContext(c)
+	if admin == nil {
+		c.JSON(http.StatusUnauthorized, protocol.NewAuthErrorResult())
+		return
+	}
+	var req protocol.CreatePromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	req.UserID = admin.AdminID
+
+	promotion, errCode := services.GetAdminService().CreatePromotion(&req)
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(promotion.Protocol()))
+}
+
+// UpdatePromotion 更新优惠码
+// @Summary 更新优惠码
+// @Description 修改优惠力度、使用限制或预算后需重新审批
+// @Tags Admin,管理员-优惠码
+// @Accept json
+// @Produce json
+// @Param request body protocol.UpdatePromotionRequest true "更新内容"
+// @Success 200 {object} protocol.Result
+// @Security BearerAuth
+// @Router /promotions/update [post]
+func (t *Admin) UpdatePromotion(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	admin := t.GetUserFromContext(c)
+	if admin == nil {
+		c.JSON(http.StatusUnauthorized, protocol.NewAuthErrorResult())
+		return
+	}
+	var req protocol.UpdatePromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	req.UserID = admin.AdminID
+
+	if errCode := services.GetAdminService().UpdatePromotion(&req); errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(services.GetAdminService().GetPromotionDetail(req.PromotionID)))
+}
+
+// UpdatePromotionStatus 更新优惠码状态
+// @Summary 更新优惠码状态
+// @Description 启用需优惠码已审批通过且预算未用完
+// @Tags Admin,管理员-优惠码
+// @Accept json
+// @Produce json
+// @Param request body protocol.UpdatePromotionStatusRequest true "状态信息"
+// @Success 200 {object} protocol.Result
+// @Security BearerAuth
+// @Router /promotions/status [post]
+func (t *Admin) UpdatePromotionStatus(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	admin := t.GetUserFromContext(c)
+	if admin == nil {
+		c.JSON(http.StatusUnauthorized, protocol.NewAuthErrorResult())
+		return
+	}
+	var req protocol.UpdatePromotionStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	req.UserID = admin.AdminID
+
+	if errCode := services.GetAdminService().UpdatePromoCodeStatus(&req); errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(nil))
+}
+
+// ApprovePromotion 审批通过优惠码
+// @Summary 审批通过优惠码
+// @Description 创建人或最后修改人不能审批自己的优惠码
+// @Tags Admin,管理员-优惠码
+// @Accept json
+// @Produce json
+// @Param request body protocol.ApprovePromotionRequest true "审批信息"
+// @Success 200 {object} protocol.Result
+// @Security BearerAuth
+// @Router /promotions/approve [post]
+func (t *Admin) ApprovePromotion(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	admin := t.GetUserFromContext(c)
+	if admin == nil {
+		c.JSON(http.StatusUnauthorized, protocol.NewAuthErrorResult())
+		return
+	}
+	var req protocol.ApprovePromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	req.UserID = admin.AdminID
+
+	if errCode := services.GetAdminService().ApprovePromotion(&req); errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(nil))
+}
+
+// RejectPromotion 审批拒绝优惠码
+// @Summary 审批拒绝优惠码
+// @Tags Admin,管理员-优惠码
+// @Accept json
+// @Produce json
+// @Param request body protocol.ApprovePromotionRequest true "审批信息"
+// @Success 200 {object} protocol.Result
+// @Security BearerAuth
+// @Router /promotions/reject [post]
+func (t *Admin) RejectPromotion(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	admin := t.GetUserFromContext(c)
+	if admin == nil {
+		c.JSON(http.StatusUnauthorized, protocol.NewAuthErrorResult())
+		return
+	}
+	var req protocol.ApprovePromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	req.UserID = admin.AdminID
+
+	if errCode := services.GetAdminService().RejectPromotion(&req); errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(nil))
+}
+
+// GetPromotionUsage 获取优惠码使用统计
+// @Summary 获取优惠码使用统计
+// @Tags Admin,管理员-优惠码
+// @Accept json
+// @Produce json
+// @Param request body protocol.PromotionUsageRequest true "统计条件"
+// @Success 200 {object} protocol.Result
+// @Security BearerAuth
+// @Router /promotions/usage [post]
+func (t *Admin) GetPromotionUsage(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	var req protocol.PromotionUsageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	usage, errCode := services.GetAdminService().GetPromotionUsage(req.PromotionID, req.StartDate, req.EndDate)
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(usage))
+}
+
+// DeletePromotion 删除优惠码
+// @Summary 删除优惠码
+// @Description 已发放给用户的优惠码不能删除
+// @Tags Admin,管理员-优惠码
+// @Accept json
+// @Produce json
+// @Param request body protocol.DeletePromotionRequest true "优惠码ID"
+// @Success 200 {object} protocol.Result
+// @Security BearerAuth
+// @Router /promotions/delete [post]
+func (t *Admin) DeletePromotion(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	admin := t.GetUserFromContext(c)
+	if admin == nil {
+		c.JSON(http.StatusUnauthorized, protocol.NewAuthErrorResult())
+		return
+	}
+	var req protocol.DeletePromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+
+	if errCode := services.GetAdminService().DeletePromotion(req.PromotionID, admin.AdminID); errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(nil))
+}
+
+// CreatePromotionCampaign 创建批量发券活动
+// @Summary 创建批量发券活动
+// @Description 按用户类型、城市、最近行程时间、行程数或CSV名单向用户批量发放已审批的优惠码，后台分批执行。
+// @Description 上传CSV时使用 multipart/form-data：data 字段为JSON格式的活动信息，file 字段为用户ID名单（第一列）
+// @Tags Admin,管理员-优惠码
+// @Accept json,mpfd
+// @Produce json
+// @Param request body protocol.CreatePromotionCampaignRequest true "活动信息"
+// @Success 200 {object} protocol.Result{data=protocol.PromotionCampaign}
+// @Security BearerAuth
+// @Router /promotions/campaigns/create [post]
+func (t *Admin) CreatePromotionCampaign(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	admin := t.GetUserFromContext(c)
+	if admin == nil {
+		c.JSON(http.StatusUnauthorized, protocol.NewAuthErrorResult())
+		return
+	}
+
+	var req protocol.CreatePromotionCampaignRequest
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		if err := binding.JSON.BindBody([]byte(c.PostForm("data")), &req); err != nil {
+			c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+			return
+		}
+		header, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidParams, lang, "file is required"))
+			return
+		}
+		file, err := header.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidParams, lang, err.Error()))
+			return
+		}
+		userIDs, err := services.ParseCampaignUserIDs(file)
+		file.Close()
+		if err != nil {
+			c.JSON(http.StatusOK, protocol.NewErrorResult(protocol.CampaignSegmentInvalid, lang, err.Error()))
+			return
+		}
+		req.UserIDs = userIDs
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	req.UserID = admin.AdminID
+
+	campaign, errCode := services.GetPromotionCampaignService().CreateCampaign(&req)
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(campaign.Protocol()))
+}
+
+// SearchPromotionCampaigns 搜索批量发券活动
+// @Summary 搜索批量发券活动
+// @Tags Admin,管理员-优惠码
+// @Accept json
+// @Produce json
+// @Param request body protocol.PromotionCampaignSearchRequest true "查询请求"
+// @Success 200 {object} protocol.Result{data=protocol.PageResult}
+// @Security BearerAuth
+// @Router /promotions/campaigns/search [post]
+func (t *Admin) SearchPromotionCampaigns(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	var req protocol.PromotionCampaignSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.Limit < 1 || req.Limit > 100 {
+		req.Limit = 10
+	}
+
+	list, total := services.GetPromotionCampaignService().SearchCampaigns(&req)
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(protocol.NewPageResult(list, total, &protocol.Pagination{
+		Page: req.Page,
+		Size: req.Limit,
+	})))
+}
+
+// GetPromotionCampaignDetail 获取批量发券活动详情与进度
+// @Summary 获取批量发券活动详情与进度
+// @Tags Admin,管理员-优惠码
+// @Accept json
+// @Produce json
+// @Param request body protocol.PromotionCampaignActionRequest true "活动ID"
+// @Success 200 {object} protocol.Result{data=protocol.PromotionCampaign}
+// @Security BearerAuth
+// @Router /promotions/campaigns/detail [post]
+func (t *Admin) GetPromotionCampaignDetail(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	var req protocol.PromotionCampaignActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	campaign := services.GetPromotionCampaignService().GetCampaign(req.CampaignID)
+	if campaign == nil {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(protocol.CampaignNotFound, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(campaign))
+}
+
+// PausePromotionCampaign 暂停批量发券活动
+// @Summary 暂停批量发券活动
+// @Tags Admin,管理员-优惠码
+// @Accept json
+// @Produce json
+// @Param request body protocol.PromotionCampaignActionRequest true "活动ID"
+// @Success 200 {object} protocol.Result
+// @Security BearerAuth
+// @Router /promotions/campaigns/pause [post]
+func (t *Admin) PausePromotionCampaign(c *gin.Context) {
+	t.changePromotionCampaignStatus(c, services.GetPromotionCampaignService().PauseCampaign)
+}
+
+// ResumePromotionCampaign 继续批量发券活动
+// @Summary 继续批量发券活动
+// @Description 从暂停时的进度继续发放，已领取的用户不会重复发放
+// @Tags Admin,管理员-优惠码
+// @Accept json
+// @Produce json
+// @Param request body protocol.PromotionCampaignActionRequest true "活动ID"
+// @Success 200 {object} protocol.Result
+// @Security BearerAuth
+// @Router /promotions/campaigns/resume [post]
+func (t *Admin) ResumePromotionCampaign(c *gin.Context) {
+	t.changePromotionCampaignStatus(c, services.GetPromotionCampaignService().ResumeCampaign)
+}
+
+// CancelPromotionCampaign 取消批量发券活动
+// @Summary 取消批量发券活动
+// @Tags Admin,管理员-优惠码
+// @Accept json
+// @Produce json
+// @Param request body protocol.PromotionCampaignActionRequest true "活动ID"
+// @Success 200 {object} protocol.Result
+// @Security BearerAuth
+// @Router /promotions/campaigns/cancel [post]
+func (t *Admin) CancelPromotionCampaign(c *gin.Context) {
+	t.changePromotionCampaignStatus(c, services.GetPromotionCampaignService().CancelCampaign)
+}
+
+func (t *Admin) changePromotionCampaignStatus(c *gin.Context, action func(campaignID, adminID string) protocol.ErrorCode) {
+	lang := middleware.GetLanguageFromContext(c)
+	admin := t.GetUserFromContext(c)
+	if admin == nil {
+		c.JSON(http.StatusUnauthorized, protocol.NewAuthErrorResult())
+		return
+	}
+	var req protocol.PromotionCampaignActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+
+	if errCode := action(req.CampaignID, admin.AdminID); errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(services.GetPromotionCampaignService().GetCampaign(req.CampaignID)))
+}
+
+// CreatePromotionExperiment 创建优惠A/B实验
+// @Summary 创建优惠A/B实验
+// @Description 为已审批的优惠码定义多个折扣变体和对照组，需另一位管理员审批后才能通过批量发券活动的 experiment_id 按实验发券
+// @Tags Admin,管理员-优惠码
+// @Accept json
+// @Produce json
+// @Param request body protocol.CreatePromotionExperimentRequest true "实验信息"
+// @Success 200 {object} protocol.Result{data=protocol.PromotionExperiment}
+// @Security BearerAuth
+// @Router /promotions/experiments/create [post]
+func (t *Admin) CreatePromotionExperiment(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	admin := t.GetUserFromContext(c)
+	if admin == nil {
+		c.JSON(http.StatusUnauthorized, protocol.NewAuthErrorResult())
+		return
+	}
+	var req protocol.CreatePromotionExperimentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	req.UserID = admin.AdminID
+
+	experiment, errCode := services.GetPromotionExperimentService().CreateExperiment(&req)
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(experiment.Protocol()))
+}
+
+// ApprovePromotionExperiment 审批通过优惠A/B实验
+// @Summary 审批通过优惠A/B实验
+// @Description 实验变体折扣需另一位管理员审批通过后才开始运行，审批人不能是创建人
+// @Tags Admin,管理员-优惠码
+// @Accept json
+// @Produce json
+// @Param request body protocol.ReviewPromotionExperimentRequest true "审批信息"
+// @Success 200 {object} protocol.Result{data=protocol.PromotionExperiment}
+// @Security BearerAuth
+// @Router /promotions/experiments/approve [post]
+func (t *Admin) ApprovePromotionExperiment(c *gin.Context) {
+	t.reviewPromotionExperiment(c, services.GetPromotionExperimentService().ApproveExperiment)
+}
+
+// RejectPromotionExperiment 拒绝优惠A/B实验
+// @Summary 拒绝优惠A/B实验
+// @Description 拒绝后实验不能用于发券，审批人不能是创建人
+// @Tags Admin,管理员-优惠码
+// @Accept json
+// @Produce json
+// @Param request body protocol.ReviewPromotionExperimentRequest true "审批信息"
+// @Success 200 {object} protocol.Result{data=protocol.PromotionExperiment}
+// @Security BearerAuth
+// @Router /promotions/experiments/reject [post]
+func (t *Admin) RejectPromotionExperiment(c *gin.Context) {
+	t.reviewPromotionExperiment(c, services.GetPromotionExperimentService().RejectExperiment)
+}
+
+func (t *Admin) reviewPromotionExperiment(c *gin.Context, action func(*protocol.ReviewPromotionExperimentRequest) protocol.ErrorCode) {
+	lang := middleware.GetLanguageFromContext(c)
+	admin := t.GetUserFromContext(c)
+	if admin == nil {
+		c.JSON(http.StatusUnauthorized, protocol.NewAuthErrorResult())
+		return
+	}
+	var req protocol.ReviewPromotionExperimentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	req.UserID = admin.AdminID
+
+	if errCode := action(&req); errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(services.GetPromotionExperimentService().GetExperiment(req.ExperimentID)))
+}
+
+// SearchPromotionExperiments 搜索优惠A/B实验
+// @Summary 搜索优惠A/B实验
+// @Tags Admin,管理员-优惠码
+// @Accept json
+// @Produce json
+// @Param request body protocol.PromotionExperimentSearchRequest true "查询请求"
+// @Success 200 {object} protocol.Result{data=protocol.PageResult}
+// @Security BearerAuth
+// @Router /promotions/experiments/search [post]
+func (t *Admin) SearchPromotionExperiments(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	var req protocol.PromotionExperimentSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.Limit < 1 || req.Limit > 100 {
+		req.Limit = 10
+	}
+
+	list, total := services.GetPromotionExperimentService().SearchExperiments(&req)
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(protocol.NewPageResult(list, total, &protocol.Pagination{
+		Page: req.Page,
+		Size: req.Limit,
+	})))
+}
+
+// GetPromotionExperimentDetail 获取优惠A/B实验详情
+// @Summary 获取优惠A/B实验详情
+// @Tags Admin,管理员-优惠码
+// @Accept json
+// @Produce json
+// @Param request body protocol.PromotionExperimentActionRequest true "实验ID"
+// @Success 200 {object} protocol.Result{data=protocol.PromotionExperiment}
+// @Security BearerAuth
+// @Router /promotions/experiments/detail [post]
+func (t *Admin) GetPromotionExperimentDetail(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	var req protocol.PromotionExperimentActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	experiment := services.GetPromotionExperimentService().GetExperiment(req.ExperimentID)
+	if experiment == nil {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(protocol.ExperimentNotFound, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(experiment))
+}
+
+// GetPromotionExperimentReport 获取优惠A/B实验效果报告
+// @Summary 获取优惠A/B实验效果报告
+// @Description 按变体统计转化率（窗口期内完成订单）、核销率、优惠成本以及相对对照组的增量订单
+// @Tags Admin,管理员-优惠码
+// @Accept json
+// @Produce json
+// @Param request body protocol.PromotionExperimentActionRequest true "实验ID"
+// @Success 200 {object} protocol.Result{data=protocol.PromotionExperimentReport}
+// @Security BearerAuth
+// @Router /promotions/experiments/report [post]
+func (t *Admin) GetPromotionExperimentReport(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	var req protocol.PromotionExperimentActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	report, errCode := services.GetPromotionExperimentService().GetExperimentReport(req.ExperimentID)
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(report))
+}
+
+// CompletePromotionExperiment 结束优惠A/B实验
+// @Summary 结束优惠A/B实验
+// @Description 结束后关联的发券活动自动暂停，已分组用户继续统计转化
+// @Tags Admin,管理员-优惠码
+// @Accept json
+// @Produce json
+// @Param request body protocol.PromotionExperimentActionRequest true "实验ID"
+// @Success 200 {object} protocol.Result
+// @Security BearerAuth
+// @Router /promotions/experiments/complete [post]
+func (t *Admin) CompletePromotionExperiment(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	admin := t.GetUserFromContext(c)
+	if admin == nil {
+		c.JSON(http.StatusUnauthorized, protocol.NewAuthErrorResult())
+		return
+	}
+	var req protocol.PromotionExperimentActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+
+	if errCode := services.GetPromotionExperimentService().CompleteExperiment(req.ExperimentID); errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(nil))
+}
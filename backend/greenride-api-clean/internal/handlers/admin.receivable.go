@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"net/http"
+
+	"greenride/internal/middleware"
+	"greenride/internal/protocol"
+	"greenride/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SearchReceivables 搜索应收欠款
+// @Summary 搜索应收欠款
+// @Description 默认只查未结清的欠款，可按乘客、订单、欠款类型筛选，exhausted=true 只看已停止自动重试的车费欠款；附带按币种的应收汇总
+// @Tags Admin,管理员-应收
+// @Accept json
+// @Produce json
+// @Param request body protocol.ReceivableSearchRequest true "查询请求"
+// @Success 200 {object} protocol.Result{data=protocol.PageResult}
+// @Security BearerAuth
+// @Router /receivables/search [post]
+func (t *Admin) SearchReceivables(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	var req protocol.ReceivableSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.Limit < 1 || req.Limit > 100 {
+		req.Limit = 10
+	}
+
+	list, total := services.GetDunningService().SearchReceivables(&req)
+	result := protocol.NewPageResult(list, total, &protocol.Pagination{
+		Page: req.Page,
+		Size: req.Limit,
+	})
+	if summary, errCode := services.GetDunningService().GetReceivableSummary(); errCode == protocol.Success {
+		result.AddAttach("summary", summary)
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(result))
+}
+
+// GetReceivableSummary 获取应收汇总
+// @Summary 获取应收汇总
+// @Description 按币种汇总未结清欠款的金额、笔数、乘客数和已停止自动重试的笔数
+// @Tags Admin,管理员-应收
+// @Produce json
+// @Success 200 {object} protocol.Result{data=[]protocol.ReceivableSummary}
+// @Security BearerAuth
+// @Router /receivables/summary [get]
+func (t *Admin) GetReceivableSummary(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	summary, errCode := services.GetDunningService().GetReceivableSummary()
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(summary))
+}
+
+// WaiveDebt 减免欠款
+// @Summary 减免欠款
+// @Description 减免乘客未结清的欠款并停止自动扣款，减免后不再计入下单限制
+// @Tags Admin,管理员-应收
+// @Accept json
+// @Produce json
+// @Param request body protocol.DebtActionRequest true "欠款ID及备注"
+// @Success 200 {object} protocol.Result
+// @Security BearerAuth
+// @Router /receivables/waive [post]
+func (t *Admin) WaiveDebt(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	admin := t.GetUserFromContext(c)
+	if admin == nil {
+		c.JSON(http.StatusUnauthorized, protocol.NewAuthErrorResult())
+		return
+	}
+	var req protocol.DebtActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	req.UserID = admin.AdminID
+	if errCode := services.GetDunningService().WaiveDebt(&req); errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(nil))
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"net/http"
+
+	"greenride/internal/middleware"
+	"greenride/internal/protocol"
+	"greenride/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SearchReferrals 搜索邀请记录
+// @Summary 搜索邀请记录
+// @Description 可按邀请人、被邀请人、计划类型和状态筛选，status=review 即风控待审核队列
+// @Tags Admin,管理员-邀请
+// @Accept json
+// @Produce json
+// @Param request body protocol.ReferralSearchRequest true "查询请求"
+// @Success 200 {object} protocol.Result{data=protocol.PageResult}
+// @Security BearerAuth
+// @Router /referrals/search [post]
+func (t *Admin) SearchReferrals(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	var req protocol.ReferralSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.Limit < 1 || req.Limit > 100 {
+		req.Limit = 10
+	}
+
+	list, total := services.GetReferralService().SearchReferrals(&req)
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(protocol.NewPageResult(list, total, &protocol.Pagination{
+		Page: req.Page,
+		Size: req.Limit,
+	})))
+}
+
+// GetReferralDetail 获取邀请记录详情
+// @Summary 获取邀请记录详情
+// @Tags Admin,管理员-邀请
+// @Accept json
+// @Produce json
+// @Param request body protocol.ReferralActionRequest true "邀请记录ID"
+// @Success 200 {object} protocol.Result{data=protocol.Referral}
+// @Security BearerAuth
+// @Router /referrals/detail [post]
+func (t *Admin) GetReferralDetail(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	var req protocol.ReferralActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	referral := services.GetReferralService().GetReferral(req.ReferralID)
+	if referral == nil {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(protocol.ReferralNotFound, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(referral))
+}
+
+// GetReferralTree 获取用户邀请树
+// @Summary 获取用户邀请树
+// @Description 返回向上的邀请链和向下若干层被邀请人，每个节点附带邀请记录状态和风控信号
+// @Tags Admin,管理员-邀请
+// @Accept json
+// @Produce json
+// @Param request body protocol.ReferralTreeRequest true "用户ID和展开层数"
+// @Success 200 {object} protocol.Result{data=protocol.ReferralTree}
+// @Security BearerAuth
+// @Router /referrals/tree [post]
+func (t *Admin) GetReferralTree(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	var req protocol.ReferralTreeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	tree, errCode := services.GetReferralService().GetReferralTree(req.TargetUserID, req.Depth)
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(tree))
+}
+
+// ApproveReferral 审核通过邀请
+// @Summary 审核通过邀请
+// @Description 风控误判时人工放行，被邀请人已达成条件的立即发放奖励
+// @Tags Admin,管理员-邀请
+// @Accept json
+// @Produce json
+// @Param request body protocol.ReferralActionRequest true "审核请求"
+// @Success 200 {object} protocol.Result
+// @Security BearerAuth
+// @Router /referrals/approve [post]
+func (t *Admin) ApproveReferral(c *gin.Context) {
+	t.reviewReferral(c, services.GetReferralService().ApproveReferral)
+}
+
+// RejectReferral 拒绝邀请奖励
+// @Summary 拒绝邀请奖励
+// @Tags Admin,管理员-邀请
+// @Accept json
+// @Produce json
+// @Param request body protocol.ReferralActionRequest true "审核请求"
+// @Success 200 {object} protocol.Result
+// @Security BearerAuth
+// @Router /referrals/reject [post]
+func (t *Admin) RejectReferral(c *gin.Context) {
+	t.reviewReferral(c, services.GetReferralService().RejectReferral)
+}
+
+func (t *Admin) reviewReferral(c *gin.Context, action func(*protocol.ReferralActionRequest) protocol.ErrorCode) {
+	lang := middleware.GetLanguageFromContext(c)
+	admin := t.GetUserFromContext(c)
+	if admin == nil {
+		c.JSON(http.StatusUnauthorized, protocol.NewAuthErrorResult())
+		return
+	}
+	var req protocol.ReferralActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	req.UserID = admin.AdminID
+
+	if errCode := action(&req); errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(nil))
+}
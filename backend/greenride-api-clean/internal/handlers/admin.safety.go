@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"net/http"
+
+	"greenride/internal/middleware"
+	"greenride/internal/protocol"
+	"greenride/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SearchSafetyIncidents 搜索安全事件
+// @Summary 搜索安全事件
+// @Description 紧急求助记录列表，可按用户、订单、状态筛选，待处理的排在前面
+// @Tags Admin,管理员-安全
+// @Accept json
+// @Produce json
+// @Param request body protocol.SafetyIncidentSearchRequest true "查询请求"
+// @Success 200 {object} protocol.Result{data=protocol.PageResult}
+// @Security BearerAuth
+// @Router /safety-incidents/search [post]
+func (t *Admin) SearchSafetyIncidents(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	var req protocol.SafetyIncidentSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.Limit < 1 || req.Limit > 100 {
+		req.Limit = 10
+	}
+
+	list, total := services.GetSafetyService().SearchIncidents(&req)
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(protocol.NewPageResult(list, total, &protocol.Pagination{
+		Page: req.Page,
+		Size: req.Limit,
+	})))
+}
+
+// GetSafetyIncidentDetail 获取安全事件详情
+// @Summary 获取安全事件详情
+// @Description 包含求助位置、说明及求助时的订单快照
+// @Tags Admin,管理员-安全
+// @Accept json
+// @Produce json
+// @Param request body protocol.SafetyIncidentActionRequest true "事件ID"
+// @Success 200 {object} protocol.Result{data=protocol.SafetyIncident}
+// @Security BearerAuth
+// @Router /safety-incidents/detail [post]
+func (t *Admin) GetSafetyIncidentDetail(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	var req protocol.SafetyIncidentActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	incident, errCode := services.GetSafetyService().GetIncident(req.IncidentID)
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(incident))
+}
+
+// AcknowledgeSafetyIncident 响应安全事件
+// @Summary 响应安全事件
+// @Tags Admin,管理员-安全
+// @Accept json
+// @Produce json
+// @Param request body protocol.SafetyIncidentActionRequest true "事件ID及处理说明"
+// @Success 200 {object} protocol.Result
+// @Security BearerAuth
+// @Router /safety-incidents/acknowledge [post]
+func (t *Admin) AcknowledgeSafetyIncident(c *gin.Context) {
+	t.handleSafetyIncident(c, services.GetSafetyService().AcknowledgeIncident)
+}
+
+// ResolveSafetyIncident 关闭安全事件
+// @Summary 关闭安全事件
+// @Tags Admin,管理员-安全
+// @Accept json
+// @Produce json
+// @Param request body protocol.SafetyIncidentActionRequest true "事件ID及处理说明"
+// @Success 200 {object} protocol.Result
+// @Security BearerAuth
+// @Router /safety-incidents/resolve [post]
+func (t *Admin) ResolveSafetyIncident(c *gin.Context) {
+	t.handleSafetyIncident(c, services.GetSafetyService().ResolveIncident)
+}
+
+func (t *Admin) handleSafetyIncident(c *gin.Context, action func(*protocol.SafetyIncidentActionRequest) protocol.ErrorCode) {
+	lang := middleware.GetLanguageFromContext(c)
+	admin := t.GetUserFromContext(c)
+	if admin == nil {
+		c.JSON(http.StatusUnauthorized, protocol.NewAuthErrorResult())
+		return
+	}
+	var req protocol.SafetyIncidentActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	req.UserID = admin.AdminID
+	if errCode := action(&req); errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(nil))
+}
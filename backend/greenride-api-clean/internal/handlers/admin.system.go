@@ -7,6 +7,7 @@ import (
 	"greenride/internal/services"
 
 	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
 )
 
 // GetSystemConfig 获取系统配置（公共接口，用于移动端启动检查）
@@ -98,3 +99,23 @@ func (a *Admin) AdminPurgeLegacyDeleted(c *gin.Context) {
 
 	c.JSON(http.StatusOK, protocol.NewSuccessResult(summary))
 }
+
+// AdminGetRateLimitOffenders 获取限流拦截排行
+// @Summary 获取限流拦截排行（管理员）
+// @Description 按天统计被限流拦截次数最多的IP/用户
+// @Tags Admin,System
+// @Produce json
+// @Security ApiKeyAuth
+// @Param date query string false "日期(yyyyMMdd)，默认今天"
+// @Param limit query int false "返回条数" default(20)
+// @Success 200 {object} protocol.Result{data=[]protocol.RateLimitOffender}
+// @Router /system/rate-limit/offenders [get]
+func (a *Admin) AdminGetRateLimitOffenders(c *gin.Context) {
+	limit := cast.ToInt(c.DefaultQuery("limit", "20"))
+	offenders, errCode := services.GetRateLimitService().GetTopOffenders(c.Query("date"), limit)
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, ""))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(offenders))
+}
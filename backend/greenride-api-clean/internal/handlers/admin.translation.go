@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"greenride/internal/middleware"
+	"greenride/internal/protocol"
+	"greenride/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SearchTranslations 搜索翻译
+// @Summary 搜索翻译
+// @Description 列出命名空间下所有语言出现过的key及该语言的内容，source 区分 locales 文件和后台维护；missing=true 只看缺少的key
+// @Tags Admin,管理员-翻译
+// @Accept json
+// @Produce json
+// @Param request body protocol.TranslationSearchRequest true "查询请求"
+// @Success 200 {object} protocol.Result{data=protocol.PageResult}
+// @Security BearerAuth
+// @Router /translations/search [post]
+func (t *Admin) SearchTranslations(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	var req protocol.TranslationSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.Limit < 1 || req.Limit > 100 {
+		req.Limit = 10
+	}
+
+	list, total, errCode := services.GetTranslationService().SearchTranslations(&req)
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(protocol.NewPageResult(list, total, &protocol.Pagination{
+		Page: req.Page,
+		Size: req.Limit,
+	})))
+}
+
+// SaveTranslation 保存翻译
+// @Summary 保存翻译
+// @Description 新增或覆盖一条翻译，占位符需与英文一致；所有实例热加载，无需重启
+// @Tags Admin,管理员-翻译
+// @Accept json
+// @Produce json
+// @Param request body protocol.SaveTranslationRequest true "翻译内容"
+// @Success 200 {object} protocol.Result
+// @Security BearerAuth
+// @Router /translations/save [post]
+func (t *Admin) SaveTranslation(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	admin := t.GetUserFromContext(c)
+	if admin == nil {
+		c.JSON(http.StatusUnauthorized, protocol.NewAuthErrorResult())
+		return
+	}
+	var req protocol.SaveTranslationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	req.UserID = admin.AdminID
+
+	reason, errCode := services.GetTranslationService().SaveTranslation(&req)
+	if errCode == protocol.TranslationInvalid {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang, reason))
+		return
+	}
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(nil))
+}
+
+// DeleteTranslation 删除翻译
+// @Summary 删除翻译
+// @Description 删除后台维护的翻译，回退到 locales 文件中的内容
+// @Tags Admin,管理员-翻译
+// @Accept json
+// @Produce json
+// @Param request body protocol.DeleteTranslationRequest true "翻译key"
+// @Success 200 {object} protocol.Result
+// @Security BearerAuth
+// @Router /translations/delete [post]
+func (t *Admin) DeleteTranslation(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	admin := t.GetUserFromContext(c)
+	if admin == nil {
+		c.JSON(http.StatusUnauthorized, protocol.NewAuthErrorResult())
+		return
+	}
+	var req protocol.DeleteTranslationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	req.UserID = admin.AdminID
+
+	if errCode := services.GetTranslationService().DeleteTranslation(&req); errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(nil))
+}
+
+// ImportTranslations 导入翻译
+// @Summary 导入翻译
+// @Description entries 为 locales 文件（common.json/errors.json）的内容；内容不同的已有key仅在 overwrite=true 时覆盖，不合法的key跳过并返回原因
+// @Tags Admin,管理员-翻译
+// @Accept json
+// @Produce json
+// @Param request body protocol.ImportTranslationsRequest true "导入请求"
+// @Success 200 {object} protocol.Result{data=protocol.TranslationImportResult}
+// @Security BearerAuth
+// @Router /translations/import [post]
+func (t *Admin) ImportTranslations(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	admin := t.GetUserFromContext(c)
+	if admin == nil {
+		c.JSON(http.StatusUnauthorized, protocol.NewAuthErrorResult())
+		return
+	}
+	var req protocol.ImportTranslationsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	req.UserID = admin.AdminID
+
+	result, errCode := services.GetTranslationService().ImportTranslations(&req)
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(result))
+}
+
+// ExportTranslations 导出翻译
+// @Summary 导出翻译
+// @Description 导出当前生效的翻译（locales 文件与后台维护合并），格式与 locales 文件相同；download=true 时直接下载JSON文件
+// @Tags Admin,管理员-翻译
+// @Accept json
+// @Produce json
+// @Param request body protocol.ExportTranslationsRequest true "导出请求"
+// @Success 200 {object} protocol.Result{data=map[string]string}
+// @Security BearerAuth
+// @Router /translations/export [post]
+func (t *Admin) ExportTranslations(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	var req protocol.ExportTranslationsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+
+	entries, errCode := services.GetTranslationService().ExportTranslations(&req)
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	if !req.Download {
+		c.JSON(http.StatusOK, protocol.NewSuccessResult(entries))
+		return
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(protocol.SystemError, lang))
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.%s.json", req.Language, req.Namespace))
+	c.Data(http.StatusOK, "application/json; charset=utf-8", append(data, '\n'))
+}
+
+// GetTranslationReport 翻译缺失报表
+// @Summary 翻译缺失报表
+// @Description 每个语言缺少的key（以所有语言key的并集为准），以及线上请求该语言时降级到英文或原始key的次数
+// @Tags Admin,管理员-翻译
+// @Accept json
+// @Produce json
+// @Param request body protocol.TranslationReportRequest true "查询请求"
+// @Success 200 {object} protocol.Result{data=[]protocol.TranslationLanguageReport}
+// @Security BearerAuth
+// @Router /translations/report [post]
+func (t *Admin) GetTranslationReport(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	var req protocol.TranslationReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+
+	reports, errCode := services.GetTranslationService().GetTranslationReport(&req)
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(reports))
+}
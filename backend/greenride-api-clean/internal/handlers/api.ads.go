@@ -17,7 +17,7 @@ import (
 
 // GetLocalAdvertisements 获取本地广告列表
 // @Summary 获取本地广告列表
-// @Description 获取本地商家广告列表，支持按城市、地区、类别筛选
+// @Description 获取本地商家广告列表，支持按城市、地区、类别筛选；传入位置时按距离、营业状态、推广标记和点击率排序，placement=trip 时按行程目的地推荐
 // @Tags Api 广告
 // @Accept json
 // @Produce json
@@ -36,6 +36,9 @@ func (a *Api) GetLocalAdvertisements(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidParams, lang))
 		return
 	}
+	if user := GetUserFromContext(c); user != nil {
+		req.UserID = user.UserID
+	}
 
 	// 获取本地广告服务
 	service := services.GetLocalAdvertisementService()
@@ -77,6 +80,9 @@ func (a *Api) UpdateAdvertisementStats(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidParams, lang))
 		return
 	}
+	if user := GetUserFromContext(c); user != nil {
+		req.UserID = user.UserID
+	}
 
 	// 获取本地广告服务
 	service := services.GetLocalAdvertisementService()
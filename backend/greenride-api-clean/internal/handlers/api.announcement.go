@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"net/http"
+
+	"greenride/internal/middleware"
+	"greenride/internal/protocol"
+	"greenride/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetActiveAnnouncements 获取当前生效的公告
+// @Summary 获取当前生效的公告
+// @Description 按用户身份、城市和位置筛选，内容按请求语言返回；只显示一次的公告读过后不再返回
+// @Tags Api,公告
+// @Accept json
+// @Produce json
+// @Param request body protocol.UserAnnouncementListRequest true "位置和展示位置"
+// @Success 200 {object} protocol.Result{data=[]protocol.UserAnnouncement}
+// @Security BearerAuth
+// @Router /announcements/active [post]
+func (a *Api) GetActiveAnnouncements(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	user := GetUserFromContext(c)
+	var req protocol.UserAnnouncementListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	list := services.GetAnnouncementService().GetUserAnnouncements(user, &req, lang)
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(list))
+}
+
+// MarkAnnouncementRead 标记公告已读
+// @Summary 标记公告已读
+// @Tags Api,公告
+// @Accept json
+// @Produce json
+// @Param request body protocol.UserAnnouncementActionRequest true "公告ID"
+// @Success 200 {object} protocol.Result
+// @Security BearerAuth
+// @Router /announcements/read [post]
+func (a *Api) MarkAnnouncementRead(c *gin.Context) {
+	a.announcementAction(c, services.GetAnnouncementService().MarkRead)
+}
+
+// ConfirmAnnouncement 确认公告
+// @Summary 确认公告
+// @Description 需要确认的公告（如服务条款变更）在用户点击确认后调用，同时记为已读
+// @Tags Api,公告
+// @Accept json
+// @Produce json
+// @Param request body protocol.UserAnnouncementActionRequest true "公告ID"
+// @Success 200 {object} protocol.Result
+// @Security BearerAuth
+// @Router /announcements/confirm [post]
+func (a *Api) ConfirmAnnouncement(c *gin.Context) {
+	a.announcementAction(c, services.GetAnnouncementService().MarkConfirmed)
+}
+
+func (a *Api) announcementAction(c *gin.Context, action func(userID, announcementID string) protocol.ErrorCode) {
+	lang := middleware.GetLanguageFromContext(c)
+	user := GetUserFromContext(c)
+	var req protocol.UserAnnouncementActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	if errCode := action(user.UserID, req.AnnouncementID); errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(nil))
+}
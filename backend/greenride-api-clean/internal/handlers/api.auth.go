@@ -159,7 +159,8 @@ func (a *Api) Register(c *gin.Context) {
 	}
 
 	// 注册成功后发送用户注册信号（异步处理，不影响注册流程）
-	if user.IsPassenger() {
+	// 被邀请的司机也需要发送，用于记录司机推荐司机
+	if user.IsPassenger() || user.GetInvitedBy() != "" {
 		if err := services.SendUserRegisteredSignal(user.UserID); err != nil {
 			log.Get().WithField("user_id", user.UserID).
 				Errorf("Failed to send user registered signal: %v", err)
@@ -357,6 +358,9 @@ type SendVerifyCodeRequest struct {
 	Phone       string `json:"phone,omitempty"`                                                                       // 手机号码，有则发送短信验证码
 	CountryCode string `json:"country_code,omitempty"`                                                                // 手机号国家代码，默认为卢旺达(RW)
 	UserType    string `json:"user_type" binding:"required,oneof=passenger driver"`                                   // 用户类型，用于区分同一联系方式的不同身份
+	DeviceID    string `json:"device_id,omitempty"`                                                                   // 设备ID，缺省时读取 X-Device-ID 请求头
+	ChallengeID string `json:"challenge_id,omitempty"`                                                                // 工作量证明挑战ID（多次发送后需要）
+	Nonce       string `json:"nonce,omitempty"`                                                                       // 工作量证明结果
 }
 
 // SendVerifyCode 发送验证码
@@ -423,9 +427,25 @@ func (a *Api) SendVerifyCode(c *gin.Context) {
 		target = req.Phone
 		contentType = protocol.MsgChannelSms
 	}
-	errCode, remainingSeconds := services.GetVerifyCodeService().SendVerifyCode(contentType, target, req.UserType, req.Type, lang)
+	if req.DeviceID == "" {
+		req.DeviceID = c.GetHeader("X-Device-ID")
+	}
+	sendCtx := &services.OTPSendContext{
+		IP:             c.ClientIP(),
+		DeviceID:       req.DeviceID,
+		ChallengeID:    req.ChallengeID,
+		ChallengeNonce: req.Nonce,
+	}
+	errCode, remainingSeconds := services.GetVerifyCodeService().SendVerifyCodeWithContext(sendCtx, contentType, target, req.UserType, req.Type, lang)
 	if errCode != protocol.Success {
-		if errCode == protocol.VerificationCooldown {
+		if errCode == protocol.VerificationChallenge || errCode == protocol.VerificationChallengeFail {
+			// 返回新的工作量证明挑战，客户端完成后携带 challenge_id 和 nonce 重新请求
+			result := protocol.NewErrorResult(errCode, lang)
+			if challenge, challengeErr := services.GetVerifyCodeService().IssueOTPChallenge(target); challengeErr == protocol.Success {
+				result.Data = challenge
+			}
+			c.JSON(http.StatusOK, result)
+		} else if errCode == protocol.VerificationCooldown {
 			result := protocol.NewErrorResult(protocol.VerificationCooldown, lang, strconv.Itoa(remainingSeconds))
 			result.Data = map[string]any{
 				"remaining_seconds": strconv.Itoa(remainingSeconds),
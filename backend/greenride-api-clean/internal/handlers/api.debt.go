@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+
+	"greenride/internal/middleware"
+	"greenride/internal/protocol"
+	"greenride/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetOutstandingDebts 获取未结清的欠款
+// @Summary 获取未结清的欠款
+// @Description 返回乘客未结清的车费、取消费和爽约费欠款，欠款合计超过上限时blocked为true，结清前不能下单
+// @Tags Api,支付
+// @Produce json
+// @Success 200 {object} protocol.Result{data=protocol.OutstandingBalance}
+// @Security BearerAuth
+// @Router /debts [get]
+func (a *Api) GetOutstandingDebts(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+
+	user := GetUserFromContext(c)
+	balance, errCode := services.GetDunningService().GetOutstandingBalance(user.UserID)
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(balance))
+}
+
+// PayDebt 结清欠款
+// @Summary 结清欠款
+// @Description 车费欠款通过订单支付渠道支付（未指定支付方式时使用默认支付方式），取消费和爽约费从钱包扣款
+// @Tags Api,支付
+// @Accept json
+// @Produce json
+// @Param request body protocol.PayDebtRequest true "欠款ID及支付方式"
+// @Success 200 {object} protocol.Result{data=protocol.PayDebtResult}
+// @Security BearerAuth
+// @Router /debt/pay [post]
+func (a *Api) PayDebt(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+
+	var req protocol.PayDebtRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	user := GetUserFromContext(c)
+	req.UserID = user.UserID
+	req.Language = lang
+	result, errCode := services.GetDunningService().PayDebt(&req)
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(result))
+}
@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"greenride/internal/config"
+	"greenride/internal/log"
+	"greenride/internal/middleware"
+	"greenride/internal/protocol"
+	"greenride/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+)
+
+var deliveryPhotoExts = map[string]bool{
+	".jpg":  true,
+	".jpeg": true,
+	".png":  true,
+	".webp": true,
+}
+
+// readDeliveryPhoto 读取表单中的配送照片，未上传时返回nil；调用方负责关闭返回的文件
+func readDeliveryPhoto(c *gin.Context, field string) (*services.DocumentFile, func(), protocol.ErrorCode) {
+	header, err := c.FormFile(field)
+	if err != nil {
+		return nil, func() {}, protocol.Success
+	}
+	ext := strings.ToLower(filepath.Ext(header.Filename))
+	if !deliveryPhotoExts[ext] {
+		return nil, func() {}, protocol.InvalidContentType
+	}
+	if header.Size > config.GetDeliveryConfig().MaxPhotoSize*1024*1024 {
+		return nil, func() {}, protocol.RequestTooLarge
+	}
+	file, err := header.Open()
+	if err != nil {
+		log.Errorf("readDeliveryPhoto open %s failed: %v", field, err)
+		return nil, func() {}, protocol.FileError
+	}
+	return &services.DocumentFile{Reader: file, Extension: ext}, func() { _ = file.Close() }, protocol.Success
+}
+
+// deliveryActionFromForm 从表单读取司机配送操作参数
+func deliveryActionFromForm(c *gin.Context, userID string) *protocol.OrderActionRequest {
+	return &protocol.OrderActionRequest{
+		UserID:    userID,
+		OrderID:   strings.TrimSpace(c.PostForm("order_id")),
+		Latitude:  cast.ToFloat64(c.PostForm("latitude")),
+		Longitude: cast.ToFloat64(c.PostForm("longitude")),
+	}
+}
+
+// UploadParcelPhoto 寄件人上传包裹照片
+// @Summary 上传包裹照片
+// @Description 下单前上传包裹照片，返回的地址在创建配送订单时通过delivery.photos提交
+// @Tags Api,订单
+// @Accept multipart/form-data
+// @Produce json
+// @Param photo formData file true "包裹照片"
+// @Success 200 {object} protocol.Result{data=protocol.DeliveryPhoto}
+// @Security BearerAuth
+// @Router /delivery/photo/upload [post]
+func (a *Api) UploadParcelPhoto(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	user := GetUserFromContext(c)
+
+	photo, closeFile, errCode := readDeliveryPhoto(c, "photo")
+	defer closeFile()
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	if photo == nil {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(protocol.MissingParams, lang, "photo"))
+		return
+	}
+	result, errCode := services.GetDeliveryService().UploadParcelPhoto(c.Request.Context(), user.UserID, photo)
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(result))
+}
+
+// ResendDeliveryCode 寄件人重新发送取件码
+// @Summary 重新发送取件码
+// @Description 重新生成收件人取件码并短信发送给收件人，旧取件码失效
+// @Tags Api,订单
+// @Accept json
+// @Produce json
+// @Param request body protocol.OrderIDRequest true "订单ID"
+// @Success 200 {object} protocol.Result
+// @Security BearerAuth
+// @Router /delivery/code/resend [post]
+func (a *Api) ResendDeliveryCode(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+
+	var req protocol.OrderIDRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	user := GetUserFromContext(c)
+	if errCode := services.GetDeliveryService().ResendRecipientCode(req.OrderID, user.UserID); errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(""))
+}
+
+// ArrivedDeliveryPickup 司机到达取件点
+// @Summary 到达取件点
+// @Description 司机标记已到达配送订单的取件点
+// @Tags Api,司机
+// @Accept json
+// @Produce json
+// @Param request body protocol.OrderActionRequest true "到达取件点请求"
+// @Success 200 {object} protocol.Result
+// @Security BearerAuth
+// @Router /delivery/arrived-pickup [post]
+func (a *Api) ArrivedDeliveryPickup(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+
+	var req protocol.OrderActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	user := GetUserFromContext(c)
+	req.UserID = user.UserID
+	if errCode := services.GetDeliveryService().ArrivedPickup(&req); errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(""))
+}
+
+// PickupParcel 司机取件
+// @Summary 取件
+// @Description 司机在取件点取到包裹，可附带包裹照片，订单进入配送中
+// @Tags Api,司机
+// @Accept multipart/form-data
+// @Produce json
+// @Param order_id formData string true "订单ID"
+// @Param latitude formData number false "纬度"
+// @Param longitude formData number false "经度"
+// @Param photo formData file false "取件时拍摄的包裹照片"
+// @Success 200 {object} protocol.Result
+// @Security BearerAuth
+// @Router /delivery/pickup [post]
+func (a *Api) PickupParcel(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	user := GetUserFromContext(c)
+
+	photo, closeFile, errCode := readDeliveryPhoto(c, "photo")
+	defer closeFile()
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	req := deliveryActionFromForm(c, user.UserID)
+	if errCode := services.GetDeliveryService().PickupParcel(c.Request.Context(), req, photo); errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(""))
+}
+
+// ArrivedDeliveryDropoff 司机到达收件点
+// @Summary 到达收件点
+// @Description 司机标记已到达配送订单的收件点，之后凭取件码或照片签收
+// @Tags Api,司机
+// @Accept json
+// @Produce json
+// @Param request body protocol.OrderActionRequest true "到达收件点请求"
+// @Success 200 {object} protocol.Result
+// @Security BearerAuth
+// @Router /delivery/arrived-dropoff [post]
+func (a *Api) ArrivedDeliveryDropoff(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+
+	var req protocol.OrderActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	user := GetUserFromContext(c)
+	req.UserID = user.UserID
+	if errCode := services.GetDeliveryService().ArrivedDropoff(&req); errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(""))
+}
+
+// CompleteDelivery 司机签收配送订单
+// @Summary 签收
+// @Description 司机输入收件人提供的取件码，或上传签收照片完成配送，订单进入待支付
+// @Tags Api,司机
+// @Accept multipart/form-data
+// @Produce json
+// @Param order_id formData string true "订单ID"
+// @Param code formData string false "收件人取件码"
+// @Param latitude formData number false "纬度"
+// @Param longitude formData number false "经度"
+// @Param photo formData file false "签收照片，未填写取件码时必填"
+// @Success 200 {object} protocol.Result
+// @Security BearerAuth
+// @Router /delivery/complete [post]
+func (a *Api) CompleteDelivery(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	user := GetUserFromContext(c)
+
+	photo, closeFile, errCode := readDeliveryPhoto(c, "photo")
+	defer closeFile()
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	req := deliveryActionFromForm(c, user.UserID)
+	if errCode := services.GetDeliveryService().CompleteDelivery(c.Request.Context(), req, c.PostForm("code"), photo); errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(""))
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"greenride/internal/config"
+	"greenride/internal/log"
+	"greenride/internal/middleware"
+	"greenride/internal/protocol"
+	"greenride/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+)
+
+var documentImageExts = map[string]bool{
+	".jpg":  true,
+	".jpeg": true,
+	".png":  true,
+	".webp": true,
+	".pdf":  true,
+}
+
+// UploadDriverDocument 司机上传证件
+// @Summary 司机上传证件
+// @Description 上传驾照、身份证、保险或车辆登记证图片并提交审核。重复上传同类型证件会取代待审核的旧证件
+// @Tags Api,司机
+// @Accept multipart/form-data
+// @Produce json
+// @Param document_type formData string true "证件类型 driver_license/national_id/insurance/vehicle_registration"
+// @Param document_number formData string false "证件号码"
+// @Param expires_at formData string false "过期时间（毫秒时间戳或YYYY-MM-DD）"
+// @Param front formData file true "证件正面"
+// @Param back formData file false "证件背面"
+// @Param selfie formData file false "手持证件自拍"
+// @Success 200 {object} protocol.Result{data=protocol.DriverDocument}
+// @Security BearerAuth
+// @Router /driver/documents/upload [post]
+func (a *Api) UploadDriverDocument(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	user := GetUserFromContext(c)
+	if !user.IsDriver() {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(protocol.PermissionDenied, lang))
+		return
+	}
+
+	docType := c.PostForm("document_type")
+	if !config.Get().KYC.IsSupportedDocument(docType) {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(protocol.InvalidDocumentType, lang))
+		return
+	}
+	expiresAt, ok := parseDocumentExpiry(c.PostForm("expires_at"))
+	if !ok {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(protocol.InvalidParams, lang, "expires_at"))
+		return
+	}
+
+	maxSize := config.Get().KYC.MaxFileSize * 1024 * 1024
+	files := make(map[string]*services.DocumentFile)
+	for _, side := range []string{services.DocumentSideFront, services.DocumentSideBack, services.DocumentSideSelfie} {
+		header, err := c.FormFile(side)
+		if err != nil {
+			continue
+		}
+		ext := strings.ToLower(filepath.Ext(header.Filename))
+		if !documentImageExts[ext] {
+			c.JSON(http.StatusOK, protocol.NewErrorResult(protocol.InvalidContentType, lang))
+			return
+		}
+		if header.Size > maxSize {
+			c.JSON(http.StatusOK, protocol.NewErrorResult(protocol.RequestTooLarge, lang))
+			return
+		}
+		file, err := header.Open()
+		if err != nil {
+			log.Errorf("UploadDriverDocument open %s failed - user_id: %s, error: %v", side, user.UserID, err)
+			c.JSON(http.StatusOK, protocol.NewErrorResult(protocol.FileError, lang))
+			return
+		}
+		defer func(f multipart.File) { _ = f.Close() }(file)
+		files[side] = &services.DocumentFile{Reader: file, Extension: ext}
+	}
+	if files[services.DocumentSideFront] == nil {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(protocol.MissingParams, lang, services.DocumentSideFront))
+		return
+	}
+
+	doc, errCode := services.GetDriverDocumentService().UploadDocument(c.Request.Context(), &services.UploadDocumentRequest{
+		UserID:         user.UserID,
+		DocumentType:   docType,
+		DocumentNumber: strings.TrimSpace(c.PostForm("document_number")),
+		ExpiresAt:      expiresAt,
+		Files:          files,
+	})
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(doc))
+}
+
+// GetDriverDocuments 获取司机证件审核状态
+// @Summary 获取司机证件审核状态
+// @Description 返回各类证件最新记录，以及缺失、待审核、被拒绝、已过期的证件类型
+// @Tags Api,司机
+// @Produce json
+// @Success 200 {object} protocol.Result{data=protocol.DriverDocumentStatus}
+// @Security BearerAuth
+// @Router /driver/documents [get]
+func (a *Api) GetDriverDocuments(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	user := GetUserFromContext(c)
+	if !user.IsDriver() {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(protocol.PermissionDenied, lang))
+		return
+	}
+	status, errCode := services.GetDriverDocumentService().GetDriverDocumentStatus(user.UserID)
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	// 审核备注和审核人仅管理端可见
+	for _, doc := range status.Documents {
+		doc.ReviewComment = ""
+		doc.ReviewedBy = ""
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(status))
+}
+
+// parseDocumentExpiry 解析证件过期时间，支持毫秒时间戳或YYYY-MM-DD
+func parseDocumentExpiry(value string) (int64, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, true
+	}
+	if ms, err := cast.ToInt64E(value); err == nil && ms > 0 {
+		return ms, true
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		// 当天结束前仍有效
+		return t.Add(24*time.Hour - time.Millisecond).UnixMilli(), true
+	}
+	return 0, false
+}
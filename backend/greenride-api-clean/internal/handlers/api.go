@@ -35,8 +35,8 @@ func (a *Api) AuthMiddleware() gin.HandlerFunc {
 				return
 			}
 
-			// 检查用户状态
-			if user.GetStatus() != protocol.StatusActive {
+			// 检查用户状态（因证件过期被停用的司机仍可登录以上传新证件）
+			if user.GetStatus() != protocol.StatusActive && !user.IsSuspendedForDocuments() {
 				c.JSON(http.StatusUnauthorized, protocol.NewAuthErrorResult())
 				c.Abort()
 				return
@@ -98,23 +98,31 @@ func (a *Api) SetupRouter() *gin.Engine {
 	api := router.Group("/")
 	{
 		// 无需认证的路由
-		api.POST("/register", a.Register)
-		api.POST("/login", a.Login)
-		api.POST("/send-verify-code", a.SendVerifyCode)
-		api.POST("/verify-code", a.VerifyCode)
-		api.POST("/reset-password", a.ResetPassword)
-		api.POST("/feedback/submit", a.SubmitFeedback) // 提交反馈 - 无需认证
-		api.GET("/support/config", a.GetSupportConfig) // 获取支持配置 - 无需认证（公共信息）
-		api.GET("/system/config", a.GetSystemConfig)   // 获取系统配置 - 无需认证（维护模式检查）
+		anonymousLimit := middleware.RateLimitMiddleware(config.RateLimitPolicyAnonymous)
+		api.POST("/register", anonymousLimit, a.Register)
+		api.POST("/login", middleware.RateLimitMiddleware(config.RateLimitPolicyLogin), a.Login)
+		api.POST("/send-verify-code", middleware.RateLimitMiddleware(config.RateLimitPolicyOTP), a.SendVerifyCode)
+		api.POST("/verify-code", anonymousLimit, a.VerifyCode)
+		api.POST("/reset-password", anonymousLimit, a.ResetPassword)
+		api.POST("/feedback/submit", middleware.RateLimitMiddleware(config.RateLimitPolicyFeedback), a.SubmitFeedback) // 提交反馈 - 无需认证
+		api.GET("/support/config", anonymousLimit, a.GetSupportConfig)                                                 // 获取支持配置 - 无需认证（公共信息）
+		api.GET("/system/config", anonymousLimit, a.GetSystemConfig)                                                   // 获取系统配置 - 无需认证（维护模式检查）
+
+		api.GET("/share/trip/:token", anonymousLimit, a.GetSharedTrip) // 查看分享的行程 - 无需认证（凭分享令牌）
 
 		// Checkout 状态查询接口
-		api.POST("/checkout/status", a.GetCheckoutStatus) // 查询checkout状态
+		api.POST("/checkout/status", anonymousLimit, a.GetCheckoutStatus) // 查询checkout状态
 
 		// Webhook 回调接口 - 无需认证（第三方支付回调）
-		api.POST("/webhook/kpay/:payment_id", a.KPayWebhook) // KPay 支付回调
-		api.POST("/webhook/momo/:payment_id", a.MoMoWebhook) // MTN MoMo 支付回调
-		api.POST("/webhook/stripe", a.StripeWebhook)         // Stripe 支付回调
-		api.POST("/webhook/innopaas", a.InnoPaaSWebhook)     // InnoPaaS OTP/消息状态回调
+		webhookAPI := api.Group("/webhook")
+		webhookAPI.Use(middleware.RateLimitMiddleware(config.RateLimitPolicyWebhook))
+		{
+			webhookAPI.POST("/kpay/:payment_id", a.KPayWebhook) // KPay 支付回调
+			webhookAPI.POST("/momo/:payment_id", a.MoMoWebhook) // MTN MoMo 支付回调
+			webhookAPI.POST("/stripe", a.StripeWebhook)         // Stripe 支付回调
+			webhookAPI.POST("/innopaas", a.InnoPaaSWebhook)     // InnoPaaS OTP/消息状态回调
+			webhookAPI.POST("/twilio/sms", a.TwilioSMSWebhook)  // Twilio 短信状态回调
+		}
 
 	}
 
@@ -122,6 +130,7 @@ func (a *Api) SetupRouter() *gin.Engine {
 	authRequired := api.Group("")
 	authRequired.Use(middleware.MaintenanceMiddleware()) // 维护模式检查
 	authRequired.Use(a.AuthMiddleware())
+	authRequired.Use(middleware.RateLimitMiddleware(config.RateLimitPolicyUser)) // 按用户限流
 	{
 		authRequired.GET("/profile", a.Profile)
 		authRequired.POST("/logout", a.Logout)
@@ -134,6 +143,16 @@ func (a *Api) SetupRouter() *gin.Engine {
 		authRequired.POST("/profile/update/avatar", a.UpdateAvatar) // 更新用户头像
 		authRequired.POST("/account/delete", a.DeleteAccount)       // 删除账户
 
+		// 常用地点接口
+		authRequired.GET("/places", a.GetSavedPlaces)               // 常用地点列表
+		authRequired.GET("/places/recent", a.GetRecentDestinations) // 最近目的地
+		authRequired.POST("/place/save", a.SavePlace)               // 新增或修改常用地点
+		authRequired.POST("/place/delete", a.DeletePlace)           // 删除常用地点
+
+		// 司机证件接口
+		authRequired.POST("/driver/documents/upload", a.UploadDriverDocument) // 上传证件
+		authRequired.GET("/driver/documents", a.GetDriverDocuments)           // 证件审核状态
+
 		// 订单相关接口 (通用订单系统，支持网约车等多种订单类型)
 		// 订单预估接口
 		authRequired.POST("/order/estimate", a.EstimateOrder) // 预估订单
@@ -148,12 +167,29 @@ func (a *Api) SetupRouter() *gin.Engine {
 		authRequired.POST("/order/start", a.StartOrder)               // 开始订单
 		authRequired.POST("/order/finish", a.FinishOrder)             // 完成订单
 		authRequired.POST("/order/cancel", a.CancelOrder)             // 取消订单
+		authRequired.POST("/order/cancel-fee", a.PreviewCancelOrder)  // 查询取消费
 		authRequired.GET("/order/cancel-reasons", a.GetCancelReasons) // 获取取消原因列表
 		authRequired.POST("/order/rating", a.CreateOrderRating)       // 创建订单评价
 		authRequired.POST("/order/ratings", a.GetOrderRatings)        // 获取订单评价
 		authRequired.POST("/order/contact", a.GetOrderContact)        // 获取订单联系方式（通话权限）
 		authRequired.POST("/order/eta", a.GetOrderETA)                // 获取订单实时ETA
 
+		// 行程途经点接口
+		authRequired.POST("/order/stop/add", a.AddOrderStop)         // 乘客新增途经点
+		authRequired.POST("/order/stop/remove", a.RemoveOrderStop)   // 乘客删除途经点
+		authRequired.POST("/order/stop/arrived", a.ArrivedOrderStop) // 司机到达途经点
+
+		// 拼车接口
+		authRequired.GET("/driver/pool", a.GetDriverPool) // 司机拼车接送计划
+
+		// 包裹配送接口
+		authRequired.POST("/delivery/photo/upload", a.UploadParcelPhoto)         // 寄件人上传包裹照片
+		authRequired.POST("/delivery/code/resend", a.ResendDeliveryCode)         // 寄件人重新发送取件码
+		authRequired.POST("/delivery/arrived-pickup", a.ArrivedDeliveryPickup)   // 司机到达取件点
+		authRequired.POST("/delivery/pickup", a.PickupParcel)                    // 司机取件
+		authRequired.POST("/delivery/arrived-dropoff", a.ArrivedDeliveryDropoff) // 司机到达收件点
+		authRequired.POST("/delivery/complete", a.CompleteDelivery)              // 司机凭取件码或照片签收
+
 		// 服务提供者接口 (司机、外卖员等)
 		authRequired.POST("/nearby", a.GetNearbyOrders)                // 获取附近订单
 		authRequired.POST("/order/nearby", a.GetNearbyOrders)          // 获取附近订单
@@ -161,10 +197,33 @@ func (a *Api) SetupRouter() *gin.Engine {
 		authRequired.POST("/order/cash/received", a.OrderCashReceived) // 确认现金收款
 		authRequired.POST("/order/payment", a.OrderPayment)            // 处理订单支付
 
+		// 行程小费接口
+		authRequired.POST("/order/tip", a.TipOrder)              // 乘客给司机小费
+		authRequired.POST("/order/tip/options", a.GetTipOptions) // 评价页小费选项
+
 		// 支付方式接口
 		authRequired.POST("/payment/methods", a.GetPaymentMethods) // 获取支付方式列表
 		authRequired.POST("/payment/cancel", a.CancelPayment)      // 取消支付
 
+		// 已保存支付方式接口
+		authRequired.GET("/payment/saved-methods", a.GetSavedPaymentMethods)          // 已保存的支付方式列表
+		authRequired.POST("/payment/saved-method/add", a.AddSavedPaymentMethod)       // 添加支付方式并发起验证
+		authRequired.POST("/payment/saved-method/verify", a.VerifySavedPaymentMethod) // 同步或重新发起验证
+		authRequired.POST("/payment/saved-method/default", a.SetDefaultPaymentMethod) // 设置默认支付方式
+		authRequired.POST("/payment/saved-method/remove", a.RemoveSavedPaymentMethod) // 删除支付方式
+
+		// 乘客欠款接口
+		authRequired.GET("/debts", a.GetOutstandingDebts) // 未结清的欠款
+		authRequired.POST("/debt/pay", a.PayDebt)         // 结清欠款
+
+		// 行程安全接口
+		authRequired.POST("/safety/sos", a.TriggerSOS)                      // 紧急求助
+		authRequired.POST("/safety/trip-share", a.CreateTripShare)          // 生成行程分享链接
+		authRequired.POST("/safety/trip-share/revoke", a.RevokeTripShare)   // 撤销行程分享
+		authRequired.GET("/safety/contacts", a.GetTrustedContacts)          // 紧急联系人列表
+		authRequired.POST("/safety/contact/add", a.AddTrustedContact)       // 添加紧急联系人
+		authRequired.POST("/safety/contact/remove", a.RemoveTrustedContact) // 删除紧急联系人
+
 		// 车辆信息接口
 		authRequired.POST("/vehicle", a.GetUserVehicle) // 获取用户车辆信息
 		authRequired.POST("/vehicles", a.GetVehicles)   // 获取车辆列表
@@ -184,6 +243,19 @@ func (a *Api) SetupRouter() *gin.Engine {
 		authRequired.POST("/ads/list", a.GetLocalAdvertisements)      // 获取本地广告列表
 		authRequired.POST("/ads/detail", a.GetLocalAdvertisementByID) // 获取单个广告详情
 		authRequired.POST("/ads/stats", a.UpdateAdvertisementStats)   // 更新广告统计
+
+		// 公告接口
+		authRequired.POST("/announcements/active", a.GetActiveAnnouncements) // 当前生效的公告
+		authRequired.POST("/announcements/read", a.MarkAnnouncementRead)     // 标记已读
+		authRequired.POST("/announcements/confirm", a.ConfirmAnnouncement)   // 确认公告
+
+		// 站内通知
+		authRequired.POST("/notifications", a.GetNotifications)                          // 通知列表
+		authRequired.GET("/notifications/unread-count", a.GetNotificationUnreadCount)    // 未读数
+		authRequired.POST("/notifications/mark-read", a.MarkNotificationsRead)           // 标记已读
+		authRequired.POST("/notifications/delete", a.DeleteNotifications)                // 删除
+		authRequired.GET("/notifications/preferences", a.GetNotificationPreferences)     // 推送偏好
+		authRequired.POST("/notifications/preferences", a.UpdateNotificationPreferences) // 更新推送偏好
 	}
 
 	log.Infof("API router setup completed for service: %s on port %s", a.ServiceConfig.Name, a.ServiceConfig.Port)
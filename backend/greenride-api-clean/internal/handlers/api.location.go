@@ -14,12 +14,15 @@ import (
 // 包含司机位置上报功能
 
 // @Summary 更新司机位置
-// @Description 司机上报当前位置信息
+// @Description 司机上报当前位置信息。开启证件审核（kyc.enforce=on）时，证件未齐全的司机位置仍会保存，
+// @Description 但保持离线并返回 HTTP 200 + 错误码 6020（DriverDocumentsIncomplete），客户端应引导司机补充证件；其他错误返回 HTTP 500
 // @Tags Api,位置
 // @Accept json
 // @Produce json
 // @Param Authorization header string true "Bearer token"
 // @Param request body protocol.UpdateLocationRequest true "位置信息"
+// @Success 200 {object} protocol.Result "成功；或 code=6020 位置已保存但司机证件未齐全、保持离线"
+// @Failure 500 {object} protocol.Result "位置更新失败"
 // @Router /location/update [post]
 func (a *Api) UpdateLocation(c *gin.Context) {
 	lang := middleware.GetLanguageFromContext(c)
@@ -36,6 +39,11 @@ func (a *Api) UpdateLocation(c *gin.Context) {
 
 	// 调用服务更新位置
 	errCode := services.GetUserService().UpdateUserLocation(&req)
+	if errCode == protocol.DriverDocumentsIncomplete {
+		// 位置已记录，但司机证件不齐全，保持离线
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
 	if errCode != protocol.Success {
 		c.JSON(http.StatusInternalServerError, protocol.NewErrorResult(errCode, lang))
 		return
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"net/http"
+
+	"greenride/internal/middleware"
+	"greenride/internal/protocol"
+	"greenride/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetNotifications 获取站内通知列表
+// @Summary 获取站内通知列表
+// @Description 当前用户的通知，新通知在前；可按分类（trip, payment, marketing, system）或只看未读筛选
+// @Tags Api,通知
+// @Accept json
+// @Produce json
+// @Param request body protocol.UserNotificationListRequest true "查询请求"
+// @Success 200 {object} protocol.Result{data=protocol.PageResult}
+// @Security BearerAuth
+// @Router /notifications [post]
+func (a *Api) GetNotifications(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	user := GetUserFromContext(c)
+	var req protocol.UserNotificationListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.Limit <= 0 || req.Limit > 100 {
+		req.Limit = 20
+	}
+
+	list, total, errCode := services.GetUserNotificationService().ListNotifications(user.UserID, &req)
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(protocol.NewPageResult(list, total, &protocol.Pagination{
+		Page: req.Page,
+		Size: req.Limit,
+	})))
+}
+
+// GetNotificationUnreadCount 获取未读通知数
+// @Summary 获取未读通知数
+// @Description 返回未读总数及各分类的未读数
+// @Tags Api,通知
+// @Produce json
+// @Success 200 {object} protocol.Result{data=protocol.NotificationUnreadCount}
+// @Security BearerAuth
+// @Router /notifications/unread-count [get]
+func (a *Api) GetNotificationUnreadCount(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	user := GetUserFromContext(c)
+	count, errCode := services.GetUserNotificationService().GetUnreadCount(user.UserID)
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(count))
+}
+
+// MarkNotificationsRead 标记通知已读
+// @Summary 标记通知已读
+// @Description 不传 notification_ids 时标记全部已读，可用 category 限定分类
+// @Tags Api,通知
+// @Accept json
+// @Produce json
+// @Param request body protocol.UserNotificationActionRequest true "通知ID"
+// @Success 200 {object} protocol.Result
+// @Security BearerAuth
+// @Router /notifications/mark-read [post]
+func (a *Api) MarkNotificationsRead(c *gin.Context) {
+	a.notificationAction(c, services.GetUserNotificationService().MarkRead)
+}
+
+// DeleteNotifications 删除通知
+// @Summary 删除通知
+// @Description 删除指定的通知，notification_ids 必填
+// @Tags Api,通知
+// @Accept json
+// @Produce json
+// @Param request body protocol.UserNotificationActionRequest true "通知ID"
+// @Success 200 {object} protocol.Result
+// @Security BearerAuth
+// @Router /notifications/delete [post]
+func (a *Api) DeleteNotifications(c *gin.Context) {
+	a.notificationAction(c, services.GetUserNotificationService().DeleteNotifications)
+}
+
+func (a *Api) notificationAction(c *gin.Context, action func(userID string, req *protocol.UserNotificationActionRequest) protocol.ErrorCode) {
+	lang := middleware.GetLanguageFromContext(c)
+	user := GetUserFromContext(c)
+	var req protocol.UserNotificationActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	if errCode := action(user.UserID, &req); errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(nil))
+}
+
+// GetNotificationPreferences 获取推送偏好
+// @Summary 获取推送偏好
+// @Description 各分类是否推送，默认全部开启；关闭的分类仍会出现在通知列表中
+// @Tags Api,通知
+// @Produce json
+// @Success 200 {object} protocol.Result{data=protocol.NotificationPreferences}
+// @Security BearerAuth
+// @Router /notifications/preferences [get]
+func (a *Api) GetNotificationPreferences(c *gin.Context) {
+	user := GetUserFromContext(c)
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(services.GetUserNotificationService().GetPreferences(user.UserID)))
+}
+
+// UpdateNotificationPreferences 更新推送偏好
+// @Summary 更新推送偏好
+// @Description 只更新传入的分类；系统类通知不可关闭
+// @Tags Api,通知
+// @Accept json
+// @Produce json
+// @Param request body protocol.UpdateNotificationPreferencesRequest true "推送偏好"
+// @Success 200 {object} protocol.Result{data=protocol.NotificationPreferences}
+// @Security BearerAuth
+// @Router /notifications/preferences [post]
+func (a *Api) UpdateNotificationPreferences(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	user := GetUserFromContext(c)
+	var req protocol.UpdateNotificationPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	prefs, errCode := services.GetUserNotificationService().UpdatePreferences(user.UserID, &req)
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(prefs))
+}
@@ -373,6 +373,121 @@ func (a *Api) CancelOrder(c *gin.Context) {
 	c.JSON(http.StatusOK, protocol.NewSuccessResult(""))
 }
 
+// PreviewCancelOrder 取消订单前查询取消费
+// @Summary 查询取消费
+// @Description 乘客取消前查询需支付的取消费及免费取消截止时间；司机传 reason_key=passenger_not_at_pickup 时校验能否报乘客未到场并返回爽约费
+// @Tags Api,订单
+// @Accept json
+// @Produce json
+// @Param request body protocol.CancelOrderRequest true "取消请求"
+// @Success 200 {object} protocol.Result{data=protocol.CancellationQuote} "取消费"
+// @Security BearerAuth
+// @Router /order/cancel-fee [post]
+func (a *Api) PreviewCancelOrder(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+
+	var req protocol.CancelOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	user := GetUserFromContext(c)
+	req.UserID = user.UserID
+
+	quote, errCode := services.GetCancellationService().PreviewCancellation(&req)
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(quote))
+}
+
+// AddOrderStop 行程中新增途经点
+// @Summary 新增途经点
+// @Description 乘客在司机接单后至行程结束前新增途经点，按新路线重新计价，返回更新后的订单
+// @Tags Api,订单
+// @Accept json
+// @Produce json
+// @Param request body protocol.OrderStopAddRequest true "新增途经点请求"
+// @Success 200 {object} protocol.Result{data=protocol.Order} "更新后的订单"
+// @Security BearerAuth
+// @Router /order/stop/add [post]
+func (a *Api) AddOrderStop(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+
+	var req protocol.OrderStopAddRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	user := GetUserFromContext(c)
+	req.UserID = user.UserID
+
+	order, errCode := services.GetRideStopService().AddStop(&req)
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(order))
+}
+
+// RemoveOrderStop 行程中删除途经点
+// @Summary 删除途经点
+// @Description 乘客删除司机尚未到达的途经点，按新路线重新计价，返回更新后的订单
+// @Tags Api,订单
+// @Accept json
+// @Produce json
+// @Param request body protocol.OrderStopActionRequest true "删除途经点请求"
+// @Success 200 {object} protocol.Result{data=protocol.Order} "更新后的订单"
+// @Security BearerAuth
+// @Router /order/stop/remove [post]
+func (a *Api) RemoveOrderStop(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+
+	var req protocol.OrderStopActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	user := GetUserFromContext(c)
+	req.UserID = user.UserID
+
+	order, errCode := services.GetRideStopService().RemoveStop(&req)
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(order))
+}
+
+// ArrivedOrderStop 司机到达途经点
+// @Summary 到达途经点
+// @Description 司机在行程中标记已到达某个途经点，并记录订单历史
+// @Tags Api,司机
+// @Accept json
+// @Produce json
+// @Param request body protocol.OrderStopActionRequest true "到达途经点请求"
+// @Success 200 {object} protocol.Result "到达成功"
+// @Security BearerAuth
+// @Router /order/stop/arrived [post]
+func (a *Api) ArrivedOrderStop(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+
+	var req protocol.OrderStopActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	user := GetUserFromContext(c)
+	req.UserID = user.UserID
+
+	if errCode := services.GetRideStopService().ArrivedStop(&req); errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(""))
+}
+
 // GetCancelReasons 获取取消原因列表
 // @Summary 获取取消原因列表
 // @Description 根据用户类型返回预定义的取消原因列表
@@ -601,3 +716,23 @@ func (a *Api) OrderPayment(c *gin.Context) {
 
 	c.JSON(http.StatusOK, protocol.NewSuccessResult(result))
 }
+
+// GetDriverPool 获取司机进行中的拼车
+// @Summary 获取拼车接送计划
+// @Description 返回司机进行中的拼车、剩余座位及按顺序排列的上下车计划，乘客加入或取消后计划随之更新；没有拼车时data为空
+// @Tags Api,司机
+// @Produce json
+// @Success 200 {object} protocol.Result{data=protocol.RidePool}
+// @Security BearerAuth
+// @Router /driver/pool [get]
+func (a *Api) GetDriverPool(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	user := GetUserFromContext(c)
+
+	pool, errCode := services.GetRidePoolService().GetDriverPool(user.UserID)
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(pool))
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"net/http"
+
+	"greenride/internal/middleware"
+	"greenride/internal/protocol"
+	"greenride/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetSavedPaymentMethods 获取已保存的支付方式
+// @Summary 获取已保存的支付方式
+// @Description 返回乘客保存的MoMo号码和银行卡，只包含脱敏信息，默认支付方式排在最前
+// @Tags Api,支付
+// @Produce json
+// @Success 200 {object} protocol.Result{data=[]protocol.SavedPaymentMethod}
+// @Security BearerAuth
+// @Router /payment/saved-methods [get]
+func (a *Api) GetSavedPaymentMethods(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+
+	user := GetUserFromContext(c)
+	methods, errCode := services.GetSavedPaymentMethodService().ListPaymentMethods(user.UserID)
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(methods))
+}
+
+// AddSavedPaymentMethod 添加支付方式
+// @Summary 添加支付方式
+// @Description MoMo号码立即发起小额验证扣款，验证成功后金额退回钱包；银行卡返回client_secret，客户端用Stripe SDK确认后调用验证接口
+// @Tags Api,支付
+// @Accept json
+// @Produce json
+// @Param request body protocol.AddPaymentMethodRequest true "支付方式"
+// @Success 200 {object} protocol.Result{data=protocol.SavedPaymentMethod}
+// @Security BearerAuth
+// @Router /payment/saved-method/add [post]
+func (a *Api) AddSavedPaymentMethod(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+
+	var req protocol.AddPaymentMethodRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	user := GetUserFromContext(c)
+	req.UserID = user.UserID
+	req.Language = lang
+	method, errCode := services.GetSavedPaymentMethodService().AddPaymentMethod(&req)
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(method))
+}
+
+// VerifySavedPaymentMethod 验证支付方式
+// @Summary 验证支付方式
+// @Description 待验证时同步MoMo扣款或Stripe SetupIntent结果；验证失败时重新发起验证，银行卡会返回新的client_secret
+// @Tags Api,支付
+// @Accept json
+// @Produce json
+// @Param request body protocol.PaymentMethodIDRequest true "支付方式ID"
+// @Success 200 {object} protocol.Result{data=protocol.SavedPaymentMethod}
+// @Security BearerAuth
+// @Router /payment/saved-method/verify [post]
+func (a *Api) VerifySavedPaymentMethod(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+
+	var req protocol.PaymentMethodIDRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	user := GetUserFromContext(c)
+	req.UserID = user.UserID
+	req.Language = lang
+	method, errCode := services.GetSavedPaymentMethodService().VerifyPaymentMethod(&req)
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(method))
+}
+
+// SetDefaultPaymentMethod 设置默认支付方式
+// @Summary 设置默认支付方式
+// @Description 只有已验证的支付方式可以设为默认，行程结束后自动使用默认支付方式扣款
+// @Tags Api,支付
+// @Accept json
+// @Produce json
+// @Param request body protocol.PaymentMethodIDRequest true "支付方式ID"
+// @Success 200 {object} protocol.Result
+// @Security BearerAuth
+// @Router /payment/saved-method/default [post]
+func (a *Api) SetDefaultPaymentMethod(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+
+	var req protocol.PaymentMethodIDRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	user := GetUserFromContext(c)
+	req.UserID = user.UserID
+	req.Language = lang
+	if errCode := services.GetSavedPaymentMethodService().SetDefaultPaymentMethod(&req); errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(nil))
+}
+
+// RemoveSavedPaymentMethod 删除支付方式
+// @Summary 删除支付方式
+// @Description 银行卡同时从Stripe解绑；删除默认支付方式时改用最近验证的其他支付方式
+// @Tags Api,支付
+// @Accept json
+// @Produce json
+// @Param request body protocol.PaymentMethodIDRequest true "支付方式ID"
+// @Success 200 {object} protocol.Result
+// @Security BearerAuth
+// @Router /payment/saved-method/remove [post]
+func (a *Api) RemoveSavedPaymentMethod(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+
+	var req protocol.PaymentMethodIDRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	user := GetUserFromContext(c)
+	req.UserID = user.UserID
+	req.Language = lang
+	if errCode := services.GetSavedPaymentMethodService().RemovePaymentMethod(&req); errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(nil))
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"net/http"
+
+	"greenride/internal/middleware"
+	"greenride/internal/protocol"
+	"greenride/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetSavedPlaces 获取常用地点
+// @Summary 获取常用地点
+// @Description 返回乘客保存的家、公司和自定义地点，预估和下单时可用地点ID代替坐标
+// @Tags Api,常用地点
+// @Produce json
+// @Success 200 {object} protocol.Result{data=protocol.SavedPlacesResponse}
+// @Security BearerAuth
+// @Router /places [get]
+func (a *Api) GetSavedPlaces(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+
+	user := GetUserFromContext(c)
+	places, errCode := services.GetSavedPlaceService().ListSavedPlaces(user.UserID)
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(places))
+}
+
+// SavePlace 新增或修改常用地点
+// @Summary 保存常用地点
+// @Description 不传place_id时新增；家和公司每个用户只保留一个，重复保存时覆盖原地址；自定义地点须填写名称且有数量上限
+// @Tags Api,常用地点
+// @Accept json
+// @Produce json
+// @Param request body protocol.SavePlaceRequest true "常用地点"
+// @Success 200 {object} protocol.Result{data=protocol.SavedPlace}
+// @Security BearerAuth
+// @Router /place/save [post]
+func (a *Api) SavePlace(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+
+	var req protocol.SavePlaceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	user := GetUserFromContext(c)
+	req.UserID = user.UserID
+	place, errCode := services.GetSavedPlaceService().SavePlace(&req)
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(place))
+}
+
+// DeletePlace 删除常用地点
+// @Summary 删除常用地点
+// @Description 删除后不能再用于预估和下单，历史订单不受影响
+// @Tags Api,常用地点
+// @Accept json
+// @Produce json
+// @Param request body protocol.PlaceIDRequest true "常用地点ID"
+// @Success 200 {object} protocol.Result
+// @Security BearerAuth
+// @Router /place/delete [post]
+func (a *Api) DeletePlace(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+
+	var req protocol.PlaceIDRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	user := GetUserFromContext(c)
+	req.UserID = user.UserID
+	if errCode := services.GetSavedPlaceService().DeletePlace(&req); errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(nil))
+}
+
+// GetRecentDestinations 获取最近目的地
+// @Summary 获取最近目的地
+// @Description 从最近已完成的行程中统计去过的目的地，相近的下车点合并计数，与常用地点重合时返回地点ID
+// @Tags Api,常用地点
+// @Produce json
+// @Success 200 {object} protocol.Result{data=[]protocol.RecentDestination}
+// @Security BearerAuth
+// @Router /places/recent [get]
+func (a *Api) GetRecentDestinations(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+
+	user := GetUserFromContext(c)
+	destinations, errCode := services.GetSavedPlaceService().GetRecentDestinations(user.UserID)
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(destinations))
+}
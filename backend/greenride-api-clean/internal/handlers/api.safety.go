@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"net/http"
+
+	"greenride/internal/middleware"
+	"greenride/internal/protocol"
+	"greenride/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TriggerSOS 紧急求助
+// @Summary 紧急求助
+// @Description 记录高优先级安全事件（当前位置和订单快照），通知管理员并短信通知响应人员和紧急联系人；行程进行中时返回限时分享链接
+// @Tags Api,安全
+// @Accept json
+// @Produce json
+// @Param request body protocol.SOSRequest true "求助位置及说明，未指定订单时使用当前进行中的订单"
+// @Success 200 {object} protocol.Result{data=protocol.SOSResult}
+// @Security BearerAuth
+// @Router /safety/sos [post]
+func (a *Api) TriggerSOS(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+
+	var req protocol.SOSRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	user := GetUserFromContext(c)
+	req.UserID = user.UserID
+	req.UserType = user.GetUserType()
+	req.Language = lang
+	result, errCode := services.GetSafetyService().TriggerSOS(&req)
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(result))
+}
+
+// CreateTripShare 分享行程
+// @Summary 分享行程
+// @Description 为进行中的行程生成限时公开链接，可查看司机实时位置和车辆信息；notify_contacts=true 时短信发送给紧急联系人
+// @Tags Api,安全
+// @Accept json
+// @Produce json
+// @Param request body protocol.TripShareRequest true "订单ID"
+// @Success 200 {object} protocol.Result{data=protocol.TripShare}
+// @Security BearerAuth
+// @Router /safety/trip-share [post]
+func (a *Api) CreateTripShare(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+
+	var req protocol.TripShareRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	user := GetUserFromContext(c)
+	req.UserID = user.UserID
+	req.Language = lang
+	share, errCode := services.GetSafetyService().CreateTripShare(&req)
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(share))
+}
+
+// RevokeTripShare 撤销行程分享
+// @Summary 撤销行程分享
+// @Tags Api,安全
+// @Accept json
+// @Produce json
+// @Param request body protocol.TripShareIDRequest true "分享ID"
+// @Success 200 {object} protocol.Result
+// @Security BearerAuth
+// @Router /safety/trip-share/revoke [post]
+func (a *Api) RevokeTripShare(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+
+	var req protocol.TripShareIDRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	req.UserID = GetUserFromContext(c).UserID
+	if errCode := services.GetSafetyService().RevokeTripShare(&req); errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(nil))
+}
+
+// GetSharedTrip 查看分享的行程
+// @Summary 查看分享的行程
+// @Description 公开接口，凭分享链接令牌查看行程状态、司机实时位置和车辆信息，不返回手机号；链接过期或撤销后不可访问
+// @Tags Api,安全
+// @Produce json
+// @Param token path string true "分享令牌"
+// @Success 200 {object} protocol.Result{data=protocol.SharedTrip}
+// @Router /share/trip/{token} [get]
+func (a *Api) GetSharedTrip(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+
+	trip, errCode := services.GetSafetyService().GetSharedTrip(c.Param("token"))
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(trip))
+}
+
+// GetTrustedContacts 获取紧急联系人
+// @Summary 获取紧急联系人
+// @Tags Api,安全
+// @Produce json
+// @Success 200 {object} protocol.Result{data=[]protocol.TrustedContact}
+// @Security BearerAuth
+// @Router /safety/contacts [get]
+func (a *Api) GetTrustedContacts(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+
+	contacts, errCode := services.GetSafetyService().ListTrustedContacts(GetUserFromContext(c).UserID)
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(contacts))
+}
+
+// AddTrustedContact 添加紧急联系人
+// @Summary 添加紧急联系人
+// @Description 紧急求助时短信通知紧急联系人，分享行程时可选发送分享链接
+// @Tags Api,安全
+// @Accept json
+// @Produce json
+// @Param request body protocol.TrustedContactRequest true "联系人"
+// @Success 200 {object} protocol.Result{data=protocol.TrustedContact}
+// @Security BearerAuth
+// @Router /safety/contact/add [post]
+func (a *Api) AddTrustedContact(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+
+	var req protocol.TrustedContactRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	req.UserID = GetUserFromContext(c).UserID
+	contact, errCode := services.GetSafetyService().AddTrustedContact(&req)
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(contact))
+}
+
+// RemoveTrustedContact 删除紧急联系人
+// @Summary 删除紧急联系人
+// @Tags Api,安全
+// @Accept json
+// @Produce json
+// @Param request body protocol.TrustedContactIDRequest true "联系人ID"
+// @Success 200 {object} protocol.Result
+// @Security BearerAuth
+// @Router /safety/contact/remove [post]
+func (a *Api) RemoveTrustedContact(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+
+	var req protocol.TrustedContactIDRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	req.UserID = GetUserFromContext(c).UserID
+	if errCode := services.GetSafetyService().RemoveTrustedContact(&req); errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(nil))
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"net/http"
+
+	"greenride/internal/middleware"
+	"greenride/internal/protocol"
+	"greenride/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetTipOptions 获取行程小费选项
+// @Summary 获取小费选项
+// @Description 评价页展示：是否可以给小费、截止时间、快捷金额、是否可用现金以及已给的小费
+// @Tags Api,订单
+// @Accept json
+// @Produce json
+// @Param request body protocol.OrderIDRequest true "订单ID"
+// @Success 200 {object} protocol.Result{data=protocol.TipOptions}
+// @Security BearerAuth
+// @Router /order/tip/options [post]
+func (a *Api) GetTipOptions(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+
+	var req protocol.OrderIDRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	user := GetUserFromContext(c)
+	req.UserID = user.UserID
+	options, errCode := services.GetTipService().GetTipOptions(&req)
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(options))
+}
+
+// TipOrder 乘客给司机小费
+// @Summary 给小费
+// @Description 行程结束后的时限内给司机小费，走与订单相同的支付渠道；选择现金时需在车费以现金结清前发起，由司机一并收取。小费全额归司机，不计入平台抽成
+// @Tags Api,订单,支付
+// @Accept json
+// @Produce json
+// @Param request body protocol.OrderTipRequest true "小费请求"
+// @Success 200 {object} protocol.Result{data=protocol.Tip}
+// @Security BearerAuth
+// @Router /order/tip [post]
+func (a *Api) TipOrder(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+
+	var req protocol.OrderTipRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, protocol.NewErrorResult(protocol.InvalidJSON, lang, err.Error()))
+		return
+	}
+	user := GetUserFromContext(c)
+	req.UserID = user.UserID
+	req.Language = lang
+	tip, errCode := services.GetTipService().CreateTip(&req)
+	if errCode != protocol.Success {
+		c.JSON(http.StatusOK, protocol.NewErrorResult(errCode, lang))
+		return
+	}
+	c.JSON(http.StatusOK, protocol.NewSuccessResult(tip))
+}
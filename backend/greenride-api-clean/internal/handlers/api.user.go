@@ -47,6 +47,7 @@ func (a *Api) Profile(c *gin.Context) {
 // UserOnline 司机上线
 // @Summary 司机上线
 // @Description 司机上线，只有司机类型用户可以调用此接口。上线后司机状态变为在线，车辆状态变为可用
+// @Description 开启证件审核（kyc.enforce=on）时，必需证件未全部审核通过或已过期的司机返回错误码 6020（DriverDocumentsIncomplete）
 // @Tags Api,司机
 // @Accept json
 // @Produce json
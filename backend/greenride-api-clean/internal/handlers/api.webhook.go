@@ -218,6 +218,38 @@ func (a *Api) InnoPaaSWebhook(c *gin.Context) {
 	_ = c.ShouldBindJSON(&body)
 	if body != nil {
 		log.Get().Infof("InnoPaaS webhook: %s", body.ToJson())
+		// 回执字段可能在顶层，也可能包在data中
+		receipt := body
+		if data := body.GetMapData("data"); len(data) > 0 {
+			receipt = data
+		}
+		messageID := receipt.FirstVal([]string{"messageId", "msgId", "message_id"})
+		status := receipt.FirstVal([]string{"status", "state", "deliveryStatus"})
+		errMsg := receipt.FirstVal([]string{"errorMessage", "errorCode", "error"})
+		if messageID != "" && status != "" {
+			if err := services.GetSMSRouter().HandleReceipt("innopaas", messageID, status, errMsg); err != nil {
+				log.Get().Warnf("InnoPaaS webhook: handle receipt %s failed: %v", messageID, err)
+			}
+		}
+	}
+	c.Status(http.StatusOK)
+}
+
+// TwilioSMSWebhook handles Twilio SMS status callbacks
+// @Summary Handle Twilio SMS status callback
+// @Description Receives Twilio message status callbacks and updates SMS delivery records
+// @Tags Api,Webhook
+// @Accept x-www-form-urlencoded
+// @Success 200 "OK"
+// @Router /webhook/twilio/sms [post]
+func (a *Api) TwilioSMSWebhook(c *gin.Context) {
+	messageSid := c.PostForm("MessageSid")
+	status := c.PostForm("MessageStatus")
+	errMsg := c.PostForm("ErrorCode")
+	if messageSid != "" {
+		if err := services.GetSMSRouter().HandleReceipt("twilio", messageSid, status, errMsg); err != nil {
+			log.Get().Warnf("Twilio SMS webhook: handle receipt %s failed: %v", messageSid, err)
+		}
 	}
 	c.Status(http.StatusOK)
 }
@@ -27,6 +27,20 @@ const (
 // DefaultLanguage 默认语言
 const DefaultLanguage = LanguageEnglish
 
+// 翻译命名空间，对应语言目录下的文件
+const (
+	NamespaceCommon = "common" // common.json 通用消息
+	NamespaceErrors = "errors" // errors.json 错误码消息
+)
+
+// Fallback 一次翻译降级：请求的语言缺少该key，改用默认语言或直接返回了key
+type Fallback struct {
+	Namespace      string
+	Language       string // 请求的语言
+	Key            string
+	ServedLanguage string // 实际使用的语言，为空表示所有语言都没有，返回了原始key
+}
+
 // ProjectSupportedLanguages 项目实际支持的语言列表
 var ProjectSupportedLanguages = []string{
 	LanguageEnglish,     // en - 英语
@@ -69,6 +83,9 @@ type FileTranslator struct {
 	supportedLangs    []string
 	translations      map[string]map[string]string // [language][key]message
 	errorTranslations map[string]map[string]string // [language][code]message
+
+	fallbackMu sync.Mutex
+	fallbacks  map[Fallback]int64 // 上次取出后各降级发生的次数
 }
 
 // NewFileTranslator 创建基于文件的翻译器
@@ -78,6 +95,7 @@ func NewFileTranslator() *FileTranslator {
 		supportedLangs:    []string{LanguageEnglish, LanguageChinese, LanguageFrench, LanguageKinyarwanda},
 		translations:      make(map[string]map[string]string),
 		errorTranslations: make(map[string]map[string]string),
+		fallbacks:         make(map[Fallback]int64),
 	}
 }
 
@@ -97,20 +115,73 @@ func (ft *FileTranslator) GetSupportedLanguages() []string {
 
 // LoadTranslations 从指定目录加载翻译文件
 func (ft *FileTranslator) LoadTranslations(localesDir string) error {
+	translations, errorTranslations, err := ReadLocaleFiles(localesDir)
+	if err != nil {
+		return err
+	}
+
+	ft.mu.Lock()
+	defer ft.mu.Unlock()
+	mergeTranslations(ft.translations, translations)
+	mergeTranslations(ft.errorTranslations, errorTranslations)
+	return nil
+}
+
+// ReplaceTranslations 整体替换翻译内容，用于从数据库热加载
+func (ft *FileTranslator) ReplaceTranslations(translations, errorTranslations map[string]map[string]string) {
 	ft.mu.Lock()
 	defer ft.mu.Unlock()
+	ft.translations = make(map[string]map[string]string)
+	ft.errorTranslations = make(map[string]map[string]string)
+	mergeTranslations(ft.translations, translations)
+	mergeTranslations(ft.errorTranslations, errorTranslations)
+}
+
+// Snapshot 返回当前翻译内容的副本
+func (ft *FileTranslator) Snapshot() (translations, errorTranslations map[string]map[string]string) {
+	ft.mu.RLock()
+	defer ft.mu.RUnlock()
+	translations = make(map[string]map[string]string)
+	errorTranslations = make(map[string]map[string]string)
+	mergeTranslations(translations, ft.translations)
+	mergeTranslations(errorTranslations, ft.errorTranslations)
+	return translations, errorTranslations
+}
+
+// DrainFallbacks 取出并清空上次取出以来记录的降级
+func (ft *FileTranslator) DrainFallbacks() map[Fallback]int64 {
+	ft.fallbackMu.Lock()
+	defer ft.fallbackMu.Unlock()
+	fallbacks := ft.fallbacks
+	ft.fallbacks = make(map[Fallback]int64)
+	return fallbacks
+}
+
+// recordFallback 记录降级，只记录已有翻译的语言；调用方需持有读锁
+func (ft *FileTranslator) recordFallback(namespace, lang, key, servedLang string) {
+	if ft.translations[lang] == nil && ft.errorTranslations[lang] == nil {
+		return
+	}
+	ft.fallbackMu.Lock()
+	ft.fallbacks[Fallback{Namespace: namespace, Language: lang, Key: key, ServedLanguage: servedLang}]++
+	ft.fallbackMu.Unlock()
+}
 
+// ReadLocaleFiles 读取语言目录下的 common.json 和 errors.json
+func ReadLocaleFiles(localesDir string) (translations, errorTranslations map[string]map[string]string, err error) {
 	// 检查目录是否存在
 	if _, err := os.Stat(localesDir); os.IsNotExist(err) {
-		return fmt.Errorf("locales directory does not exist: %s", localesDir)
+		return nil, nil, fmt.Errorf("locales directory does not exist: %s", localesDir)
 	}
 
 	// 遍历语言目录
 	entries, err := os.ReadDir(localesDir)
 	if err != nil {
-		return fmt.Errorf("failed to read locales directory: %w", err)
+		return nil, nil, fmt.Errorf("failed to read locales directory: %w", err)
 	}
 
+	translations = make(map[string]map[string]string)
+	errorTranslations = make(map[string]map[string]string)
 	for _, entry := range entries {
 		if !entry.IsDir() {
 			continue
@@ -120,78 +191,56 @@ func (ft *FileTranslator) LoadTranslations(localesDir string) error {
 		langDir := filepath.Join(localesDir, lang)
 
 		// 加载通用翻译
-		if err := ft.loadGeneralTranslations(lang, langDir); err != nil {
-			return fmt.Errorf("failed to load general translations for %s: %w", lang, err)
+		general, err := readLocaleFile(filepath.Join(langDir, NamespaceCommon+".json"))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load general translations for %s: %w", lang, err)
+		}
+		if general != nil {
+			translations[lang] = general
 		}
 
 		// 加载错误码翻译
-		if err := ft.loadErrorTranslations(lang, langDir); err != nil {
-			return fmt.Errorf("failed to load error translations for %s: %w", lang, err)
+		errorMessages, err := readLocaleFile(filepath.Join(langDir, NamespaceErrors+".json"))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load error translations for %s: %w", lang, err)
+		}
+		if errorMessages != nil {
+			errorTranslations[lang] = errorMessages
 		}
 	}
 
-	return nil
+	return translations, errorTranslations, nil
 }
 
-// loadGeneralTranslations 加载通用翻译
-func (ft *FileTranslator) loadGeneralTranslations(lang, langDir string) error {
-	filePath := filepath.Join(langDir, "common.json")
-
+// readLocaleFile 读取单个翻译文件，文件不存在时返回nil
+func readLocaleFile(filePath string) (map[string]string, error) {
 	// 如果文件不存在，跳过
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		return nil
+		return nil, nil
 	}
 
 	data, err := os.ReadFile(filePath)
 	if err != nil {
-		return err
-	}
-
-	var translations map[string]string
-	if err := json.Unmarshal(data, &translations); err != nil {
-		return err
-	}
-
-	if ft.translations[lang] == nil {
-		ft.translations[lang] = make(map[string]string)
+		return nil, err
 	}
 
-	for key, value := range translations {
-		ft.translations[lang][key] = value
+	var messages map[string]string
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return nil, err
 	}
-
-	return nil
+	return messages, nil
 }
 
-// loadErrorTranslations 加载错误码翻译
-func (ft *FileTranslator) loadErrorTranslations(lang, langDir string) error {
-	filePath := filepath.Join(langDir, "errors.json")
-
-	// 如果文件不存在，跳过
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		return nil
-	}
-
-	data, err := os.ReadFile(filePath)
-	if err != nil {
-		return err
-	}
-
-	var errorMessages map[string]string
-	if err := json.Unmarshal(data, &errorMessages); err != nil {
-		return err
-	}
-
-	if ft.errorTranslations[lang] == nil {
-		ft.errorTranslations[lang] = make(map[string]string)
-	}
-
-	// 直接存储code字符串到消息的映射
-	for codeStr, message := range errorMessages {
-		ft.errorTranslations[lang][codeStr] = message
+// mergeTranslations 将 src 的内容合并到 dst
+func mergeTranslations(dst, src map[string]map[string]string) {
+	for lang, messages := range src {
+		if dst[lang] == nil {
+			dst[lang] = make(map[string]string, len(messages))
+		}
+		for key, value := range messages {
+			dst[lang][key] = value
+		}
 	}
-
-	return nil
 }
 
 // Translate 翻译错误码
@@ -216,6 +265,7 @@ func (ft *FileTranslator) Translate(code string, lang string, args ...interface{
 	if normalizedLang != ft.defaultLanguage {
 		if errorTranslations, exists := ft.errorTranslations[ft.defaultLanguage]; exists {
 			if message, found := errorTranslations[code]; found {
+				ft.recordFallback(NamespaceErrors, normalizedLang, code, ft.defaultLanguage)
 				if len(args) > 0 {
 					return ft.safeFormat(message, args...)
 				}
@@ -226,6 +276,7 @@ func (ft *FileTranslator) Translate(code string, lang string, args ...interface{
 
 	// Log if translation is missing - helps diagnose issues like 7102 showing as message
 	fmt.Printf("Translation missing for code: %s, language: %s\n", code, lang)
+	ft.recordFallback(NamespaceErrors, normalizedLang, code, "")
 
 	// 如果找不到翻译，返回原始错误码作为后备
 	if len(args) > 0 {
@@ -256,6 +307,7 @@ func (ft *FileTranslator) TranslateMessage(key string, lang string, args ...inte
 	if normalizedLang != ft.defaultLanguage {
 		if translations, exists := ft.translations[ft.defaultLanguage]; exists {
 			if message, found := translations[key]; found {
+				ft.recordFallback(NamespaceCommon, normalizedLang, key, ft.defaultLanguage)
 				if len(args) > 0 {
 					return ft.safeFormat(message, args...)
 				}
@@ -265,6 +317,7 @@ func (ft *FileTranslator) TranslateMessage(key string, lang string, args ...inte
 	}
 
 	// 返回原始key
+	ft.recordFallback(NamespaceCommon, normalizedLang, key, "")
 	if len(args) > 0 {
 		return ft.safeFormat(key, args...)
 	}
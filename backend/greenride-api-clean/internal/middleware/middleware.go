@@ -116,15 +116,6 @@ func RoleMiddleware(allowedRoles ...string) gin.HandlerFunc {
 	}
 }
 
-// RateLimitMiddleware 简单的速率限制中间件（生产环境建议使用Redis实现）
-func RateLimitMiddleware() gin.HandlerFunc {
-	// 这里可以集成第三方限流库，如golang.org/x/time/rate
-	return func(c *gin.Context) {
-		// 简单示例，实际应该根据IP或用户进行限制
-		c.Next()
-	}
-}
-
 // RequireUserType 用户类型验证中间件
 func RequireUserType(userTypes ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
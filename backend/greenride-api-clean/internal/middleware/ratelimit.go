@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"greenride/internal/config"
+	"greenride/internal/protocol"
+	"greenride/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+)
+
+// 限流响应头
+const (
+	HeaderRateLimitLimit     = "X-RateLimit-Limit"
+	HeaderRateLimitRemaining = "X-RateLimit-Remaining"
+	HeaderRateLimitReset     = "X-RateLimit-Reset"
+	HeaderRetryAfter         = "Retry-After"
+)
+
+// RateLimitMiddleware 基于Redis滑动窗口的限流中间件，按策略对IP或用户限流
+// 按用户限流的策略需放在认证中间件之后，未认证时退化为按IP限流
+func RateLimitMiddleware(policyName string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		decision := services.GetRateLimitService().Allow(policyName, rateLimitIdentity(c, policyName))
+		if decision == nil {
+			c.Next()
+			return
+		}
+
+		c.Header(HeaderRateLimitLimit, strconv.Itoa(decision.Limit))
+		c.Header(HeaderRateLimitRemaining, strconv.Itoa(decision.Remaining))
+		c.Header(HeaderRateLimitReset, strconv.FormatInt(decision.ResetAt.Unix(), 10))
+
+		if !decision.Allowed {
+			c.Header(HeaderRetryAfter, strconv.FormatInt(decision.RetryAfter(), 10))
+			c.JSON(http.StatusTooManyRequests, protocol.NewErrorResult(protocol.RateLimitExceeded, GetLanguageFromContext(c)))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// rateLimitIdentity 获取限流身份标识
+func rateLimitIdentity(c *gin.Context, policyName string) string {
+	if policy := config.Get().RateLimit.GetPolicy(policyName); policy != nil && policy.By == config.RateLimitByUser {
+		if userID, exists := c.Get("user_id"); exists {
+			if id := cast.ToString(userID); id != "" {
+				return config.RateLimitByUser + ":" + id
+			}
+		}
+	}
+	return config.RateLimitByIP + ":" + c.ClientIP()
+}
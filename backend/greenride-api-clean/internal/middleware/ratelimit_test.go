@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"greenride/internal/config"
+	"greenride/internal/models"
+	"greenride/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// countingWindow 按key计数的固定额度窗口，down=true 时模拟Redis不可用
+type countingWindow struct {
+	down  bool
+	count map[string]int
+}
+
+func (w *countingWindow) allow(key string, limit int, window time.Duration) *models.SlidingWindowResult {
+	resetAt := time.Now().Add(window)
+	if w.down {
+		return &models.SlidingWindowResult{Allowed: true, ResetAt: resetAt}
+	}
+	if w.count[key] >= limit {
+		return &models.SlidingWindowResult{Allowed: false, Count: int64(w.count[key]), ResetAt: resetAt, Available: true}
+	}
+	w.count[key]++
+	return &models.SlidingWindowResult{Allowed: true, Count: int64(w.count[key]), ResetAt: resetAt, Available: true}
+}
+
+func setupRateLimitRouter(t *testing.T, window *countingWindow) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	previousConfig := config.Get()
+	previousService := services.GetRateLimitService()
+	t.Cleanup(func() {
+		config.Set(previousConfig)
+		services.SetupRateLimitService(previousService)
+	})
+
+	rateLimit := &config.RateLimitConfig{Policies: map[string]*config.RateLimitPolicy{
+		config.RateLimitPolicyLogin: {Limit: 2, Window: 60, By: config.RateLimitByIP},
+		config.RateLimitPolicyUser:  {Limit: 1, Window: 60, By: config.RateLimitByUser},
+	}}
+	rateLimit.Validate()
+	config.Set(&config.Config{Log: &config.LogConfig{Path: os.TempDir()}, RateLimit: rateLimit})
+	services.SetupRateLimitService(services.NewRateLimitService(window.allow))
+
+	router := gin.New()
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.POST("/login", RateLimitMiddleware(config.RateLimitPolicyLogin), ok)
+	router.GET("/profile", func(c *gin.Context) {
+		if userID := c.GetHeader("X-Test-User"); userID != "" {
+			c.Set("user_id", userID)
+		}
+	}, RateLimitMiddleware(config.RateLimitPolicyUser), ok)
+	return router
+}
+
+func doRateLimitRequest(router *gin.Engine, method, path, ip, userID string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.RemoteAddr = ip + ":12345"
+	if userID != "" {
+		req.Header.Set("X-Test-User", userID)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	router := setupRateLimitRouter(t, &countingWindow{count: make(map[string]int)})
+
+	cases := []struct {
+		name          string
+		method, path  string
+		ip, userID    string
+		wantStatus    int
+		wantRemaining string
+	}{
+		{"first login", http.MethodPost, "/login", "10.0.0.1", "", http.StatusOK, "1"},
+		{"second login", http.MethodPost, "/login", "10.0.0.1", "", http.StatusOK, "0"},
+		{"third login blocked", http.MethodPost, "/login", "10.0.0.1", "", http.StatusTooManyRequests, "0"},
+		{"other ip allowed", http.MethodPost, "/login", "10.0.0.2", "", http.StatusOK, "1"},
+		{"user allowed", http.MethodGet, "/profile", "10.0.0.1", "U1", http.StatusOK, "0"},
+		{"same user other ip blocked", http.MethodGet, "/profile", "10.0.0.9", "U1", http.StatusTooManyRequests, "0"},
+		{"other user same ip allowed", http.MethodGet, "/profile", "10.0.0.9", "U2", http.StatusOK, "0"},
+	}
+	for _, tc := range cases {
+		w := doRateLimitRequest(router, tc.method, tc.path, tc.ip, tc.userID)
+		if w.Code != tc.wantStatus {
+			t.Errorf("%s: status = %d, want %d", tc.name, w.Code, tc.wantStatus)
+		}
+		if got := w.Header().Get(HeaderRateLimitRemaining); got != tc.wantRemaining {
+			t.Errorf("%s: remaining = %q, want %q", tc.name, got, tc.wantRemaining)
+		}
+		if tc.wantStatus == http.StatusTooManyRequests && w.Header().Get(HeaderRetryAfter) == "" {
+			t.Errorf("%s: missing %s header", tc.name, HeaderRetryAfter)
+		}
+	}
+}
+
+func TestRateLimitMiddlewareFailOpen(t *testing.T) {
+	router := setupRateLimitRouter(t, &countingWindow{down: true, count: make(map[string]int)})
+	for i := 0; i < 5; i++ {
+		if w := doRateLimitRequest(router, http.MethodPost, "/login", "10.0.0.1", ""); w.Code != http.StatusOK {
+			t.Fatalf("request %d: status = %d, want 200 when redis is unavailable", i, w.Code)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"greenride/internal/utils"
+
+	"gorm.io/gorm/clause"
+)
+
+// AnnouncementRead 公告阅读记录表 - 每个用户每条公告一条，记录阅读和确认时间
+type AnnouncementRead struct {
+	ID             int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	AnnouncementID string `json:"announcement_id" gorm:"column:announcement_id;type:varchar(64);uniqueIndex:idx_announcement_user"`
+	UserID         string `json:"user_id" gorm:"column:user_id;type:varchar(64);uniqueIndex:idx_announcement_user;index"`
+	ReadAt         int64  `json:"read_at" gorm:"column:read_at"`
+	ConfirmedAt    *int64 `json:"confirmed_at" gorm:"column:confirmed_at"`
+	CreatedAt      int64  `json:"created_at" gorm:"column:created_at;autoCreateTime:milli"`
+}
+
+func (AnnouncementRead) TableName() string {
+	return "t_announcement_reads"
+}
+
+// IsConfirmed 是否已确认
+func (r *AnnouncementRead) IsConfirmed() bool {
+	return r.ConfirmedAt != nil && *r.ConfirmedAt > 0
+}
+
+// MarkAnnouncementRead 记录阅读，返回是否首次阅读
+func MarkAnnouncementRead(announcementID, userID string) (bool, error) {
+	record := &AnnouncementRead{
+		AnnouncementID: announcementID,
+		UserID:         userID,
+		ReadAt:         utils.TimeNowMilli(),
+	}
+	result := GetDB().Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+	return result.RowsAffected > 0, result.Error
+}
+
+// MarkAnnouncementConfirmed 记录确认（未读时一并记为已读），返回是否首次确认
+func MarkAnnouncementConfirmed(announcementID, userID string) (firstRead, firstConfirm bool, err error) {
+	if firstRead, err = MarkAnnouncementRead(announcementID, userID); err != nil {
+		return false, false, err
+	}
+	result := GetDB().Model(&AnnouncementRead{}).
+		Where("announcement_id = ? AND user_id = ? AND confirmed_at IS NULL", announcementID, userID).
+		Update("confirmed_at", utils.TimeNowMilli())
+	return firstRead, result.RowsAffected > 0, result.Error
+}
+
+// GetAnnouncementReads 批量获取用户的公告阅读记录，按公告ID索引
+func GetAnnouncementReads(userID string, announcementIDs []string) map[string]*AnnouncementRead {
+	result := make(map[string]*AnnouncementRead)
+	if len(announcementIDs) == 0 {
+		return result
+	}
+	var reads []*AnnouncementRead
+	GetDB().Where("user_id = ? AND announcement_id IN ?", userID, announcementIDs).Find(&reads)
+	for _, read := range reads {
+		result[read.AnnouncementID] = read
+	}
+	return result
+}
@@ -1,8 +1,11 @@
 package models
 
 import (
+	"greenride/internal/protocol"
 	"greenride/internal/utils"
 	"strings"
+
+	"gorm.io/gorm"
 )
 
 // Announcement 公告表 - 系统公告和通知管理
@@ -93,6 +96,12 @@ type AnnouncementValues struct {
 	SMSTemplate *string `json:"sms_template" gorm:"column:sms_template;type:varchar(100)"` // 短信模板
 	SMSTime     *int64  `json:"sms_time" gorm:"column:sms_time"`                           // 短信发送时间
 
+	// 分发进度（推送/邮件按用户主键分批发送，可中断续发）
+	DeliveryStatus      *string `json:"delivery_status" gorm:"column:delivery_status;type:varchar(20);index;default:'none'"` // none, pending, running, completed
+	DeliveryCursor      *int64  `json:"delivery_cursor" gorm:"column:delivery_cursor;default:0"`                             // 已处理到的用户主键
+	DeliveryStartedAt   *int64  `json:"delivery_started_at" gorm:"column:delivery_started_at"`                               // 开始分发时间
+	DeliveryCompletedAt *int64  `json:"delivery_completed_at" gorm:"column:delivery_completed_at"`                           // 分发完成时间
+
 	// 状态管理
 	Status      *string `json:"status" gorm:"column:status;type:varchar(30);index;default:'draft'"` // draft, published, scheduled, paused, expired, deleted
 	IsActive    *bool   `json:"is_active" gorm:"column:is_active;default:false"`                    // 是否激活
@@ -116,6 +125,7 @@ type AnnouncementValues struct {
 	EmailSentCount *int `json:"email_sent_count" gorm:"column:email_sent_count;type:int;default:0"` // 邮件发送数量
 	EmailOpenCount *int `json:"email_open_count" gorm:"column:email_open_count;type:int;default:0"` // 邮件打开数量
 	SMSSentCount   *int `json:"sms_sent_count" gorm:"column:sms_sent_count;type:int;default:0"`     // 短信发送数量
+	ConfirmCount   *int `json:"confirm_count" gorm:"column:confirm_count;type:int;default:0"`       // 确认人数
 
 	// 成功率统计
 	ClickRate      *float64 `json:"click_rate" gorm:"column:click_rate;type:decimal(5,2);default:0.00"`           // 点击率
@@ -138,6 +148,7 @@ type AnnouncementValues struct {
 
 	// 创建者信息
 	CreatedBy    *string `json:"created_by" gorm:"column:created_by;type:varchar(100)"`       // 创建者
+	UpdatedBy    *string `json:"updated_by" gorm:"column:updated_by;type:varchar(100)"`       // 最后修改者
 	CreatorType  *string `json:"creator_type" gorm:"column:creator_type;type:varchar(30)"`    // admin, system, auto
 	CreatorID    *string `json:"creator_id" gorm:"column:creator_id;type:varchar(100)"`       // 创建者ID
 	DepartmentID *string `json:"department_id" gorm:"column:department_id;type:varchar(100)"` // 部门ID
@@ -268,6 +279,9 @@ func NewAnnouncementV2() *Announcement {
 			EmailSentCount:  utils.IntPtr(0),
 			EmailOpenCount:  utils.IntPtr(0),
 			SMSSentCount:    utils.IntPtr(0),
+			ConfirmCount:    utils.IntPtr(0),
+			DeliveryStatus:  utils.StringPtr(protocol.AnnouncementDeliveryNone),
+			DeliveryCursor:  utils.Int64Ptr(0),
 			ClickRate:       utils.Float64Ptr(0.00),
 			ShareRate:       utils.Float64Ptr(0.00),
 			PushOpenRate:    utils.Float64Ptr(0.00),
@@ -594,7 +608,7 @@ func (a *Announcement) IsDraft() bool {
 }
 
 func (a *Announcement) IsExpired() bool {
-	if a.AnnouncementValues.EndTime == nil {
+	if a.AnnouncementValues.EndTime == nil || *a.AnnouncementValues.EndTime == 0 {
 		return false
 	}
 	return *a.AnnouncementValues.EndTime < utils.TimeNowMilli()
@@ -609,7 +623,7 @@ func (a *Announcement) IsTimeValid() bool {
 	}
 
 	// 检查结束时间
-	if a.AnnouncementValues.EndTime != nil && now > *a.AnnouncementValues.EndTime {
+	if a.AnnouncementValues.EndTime != nil && *a.AnnouncementValues.EndTime > 0 && now > *a.AnnouncementValues.EndTime {
 		return false
 	}
 
@@ -1108,3 +1122,390 @@ func NewSecurityAnnouncement(title, content string) *Announcement {
 
 	return announcement
 }
+
+// 发布与分发相关 Getter
+func (a *AnnouncementValues) GetPublishTime() int64 {
+	if a.PublishTime == nil {
+		return 0
+	}
+	return *a.PublishTime
+}
+
+func (a *AnnouncementValues) GetStartTime() int64 {
+	if a.StartTime == nil {
+		return 0
+	}
+	return *a.StartTime
+}
+
+func (a *AnnouncementValues) GetEndTime() int64 {
+	if a.EndTime == nil {
+		return 0
+	}
+	return *a.EndTime
+}
+
+func (a *AnnouncementValues) GetPublishedAt() int64 {
+	if a.PublishedAt == nil {
+		return 0
+	}
+	return *a.PublishedAt
+}
+
+func (a *AnnouncementValues) GetApprovedAt() int64 {
+	if a.ApprovedAt == nil {
+		return 0
+	}
+	return *a.ApprovedAt
+}
+
+func (a *AnnouncementValues) GetShowOnce() bool {
+	if a.ShowOnce == nil {
+		return false
+	}
+	return *a.ShowOnce
+}
+
+func (a *AnnouncementValues) GetSendPush() bool {
+	if a.SendPush == nil {
+		return false
+	}
+	return *a.SendPush
+}
+
+func (a *AnnouncementValues) GetSendEmail() bool {
+	if a.SendEmail == nil {
+		return false
+	}
+	return *a.SendEmail
+}
+
+func (a *AnnouncementValues) GetPushDelay() int {
+	if a.PushDelay == nil {
+		return 0
+	}
+	return *a.PushDelay
+}
+
+func (a *AnnouncementValues) GetPushBatchSize() int {
+	if a.PushBatchSize == nil || *a.PushBatchSize <= 0 {
+		return 1000
+	}
+	return *a.PushBatchSize
+}
+
+func (a *AnnouncementValues) GetDeliveryStatus() string {
+	if a.DeliveryStatus == nil {
+		return protocol.AnnouncementDeliveryNone
+	}
+	return *a.DeliveryStatus
+}
+
+func (a *AnnouncementValues) GetDeliveryCursor() int64 {
+	if a.DeliveryCursor == nil {
+		return 0
+	}
+	return *a.DeliveryCursor
+}
+
+func (a *AnnouncementValues) GetConfirmCount() int {
+	if a.ConfirmCount == nil {
+		return 0
+	}
+	return *a.ConfirmCount
+}
+
+func (a *AnnouncementValues) GetPushSentCount() int {
+	if a.PushSentCount == nil {
+		return 0
+	}
+	return *a.PushSentCount
+}
+
+func (a *AnnouncementValues) GetEmailSentCount() int {
+	if a.EmailSentCount == nil {
+		return 0
+	}
+	return *a.EmailSentCount
+}
+
+// NeedsDelivery 是否需要推送或邮件分发
+func (a *AnnouncementValues) NeedsDelivery() bool {
+	return a.GetSendPush() || a.GetSendEmail()
+}
+
+// 列表字段以逗号分隔存储
+func (a *AnnouncementValues) GetTargetCityList() []string {
+	return SplitAnnouncementList(a.TargetCities)
+}
+
+func (a *AnnouncementValues) GetTargetServiceAreaList() []string {
+	return SplitAnnouncementList(a.TargetServiceAreas)
+}
+
+func (a *AnnouncementValues) GetExcludedAreaList() []string {
+	return SplitAnnouncementList(a.ExcludedAreas)
+}
+
+func (a *AnnouncementValues) GetTargetUserIDList() []string {
+	return SplitAnnouncementList(a.TargetUserIDs)
+}
+
+func (a *AnnouncementValues) GetExcludedUserIDList() []string {
+	return SplitAnnouncementList(a.ExcludedUserIDs)
+}
+
+// GetTranslationMap 解析多语言内容
+func (a *AnnouncementValues) GetTranslationMap() map[string]*protocol.AnnouncementTranslation {
+	translations := make(map[string]*protocol.AnnouncementTranslation)
+	if a.Translations != nil && *a.Translations != "" {
+		_ = utils.FromJSON(*a.Translations, &translations)
+	}
+	return translations
+}
+
+// SetTranslationMap 保存多语言内容
+func (a *AnnouncementValues) SetTranslationMap(translations map[string]*protocol.AnnouncementTranslation) *AnnouncementValues {
+	if translations == nil {
+		translations = map[string]*protocol.AnnouncementTranslation{}
+	}
+	data, err := utils.ToJSON(translations)
+	if err != nil {
+		return a
+	}
+	a.Translations = &data
+	a.IsTranslated = utils.BoolPtr(len(translations) > 0)
+	return a
+}
+
+// SplitAnnouncementList 拆分逗号分隔的列表字段
+func SplitAnnouncementList(value *string) []string {
+	if value == nil || *value == "" {
+		return nil
+	}
+	var list []string
+	for _, item := range strings.Split(*value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// JoinAnnouncementList 合并列表字段，空列表存为空字符串以便更新时清空
+func JoinAnnouncementList(list []string) *string {
+	items := make([]string, 0, len(list))
+	for _, item := range list {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return utils.StringPtr(strings.Join(items, ","))
+}
+
+// Protocol 转换为协议对象
+func (a *Announcement) Protocol() *protocol.Announcement {
+	return &protocol.Announcement{
+		AnnouncementID:     a.AnnouncementID,
+		Title:              a.GetTitle(),
+		Summary:            a.GetSummary(),
+		Content:            a.GetContent(),
+		Type:               a.GetType(),
+		Level:              a.GetLevel(),
+		Language:           a.GetLanguage(),
+		Translations:       a.GetTranslationMap(),
+		TargetAudience:     a.GetTargetAudience(),
+		TargetCities:       a.GetTargetCityList(),
+		TargetServiceAreas: a.GetTargetServiceAreaList(),
+		ExcludedAreas:      a.GetExcludedAreaList(),
+		TargetUserIDs:      a.GetTargetUserIDList(),
+		ExcludedUserIDs:    a.GetExcludedUserIDList(),
+		PublishTime:        a.GetPublishTime(),
+		StartTime:          a.GetStartTime(),
+		EndTime:            a.GetEndTime(),
+		IsSticky:           a.GetIsSticky(),
+		IsBanner:           a.GetIsBanner(),
+		IsPopup:            a.GetIsPopup(),
+		ShowOnce:           a.GetShowOnce(),
+		RequireConfirm:     a.GetRequireConfirm(),
+		DisplayPosition:    utils.SafeStringDeref(a.DisplayPosition),
+		Priority:           a.GetPriority(),
+		ImageURL:           utils.SafeStringDeref(a.ImageURL),
+		BannerURL:          utils.SafeStringDeref(a.BannerURL),
+		ActionType:         utils.SafeStringDeref(a.ActionType),
+		ActionURL:          utils.SafeStringDeref(a.ActionURL),
+		ActionText:         utils.SafeStringDeref(a.ActionText),
+		SendPush:           a.GetSendPush(),
+		PushTitle:          utils.SafeStringDeref(a.PushTitle),
+		PushContent:        utils.SafeStringDeref(a.PushContent),
+		PushDelay:          a.GetPushDelay(),
+		SendEmail:          a.GetSendEmail(),
+		EmailSubject:       utils.SafeStringDeref(a.EmailSubject),
+		Status:             a.GetStatus(),
+		ApprovalStatus:     a.GetApprovalStatus(),
+		ApprovedBy:         utils.SafeStringDeref(a.ApprovedBy),
+		ApprovedAt:         a.GetApprovedAt(),
+		ApprovalNotes:      utils.SafeStringDeref(a.ApprovalNotes),
+		PublishedAt:        a.GetPublishedAt(),
+		DeliveryStatus:     a.GetDeliveryStatus(),
+		ViewCount:          a.GetViewCount(),
+		ConfirmCount:       a.GetConfirmCount(),
+		PushSentCount:      a.GetPushSentCount(),
+		EmailSentCount:     a.GetEmailSentCount(),
+		CreatedBy:          utils.SafeStringDeref(a.CreatedBy),
+		UpdatedBy:          utils.SafeStringDeref(a.UpdatedBy),
+		CreatedAt:          a.CreatedAt,
+		UpdatedAt:          a.UpdatedAt,
+	}
+}
+
+// GetAnnouncementByID 根据公告ID获取公告（不含已删除）
+func GetAnnouncementByID(announcementID string) *Announcement {
+	var announcement Announcement
+	err := GetDB().Where("announcement_id = ? AND status <> ?", announcementID, AnnouncementStatusDeleted).
+		First(&announcement).Error
+	if err != nil {
+		return nil
+	}
+	return &announcement
+}
+
+// SearchAnnouncements 分页查询公告
+func SearchAnnouncements(req *protocol.AnnouncementSearchRequest) ([]*Announcement, int64) {
+	query := GetDB().Model(&Announcement{}).Where("status <> ?", AnnouncementStatusDeleted)
+	if req.Keyword != "" {
+		keyword := "%" + req.Keyword + "%"
+		query = query.Where("title LIKE ? OR summary LIKE ?", keyword, keyword)
+	}
+	if req.Type != "" {
+		query = query.Where("type = ?", req.Type)
+	}
+	if req.Status != "" {
+		query = query.Where("status = ?", req.Status)
+	}
+	if req.ApprovalStatus != "" {
+		query = query.Where("approval_status = ?", req.ApprovalStatus)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var announcements []*Announcement
+	query.Order("created_at DESC").
+		Offset((req.Page - 1) * req.Limit).
+		Limit(req.Limit).
+		Find(&announcements)
+	return announcements, total
+}
+
+// GetLiveAnnouncements 获取当前生效的公告（已发布、已审批、在有效期内），受众和地域由调用方过滤
+func GetLiveAnnouncements(now int64, limit int) []*Announcement {
+	var announcements []*Announcement
+	GetDB().Where("status = ? AND is_active = ? AND approval_status = ?",
+		AnnouncementStatusPublished, true, AnnouncementApprovalStatusApproved).
+		Where("start_time IS NULL OR start_time <= ?", now).
+		Where("end_time IS NULL OR end_time = 0 OR end_time > ?", now).
+		Order("is_sticky DESC, priority DESC, display_order ASC, published_at DESC").
+		Limit(limit).
+		Find(&announcements)
+	return announcements
+}
+
+// GetDueScheduledAnnouncements 获取已到发布时间的定时公告
+func GetDueScheduledAnnouncements(now int64, limit int) []*Announcement {
+	var announcements []*Announcement
+	GetDB().Where("status = ? AND approval_status = ? AND publish_time <= ?",
+		AnnouncementStatusScheduled, AnnouncementApprovalStatusApproved, now).
+		Order("publish_time ASC").
+		Limit(limit).
+		Find(&announcements)
+	return announcements
+}
+
+// ExpireEndedAnnouncements 将已过失效时间的公告标记为过期，未完成的分发一并结束
+func ExpireEndedAnnouncements(now int64) (int64, error) {
+	result := GetDB().Model(&Announcement{}).
+		Where("status = ? AND end_time > 0 AND end_time <= ?", AnnouncementStatusPublished, now).
+		Updates(map[string]any{
+			"status":    AnnouncementStatusExpired,
+			"is_active": false,
+		})
+	return result.RowsAffected, result.Error
+}
+
+// GetAnnouncementsPendingDelivery 获取待分发或分发中的已发布公告
+func GetAnnouncementsPendingDelivery(limit int) []*Announcement {
+	var announcements []*Announcement
+	GetDB().Where("status = ? AND delivery_status IN ?", AnnouncementStatusPublished,
+		[]string{protocol.AnnouncementDeliveryPending, protocol.AnnouncementDeliveryRunning}).
+		Order("published_at ASC").
+		Limit(limit).
+		Find(&announcements)
+	return announcements
+}
+
+// TransitionAnnouncementStatus 条件更新公告状态，返回是否更新成功
+func TransitionAnnouncementStatus(announcementID string, fromStatuses []string, values *AnnouncementValues) (bool, error) {
+	result := GetDB().Model(&Announcement{}).
+		Where("announcement_id = ? AND status IN ?", announcementID, fromStatuses).
+		UpdateColumns(values)
+	return result.RowsAffected > 0, result.Error
+}
+
+// AddAnnouncementDeliveryProgress 记录一批分发结果并推进游标，completed 为 true 时结束分发
+func AddAnnouncementDeliveryProgress(announcementID string, cursor int64, pushSent, emailSent int, completed bool) error {
+	updates := map[string]any{
+		"delivery_cursor":  cursor,
+		"delivery_status":  protocol.AnnouncementDeliveryRunning,
+		"push_sent_count":  gorm.Expr("push_sent_count + ?", pushSent),
+		"email_sent_count": gorm.Expr("email_sent_count + ?", emailSent),
+	}
+	if completed {
+		updates["delivery_status"] = protocol.AnnouncementDeliveryCompleted
+		updates["delivery_completed_at"] = utils.TimeNowMilli()
+	}
+	return GetDB().Model(&Announcement{}).Where("announcement_id = ?", announcementID).Updates(updates).Error
+}
+
+// GetAnnouncementDeliveryUsers 按用户主键分页获取公告的推送/邮件对象
+// 这里按受众、城市和指定用户筛选，服务区域和排除区域由服务层按用户最后已知位置过滤
+func GetAnnouncementDeliveryUsers(announcement *Announcement, cursor int64, limit int) []*User {
+	query := GetDB().Model(&User{}).
+		Where("id > ? AND status = ?", cursor, protocol.StatusActive).
+		Where("(deleted_at IS NULL OR deleted_at = 0)")
+
+	switch announcement.GetTargetAudience() {
+	case TargetAudienceUsers:
+		query = query.Where("user_type = ?", protocol.UserTypePassenger)
+	case TargetAudienceDrivers:
+		query = query.Where("user_type = ?", protocol.UserTypeDriver)
+	case TargetAudienceNewUsers:
+		query = query.Where("created_at >= ?", utils.TimeNowMilli()-AnnouncementNewUserDays*24*3600*1000)
+	case TargetAudienceAll:
+	default:
+		return nil
+	}
+	if cities := announcement.GetTargetCityList(); len(cities) > 0 {
+		query = query.Where("city IN ?", cities)
+	}
+	if userIDs := announcement.GetTargetUserIDList(); len(userIDs) > 0 {
+		query = query.Where("user_id IN ?", userIDs)
+	}
+	if excluded := announcement.GetExcludedUserIDList(); len(excluded) > 0 {
+		query = query.Where("user_id NOT IN ?", excluded)
+	}
+
+	var users []*User
+	query.Order("id ASC").Limit(limit).Find(&users)
+	return users
+}
+
+// AnnouncementNewUserDays new_users 受众的注册天数
+const AnnouncementNewUserDays = 30
+
+// IncrementAnnouncementCounter 原子递增公告计数字段
+func IncrementAnnouncementCounter(announcementID, column string) error {
+	return GetDB().Model(&Announcement{}).
+		Where("announcement_id = ?", announcementID).
+		UpdateColumn(column, gorm.Expr(column+" + ?", 1)).Error
+}
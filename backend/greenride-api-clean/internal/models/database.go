@@ -79,6 +79,10 @@ func AutoMigrate() error {
 		&OrderHistoryLog{},
 		// &OrderStats{}, // Comented out if it doesn't exist
 		&RideOrder{},
+		&RideOrderStop{},
+		&RidePool{},
+		&DeliveryOrder{},
+		&OrderTip{},
 
 		// 派单相关
 		&DispatchRecord{},
@@ -99,25 +103,40 @@ func AutoMigrate() error {
 		// 钱包相关
 		&Wallet{},
 		&WalletTransaction{},
+		&UserDebt{},
 		&Withdrawal{},
 
 		// 促销相关
 		&Promotion{},
+		&PromotionCampaign{},
+		&PromotionExperiment{},
+		&PromotionExperimentAssignment{},
+
+		// 邀请相关
+		&Referral{},
 
 		// 消息相关
 		&Message{},
 		&MessageTemplate{},
+		&MessageTemplateVersion{},
+		&Translation{},
+		&TranslationFallback{},
 		&Notification{},
+		&NotificationPreference{},
 
 		// FCM相关
 		&FCMToken{},
 		&FCMMessageLog{},
 
+		// 短信投递
+		&SMSDelivery{},
+
 		// 服务区域
 		&ServiceArea{},
 
 		// 公告
 		&Announcement{},
+		&AnnouncementRead{},
 
 		// 反馈
 		&Feedback{},
@@ -128,6 +147,11 @@ func AutoMigrate() error {
 		// 系统配置
 		&SystemConfig{},
 
+		// 行程安全
+		&SafetyIncident{},
+		&TripShare{},
+		&TrustedContact{},
+
 		// 任务
 		&Task{},
 	}
@@ -0,0 +1,365 @@
+package models
+
+import (
+	"greenride/internal/protocol"
+	"greenride/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+// DeliveryOrder 包裹配送订单表 - 寄件人、收件人、包裹信息及签收凭证
+// 取件点和收件点沿用pickup_*/dropoff_*字段，派单和司机订单队列可以和网约车一样读取
+type DeliveryOrder struct {
+	ID      int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	OrderID string `json:"order_id" gorm:"column:order_id;type:varchar(64);uniqueIndex"` // 关联t_orders的order_id
+	Salt    string `json:"salt" gorm:"column:salt;type:varchar(256)"`
+	*DeliveryOrderValues
+	CreatedAt int64 `json:"created_at" gorm:"column:created_at;autoCreateTime:milli"`
+}
+
+type DeliveryOrderValues struct {
+	// 车辆信息
+	VehicleID       *string `json:"vehicle_id" gorm:"column:vehicle_id;type:varchar(64);index"`
+	VehicleCategory *string `json:"vehicle_category" gorm:"column:vehicle_category;type:varchar(64);index"`
+	VehicleLevel    *string `json:"vehicle_level" gorm:"column:vehicle_level;type:varchar(64);index"`
+
+	// 寄件人及取件地点
+	SenderName      *string  `json:"sender_name" gorm:"column:sender_name;type:varchar(255)"`
+	SenderPhone     *string  `json:"sender_phone" gorm:"column:sender_phone;type:varchar(50)"`
+	PickupAddress   *string  `json:"pickup_address" gorm:"column:pickup_address;type:text"`
+	PickupLatitude  *float64 `json:"pickup_latitude" gorm:"column:pickup_latitude;type:decimal(10,8)"`
+	PickupLongitude *float64 `json:"pickup_longitude" gorm:"column:pickup_longitude;type:decimal(11,8)"`
+	PickupLandmark  *string  `json:"pickup_landmark" gorm:"column:pickup_landmark;type:varchar(255)"`
+
+	// 收件人及收件地点
+	RecipientName    *string  `json:"recipient_name" gorm:"column:recipient_name;type:varchar(255)"`
+	RecipientPhone   *string  `json:"recipient_phone" gorm:"column:recipient_phone;type:varchar(50)"`
+	DropoffAddress   *string  `json:"dropoff_address" gorm:"column:dropoff_address;type:text"`
+	DropoffLatitude  *float64 `json:"dropoff_latitude" gorm:"column:dropoff_latitude;type:decimal(10,8)"`
+	DropoffLongitude *float64 `json:"dropoff_longitude" gorm:"column:dropoff_longitude;type:decimal(11,8)"`
+	DropoffLandmark  *string  `json:"dropoff_landmark" gorm:"column:dropoff_landmark;type:varchar(255)"`
+
+	// 包裹信息
+	ParcelSize        *string  `json:"parcel_size" gorm:"column:parcel_size;type:varchar(32)"`               // small, medium, large
+	ParcelWeight      *float64 `json:"parcel_weight" gorm:"column:parcel_weight;type:decimal(8,2)"`          // 重量（公斤）
+	ParcelDescription *string  `json:"parcel_description" gorm:"column:parcel_description;type:text"`        // 包裹内容描述
+	ParcelPhotos      []string `json:"parcel_photos" gorm:"column:parcel_photos;type:json;serializer:json"`  // 寄件人上传的包裹照片
+	Instructions      *string  `json:"instructions" gorm:"column:instructions;type:text"`                    // 取件/送达说明
+	PickupPhotoURL    *string  `json:"pickup_photo_url" gorm:"column:pickup_photo_url;type:varchar(512)"`    // 司机取件时拍摄的包裹照片
+	DeliveryStatus    *string  `json:"delivery_status" gorm:"column:delivery_status;type:varchar(32);index"` // 配送状态
+	DeliveryCode      *string  `json:"delivery_code" gorm:"column:delivery_code;type:varchar(16)"`           // 收件人取件码
+	CodeAttempts      *int     `json:"code_attempts" gorm:"column:code_attempts;type:int;default:0"`         // 取件码输错次数
+	CodeResends       *int     `json:"code_resends" gorm:"column:code_resends;type:int;default:0"`           // 寄件人重发取件码次数
+	CodeSentAt        *int64   `json:"code_sent_at" gorm:"column:code_sent_at"`                              // 最近一次生成取件码的时间
+
+	// 签收凭证
+	ProofType     *string `json:"proof_type" gorm:"column:proof_type;type:varchar(32)"`            // otp, photo
+	ProofPhotoURL *string `json:"proof_photo_url" gorm:"column:proof_photo_url;type:varchar(512)"` // 签收照片
+
+	// 距离和时长
+	EstimatedDistance *float64 `json:"estimated_distance" gorm:"column:estimated_distance;type:decimal(8,2)"`
+	EstimatedDuration *int     `json:"estimated_duration" gorm:"column:estimated_duration"`
+	ActualDistance    *float64 `json:"actual_distance" gorm:"column:actual_distance;type:decimal(8,2)"`
+	ActualDuration    *int     `json:"actual_duration" gorm:"column:actual_duration"`
+
+	// 时间节点
+	DriverEnRouteAt  *int64 `json:"driver_en_route_at" gorm:"column:driver_en_route_at"`
+	ArrivedAt        *int64 `json:"arrived_at" gorm:"column:arrived_at"` // 司机到达取件点时间
+	PickedUpAt       *int64 `json:"picked_up_at" gorm:"column:picked_up_at"`
+	ArrivedDropoffAt *int64 `json:"arrived_dropoff_at" gorm:"column:arrived_dropoff_at"`
+	DeliveredAt      *int64 `json:"delivered_at" gorm:"column:delivered_at"`
+
+	UpdatedAt int64 `json:"updated_at" gorm:"column:updated_at;autoUpdateTime:milli"`
+}
+
+func (DeliveryOrder) TableName() string {
+	return "t_delivery_orders"
+}
+
+// NewDeliveryOrder 创建配送订单详情
+func NewDeliveryOrder(orderID string) *DeliveryOrder {
+	return &DeliveryOrder{
+		OrderID: orderID,
+		Salt:    utils.GenerateSalt(),
+		DeliveryOrderValues: &DeliveryOrderValues{
+			DeliveryStatus: utils.StringPtr(protocol.DeliveryStatusPending),
+			CodeResends:    utils.IntPtr(0),
+			CodeSentAt:     utils.TimeNowMilliPtr(),
+		},
+	}
+}
+
+func (d *DeliveryOrderValues) GetDeliveryStatus() string {
+	if d.DeliveryStatus == nil {
+		return ""
+	}
+	return *d.DeliveryStatus
+}
+
+func (d *DeliveryOrderValues) GetDeliveryCode() string {
+	if d.DeliveryCode == nil {
+		return ""
+	}
+	return *d.DeliveryCode
+}
+
+func (d *DeliveryOrderValues) GetCodeAttempts() int {
+	if d.CodeAttempts == nil {
+		return 0
+	}
+	return *d.CodeAttempts
+}
+
+func (d *DeliveryOrderValues) GetCodeResends() int {
+	if d.CodeResends == nil {
+		return 0
+	}
+	return *d.CodeResends
+}
+
+func (d *DeliveryOrderValues) GetCodeSentAt() int64 {
+	if d.CodeSentAt == nil {
+		return 0
+	}
+	return *d.CodeSentAt
+}
+
+func (d *DeliveryOrderValues) GetSenderName() string {
+	if d.SenderName == nil {
+		return ""
+	}
+	return *d.SenderName
+}
+
+func (d *DeliveryOrderValues) GetSenderPhone() string {
+	if d.SenderPhone == nil {
+		return ""
+	}
+	return *d.SenderPhone
+}
+
+func (d *DeliveryOrderValues) GetRecipientName() string {
+	if d.RecipientName == nil {
+		return ""
+	}
+	return *d.RecipientName
+}
+
+func (d *DeliveryOrderValues) GetRecipientPhone() string {
+	if d.RecipientPhone == nil {
+		return ""
+	}
+	return *d.RecipientPhone
+}
+
+func (d *DeliveryOrderValues) GetParcelSize() string {
+	if d.ParcelSize == nil {
+		return ""
+	}
+	return *d.ParcelSize
+}
+
+func (d *DeliveryOrderValues) GetParcelWeight() float64 {
+	if d.ParcelWeight == nil {
+		return 0
+	}
+	return *d.ParcelWeight
+}
+
+func (d *DeliveryOrderValues) GetPickupAddress() string {
+	if d.PickupAddress == nil {
+		return ""
+	}
+	return *d.PickupAddress
+}
+
+func (d *DeliveryOrderValues) GetDropoffAddress() string {
+	if d.DropoffAddress == nil {
+		return ""
+	}
+	return *d.DropoffAddress
+}
+
+func (d *DeliveryOrderValues) SetVehicleCategory(category string) *DeliveryOrderValues {
+	d.VehicleCategory = &category
+	return d
+}
+
+func (d *DeliveryOrderValues) SetVehicleLevel(level string) *DeliveryOrderValues {
+	d.VehicleLevel = &level
+	return d
+}
+
+func (d *DeliveryOrderValues) SetSender(name, phone string) *DeliveryOrderValues {
+	d.SenderName = &name
+	d.SenderPhone = &phone
+	return d
+}
+
+func (d *DeliveryOrderValues) SetRecipient(name, phone string) *DeliveryOrderValues {
+	d.RecipientName = &name
+	d.RecipientPhone = &phone
+	return d
+}
+
+func (d *DeliveryOrderValues) SetPickupLocation(address, landmark string, lat, lng float64) *DeliveryOrderValues {
+	d.PickupAddress = &address
+	d.PickupLandmark = &landmark
+	d.PickupLatitude = &lat
+	d.PickupLongitude = &lng
+	return d
+}
+
+func (d *DeliveryOrderValues) SetDropoffLocation(address, landmark string, lat, lng float64) *DeliveryOrderValues {
+	d.DropoffAddress = &address
+	d.DropoffLandmark = &landmark
+	d.DropoffLatitude = &lat
+	d.DropoffLongitude = &lng
+	return d
+}
+
+func (d *DeliveryOrderValues) SetParcel(size string, weight float64, description string, photos []string) *DeliveryOrderValues {
+	d.ParcelSize = &size
+	d.ParcelWeight = &weight
+	d.ParcelDescription = &description
+	d.ParcelPhotos = photos
+	return d
+}
+
+func (d *DeliveryOrderValues) SetInstructions(instructions string) *DeliveryOrderValues {
+	d.Instructions = &instructions
+	return d
+}
+
+func (d *DeliveryOrderValues) SetDeliveryCode(code string) *DeliveryOrderValues {
+	d.DeliveryCode = &code
+	return d
+}
+
+func (d *DeliveryOrderValues) SetEstimatedDistance(distance float64) *DeliveryOrderValues {
+	d.EstimatedDistance = &distance
+	return d
+}
+
+func (d *DeliveryOrderValues) SetEstimatedDuration(duration int) *DeliveryOrderValues {
+	d.EstimatedDuration = &duration
+	return d
+}
+
+// ToOrderDetail 转换为统一的OrderDetail
+func (d *DeliveryOrder) ToOrderDetail() *protocol.OrderDetail {
+	detail := &protocol.OrderDetail{
+		OrderID:        d.OrderID,
+		OrderType:      protocol.DeliveryOrder,
+		PickupAddress:  d.GetPickupAddress(),
+		DropoffAddress: d.GetDropoffAddress(),
+	}
+	if d.PickupLatitude != nil {
+		detail.PickupLatitude = *d.PickupLatitude
+	}
+	if d.PickupLongitude != nil {
+		detail.PickupLongitude = *d.PickupLongitude
+	}
+	if d.DropoffLatitude != nil {
+		detail.DropoffLatitude = *d.DropoffLatitude
+	}
+	if d.DropoffLongitude != nil {
+		detail.DropoffLongitude = *d.DropoffLongitude
+	}
+	if d.EstimatedDistance != nil {
+		detail.EstimatedDistance = *d.EstimatedDistance
+	}
+	if d.EstimatedDuration != nil {
+		detail.EstimatedDuration = *d.EstimatedDuration
+	}
+	if d.VehicleID != nil {
+		detail.VehicleID = *d.VehicleID
+	}
+	return detail
+}
+
+// Protocol 转换为配送详情，取件码不在此返回，由调用方按身份决定是否展示
+func (d *DeliveryOrder) Protocol() *protocol.DeliveryInfo {
+	info := &protocol.DeliveryInfo{
+		DeliveryStatus: d.GetDeliveryStatus(),
+		SenderName:     d.GetSenderName(),
+		SenderPhone:    d.GetSenderPhone(),
+		RecipientName:  d.GetRecipientName(),
+		RecipientPhone: d.GetRecipientPhone(),
+		ParcelSize:     d.GetParcelSize(),
+		ParcelWeight:   d.GetParcelWeight(),
+		Photos:         d.ParcelPhotos,
+	}
+	if d.ParcelDescription != nil {
+		info.Description = *d.ParcelDescription
+	}
+	if d.Instructions != nil {
+		info.Instructions = *d.Instructions
+	}
+	if d.PickupPhotoURL != nil {
+		info.PickupPhotoURL = *d.PickupPhotoURL
+	}
+	if d.ProofType != nil {
+		info.ProofType = *d.ProofType
+	}
+	if d.ProofPhotoURL != nil {
+		info.ProofPhotoURL = *d.ProofPhotoURL
+	}
+	if d.ArrivedAt != nil {
+		info.ArrivedPickupAt = *d.ArrivedAt
+	}
+	if d.PickedUpAt != nil {
+		info.PickedUpAt = *d.PickedUpAt
+	}
+	if d.ArrivedDropoffAt != nil {
+		info.ArrivedDropoffAt = *d.ArrivedDropoffAt
+	}
+	if d.DeliveredAt != nil {
+		info.DeliveredAt = *d.DeliveredAt
+	}
+	return info
+}
+
+func GetDeliveryOrderByOrderID(orderID string) *DeliveryOrder {
+	var deliveryOrder DeliveryOrder
+	err := DB.Where("order_id = ?", orderID).First(&deliveryOrder).Error
+	if err != nil {
+		return nil
+	}
+	return &deliveryOrder
+}
+
+// TransitDeliveryStatus 仅当配送状态仍为from时更新为values中的状态，返回是否更新成功，防止并发重复操作
+func TransitDeliveryStatus(tx *gorm.DB, orderID, from string, values map[string]any) (bool, error) {
+	rs := tx.Model(&DeliveryOrder{}).
+		Where("order_id = ? AND delivery_status = ?", orderID, from).
+		UpdateColumns(values)
+	if rs.Error != nil {
+		return false, rs.Error
+	}
+	return rs.RowsAffected > 0, nil
+}
+
+// IncrDeliveryCodeAttempts 取件码输错次数加一
+func IncrDeliveryCodeAttempts(orderID string) error {
+	return DB.Model(&DeliveryOrder{}).
+		Where("order_id = ?", orderID).
+		UpdateColumn("code_attempts", gorm.Expr("code_attempts + 1")).Error
+}
+
+// ResetDeliveryCode 重新生成取件码后清零输错次数并累加重发次数
+// 仅当重发次数未达到 maxResends 且上次生成时间不晚于 sentBefore 时更新，返回是否更新成功，防止并发重复发送
+func ResetDeliveryCode(orderID, code string, maxResends int, sentBefore int64) (bool, error) {
+	rs := DB.Model(&DeliveryOrder{}).
+		Where("order_id = ? AND COALESCE(code_resends, 0) < ? AND COALESCE(code_sent_at, 0) <= ?", orderID, maxResends, sentBefore).
+		UpdateColumns(map[string]any{
+			"delivery_code": code,
+			"code_attempts": 0,
+			"code_resends":  gorm.Expr("COALESCE(code_resends, 0) + 1"),
+			"code_sent_at":  utils.TimeNowMilli(),
+		})
+	if rs.Error != nil {
+		return false, rs.Error
+	}
+	return rs.RowsAffected > 0, nil
+}
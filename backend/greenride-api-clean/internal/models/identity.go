@@ -78,6 +78,7 @@ const (
 	IdentityStatusApproved = "approved"
 	IdentityStatusRejected = "rejected"
 	IdentityStatusExpired  = "expired"
+	IdentityStatusReplaced = "replaced" // 被重新上传的证件取代
 )
 
 // 证件类型常量
@@ -85,6 +86,9 @@ const (
 	IDTypePassport      = "passport"
 	IDTypeDriverLicense = "driver_license"
 	IDTypeNationalID    = "national_id"
+
+	IDTypeInsurance           = "insurance"            // 车辆保险
+	IDTypeVehicleRegistration = "vehicle_registration" // 车辆登记证
 )
 
 // 性别常量
@@ -108,6 +112,15 @@ func NewIdentityV2() *Identity {
 	}
 }
 
+// NewDriverDocument 创建司机证件记录
+func NewDriverDocument(userID, docType string) *Identity {
+	identity := NewIdentityV2()
+	identity.SetUserID(userID)
+	identity.UserType = utils.StringPtr(protocol.UserTypeDriver)
+	identity.IDType = utils.StringPtr(docType)
+	return identity
+}
+
 // SetValues 更新IdentityV2Values中的非nil值
 func (i *IdentityValues) SetValues(values *IdentityValues) {
 	if values == nil {
@@ -242,6 +255,20 @@ func (i *IdentityValues) GetDateOfBirth() string {
 	return *i.DateOfBirth
 }
 
+func (i *IdentityValues) GetExpiresAt() int64 {
+	if i.ExpiresAt == nil {
+		return 0
+	}
+	return *i.ExpiresAt
+}
+
+func (i *IdentityValues) GetRejectReason() string {
+	if i.RejectReason == nil {
+		return ""
+	}
+	return *i.RejectReason
+}
+
 func (i *IdentityValues) GetOCRConfidence() float64 {
 	if i.OCRConfidence == nil {
 		return 0.0
@@ -309,6 +336,15 @@ func (i *Identity) IsExpired() bool {
 	return i.GetStatus() == IdentityStatusExpired
 }
 
+// IsExpiredAt 证件在指定时间是否已过期（未设置有效期视为不过期）
+func (i *Identity) IsExpiredAt(now int64) bool {
+	if i.IsExpired() {
+		return true
+	}
+	expiresAt := i.GetExpiresAt()
+	return expiresAt > 0 && expiresAt <= now
+}
+
 func (i *Identity) IsVerified() bool {
 	return i.GetIsVerified() && i.IsApproved()
 }
@@ -1,10 +1,14 @@
 package models
 
 import (
+	"context"
 	"greenride/internal/protocol"
 	"greenride/internal/utils"
+	"math"
 	"strings"
+	"time"
 
+	"github.com/spf13/cast"
 	"gorm.io/gorm"
 )
 
@@ -578,3 +582,302 @@ func GetLocalAdvertisementsByName(name string) ([]*LocalAdvertisement, error) {
 	err := DB.Where("name LIKE ?", "%"+name+"%").Find(&ads).Error
 	return ads, err
 }
+
+func (la *LocalAdvertisementValues) GetCountry() string {
+	if la.Country == nil {
+		return ""
+	}
+	return *la.Country
+}
+
+func (la *LocalAdvertisementValues) GetEmail() string {
+	if la.Email == nil {
+		return ""
+	}
+	return *la.Email
+}
+
+func (la *LocalAdvertisementValues) GetGooglePlaceID() string {
+	if la.GooglePlaceID == nil {
+		return ""
+	}
+	return *la.GooglePlaceID
+}
+
+func (la *LocalAdvertisementValues) GetStartAt() int64 {
+	if la.StartAt == nil {
+		return 0
+	}
+	return *la.StartAt
+}
+
+func (la *LocalAdvertisementValues) GetEndAt() int64 {
+	if la.EndAt == nil {
+		return 0
+	}
+	return *la.EndAt
+}
+
+func (la *LocalAdvertisementValues) GetViewCount() int {
+	if la.ViewCount == nil {
+		return 0
+	}
+	return *la.ViewCount
+}
+
+func (la *LocalAdvertisementValues) GetClickCount() int {
+	if la.ClickCount == nil {
+		return 0
+	}
+	return *la.ClickCount
+}
+
+func (la *LocalAdvertisementValues) GetCallCount() int {
+	if la.CallCount == nil {
+		return 0
+	}
+	return *la.CallCount
+}
+
+func (la *LocalAdvertisementValues) GetNotes() string {
+	if la.Notes == nil {
+		return ""
+	}
+	return *la.Notes
+}
+
+// GetTargetCityList 目标城市列表（逗号分隔存储）
+func (la *LocalAdvertisementValues) GetTargetCityList() []string {
+	return splitCommaList(la.TargetCities)
+}
+
+// GetTagList 标签列表（逗号分隔存储）
+func (la *LocalAdvertisementValues) GetTagList() []string {
+	return splitCommaList(la.Tags)
+}
+
+func splitCommaList(value *string) []string {
+	if value == nil || *value == "" {
+		return nil
+	}
+	var list []string
+	for _, item := range strings.Split(*value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// AdminProtocol 转换为管理后台协议对象
+func (la *LocalAdvertisement) AdminProtocol() *protocol.AdminLocalAdvertisement {
+	return &protocol.AdminLocalAdvertisement{
+		LocalAdvertisement: la.Protocol(),
+		Status:             la.GetStatus(),
+		Country:            la.GetCountry(),
+		Email:              la.GetEmail(),
+		GooglePlaceID:      la.GetGooglePlaceID(),
+		StartAt:            la.GetStartAt(),
+		EndAt:              la.GetEndAt(),
+		IsLive:             la.IsVisible(),
+		TargetCities:       la.GetTargetCityList(),
+		Tags:               la.GetTagList(),
+		Notes:              la.GetNotes(),
+		ViewCount:          la.GetViewCount(),
+		ClickCount:         la.GetClickCount(),
+		CallCount:          la.GetCallCount(),
+		CreatedBy:          utils.SafeStringDeref(la.CreatedBy),
+		UpdatedBy:          utils.SafeStringDeref(la.UpdatedBy),
+		CreatedAt:          la.CreatedAt,
+		UpdatedAt:          la.UpdatedAt,
+	}
+}
+
+// LocalAdvertisementEditableColumns 管理后台修改广告时整体替换的字段
+var LocalAdvertisementEditableColumns = []string{
+	"name", "description", "category", "address", "city", "region", "country", "latitude", "longitude",
+	"phone", "email", "website", "image_url", "logo_url", "status", "priority", "display_order",
+	"is_promoted", "is_featured", "start_at", "end_at", "target_cities", "tags", "notes", "updated_by", "updated_at",
+}
+
+// GetLocalAdvertisementByPlaceID 根据Google Place ID获取未删除的广告（导入去重）
+func GetLocalAdvertisementByPlaceID(placeID string) *LocalAdvertisement {
+	var ad LocalAdvertisement
+	err := DB.Where("google_place_id = ? AND status <> ?", placeID, protocol.StatusDeleted).First(&ad).Error
+	if err != nil {
+		return nil
+	}
+	return &ad
+}
+
+// localAdvertisementAdminQuery 管理后台广告筛选条件（不含已删除）
+func localAdvertisementAdminQuery(keyword, city, category, status string) *gorm.DB {
+	query := DB.Model(&LocalAdvertisement{}).Where("status <> ?", protocol.StatusDeleted)
+	if keyword != "" {
+		like := "%" + keyword + "%"
+		query = query.Where("name LIKE ? OR address LIKE ? OR ad_id = ?", like, like, keyword)
+	}
+	if city != "" {
+		query = query.Where("city = ?", city)
+	}
+	if category != "" {
+		query = query.Where("category = ?", category)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	return query
+}
+
+// localAdvertisementOrder 管理后台排序方式
+func localAdvertisementOrder(sortBy string) string {
+	switch sortBy {
+	case "views":
+		return "view_count DESC, id DESC"
+	case "clicks":
+		return "click_count DESC, id DESC"
+	case "ctr":
+		return "click_count / NULLIF(view_count, 0) DESC, view_count DESC, id DESC"
+	case "created_at":
+		return "created_at DESC"
+	default:
+		return "priority DESC, display_order ASC, created_at DESC"
+	}
+}
+
+// SearchLocalAdvertisements 管理后台分页查询广告
+func SearchLocalAdvertisements(req *protocol.LocalAdvertisementSearchRequest) ([]*LocalAdvertisement, int64) {
+	query := localAdvertisementAdminQuery(req.Keyword, req.City, req.Category, req.Status)
+	if req.Live {
+		now := utils.TimeNowMilli()
+		query = query.Where("status = ?", protocol.StatusActive).
+			Where("(start_at IS NULL OR start_at <= ?) AND (end_at IS NULL OR end_at >= ?)", now, now)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var ads []*LocalAdvertisement
+	query.Order(localAdvertisementOrder(req.SortBy)).
+		Offset((req.Page - 1) * req.Limit).
+		Limit(req.Limit).
+		Find(&ads)
+	return ads, total
+}
+
+// GetLocalAdvertisementReports 分页查询广告统计，并返回筛选范围内的汇总
+func GetLocalAdvertisementReports(req *protocol.LocalAdvertisementReportRequest) ([]*LocalAdvertisement, int64, *protocol.LocalAdvertisementReportSummary) {
+	filter := func() *gorm.DB {
+		query := localAdvertisementAdminQuery("", req.City, req.Category, req.Status)
+		if len(req.AdIDs) > 0 {
+			query = query.Where("ad_id IN ?", req.AdIDs)
+		}
+		return query
+	}
+
+	summary := &protocol.LocalAdvertisementReportSummary{}
+	filter().Select("COALESCE(SUM(view_count), 0) AS total_views, COALESCE(SUM(click_count), 0) AS total_clicks, COALESCE(SUM(call_count), 0) AS total_calls").
+		Scan(summary)
+
+	var total int64
+	filter().Count(&total)
+
+	sortBy := req.SortBy
+	if sortBy == "" {
+		sortBy = "views"
+	}
+	var ads []*LocalAdvertisement
+	filter().Order(localAdvertisementOrder(sortBy)).
+		Offset((req.Page - 1) * req.Limit).
+		Limit(req.Limit).
+		Find(&ads)
+	return ads, total, summary
+}
+
+// UpdateLocalAdvertisement 按指定字段更新广告（未删除），空值字段会被清空
+func UpdateLocalAdvertisement(adID string, values *LocalAdvertisementValues, columns []string) (bool, error) {
+	result := DB.Model(&LocalAdvertisement{}).
+		Where("ad_id = ? AND status <> ?", adID, protocol.StatusDeleted).
+		Select(columns).
+		Updates(&LocalAdvertisement{LocalAdvertisementValues: values})
+	return result.RowsAffected > 0, result.Error
+}
+
+// LocalAdvertisementFilter 排序推荐时的候选广告筛选条件
+type LocalAdvertisementFilter struct {
+	City            string
+	Region          string
+	Category        string
+	Latitude        float64 // 参考点，为0时不按位置筛选
+	Longitude       float64
+	RadiusKm        float64
+	RequireLocation bool // 只要有坐标的广告（按目的地推荐时）
+}
+
+// GetLocalAdvertisementCandidates 获取正在展示的候选广告，有参考点时按半径外接矩形粗筛，精确距离由调用方计算
+func GetLocalAdvertisementCandidates(filter *LocalAdvertisementFilter) ([]*LocalAdvertisement, error) {
+	now := utils.TimeNowMilli()
+	query := GetDB().Where("status = ?", protocol.StatusActive).
+		Where("(start_at IS NULL OR start_at <= ?) AND (end_at IS NULL OR end_at >= ?)", now, now)
+	if filter.City != "" {
+		query = query.Where("city = ?", filter.City)
+	}
+	if filter.Region != "" {
+		query = query.Where("region = ?", filter.Region)
+	}
+	if filter.Category != "" {
+		query = query.Where("category = ?", filter.Category)
+	}
+	if filter.Latitude != 0 || filter.Longitude != 0 {
+		latDelta := filter.RadiusKm / 111.0
+		lngDelta := latDelta / math.Max(math.Cos(filter.Latitude*math.Pi/180), 0.01)
+		inBox := "latitude BETWEEN ? AND ? AND longitude BETWEEN ? AND ?"
+		args := []any{filter.Latitude - latDelta, filter.Latitude + latDelta, filter.Longitude - lngDelta, filter.Longitude + lngDelta}
+		if filter.RequireLocation {
+			query = query.Where(inBox, args...)
+		} else {
+			query = query.Where("(latitude IS NULL OR latitude = 0 OR ("+inBox+"))", args...)
+		}
+	} else if filter.RequireLocation {
+		query = query.Where("latitude IS NOT NULL AND latitude <> 0")
+	}
+
+	var ads []*LocalAdvertisement
+	err := query.Order("priority DESC, display_order ASC, created_at DESC").Find(&ads).Error
+	return ads, err
+}
+
+// localAdViewsKey 用户当天各广告展示次数（hash: ad_id -> 次数）
+func localAdViewsKey(userID, day string) string {
+	return FormatCacheKey("ad_views:%s:%s", userID, day)
+}
+
+// IncrLocalAdUserViews 记录用户当天看到广告的次数，用于频次控制；Redis不可用时忽略
+func IncrLocalAdUserViews(userID, adID, day string) error {
+	if Redis == nil || userID == "" {
+		return nil
+	}
+	ctx := context.Background()
+	key := localAdViewsKey(userID, day)
+	pipe := Redis.TxPipeline()
+	pipe.HIncrBy(ctx, key, adID, 1)
+	pipe.Expire(ctx, key, 48*time.Hour)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// GetLocalAdUserViews 获取用户当天各广告的展示次数
+func GetLocalAdUserViews(userID, day string) (map[string]int, error) {
+	views := make(map[string]int)
+	if Redis == nil || userID == "" {
+		return views, nil
+	}
+	values, err := Redis.HGetAll(context.Background(), localAdViewsKey(userID, day)).Result()
+	if err != nil {
+		return views, err
+	}
+	for adID, count := range values {
+		views[adID] = cast.ToInt(count)
+	}
+	return views, nil
+}
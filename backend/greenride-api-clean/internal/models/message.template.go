@@ -1,5 +1,11 @@
 package models
 
+import (
+	"greenride/internal/protocol"
+
+	"gorm.io/gorm"
+)
+
 type MessageTemplate struct {
 	ID          int64    `gorm:"column:id;primaryKey;autoIncrement"`
 	TemplateID  string   `gorm:"column:template_id;type:varchar(64);uniqueIndex"`
@@ -11,10 +17,13 @@ type MessageTemplate struct {
 	Region      string   `gorm:"column:region;type:varchar(32);"`
 	Tags        []string `gorm:"column:tags;type:varchar(255);serializer:json"`
 	Title       string   `gorm:"column:title;type:varchar(255)"`
-	Status      string   `gorm:"column:status;type:varchar(32);default:''"`
+	Status      string   `gorm:"column:status;type:varchar(32);default:''"` // draft（未发布）, active, inactive（回退到系统模板）, deleted
 	Description string   `gorm:"column:description;type:text"`
 	Content     string   `gorm:"column:content;type:text"`
 	Url         string   `gorm:"column:url;type:varchar(255)"`
+	Version     int      `gorm:"column:version;default:0"` // 当前发布的版本号，0表示未发布
+	CreatedBy   string   `gorm:"column:created_by;type:varchar(64)"`
+	UpdatedBy   string   `gorm:"column:updated_by;type:varchar(64)"`
 	CreatedAt   int64    `json:"created_at" gorm:"column:created_at;autoCreateTime:milli"`
 	UpdatedAt   int64    `json:"updated_at" gorm:"column:updated_at;autoUpdateTime:milli"` // 更新时间 (毫秒时间戳)
 }
@@ -22,3 +31,140 @@ type MessageTemplate struct {
 func (m *MessageTemplate) TableName() string {
 	return "t_message_template"
 }
+
+// Protocol 转换为管理后台协议对象
+func (m *MessageTemplate) Protocol() *protocol.AdminMessageTemplate {
+	return &protocol.AdminMessageTemplate{
+		TemplateID:  m.TemplateID,
+		Type:        m.Type,
+		Channel:     m.Channel,
+		Language:    m.Language,
+		Region:      m.Region,
+		Title:       m.Title,
+		Content:     m.Content,
+		Status:      m.Status,
+		Description: m.Description,
+		Version:     m.Version,
+		CreatedBy:   m.CreatedBy,
+		UpdatedBy:   m.UpdatedBy,
+		CreatedAt:   m.CreatedAt,
+		UpdatedAt:   m.UpdatedAt,
+	}
+}
+
+// GetLiveMessageTemplates 获取生效中的模板（早期直接写库的模板状态为空，同样视为生效）
+func GetLiveMessageTemplates() ([]*MessageTemplate, error) {
+	var templates []*MessageTemplate
+	err := GetDB().Where("status IN ?", []string{"", protocol.StatusActive}).Find(&templates).Error
+	return templates, err
+}
+
+// GetMessageTemplateByID 根据模板ID获取未删除的模板
+func GetMessageTemplateByID(templateID string) *MessageTemplate {
+	var tmpl MessageTemplate
+	if err := GetDB().Where("template_id = ? AND status <> ?", templateID, protocol.StatusDeleted).First(&tmpl).Error; err != nil {
+		return nil
+	}
+	return &tmpl
+}
+
+// FindMessageTemplate 按类型、渠道、语言和地区查找未删除的模板
+func FindMessageTemplate(msgType, channel, language, region string) *MessageTemplate {
+	var tmpl MessageTemplate
+	err := GetDB().
+		Where("type = ? AND channel = ? AND language = ? AND region = ? AND status <> ?", msgType, channel, language, region, protocol.StatusDeleted).
+		First(&tmpl).Error
+	if err != nil {
+		return nil
+	}
+	return &tmpl
+}
+
+// SearchMessageTemplates 分页查询模板
+func SearchMessageTemplates(req *protocol.MessageTemplateSearchRequest) ([]*MessageTemplate, int64) {
+	query := GetDB().Model(&MessageTemplate{})
+	if req.Status != "" {
+		query = query.Where("status = ?", req.Status)
+	} else {
+		query = query.Where("status <> ?", protocol.StatusDeleted)
+	}
+	if req.Type != "" {
+		query = query.Where("type = ?", req.Type)
+	}
+	if req.Channel != "" {
+		query = query.Where("channel = ?", req.Channel)
+	}
+	if req.Language != "" {
+		query = query.Where("language = ?", req.Language)
+	}
+	if req.Keyword != "" {
+		like := "%" + req.Keyword + "%"
+		query = query.Where("(title LIKE ? OR content LIKE ? OR description LIKE ?)", like, like, like)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var templates []*MessageTemplate
+	query.Order("type ASC, channel ASC, language ASC").
+		Offset((req.Page - 1) * req.Limit).
+		Limit(req.Limit).
+		Find(&templates)
+	return templates, total
+}
+
+// UpdateMessageTemplateStatus 条件更新模板状态
+func UpdateMessageTemplateStatus(templateID string, fromStatuses []string, status, operatorID string) (bool, error) {
+	result := GetDB().Model(&MessageTemplate{}).
+		Where("template_id = ? AND status IN ?", templateID, fromStatuses).
+		Updates(map[string]any{"status": status, "updated_by": operatorID})
+	return result.RowsAffected > 0, result.Error
+}
+
+// PublishMessageTemplateVersion 发布指定版本：原发布版本归档，版本内容写入模板并生效
+// 模板的 updated_at 随之更新，各实例据此热加载
+func PublishMessageTemplateVersion(tmpl *MessageTemplate, version *MessageTemplateVersion, operatorID string, now int64) error {
+	return GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&MessageTemplateVersion{}).
+			Where("template_id = ? AND status = ?", tmpl.TemplateID, protocol.StatusActive).
+			Update("status", protocol.StatusArchived).Error; err != nil {
+			return err
+		}
+		result := tx.Model(&MessageTemplateVersion{}).
+			Where("version_id = ? AND status IN ?", version.VersionID, []string{protocol.StatusDraft, protocol.StatusArchived}).
+			Updates(map[string]any{"status": protocol.StatusActive, "published_by": operatorID, "published_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Model(&MessageTemplate{}).
+			Where("template_id = ?", tmpl.TemplateID).
+			Updates(map[string]any{
+				"title":       version.Title,
+				"content":     version.Content,
+				"description": version.Description,
+				"version":     version.Version,
+				"status":      protocol.StatusActive,
+				"updated_by":  operatorID,
+			}).Error
+	})
+}
+
+// CreateMessageTemplateWithDraft 创建模板及其第一个草稿版本
+func CreateMessageTemplateWithDraft(tmpl *MessageTemplate, draft *MessageTemplateVersion) error {
+	return GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(tmpl).Error; err != nil {
+			return err
+		}
+		return tx.Create(draft).Error
+	})
+}
+
+// GetMessageTemplatesLastUpdatedAt 获取模板表最近的更新时间，用于判断是否需要重新加载
+func GetMessageTemplatesLastUpdatedAt() (int64, error) {
+	var lastUpdatedAt int64
+	err := GetDB().Model(&MessageTemplate{}).Select("COALESCE(MAX(updated_at), 0)").Scan(&lastUpdatedAt).Error
+	return lastUpdatedAt, err
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"greenride/internal/protocol"
+	"greenride/internal/utils"
+)
+
+// MessageTemplateVersion 消息模板版本 - 每个模板最多一个草稿，发布后成为当前版本，旧版本归档可回滚
+type MessageTemplateVersion struct {
+	ID          int64  `gorm:"column:id;primaryKey;autoIncrement"`
+	VersionID   string `gorm:"column:version_id;type:varchar(64);uniqueIndex"`
+	TemplateID  string `gorm:"column:template_id;type:varchar(64);index"`
+	Version     int    `gorm:"column:version"`
+	Title       string `gorm:"column:title;type:varchar(255)"`
+	Content     string `gorm:"column:content;type:text"`
+	Description string `gorm:"column:description;type:text"`
+	Status      string `gorm:"column:status;type:varchar(32);index"` // draft, active（当前发布）, archived
+	ChangeNote  string `gorm:"column:change_note;type:varchar(500)"`
+	CreatedBy   string `gorm:"column:created_by;type:varchar(64)"`
+	UpdatedBy   string `gorm:"column:updated_by;type:varchar(64)"`
+	PublishedBy string `gorm:"column:published_by;type:varchar(64)"`
+	PublishedAt int64  `gorm:"column:published_at"`
+	CreatedAt   int64  `gorm:"column:created_at;autoCreateTime:milli"`
+	UpdatedAt   int64  `gorm:"column:updated_at;autoUpdateTime:milli"`
+}
+
+func (MessageTemplateVersion) TableName() string {
+	return "t_message_template_versions"
+}
+
+// NewMessageTemplateVersion 创建草稿版本
+func NewMessageTemplateVersion(templateID string, version int) *MessageTemplateVersion {
+	return &MessageTemplateVersion{
+		VersionID:  utils.GenerateMessageTemplateVersionID(),
+		TemplateID: templateID,
+		Version:    version,
+		Status:     protocol.StatusDraft,
+	}
+}
+
+// Protocol 转换为协议对象
+func (v *MessageTemplateVersion) Protocol() *protocol.MessageTemplateVersion {
+	return &protocol.MessageTemplateVersion{
+		VersionID:   v.VersionID,
+		TemplateID:  v.TemplateID,
+		Version:     v.Version,
+		Title:       v.Title,
+		Content:     v.Content,
+		Description: v.Description,
+		Status:      v.Status,
+		ChangeNote:  v.ChangeNote,
+		CreatedBy:   v.CreatedBy,
+		UpdatedBy:   v.UpdatedBy,
+		PublishedBy: v.PublishedBy,
+		PublishedAt: v.PublishedAt,
+		CreatedAt:   v.CreatedAt,
+		UpdatedAt:   v.UpdatedAt,
+	}
+}
+
+// GetMessageTemplateVersions 获取模板的全部版本，新版本在前
+func GetMessageTemplateVersions(templateID string) []*MessageTemplateVersion {
+	var versions []*MessageTemplateVersion
+	GetDB().Where("template_id = ?", templateID).Order("version DESC").Find(&versions)
+	return versions
+}
+
+// GetMessageTemplateVersion 获取模板的指定版本
+func GetMessageTemplateVersion(templateID, versionID string) *MessageTemplateVersion {
+	var version MessageTemplateVersion
+	if err := GetDB().Where("template_id = ? AND version_id = ?", templateID, versionID).First(&version).Error; err != nil {
+		return nil
+	}
+	return &version
+}
+
+// GetMessageTemplateDraft 获取模板当前的草稿
+func GetMessageTemplateDraft(templateID string) *MessageTemplateVersion {
+	var version MessageTemplateVersion
+	if err := GetDB().Where("template_id = ? AND status = ?", templateID, protocol.StatusDraft).
+		Order("version DESC").First(&version).Error; err != nil {
+		return nil
+	}
+	return &version
+}
+
+// GetMaxMessageTemplateVersion 获取模板最大版本号
+func GetMaxMessageTemplateVersion(templateID string) int {
+	var maxVersion int
+	GetDB().Model(&MessageTemplateVersion{}).
+		Where("template_id = ?", templateID).
+		Select("COALESCE(MAX(version), 0)").
+		Scan(&maxVersion)
+	return maxVersion
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"greenride/internal/protocol"
 	"greenride/internal/utils"
+
+	"gorm.io/gorm"
 )
 
 // Notification 通知表 - 基于最新设计文档
@@ -551,3 +553,91 @@ func (n *Notification) ToMap() map[string]interface{} {
 
 	return result
 }
+
+// Protocol 转换为用户站内通知
+func (n *Notification) Protocol() *protocol.UserNotification {
+	result := &protocol.UserNotification{
+		NotificationID: n.NotificationID,
+		Type:           n.GetType(),
+		Category:       n.GetCategory(),
+		Title:          n.GetTitle(),
+		Content:        n.GetContent(),
+		RelatedType:    n.GetRelatedType(),
+		RelatedID:      n.GetRelatedID(),
+		IsRead:         n.GetIsRead(),
+		CreatedAt:      n.CreatedAt,
+	}
+	if n.ReadAt != nil {
+		result.ReadAt = *n.ReadAt
+	}
+	return result
+}
+
+// userInboxQuery 用户站内通知查询：只包含已到计划发送时间的通知
+func userInboxQuery(userID string) *gorm.DB {
+	return GetDB().Model(&Notification{}).
+		Where("user_id = ? AND (scheduled_at IS NULL OR scheduled_at <= ?)", userID, utils.TimeNowMilli())
+}
+
+// SearchUserNotifications 分页查询用户站内通知，新通知在前
+func SearchUserNotifications(userID, category string, unreadOnly bool, page, limit int) ([]*Notification, int64, error) {
+	query := userInboxQuery(userID)
+	if category != "" {
+		query = query.Where("category = ?", category)
+	}
+	if unreadOnly {
+		query = query.Where("is_read = ?", false)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var notifications []*Notification
+	err := query.Order("created_at DESC").Offset((page - 1) * limit).Limit(limit).Find(&notifications).Error
+	return notifications, total, err
+}
+
+// CountUserUnreadNotifications 按分类统计用户未读通知数
+func CountUserUnreadNotifications(userID string) (map[string]int64, error) {
+	var rows []struct {
+		Category string
+		Count    int64
+	}
+	err := userInboxQuery(userID).
+		Select("COALESCE(category, '') AS category, COUNT(*) AS count").
+		Where("is_read = ?", false).
+		Group("category").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Category] += row.Count
+	}
+	return counts, nil
+}
+
+// MarkUserNotificationsRead 标记用户通知已读，notificationIDs 为空时标记全部（可限定分类）
+func MarkUserNotificationsRead(userID string, notificationIDs []string, category string) (int64, error) {
+	query := userInboxQuery(userID).Where("is_read = ?", false)
+	if len(notificationIDs) > 0 {
+		query = query.Where("notification_id IN ?", notificationIDs)
+	}
+	if category != "" {
+		query = query.Where("category = ?", category)
+	}
+	result := query.Updates(map[string]any{
+		"is_read": true,
+		"read_at": utils.TimeNowMilli(),
+		"status":  NotificationStatusRead,
+	})
+	return result.RowsAffected, result.Error
+}
+
+// DeleteUserNotifications 删除用户自己的通知
+func DeleteUserNotifications(userID string, notificationIDs []string) (int64, error) {
+	result := GetDB().Where("user_id = ? AND notification_id IN ?", userID, notificationIDs).Delete(&Notification{})
+	return result.RowsAffected, result.Error
+}
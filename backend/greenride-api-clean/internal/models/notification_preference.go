@@ -0,0 +1,97 @@
+package models
+
+import (
+	"greenride/internal/protocol"
+	"greenride/internal/utils"
+)
+
+// NotificationPreference 用户推送偏好表 - 每个用户一条，未设置的分类默认开启
+type NotificationPreference struct {
+	ID     int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	UserID string `json:"user_id" gorm:"column:user_id;type:varchar(64);uniqueIndex"`
+	*NotificationPreferenceValues
+	CreatedAt int64 `json:"created_at" gorm:"column:created_at;autoCreateTime:milli"`
+}
+
+type NotificationPreferenceValues struct {
+	TripPush      *bool `json:"trip_push" gorm:"column:trip_push;default:true"`
+	PaymentPush   *bool `json:"payment_push" gorm:"column:payment_push;default:true"`
+	MarketingPush *bool `json:"marketing_push" gorm:"column:marketing_push;default:true"`
+
+	UpdatedAt int64 `json:"updated_at" gorm:"column:updated_at;autoUpdateTime:milli"`
+}
+
+func (NotificationPreference) TableName() string {
+	return "t_notification_preferences"
+}
+
+// NewNotificationPreference 创建默认全部开启的推送偏好
+func NewNotificationPreference(userID string) *NotificationPreference {
+	return &NotificationPreference{
+		UserID: userID,
+		NotificationPreferenceValues: &NotificationPreferenceValues{
+			TripPush:      utils.BoolPtr(true),
+			PaymentPush:   utils.BoolPtr(true),
+			MarketingPush: utils.BoolPtr(true),
+		},
+	}
+}
+
+func (p *NotificationPreferenceValues) GetTripPush() bool {
+	return p.TripPush == nil || *p.TripPush
+}
+
+func (p *NotificationPreferenceValues) GetPaymentPush() bool {
+	return p.PaymentPush == nil || *p.PaymentPush
+}
+
+func (p *NotificationPreferenceValues) GetMarketingPush() bool {
+	return p.MarketingPush == nil || *p.MarketingPush
+}
+
+// PushEnabled 指定分类是否推送，系统类通知不可关闭
+func (p *NotificationPreferenceValues) PushEnabled(category string) bool {
+	switch category {
+	case protocol.NotificationCategoryTrip:
+		return p.GetTripPush()
+	case protocol.NotificationCategoryPayment:
+		return p.GetPaymentPush()
+	case protocol.NotificationCategoryMarketing:
+		return p.GetMarketingPush()
+	default:
+		return true
+	}
+}
+
+// Protocol 转换为协议对象
+func (p *NotificationPreference) Protocol() *protocol.NotificationPreferences {
+	return &protocol.NotificationPreferences{
+		Trip:      p.GetTripPush(),
+		Payment:   p.GetPaymentPush(),
+		Marketing: p.GetMarketingPush(),
+	}
+}
+
+// GetNotificationPreference 获取用户推送偏好，未设置过时返回默认值
+func GetNotificationPreference(userID string) *NotificationPreference {
+	var pref NotificationPreference
+	if err := GetDB().Where("user_id = ?", userID).First(&pref).Error; err != nil {
+		return NewNotificationPreference(userID)
+	}
+	if pref.NotificationPreferenceValues == nil {
+		pref.NotificationPreferenceValues = &NotificationPreferenceValues{}
+	}
+	return &pref
+}
+
+// SaveNotificationPreference 保存用户推送偏好
+func SaveNotificationPreference(pref *NotificationPreference) error {
+	if pref.ID > 0 {
+		return GetDB().Model(&NotificationPreference{}).Where("id = ?", pref.ID).Updates(map[string]any{
+			"trip_push":      pref.GetTripPush(),
+			"payment_push":   pref.GetPaymentPush(),
+			"marketing_push": pref.GetMarketingPush(),
+		}).Error
+	}
+	return GetDB().Create(pref).Error
+}
@@ -69,6 +69,9 @@ type OrderValues struct {
 	CancelReason    *string          `json:"cancel_reason" gorm:"column:cancel_reason;type:varchar(255)"`
 	CancellationFee *decimal.Decimal `json:"cancellation_fee" gorm:"column:cancellation_fee;type:decimal(20,6)"`
 
+	// 小费信息 - 单独支付，全额归司机，不计入 payment_amount 和平台费用
+	TipAmount *decimal.Decimal `json:"tip_amount" gorm:"column:tip_amount;type:decimal(20,6)"`
+
 	// 评价信息 - 已改用单独的Rating表，不再保存评价信息
 
 	*OrderDispatchValues
@@ -232,6 +235,9 @@ func (o *OrderValues) SetValues(values *OrderValues) {
 	if values.CancellationFee != nil {
 		o.CancellationFee = values.CancellationFee
 	}
+	if values.TipAmount != nil {
+		o.TipAmount = values.TipAmount
+	}
 
 	// 派单信息 (OrderDispatchValues)
 	if values.OrderDispatchValues != nil {
@@ -496,6 +502,13 @@ func (o *OrderValues) GetCancellationFee() decimal.Decimal {
 	return *o.CancellationFee
 }
 
+func (o *OrderValues) GetTipAmount() decimal.Decimal {
+	if o.TipAmount == nil {
+		return decimal.Zero
+	}
+	return *o.TipAmount
+}
+
 func (o *OrderValues) GetDispatchStatus() string {
 	if o.DispatchStatus == nil {
 		return "not_started"
@@ -732,6 +745,11 @@ func (o *OrderValues) SetCancellationFee(fee decimal.Decimal) *OrderValues {
 	return o
 }
 
+func (o *OrderValues) SetTipAmount(amount decimal.Decimal) *OrderValues {
+	o.TipAmount = &amount
+	return o
+}
+
 func (o *OrderValues) SetDispatchStatus(status string) *OrderValues {
 	o.DispatchStatus = &status
 	return o
@@ -1035,6 +1053,7 @@ func (o *Order) Protocol() *protocol.Order {
 	platformFee, _ := o.GetPlatformFee().Float64()
 	promoDiscount, _ := o.GetPromoDiscount().Float64()
 	cancellationFee, _ := o.GetCancellationFee().Float64()
+	tipAmount, _ := o.GetTipAmount().Float64()
 
 	info := &protocol.Order{
 		OrderID:             o.OrderID,
@@ -1050,6 +1069,7 @@ func (o *Order) Protocol() *protocol.Order {
 		PlatformFee:         platformFee,
 		PromoDiscount:       promoDiscount,
 		CancellationFee:     cancellationFee,
+		TipAmount:           tipAmount,
 		Currency:            o.GetCurrency(),
 		PaymentMethod:       o.GetPaymentMethod(),
 		PaymentID:           o.GetPaymentID(),
@@ -76,6 +76,8 @@ func GetTableNameByOrderType(orderType string) string {
 	switch orderType {
 	case "ride":
 		return "t_ride_orders"
+	case "delivery":
+		return "t_delivery_orders"
 	default:
 		return "" // 默认表名
 	}
@@ -0,0 +1,267 @@
+package models
+
+import (
+	"errors"
+
+	"greenride/internal/protocol"
+	"greenride/internal/utils"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// OrderTip 行程小费表 - 每个订单最多一笔，支付失败后可在同一记录上重新发起
+type OrderTip struct {
+	ID    int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	TipID string `json:"tip_id" gorm:"column:tip_id;type:varchar(64);uniqueIndex"`
+	*OrderTipValues
+	CreatedAt int64 `json:"created_at" gorm:"column:created_at;autoCreateTime:milli"`
+}
+
+type OrderTipValues struct {
+	OrderID       *string          `json:"order_id" gorm:"column:order_id;type:varchar(64);uniqueIndex"`
+	UserID        *string          `json:"user_id" gorm:"column:user_id;type:varchar(64);index"`     // 给小费的乘客
+	DriverID      *string          `json:"driver_id" gorm:"column:driver_id;type:varchar(64);index"` // 收小费的司机
+	Amount        *decimal.Decimal `json:"amount" gorm:"column:amount;type:decimal(20,6)"`
+	Currency      *string          `json:"currency" gorm:"column:currency;type:varchar(3)"`
+	PaymentMethod *string          `json:"payment_method" gorm:"column:payment_method;type:varchar(32)"`
+	PaymentID     *string          `json:"payment_id" gorm:"column:payment_id;type:varchar(64)"`                 // 线上支付记录ID（支付记录的order_id为小费ID）
+	Status        *string          `json:"status" gorm:"column:status;type:varchar(32);index;default:'pending'"` // pending, success, failed
+	Reason        *string          `json:"reason" gorm:"column:reason;type:varchar(255)"`                        // 支付失败原因
+	RedirectURL   *string          `json:"redirect_url" gorm:"column:redirect_url;type:varchar(512)"`
+	TransactionID *string          `json:"transaction_id" gorm:"column:transaction_id;type:varchar(64)"` // 司机钱包入账流水ID，现金小费为空；线上小费为空表示已支付待入账
+	PaidAt        *int64           `json:"paid_at" gorm:"column:paid_at;index"`
+	UpdatedAt     int64            `json:"updated_at" gorm:"column:updated_at;autoUpdateTime:milli"`
+}
+
+func (OrderTip) TableName() string {
+	return "t_order_tips"
+}
+
+// NewOrderTip 创建新的行程小费
+func NewOrderTip(orderID, userID, driverID string) *OrderTip {
+	return &OrderTip{
+		TipID: utils.GenerateOrderTipID(),
+		OrderTipValues: &OrderTipValues{
+			OrderID:  utils.StringPtr(orderID),
+			UserID:   utils.StringPtr(userID),
+			DriverID: utils.StringPtr(driverID),
+			Status:   utils.StringPtr(protocol.TipStatusPending),
+		},
+	}
+}
+
+func (t *OrderTipValues) GetOrderID() string {
+	if t.OrderID == nil {
+		return ""
+	}
+	return *t.OrderID
+}
+
+func (t *OrderTipValues) GetUserID() string {
+	if t.UserID == nil {
+		return ""
+	}
+	return *t.UserID
+}
+
+func (t *OrderTipValues) GetDriverID() string {
+	if t.DriverID == nil {
+		return ""
+	}
+	return *t.DriverID
+}
+
+func (t *OrderTipValues) GetAmount() decimal.Decimal {
+	if t.Amount == nil {
+		return decimal.Zero
+	}
+	return *t.Amount
+}
+
+func (t *OrderTipValues) GetCurrency() string {
+	if t.Currency == nil {
+		return ""
+	}
+	return *t.Currency
+}
+
+func (t *OrderTipValues) GetPaymentMethod() string {
+	if t.PaymentMethod == nil {
+		return ""
+	}
+	return *t.PaymentMethod
+}
+
+func (t *OrderTipValues) GetPaymentID() string {
+	if t.PaymentID == nil {
+		return ""
+	}
+	return *t.PaymentID
+}
+
+func (t *OrderTipValues) GetStatus() string {
+	if t.Status == nil {
+		return ""
+	}
+	return *t.Status
+}
+
+func (t *OrderTipValues) GetReason() string {
+	if t.Reason == nil {
+		return ""
+	}
+	return *t.Reason
+}
+
+func (t *OrderTipValues) GetRedirectURL() string {
+	if t.RedirectURL == nil {
+		return ""
+	}
+	return *t.RedirectURL
+}
+
+func (t *OrderTipValues) GetTransactionID() string {
+	if t.TransactionID == nil {
+		return ""
+	}
+	return *t.TransactionID
+}
+
+func (t *OrderTipValues) GetPaidAt() int64 {
+	if t.PaidAt == nil {
+		return 0
+	}
+	return *t.PaidAt
+}
+
+// SetAmount 设置小费金额和币种
+func (t *OrderTipValues) SetAmount(amount decimal.Decimal, currency string) *OrderTipValues {
+	t.Amount = &amount
+	t.Currency = &currency
+	return t
+}
+
+func (t *OrderTipValues) SetPaymentMethod(method string) *OrderTipValues {
+	t.PaymentMethod = &method
+	return t
+}
+
+func (t *OrderTipValues) SetStatus(status string) *OrderTipValues {
+	t.Status = &status
+	return t
+}
+
+// Protocol 转换为协议对象
+func (t *OrderTip) Protocol() *protocol.Tip {
+	amount, _ := t.GetAmount().Float64()
+	return &protocol.Tip{
+		TipID:         t.TipID,
+		OrderID:       t.GetOrderID(),
+		UserID:        t.GetUserID(),
+		DriverID:      t.GetDriverID(),
+		Amount:        amount,
+		Currency:      t.GetCurrency(),
+		PaymentMethod: t.GetPaymentMethod(),
+		PaymentID:     t.GetPaymentID(),
+		Status:        t.GetStatus(),
+		Reason:        t.GetReason(),
+		RedirectURL:   t.GetRedirectURL(),
+		PaidAt:        t.GetPaidAt(),
+		CreatedAt:     t.CreatedAt,
+	}
+}
+
+// GetOrderTipByID 根据小费ID获取
+func GetOrderTipByID(tipID string) *OrderTip {
+	var tip OrderTip
+	if err := GetDB().Where("tip_id = ?", tipID).First(&tip).Error; err != nil {
+		return nil
+	}
+	return &tip
+}
+
+// GetOrderTipByOrderID 获取订单的小费
+func GetOrderTipByOrderID(orderID string) *OrderTip {
+	var tip OrderTip
+	if err := GetDB().Where("order_id = ?", orderID).First(&tip).Error; err != nil {
+		return nil
+	}
+	return &tip
+}
+
+// SaveOrderTip 新建或在原记录上重新发起小费
+func SaveOrderTip(tx *gorm.DB, tip *OrderTip) error {
+	if tip.ID == 0 {
+		return tx.Create(tip).Error
+	}
+	return tx.Model(&OrderTip{}).Where("tip_id = ?", tip.TipID).Updates(map[string]any{
+		"amount":         tip.GetAmount(),
+		"currency":       tip.GetCurrency(),
+		"payment_method": tip.GetPaymentMethod(),
+		"payment_id":     "",
+		"status":         tip.GetStatus(),
+		"reason":         "",
+		"redirect_url":   "",
+	}).Error
+}
+
+// TransitionOrderTipStatus 条件更新小费状态，返回是否更新成功（用于防止重复入账）
+func TransitionOrderTipStatus(tipID string, fromStatuses []string, updates map[string]any) (bool, error) {
+	result := GetDB().Model(&OrderTip{}).
+		Where("tip_id = ? AND status IN ?", tipID, fromStatuses).
+		Updates(updates)
+	return result.RowsAffected > 0, result.Error
+}
+
+// ErrOrderTipCredited 小费已入账或不是可入账的已支付小费
+var ErrOrderTipCredited = errors.New("order tip already credited")
+
+// CreditOrderTip 已支付的线上小费入账司机钱包，钱包余额、入账流水和小费的transaction_id在同一事务内写入
+// 小费不是success或已记录入账流水时回滚并返回ErrOrderTipCredited，重复调用不会重复入账
+func CreditOrderTip(tip *OrderTip) (*WalletTransaction, error) {
+	amount, _ := tip.GetAmount().Float64()
+	var transaction *WalletTransaction
+	err := GetDB().Transaction(func(tx *gorm.DB) error {
+		var err error
+		transaction, err = creditWalletTx(tx, tip.GetDriverID(), protocol.UserTypeDriver, tip.GetCurrency(), amount, func(walletID string) *WalletTransaction {
+			transaction := NewWalletTransactionV2()
+			transaction.SetAccountID(walletID).
+				SetUserID(tip.GetDriverID()).
+				SetType(TransactionTypeIncome).
+				SetCategory(TransactionCategoryTip).
+				SetAmount(amount).
+				SetTitle("乘客小费").
+				SetRelated("order_tip", tip.TipID)
+			return transaction
+		})
+		if err != nil {
+			return err
+		}
+		result := tx.Model(&OrderTip{}).
+			Where("tip_id = ? AND status = ? AND (transaction_id IS NULL OR transaction_id = '')", tip.TipID, protocol.TipStatusSuccess).
+			Update("transaction_id", transaction.TransactionID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrOrderTipCredited
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+// GetUncreditedOrderTips 已支付但尚未入账司机钱包的线上小费，paid_at 不晚于 paidBefore
+func GetUncreditedOrderTips(paidBefore int64, limit int) []*OrderTip {
+	var tips []*OrderTip
+	GetDB().Where("status = ? AND payment_method <> ? AND (transaction_id IS NULL OR transaction_id = '') AND paid_at <= ?",
+		protocol.TipStatusSuccess, protocol.PaymentMethodCash, paidBefore).
+		Order("paid_at ASC").
+		Limit(limit).
+		Find(&tips)
+	return tips
+}
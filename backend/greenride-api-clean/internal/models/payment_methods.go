@@ -293,6 +293,67 @@ func (p *PaymentMethodValues) GetRequireAuth() bool {
 	return *p.RequireAuth
 }
 
+func (p *PaymentMethodValues) GetSubType() string {
+	if p.SubType == nil {
+		return ""
+	}
+	return *p.SubType
+}
+
+func (p *PaymentMethodValues) GetPhoneNumber() string {
+	if p.PhoneNumber == nil {
+		return ""
+	}
+	return *p.PhoneNumber
+}
+
+func (p *PaymentMethodValues) GetDisplayName() string {
+	if p.DisplayName == nil {
+		return ""
+	}
+	return *p.DisplayName
+}
+
+func (p *PaymentMethodValues) GetExpiryMonth() int {
+	if p.ExpiryMonth == nil {
+		return 0
+	}
+	return *p.ExpiryMonth
+}
+
+func (p *PaymentMethodValues) GetExpiryYear() int {
+	if p.ExpiryYear == nil {
+		return 0
+	}
+	return *p.ExpiryYear
+}
+
+func (p *PaymentMethodValues) GetStripeCustomerID() string {
+	if p.StripeCustomerID == nil {
+		return ""
+	}
+	return *p.StripeCustomerID
+}
+
+func (p *PaymentMethodValues) GetStripePaymentMethodID() string {
+	if p.StripePaymentMethodID == nil {
+		return ""
+	}
+	return *p.StripePaymentMethodID
+}
+
+// GetMetadata 获取附加元数据（如Stripe渠道账户、SetupIntent、验证支付ID）
+func (p *PaymentMethodValues) GetMetadata() protocol.MapData {
+	metadata := protocol.MapData{}
+	if p.Metadata == nil || *p.Metadata == "" {
+		return metadata
+	}
+	if err := utils.FromJSON(*p.Metadata, &metadata); err != nil {
+		return protocol.MapData{}
+	}
+	return metadata
+}
+
 // Setter 方法
 func (p *PaymentMethodValues) SetUserID(userID string) *PaymentMethodValues {
 	p.UserID = &userID
@@ -344,6 +405,29 @@ func (p *PaymentMethodValues) SetMobileInfo(phoneNumber, carrier string) *Paymen
 	return p
 }
 
+// SetStripeCard 记录Stripe客户和卡的令牌及脱敏信息，不保存卡号
+func (p *PaymentMethodValues) SetStripeCard(customerID, paymentMethodID, brand, last4 string, expiryMonth, expiryYear int) *PaymentMethodValues {
+	p.StripeCustomerID = &customerID
+	p.StripePaymentMethodID = &paymentMethodID
+	p.SubType = &brand
+	p.ExpiryMonth = &expiryMonth
+	p.ExpiryYear = &expiryYear
+	masked := "****" + last4
+	p.MaskedNumber = &masked
+	name := masked
+	if brand != "" {
+		name = strings.ToUpper(brand[:1]) + brand[1:] + " " + masked
+	}
+	p.DisplayName = &name
+	return p
+}
+
+func (p *PaymentMethodValues) SetMetadata(metadata protocol.MapData) *PaymentMethodValues {
+	raw := metadata.ToJson()
+	p.Metadata = &raw
+	return p
+}
+
 func (p *PaymentMethodValues) SetStatus(status string) *PaymentMethodValues {
 	p.Status = &status
 	return p
@@ -692,3 +776,36 @@ func NewPaypalPaymentMethod(userID, email string) *PaymentMethod {
 
 	return payment
 }
+
+// GetPaymentMethodByID 根据支付方式ID获取
+func GetPaymentMethodByID(paymentMethodID string) *PaymentMethod {
+	var method PaymentMethod
+	if err := GetDB().Where("payment_method_id = ?", paymentMethodID).First(&method).Error; err != nil {
+		return nil
+	}
+	return &method
+}
+
+// GetUserStripeCustomerID 获取用户在Stripe渠道账户下已创建的客户ID，同一渠道账户下的卡共用一个客户
+func GetUserStripeCustomerID(userID, channelAccountID string) string {
+	var methods []*PaymentMethod
+	if err := GetDB().Where("user_id = ? AND payment_type = ? AND stripe_customer_id <> ''", userID, PaymentTypeCreditCard).
+		Order("id DESC").Find(&methods).Error; err != nil {
+		return ""
+	}
+	for _, method := range methods {
+		if method.GetMetadata().Get("channel_account_id") == channelAccountID {
+			return method.GetStripeCustomerID()
+		}
+	}
+	return ""
+}
+
+// UpdatePaymentMethod 更新支付方式中的非nil字段
+func UpdatePaymentMethod(method *PaymentMethod, values *PaymentMethodValues) error {
+	if err := GetDB().Model(method).UpdateColumns(values).Error; err != nil {
+		return err
+	}
+	method.SetValues(values)
+	return nil
+}
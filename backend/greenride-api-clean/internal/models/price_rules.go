@@ -85,7 +85,7 @@ type PriceRuleValues struct {
 	// 条件限制
 	MinDistance *float64 `json:"min_distance" gorm:"column:min_distance;type:decimal(8,2)"` // 最小距离
 	MaxDistance *float64 `json:"max_distance" gorm:"column:max_distance;type:decimal(8,2)"` // 最大距离
-	MinDuration *int     `json:"min_duration" gorm:"column:min_duration;type:int"`          // 最小时长(分钟)
+	MinDuration *int     `json:"min_duration" gorm:"column:min_duration;type:int"`          // 最小时长(分钟)，等候费规则中为免费等候分钟数
 	MaxDuration *int     `json:"max_duration" gorm:"column:max_duration;type:int"`          // 最大时长(分钟)
 
 	// 组合规则
@@ -33,6 +33,8 @@ type PriceSnapshotValues struct {
 	TimeFare       *decimal.Decimal `json:"time_fare" gorm:"column:time_fare;type:decimal(20,6)"`
 	SurgeFare      *decimal.Decimal `json:"surge_fare" gorm:"column:surge_fare;type:decimal(20,6)"`
 	ServiceFee     *decimal.Decimal `json:"service_fee" gorm:"column:service_fee;type:decimal(20,6)"`
+	WaitingFare    *decimal.Decimal `json:"waiting_fare" gorm:"column:waiting_fare;type:decimal(20,6)"`      // 上车点等候费，结束行程时写入
+	ParcelFare     *decimal.Decimal `json:"parcel_fare" gorm:"column:parcel_fare;type:decimal(20,6)"`        // 包裹费，仅配送订单
 	OriginalFare   *decimal.Decimal `json:"original_fare" gorm:"column:total_fare;type:decimal(20,6)"`       // 优惠前原始费用
 	DiscountedFare *decimal.Decimal `json:"discounted_fare" gorm:"column:estimated_fare;type:decimal(20,6)"` // 优惠后折扣费用
 	Currency       *string          `json:"currency" gorm:"column:currency;type:varchar(3);default:'RWF'"`
@@ -154,6 +156,14 @@ func (p *PriceSnapshotValues) GetServiceFee() decimal.Decimal {
 	return utils.SafeDecimalDeref(p.ServiceFee)
 }
 
+func (p *PriceSnapshotValues) GetWaitingFare() decimal.Decimal {
+	return utils.SafeDecimalDeref(p.WaitingFare)
+}
+
+func (p *PriceSnapshotValues) GetParcelFare() decimal.Decimal {
+	return utils.SafeDecimalDeref(p.ParcelFare)
+}
+
 func (p *PriceSnapshotValues) GetDiscountedFare() decimal.Decimal {
 	return utils.SafeDecimalDeref(p.DiscountedFare)
 }
@@ -334,6 +344,16 @@ func (p *PriceSnapshotValues) SetServiceFee(fee decimal.Decimal) *PriceSnapshotV
 	return p
 }
 
+func (p *PriceSnapshotValues) SetWaitingFare(fare decimal.Decimal) *PriceSnapshotValues {
+	p.WaitingFare = &fare
+	return p
+}
+
+func (p *PriceSnapshotValues) SetParcelFare(fare decimal.Decimal) *PriceSnapshotValues {
+	p.ParcelFare = &fare
+	return p
+}
+
 func (p *PriceSnapshotValues) SetDiscountedFare(fare decimal.Decimal) *PriceSnapshotValues {
 	p.DiscountedFare = &fare
 	return p
@@ -471,6 +491,39 @@ func UpdatePriceOrderID(tx *gorm.DB, snapshotID, orderID string) error {
 	return tx.Model(&PriceSnapshot{}).Where("snapshot_id = ?", snapshotID).UpdateColumns(values).Error
 }
 
+// UpdatePriceSnapshot 在事务中更新价格快照
+func UpdatePriceSnapshot(tx *gorm.DB, snapshotID string, values *PriceSnapshotValues) error {
+	return tx.Model(&PriceSnapshot{}).Where("snapshot_id = ?", snapshotID).UpdateColumns(values).Error
+}
+
+// AddPriceSnapshotWaitingFare 在事务中将等候费计入价格快照：累加原始价与折后价，并追加（或替换）等候费明细
+func AddPriceSnapshotWaitingFare(tx *gorm.DB, snapshotID string, breakdown *protocol.PriceRuleResult) error {
+	if snapshotID == "" || breakdown == nil {
+		return nil
+	}
+	var snapshot PriceSnapshot
+	if err := tx.Where("snapshot_id = ?", snapshotID).First(&snapshot).Error; err != nil {
+		return err
+	}
+	amount := decimal.NewFromFloat(breakdown.Amount)
+	previous := snapshot.GetWaitingFare()
+
+	breakdowns := []*protocol.PriceRuleResult{}
+	for _, item := range snapshot.GetBreakdowns() {
+		if item.Category != protocol.PriceRuleCategoryWaitingFare {
+			breakdowns = append(breakdowns, item)
+		}
+	}
+	breakdowns = append(breakdowns, breakdown)
+
+	values := &PriceSnapshotValues{}
+	values.SetWaitingFare(amount).
+		SetOriginalFare(snapshot.GetOriginalFare().Sub(previous).Add(amount)).
+		SetDiscountedFare(snapshot.GetDiscountedFare().Sub(previous).Add(amount)).
+		SetBreakdowns(breakdowns)
+	return tx.Model(&PriceSnapshot{}).Where("snapshot_id = ?", snapshotID).UpdateColumns(values).Error
+}
+
 func (t *PriceSnapshot) Protocol() *protocol.OrderPrice {
 	if t == nil {
 		return nil
@@ -496,6 +549,8 @@ func (t *PriceSnapshot) Protocol() *protocol.OrderPrice {
 			TimeFare:          toFloat64(t.GetTimeFare()),
 			SurgeFare:         toFloat64(t.GetSurgeFare()),
 			ServiceFee:        toFloat64(t.GetServiceFee()),
+			WaitingFare:       toFloat64(t.GetWaitingFare()),
+			ParcelFare:        toFloat64(t.GetParcelFare()),
 			DiscountedFare:    toFloat64(t.GetDiscountedFare()),
 			DiscountAmount:    func() float64 { val, _ := t.GetDiscountAmount().Float64(); return val }(),
 			PromoDiscount:     func() float64 { val, _ := t.GetPromoDiscount().Float64(); return val }(),
@@ -1,10 +1,25 @@
 package models
 
 import (
+	"errors"
 	"greenride/internal/protocol"
 	"greenride/internal/utils"
 	"log"
+	"slices"
+	"strings"
 	"time"
+
+	"gorm.io/gorm"
+)
+
+// PromotionStatusReasonBudgetExhausted 预算用完自动停用的状态原因
+const PromotionStatusReasonBudgetExhausted = "budget_exhausted"
+
+var (
+	// ErrPromotionBudgetExceeded 优惠预算已用完，无法核销
+	ErrPromotionBudgetExceeded = errors.New("promotion budget exceeded")
+	// ErrPromotionUnavailable 优惠已停用或预算已用完，无法发放
+	ErrPromotionUnavailable = errors.New("promotion is not available for issuance")
 )
 
 // Promotion 促销代码表 - 简化版优惠券管理
@@ -32,6 +47,10 @@ type PromotionValues struct {
 	UsageCount     *int `json:"usage_count" gorm:"column:usage_count;type:int;default:0"`           // 已使用次数
 	UserUsageLimit *int `json:"user_usage_limit" gorm:"column:user_usage_limit;type:int;default:1"` // 单用户使用限制
 
+	// 预算控制
+	BudgetAmount *float64 `json:"budget_amount" gorm:"column:budget_amount;type:decimal(12,2)"`            // 优惠总预算，为空或0表示不限
+	SpentAmount  *float64 `json:"spent_amount" gorm:"column:spent_amount;type:decimal(12,2);default:0.00"` // 已核销优惠金额
+
 	// 时间限制
 	StartDate *int64 `json:"start_date" gorm:"column:start_date"` // 开始时间
 	EndDate   *int64 `json:"end_date" gorm:"column:end_date"`     // 结束时间
@@ -54,6 +73,7 @@ type PromotionValues struct {
 	ApprovedBy     *string `json:"approved_by" gorm:"column:approved_by;type:varchar(100)"`                          // 审批者
 	ApprovedAt     *int64  `json:"approved_at" gorm:"column:approved_at"`                                            // 审批时间
 	ApprovalNotes  *string `json:"approval_notes" gorm:"column:approval_notes;type:text"`                            // 审批备注
+	SubmittedBy    *string `json:"submitted_by" gorm:"column:submitted_by;type:varchar(500)"`                        // 待审批修改的提交者，多人修改时逗号分隔
 
 	// 管理信息
 	CreatedBy *string `json:"created_by" gorm:"column:created_by;type:varchar(100)"` // 创建者
@@ -156,6 +176,20 @@ func (p *PromotionValues) GetUserUsageLimit() int {
 	return *p.UserUsageLimit
 }
 
+func (p *PromotionValues) GetBudgetAmount() float64 {
+	if p.BudgetAmount == nil {
+		return 0.0
+	}
+	return *p.BudgetAmount
+}
+
+func (p *PromotionValues) GetSpentAmount() float64 {
+	if p.SpentAmount == nil {
+		return 0.0
+	}
+	return *p.SpentAmount
+}
+
 func (p *PromotionValues) GetStatus() string {
 	if p.Status == nil {
 		return protocol.StatusInactive
@@ -254,6 +288,13 @@ func (p *PromotionValues) GetApprovalNotes() string {
 	return *p.ApprovalNotes
 }
 
+func (p *PromotionValues) GetSubmittedBy() string {
+	if p.SubmittedBy == nil {
+		return ""
+	}
+	return *p.SubmittedBy
+}
+
 func (p *PromotionValues) GetUpdatedBy() string {
 	if p.UpdatedBy == nil {
 		return ""
@@ -345,6 +386,11 @@ func (p *PromotionValues) SetUsageCount(count int) *PromotionValues {
 	return p
 }
 
+func (p *PromotionValues) SetBudgetAmount(budget float64) *PromotionValues {
+	p.BudgetAmount = &budget
+	return p
+}
+
 func (p *PromotionValues) SetUserUsageLimit(limit int) *PromotionValues {
 	p.UserUsageLimit = &limit
 	return p
@@ -438,8 +484,21 @@ func (p *Promotion) IsUsageExceeded() bool {
 	return p.GetUsageCount() >= usageLimit
 }
 
+// IsBudgetExhausted 已核销金额达到预算上限
+func (p *Promotion) IsBudgetExhausted() bool {
+	budget := p.GetBudgetAmount()
+	if budget <= 0 {
+		return false
+	}
+	return p.GetSpentAmount() >= budget
+}
+
+func (p *Promotion) IsApproved() bool {
+	return p.GetApprovalStatus() == protocol.StatusApproved
+}
+
 func (p *Promotion) IsValid() bool {
-	return p.IsActive() && !p.IsUsageExceeded()
+	return p.IsActive() && !p.IsUsageExceeded() && !p.IsBudgetExhausted()
 }
 
 func (p *Promotion) IsPercentageDiscount() bool {
@@ -519,11 +578,28 @@ func (p *PromotionValues) Delete() *PromotionValues {
 }
 
 // 审批状态管理
-func (p *PromotionValues) MarkPendingApproval() *PromotionValues {
+// MarkPendingApproval 标记待审批并记录提交者，待审批期间多人修改时累计所有提交者
+func (p *PromotionValues) MarkPendingApproval(submittedBy string) *PromotionValues {
+	submitters := []string{}
+	if p.GetApprovalStatus() == protocol.StatusPending && p.GetSubmittedBy() != "" {
+		submitters = strings.Split(p.GetSubmittedBy(), ",")
+	}
+	if submittedBy != "" && !slices.Contains(submitters, submittedBy) {
+		submitters = append(submitters, submittedBy)
+	}
 	p.SetApprovalStatus(protocol.StatusPending)
+	p.SubmittedBy = utils.StringPtr(strings.Join(submitters, ","))
 	return p
 }
 
+// IsApprovalSubmitter 是否为待审批修改的提交者，未记录提交者的旧数据按最后更新者判断
+func (p *PromotionValues) IsApprovalSubmitter(userID string) bool {
+	if p.GetSubmittedBy() == "" {
+		return p.GetUpdatedBy() == userID
+	}
+	return slices.Contains(strings.Split(p.GetSubmittedBy(), ","), userID)
+}
+
 func (p *PromotionValues) Approve(approvedBy string, notes string) *PromotionValues {
 	currentTime := time.Now().Unix()
 	p.SetApprovalStatus(protocol.StatusApproved).
@@ -610,6 +686,8 @@ func (p *Promotion) Protocol() *protocol.Promotion {
 		UsageLimit:     p.GetUsageLimit(),
 		UsageCount:     p.GetUsageCount(),
 		UserUsageLimit: p.GetUserUsageLimit(),
+		BudgetAmount:   p.GetBudgetAmount(),
+		SpentAmount:    p.GetSpentAmount(),
 
 		StartDate:         p.GetStartDate(),
 		EndDate:           p.GetEndDate(),
@@ -629,6 +707,89 @@ func (p *Promotion) Protocol() *protocol.Promotion {
 	}
 }
 
+// ConsumePromotionBudget 核销优惠券时累加已用金额和使用次数
+// 预算为软上限：已用金额未达上限时允许本次核销，达到上限后自动停用该优惠，停止发放和核销
+func ConsumePromotionBudget(tx *gorm.DB, promotionID string, amount float64) error {
+	if amount < 0 {
+		amount = -amount
+	}
+	result := tx.Model(&Promotion{}).
+		Where("promotion_id = ?", promotionID).
+		Where("budget_amount IS NULL OR budget_amount <= 0 OR COALESCE(spent_amount, 0) < budget_amount").
+		UpdateColumns(map[string]any{
+			"spent_amount": gorm.Expr("COALESCE(spent_amount, 0) + ?", amount),
+			"usage_count":  gorm.Expr("COALESCE(usage_count, 0) + 1"),
+			"updated_at":   utils.TimeNowMilli(),
+		})
+	if result.Error != nil {
+		log.Printf("Failed to consume promotion budget %s: %v", promotionID, result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		var count int64
+		tx.Model(&Promotion{}).Where("promotion_id = ?", promotionID).Count(&count)
+		if count == 0 {
+			return nil
+		}
+		return ErrPromotionBudgetExceeded
+	}
+
+	return tx.Model(&Promotion{}).
+		Where("promotion_id = ? AND status = ?", promotionID, protocol.StatusActive).
+		Where("budget_amount > 0 AND spent_amount >= budget_amount").
+		UpdateColumns(map[string]any{
+			"status":        protocol.StatusSuspended,
+			"status_reason": PromotionStatusReasonBudgetExhausted,
+			"suspended_at":  time.Now().Unix(),
+			"updated_at":    utils.TimeNowMilli(),
+		}).Error
+}
+
+// ReleasePromotionBudget 订单取消时退回已用金额，因预算用完停用的优惠在预算恢复后重新启用
+func ReleasePromotionBudget(tx *gorm.DB, promotionID string, amount float64) error {
+	if amount < 0 {
+		amount = -amount
+	}
+	if err := tx.Model(&Promotion{}).
+		Where("promotion_id = ?", promotionID).
+		UpdateColumns(map[string]any{
+			"spent_amount": gorm.Expr("GREATEST(COALESCE(spent_amount, 0) - ?, 0)", amount),
+			"usage_count":  gorm.Expr("GREATEST(COALESCE(usage_count, 0) - 1, 0)"),
+			"updated_at":   utils.TimeNowMilli(),
+		}).Error; err != nil {
+		log.Printf("Failed to release promotion budget %s: %v", promotionID, err)
+		return err
+	}
+
+	return tx.Model(&Promotion{}).
+		Where("promotion_id = ? AND status = ? AND status_reason = ?", promotionID, protocol.StatusSuspended, PromotionStatusReasonBudgetExhausted).
+		Where("spent_amount < budget_amount").
+		UpdateColumns(map[string]any{
+			"status":        protocol.StatusActive,
+			"status_reason": "",
+			"updated_at":    utils.TimeNowMilli(),
+		}).Error
+}
+
+// GetBudgetExhaustedPromotionIDs 返回预算已用完的优惠ID集合
+func GetBudgetExhaustedPromotionIDs(promotionIDs []string) map[string]bool {
+	exhausted := make(map[string]bool)
+	if len(promotionIDs) == 0 {
+		return exhausted
+	}
+	var ids []string
+	if err := GetDB().Model(&Promotion{}).
+		Where("promotion_id IN ? AND budget_amount > 0 AND spent_amount >= budget_amount", promotionIDs).
+		Pluck("promotion_id", &ids).Error; err != nil {
+		log.Printf("Failed to query exhausted promotions: %v", err)
+		return exhausted
+	}
+	for _, id := range ids {
+		exhausted[id] = true
+	}
+	return exhausted
+}
+
 // GetReferralPromotionTemplate 获取推荐优惠券模板
 func GetReferralPromotionTemplate(promotionCode string) *Promotion {
 	var promotion Promotion
@@ -0,0 +1,421 @@
+package models
+
+import (
+	"greenride/internal/protocol"
+	"greenride/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+// PromotionCampaign 批量发券活动表 - 按人群分批向用户发放同一优惠码，进度通过游标持久化以便中断后继续
+type PromotionCampaign struct {
+	ID         int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	CampaignID string `json:"campaign_id" gorm:"column:campaign_id;type:varchar(64);uniqueIndex"`
+	*PromotionCampaignValues
+	CreatedAt int64 `json:"created_at" gorm:"column:created_at;autoCreateTime:milli"`
+}
+
+type PromotionCampaignValues struct {
+	PromotionID *string `json:"promotion_id" gorm:"column:promotion_id;type:varchar(64);index"`
+	Name        *string `json:"name" gorm:"column:name;type:varchar(255)"`
+	Description *string `json:"description" gorm:"column:description;type:text"`
+
+	// 目标人群
+	TargetUserType *string  `json:"target_user_type" gorm:"column:target_user_type;type:varchar(32)"` // passenger, driver, user，空表示不限
+	TargetCity     *string  `json:"target_city" gorm:"column:target_city;type:varchar(100)"`
+	LastRideAfter  *int64   `json:"last_ride_after" gorm:"column:last_ride_after"`             // 最近一次完成行程晚于该时间
+	LastRideBefore *int64   `json:"last_ride_before" gorm:"column:last_ride_before"`           // 最近一次完成行程早于该时间，用于召回沉睡用户
+	MinRides       *int     `json:"min_rides" gorm:"column:min_rides"`                         // 最少完成行程数
+	MaxRides       *int     `json:"max_rides" gorm:"column:max_rides"`                         // 最多完成行程数
+	UserIDs        []string `json:"user_ids" gorm:"column:user_ids;type:json;serializer:json"` // CSV导入的用户ID列表，非空时只在该名单内发放
+
+	// 发放设置
+	ValidDays *int  `json:"valid_days" gorm:"column:valid_days;default:0"`   // 券有效天数，0表示跟随优惠码
+	SendPush  *bool `json:"send_push" gorm:"column:send_push;default:false"` // 是否推送发券通知
+	ChunkSize *int  `json:"chunk_size" gorm:"column:chunk_size;default:500"` // 每批处理用户数
+
+	// A/B实验，设置后按实验分组发放不同折扣变体，对照组不发券
+	ExperimentID *string `json:"experiment_id" gorm:"column:experiment_id;type:varchar(64);index"`
+
+	// 执行进度
+	Status         *string `json:"status" gorm:"column:status;type:varchar(32);index;default:'running'"` // running, paused, completed, cancelled
+	Cursor         *int64  `json:"cursor" gorm:"column:cursor;default:0"`                                // 人群查询为最后处理的用户主键，CSV名单为已处理的下标
+	TotalUsers     *int    `json:"total_users" gorm:"column:total_users;default:0"`
+	ProcessedUsers *int    `json:"processed_users" gorm:"column:processed_users;default:0"`
+	IssuedCount    *int    `json:"issued_count" gorm:"column:issued_count;default:0"`
+	SkippedCount   *int    `json:"skipped_count" gorm:"column:skipped_count;default:0"` // 已领取过、名单内用户不存在或不符合条件
+	FailedCount    *int    `json:"failed_count" gorm:"column:failed_count;default:0"`
+	ErrorMessage   *string `json:"error_message" gorm:"column:error_message;type:text"` // 自动暂停的原因
+	StartedAt      *int64  `json:"started_at" gorm:"column:started_at"`
+	CompletedAt    *int64  `json:"completed_at" gorm:"column:completed_at"`
+
+	CreatedBy *string `json:"created_by" gorm:"column:created_by;type:varchar(64)"`
+	UpdatedBy *string `json:"updated_by" gorm:"column:updated_by;type:varchar(64)"`
+	UpdatedAt int64   `json:"updated_at" gorm:"column:updated_at;autoUpdateTime:milli"`
+}
+
+func (PromotionCampaign) TableName() string {
+	return "t_promotion_campaigns"
+}
+
+// NewPromotionCampaign 创建新的发券活动
+func NewPromotionCampaign() *PromotionCampaign {
+	return &PromotionCampaign{
+		CampaignID: utils.GeneratePromotionCampaignID(),
+		PromotionCampaignValues: &PromotionCampaignValues{
+			Status:         utils.StringPtr(protocol.StatusRunning),
+			Cursor:         utils.Int64Ptr(0),
+			ChunkSize:      utils.IntPtr(500),
+			ValidDays:      utils.IntPtr(0),
+			SendPush:       utils.BoolPtr(false),
+			TotalUsers:     utils.IntPtr(0),
+			ProcessedUsers: utils.IntPtr(0),
+			IssuedCount:    utils.IntPtr(0),
+			SkippedCount:   utils.IntPtr(0),
+			FailedCount:    utils.IntPtr(0),
+			StartedAt:      utils.TimeNowMilliPtr(),
+		},
+	}
+}
+
+func (p *PromotionCampaignValues) GetPromotionID() string {
+	if p.PromotionID == nil {
+		return ""
+	}
+	return *p.PromotionID
+}
+
+func (p *PromotionCampaignValues) GetName() string {
+	if p.Name == nil {
+		return ""
+	}
+	return *p.Name
+}
+
+func (p *PromotionCampaignValues) GetTargetUserType() string {
+	if p.TargetUserType == nil {
+		return ""
+	}
+	return *p.TargetUserType
+}
+
+func (p *PromotionCampaignValues) GetTargetCity() string {
+	if p.TargetCity == nil {
+		return ""
+	}
+	return *p.TargetCity
+}
+
+func (p *PromotionCampaignValues) GetValidDays() int {
+	if p.ValidDays == nil {
+		return 0
+	}
+	return *p.ValidDays
+}
+
+func (p *PromotionCampaignValues) GetSendPush() bool {
+	if p.SendPush == nil {
+		return false
+	}
+	return *p.SendPush
+}
+
+func (p *PromotionCampaignValues) GetChunkSize() int {
+	if p.ChunkSize == nil || *p.ChunkSize <= 0 {
+		return 500
+	}
+	return *p.ChunkSize
+}
+
+func (p *PromotionCampaignValues) GetStatus() string {
+	if p.Status == nil {
+		return ""
+	}
+	return *p.Status
+}
+
+func (p *PromotionCampaignValues) GetCursor() int64 {
+	if p.Cursor == nil {
+		return 0
+	}
+	return *p.Cursor
+}
+
+func (p *PromotionCampaignValues) GetTotalUsers() int {
+	if p.TotalUsers == nil {
+		return 0
+	}
+	return *p.TotalUsers
+}
+
+func (p *PromotionCampaignValues) GetProcessedUsers() int {
+	if p.ProcessedUsers == nil {
+		return 0
+	}
+	return *p.ProcessedUsers
+}
+
+func (p *PromotionCampaignValues) GetIssuedCount() int {
+	if p.IssuedCount == nil {
+		return 0
+	}
+	return *p.IssuedCount
+}
+
+func (p *PromotionCampaignValues) GetSkippedCount() int {
+	if p.SkippedCount == nil {
+		return 0
+	}
+	return *p.SkippedCount
+}
+
+func (p *PromotionCampaignValues) GetFailedCount() int {
+	if p.FailedCount == nil {
+		return 0
+	}
+	return *p.FailedCount
+}
+
+func (p *PromotionCampaignValues) GetErrorMessage() string {
+	if p.ErrorMessage == nil {
+		return ""
+	}
+	return *p.ErrorMessage
+}
+
+func (p *PromotionCampaignValues) GetStartedAt() int64 {
+	if p.StartedAt == nil {
+		return 0
+	}
+	return *p.StartedAt
+}
+
+func (p *PromotionCampaignValues) GetCompletedAt() int64 {
+	if p.CompletedAt == nil {
+		return 0
+	}
+	return *p.CompletedAt
+}
+
+func (p *PromotionCampaignValues) GetExperimentID() string {
+	if p.ExperimentID == nil {
+		return ""
+	}
+	return *p.ExperimentID
+}
+
+func (p *PromotionCampaignValues) GetCreatedBy() string {
+	if p.CreatedBy == nil {
+		return ""
+	}
+	return *p.CreatedBy
+}
+
+// UsesUserList 是否按CSV导入的名单发放
+func (p *PromotionCampaignValues) UsesUserList() bool {
+	return len(p.UserIDs) > 0
+}
+
+func (p *PromotionCampaignValues) IsRunning() bool {
+	return p.GetStatus() == protocol.StatusRunning
+}
+
+func (p *PromotionCampaignValues) SetStatus(status string) *PromotionCampaignValues {
+	p.Status = &status
+	return p
+}
+
+func (p *PromotionCampaignValues) SetErrorMessage(message string) *PromotionCampaignValues {
+	p.ErrorMessage = &message
+	return p
+}
+
+func (p *PromotionCampaignValues) SetUpdatedBy(updatedBy string) *PromotionCampaignValues {
+	p.UpdatedBy = &updatedBy
+	return p
+}
+
+// Progress 处理进度百分比
+func (p *PromotionCampaignValues) Progress() float64 {
+	total := p.GetTotalUsers()
+	if total <= 0 {
+		if p.GetStatus() == protocol.StatusCompleted {
+			return 100
+		}
+		return 0
+	}
+	progress := float64(p.GetProcessedUsers()) * 100 / float64(total)
+	if progress > 100 {
+		progress = 100
+	}
+	return progress
+}
+
+// Protocol 转换为协议对象
+func (p *PromotionCampaign) Protocol() *protocol.PromotionCampaign {
+	return &protocol.PromotionCampaign{
+		CampaignID:     p.CampaignID,
+		PromotionID:    p.GetPromotionID(),
+		Name:           p.GetName(),
+		TargetUserType: p.GetTargetUserType(),
+		TargetCity:     p.GetTargetCity(),
+		LastRideAfter:  p.LastRideAfter,
+		LastRideBefore: p.LastRideBefore,
+		MinRides:       p.MinRides,
+		MaxRides:       p.MaxRides,
+		UserListSize:   len(p.UserIDs),
+		ValidDays:      p.GetValidDays(),
+		SendPush:       p.GetSendPush(),
+		ExperimentID:   p.GetExperimentID(),
+		Status:         p.GetStatus(),
+		TotalUsers:     p.GetTotalUsers(),
+		ProcessedUsers: p.GetProcessedUsers(),
+		IssuedCount:    p.GetIssuedCount(),
+		SkippedCount:   p.GetSkippedCount(),
+		FailedCount:    p.GetFailedCount(),
+		Progress:       p.Progress(),
+		ErrorMessage:   p.GetErrorMessage(),
+		StartedAt:      p.GetStartedAt(),
+		CompletedAt:    p.GetCompletedAt(),
+		CreatedBy:      p.GetCreatedBy(),
+		CreatedAt:      p.CreatedAt,
+		UpdatedAt:      p.UpdatedAt,
+	}
+}
+
+// GetPromotionCampaignByID 根据活动ID获取发券活动
+func GetPromotionCampaignByID(campaignID string) *PromotionCampaign {
+	var campaign PromotionCampaign
+	if err := GetDB().Where("campaign_id = ?", campaignID).First(&campaign).Error; err != nil {
+		return nil
+	}
+	return &campaign
+}
+
+// GetRunningPromotionCampaigns 获取执行中的发券活动，按创建顺序处理
+func GetRunningPromotionCampaigns(limit int) []*PromotionCampaign {
+	var campaigns []*PromotionCampaign
+	GetDB().Where("status = ?", protocol.StatusRunning).
+		Order("id ASC").
+		Limit(limit).
+		Find(&campaigns)
+	return campaigns
+}
+
+// SearchPromotionCampaigns 分页查询发券活动
+func SearchPromotionCampaigns(promotionID, status string, page, limit int) ([]*PromotionCampaign, int64) {
+	query := GetDB().Model(&PromotionCampaign{})
+	if promotionID != "" {
+		query = query.Where("promotion_id = ?", promotionID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var campaigns []*PromotionCampaign
+	query.Order("created_at DESC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&campaigns)
+	return campaigns, total
+}
+
+// TransitionPromotionCampaignStatus 条件更新活动状态，只有当前状态在 fromStatuses 中时才生效
+func TransitionPromotionCampaignStatus(campaignID string, fromStatuses []string, updates map[string]any) (bool, error) {
+	result := GetDB().Model(&PromotionCampaign{}).
+		Where("campaign_id = ? AND status IN ?", campaignID, fromStatuses).
+		Updates(updates)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// AddPromotionCampaignProgress 累加一批的处理结果并推进游标
+func AddPromotionCampaignProgress(campaignID string, cursor int64, processed, issued, skipped, failed int) error {
+	return GetDB().Model(&PromotionCampaign{}).
+		Where("campaign_id = ?", campaignID).
+		Updates(map[string]any{
+			"cursor":          cursor,
+			"processed_users": gorm.Expr("processed_users + ?", processed),
+			"issued_count":    gorm.Expr("issued_count + ?", issued),
+			"skipped_count":   gorm.Expr("skipped_count + ?", skipped),
+			"failed_count":    gorm.Expr("failed_count + ?", failed),
+		}).Error
+}
+
+// promotionCampaignSegmentQuery 构建目标人群的用户查询（不含CSV名单）
+func promotionCampaignSegmentQuery(campaign *PromotionCampaign) *gorm.DB {
+	query := GetDB().Model(&User{}).
+		Where("status = ?", protocol.StatusActive).
+		Where("(deleted_at IS NULL OR deleted_at = 0)")
+	if userType := campaign.GetTargetUserType(); userType != "" {
+		query = query.Where("user_type = ?", userType)
+	}
+	if city := campaign.GetTargetCity(); city != "" {
+		query = query.Where("city = ?", city)
+	}
+	if campaign.MinRides != nil {
+		query = query.Where("total_rides >= ?", *campaign.MinRides)
+	}
+	if campaign.MaxRides != nil {
+		query = query.Where("total_rides <= ?", *campaign.MaxRides)
+	}
+	if campaign.LastRideAfter != nil || campaign.LastRideBefore != nil {
+		lastRide := GetDB().Model(&Order{}).
+			Select("user_id").
+			Where("status = ?", protocol.StatusCompleted).
+			Group("user_id")
+		if campaign.LastRideAfter != nil {
+			lastRide = lastRide.Having("MAX(completed_at) >= ?", *campaign.LastRideAfter)
+		}
+		if campaign.LastRideBefore != nil {
+			lastRide = lastRide.Having("MAX(completed_at) < ?", *campaign.LastRideBefore)
+		}
+		query = query.Where("user_id IN (?)", lastRide)
+	}
+	return query
+}
+
+// CountPromotionCampaignUsers 统计活动目标人数
+func CountPromotionCampaignUsers(campaign *PromotionCampaign) int {
+	if campaign.UsesUserList() {
+		return len(campaign.UserIDs)
+	}
+	var count int64
+	promotionCampaignSegmentQuery(campaign).Count(&count)
+	return int(count)
+}
+
+// GetPromotionCampaignUserBatch 获取下一批目标用户
+// 人群查询按用户主键翻页，返回下一批用户和新游标；CSV名单按下标切片，名单中不存在或不符合条件的用户不会返回
+// scanned 为本批推进的名单条数，用于计算进度
+func GetPromotionCampaignUserBatch(campaign *PromotionCampaign) (users []*User, nextCursor int64, scanned int) {
+	cursor := campaign.GetCursor()
+	chunkSize := campaign.GetChunkSize()
+
+	if campaign.UsesUserList() {
+		start := int(cursor)
+		if start >= len(campaign.UserIDs) {
+			return nil, cursor, 0
+		}
+		end := min(start+chunkSize, len(campaign.UserIDs))
+		promotionCampaignSegmentQuery(campaign).
+			Where("user_id IN ?", campaign.UserIDs[start:end]).
+			Find(&users)
+		return users, int64(end), end - start
+	}
+
+	promotionCampaignSegmentQuery(campaign).
+		Where("id > ?", cursor).
+		Order("id ASC").
+		Limit(chunkSize).
+		Find(&users)
+	if len(users) == 0 {
+		return nil, cursor, 0
+	}
+	return users, users[len(users)-1].ID, len(users)
+}
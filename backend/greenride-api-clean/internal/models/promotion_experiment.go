@@ -0,0 +1,366 @@
+package models
+
+import (
+	"hash/fnv"
+
+	"greenride/internal/protocol"
+	"greenride/internal/utils"
+
+	"gorm.io/gorm/clause"
+)
+
+// ExperimentHoldoutVariant 对照组的变体ID，对照组用户不发券
+const ExperimentHoldoutVariant = "holdout"
+
+// PromotionExperiment 优惠A/B实验表 - 同一优惠码下设置多个折扣变体和对照组，按用户哈希稳定分组
+type PromotionExperiment struct {
+	ID           int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ExperimentID string `json:"experiment_id" gorm:"column:experiment_id;type:varchar(64);uniqueIndex"`
+	*PromotionExperimentValues
+	CreatedAt int64 `json:"created_at" gorm:"column:created_at;autoCreateTime:milli"`
+}
+
+type PromotionExperimentValues struct {
+	PromotionID          *string                                `json:"promotion_id" gorm:"column:promotion_id;type:varchar(64);index"` // 基础优惠码，预算和有效期以其为准
+	Name                 *string                                `json:"name" gorm:"column:name;type:varchar(255)"`
+	Description          *string                                `json:"description" gorm:"column:description;type:text"`
+	Variants             []*protocol.PromotionExperimentVariant `json:"variants" gorm:"column:variants;type:json;serializer:json"`
+	HoldoutPercent       *int                                   `json:"holdout_percent" gorm:"column:holdout_percent;default:0"`                // 对照组比例 0-90
+	ConversionWindowDays *int                                   `json:"conversion_window_days" gorm:"column:conversion_window_days;default:14"` // 转化观察窗口天数
+	Status               *string                                `json:"status" gorm:"column:status;type:varchar(32);index;default:'pending'"`   // pending(待审批), running, completed, rejected
+	StartedAt            *int64                                 `json:"started_at" gorm:"column:started_at"`
+	EndedAt              *int64                                 `json:"ended_at" gorm:"column:ended_at"`
+	CreatedBy            *string                                `json:"created_by" gorm:"column:created_by;type:varchar(64)"`
+	ApprovedBy           *string                                `json:"approved_by" gorm:"column:approved_by;type:varchar(64)"` // 变体折扣审批人，不能是创建人
+	ApprovedAt           *int64                                 `json:"approved_at" gorm:"column:approved_at"`
+	ApprovalNotes        *string                                `json:"approval_notes" gorm:"column:approval_notes;type:text"`
+	UpdatedAt            int64                                  `json:"updated_at" gorm:"column:updated_at;autoUpdateTime:milli"`
+}
+
+func (PromotionExperiment) TableName() string {
+	return "t_promotion_experiments"
+}
+
+// PromotionExperimentAssignment 实验分组记录 - 记录每个用户进入实验的时间和所属变体（含对照组），用于计算转化
+type PromotionExperimentAssignment struct {
+	ID           int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ExperimentID string `json:"experiment_id" gorm:"column:experiment_id;type:varchar(64);uniqueIndex:idx_experiment_user"`
+	UserID       string `json:"user_id" gorm:"column:user_id;type:varchar(64);uniqueIndex:idx_experiment_user"`
+	VariantID    string `json:"variant_id" gorm:"column:variant_id;type:varchar(64);index"`
+	AssignedAt   int64  `json:"assigned_at" gorm:"column:assigned_at"`
+	CreatedAt    int64  `json:"created_at" gorm:"column:created_at;autoCreateTime:milli"`
+}
+
+func (PromotionExperimentAssignment) TableName() string {
+	return "t_promotion_experiment_assignments"
+}
+
+// NewPromotionExperiment 创建新的优惠实验，变体折扣需另一位管理员审批后才开始运行
+func NewPromotionExperiment() *PromotionExperiment {
+	return &PromotionExperiment{
+		ExperimentID: utils.GeneratePromotionExperimentID(),
+		PromotionExperimentValues: &PromotionExperimentValues{
+			HoldoutPercent:       utils.IntPtr(0),
+			ConversionWindowDays: utils.IntPtr(14),
+			Status:               utils.StringPtr(protocol.StatusPending),
+		},
+	}
+}
+
+func (p *PromotionExperimentValues) GetPromotionID() string {
+	if p.PromotionID == nil {
+		return ""
+	}
+	return *p.PromotionID
+}
+
+func (p *PromotionExperimentValues) GetName() string {
+	if p.Name == nil {
+		return ""
+	}
+	return *p.Name
+}
+
+func (p *PromotionExperimentValues) GetDescription() string {
+	if p.Description == nil {
+		return ""
+	}
+	return *p.Description
+}
+
+func (p *PromotionExperimentValues) GetHoldoutPercent() int {
+	if p.HoldoutPercent == nil {
+		return 0
+	}
+	return *p.HoldoutPercent
+}
+
+func (p *PromotionExperimentValues) GetConversionWindowDays() int {
+	if p.ConversionWindowDays == nil || *p.ConversionWindowDays <= 0 {
+		return 14
+	}
+	return *p.ConversionWindowDays
+}
+
+func (p *PromotionExperimentValues) GetStatus() string {
+	if p.Status == nil {
+		return ""
+	}
+	return *p.Status
+}
+
+func (p *PromotionExperimentValues) GetStartedAt() int64 {
+	if p.StartedAt == nil {
+		return 0
+	}
+	return *p.StartedAt
+}
+
+func (p *PromotionExperimentValues) GetEndedAt() int64 {
+	if p.EndedAt == nil {
+		return 0
+	}
+	return *p.EndedAt
+}
+
+func (p *PromotionExperimentValues) GetCreatedBy() string {
+	if p.CreatedBy == nil {
+		return ""
+	}
+	return *p.CreatedBy
+}
+
+func (p *PromotionExperimentValues) GetApprovedBy() string {
+	if p.ApprovedBy == nil {
+		return ""
+	}
+	return *p.ApprovedBy
+}
+
+func (p *PromotionExperimentValues) GetApprovedAt() int64 {
+	if p.ApprovedAt == nil {
+		return 0
+	}
+	return *p.ApprovedAt
+}
+
+func (p *PromotionExperimentValues) GetApprovalNotes() string {
+	if p.ApprovalNotes == nil {
+		return ""
+	}
+	return *p.ApprovalNotes
+}
+
+func (p *PromotionExperimentValues) IsPending() bool {
+	return p.GetStatus() == protocol.StatusPending
+}
+
+func (p *PromotionExperimentValues) IsRunning() bool {
+	return p.GetStatus() == protocol.StatusRunning
+}
+
+// GetVariant 根据变体ID获取变体，对照组返回nil
+func (p *PromotionExperimentValues) GetVariant(variantID string) *protocol.PromotionExperimentVariant {
+	for _, variant := range p.Variants {
+		if variant.VariantID == variantID {
+			return variant
+		}
+	}
+	return nil
+}
+
+// AssignVariant 按用户ID哈希稳定分组：先按 HoldoutPercent 划出对照组，其余用户按变体权重分配
+// 同一实验内同一用户始终得到相同结果，不依赖分组记录
+func (p *PromotionExperiment) AssignVariant(userID string) string {
+	h := fnv.New32a()
+	h.Write([]byte(p.ExperimentID + ":" + userID))
+	bucket := int(h.Sum32() % 10000)
+
+	holdoutBuckets := p.GetHoldoutPercent() * 100
+	if bucket < holdoutBuckets || len(p.Variants) == 0 {
+		return ExperimentHoldoutVariant
+	}
+
+	totalWeight := 0
+	for _, variant := range p.Variants {
+		totalWeight += max(variant.Weight, 0)
+	}
+	if totalWeight == 0 {
+		return p.Variants[0].VariantID
+	}
+
+	// 把剩余桶按权重映射到变体
+	point := (bucket - holdoutBuckets) * totalWeight / (10000 - holdoutBuckets)
+	for _, variant := range p.Variants {
+		point -= max(variant.Weight, 0)
+		if point < 0 {
+			return variant.VariantID
+		}
+	}
+	return p.Variants[len(p.Variants)-1].VariantID
+}
+
+// Protocol 转换为协议对象
+func (p *PromotionExperiment) Protocol() *protocol.PromotionExperiment {
+	return &protocol.PromotionExperiment{
+		ExperimentID:         p.ExperimentID,
+		PromotionID:          p.GetPromotionID(),
+		Name:                 p.GetName(),
+		Description:          p.GetDescription(),
+		Variants:             p.Variants,
+		HoldoutPercent:       p.GetHoldoutPercent(),
+		ConversionWindowDays: p.GetConversionWindowDays(),
+		Status:               p.GetStatus(),
+		StartedAt:            p.GetStartedAt(),
+		EndedAt:              p.GetEndedAt(),
+		CreatedBy:            p.GetCreatedBy(),
+		ApprovedBy:           p.GetApprovedBy(),
+		ApprovedAt:           p.GetApprovedAt(),
+		ApprovalNotes:        p.GetApprovalNotes(),
+		CreatedAt:            p.CreatedAt,
+		UpdatedAt:            p.UpdatedAt,
+	}
+}
+
+// GetPromotionExperimentByID 根据实验ID获取实验
+func GetPromotionExperimentByID(experimentID string) *PromotionExperiment {
+	var experiment PromotionExperiment
+	if err := GetDB().Where("experiment_id = ?", experimentID).First(&experiment).Error; err != nil {
+		return nil
+	}
+	return &experiment
+}
+
+// SearchPromotionExperiments 分页查询实验
+func SearchPromotionExperiments(promotionID, status string, page, limit int) ([]*PromotionExperiment, int64) {
+	query := GetDB().Model(&PromotionExperiment{})
+	if promotionID != "" {
+		query = query.Where("promotion_id = ?", promotionID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var experiments []*PromotionExperiment
+	query.Order("created_at DESC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&experiments)
+	return experiments, total
+}
+
+// CompletePromotionExperiment 结束实验，结束后不再分组发券，已分组用户继续统计转化
+func CompletePromotionExperiment(experimentID string) (bool, error) {
+	result := GetDB().Model(&PromotionExperiment{}).
+		Where("experiment_id = ? AND status = ?", experimentID, protocol.StatusRunning).
+		Updates(map[string]any{
+			"status":   protocol.StatusCompleted,
+			"ended_at": utils.TimeNowMilli(),
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// ApprovePromotionExperiment 审批通过待审批实验并开始运行
+func ApprovePromotionExperiment(experimentID, approvedBy, notes string) (bool, error) {
+	now := utils.TimeNowMilli()
+	result := GetDB().Model(&PromotionExperiment{}).
+		Where("experiment_id = ? AND status = ?", experimentID, protocol.StatusPending).
+		Updates(map[string]any{
+			"status":         protocol.StatusRunning,
+			"approved_by":    approvedBy,
+			"approved_at":    now,
+			"approval_notes": notes,
+			"started_at":     now,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// RejectPromotionExperiment 拒绝待审批实验，拒绝后不能发券
+func RejectPromotionExperiment(experimentID, rejectedBy, notes string) (bool, error) {
+	now := utils.TimeNowMilli()
+	result := GetDB().Model(&PromotionExperiment{}).
+		Where("experiment_id = ? AND status = ?", experimentID, protocol.StatusPending).
+		Updates(map[string]any{
+			"status":         protocol.StatusRejected,
+			"approved_by":    rejectedBy,
+			"approved_at":    now,
+			"approval_notes": notes,
+			"ended_at":       now,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// SavePromotionExperimentAssignment 记录用户分组，已存在时保留首次分组
+func SavePromotionExperimentAssignment(experimentID, userID, variantID string) error {
+	assignment := &PromotionExperimentAssignment{
+		ExperimentID: experimentID,
+		UserID:       userID,
+		VariantID:    variantID,
+		AssignedAt:   utils.TimeNowMilli(),
+	}
+	return GetDB().Clauses(clause.OnConflict{DoNothing: true}).Create(assignment).Error
+}
+
+// ExperimentVariantStats 实验变体原始统计
+type ExperimentVariantStats struct {
+	VariantID      string  `gorm:"column:variant_id"`
+	AssignedUsers  int64   `gorm:"column:assigned_users"`
+	IssuedCoupons  int64   `gorm:"column:issued_coupons"`
+	RedeemedCount  int64   `gorm:"column:redeemed_count"`
+	DiscountCost   float64 `gorm:"column:discount_cost"`
+	ConvertedUsers int64   `gorm:"column:converted_users"`
+	Rides          int64   `gorm:"column:rides"`
+}
+
+// GetPromotionExperimentStats 统计实验各变体的分组、发券、核销和窗口期内完成订单情况
+func GetPromotionExperimentStats(experiment *PromotionExperiment) map[string]*ExperimentVariantStats {
+	stats := make(map[string]*ExperimentVariantStats)
+	get := func(variantID string) *ExperimentVariantStats {
+		if stats[variantID] == nil {
+			stats[variantID] = &ExperimentVariantStats{VariantID: variantID}
+		}
+		return stats[variantID]
+	}
+
+	var assigned []*ExperimentVariantStats
+	GetDB().Model(&PromotionExperimentAssignment{}).
+		Select("variant_id, COUNT(*) AS assigned_users").
+		Where("experiment_id = ?", experiment.ExperimentID).
+		Group("variant_id").
+		Scan(&assigned)
+	for _, row := range assigned {
+		get(row.VariantID).AssignedUsers = row.AssignedUsers
+	}
+
+	var coupons []*ExperimentVariantStats
+	GetDB().Model(&UserPromotion{}).
+		Select("variant_id, COUNT(*) AS issued_coupons, SUM(CASE WHEN is_used = 1 THEN 1 ELSE 0 END) AS redeemed_count, COALESCE(SUM(CASE WHEN is_used = 1 THEN used_amount ELSE 0 END), 0) AS discount_cost").
+		Where("experiment_id = ?", experiment.ExperimentID).
+		Group("variant_id").
+		Scan(&coupons)
+	for _, row := range coupons {
+		s := get(row.VariantID)
+		s.IssuedCoupons = row.IssuedCoupons
+		s.RedeemedCount = row.RedeemedCount
+		s.DiscountCost = row.DiscountCost
+	}
+
+	windowMs := int64(experiment.GetConversionWindowDays()) * 24 * 3600 * 1000
+	var conversions []*ExperimentVariantStats
+	GetDB().Table(PromotionExperimentAssignment{}.TableName()+" AS a").
+		Select("a.variant_id, COUNT(DISTINCT o.user_id) AS converted_users, COUNT(o.id) AS rides").
+		Joins("JOIN "+Order{}.TableName()+" AS o ON o.user_id = a.user_id AND o.status = ? AND o.completed_at >= a.assigned_at AND o.completed_at < a.assigned_at + ?",
+			protocol.StatusCompleted, windowMs).
+		Where("a.experiment_id = ?", experiment.ExperimentID).
+		Group("a.variant_id").
+		Scan(&conversions)
+	for _, row := range conversions {
+		s := get(row.VariantID)
+		s.ConvertedUsers = row.ConvertedUsers
+		s.Rides = row.Rides
+	}
+	return stats
+}
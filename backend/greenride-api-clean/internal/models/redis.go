@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"math/rand"
 	"reflect"
 	"time"
 
@@ -292,6 +293,60 @@ func IncrWithExpire(key string, expiration time.Duration) (int64, error) {
 	return incr.Val(), nil
 }
 
+// slidingWindowScript 基于有序集合的滑动窗口限流
+// KEYS[1]=限流键 ARGV[1]=当前时间(毫秒) ARGV[2]=窗口(毫秒) ARGV[3]=上限 ARGV[4]=成员
+// 返回 {是否放行, 窗口内请求数, 最早请求时间(毫秒)}
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+redis.call('ZREMRANGEBYSCORE', key, 0, now - window)
+local count = redis.call('ZCARD', key)
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', key, now, ARGV[4])
+	count = count + 1
+	allowed = 1
+end
+redis.call('PEXPIRE', key, window)
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+local oldestScore = now
+if oldest[2] then
+	oldestScore = tonumber(oldest[2])
+end
+return {allowed, count, oldestScore}
+`)
+
+// SlidingWindowResult 滑动窗口限流结果
+type SlidingWindowResult struct {
+	Allowed   bool
+	Count     int64     // 窗口内已记录的请求数
+	ResetAt   time.Time // 窗口内最早的请求滑出窗口的时间
+	Available bool      // Redis是否可用，不可用时放行
+}
+
+// SlidingWindowAllow 滑动窗口限流检查，Redis不可用时放行
+func SlidingWindowAllow(key string, limit int, window time.Duration) *SlidingWindowResult {
+	now := time.Now()
+	if Redis == nil {
+		return &SlidingWindowResult{Allowed: true, ResetAt: now.Add(window)}
+	}
+	member := fmt.Sprintf("%d-%d", now.UnixNano(), rand.Int63())
+	values, err := slidingWindowScript.Run(context.Background(), Redis, []string{key},
+		now.UnixMilli(), window.Milliseconds(), limit, member).Int64Slice()
+	if err != nil || len(values) < 3 {
+		log.Printf("SlidingWindowAllow %s failed: %v", key, err)
+		return &SlidingWindowResult{Allowed: true, ResetAt: now.Add(window)}
+	}
+	return &SlidingWindowResult{
+		Allowed:   values[0] == 1,
+		Count:     values[1],
+		ResetAt:   time.UnixMilli(values[2]).Add(window),
+		Available: true,
+	}
+}
+
 // 分布式锁相关方法
 func SetNX(key string, value interface{}, expiration time.Duration) (bool, error) {
 	return Redis.SetNX(context.Background(), key, value, expiration).Result()
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+func TestSlidingWindowAllowFailOpen(t *testing.T) {
+	previous := Redis
+	t.Cleanup(func() { Redis = previous })
+
+	// 未配置Redis
+	Redis = nil
+	if result := SlidingWindowAllow("test:nil", 1, time.Minute); !result.Allowed || result.Available {
+		t.Errorf("nil redis: got %+v, want allowed and unavailable", result)
+	}
+
+	// Redis连接失败
+	Redis = redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", DialTimeout: 100 * time.Millisecond, MaxRetries: -1})
+	t.Cleanup(func() { _ = Redis.Close() })
+	for i := 0; i < 3; i++ {
+		if result := SlidingWindowAllow("test:down", 1, time.Minute); !result.Allowed || result.Available {
+			t.Fatalf("redis down request %d: got %+v, want allowed and unavailable", i, result)
+		}
+	}
+}
@@ -0,0 +1,318 @@
+package models
+
+import (
+	"greenride/internal/protocol"
+	"greenride/internal/utils"
+)
+
+// Referral 邀请记录表 - 每个被邀请人一条，记录奖励条件、风控结果和奖励发放情况
+type Referral struct {
+	ID         int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ReferralID string `json:"referral_id" gorm:"column:referral_id;type:varchar(64);uniqueIndex"`
+	InviteeID  string `json:"invitee_id" gorm:"column:invitee_id;type:varchar(64);uniqueIndex"`
+	*ReferralValues
+	CreatedAt int64 `json:"created_at" gorm:"column:created_at;autoCreateTime:milli"`
+}
+
+type ReferralValues struct {
+	InviterID          *string  `json:"inviter_id" gorm:"column:inviter_id;type:varchar(64);index"`
+	ProgramType        *string  `json:"program_type" gorm:"column:program_type;type:varchar(32);index"`           // passenger, driver
+	Status             *string  `json:"status" gorm:"column:status;type:varchar(32);index;default:'pending'"`     // pending, review, rewarded, rejected, capped, expired
+	RequiredRides      *int     `json:"required_rides" gorm:"column:required_rides;default:1"`                    // 注册时的奖励条件快照
+	FraudSignals       []string `json:"fraud_signals" gorm:"column:fraud_signals;type:json;serializer:json"`      // 命中的风控信号
+	FraudApproved      *bool    `json:"fraud_approved" gorm:"column:fraud_approved;default:false"`                // 人工审核通过后不再拦截
+	InviterPromotionID *string  `json:"inviter_promotion_id" gorm:"column:inviter_promotion_id;type:varchar(64)"` // 邀请人获得的优惠券
+	InviteePromotionID *string  `json:"invitee_promotion_id" gorm:"column:invitee_promotion_id;type:varchar(64)"` // 被邀请人获得的优惠券
+	BonusAmount        *float64 `json:"bonus_amount" gorm:"column:bonus_amount;type:decimal(12,2);default:0"`     // 司机奖励金额
+	BonusTransactionID *string  `json:"bonus_transaction_id" gorm:"column:bonus_transaction_id;type:varchar(64)"` // 钱包入账流水ID
+	ExpireAt           *int64   `json:"expire_at" gorm:"column:expire_at"`                                        // 超过该时间未达成条件不再奖励，0表示不限
+	RewardedAt         *int64   `json:"rewarded_at" gorm:"column:rewarded_at"`
+	ReviewedBy         *string  `json:"reviewed_by" gorm:"column:reviewed_by;type:varchar(64)"`
+	ReviewedAt         *int64   `json:"reviewed_at" gorm:"column:reviewed_at"`
+	Remark             *string  `json:"remark" gorm:"column:remark;type:varchar(500)"`
+	UpdatedAt          int64    `json:"updated_at" gorm:"column:updated_at;autoUpdateTime:milli"`
+}
+
+func (Referral) TableName() string {
+	return "t_referrals"
+}
+
+// NewReferral 创建新的邀请记录
+func NewReferral(inviterID, inviteeID string) *Referral {
+	return &Referral{
+		ReferralID: utils.GenerateReferralID(),
+		InviteeID:  inviteeID,
+		ReferralValues: &ReferralValues{
+			InviterID:     utils.StringPtr(inviterID),
+			ProgramType:   utils.StringPtr(protocol.ReferralProgramPassenger),
+			Status:        utils.StringPtr(protocol.ReferralStatusPending),
+			RequiredRides: utils.IntPtr(1),
+			FraudApproved: utils.BoolPtr(false),
+			BonusAmount:   utils.Float64Ptr(0),
+			ExpireAt:      utils.Int64Ptr(0),
+		},
+	}
+}
+
+func (r *ReferralValues) GetInviterID() string {
+	if r.InviterID == nil {
+		return ""
+	}
+	return *r.InviterID
+}
+
+func (r *ReferralValues) GetProgramType() string {
+	if r.ProgramType == nil {
+		return ""
+	}
+	return *r.ProgramType
+}
+
+func (r *ReferralValues) GetStatus() string {
+	if r.Status == nil {
+		return ""
+	}
+	return *r.Status
+}
+
+func (r *ReferralValues) GetRequiredRides() int {
+	if r.RequiredRides == nil || *r.RequiredRides <= 0 {
+		return 1
+	}
+	return *r.RequiredRides
+}
+
+func (r *ReferralValues) GetFraudApproved() bool {
+	if r.FraudApproved == nil {
+		return false
+	}
+	return *r.FraudApproved
+}
+
+func (r *ReferralValues) GetInviterPromotionID() string {
+	if r.InviterPromotionID == nil {
+		return ""
+	}
+	return *r.InviterPromotionID
+}
+
+func (r *ReferralValues) GetInviteePromotionID() string {
+	if r.InviteePromotionID == nil {
+		return ""
+	}
+	return *r.InviteePromotionID
+}
+
+func (r *ReferralValues) GetBonusAmount() float64 {
+	if r.BonusAmount == nil {
+		return 0
+	}
+	return *r.BonusAmount
+}
+
+func (r *ReferralValues) GetBonusTransactionID() string {
+	if r.BonusTransactionID == nil {
+		return ""
+	}
+	return *r.BonusTransactionID
+}
+
+func (r *ReferralValues) GetExpireAt() int64 {
+	if r.ExpireAt == nil {
+		return 0
+	}
+	return *r.ExpireAt
+}
+
+func (r *ReferralValues) GetRewardedAt() int64 {
+	if r.RewardedAt == nil {
+		return 0
+	}
+	return *r.RewardedAt
+}
+
+func (r *ReferralValues) GetReviewedBy() string {
+	if r.ReviewedBy == nil {
+		return ""
+	}
+	return *r.ReviewedBy
+}
+
+func (r *ReferralValues) GetReviewedAt() int64 {
+	if r.ReviewedAt == nil {
+		return 0
+	}
+	return *r.ReviewedAt
+}
+
+func (r *ReferralValues) GetRemark() string {
+	if r.Remark == nil {
+		return ""
+	}
+	return *r.Remark
+}
+
+// IsExpired 是否已超过奖励期限
+func (r *ReferralValues) IsExpired(now int64) bool {
+	expireAt := r.GetExpireAt()
+	return expireAt > 0 && now > expireAt
+}
+
+// Protocol 转换为协议对象
+func (r *Referral) Protocol() *protocol.Referral {
+	return &protocol.Referral{
+		ReferralID:         r.ReferralID,
+		InviterID:          r.GetInviterID(),
+		InviteeID:          r.InviteeID,
+		ProgramType:        r.GetProgramType(),
+		Status:             r.GetStatus(),
+		RequiredRides:      r.GetRequiredRides(),
+		FraudSignals:       r.FraudSignals,
+		InviterPromotionID: r.GetInviterPromotionID(),
+		InviteePromotionID: r.GetInviteePromotionID(),
+		BonusAmount:        r.GetBonusAmount(),
+		BonusTransactionID: r.GetBonusTransactionID(),
+		ExpireAt:           r.GetExpireAt(),
+		RewardedAt:         r.GetRewardedAt(),
+		ReviewedBy:         r.GetReviewedBy(),
+		ReviewedAt:         r.GetReviewedAt(),
+		Remark:             r.GetRemark(),
+		CreatedAt:          r.CreatedAt,
+		UpdatedAt:          r.UpdatedAt,
+	}
+}
+
+// GetReferralByID 根据邀请记录ID获取
+func GetReferralByID(referralID string) *Referral {
+	var referral Referral
+	if err := GetDB().Where("referral_id = ?", referralID).First(&referral).Error; err != nil {
+		return nil
+	}
+	return &referral
+}
+
+// GetReferralByInviteeID 根据被邀请人获取邀请记录
+func GetReferralByInviteeID(inviteeID string) *Referral {
+	var referral Referral
+	if err := GetDB().Where("invitee_id = ?", inviteeID).First(&referral).Error; err != nil {
+		return nil
+	}
+	return &referral
+}
+
+// GetReferralsByInviteeIDs 批量获取邀请记录，按被邀请人ID索引
+func GetReferralsByInviteeIDs(inviteeIDs []string) map[string]*Referral {
+	result := make(map[string]*Referral)
+	if len(inviteeIDs) == 0 {
+		return result
+	}
+	var referrals []*Referral
+	GetDB().Where("invitee_id IN ?", inviteeIDs).Find(&referrals)
+	for _, referral := range referrals {
+		result[referral.InviteeID] = referral
+	}
+	return result
+}
+
+// SearchReferrals 分页查询邀请记录
+func SearchReferrals(req *protocol.ReferralSearchRequest) ([]*Referral, int64) {
+	query := GetDB().Model(&Referral{})
+	if req.InviterID != "" {
+		query = query.Where("inviter_id = ?", req.InviterID)
+	}
+	if req.InviteeID != "" {
+		query = query.Where("invitee_id = ?", req.InviteeID)
+	}
+	if req.ProgramType != "" {
+		query = query.Where("program_type = ?", req.ProgramType)
+	}
+	if req.Status != "" {
+		query = query.Where("status = ?", req.Status)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var referrals []*Referral
+	query.Order("created_at DESC").
+		Offset((req.Page - 1) * req.Limit).
+		Limit(req.Limit).
+		Find(&referrals)
+	return referrals, total
+}
+
+// CountRewardedReferrals 统计邀请人已获奖励次数
+func CountRewardedReferrals(inviterID string) int64 {
+	var count int64
+	GetDB().Model(&Referral{}).
+		Where("inviter_id = ? AND status = ?", inviterID, protocol.ReferralStatusRewarded).
+		Count(&count)
+	return count
+}
+
+// TransitionReferralStatus 条件更新邀请记录状态，返回是否更新成功（用于防止重复发奖）
+func TransitionReferralStatus(referralID string, fromStatuses []string, updates map[string]any) (bool, error) {
+	result := GetDB().Model(&Referral{}).
+		Where("referral_id = ? AND status IN ?", referralID, fromStatuses).
+		Updates(updates)
+	return result.RowsAffected > 0, result.Error
+}
+
+// FlagReferral 发奖前复查命中风控时更新状态和风控信号
+func FlagReferral(referralID, fromStatus, status string, signals []string) (bool, error) {
+	result := GetDB().Model(&Referral{}).
+		Where("referral_id = ? AND status = ?", referralID, fromStatus).
+		Select("status", "fraud_signals").
+		Updates(&Referral{ReferralValues: &ReferralValues{Status: &status, FraudSignals: signals}})
+	return result.RowsAffected > 0, result.Error
+}
+
+// UpdateReferralRewards 记录已发放的奖励
+func UpdateReferralRewards(referralID string, values *ReferralValues) error {
+	return GetDB().Model(&Referral{}).Where("referral_id = ?", referralID).UpdateColumns(values).Error
+}
+
+// GetUsersInvitedBy 批量获取被指定邀请人邀请的用户
+func GetUsersInvitedBy(inviterIDs []string) []*User {
+	var users []*User
+	if len(inviterIDs) == 0 {
+		return users
+	}
+	GetDB().Where("invited_by IN ?", inviterIDs).Order("created_at ASC").Find(&users)
+	return users
+}
+
+// GetUserFCMDeviceIDs 获取用户登记过的推送设备ID
+func GetUserFCMDeviceIDs(userID string) []string {
+	var deviceIDs []string
+	GetDB().Model(&FCMToken{}).
+		Where("user_id = ? AND device_id <> ''", userID).
+		Distinct().
+		Pluck("device_id", &deviceIDs)
+	return deviceIDs
+}
+
+// GetUserPaymentAccounts 获取用户付款时使用过的账号（手机号钱包账号、银行卡号、卡ID）
+func GetUserPaymentAccounts(userID string) []string {
+	var rows []struct {
+		Phone     string
+		AccountNo string
+		CardID    string
+	}
+	GetDB().Model(&Payment{}).
+		Select("DISTINCT phone, account_no, card_id").
+		Where("user_id = ?", userID).
+		Scan(&rows)
+
+	seen := make(map[string]bool)
+	accounts := make([]string, 0, len(rows))
+	for _, row := range rows {
+		for _, account := range []string{row.Phone, row.AccountNo, row.CardID} {
+			if account != "" && !seen[account] {
+				seen[account] = true
+				accounts = append(accounts, account)
+			}
+		}
+	}
+	return accounts
+}
@@ -24,6 +24,10 @@ type RideOrderValues struct {
 	// 乘客信息
 	PassengerCount *int `json:"passenger_count" gorm:"column:passenger_count;default:1"` // 乘客数量，默认1人
 
+	// 拼车信息
+	RideType *string `json:"ride_type" gorm:"column:ride_type;type:varchar(32);default:'standard'"` // 行程类型：standard独享, pool拼车
+	PoolID   *string `json:"pool_id" gorm:"column:pool_id;type:varchar(64);index"`                  // 司机接单后加入的拼车ID
+
 	// 上车地点信息
 	PickupAddress   *string  `json:"pickup_address" gorm:"column:pickup_address;type:text"`              // 上车地址详情
 	PickupLatitude  *float64 `json:"pickup_latitude" gorm:"column:pickup_latitude;type:decimal(10,8)"`   // 上车地点纬度
@@ -89,6 +93,12 @@ func (r *RideOrderValues) SetValues(values *RideOrderValues) {
 	if values.PassengerCount != nil {
 		r.PassengerCount = values.PassengerCount
 	}
+	if values.RideType != nil {
+		r.RideType = values.RideType
+	}
+	if values.PoolID != nil {
+		r.PoolID = values.PoolID
+	}
 	if values.PickupAddress != nil {
 		r.PickupAddress = values.PickupAddress
 	}
@@ -148,6 +158,30 @@ func (r *RideOrderValues) GetPassengerCount() int {
 	return *r.PassengerCount
 }
 
+func (r *RideOrderValues) GetRideType() string {
+	if r.RideType == nil || *r.RideType == "" {
+		return protocol.RideTypeStandard
+	}
+	return *r.RideType
+}
+
+// IsPool 是否为拼车订单
+func (r *RideOrderValues) IsPool() bool {
+	return r.GetRideType() == protocol.RideTypePool
+}
+
+func (r *RideOrderValues) GetPoolID() string {
+	if r.PoolID == nil {
+		return ""
+	}
+	return *r.PoolID
+}
+
+func (r *RideOrderValues) SetRideType(rideType string) *RideOrderValues {
+	r.RideType = &rideType
+	return r
+}
+
 func (r *RideOrderValues) GetTotalFare() float64 {
 	if r.TotalFare == nil {
 		return 0
@@ -169,6 +203,13 @@ func (r *RideOrderValues) GetPickupLongitude() float64 {
 	return *r.PickupLongitude
 }
 
+func (r *RideOrderValues) GetArrivedAt() int64 {
+	if r.ArrivedAt == nil {
+		return 0
+	}
+	return *r.ArrivedAt
+}
+
 func (r *RideOrderValues) GetDropoffLatitude() float64 {
 	if r.DropoffLatitude == nil {
 		return 0
@@ -310,6 +351,8 @@ func (r *RideOrder) ToOrderDetail() *protocol.OrderDetail {
 	if r.PassengerCount != nil {
 		detail.PassengerCount = *r.PassengerCount
 	}
+	detail.RideType = r.GetRideType()
+	detail.PoolID = r.GetPoolID()
 	if r.PickupAddress != nil {
 		detail.PickupAddress = *r.PickupAddress
 	}
@@ -0,0 +1,187 @@
+package models
+
+import (
+	"greenride/internal/protocol"
+	"greenride/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+// RideOrderStop 行程途经点表 - 上车点与下车点之间按顺序经过的停靠点
+type RideOrderStop struct {
+	ID     int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	StopID string `json:"stop_id" gorm:"column:stop_id;type:varchar(64);uniqueIndex"`
+	*RideOrderStopValues
+	CreatedAt int64 `json:"created_at" gorm:"column:created_at;autoCreateTime:milli"`
+}
+
+type RideOrderStopValues struct {
+	OrderID   *string  `json:"order_id" gorm:"column:order_id;type:varchar(64);index"`
+	Seq       *int     `json:"seq" gorm:"column:seq;type:int"` // 途经顺序，从1开始
+	Latitude  *float64 `json:"latitude" gorm:"column:latitude;type:decimal(10,8)"`
+	Longitude *float64 `json:"longitude" gorm:"column:longitude;type:decimal(11,8)"`
+	Address   *string  `json:"address" gorm:"column:address;type:text"`
+	Landmark  *string  `json:"landmark" gorm:"column:landmark;type:varchar(255)"`
+	Status    *string  `json:"status" gorm:"column:status;type:varchar(32);default:'pending'"` // pending, arrived, removed
+	ArrivedAt *int64   `json:"arrived_at" gorm:"column:arrived_at"`                            // 司机到达途经点时间
+	RemovedAt *int64   `json:"removed_at" gorm:"column:removed_at"`                            // 乘客删除途经点时间
+	UpdatedAt int64    `json:"updated_at" gorm:"column:updated_at;autoUpdateTime:milli"`
+}
+
+func (RideOrderStop) TableName() string {
+	return "t_ride_order_stops"
+}
+
+// NewRideOrderStop 根据请求中的途经点创建记录
+func NewRideOrderStop(orderID string, seq int, stop *protocol.RideStop) *RideOrderStop {
+	return &RideOrderStop{
+		StopID: utils.GenerateRideStopID(),
+		RideOrderStopValues: &RideOrderStopValues{
+			OrderID:   utils.StringPtr(orderID),
+			Seq:       &seq,
+			Latitude:  &stop.Latitude,
+			Longitude: &stop.Longitude,
+			Address:   utils.StringPtr(stop.Address),
+			Landmark:  utils.StringPtr(stop.Landmark),
+			Status:    utils.StringPtr(protocol.RideStopStatusPending),
+		},
+	}
+}
+
+func (s *RideOrderStopValues) GetOrderID() string {
+	if s.OrderID == nil {
+		return ""
+	}
+	return *s.OrderID
+}
+
+func (s *RideOrderStopValues) GetSeq() int {
+	if s.Seq == nil {
+		return 0
+	}
+	return *s.Seq
+}
+
+func (s *RideOrderStopValues) GetLatitude() float64 {
+	if s.Latitude == nil {
+		return 0
+	}
+	return *s.Latitude
+}
+
+func (s *RideOrderStopValues) GetLongitude() float64 {
+	if s.Longitude == nil {
+		return 0
+	}
+	return *s.Longitude
+}
+
+func (s *RideOrderStopValues) GetAddress() string {
+	if s.Address == nil {
+		return ""
+	}
+	return *s.Address
+}
+
+func (s *RideOrderStopValues) GetLandmark() string {
+	if s.Landmark == nil {
+		return ""
+	}
+	return *s.Landmark
+}
+
+func (s *RideOrderStopValues) GetStatus() string {
+	if s.Status == nil {
+		return ""
+	}
+	return *s.Status
+}
+
+func (s *RideOrderStopValues) GetArrivedAt() int64 {
+	if s.ArrivedAt == nil {
+		return 0
+	}
+	return *s.ArrivedAt
+}
+
+// IsArrived 司机是否已到达该途经点
+func (s *RideOrderStopValues) IsArrived() bool {
+	return s.GetStatus() == protocol.RideStopStatusArrived
+}
+
+// Protocol 转换为协议对象
+func (s *RideOrderStop) Protocol() *protocol.RideStop {
+	return &protocol.RideStop{
+		StopID:    s.StopID,
+		Seq:       s.GetSeq(),
+		Latitude:  s.GetLatitude(),
+		Longitude: s.GetLongitude(),
+		Address:   s.GetAddress(),
+		Landmark:  s.GetLandmark(),
+		Status:    s.GetStatus(),
+		ArrivedAt: s.GetArrivedAt(),
+	}
+}
+
+// GetRideOrderStops 获取订单未删除的途经点，按途经顺序排列
+func GetRideOrderStops(orderID string) []*RideOrderStop {
+	var stops []*RideOrderStop
+	err := GetDB().Where("order_id = ? AND status != ?", orderID, protocol.RideStopStatusRemoved).
+		Order("seq ASC").Find(&stops).Error
+	if err != nil {
+		return nil
+	}
+	return stops
+}
+
+// GetRideOrderStop 获取订单的某个未删除途经点
+func GetRideOrderStop(orderID, stopID string) *RideOrderStop {
+	var stop RideOrderStop
+	err := GetDB().Where("order_id = ? AND stop_id = ? AND status != ?", orderID, stopID, protocol.RideStopStatusRemoved).
+		First(&stop).Error
+	if err != nil {
+		return nil
+	}
+	return &stop
+}
+
+// CreateRideOrderStops 在事务中按给定顺序创建途经点
+func CreateRideOrderStops(tx *gorm.DB, orderID string, stops []*protocol.RideStop) error {
+	for i, stop := range stops {
+		if err := tx.Create(NewRideOrderStop(orderID, i+1, stop)).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ResequenceRideOrderStops 在事务中按切片顺序重排途经点序号
+func ResequenceRideOrderStops(tx *gorm.DB, stops []*RideOrderStop) error {
+	for i, stop := range stops {
+		if stop.GetSeq() == i+1 {
+			continue
+		}
+		if err := tx.Model(&RideOrderStop{}).Where("stop_id = ?", stop.StopID).UpdateColumn("seq", i+1).Error; err != nil {
+			return err
+		}
+		seq := i + 1
+		stop.Seq = &seq
+	}
+	return nil
+}
+
+// RemoveRideOrderStop 在事务中删除未到达的途经点，返回是否删除成功
+func RemoveRideOrderStop(tx *gorm.DB, stopID string) (bool, error) {
+	result := tx.Model(&RideOrderStop{}).
+		Where("stop_id = ? AND status = ?", stopID, protocol.RideStopStatusPending).
+		Updates(map[string]any{"status": protocol.RideStopStatusRemoved, "removed_at": utils.TimeNowMilli()})
+	return result.RowsAffected > 0, result.Error
+}
+
+// MarkRideOrderStopArrived 标记司机到达途经点，返回是否更新成功（重复到达不更新）
+func MarkRideOrderStopArrived(stopID string) (bool, error) {
+	result := GetDB().Model(&RideOrderStop{}).
+		Where("stop_id = ? AND status = ?", stopID, protocol.RideStopStatusPending).
+		Updates(map[string]any{"status": protocol.RideStopStatusArrived, "arrived_at": utils.TimeNowMilli()})
+	return result.RowsAffected > 0, result.Error
+}
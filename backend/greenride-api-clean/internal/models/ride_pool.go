@@ -0,0 +1,181 @@
+package models
+
+import (
+	"encoding/json"
+	"slices"
+
+	"greenride/internal/protocol"
+	"greenride/internal/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RidePool 拼车表 - 司机一次拼车行程的座位及按顺序排列的接送计划
+type RidePool struct {
+	ID     int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	PoolID string `json:"pool_id" gorm:"column:pool_id;type:varchar(64);uniqueIndex"`
+	*RidePoolValues
+	CreatedAt int64 `json:"created_at" gorm:"column:created_at;autoCreateTime:milli"`
+}
+
+type RidePoolValues struct {
+	DriverID     *string              `json:"driver_id" gorm:"column:driver_id;type:varchar(64);index"`
+	VehicleID    *string              `json:"vehicle_id" gorm:"column:vehicle_id;type:varchar(64)"`
+	Status       *string              `json:"status" gorm:"column:status;type:varchar(32);index;default:'open'"` // open, completed
+	SeatCapacity *int                 `json:"seat_capacity" gorm:"column:seat_capacity;type:int"`                // 可供乘客使用的座位数
+	Plan         []*protocol.PoolStop `json:"plan" gorm:"column:plan;type:json;serializer:json"`                 // 接送计划，按顺序排列
+	CompletedAt  *int64               `json:"completed_at" gorm:"column:completed_at"`
+	UpdatedAt    int64                `json:"updated_at" gorm:"column:updated_at;autoUpdateTime:milli"`
+}
+
+func (RidePool) TableName() string {
+	return "t_ride_pools"
+}
+
+// NewRidePool 创建司机的拼车行程
+func NewRidePool(driverID, vehicleID string, seatCapacity int) *RidePool {
+	return &RidePool{
+		PoolID: utils.GenerateRidePoolID(),
+		RidePoolValues: &RidePoolValues{
+			DriverID:     utils.StringPtr(driverID),
+			VehicleID:    utils.StringPtr(vehicleID),
+			Status:       utils.StringPtr(protocol.RidePoolStatusOpen),
+			SeatCapacity: &seatCapacity,
+			Plan:         []*protocol.PoolStop{},
+		},
+	}
+}
+
+func (p *RidePoolValues) GetDriverID() string {
+	if p.DriverID == nil {
+		return ""
+	}
+	return *p.DriverID
+}
+
+func (p *RidePoolValues) GetStatus() string {
+	if p.Status == nil {
+		return ""
+	}
+	return *p.Status
+}
+
+func (p *RidePoolValues) GetSeatCapacity() int {
+	if p.SeatCapacity == nil {
+		return 0
+	}
+	return *p.SeatCapacity
+}
+
+func (p *RidePoolValues) GetPlan() []*protocol.PoolStop {
+	if p.Plan == nil {
+		return []*protocol.PoolStop{}
+	}
+	return p.Plan
+}
+
+// IsOpen 拼车是否仍在进行中
+func (p *RidePoolValues) IsOpen() bool {
+	return p.GetStatus() == protocol.RidePoolStatusOpen
+}
+
+// GetOrderIDs 获取拼车中未移除的订单ID，按加入顺序排列
+func (p *RidePoolValues) GetOrderIDs() []string {
+	orderIDs := []string{}
+	for _, stop := range p.GetPlan() {
+		if stop.Status == protocol.PoolStopStatusRemoved || slices.Contains(orderIDs, stop.OrderID) {
+			continue
+		}
+		orderIDs = append(orderIDs, stop.OrderID)
+	}
+	return orderIDs
+}
+
+// GetBookedSeats 获取尚未下车的乘客占用的座位数
+func (p *RidePoolValues) GetBookedSeats() int {
+	seats := 0
+	for _, stop := range p.GetPlan() {
+		if stop.Type == protocol.PoolStopTypeDropoff && stop.IsPending() {
+			seats += stop.Seats
+		}
+	}
+	return seats
+}
+
+// GetNextStop 获取下一个待完成的节点
+func (p *RidePoolValues) GetNextStop() *protocol.PoolStop {
+	for _, stop := range p.GetPlan() {
+		if stop.IsPending() {
+			return stop
+		}
+	}
+	return nil
+}
+
+// Protocol 转换为协议对象
+func (p *RidePool) Protocol() *protocol.RidePool {
+	return &protocol.RidePool{
+		PoolID:         p.PoolID,
+		DriverID:       p.GetDriverID(),
+		Status:         p.GetStatus(),
+		SeatCapacity:   p.GetSeatCapacity(),
+		AvailableSeats: max(p.GetSeatCapacity()-p.GetBookedSeats(), 0),
+		OrderIDs:       p.GetOrderIDs(),
+		Plan:           p.GetPlan(),
+		NextStop:       p.GetNextStop(),
+		CreatedAt:      p.CreatedAt,
+		UpdatedAt:      p.UpdatedAt,
+	}
+}
+
+// GetRidePoolByID 根据拼车ID获取拼车
+func GetRidePoolByID(poolID string) *RidePool {
+	var pool RidePool
+	if err := GetDB().Where("pool_id = ?", poolID).First(&pool).Error; err != nil {
+		return nil
+	}
+	return &pool
+}
+
+// GetOpenRidePoolByDriver 获取司机进行中的拼车
+func GetOpenRidePoolByDriver(driverID string) *RidePool {
+	var pool RidePool
+	err := GetDB().Where("driver_id = ? AND status = ?", driverID, protocol.RidePoolStatusOpen).
+		Order("id DESC").First(&pool).Error
+	if err != nil {
+		return nil
+	}
+	return &pool
+}
+
+// LockRidePool 在事务中加锁读取拼车，用于修改接送计划
+func LockRidePool(tx *gorm.DB, poolID string) *RidePool {
+	var pool RidePool
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("pool_id = ?", poolID).First(&pool).Error; err != nil {
+		return nil
+	}
+	return &pool
+}
+
+// SaveRidePoolPlan 在事务中保存接送计划，没有待完成节点时拼车结束
+func SaveRidePoolPlan(tx *gorm.DB, pool *RidePool) error {
+	plan, err := json.Marshal(pool.GetPlan())
+	if err != nil {
+		return err
+	}
+	values := map[string]any{"plan": string(plan)}
+	if pool.GetNextStop() == nil {
+		now := utils.TimeNowMilli()
+		values["status"] = protocol.RidePoolStatusCompleted
+		values["completed_at"] = now
+		pool.Status = utils.StringPtr(protocol.RidePoolStatusCompleted)
+		pool.CompletedAt = &now
+	}
+	return tx.Model(&RidePool{}).Where("pool_id = ?", pool.PoolID).Updates(values).Error
+}
+
+// SetRideOrderPoolID 在事务中记录订单加入的拼车
+func SetRideOrderPoolID(tx *gorm.DB, orderID, poolID string) error {
+	return tx.Model(&RideOrder{}).Where("order_id = ?", orderID).Update("pool_id", poolID).Error
+}
@@ -0,0 +1,236 @@
+package models
+
+import (
+	"greenride/internal/protocol"
+	"greenride/internal/utils"
+)
+
+// SafetyIncident 安全事件表 - 乘客或司机发起的紧急求助，记录求助位置和订单快照
+type SafetyIncident struct {
+	ID         int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	IncidentID string `json:"incident_id" gorm:"column:incident_id;type:varchar(64);uniqueIndex"`
+	*SafetyIncidentValues
+	CreatedAt int64 `json:"created_at" gorm:"column:created_at;autoCreateTime:milli;index"`
+}
+
+type SafetyIncidentValues struct {
+	UserID         *string        `json:"user_id" gorm:"column:user_id;type:varchar(64);index"`
+	UserType       *string        `json:"user_type" gorm:"column:user_type;type:varchar(32)"` // passenger, driver
+	OrderID        *string        `json:"order_id" gorm:"column:order_id;type:varchar(64);index"`
+	Status         *string        `json:"status" gorm:"column:status;type:varchar(32);index;default:'open'"` // open, acknowledged, resolved
+	Priority       *string        `json:"priority" gorm:"column:priority;type:varchar(32);default:'high'"`
+	Latitude       *float64       `json:"latitude" gorm:"column:latitude;type:decimal(10,8)"`
+	Longitude      *float64       `json:"longitude" gorm:"column:longitude;type:decimal(11,8)"`
+	Message        *string        `json:"message" gorm:"column:message;type:varchar(500)"`
+	OrderSnapshot  map[string]any `json:"order_snapshot" gorm:"column:order_snapshot;type:json;serializer:json"` // 求助时的订单快照
+	ShareID        *string        `json:"share_id" gorm:"column:share_id;type:varchar(64)"`                      // 求助时生成的行程分享
+	HandledBy      *string        `json:"handled_by" gorm:"column:handled_by;type:varchar(64)"`                  // 处理的管理员
+	AcknowledgedAt *int64         `json:"acknowledged_at" gorm:"column:acknowledged_at"`
+	ResolvedAt     *int64         `json:"resolved_at" gorm:"column:resolved_at"`
+	Resolution     *string        `json:"resolution" gorm:"column:resolution;type:varchar(1000)"` // 处理说明
+	UpdatedAt      int64          `json:"updated_at" gorm:"column:updated_at;autoUpdateTime:milli"`
+}
+
+func (SafetyIncident) TableName() string {
+	return "t_safety_incidents"
+}
+
+// NewSafetyIncident 创建新的安全事件
+func NewSafetyIncident(userID, userType string) *SafetyIncident {
+	return &SafetyIncident{
+		IncidentID: utils.GenerateSafetyIncidentID(),
+		SafetyIncidentValues: &SafetyIncidentValues{
+			UserID:   utils.StringPtr(userID),
+			UserType: utils.StringPtr(userType),
+			Status:   utils.StringPtr(protocol.SafetyIncidentStatusOpen),
+			Priority: utils.StringPtr(NotificationPriorityHigh),
+		},
+	}
+}
+
+func (s *SafetyIncidentValues) GetUserID() string {
+	if s.UserID == nil {
+		return ""
+	}
+	return *s.UserID
+}
+
+func (s *SafetyIncidentValues) GetUserType() string {
+	if s.UserType == nil {
+		return ""
+	}
+	return *s.UserType
+}
+
+func (s *SafetyIncidentValues) GetOrderID() string {
+	if s.OrderID == nil {
+		return ""
+	}
+	return *s.OrderID
+}
+
+func (s *SafetyIncidentValues) GetStatus() string {
+	if s.Status == nil {
+		return ""
+	}
+	return *s.Status
+}
+
+func (s *SafetyIncidentValues) GetPriority() string {
+	if s.Priority == nil {
+		return ""
+	}
+	return *s.Priority
+}
+
+func (s *SafetyIncidentValues) GetLatitude() float64 {
+	if s.Latitude == nil {
+		return 0
+	}
+	return *s.Latitude
+}
+
+func (s *SafetyIncidentValues) GetLongitude() float64 {
+	if s.Longitude == nil {
+		return 0
+	}
+	return *s.Longitude
+}
+
+func (s *SafetyIncidentValues) GetMessage() string {
+	if s.Message == nil {
+		return ""
+	}
+	return *s.Message
+}
+
+func (s *SafetyIncidentValues) GetShareID() string {
+	if s.ShareID == nil {
+		return ""
+	}
+	return *s.ShareID
+}
+
+func (s *SafetyIncidentValues) GetHandledBy() string {
+	if s.HandledBy == nil {
+		return ""
+	}
+	return *s.HandledBy
+}
+
+func (s *SafetyIncidentValues) GetAcknowledgedAt() int64 {
+	if s.AcknowledgedAt == nil {
+		return 0
+	}
+	return *s.AcknowledgedAt
+}
+
+func (s *SafetyIncidentValues) GetResolvedAt() int64 {
+	if s.ResolvedAt == nil {
+		return 0
+	}
+	return *s.ResolvedAt
+}
+
+func (s *SafetyIncidentValues) GetResolution() string {
+	if s.Resolution == nil {
+		return ""
+	}
+	return *s.Resolution
+}
+
+// SetLocation 设置求助位置
+func (s *SafetyIncidentValues) SetLocation(latitude, longitude float64) *SafetyIncidentValues {
+	s.Latitude = &latitude
+	s.Longitude = &longitude
+	return s
+}
+
+func (s *SafetyIncidentValues) SetOrderID(orderID string) *SafetyIncidentValues {
+	s.OrderID = &orderID
+	return s
+}
+
+func (s *SafetyIncidentValues) SetMessage(message string) *SafetyIncidentValues {
+	s.Message = &message
+	return s
+}
+
+func (s *SafetyIncidentValues) SetShareID(shareID string) *SafetyIncidentValues {
+	s.ShareID = &shareID
+	return s
+}
+
+// Protocol 转换为协议对象，订单快照只在管理后台返回
+func (s *SafetyIncident) Protocol(withSnapshot bool) *protocol.SafetyIncident {
+	incident := &protocol.SafetyIncident{
+		IncidentID:     s.IncidentID,
+		UserID:         s.GetUserID(),
+		UserType:       s.GetUserType(),
+		OrderID:        s.GetOrderID(),
+		Status:         s.GetStatus(),
+		Priority:       s.GetPriority(),
+		Latitude:       s.GetLatitude(),
+		Longitude:      s.GetLongitude(),
+		Message:        s.GetMessage(),
+		ShareID:        s.GetShareID(),
+		HandledBy:      s.GetHandledBy(),
+		AcknowledgedAt: s.GetAcknowledgedAt(),
+		ResolvedAt:     s.GetResolvedAt(),
+		Resolution:     s.GetResolution(),
+		CreatedAt:      s.CreatedAt,
+		UpdatedAt:      s.UpdatedAt,
+	}
+	if withSnapshot {
+		incident.OrderSnapshot = s.OrderSnapshot
+	}
+	return incident
+}
+
+// CreateSafetyIncident 保存安全事件
+func CreateSafetyIncident(incident *SafetyIncident) error {
+	return GetDB().Create(incident).Error
+}
+
+// GetSafetyIncidentByID 根据事件ID获取
+func GetSafetyIncidentByID(incidentID string) *SafetyIncident {
+	var incident SafetyIncident
+	if err := GetDB().Where("incident_id = ?", incidentID).First(&incident).Error; err != nil {
+		return nil
+	}
+	return &incident
+}
+
+// TransitionSafetyIncidentStatus 条件更新安全事件状态，返回是否更新成功
+func TransitionSafetyIncidentStatus(incidentID string, fromStatuses []string, updates map[string]any) (bool, error) {
+	result := GetDB().Model(&SafetyIncident{}).
+		Where("incident_id = ? AND status IN ?", incidentID, fromStatuses).
+		Updates(updates)
+	return result.RowsAffected > 0, result.Error
+}
+
+// SearchSafetyIncidents 分页查询安全事件，未处理的排在前面
+func SearchSafetyIncidents(req *protocol.SafetyIncidentSearchRequest) ([]*SafetyIncident, int64) {
+	query := GetDB().Model(&SafetyIncident{})
+	if req.UserID != "" {
+		query = query.Where("user_id = ?", req.UserID)
+	}
+	if req.OrderID != "" {
+		query = query.Where("order_id = ?", req.OrderID)
+	}
+	if req.Status != "" {
+		query = query.Where("status = ?", req.Status)
+	}
+	if req.UserType != "" {
+		query = query.Where("user_type = ?", req.UserType)
+	}
+	var total int64
+	query.Count(&total)
+
+	var incidents []*SafetyIncident
+	query.Order("CASE WHEN status = 'open' THEN 0 ELSE 1 END, created_at DESC").
+		Offset((req.Page - 1) * req.Limit).
+		Limit(req.Limit).
+		Find(&incidents)
+	return incidents, total
+}
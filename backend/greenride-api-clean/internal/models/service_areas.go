@@ -828,3 +828,17 @@ func NewBusinessDistrictArea(name, city string, lat, lng, radius float64) *Servi
 
 	return area
 }
+
+// GetServiceAreasByIDs 批量获取服务区域，按区域ID索引
+func GetServiceAreasByIDs(serviceAreaIDs []string) map[string]*ServiceArea {
+	result := make(map[string]*ServiceArea)
+	if len(serviceAreaIDs) == 0 {
+		return result
+	}
+	var areas []*ServiceArea
+	GetDB().Where("service_area_id IN ?", serviceAreaIDs).Find(&areas)
+	for _, area := range areas {
+		result[area.ServiceAreaID] = area
+	}
+	return result
+}
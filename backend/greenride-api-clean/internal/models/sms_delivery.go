@@ -0,0 +1,78 @@
+package models
+
+import (
+	"greenride/internal/protocol"
+	"greenride/internal/utils"
+)
+
+// SMSDelivery 短信投递记录表 - 每次向服务商提交短信为一条记录，同一请求的多次尝试共享RequestID
+type SMSDelivery struct {
+	ID         int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	DeliveryID string `json:"delivery_id" gorm:"column:delivery_id;type:varchar(64);uniqueIndex"`
+	*SMSDeliveryValues
+	CreatedAt int64 `json:"created_at" gorm:"column:created_at;autoCreateTime:milli"`
+}
+
+type SMSDeliveryValues struct {
+	RequestID         *string `json:"request_id" gorm:"column:request_id;type:varchar(64);index"`                    // 同一短信请求的标识
+	Provider          *string `json:"provider" gorm:"column:provider;type:varchar(32);index"`                        // twilio, innopaas
+	ProviderMessageID *string `json:"provider_message_id" gorm:"column:provider_message_id;type:varchar(128);index"` // 服务商返回的消息ID
+	Phone             *string `json:"phone" gorm:"column:phone;type:varchar(30);index"`
+	CountryCode       *string `json:"country_code" gorm:"column:country_code;type:varchar(8);index"` // 国家区号，不含+
+	MessageType       *string `json:"message_type" gorm:"column:message_type;type:varchar(64);index"`
+	Attempt           *int    `json:"attempt" gorm:"column:attempt;type:int;default:1"` // 第几次尝试
+
+	// 投递状态
+	Status          *string `json:"status" gorm:"column:status;type:varchar(32);index;default:'pending'"` // pending, sent, delivered, failed, expired
+	ErrorMessage    *string `json:"error_message" gorm:"column:error_message;type:text"`
+	SentAt          *int64  `json:"sent_at" gorm:"column:sent_at"`
+	DeliveredAt     *int64  `json:"delivered_at" gorm:"column:delivered_at"`
+	FailedAt        *int64  `json:"failed_at" gorm:"column:failed_at"`
+	ReceiptDeadline *int64  `json:"receipt_deadline" gorm:"column:receipt_deadline;index"` // 等待送达回执的截止时间，0表示不等待
+
+	UpdatedAt int64 `json:"updated_at" gorm:"column:updated_at;autoUpdateTime:milli"`
+}
+
+func (SMSDelivery) TableName() string {
+	return "t_sms_deliveries"
+}
+
+// NewSMSDelivery 创建新的短信投递记录
+func NewSMSDelivery() *SMSDelivery {
+	return &SMSDelivery{
+		DeliveryID: utils.GenerateSMSDeliveryID(),
+		SMSDeliveryValues: &SMSDeliveryValues{
+			Status:          utils.StringPtr(protocol.StatusPending),
+			Attempt:         utils.IntPtr(1),
+			ReceiptDeadline: utils.Int64Ptr(0),
+		},
+	}
+}
+
+func (s *SMSDeliveryValues) GetRequestID() string {
+	if s.RequestID == nil {
+		return ""
+	}
+	return *s.RequestID
+}
+
+func (s *SMSDeliveryValues) GetProvider() string {
+	if s.Provider == nil {
+		return ""
+	}
+	return *s.Provider
+}
+
+func (s *SMSDeliveryValues) GetStatus() string {
+	if s.Status == nil {
+		return ""
+	}
+	return *s.Status
+}
+
+func (s *SMSDeliveryValues) GetAttempt() int {
+	if s.Attempt == nil {
+		return 0
+	}
+	return *s.Attempt
+}
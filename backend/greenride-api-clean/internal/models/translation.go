@@ -0,0 +1,142 @@
+package models
+
+import (
+	"greenride/internal/i18n"
+	"greenride/internal/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Translation 翻译表 - 管理后台维护的翻译，覆盖 locales 文件中的同名key
+type Translation struct {
+	ID        int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	Namespace string `json:"namespace" gorm:"column:namespace;type:varchar(16);uniqueIndex:idx_translation_key,priority:1"` // common, errors
+	Language  string `json:"language" gorm:"column:language;type:varchar(16);uniqueIndex:idx_translation_key,priority:2"`
+	Key       string `json:"key" gorm:"column:translation_key;type:varchar(128);uniqueIndex:idx_translation_key,priority:3"`
+	Value     string `json:"value" gorm:"column:value;type:text"`
+	UpdatedBy string `json:"updated_by" gorm:"column:updated_by;type:varchar(64)"`
+	CreatedAt int64  `json:"created_at" gorm:"column:created_at;autoCreateTime:milli"`
+	UpdatedAt int64  `json:"updated_at" gorm:"column:updated_at;autoUpdateTime:milli"`
+}
+
+func (Translation) TableName() string {
+	return "t_translations"
+}
+
+// TranslationFallback 翻译降级统计 - 请求的语言缺少该key时记录，供翻译人员补齐
+type TranslationFallback struct {
+	ID             int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	Namespace      string `json:"namespace" gorm:"column:namespace;type:varchar(16);uniqueIndex:idx_translation_fallback,priority:1"`
+	Language       string `json:"language" gorm:"column:language;type:varchar(16);uniqueIndex:idx_translation_fallback,priority:2"`
+	Key            string `json:"key" gorm:"column:translation_key;type:varchar(128);uniqueIndex:idx_translation_fallback,priority:3"`
+	ServedLanguage string `json:"served_language" gorm:"column:served_language;type:varchar(16)"` // 为空表示返回了原始key
+	Count          int64  `json:"count" gorm:"column:count;default:0"`
+	FirstSeenAt    int64  `json:"first_seen_at" gorm:"column:first_seen_at"`
+	LastSeenAt     int64  `json:"last_seen_at" gorm:"column:last_seen_at"`
+}
+
+func (TranslationFallback) TableName() string {
+	return "t_translation_fallbacks"
+}
+
+// GetAllTranslations 获取全部翻译
+func GetAllTranslations() ([]*Translation, error) {
+	var translations []*Translation
+	err := GetDB().Order("id ASC").Find(&translations).Error
+	return translations, err
+}
+
+// GetTranslationsByLanguage 获取某个命名空间和语言下的翻译，按key索引
+func GetTranslationsByLanguage(namespace, language string) (map[string]*Translation, error) {
+	var translations []*Translation
+	if err := GetDB().Where("namespace = ? AND language = ?", namespace, language).Find(&translations).Error; err != nil {
+		return nil, err
+	}
+	result := make(map[string]*Translation, len(translations))
+	for _, t := range translations {
+		result[t.Key] = t
+	}
+	return result, nil
+}
+
+// SaveTranslations 批量写入翻译，已存在的key更新内容；同时清除这些key的降级记录
+func SaveTranslations(translations []*Translation) error {
+	if len(translations) == 0 {
+		return nil
+	}
+	return GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "namespace"}, {Name: "language"}, {Name: "translation_key"}},
+			DoUpdates: clause.AssignmentColumns([]string{"value", "updated_by", "updated_at"}),
+		}).CreateInBatches(translations, 200).Error; err != nil {
+			return err
+		}
+		for _, t := range translations {
+			if err := tx.Where("namespace = ? AND language = ? AND translation_key = ?", t.Namespace, t.Language, t.Key).
+				Delete(&TranslationFallback{}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteTranslation 删除翻译，删除后回退到 locales 文件中的内容
+func DeleteTranslation(namespace, language, key string) (bool, error) {
+	result := GetDB().Where("namespace = ? AND language = ? AND translation_key = ?", namespace, language, key).Delete(&Translation{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetTranslationsVersion 翻译表的数据版本（条数和最近更新时间），用于判断是否需要重新加载
+func GetTranslationsVersion() (count int64, lastUpdatedAt int64, err error) {
+	var row struct {
+		Count         int64
+		LastUpdatedAt int64
+	}
+	err = GetDB().Model(&Translation{}).
+		Select("COUNT(*) AS count, COALESCE(MAX(updated_at), 0) AS last_updated_at").
+		Scan(&row).Error
+	return row.Count, row.LastUpdatedAt, err
+}
+
+// RecordTranslationFallbacks 累加降级次数
+func RecordTranslationFallbacks(fallbacks map[i18n.Fallback]int64) error {
+	if len(fallbacks) == 0 {
+		return nil
+	}
+	now := utils.TimeNowMilli()
+	records := make([]*TranslationFallback, 0, len(fallbacks))
+	for f, count := range fallbacks {
+		records = append(records, &TranslationFallback{
+			Namespace:      f.Namespace,
+			Language:       f.Language,
+			Key:            f.Key,
+			ServedLanguage: f.ServedLanguage,
+			Count:          count,
+			FirstSeenAt:    now,
+			LastSeenAt:     now,
+		})
+	}
+	return GetDB().Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "namespace"}, {Name: "language"}, {Name: "translation_key"}},
+		DoUpdates: append(
+			clause.Assignments(map[string]any{"count": gorm.Expr("count + VALUES(count)")}),
+			clause.AssignmentColumns([]string{"served_language", "last_seen_at"})...,
+		),
+	}).CreateInBatches(records, 200).Error
+}
+
+// GetTranslationFallbacks 获取降级记录，按次数从多到少
+func GetTranslationFallbacks(namespace string, languages []string) ([]*TranslationFallback, error) {
+	query := GetDB().Model(&TranslationFallback{})
+	if namespace != "" {
+		query = query.Where("namespace = ?", namespace)
+	}
+	if len(languages) > 0 {
+		query = query.Where("language IN ?", languages)
+	}
+	var fallbacks []*TranslationFallback
+	err := query.Order("count DESC").Find(&fallbacks).Error
+	return fallbacks, err
+}
@@ -0,0 +1,132 @@
+package models
+
+import (
+	"greenride/internal/protocol"
+	"greenride/internal/utils"
+)
+
+// TripShare 行程分享表 - 公开链接凭随机令牌访问，到期或撤销后失效
+type TripShare struct {
+	ID      int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ShareID string `json:"share_id" gorm:"column:share_id;type:varchar(64);uniqueIndex"`
+	*TripShareValues
+	CreatedAt int64 `json:"created_at" gorm:"column:created_at;autoCreateTime:milli"`
+}
+
+type TripShareValues struct {
+	UserID    *string `json:"user_id" gorm:"column:user_id;type:varchar(64);index"`
+	OrderID   *string `json:"order_id" gorm:"column:order_id;type:varchar(64);index"`
+	Token     *string `json:"token" gorm:"column:token;type:varchar(64);uniqueIndex"`
+	Source    *string `json:"source" gorm:"column:source;type:varchar(32)"` // manual, sos
+	ExpiresAt *int64  `json:"expires_at" gorm:"column:expires_at"`
+	RevokedAt *int64  `json:"revoked_at" gorm:"column:revoked_at"`
+	UpdatedAt int64   `json:"updated_at" gorm:"column:updated_at;autoUpdateTime:milli"`
+}
+
+func (TripShare) TableName() string {
+	return "t_trip_shares"
+}
+
+// NewTripShare 创建新的行程分享
+func NewTripShare(userID, orderID, source string, expiresAt int64) *TripShare {
+	return &TripShare{
+		ShareID: utils.GenerateTripShareID(),
+		TripShareValues: &TripShareValues{
+			UserID:    utils.StringPtr(userID),
+			OrderID:   utils.StringPtr(orderID),
+			Token:     utils.StringPtr(utils.GenerateShareToken()),
+			Source:    utils.StringPtr(source),
+			ExpiresAt: &expiresAt,
+		},
+	}
+}
+
+func (t *TripShareValues) GetUserID() string {
+	if t.UserID == nil {
+		return ""
+	}
+	return *t.UserID
+}
+
+func (t *TripShareValues) GetOrderID() string {
+	if t.OrderID == nil {
+		return ""
+	}
+	return *t.OrderID
+}
+
+func (t *TripShareValues) GetToken() string {
+	if t.Token == nil {
+		return ""
+	}
+	return *t.Token
+}
+
+func (t *TripShareValues) GetSource() string {
+	if t.Source == nil {
+		return ""
+	}
+	return *t.Source
+}
+
+func (t *TripShareValues) GetExpiresAt() int64 {
+	if t.ExpiresAt == nil {
+		return 0
+	}
+	return *t.ExpiresAt
+}
+
+func (t *TripShareValues) GetRevokedAt() int64 {
+	if t.RevokedAt == nil {
+		return 0
+	}
+	return *t.RevokedAt
+}
+
+// IsValid 分享链接未撤销且未过期
+func (t *TripShareValues) IsValid(now int64) bool {
+	return t.GetRevokedAt() == 0 && t.GetExpiresAt() > now
+}
+
+// Protocol 转换为协议对象
+func (t *TripShare) Protocol(url string) *protocol.TripShare {
+	return &protocol.TripShare{
+		ShareID:   t.ShareID,
+		OrderID:   t.GetOrderID(),
+		Source:    t.GetSource(),
+		URL:       url,
+		ExpiresAt: t.GetExpiresAt(),
+		RevokedAt: t.GetRevokedAt(),
+		CreatedAt: t.CreatedAt,
+	}
+}
+
+// CreateTripShare 保存行程分享
+func CreateTripShare(share *TripShare) error {
+	return GetDB().Create(share).Error
+}
+
+// GetTripShareByID 根据分享ID获取
+func GetTripShareByID(shareID string) *TripShare {
+	var share TripShare
+	if err := GetDB().Where("share_id = ?", shareID).First(&share).Error; err != nil {
+		return nil
+	}
+	return &share
+}
+
+// GetTripShareByToken 根据分享令牌获取
+func GetTripShareByToken(token string) *TripShare {
+	var share TripShare
+	if err := GetDB().Where("token = ?", token).First(&share).Error; err != nil {
+		return nil
+	}
+	return &share
+}
+
+// RevokeTripShare 撤销分享链接
+func RevokeTripShare(shareID string, revokedAt int64) error {
+	return GetDB().Model(&TripShare{}).
+		Where("share_id = ? AND (revoked_at IS NULL OR revoked_at = 0)", shareID).
+		Update("revoked_at", revokedAt).Error
+}
@@ -0,0 +1,112 @@
+package models
+
+import (
+	"greenride/internal/protocol"
+	"greenride/internal/utils"
+)
+
+// TrustedContact 紧急联系人表 - 紧急求助或分享行程时短信通知
+type TrustedContact struct {
+	ID        int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ContactID string `json:"contact_id" gorm:"column:contact_id;type:varchar(64);uniqueIndex"`
+	*TrustedContactValues
+	CreatedAt int64 `json:"created_at" gorm:"column:created_at;autoCreateTime:milli"`
+}
+
+type TrustedContactValues struct {
+	UserID       *string `json:"user_id" gorm:"column:user_id;type:varchar(64);uniqueIndex:idx_trusted_contact_phone"`
+	Name         *string `json:"name" gorm:"column:name;type:varchar(100)"`
+	Phone        *string `json:"phone" gorm:"column:phone;type:varchar(32);uniqueIndex:idx_trusted_contact_phone"`
+	Relationship *string `json:"relationship" gorm:"column:relationship;type:varchar(50)"`
+	UpdatedAt    int64   `json:"updated_at" gorm:"column:updated_at;autoUpdateTime:milli"`
+}
+
+func (TrustedContact) TableName() string {
+	return "t_trusted_contacts"
+}
+
+// NewTrustedContact 创建新的紧急联系人
+func NewTrustedContact(userID, name, phone, relationship string) *TrustedContact {
+	return &TrustedContact{
+		ContactID: utils.GenerateTrustedContactID(),
+		TrustedContactValues: &TrustedContactValues{
+			UserID:       utils.StringPtr(userID),
+			Name:         utils.StringPtr(name),
+			Phone:        utils.StringPtr(phone),
+			Relationship: utils.StringPtr(relationship),
+		},
+	}
+}
+
+func (t *TrustedContactValues) GetUserID() string {
+	if t.UserID == nil {
+		return ""
+	}
+	return *t.UserID
+}
+
+func (t *TrustedContactValues) GetName() string {
+	if t.Name == nil {
+		return ""
+	}
+	return *t.Name
+}
+
+func (t *TrustedContactValues) GetPhone() string {
+	if t.Phone == nil {
+		return ""
+	}
+	return *t.Phone
+}
+
+func (t *TrustedContactValues) GetRelationship() string {
+	if t.Relationship == nil {
+		return ""
+	}
+	return *t.Relationship
+}
+
+// Protocol 转换为协议对象
+func (t *TrustedContact) Protocol() *protocol.TrustedContact {
+	return &protocol.TrustedContact{
+		ContactID:    t.ContactID,
+		Name:         t.GetName(),
+		Phone:        t.GetPhone(),
+		Relationship: t.GetRelationship(),
+		CreatedAt:    t.CreatedAt,
+	}
+}
+
+// ListTrustedContacts 获取用户的紧急联系人
+func ListTrustedContacts(userID string) ([]*TrustedContact, error) {
+	var contacts []*TrustedContact
+	err := GetDB().Where("user_id = ?", userID).Order("created_at ASC").Find(&contacts).Error
+	return contacts, err
+}
+
+// CountTrustedContacts 统计用户的紧急联系人数
+func CountTrustedContacts(userID string) int64 {
+	var count int64
+	GetDB().Model(&TrustedContact{}).Where("user_id = ?", userID).Count(&count)
+	return count
+}
+
+// GetTrustedContactByPhone 获取用户已保存的同号码联系人
+func GetTrustedContactByPhone(userID, phone string) *TrustedContact {
+	var contact TrustedContact
+	if err := GetDB().Where("user_id = ? AND phone = ?", userID, phone).First(&contact).Error; err != nil {
+		return nil
+	}
+	return &contact
+}
+
+// CreateTrustedContact 保存紧急联系人
+func CreateTrustedContact(contact *TrustedContact) error {
+	return GetDB().Create(contact).Error
+}
+
+// DeleteTrustedContact 删除用户的紧急联系人，返回是否删除
+func DeleteTrustedContact(userID, contactID string) (bool, error) {
+	result := GetDB().Where("user_id = ? AND contact_id = ?", userID, contactID).Delete(&TrustedContact{})
+	return result.RowsAffected > 0, result.Error
+}
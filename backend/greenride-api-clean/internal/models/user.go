@@ -7,6 +7,11 @@ import (
 	"gorm.io/gorm"
 )
 
+// 系统自动停用原因
+const (
+	SuspendReasonDocumentExpired = "document_expired" // 证件过期
+)
+
 // User 用户表
 type User struct {
 	ID     int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
@@ -46,6 +51,7 @@ type UserValues struct {
 
 	// 状态信息
 	Status          *string `json:"status" gorm:"column:status;type:varchar(32);index;default:'active'"` // active, inactive, suspended, banned
+	SuspendReason   *string `json:"suspend_reason" gorm:"column:suspend_reason;type:varchar(64)"`        // 系统自动停用原因，如 document_expired
 	IsEmailVerified *bool   `json:"is_email_verified" gorm:"column:is_email_verified;default:false"`
 	IsPhoneVerified *bool   `json:"is_phone_verified" gorm:"column:is_phone_verified;default:false"`
 	OnlineStatus    *string `json:"online_status" gorm:"column:online_status;type:varchar(20);default:'offline'"` // online, offline, busy
@@ -215,6 +221,9 @@ func (u *UserValues) SetValues(values *UserValues) {
 	if values.Status != nil {
 		u.Status = values.Status
 	}
+	if values.SuspendReason != nil {
+		u.SuspendReason = values.SuspendReason
+	}
 	if values.IsEmailVerified != nil {
 		u.IsEmailVerified = values.IsEmailVerified
 	}
@@ -377,6 +386,18 @@ func (u *UserValues) GetStatus() string {
 	return *u.Status
 }
 
+func (u *UserValues) GetSuspendReason() string {
+	if u.SuspendReason == nil {
+		return ""
+	}
+	return *u.SuspendReason
+}
+
+// IsSuspendedForDocuments 是否因证件过期被系统自动停用
+func (u *User) IsSuspendedForDocuments() bool {
+	return u.GetStatus() == protocol.StatusSuspended && u.GetSuspendReason() == SuspendReasonDocumentExpired
+}
+
 func (u *UserValues) IsActive() bool {
 	return u.GetStatus() == protocol.StatusActive
 }
@@ -477,6 +498,12 @@ func (u *UserValues) SetStatus(status string) *UserValues {
 	return u
 }
 
+// SetSuspendReason 设置停用原因，传空字符串表示清除
+func (u *UserValues) SetSuspendReason(reason string) *UserValues {
+	u.SuspendReason = &reason
+	return u
+}
+
 // SetActiveStatus 设置用户在线状态
 func (u *UserValues) SetActiveStatus(status string) *UserValues {
 	u.OnlineStatus = &status
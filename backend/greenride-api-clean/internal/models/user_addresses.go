@@ -5,6 +5,8 @@ import (
 	"greenride/internal/protocol"
 	"greenride/internal/utils"
 	"strings"
+
+	"gorm.io/gorm"
 )
 
 // UserAddress 用户地址表 - 用户常用地址和收货地址管理
@@ -147,6 +149,7 @@ const (
 	AddressTypePickup   = "pickup"
 	AddressTypeDropoff  = "dropoff"
 	AddressTypeDelivery = "delivery"
+	AddressTypeCustom   = "custom" // 乘客自定义的常用地点
 )
 
 // 地址状态常量
@@ -364,6 +367,41 @@ func (u *UserAddressValues) GetSafetyLevel() string {
 	return *u.SafetyLevel
 }
 
+func (u *UserAddressValues) GetBuildingName() string {
+	if u.BuildingName == nil {
+		return ""
+	}
+	return *u.BuildingName
+}
+
+func (u *UserAddressValues) GetFloor() string {
+	if u.Floor == nil {
+		return ""
+	}
+	return *u.Floor
+}
+
+func (u *UserAddressValues) GetUnit() string {
+	if u.Unit == nil {
+		return ""
+	}
+	return *u.Unit
+}
+
+func (u *UserAddressValues) GetAccessInstructions() string {
+	if u.AccessInstructions == nil {
+		return ""
+	}
+	return *u.AccessInstructions
+}
+
+func (u *UserAddressValues) GetLastUsedAt() int64 {
+	if u.LastUsedAt == nil {
+		return 0
+	}
+	return *u.LastUsedAt
+}
+
 func (u *UserAddressValues) GetIconType() string {
 	if u.IconType == nil {
 		return IconTypeOther
@@ -422,6 +460,11 @@ func (u *UserAddressValues) SetContact(name, phone, email string) *UserAddressVa
 	return u
 }
 
+func (u *UserAddressValues) SetAccessInstructions(instructions string) *UserAddressValues {
+	u.AccessInstructions = &instructions
+	return u
+}
+
 func (u *UserAddressValues) SetStatus(status string) *UserAddressValues {
 	u.Status = &status
 	return u
@@ -885,3 +928,70 @@ func NewDropoffAddress(userID string, lat, lng float64, address string) *UserAdd
 
 	return addr
 }
+
+// Protocol 转换为常用地点协议对象
+func (u *UserAddress) Protocol() *protocol.SavedPlace {
+	return &protocol.SavedPlace{
+		PlaceID:            u.UserAddressID,
+		Type:               u.GetAddressType(),
+		Label:              u.GetLabel(),
+		Latitude:           u.GetLatitude(),
+		Longitude:          u.GetLongitude(),
+		Address:            u.GetFormattedAddress(),
+		BuildingName:       u.GetBuildingName(),
+		Floor:              u.GetFloor(),
+		Unit:               u.GetUnit(),
+		AccessInstructions: u.GetAccessInstructions(),
+		UsageCount:         u.GetUsageCount(),
+		LastUsedAt:         u.GetLastUsedAt(),
+		CreatedAt:          u.CreatedAt,
+	}
+}
+
+// 常用地点包含的地址类型
+var SavedPlaceAddressTypes = []string{AddressTypeHome, AddressTypeWork, AddressTypeCustom}
+
+// GetUserSavedPlace 获取用户未删除的常用地点
+func GetUserSavedPlace(userID, placeID string) *UserAddress {
+	var address UserAddress
+	if err := GetDB().Where("user_address_id = ? AND user_id = ? AND address_type IN ? AND status = ?",
+		placeID, userID, SavedPlaceAddressTypes, AddressStatusActive).First(&address).Error; err != nil {
+		return nil
+	}
+	return &address
+}
+
+// GetUserSavedPlaceByType 获取用户的家或公司地址
+func GetUserSavedPlaceByType(userID, addressType string) *UserAddress {
+	var address UserAddress
+	if err := GetDB().Where("user_id = ? AND address_type = ? AND status = ?", userID, addressType, AddressStatusActive).
+		Order("id DESC").First(&address).Error; err != nil {
+		return nil
+	}
+	return &address
+}
+
+// ListUserSavedPlaces 获取用户全部常用地点，按显示优先级和使用次数排序
+func ListUserSavedPlaces(userID string) ([]*UserAddress, error) {
+	var addresses []*UserAddress
+	err := GetDB().Where("user_id = ? AND address_type IN ? AND status = ?", userID, SavedPlaceAddressTypes, AddressStatusActive).
+		Order("priority ASC, usage_count DESC, id DESC").Find(&addresses).Error
+	return addresses, err
+}
+
+// CountUserSavedPlaces 统计用户某类常用地点数量
+func CountUserSavedPlaces(userID, addressType string) int64 {
+	var count int64
+	GetDB().Model(&UserAddress{}).Where("user_id = ? AND address_type = ? AND status = ?", userID, addressType, AddressStatusActive).Count(&count)
+	return count
+}
+
+// IncrementUserAddressUsage 下单使用常用地点后累计使用次数
+func IncrementUserAddressUsage(addressID string) error {
+	now := utils.TimeNowMilli()
+	return GetDB().Model(&UserAddress{}).Where("user_address_id = ?", addressID).Updates(map[string]any{
+		"usage_count":   gorm.Expr("usage_count + 1"),
+		"last_used_at":  now,
+		"first_used_at": gorm.Expr("COALESCE(first_used_at, ?)", now),
+	}).Error
+}
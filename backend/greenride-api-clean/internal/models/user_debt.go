@@ -0,0 +1,344 @@
+package models
+
+import (
+	"greenride/internal/protocol"
+	"greenride/internal/utils"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// UserDebt 乘客欠款表 - 取消费、爽约费等未能即时扣款的费用，每个订单每种费用一条
+type UserDebt struct {
+	ID     int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	DebtID string `json:"debt_id" gorm:"column:debt_id;type:varchar(64);uniqueIndex"`
+	*UserDebtValues
+	CreatedAt int64 `json:"created_at" gorm:"column:created_at;autoCreateTime:milli"`
+}
+
+type UserDebtValues struct {
+	UserID        *string          `json:"user_id" gorm:"column:user_id;type:varchar(64);index"`
+	OrderID       *string          `json:"order_id" gorm:"column:order_id;type:varchar(64);uniqueIndex:idx_user_debt_order"`
+	Type          *string          `json:"type" gorm:"column:type;type:varchar(32);uniqueIndex:idx_user_debt_order"` // cancellation_fee, no_show_fee, ride_fare
+	DriverID      *string          `json:"driver_id" gorm:"column:driver_id;type:varchar(64);index"`                 // 分成司机
+	Amount        *decimal.Decimal `json:"amount" gorm:"column:amount;type:decimal(20,6)"`
+	Currency      *string          `json:"currency" gorm:"column:currency;type:varchar(3)"`
+	DriverShare   *decimal.Decimal `json:"driver_share" gorm:"column:driver_share;type:decimal(20,6)"`
+	PlatformShare *decimal.Decimal `json:"platform_share" gorm:"column:platform_share;type:decimal(20,6)"`
+	PaymentMethod *string          `json:"payment_method" gorm:"column:payment_method;type:varchar(32)"`             // 实际扣款方式
+	Status        *string          `json:"status" gorm:"column:status;type:varchar(32);index;default:'outstanding'"` // outstanding, paid, waived
+	TransactionID *string          `json:"transaction_id" gorm:"column:transaction_id;type:varchar(64)"`             // 扣款流水ID
+	PaidAt        *int64           `json:"paid_at" gorm:"column:paid_at"`
+	Remark        *string          `json:"remark" gorm:"column:remark;type:varchar(500)"`
+	Attempts      *int             `json:"attempts" gorm:"column:attempts;default:0"`             // 已自动扣款次数（车费欠款）
+	NextChargeAt  *int64           `json:"next_charge_at" gorm:"column:next_charge_at;index"`     // 下次自动扣款时间，0表示不再自动重试
+	LastError     *string          `json:"last_error" gorm:"column:last_error;type:varchar(255)"` // 最近一次扣款失败原因
+	UpdatedAt     int64            `json:"updated_at" gorm:"column:updated_at;autoUpdateTime:milli"`
+}
+
+func (UserDebt) TableName() string {
+	return "t_user_debts"
+}
+
+// NewUserDebt 创建新的乘客欠款
+func NewUserDebt(userID, orderID, debtType string) *UserDebt {
+	return &UserDebt{
+		DebtID: utils.GenerateUserDebtID(),
+		UserDebtValues: &UserDebtValues{
+			UserID:  utils.StringPtr(userID),
+			OrderID: utils.StringPtr(orderID),
+			Type:    utils.StringPtr(debtType),
+			Status:  utils.StringPtr(protocol.DebtStatusOutstanding),
+		},
+	}
+}
+
+func (d *UserDebtValues) GetUserID() string {
+	if d.UserID == nil {
+		return ""
+	}
+	return *d.UserID
+}
+
+func (d *UserDebtValues) GetOrderID() string {
+	if d.OrderID == nil {
+		return ""
+	}
+	return *d.OrderID
+}
+
+func (d *UserDebtValues) GetType() string {
+	if d.Type == nil {
+		return ""
+	}
+	return *d.Type
+}
+
+func (d *UserDebtValues) GetDriverID() string {
+	if d.DriverID == nil {
+		return ""
+	}
+	return *d.DriverID
+}
+
+func (d *UserDebtValues) GetAmount() decimal.Decimal {
+	if d.Amount == nil {
+		return decimal.Zero
+	}
+	return *d.Amount
+}
+
+func (d *UserDebtValues) GetCurrency() string {
+	if d.Currency == nil {
+		return ""
+	}
+	return *d.Currency
+}
+
+func (d *UserDebtValues) GetDriverShare() decimal.Decimal {
+	if d.DriverShare == nil {
+		return decimal.Zero
+	}
+	return *d.DriverShare
+}
+
+func (d *UserDebtValues) GetPlatformShare() decimal.Decimal {
+	if d.PlatformShare == nil {
+		return decimal.Zero
+	}
+	return *d.PlatformShare
+}
+
+func (d *UserDebtValues) GetPaymentMethod() string {
+	if d.PaymentMethod == nil {
+		return ""
+	}
+	return *d.PaymentMethod
+}
+
+func (d *UserDebtValues) GetStatus() string {
+	if d.Status == nil {
+		return ""
+	}
+	return *d.Status
+}
+
+func (d *UserDebtValues) GetTransactionID() string {
+	if d.TransactionID == nil {
+		return ""
+	}
+	return *d.TransactionID
+}
+
+func (d *UserDebtValues) GetPaidAt() int64 {
+	if d.PaidAt == nil {
+		return 0
+	}
+	return *d.PaidAt
+}
+
+func (d *UserDebtValues) GetAttempts() int {
+	if d.Attempts == nil {
+		return 0
+	}
+	return *d.Attempts
+}
+
+func (d *UserDebtValues) GetNextChargeAt() int64 {
+	if d.NextChargeAt == nil {
+		return 0
+	}
+	return *d.NextChargeAt
+}
+
+func (d *UserDebtValues) GetLastError() string {
+	if d.LastError == nil {
+		return ""
+	}
+	return *d.LastError
+}
+
+func (d *UserDebtValues) GetRemark() string {
+	if d.Remark == nil {
+		return ""
+	}
+	return *d.Remark
+}
+
+// SetAmounts 设置欠款金额及司机、平台分成
+func (d *UserDebtValues) SetAmounts(amount, driverShare, platformShare decimal.Decimal, currency string) *UserDebtValues {
+	d.Amount = &amount
+	d.DriverShare = &driverShare
+	d.PlatformShare = &platformShare
+	d.Currency = &currency
+	return d
+}
+
+// Protocol 转换为协议对象
+func (d *UserDebt) Protocol() *protocol.UserDebt {
+	amount, _ := d.GetAmount().Float64()
+	driverShare, _ := d.GetDriverShare().Float64()
+	platformShare, _ := d.GetPlatformShare().Float64()
+	return &protocol.UserDebt{
+		DebtID:        d.DebtID,
+		UserID:        d.GetUserID(),
+		OrderID:       d.GetOrderID(),
+		DriverID:      d.GetDriverID(),
+		Type:          d.GetType(),
+		Amount:        amount,
+		Currency:      d.GetCurrency(),
+		DriverShare:   driverShare,
+		PlatformShare: platformShare,
+		PaymentMethod: d.GetPaymentMethod(),
+		Status:        d.GetStatus(),
+		TransactionID: d.GetTransactionID(),
+		PaidAt:        d.GetPaidAt(),
+		Attempts:      d.GetAttempts(),
+		NextChargeAt:  d.GetNextChargeAt(),
+		LastError:     d.GetLastError(),
+		CreatedAt:     d.CreatedAt,
+		UpdatedAt:     d.UpdatedAt,
+	}
+}
+
+// CreateUserDebt 在事务中创建乘客欠款
+func CreateUserDebt(tx *gorm.DB, debt *UserDebt) error {
+	return tx.Create(debt).Error
+}
+
+// GetUserDebtByID 根据欠款ID获取
+func GetUserDebtByID(debtID string) *UserDebt {
+	var debt UserDebt
+	if err := GetDB().Where("debt_id = ?", debtID).First(&debt).Error; err != nil {
+		return nil
+	}
+	return &debt
+}
+
+// TransitionUserDebtStatus 条件更新欠款状态，返回是否更新成功（用于防止重复扣款和重复分成）
+func TransitionUserDebtStatus(debtID string, fromStatuses []string, updates map[string]any) (bool, error) {
+	result := GetDB().Model(&UserDebt{}).
+		Where("debt_id = ? AND status IN ?", debtID, fromStatuses).
+		Updates(updates)
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetUserDebtByOrder 获取订单指定类型的欠款
+func GetUserDebtByOrder(orderID, debtType string) *UserDebt {
+	var debt UserDebt
+	if err := GetDB().Where("order_id = ? AND type = ?", orderID, debtType).First(&debt).Error; err != nil {
+		return nil
+	}
+	return &debt
+}
+
+// ListUserOutstandingDebts 获取乘客未结清的欠款
+func ListUserOutstandingDebts(userID string) ([]*UserDebt, error) {
+	var debts []*UserDebt
+	err := GetDB().Where("user_id = ? AND status = ?", userID, protocol.DebtStatusOutstanding).
+		Order("created_at ASC").Find(&debts).Error
+	return debts, err
+}
+
+// SumUserOutstandingDebt 汇总乘客指定币种未结清的欠款
+func SumUserOutstandingDebt(userID, currency string) decimal.Decimal {
+	var total decimal.NullDecimal
+	GetDB().Model(&UserDebt{}).
+		Where("user_id = ? AND status = ? AND currency = ?", userID, protocol.DebtStatusOutstanding, currency).
+		Select("SUM(amount)").Scan(&total)
+	if !total.Valid {
+		return decimal.Zero
+	}
+	return total.Decimal
+}
+
+// ListDueFareDebts 获取已到自动扣款时间的车费欠款
+func ListDueFareDebts(now int64, limit int) ([]*UserDebt, error) {
+	var debts []*UserDebt
+	err := GetDB().Where("type = ? AND status = ? AND next_charge_at > 0 AND next_charge_at <= ?", protocol.DebtTypeRideFare, protocol.DebtStatusOutstanding, now).
+		Order("next_charge_at ASC").Limit(limit).Find(&debts).Error
+	return debts, err
+}
+
+// ListUncollectedOrderIDs 获取行程结束超过宽限期仍未支付、也没有车费欠款的订单（现金支付由司机收取，不计入）
+func ListUncollectedOrderIDs(endedBefore int64, limit int) ([]string, error) {
+	var orderIDs []string
+	err := GetDB().Model(&Order{}).
+		Where("order_type IN ? AND status = ?", []string{protocol.RideOrder, protocol.DeliveryOrder}, protocol.StatusTripEnded).
+		Where("ended_at > 0 AND ended_at < ?", endedBefore).
+		Where("(payment_status IS NULL OR payment_status <> ?)", protocol.StatusSuccess).
+		Where("(payment_method IS NULL OR payment_method <> ?)", protocol.PaymentMethodCash).
+		Where("order_id NOT IN (?)", GetDB().Model(&UserDebt{}).Select("order_id").Where("type = ?", protocol.DebtTypeRideFare)).
+		Order("ended_at ASC").Limit(limit).
+		Pluck("order_id", &orderIDs).Error
+	return orderIDs, err
+}
+
+// receivableQuery 应收欠款查询条件，默认只查未结清的欠款
+func receivableQuery(req *protocol.ReceivableSearchRequest) *gorm.DB {
+	query := GetDB().Model(&UserDebt{})
+	status := req.Status
+	if status == "" {
+		status = protocol.DebtStatusOutstanding
+	}
+	query = query.Where("status = ?", status)
+	if req.UserID != "" {
+		query = query.Where("user_id = ?", req.UserID)
+	}
+	if req.OrderID != "" {
+		query = query.Where("order_id = ?", req.OrderID)
+	}
+	if req.Type != "" {
+		query = query.Where("type = ?", req.Type)
+	}
+	if req.Exhausted {
+		query = query.Where("type = ? AND (next_charge_at IS NULL OR next_charge_at = 0)", protocol.DebtTypeRideFare)
+	}
+	return query
+}
+
+// SearchReceivables 分页查询应收欠款
+func SearchReceivables(req *protocol.ReceivableSearchRequest) ([]*UserDebt, int64) {
+	query := receivableQuery(req)
+	var total int64
+	query.Count(&total)
+
+	var debts []*UserDebt
+	query.Order("created_at DESC").
+		Offset((req.Page - 1) * req.Limit).
+		Limit(req.Limit).
+		Find(&debts)
+	return debts, total
+}
+
+// SummarizeReceivables 按币种汇总未结清的欠款
+func SummarizeReceivables() ([]*protocol.ReceivableSummary, error) {
+	var rows []struct {
+		Currency       string
+		Amount         decimal.NullDecimal
+		DebtCount      int64
+		RiderCount     int64
+		ExhaustedCount int64
+	}
+	err := GetDB().Model(&UserDebt{}).
+		Select("currency, SUM(amount) AS amount, COUNT(*) AS debt_count, COUNT(DISTINCT user_id) AS rider_count, "+
+			"SUM(CASE WHEN type = ? AND (next_charge_at IS NULL OR next_charge_at = 0) THEN 1 ELSE 0 END) AS exhausted_count", protocol.DebtTypeRideFare).
+		Where("status = ?", protocol.DebtStatusOutstanding).
+		Group("currency").Order("currency").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	summaries := make([]*protocol.ReceivableSummary, 0, len(rows))
+	for _, row := range rows {
+		amount, _ := row.Amount.Decimal.Float64()
+		summaries = append(summaries, &protocol.ReceivableSummary{
+			Currency:       row.Currency,
+			Amount:         amount,
+			DebtCount:      row.DebtCount,
+			RiderCount:     row.RiderCount,
+			ExhaustedCount: row.ExhaustedCount,
+		})
+	}
+	return summaries, nil
+}
@@ -3,6 +3,8 @@ package models
 import (
 	"greenride/internal/protocol"
 	"greenride/internal/utils"
+
+	"gorm.io/gorm"
 )
 
 // UserPaymentMethod 用户支付方式关联表 - 用户与支付方式的多对多关联关系
@@ -20,7 +22,7 @@ type UserPaymentMethodValues struct {
 	PaymentMethodID *string `json:"payment_method_id" gorm:"column:payment_method_id;type:varchar(64);index"` // 支付方式ID
 
 	// 关联状态
-	Status      *string `json:"status" gorm:"column:status;type:varchar(30);index;default:'active'"` // active, inactive, deleted, suspended
+	Status      *string `json:"status" gorm:"column:status;type:varchar(30);index;default:'active'"` // active, inactive, deleted, suspended, pending, failed
 	IsDefault   *bool   `json:"is_default" gorm:"column:is_default;default:false"`                   // 是否为默认支付方式
 	IsPrimary   *bool   `json:"is_primary" gorm:"column:is_primary;default:false"`                   // 是否为主要支付方式
 	IsPreferred *bool   `json:"is_preferred" gorm:"column:is_preferred;default:false"`               // 是否为偏好支付方式
@@ -130,6 +132,8 @@ const (
 	UserPaymentMethodStatusInactive  = "inactive"
 	UserPaymentMethodStatusDeleted   = "deleted"
 	UserPaymentMethodStatusSuspended = "suspended"
+	UserPaymentMethodStatusPending   = "pending" // 待验证（MoMo小额验证或Stripe SetupIntent未完成）
+	UserPaymentMethodStatusFailed    = "failed"  // 验证失败，可重新发起验证
 )
 
 // 验证方式常量
@@ -348,6 +352,27 @@ func (u *UserPaymentMethodValues) GetPriority() int {
 	return *u.Priority
 }
 
+func (u *UserPaymentMethodValues) GetVerifiedAt() int64 {
+	if u.VerifiedAt == nil {
+		return 0
+	}
+	return *u.VerifiedAt
+}
+
+func (u *UserPaymentMethodValues) GetLastUsedAt() int64 {
+	if u.LastUsedAt == nil {
+		return 0
+	}
+	return *u.LastUsedAt
+}
+
+func (u *UserPaymentMethodValues) GetNotes() string {
+	if u.Notes == nil {
+		return ""
+	}
+	return *u.Notes
+}
+
 func (u *UserPaymentMethodValues) GetUserAlias() string {
 	if u.UserAlias == nil {
 		return ""
@@ -806,3 +831,74 @@ func NewUserCashPayment(userID, paymentMethodID string) *UserPaymentMethod {
 
 	return upm
 }
+
+// GetUserPaymentMethodByID 获取用户未删除的支付方式
+func GetUserPaymentMethodByID(userID, userPaymentMethodID string) *UserPaymentMethod {
+	var method UserPaymentMethod
+	if err := GetDB().Where("user_payment_method_id = ? AND user_id = ? AND status <> ?", userPaymentMethodID, userID, UserPaymentMethodStatusDeleted).
+		First(&method).Error; err != nil {
+		return nil
+	}
+	return &method
+}
+
+// ListUserPaymentMethods 获取用户未删除的支付方式，默认支付方式排在最前
+func ListUserPaymentMethods(userID string) ([]*UserPaymentMethod, error) {
+	var methods []*UserPaymentMethod
+	err := GetDB().Where("user_id = ? AND status <> ?", userID, UserPaymentMethodStatusDeleted).
+		Order("is_default DESC, id DESC").Find(&methods).Error
+	return methods, err
+}
+
+// CountUserPaymentMethods 统计用户未删除的支付方式数量
+func CountUserPaymentMethods(userID string) int64 {
+	var count int64
+	GetDB().Model(&UserPaymentMethod{}).Where("user_id = ? AND status <> ?", userID, UserPaymentMethodStatusDeleted).Count(&count)
+	return count
+}
+
+// GetUserDefaultPaymentMethod 获取用户已验证的默认支付方式
+func GetUserDefaultPaymentMethod(userID string) *UserPaymentMethod {
+	var method UserPaymentMethod
+	if err := GetDB().Where("user_id = ? AND status = ? AND is_verified = ? AND is_default = ?", userID, UserPaymentMethodStatusActive, true, true).
+		Order("id DESC").First(&method).Error; err != nil {
+		return nil
+	}
+	return &method
+}
+
+// SetUserDefaultPaymentMethod 将指定支付方式设为默认，同时取消用户其他支付方式的默认标记
+func SetUserDefaultPaymentMethod(userID, userPaymentMethodID string) error {
+	return GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&UserPaymentMethod{}).Where("user_id = ? AND user_payment_method_id <> ? AND is_default = ?", userID, userPaymentMethodID, true).
+			Update("is_default", false).Error; err != nil {
+			return err
+		}
+		return tx.Model(&UserPaymentMethod{}).Where("user_payment_method_id = ?", userPaymentMethodID).Update("is_default", true).Error
+	})
+}
+
+// TransitionUserPaymentMethodStatus 条件更新支付方式状态，返回是否更新成功（用于防止验证结果重复处理）
+func TransitionUserPaymentMethodStatus(userPaymentMethodID string, fromStatuses []string, updates map[string]any) (bool, error) {
+	result := GetDB().Model(&UserPaymentMethod{}).
+		Where("user_payment_method_id = ? AND status IN ?", userPaymentMethodID, fromStatuses).
+		Updates(updates)
+	return result.RowsAffected > 0, result.Error
+}
+
+// RecordUserPaymentMethodUsage 自动扣款后累计交易统计
+func RecordUserPaymentMethodUsage(userPaymentMethodID string, amount float64, success bool) error {
+	now := utils.TimeNowMilli()
+	updates := map[string]any{
+		"total_transactions": gorm.Expr("total_transactions + 1"),
+		"last_used_at":       now,
+		"first_used_at":      gorm.Expr("COALESCE(first_used_at, ?)", now),
+	}
+	if success {
+		updates["successful_transactions"] = gorm.Expr("successful_transactions + 1")
+		updates["total_amount"] = gorm.Expr("total_amount + ?", amount)
+	} else {
+		updates["failed_transactions"] = gorm.Expr("failed_transactions + 1")
+	}
+	return GetDB().Model(&UserPaymentMethod{}).Where("user_payment_method_id = ?", userPaymentMethodID).Updates(updates).Error
+}
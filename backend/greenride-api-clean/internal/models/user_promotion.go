@@ -734,9 +734,17 @@ func GetAvailablePromotionsByUser(userID string, codes []string) []*UserPromotio
 		return nil
 	}
 
-	// 进一步筛选可用的优惠券（检查过期时间等）
+	// 进一步筛选可用的优惠券（检查过期时间、优惠预算等）
+	promotionIDs := make([]string, 0, len(promotions))
+	for _, promo := range promotions {
+		promotionIDs = append(promotionIDs, promo.PromotionID)
+	}
+	exhausted := GetBudgetExhaustedPromotionIDs(promotionIDs)
 	var availablePromotions []*UserPromotion
 	for _, promo := range promotions {
+		if exhausted[promo.PromotionID] {
+			continue
+		}
 		if promo.CanUse() {
 			availablePromotions = append(availablePromotions, promo)
 		}
@@ -762,14 +770,26 @@ func GetUserPromotionByID(id string) *UserPromotion {
 }
 
 // ResetUserPromotionsByIDs 批量重置用户优惠券状态为可用
-// 传入用户优惠券ID列表，将已使用的优惠券恢复为可用状态
-// 使用事务和直接批量更新，无需先查询，提高性能
-func ResetUserPromotionsByIDs(tx *gorm.DB, userPromotionIDs []string) error {
-	if len(userPromotionIDs) == 0 {
+// 传入订单ID和用户优惠券ID列表，将该订单已使用的优惠券恢复为可用状态，并退回对应的优惠预算
+func ResetUserPromotionsByIDs(tx *gorm.DB, orderID string, userPromotionIDs []string) error {
+	if orderID == "" || len(userPromotionIDs) == 0 {
+		return nil
+	}
+
+	var used []*UserPromotion
+	if err := tx.Where("order_id = ? AND promotion_id IN ? AND is_used = 1", orderID, userPromotionIDs).
+		Find(&used).Error; err != nil {
+		log.Printf("Failed to query used user promotions for order %s: %v", orderID, err)
+		return err
+	}
+	if len(used) == 0 {
 		return nil
 	}
 
-	// 直接批量更新：只更新 is_used = 1 的记录
+	ids := make([]int64, 0, len(used))
+	for _, promo := range used {
+		ids = append(ids, promo.ID)
+	}
 	updateData := map[string]any{
 		"status":      protocol.StatusAvailable,
 		"is_used":     0,
@@ -778,16 +798,20 @@ func ResetUserPromotionsByIDs(tx *gorm.DB, userPromotionIDs []string) error {
 		"used_at":     nil,
 		"updated_at":  utils.TimeNowMilli(),
 	}
-
 	result := tx.Model(&UserPromotion{}).
-		Where("promotion_id IN ? AND is_used = 1", userPromotionIDs).
+		Where("id IN ? AND is_used = 1", ids).
 		Updates(updateData)
-
 	if result.Error != nil {
 		log.Printf("Failed to batch reset user promotion status: %v", result.Error)
 		return result.Error
 	}
 
+	for _, promo := range used {
+		if err := ReleasePromotionBudget(tx, promo.PromotionID, promo.GetUsedAmount()); err != nil {
+			return err
+		}
+	}
+
 	log.Printf("Successfully reset %d user promotions to available status", result.RowsAffected)
 	return nil
 }
@@ -834,11 +858,21 @@ func UseUserPromotionsByIDs(tx *gorm.DB, orderID string, promotionUsageMap map[s
 	log.Printf("Successfully marked %d user promotions as used", successCount)
 	return nil
 }
-func UseUserPromotionByID(tx *gorm.DB, promotionID, orderID string, usedAmount float64) error {
+
+// UseUserPromotionByID 将用户的一张可用优惠券标记为已使用，并计入优惠预算
+func UseUserPromotionByID(tx *gorm.DB, userID, promotionID, orderID string, usedAmount float64) error {
 	if promotionID == "" || orderID == "" {
 		return nil
 	}
 
+	var userPromo UserPromotion
+	err := tx.Where("user_id = ? AND promotion_id = ? AND is_used = 0", userID, promotionID).
+		Order("id ASC").First(&userPromo).Error
+	if err != nil {
+		log.Printf("No available user promotion found for marking: user=%s, ID=%s", userID, promotionID)
+		return nil
+	}
+
 	now := utils.TimeNowMilli()
 	updateData := map[string]any{
 		"status":      protocol.StatusUsed,
@@ -850,7 +884,7 @@ func UseUserPromotionByID(tx *gorm.DB, promotionID, orderID string, usedAmount f
 	}
 
 	result := tx.Model(&UserPromotion{}).
-		Where("promotion_id = ? AND is_used = 0", promotionID).
+		Where("id = ? AND is_used = 0", userPromo.ID).
 		Updates(updateData)
 
 	if result.Error != nil {
@@ -860,11 +894,10 @@ func UseUserPromotionByID(tx *gorm.DB, promotionID, orderID string, usedAmount f
 
 	if result.RowsAffected == 0 {
 		log.Printf("No available user promotion found for marking: ID=%s", promotionID)
-	} else {
-		log.Printf("Successfully marked user promotion as used: ID=%s", promotionID)
+		return nil
 	}
-
-	return nil
+	log.Printf("Successfully marked user promotion as used: ID=%s", promotionID)
+	return ConsumePromotionBudget(tx, promotionID, usedAmount)
 }
 
 // CheckUserHasWelcomeCoupon 检查用户是否已有欢迎优惠券
@@ -876,8 +909,20 @@ func CheckUserHasWelcomeCoupon(userID string) bool {
 	return count > 0
 }
 
-// CreateUserPromotionInDB 创建用户优惠券并保存到数据库
+// CheckUserHasBatchCoupon 检查用户是否已领取过某批次的优惠券
+func CheckUserHasBatchCoupon(userID, batchID string) bool {
+	var count int64
+	GetDB().Model(&UserPromotion{}).
+		Where("user_id = ? AND batch_id = ?", userID, batchID).
+		Count(&count)
+	return count > 0
+}
+
+// CreateUserPromotionInDB 创建用户优惠券并保存到数据库，优惠已停用或预算用完时不再发放
 func CreateUserPromotionInDB(userPromotion *UserPromotion) error {
+	if promotion := GetPromotionByID(userPromotion.PromotionID); promotion != nil && !promotion.IsValid() {
+		return ErrPromotionUnavailable
+	}
 	return GetDB().Create(userPromotion).Error
 }
 
@@ -45,6 +45,7 @@ type VehicleValues struct {
 	InsuranceCompany      *string `json:"insurance_company" gorm:"column:insurance_company;type:varchar(100)"`
 	InsurancePolicyNumber *string `json:"insurance_policy_number" gorm:"column:insurance_policy_number;type:varchar(100)"`
 	InsuranceExpiry       *int64  `json:"insurance_expiry" gorm:"column:insurance_expiry"`
+	InspectionExpiry      *int64  `json:"inspection_expiry" gorm:"column:inspection_expiry"` // 车检有效期
 
 	// 位置信息
 	CurrentLatitude   *float64 `json:"current_latitude" gorm:"column:current_latitude;type:decimal(10,8)"`
@@ -320,6 +321,13 @@ func (v *VehicleValues) GetInsuranceExpiry() int64 {
 	return *v.InsuranceExpiry
 }
 
+func (v *VehicleValues) GetInspectionExpiry() int64 {
+	if v.InspectionExpiry == nil {
+		return 0
+	}
+	return *v.InspectionExpiry
+}
+
 func (v *VehicleValues) GetCurrentLatitude() float64 {
 	if v.CurrentLatitude == nil {
 		return 0.0
@@ -559,6 +567,21 @@ func (v *VehicleValues) SetInsurancePolicyNumber(policyNumber string) *VehicleVa
 	return v
 }
 
+func (v *VehicleValues) SetRegistrationExpiry(expiry int64) *VehicleValues {
+	v.RegistrationExpiry = &expiry
+	return v
+}
+
+func (v *VehicleValues) SetInsuranceExpiry(expiry int64) *VehicleValues {
+	v.InsuranceExpiry = &expiry
+	return v
+}
+
+func (v *VehicleValues) SetInspectionExpiry(expiry int64) *VehicleValues {
+	v.InspectionExpiry = &expiry
+	return v
+}
+
 func (v *VehicleValues) SetNotes(notes string) *VehicleValues {
 	v.Notes = &notes
 	return v
@@ -610,6 +633,13 @@ func (v *Vehicle) IsInsuranceExpired() bool {
 	return *v.InsuranceExpiry < utils.TimeNowMilli()
 }
 
+func (v *Vehicle) IsInspectionExpired() bool {
+	if v.InspectionExpiry == nil {
+		return false
+	}
+	return *v.InspectionExpiry < utils.TimeNowMilli()
+}
+
 func (v *VehicleValues) MarkAsVerified() {
 	v.VerifyStatus = utils.StringPtr(protocol.StatusVerified)
 	now := utils.TimeNowMilli()
@@ -741,6 +771,10 @@ func (v *Vehicle) Protocol() *protocol.Vehicle {
 		expiry := utils.MilliToTime(insExpiry).Format(time.DateOnly)
 		vehicle.InsuranceExpiry = &expiry
 	}
+	if inspExpiry := v.GetInspectionExpiry(); inspExpiry > 0 {
+		expiry := utils.MilliToTime(inspExpiry).Format(time.DateOnly)
+		vehicle.InspectionExpiry = expiry
+	}
 
 	// 位置信息
 	if lat := v.GetCurrentLatitude(); lat != 0 {
@@ -780,7 +814,8 @@ func (v *Vehicle) Protocol() *protocol.Vehicle {
 
 func FindDriversByVehicle(category, level string) []string {
 	var driver_list []string
-	query := GetDB().Model(&Vehicle{}).Select([]string{"driver_id"}).Where("driver_id is not null and driver_id !=''")
+	query := GetDB().Model(&Vehicle{}).Select([]string{"driver_id"}).Where("driver_id is not null and driver_id !=''").
+		Where("status = ?", protocol.StatusActive)
 	if category != "" {
 		query = query.Where("category=?", category)
 	}
@@ -1,9 +1,13 @@
 package models
 
 import (
+	"errors"
 	"fmt"
 	"greenride/internal/protocol"
 	"greenride/internal/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Wallet 钱包表 - 基于最新设计文档
@@ -372,3 +376,119 @@ func (w *WalletValues) GetTotalWithdrawn() float64 {
 func (w *WalletValues) HasSufficientBalance(amount float64) bool {
 	return w.GetAvailableBalance() >= amount
 }
+
+// ErrInsufficientBalance 钱包不存在、不可用或可用余额不足
+var ErrInsufficientBalance = errors.New("insufficient wallet balance")
+
+// CreditWalletBonus 奖励金额入账用户钱包，钱包不存在时自动创建；余额、累计收入和流水在同一事务内写入
+func CreditWalletBonus(userID, userType, currency string, amount float64, relatedType, relatedID, title string) (*WalletTransaction, error) {
+	return creditWallet(userID, userType, currency, amount, func(walletID string) *WalletTransaction {
+		return NewBonusTransaction(walletID, userID, relatedType, relatedID, title, amount)
+	})
+}
+
+// CreditWalletIncome 收入入账用户钱包（如取消费中的司机分成），钱包不存在时自动创建
+func CreditWalletIncome(userID, userType, currency string, amount float64, category, relatedType, relatedID, title string) (*WalletTransaction, error) {
+	return creditWallet(userID, userType, currency, amount, func(walletID string) *WalletTransaction {
+		transaction := NewWalletTransactionV2()
+		transaction.SetAccountID(walletID).
+			SetUserID(userID).
+			SetType(TransactionTypeIncome).
+			SetCategory(category).
+			SetAmount(amount).
+			SetTitle(title).
+			SetRelated(relatedType, relatedID)
+		return transaction
+	})
+}
+
+func creditWallet(userID, userType, currency string, amount float64, newTransaction func(walletID string) *WalletTransaction) (*WalletTransaction, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("amount must be positive")
+	}
+	var transaction *WalletTransaction
+	err := GetDB().Transaction(func(db *gorm.DB) error {
+		var err error
+		transaction, err = creditWalletTx(db, userID, userType, currency, amount, newTransaction)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+// creditWalletTx 在调用方事务内入账钱包并写入流水，便于和业务状态一起提交
+func creditWalletTx(db *gorm.DB, userID, userType, currency string, amount float64, newTransaction func(walletID string) *WalletTransaction) (*WalletTransaction, error) {
+	var wallet Wallet
+	err := db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", userID).First(&wallet).Error
+	if err == gorm.ErrRecordNotFound {
+		wallet = *NewWalletV2()
+		wallet.SetUserID(userID)
+		wallet.UserType = &userType
+		wallet.Currency = &currency
+		if err := db.Create(&wallet).Error; err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := wallet.AddEarnings(amount); err != nil {
+		return nil, err
+	}
+	if err := db.Model(&Wallet{}).Where("wallet_id = ?", wallet.WalletID).UpdateColumns(wallet.WalletValues).Error; err != nil {
+		return nil, err
+	}
+
+	transaction := newTransaction(wallet.WalletID)
+	transaction.UserType = &userType
+	transaction.Currency = &currency
+	if err := db.Create(transaction).Error; err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+// DebitWallet 从用户钱包扣款并记录支出流水，余额不足时返回ErrInsufficientBalance
+func DebitWallet(userID string, amount float64, category, relatedType, relatedID, title string) (*WalletTransaction, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("amount must be positive")
+	}
+	var transaction *WalletTransaction
+	err := GetDB().Transaction(func(db *gorm.DB) error {
+		var wallet Wallet
+		err := db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", userID).First(&wallet).Error
+		if err == gorm.ErrRecordNotFound {
+			return ErrInsufficientBalance
+		} else if err != nil {
+			return err
+		}
+		if !wallet.CanTransact() || !wallet.HasSufficientBalance(amount) {
+			return ErrInsufficientBalance
+		}
+
+		if err := wallet.SubtractBalance(amount); err != nil {
+			return err
+		}
+		if err := db.Model(&Wallet{}).Where("wallet_id = ?", wallet.WalletID).UpdateColumns(wallet.WalletValues).Error; err != nil {
+			return err
+		}
+
+		transaction = NewWalletTransactionV2()
+		transaction.SetAccountID(wallet.WalletID).
+			SetUserID(userID).
+			SetType(TransactionTypeExpense).
+			SetCategory(category).
+			SetAmount(amount).
+			SetTitle(title).
+			SetRelated(relatedType, relatedID)
+		transaction.UserType = wallet.UserType
+		transaction.Currency = wallet.Currency
+		return db.Create(transaction).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
@@ -92,6 +92,8 @@ const (
 	TransactionCategoryRefund        = "refund"
 	TransactionCategoryTopup         = "topup"
 	TransactionCategoryTransfer      = "transfer"
+	TransactionCategoryCancelFee     = "cancellation_fee"
+	TransactionCategoryNoShowFee     = "no_show_fee"
 )
 
 // 交易状态常量
@@ -511,6 +513,20 @@ func NewTopupTransaction(accountID, userID, paymentID string, amount float64) *W
 	return tx
 }
 
+func NewBonusTransaction(accountID, userID, relatedType, relatedID, title string, amount float64) *WalletTransaction {
+	tx := NewWalletTransactionV2()
+	tx.SetAccountID(accountID).
+		SetUserID(userID).
+		SetType(TransactionTypeBonus).
+		SetCategory(TransactionCategoryBonus).
+		SetAmount(amount).
+		SetTitle(title).
+		SetDescription(fmt.Sprintf("奖励入账: %.2f RWF", amount)).
+		SetRelated(relatedType, relatedID)
+
+	return tx
+}
+
 func NewWithdrawalTransaction(accountID, userID, withdrawalID string, amount, fee float64) *WalletTransaction {
 	tx := NewWalletTransactionV2()
 	tx.SetAccountID(accountID).
@@ -0,0 +1,98 @@
+package protocol
+
+// 公告推送/邮件分发状态
+const (
+	AnnouncementDeliveryNone      = "none"      // 不需要分发
+	AnnouncementDeliveryPending   = "pending"   // 等待分发（发布后按推送延迟开始）
+	AnnouncementDeliveryRunning   = "running"   // 分发中
+	AnnouncementDeliveryCompleted = "completed" // 分发完成
+)
+
+// AnnouncementTranslation 公告多语言内容，缺省字段回退到公告主语言
+type AnnouncementTranslation struct {
+	Title        string `json:"title,omitempty"`
+	Summary      string `json:"summary,omitempty"`
+	Content      string `json:"content,omitempty"`
+	ActionText   string `json:"action_text,omitempty"`
+	PushTitle    string `json:"push_title,omitempty"`
+	PushContent  string `json:"push_content,omitempty"`
+	EmailSubject string `json:"email_subject,omitempty"`
+}
+
+// Announcement 公告（管理后台）
+type Announcement struct {
+	AnnouncementID     string                              `json:"announcement_id"`
+	Title              string                              `json:"title"`
+	Summary            string                              `json:"summary,omitempty"`
+	Content            string                              `json:"content"`
+	Type               string                              `json:"type"`  // system, promotion, maintenance, emergency, feature, security
+	Level              string                              `json:"level"` // info, warning, critical, urgent
+	Language           string                              `json:"language"`
+	Translations       map[string]*AnnouncementTranslation `json:"translations,omitempty"`
+	TargetAudience     string                              `json:"target_audience"` // all, users, drivers, new_users
+	TargetCities       []string                            `json:"target_cities,omitempty"`
+	TargetServiceAreas []string                            `json:"target_service_areas,omitempty"`
+	ExcludedAreas      []string                            `json:"excluded_areas,omitempty"`
+	TargetUserIDs      []string                            `json:"target_user_ids,omitempty"`
+	ExcludedUserIDs    []string                            `json:"excluded_user_ids,omitempty"`
+	PublishTime        int64                               `json:"publish_time"`
+	StartTime          int64                               `json:"start_time"`
+	EndTime            int64                               `json:"end_time"`
+	IsSticky           bool                                `json:"is_sticky"`
+	IsBanner           bool                                `json:"is_banner"`
+	IsPopup            bool                                `json:"is_popup"`
+	ShowOnce           bool                                `json:"show_once"`
+	RequireConfirm     bool                                `json:"require_confirm"`
+	DisplayPosition    string                              `json:"display_position,omitempty"`
+	Priority           int                                 `json:"priority"`
+	ImageURL           string                              `json:"image_url,omitempty"`
+	BannerURL          string                              `json:"banner_url,omitempty"`
+	ActionType         string                              `json:"action_type,omitempty"`
+	ActionURL          string                              `json:"action_url,omitempty"`
+	ActionText         string                              `json:"action_text,omitempty"`
+	SendPush           bool                                `json:"send_push"`
+	PushTitle          string                              `json:"push_title,omitempty"`
+	PushContent        string                              `json:"push_content,omitempty"`
+	PushDelay          int                                 `json:"push_delay"` // 发布后延迟推送（分钟）
+	SendEmail          bool                                `json:"send_email"`
+	EmailSubject       string                              `json:"email_subject,omitempty"`
+	Status             string                              `json:"status"`          // draft, scheduled, published, paused, expired, deleted
+	ApprovalStatus     string                              `json:"approval_status"` // pending, approved, rejected
+	ApprovedBy         string                              `json:"approved_by,omitempty"`
+	ApprovedAt         int64                               `json:"approved_at,omitempty"`
+	ApprovalNotes      string                              `json:"approval_notes,omitempty"`
+	PublishedAt        int64                               `json:"published_at,omitempty"`
+	DeliveryStatus     string                              `json:"delivery_status"`
+	ViewCount          int                                 `json:"view_count"`
+	ConfirmCount       int                                 `json:"confirm_count"`
+	PushSentCount      int                                 `json:"push_sent_count"`
+	EmailSentCount     int                                 `json:"email_sent_count"`
+	CreatedBy          string                              `json:"created_by"`
+	UpdatedBy          string                              `json:"updated_by,omitempty"`
+	CreatedAt          int64                               `json:"created_at"`
+	UpdatedAt          int64                               `json:"updated_at"`
+}
+
+// UserAnnouncement 用户端公告，内容已按用户语言取值
+type UserAnnouncement struct {
+	AnnouncementID  string `json:"announcement_id"`
+	Title           string `json:"title"`
+	Summary         string `json:"summary,omitempty"`
+	Content         string `json:"content"`
+	Type            string `json:"type"`
+	Level           string `json:"level"`
+	IsSticky        bool   `json:"is_sticky"`
+	IsBanner        bool   `json:"is_banner"`
+	IsPopup         bool   `json:"is_popup"`
+	RequireConfirm  bool   `json:"require_confirm"`
+	DisplayPosition string `json:"display_position,omitempty"`
+	ImageURL        string `json:"image_url,omitempty"`
+	BannerURL       string `json:"banner_url,omitempty"`
+	ActionType      string `json:"action_type,omitempty"`
+	ActionURL       string `json:"action_url,omitempty"`
+	ActionText      string `json:"action_text,omitempty"`
+	IsRead          bool   `json:"is_read"`
+	IsConfirmed     bool   `json:"is_confirmed"`
+	PublishedAt     int64  `json:"published_at"`
+	EndTime         int64  `json:"end_time,omitempty"`
+}
@@ -8,3 +8,9 @@ const (
 	LocalAdCategoryRestaurant = "restaurant" // 餐厅
 	LocalAdCategoryOther      = "other"      // 其他
 )
+
+// 广告展示位置
+const (
+	LocalAdPlacementHome = "home" // 首页/叫车页，按当前位置推荐
+	LocalAdPlacementTrip = "trip" // 行程中页面，按目的地推荐
+)
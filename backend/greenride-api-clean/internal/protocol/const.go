@@ -60,6 +60,7 @@ const (
 	StatusDraft      = "draft"
 	StatusTesting    = "testing"
 	StatusPaused     = "paused"
+	StatusRunning    = "running"
 	StatusExpired    = "expired"
 	StatusDeprecated = "deprecated"
 	StatusArchived   = "archived"
@@ -87,9 +88,12 @@ const (
 
 // 订单类型常量
 const (
-	RideOrder     = "ride"
-	DeliveryOrder = "delivery"
-	ShoppingOrder = "shopping"
+	RideOrder                = "ride"
+	DeliveryOrder            = "delivery"
+	ShoppingOrder            = "shopping"
+	TipOrder                 = "tip"                   // 行程小费，仅用于支付记录的订单类型
+	PaymentMethodVerifyOrder = "payment_method_verify" // 支付方式小额验证，仅用于支付记录的订单类型
+	DebtOrder                = "user_debt"             // 乘客取消费/爽约费欠款，仅用于支付记录的订单类型
 )
 
 // 服务类型常量
@@ -13,6 +13,14 @@ const (
 	MsgTypeGeneric         = "generic"
 	MsgTypeVerifyCode      = "verify_code"
 	MsgTypeRegisterSuccess = "register_success"
+	MsgTypeAnnouncement    = "announcement"
+
+	// 包裹收件人通知类型
+	MsgTypeRecipientDeliveryCode = "recipient_delivery_code"
+
+	// 行程安全通知类型（短信）
+	MsgTypeSafetySOSAlert = "safety_sos_alert" // 紧急求助通知响应人员
+	MsgTypeTripShared     = "trip_shared"      // 行程分享链接发送给紧急联系人
 
 	// 乘客通知类型
 	MsgTypePassengerOrderAccepted    = "passenger_order_accepted"
@@ -21,12 +29,18 @@ const (
 	MsgTypePassengerTripEnded        = "passenger_trip_ended"
 	MsgTypePassengerPaymentConfirmed = "passenger_payment_confirmed"
 	MsgTypePassengerOrderCancelled   = "passenger_order_cancelled"
+	MsgTypePassengerCouponIssued     = "passenger_coupon_issued"
+	MsgTypePassengerPaymentFailed    = "passenger_payment_failed"
+	MsgTypePassengerPaymentOverdue   = "passenger_payment_overdue"
 
 	// 司机通知类型
 	MsgTypeDriverNewOrder         = "driver_new_order"
 	MsgTypeDriverTripEnded        = "driver_trip_ended"
 	MsgTypeDriverPaymentConfirmed = "driver_payment_confirmed"
 	MsgTypeDriverOrderCancelled   = "driver_order_cancelled"
+	MsgTypeDriverDocumentExpiring = "driver_document_expiring"
+	MsgTypeDriverDocumentExpired  = "driver_document_expired"
+	MsgTypeDriverTipReceived      = "driver_tip_received"
 )
 
 // 语言常量
@@ -10,4 +10,28 @@ const (
 	NotificationTypePaymentConfirmed  = "payment_confirmed"   // 支付确认
 	NotificationTypeOrderCancelled    = "order_cancelled"     // 订单已取消
 	NotificationTypeNewOrderAvailable = "new_order_available" // 新订单可用
+	NotificationTypeTipReceived       = "tip_received"        // 收到乘客小费
+	NotificationTypePaymentFailed     = "payment_failed"      // 自动扣款失败，稍后重试
+	NotificationTypePaymentOverdue    = "payment_overdue"     // 自动扣款已停止，需乘客手动结清
+
+	// 优惠券相关通知类型
+	NotificationTypeCouponIssued = "coupon_issued" // 获得新优惠券
+
+	// 司机证件相关通知类型
+	NotificationTypeDocumentExpiring = "document_expiring" // 证件即将过期
+	NotificationTypeDocumentExpired  = "document_expired"  // 证件已过期
+
+	// 系统公告通知类型
+	NotificationTypeAnnouncement = "announcement" // 系统公告
+
+	// 行程安全通知类型（管理员通知）
+	NotificationTypeSafetySOS = "safety_sos" // 乘客或司机发起紧急求助
+)
+
+// 用户通知分类，用户可按分类关闭推送（站内通知始终保留）
+const (
+	NotificationCategoryTrip      = "trip"      // 行程：接单、到达、开始、结束、取消
+	NotificationCategoryPayment   = "payment"   // 支付
+	NotificationCategoryMarketing = "marketing" // 营销：优惠券、活动
+	NotificationCategorySystem    = "system"    // 系统：证件到期等，不可关闭
 )
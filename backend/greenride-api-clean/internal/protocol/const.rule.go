@@ -11,6 +11,9 @@ const (
 	PriceRuleCategoryDistanceFare  = "distance_fare"
 	PriceRuleCategoryTimeFare      = "time_fare"
 	PriceRuleCategoryServiceFee    = "service_fee"
+	PriceRuleCategoryWaitingFare   = "waiting_fare"  // 上车点等候费，仅在行程中按实际等候时长计算，不参与预估
+	PriceRuleCategoryPoolDiscount  = "pool_discount" // 拼车折扣，仅对拼车行程生效，按其余费用合计计算
+	PriceRuleCategoryParcelFare    = "parcel_fare"   // 包裹费，仅对配送订单生效，按包裹尺寸和超重部分计算
 )
 
 // 价格规则类型常量
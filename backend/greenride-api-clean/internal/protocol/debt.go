@@ -0,0 +1,84 @@
+package protocol
+
+// 乘客欠款类型
+const (
+	DebtTypeCancellationFee = "cancellation_fee" // 司机接单后乘客取消产生的取消费
+	DebtTypeNoShowFee       = "no_show_fee"      // 乘客未到上车点产生的爽约费
+	DebtTypeRideFare        = "ride_fare"        // 行程结束后未能收取的车费，按退避间隔自动重试扣款
+)
+
+// 乘客欠款状态
+const (
+	DebtStatusOutstanding = "outstanding" // 待支付
+	DebtStatusPaid        = "paid"        // 已支付
+	DebtStatusWaived      = "waived"      // 已减免
+)
+
+// UserDebt 乘客欠款
+type UserDebt struct {
+	DebtID        string  `json:"debt_id"`
+	UserID        string  `json:"user_id"`
+	OrderID       string  `json:"order_id"`
+	DriverID      string  `json:"driver_id,omitempty"`
+	Type          string  `json:"type"` // cancellation_fee, no_show_fee, ride_fare
+	Amount        float64 `json:"amount"`
+	Currency      string  `json:"currency"`
+	DriverShare   float64 `json:"driver_share"`
+	PlatformShare float64 `json:"platform_share"`
+	PaymentMethod string  `json:"payment_method,omitempty"`
+	Status        string  `json:"status"` // outstanding, paid, waived
+	TransactionID string  `json:"transaction_id,omitempty"`
+	PaidAt        int64   `json:"paid_at,omitempty"`
+	Attempts      int     `json:"attempts,omitempty"`       // 已自动扣款次数（仅车费欠款）
+	NextChargeAt  int64   `json:"next_charge_at,omitempty"` // 下次自动扣款时间，0表示不再自动重试
+	LastError     string  `json:"last_error,omitempty"`     // 最近一次扣款失败原因
+	CreatedAt     int64   `json:"created_at"`
+	UpdatedAt     int64   `json:"updated_at"`
+}
+
+// OutstandingBalance 乘客未结清的欠款，超过上限时不能下单
+type OutstandingBalance struct {
+	Amount    float64     `json:"amount"`    // 欠款合计
+	Currency  string      `json:"currency"`  // 合计及上限的币种
+	Threshold float64     `json:"threshold"` // 欠款上限
+	Blocked   bool        `json:"blocked"`   // 是否已限制下单
+	Debts     []*UserDebt `json:"debts"`
+}
+
+// PayDebtRequest 乘客结清欠款：车费欠款通过订单支付渠道支付，取消费和爽约费从钱包扣款
+type PayDebtRequest struct {
+	Language        string `json:"-"`
+	UserID          string `json:"user_id"` // 内部设置
+	DebtID          string `json:"debt_id" binding:"required"`
+	PaymentMethod   string `json:"payment_method,omitempty"`    // 车费欠款的支付方式，为空时使用默认支付方式
+	PaymentMethodID string `json:"payment_method_id,omitempty"` // 已保存的支付方式ID
+	Phone           string `json:"phone,omitempty"`
+	Email           string `json:"email,omitempty"`
+	AccountName     string `json:"account_name,omitempty"`
+}
+
+// PayDebtResult 结清欠款结果，车费欠款附带订单支付结果（如需跳转的支付链接）
+type PayDebtResult struct {
+	Debt    *UserDebt           `json:"debt"`
+	Payment *OrderPaymentResult `json:"payment,omitempty"`
+}
+
+// ReceivableSummary 应收欠款汇总
+type ReceivableSummary struct {
+	Currency       string  `json:"currency"`
+	Amount         float64 `json:"amount"`          // 未结清金额
+	DebtCount      int64   `json:"debt_count"`      // 未结清笔数
+	RiderCount     int64   `json:"rider_count"`     // 有欠款的乘客数
+	ExhaustedCount int64   `json:"exhausted_count"` // 已停止自动重试的车费欠款笔数
+}
+
+// CancellationQuote 取消前预估的取消费
+type CancellationQuote struct {
+	OrderID        string  `json:"order_id"`
+	FeeType        string  `json:"fee_type,omitempty"` // cancellation_fee, no_show_fee，为空表示可免费取消
+	Fee            float64 `json:"fee"`
+	Currency       string  `json:"currency"`
+	FreeUntil      int64   `json:"free_until,omitempty"` // 免费取消截止时间（毫秒）
+	ElapsedSeconds int64   `json:"elapsed_seconds"`      // 司机接单后经过的秒数
+	TravelledKm    float64 `json:"travelled_km"`         // 司机接单后已行驶的公里数
+}
@@ -0,0 +1,67 @@
+package protocol
+
+// 包裹配送状态，在主订单状态之上细分配送环节
+const (
+	DeliveryStatusPending        = "pending"         // 等待司机到达取件点
+	DeliveryStatusArrivedPickup  = "arrived_pickup"  // 司机已到达取件点
+	DeliveryStatusPickedUp       = "picked_up"       // 已取件，配送中
+	DeliveryStatusArrivedDropoff = "arrived_dropoff" // 司机已到达收件点
+	DeliveryStatusDelivered      = "delivered"       // 已签收
+)
+
+// 包裹尺寸
+const (
+	ParcelSizeSmall  = "small"  // 文件、小件，可放入背包
+	ParcelSizeMedium = "medium" // 鞋盒大小
+	ParcelSizeLarge  = "large"  // 需占用后备箱
+)
+
+// 签收凭证类型
+const (
+	DeliveryProofOTP   = "otp"   // 收件人提供取件码
+	DeliveryProofPhoto = "photo" // 司机拍照留证
+)
+
+// ParcelInfo 包裹规格，预估价格时使用
+type ParcelInfo struct {
+	Size     string  `json:"size"`      // small, medium, large
+	WeightKg float64 `json:"weight_kg"` // 重量（公斤）
+}
+
+// DeliveryContact 下单时填写的寄件人、收件人及包裹信息
+type DeliveryContact struct {
+	SenderName     string   `json:"sender_name"`
+	SenderPhone    string   `json:"sender_phone"`
+	RecipientName  string   `json:"recipient_name" binding:"required"`
+	RecipientPhone string   `json:"recipient_phone" binding:"required"`
+	Description    string   `json:"description,omitempty"`  // 包裹内容描述
+	Photos         []string `json:"photos,omitempty"`       // 包裹照片URL，来自上传接口
+	Instructions   string   `json:"instructions,omitempty"` // 取件/送达说明
+}
+
+// DeliveryInfo 配送订单详情
+type DeliveryInfo struct {
+	DeliveryStatus   string   `json:"delivery_status"`
+	SenderName       string   `json:"sender_name,omitempty"`
+	SenderPhone      string   `json:"sender_phone,omitempty"`
+	RecipientName    string   `json:"recipient_name,omitempty"`
+	RecipientPhone   string   `json:"recipient_phone,omitempty"`
+	ParcelSize       string   `json:"parcel_size"`
+	ParcelWeight     float64  `json:"parcel_weight"`
+	Description      string   `json:"description,omitempty"`
+	Photos           []string `json:"photos,omitempty"`
+	Instructions     string   `json:"instructions,omitempty"`
+	DeliveryCode     string   `json:"delivery_code,omitempty"` // 取件码，仅寄件人可见
+	PickupPhotoURL   string   `json:"pickup_photo_url,omitempty"`
+	ProofType        string   `json:"proof_type,omitempty"`
+	ProofPhotoURL    string   `json:"proof_photo_url,omitempty"`
+	ArrivedPickupAt  int64    `json:"arrived_pickup_at,omitempty"`
+	PickedUpAt       int64    `json:"picked_up_at,omitempty"`
+	ArrivedDropoffAt int64    `json:"arrived_dropoff_at,omitempty"`
+	DeliveredAt      int64    `json:"delivered_at,omitempty"`
+}
+
+// DeliveryPhoto 上传后的包裹照片
+type DeliveryPhoto struct {
+	URL string `json:"url"`
+}
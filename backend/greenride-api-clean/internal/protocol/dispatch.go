@@ -45,6 +45,7 @@ type DispatchDriver struct {
 	ExperienceScore   float64 `json:"experience_score"`
 	FinalScore        float64 `json:"final_score"`
 	RejectReason      string  `json:"reject_reason,omitempty"`
+	PoolMatched       bool    `json:"pool_matched,omitempty"` // 订单可加入该司机进行中的拼车
 }
 
 // DispatchResult 派单响应
@@ -24,6 +24,8 @@ type DriverRuntime struct {
 	Rating             float64            `json:"rating"`              // 实时评分
 	ExperienceLevel    int                `json:"experience_level"`    // 经验级别
 	VehicleID          string             `json:"vehicle_id"`          // 绑定车辆ID
+	PoolID             string             `json:"pool_id,omitempty"`   // 进行中的拼车ID
+	AvailableSeats     int                `json:"available_seats"`     // 拼车剩余可售座位
 	LastHeartbeatAt    int64              `json:"last_heartbeat_at"`   // 最后心跳时间戳
 	NextAvailableAt    int64              `json:"next_available_at"`   // 下次可用时间
 	UpdatedAt          int64              `json:"updated_at"`          // 最后更新时间
@@ -102,6 +102,10 @@ const (
 	EmailServiceError          ErrorCode = "5019" // 邮件服务错误
 	InvalidVerificationMethod  ErrorCode = "5020" // 无效验证方式
 	VerificationCodeSendFailed ErrorCode = "5021" // 验证码发送失败
+	VerificationChallenge      ErrorCode = "5022" // 需要完成人机校验
+	VerificationChallengeFail  ErrorCode = "5023" // 人机校验失败
+	PhoneNumberBlocked         ErrorCode = "5024" // 号码段被禁止发送
+	VerificationBudgetExceeded ErrorCode = "5025" // 验证码发送额度已用完
 )
 
 // Checkout 相关错误码 (5100-5199)
@@ -133,6 +137,11 @@ const (
 	DriverOffline                  ErrorCode = "6017" // 司机离线
 	DriverHasActiveOrder           ErrorCode = "6018" // 司机有未完成的订单，无法接单
 	DriverHasActiveOrderInProgress ErrorCode = "6019" // 司机有在途订单，不能开启新行程
+	DriverDocumentsIncomplete      ErrorCode = "6020" // 司机证件未齐全、未审核或已过期
+	DocumentNotFound               ErrorCode = "6021" // 证件不存在
+	DocumentAlreadyReviewed        ErrorCode = "6022" // 证件已审核
+	InvalidDocumentType            ErrorCode = "6023" // 证件类型无效
+	DocumentUploadFailed           ErrorCode = "6024" // 证件上传失败
 )
 
 // 订单管理相关错误码 (6500-6599)
@@ -276,6 +285,71 @@ const (
 	PromotionCombinationInvalid ErrorCode = "10018" // 优惠码组合无效
 	PromotionSecurityViolation  ErrorCode = "10019" // 优惠码安全违规
 	PromotionInUse              ErrorCode = "10020" // 优惠码正在使用中，无法删除
+	PromotionSelfApproval       ErrorCode = "10021" // 不能审批自己创建的优惠码
+	PromotionAlreadyReviewed    ErrorCode = "10022" // 优惠码已审批
+	PromotionNotApproved        ErrorCode = "10023" // 优惠码未审批通过
+	CampaignNotFound            ErrorCode = "10024" // 发券活动不存在
+	CampaignStatusInvalid       ErrorCode = "10025" // 发券活动当前状态不允许该操作
+	CampaignSegmentInvalid      ErrorCode = "10026" // 发券活动目标人群无效
+	ExperimentNotFound          ErrorCode = "10027" // 优惠实验不存在
+	ExperimentVariantInvalid    ErrorCode = "10028" // 优惠实验变体配置无效
+	ExperimentNotRunning        ErrorCode = "10029" // 优惠实验未在进行中
+	ReferralNotFound            ErrorCode = "10030" // 邀请记录不存在
+	ReferralStatusInvalid       ErrorCode = "10031" // 邀请记录当前状态不允许该操作
+	AnnouncementNotFound        ErrorCode = "10032" // 公告不存在
+	AnnouncementStatusInvalid   ErrorCode = "10033" // 公告当前状态不允许该操作
+	AnnouncementSelfApproval    ErrorCode = "10034" // 不能审批自己创建或修改的公告
+	AdvertisementNotFound       ErrorCode = "10035" // 广告不存在
+	MessageTemplateNotFound     ErrorCode = "10036" // 消息模板不存在
+	MessageTemplateExists       ErrorCode = "10037" // 相同类型、渠道、语言和地区的模板已存在
+	MessageTemplateInvalid      ErrorCode = "10038" // 模板语法错误或变量缺失
+	MessageTemplateStateInvalid ErrorCode = "10039" // 模板当前状态不允许该操作
+	TranslationNotFound         ErrorCode = "10040" // 翻译不存在
+	TranslationInvalid          ErrorCode = "10041" // 翻译内容不合法（占位符与默认语言不一致等）
+	NoShowWaitNotElapsed        ErrorCode = "10042" // 司机在上车点等待时间不足，不能报乘客未到场
+	NoShowNotAtPickup           ErrorCode = "10043" // 司机当前位置不在上车点附近，不能报乘客未到场
+	RideStopsExceeded           ErrorCode = "10044" // 途经点数量超过上限
+	RideStopsMismatch           ErrorCode = "10045" // 下单途经点与预估时不一致
+	RideStopNotFound            ErrorCode = "10046" // 途经点不存在
+	RideStopAlreadyArrived      ErrorCode = "10047" // 途经点已到达，不能修改
+	PoolRideDisabled            ErrorCode = "10048" // 拼车服务未开启
+	PoolStopsNotSupported       ErrorCode = "10049" // 拼车行程不支持途经点
+	PoolSeatsExceeded           ErrorCode = "10050" // 拼车乘客数超过可售座位
+	PoolNotCompatible           ErrorCode = "10051" // 订单与司机当前拼车路线不匹配
+	DeliveryDisabled            ErrorCode = "10052" // 包裹配送未开放
+	InvalidParcel               ErrorCode = "10053" // 包裹尺寸或重量无效
+	ParcelTooHeavy              ErrorCode = "10054" // 包裹超过最大重量
+	DeliveryInfoRequired        ErrorCode = "10055" // 缺少收件人信息
+	TooManyParcelPhotos         ErrorCode = "10056" // 包裹照片数量超过上限
+	InvalidDeliveryStatus       ErrorCode = "10057" // 当前配送状态不允许该操作
+	DeliveryCodeInvalid         ErrorCode = "10058" // 取件码错误
+	DeliveryCodeLocked          ErrorCode = "10059" // 取件码输错次数过多
+	DeliveryProofRequired       ErrorCode = "10060" // 缺少签收凭证
+	DeliveryProofNotAllowed     ErrorCode = "10061" // 签收方式不被允许
+	DeliveryPhotoUploadFailed   ErrorCode = "10062" // 包裹照片上传失败
+	TipDisabled                 ErrorCode = "10063" // 小费功能未开启
+	TipNotAllowed               ErrorCode = "10064" // 订单不可给小费
+	TipWindowClosed             ErrorCode = "10065" // 已超过给小费时限
+	InvalidTipAmount            ErrorCode = "10066" // 小费金额无效
+	TipAlreadyPaid              ErrorCode = "10067" // 小费已支付
+	TipCashNotAvailable         ErrorCode = "10068" // 车费已结清，无法现金给小费
+	SavedPlaceNotFound          ErrorCode = "10069" // 常用地点不存在
+	InvalidSavedPlace           ErrorCode = "10070" // 常用地点信息无效
+	SavedPlaceLimitExceeded     ErrorCode = "10071" // 自定义地点数量已达上限
+	SavedPlaceMismatch          ErrorCode = "10072" // 常用地点与价格预估不一致
+	PaymentMethodNotFound       ErrorCode = "10073" // 已保存的支付方式不存在
+	PaymentMethodLimit          ErrorCode = "10074" // 已保存的支付方式数量超过上限
+	PaymentMethodNotVerified    ErrorCode = "10075" // 支付方式未验证
+	PaymentMethodVerifyFailed   ErrorCode = "10076" // 支付方式验证失败
+	OutstandingBalanceExceeded  ErrorCode = "10077" // 乘客欠款超过上限，结清前不能下单
+	DebtNotFound                ErrorCode = "10078" // 欠款不存在
+	DebtNotPayable              ErrorCode = "10079" // 欠款已结清或已减免
+	SafetyIncidentNotFound      ErrorCode = "10080" // 安全事件不存在
+	TripShareNotFound           ErrorCode = "10081" // 行程分享链接无效或已过期
+	TrustedContactLimit         ErrorCode = "10082" // 紧急联系人数量超过上限
+	TrustedContactNotFound      ErrorCode = "10083" // 紧急联系人不存在
+	SafetyIncidentStatusInvalid ErrorCode = "10084" // 安全事件当前状态不允许该操作
+	DebtPaymentPending          ErrorCode = "10085" // 欠款有渠道扣款正在处理
 )
 
 // GetMessage 获取错误码对应的英文消息
@@ -361,27 +435,36 @@ func (code ErrorCode) GetMessage() string {
 		EmailServiceError:          "Email service error",
 		InvalidVerificationMethod:  "Invalid verification method",
 		VerificationCodeSendFailed: "Failed to send verification code",
+		VerificationChallenge:      "Additional verification is required before sending another code",
+		VerificationChallengeFail:  "Additional verification failed",
+		PhoneNumberBlocked:         "Verification codes cannot be sent to this phone number",
+		VerificationBudgetExceeded: "Verification code sending is temporarily unavailable, please try again later",
 
 		// 行程相关错误码
-		RideNotFound:           "Ride not found",
-		RideAlreadyExists:      "Ride already exists",
-		RideNotAvailable:       "Ride not available",
-		RideAlreadyBooked:      "Ride already booked",
-		RideAlreadyCancelled:   "Ride already cancelled",
-		RideAlreadyCompleted:   "Ride already completed",
-		RideNotStarted:         "Ride not started",
-		RideInProgress:         "Ride in progress",
-		InvalidRideStatus:      "Invalid ride status",
-		InvalidPickupLocation:  "Invalid pickup location",
-		InvalidDropoffLocation: "Invalid dropoff location",
-		InvalidRideDate:        "Invalid ride date",
-		InsufficientSeats:      "Insufficient seats",
-		DriverCannotBook:       "Driver cannot book their own ride",
-		BookingDeadlinePassed:  "Booking deadline has passed",
-		CancellationNotAllowed: "Cancellation not allowed",
-		RideNotBookedByUser:    "User has not booked this ride",
-		DriverOffline:          "Driver is offline",
-		DriverHasActiveOrder:   "Driver has active orders and cannot accept new orders",
+		RideNotFound:              "Ride not found",
+		RideAlreadyExists:         "Ride already exists",
+		RideNotAvailable:          "Ride not available",
+		RideAlreadyBooked:         "Ride already booked",
+		RideAlreadyCancelled:      "Ride already cancelled",
+		RideAlreadyCompleted:      "Ride already completed",
+		RideNotStarted:            "Ride not started",
+		RideInProgress:            "Ride in progress",
+		InvalidRideStatus:         "Invalid ride status",
+		InvalidPickupLocation:     "Invalid pickup location",
+		InvalidDropoffLocation:    "Invalid dropoff location",
+		InvalidRideDate:           "Invalid ride date",
+		InsufficientSeats:         "Insufficient seats",
+		DriverCannotBook:          "Driver cannot book their own ride",
+		BookingDeadlinePassed:     "Booking deadline has passed",
+		CancellationNotAllowed:    "Cancellation not allowed",
+		RideNotBookedByUser:       "User has not booked this ride",
+		DriverOffline:             "Driver is offline",
+		DriverHasActiveOrder:      "Driver has active orders and cannot accept new orders",
+		DriverDocumentsIncomplete: "Required driver documents are missing, pending review or expired",
+		DocumentNotFound:          "Document not found",
+		DocumentAlreadyReviewed:   "Document has already been reviewed",
+		InvalidDocumentType:       "Invalid document type",
+		DocumentUploadFailed:      "Document upload failed",
 
 		// 订单管理相关错误码
 		OrderNotFound:             "Order not found",
@@ -496,6 +579,71 @@ func (code ErrorCode) GetMessage() string {
 		PromotionCombinationInvalid: "Invalid promo code combination",
 		PromotionSecurityViolation:  "Promo code security violation",
 		PromotionInUse:              "Promo code is in use and cannot be deleted",
+		PromotionSelfApproval:       "You cannot approve a promo code you created",
+		PromotionAlreadyReviewed:    "Promo code has already been reviewed",
+		PromotionNotApproved:        "Promo code has not been approved",
+		CampaignNotFound:            "Coupon campaign not found",
+		CampaignStatusInvalid:       "Coupon campaign status does not allow this operation",
+		CampaignSegmentInvalid:      "Invalid coupon campaign target segment",
+		ExperimentNotFound:          "Promotion experiment not found",
+		ExperimentVariantInvalid:    "Invalid promotion experiment variants",
+		ExperimentNotRunning:        "Promotion experiment is not running",
+		ReferralNotFound:            "Referral not found",
+		ReferralStatusInvalid:       "Referral status does not allow this operation",
+		AnnouncementNotFound:        "Announcement not found",
+		AnnouncementStatusInvalid:   "Announcement status does not allow this operation",
+		AnnouncementSelfApproval:    "You cannot approve an announcement you created or edited",
+		AdvertisementNotFound:       "Advertisement not found",
+		MessageTemplateNotFound:     "Message template not found",
+		MessageTemplateExists:       "A template for this type, channel, language and region already exists",
+		MessageTemplateInvalid:      "Message template is invalid",
+		MessageTemplateStateInvalid: "Message template status does not allow this operation",
+		TranslationNotFound:         "Translation not found",
+		TranslationInvalid:          "Translation is invalid",
+		NoShowWaitNotElapsed:        "Please keep waiting at the pickup point before reporting a no-show",
+		NoShowNotAtPickup:           "You are not at the pickup point",
+		RideStopsExceeded:           "Too many stops for this ride",
+		RideStopsMismatch:           "Stops differ from the price estimate, please estimate again",
+		RideStopNotFound:            "Stop not found",
+		RideStopAlreadyArrived:      "The driver has already arrived at this stop",
+		PoolRideDisabled:            "Pool rides are not available right now",
+		PoolStopsNotSupported:       "Pool rides do not support intermediate stops",
+		PoolSeatsExceeded:           "Too many passengers for a pool ride",
+		PoolNotCompatible:           "This ride cannot join your current pool",
+		DeliveryDisabled:            "Parcel delivery is currently unavailable",
+		InvalidParcel:               "Please provide a valid parcel size and weight",
+		ParcelTooHeavy:              "The parcel exceeds the maximum allowed weight",
+		DeliveryInfoRequired:        "Please provide the recipient's name and phone number",
+		TooManyParcelPhotos:         "Too many parcel photos",
+		InvalidDeliveryStatus:       "This delivery step is not allowed at the current stage",
+		DeliveryCodeInvalid:         "The delivery code is incorrect",
+		DeliveryCodeLocked:          "Too many wrong codes, please take a delivery photo instead",
+		DeliveryProofRequired:       "Please enter the recipient's code or upload a delivery photo",
+		DeliveryProofNotAllowed:     "This proof of delivery method is not allowed",
+		DeliveryPhotoUploadFailed:   "Failed to upload the photo, please try again",
+		TipDisabled:                 "Tipping is not available",
+		TipNotAllowed:               "This trip cannot be tipped",
+		TipWindowClosed:             "The tipping window for this trip has closed",
+		InvalidTipAmount:            "Invalid tip amount",
+		TipAlreadyPaid:              "A tip has already been paid for this trip",
+		TipCashNotAvailable:         "Cash tips are only available before the cash fare is paid",
+		SavedPlaceNotFound:          "Saved place not found",
+		InvalidSavedPlace:           "Invalid saved place",
+		SavedPlaceLimitExceeded:     "Saved place limit reached",
+		SavedPlaceMismatch:          "Saved place does not match the price estimate",
+		PaymentMethodNotFound:       "Payment method not found",
+		PaymentMethodLimit:          "Too many saved payment methods",
+		PaymentMethodNotVerified:    "Payment method is not verified",
+		PaymentMethodVerifyFailed:   "Payment method verification failed",
+		OutstandingBalanceExceeded:  "Outstanding balance exceeds the limit",
+		DebtNotFound:                "Debt not found",
+		DebtNotPayable:              "Debt is already settled",
+		SafetyIncidentNotFound:      "Safety incident not found",
+		TripShareNotFound:           "Trip share link is invalid or expired",
+		TrustedContactLimit:         "Too many trusted contacts",
+		TrustedContactNotFound:      "Trusted contact not found",
+		SafetyIncidentStatusInvalid: "Safety incident status does not allow this action",
+		DebtPaymentPending:          "Debt payment is pending",
 	}
 
 	if msg, exists := messages[code]; exists {
@@ -728,6 +876,136 @@ func (code ErrorCode) GetCodeInt() int {
 		return 10019
 	case PromotionInUse:
 		return 10020
+	case PromotionSelfApproval:
+		return 10021
+	case PromotionAlreadyReviewed:
+		return 10022
+	case PromotionNotApproved:
+		return 10023
+	case CampaignNotFound:
+		return 10024
+	case CampaignStatusInvalid:
+		return 10025
+	case CampaignSegmentInvalid:
+		return 10026
+	case ExperimentNotFound:
+		return 10027
+	case ExperimentVariantInvalid:
+		return 10028
+	case ExperimentNotRunning:
+		return 10029
+	case ReferralNotFound:
+		return 10030
+	case ReferralStatusInvalid:
+		return 10031
+	case AnnouncementNotFound:
+		return 10032
+	case AnnouncementStatusInvalid:
+		return 10033
+	case AnnouncementSelfApproval:
+		return 10034
+	case AdvertisementNotFound:
+		return 10035
+	case MessageTemplateNotFound:
+		return 10036
+	case MessageTemplateExists:
+		return 10037
+	case MessageTemplateInvalid:
+		return 10038
+	case MessageTemplateStateInvalid:
+		return 10039
+	case TranslationNotFound:
+		return 10040
+	case TranslationInvalid:
+		return 10041
+	case NoShowWaitNotElapsed:
+		return 10042
+	case NoShowNotAtPickup:
+		return 10043
+	case RideStopsExceeded:
+		return 10044
+	case RideStopsMismatch:
+		return 10045
+	case RideStopNotFound:
+		return 10046
+	case RideStopAlreadyArrived:
+		return 10047
+	case PoolRideDisabled:
+		return 10048
+	case PoolStopsNotSupported:
+		return 10049
+	case PoolSeatsExceeded:
+		return 10050
+	case PoolNotCompatible:
+		return 10051
+	case DeliveryDisabled:
+		return 10052
+	case InvalidParcel:
+		return 10053
+	case ParcelTooHeavy:
+		return 10054
+	case DeliveryInfoRequired:
+		return 10055
+	case TooManyParcelPhotos:
+		return 10056
+	case InvalidDeliveryStatus:
+		return 10057
+	case DeliveryCodeInvalid:
+		return 10058
+	case DeliveryCodeLocked:
+		return 10059
+	case DeliveryProofRequired:
+		return 10060
+	case DeliveryProofNotAllowed:
+		return 10061
+	case DeliveryPhotoUploadFailed:
+		return 10062
+	case TipDisabled:
+		return 10063
+	case TipNotAllowed:
+		return 10064
+	case TipWindowClosed:
+		return 10065
+	case InvalidTipAmount:
+		return 10066
+	case TipAlreadyPaid:
+		return 10067
+	case TipCashNotAvailable:
+		return 10068
+	case SavedPlaceNotFound:
+		return 10069
+	case InvalidSavedPlace:
+		return 10070
+	case SavedPlaceLimitExceeded:
+		return 10071
+	case SavedPlaceMismatch:
+		return 10072
+	case PaymentMethodNotFound:
+		return 10073
+	case PaymentMethodLimit:
+		return 10074
+	case PaymentMethodNotVerified:
+		return 10075
+	case PaymentMethodVerifyFailed:
+		return 10076
+	case OutstandingBalanceExceeded:
+		return 10077
+	case DebtNotFound:
+		return 10078
+	case DebtNotPayable:
+		return 10079
+	case SafetyIncidentNotFound:
+		return 10080
+	case TripShareNotFound:
+		return 10081
+	case TrustedContactLimit:
+		return 10082
+	case TrustedContactNotFound:
+		return 10083
+	case SafetyIncidentStatusInvalid:
+		return 10084
+	case DebtPaymentPending:
+		return 10085
 	default:
 		return 9999 // 未知错误
 	}
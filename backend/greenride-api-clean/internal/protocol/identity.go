@@ -0,0 +1,86 @@
+package protocol
+
+// DriverDocument 司机证件信息
+type DriverDocument struct {
+	IdentityID     string `json:"identity_id"`
+	UserID         string `json:"user_id"`
+	DocumentType   string `json:"document_type"`   // driver_license, national_id, insurance, vehicle_registration
+	DocumentNumber string `json:"document_number"` // 证件号码
+	Status         string `json:"status"`          // pending, approved, rejected, expired, replaced
+	FrontImageURL  string `json:"front_image_url"`
+	BackImageURL   string `json:"back_image_url,omitempty"`
+	SelfieURL      string `json:"selfie_url,omitempty"`
+	ExpiresAt      int64  `json:"expires_at"` // 证件过期时间(毫秒)，0表示未设置
+	IsExpired      bool   `json:"is_expired"`
+	RejectReason   string `json:"reject_reason,omitempty"`
+	ReviewComment  string `json:"review_comment,omitempty"`
+	ReviewedBy     string `json:"reviewed_by,omitempty"`
+	ReviewedAt     int64  `json:"reviewed_at,omitempty"`
+	CreatedAt      int64  `json:"created_at"`
+	UpdatedAt      int64  `json:"updated_at"`
+
+	Driver *User `json:"driver,omitempty"` // 管理端审核队列返回司机信息
+}
+
+// DriverDocumentStatus 司机证件审核汇总
+type DriverDocumentStatus struct {
+	Compliant bool              `json:"compliant"` // 所有必需证件均已审核通过且未过期
+	Required  []string          `json:"required"`
+	Missing   []string          `json:"missing"`  // 未上传
+	Pending   []string          `json:"pending"`  // 待审核
+	Rejected  []string          `json:"rejected"` // 被拒绝
+	Expired   []string          `json:"expired"`  // 已过期
+	Documents []*DriverDocument `json:"documents"`
+}
+
+// DocumentSearchRequest 管理端证件审核队列查询
+type DocumentSearchRequest struct {
+	Page         int    `json:"page,omitempty"`
+	Limit        int    `json:"limit,omitempty"`
+	Status       string `json:"status,omitempty"`        // 默认pending
+	DocumentType string `json:"document_type,omitempty"` // 证件类型
+	UserID       string `json:"user_id,omitempty"`       // 司机ID
+}
+
+// DocumentDetailRequest 证件详情请求
+type DocumentDetailRequest struct {
+	IdentityID string `json:"identity_id" binding:"required"`
+}
+
+// DocumentApproveRequest 审核通过请求
+type DocumentApproveRequest struct {
+	IdentityID string `json:"identity_id" binding:"required"`
+	ExpiresAt  int64  `json:"expires_at"` // 审核时确认的证件过期时间(毫秒)，可选
+	Comment    string `json:"comment"`
+}
+
+// DocumentRejectRequest 审核拒绝请求
+type DocumentRejectRequest struct {
+	IdentityID string `json:"identity_id" binding:"required"`
+	Reason     string `json:"reason" binding:"required"` // 拒绝原因，会展示给司机
+	Comment    string `json:"comment"`                   // 内部备注
+}
+
+// 到期证件来源
+const (
+	ExpiringSourceIdentity = "identity" // 司机证件
+	ExpiringSourceVehicle  = "vehicle"  // 车辆证件（保险、行驶证、车检）
+)
+
+// ExpiringDocument 即将到期或已过期的证件
+type ExpiringDocument struct {
+	Source       string `json:"source"`      // identity, vehicle
+	DocumentID   string `json:"document_id"` // identity_id 或 vehicle_id
+	DocumentType string `json:"document_type"`
+	DriverID     string `json:"driver_id"`
+	DriverName   string `json:"driver_name,omitempty"`
+	VehicleID    string `json:"vehicle_id,omitempty"`
+	PlateNumber  string `json:"plate_number,omitempty"`
+	ExpiresAt    int64  `json:"expires_at"`
+	DaysLeft     int    `json:"days_left"` // 负数表示已过期天数
+}
+
+// ExpiringDocumentsRequest 管理端即将到期证件查询
+type ExpiringDocumentsRequest struct {
+	Days int `json:"days" form:"days"` // 查询未来多少天内到期，默认30
+}
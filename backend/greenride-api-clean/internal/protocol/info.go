@@ -25,6 +25,7 @@ type Rating struct {
 	Tags        []string `json:"tags,omitempty"`
 	Reply       string   `json:"reply,omitempty"`
 	IsAnonymous bool     `json:"is_anonymous"`
+	TipAmount   float64  `json:"tip_amount,omitempty"` // 乘客对该行程给司机的小费
 	RepliedAt   *int64   `json:"replied_at,omitempty"`
 	CreatedAt   int64    `json:"created_at"`
 	UpdatedAt   int64    `json:"updated_at"`
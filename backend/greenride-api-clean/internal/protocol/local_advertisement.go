@@ -2,9 +2,17 @@ package protocol
 
 // LocalAdvertisementListRequest 本地广告列表请求
 type LocalAdvertisementListRequest struct {
-	City     string `json:"city,omitempty" form:"city"`         // 城市过滤
-	Region   string `json:"region,omitempty" form:"region"`     // 地区过滤
-	Category string `json:"category,omitempty" form:"category"` // 类别过滤
+	UserID           string  `json:"user_id,omitempty"`                  // 用户ID（后端自动填充，用于频次控制）
+	City             string  `json:"city,omitempty" form:"city"`         // 城市过滤
+	Region           string  `json:"region,omitempty" form:"region"`     // 地区过滤
+	Category         string  `json:"category,omitempty" form:"category"` // 类别过滤
+	Latitude         float64 `json:"latitude,omitempty"`                 // 当前位置/上车点纬度
+	Longitude        float64 `json:"longitude,omitempty"`                // 当前位置/上车点经度
+	DropoffLatitude  float64 `json:"dropoff_latitude,omitempty"`         // 目的地纬度（placement=trip 时使用）
+	DropoffLongitude float64 `json:"dropoff_longitude,omitempty"`        // 目的地经度（placement=trip 时使用）
+	Placement        string  `json:"placement,omitempty"`                // home（默认）, trip（行程中页面，按目的地推荐）
+	OrderID          string  `json:"order_id,omitempty"`                 // 行程中页面传当前订单ID，以订单目的地为准
+	Limit            int     `json:"limit,omitempty"`                    // 返回数量，默认及上限见 ads.max_results
 }
 
 // LocalAdvertisementDetailRequest 本地广告详情请求
@@ -35,6 +43,8 @@ type LocalAdvertisement struct {
 	PriceLevel        int     `json:"price_level,omitempty"`
 	Priority          int     `json:"priority"`
 	DisplayOrder      int     `json:"display_order"`
+	DistanceKm        float64 `json:"distance_km,omitempty"` // 与上车点/目的地的距离
+	OpenNow           *bool   `json:"open_now,omitempty"`    // 当前是否营业，营业时间未知时不返回
 }
 
 // LocalAdvertisementStatsRequest 广告统计请求
@@ -44,3 +54,74 @@ type LocalAdvertisementStatsRequest struct {
 	UserID    string `json:"user_id,omitempty"`
 	SessionID string `json:"session_id,omitempty"`
 }
+
+// AdminLocalAdvertisement 本地广告（管理后台），包含排期、状态和统计
+type AdminLocalAdvertisement struct {
+	LocalAdvertisement
+	Status        string   `json:"status"`
+	Country       string   `json:"country,omitempty"`
+	Email         string   `json:"email,omitempty"`
+	GooglePlaceID string   `json:"google_place_id,omitempty"`
+	StartAt       int64    `json:"start_at,omitempty"`
+	EndAt         int64    `json:"end_at,omitempty"`
+	IsLive        bool     `json:"is_live"` // 当前是否在用户端展示
+	TargetCities  []string `json:"target_cities,omitempty"`
+	Tags          []string `json:"tags,omitempty"`
+	Notes         string   `json:"notes,omitempty"`
+	ViewCount     int      `json:"view_count"`
+	ClickCount    int      `json:"click_count"`
+	CallCount     int      `json:"call_count"`
+	CreatedBy     string   `json:"created_by,omitempty"`
+	UpdatedBy     string   `json:"updated_by,omitempty"`
+	CreatedAt     int64    `json:"created_at"`
+	UpdatedAt     int64    `json:"updated_at"`
+}
+
+// 广告导入结果
+const (
+	LocalAdImportCreated = "created" // 已创建
+	LocalAdImportSkipped = "skipped" // 该地点已有广告
+	LocalAdImportFailed  = "failed"  // 未找到地点或保存失败
+)
+
+// LocalAdvertisementImportItem 单个地点的导入结果
+type LocalAdvertisementImportItem struct {
+	Name          string `json:"name"`
+	Result        string `json:"result"` // created, skipped, failed
+	AdID          string `json:"ad_id,omitempty"`
+	GooglePlaceID string `json:"google_place_id,omitempty"`
+	ImageRehosted bool   `json:"image_rehosted"`
+	Error         string `json:"error,omitempty"`
+}
+
+// LocalAdvertisementImportResult 批量导入结果
+type LocalAdvertisementImportResult struct {
+	Created int                             `json:"created"`
+	Skipped int                             `json:"skipped"`
+	Failed  int                             `json:"failed"`
+	Items   []*LocalAdvertisementImportItem `json:"items"`
+}
+
+// LocalAdvertisementReport 单个广告的展示/点击统计（来自 /ads/stats 上报）
+type LocalAdvertisementReport struct {
+	AdID       string  `json:"ad_id"`
+	Name       string  `json:"name"`
+	Category   string  `json:"category"`
+	City       string  `json:"city,omitempty"`
+	Status     string  `json:"status"`
+	IsLive     bool    `json:"is_live"`
+	ViewCount  int     `json:"view_count"`
+	ClickCount int     `json:"click_count"`
+	CallCount  int     `json:"call_count"`
+	ClickRate  float64 `json:"click_rate"` // 点击数/展示数，百分比
+	CallRate   float64 `json:"call_rate"`  // 电话数/展示数，百分比
+}
+
+// LocalAdvertisementReportSummary 报表汇总
+type LocalAdvertisementReportSummary struct {
+	TotalViews  int64   `json:"total_views"`
+	TotalClicks int64   `json:"total_clicks"`
+	TotalCalls  int64   `json:"total_calls"`
+	ClickRate   float64 `json:"click_rate"`
+	CallRate    float64 `json:"call_rate"`
+}
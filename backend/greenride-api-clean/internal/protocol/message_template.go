@@ -0,0 +1,49 @@
+package protocol
+
+// AdminMessageTemplate 消息模板（管理后台），Title/Content 为当前发布版本的内容
+type AdminMessageTemplate struct {
+	TemplateID  string                    `json:"template_id"`
+	Type        string                    `json:"type"`
+	Channel     string                    `json:"channel"`
+	Language    string                    `json:"language,omitempty"`
+	Region      string                    `json:"region,omitempty"`
+	Title       string                    `json:"title,omitempty"`
+	Content     string                    `json:"content,omitempty"`
+	Status      string                    `json:"status"`
+	Description string                    `json:"description,omitempty"`
+	Version     int                       `json:"version"`             // 当前发布的版本号，0表示未发布
+	Draft       *MessageTemplateVersion   `json:"draft,omitempty"`     // 未发布的草稿（详情接口返回）
+	Versions    []*MessageTemplateVersion `json:"versions,omitempty"`  // 历史版本（详情接口返回）
+	Variables   []string                  `json:"variables,omitempty"` // 该消息类型可用的变量（详情接口返回）
+	CreatedBy   string                    `json:"created_by,omitempty"`
+	UpdatedBy   string                    `json:"updated_by,omitempty"`
+	CreatedAt   int64                     `json:"created_at"`
+	UpdatedAt   int64                     `json:"updated_at"`
+}
+
+// MessageTemplateVersion 消息模板版本
+type MessageTemplateVersion struct {
+	VersionID   string `json:"version_id"`
+	TemplateID  string `json:"template_id"`
+	Version     int    `json:"version"`
+	Title       string `json:"title,omitempty"`
+	Content     string `json:"content"`
+	Description string `json:"description,omitempty"`
+	Status      string `json:"status"` // draft, active（当前发布）, archived
+	ChangeNote  string `json:"change_note,omitempty"`
+	CreatedBy   string `json:"created_by,omitempty"`
+	UpdatedBy   string `json:"updated_by,omitempty"`
+	PublishedBy string `json:"published_by,omitempty"`
+	PublishedAt int64  `json:"published_at,omitempty"`
+	CreatedAt   int64  `json:"created_at"`
+	UpdatedAt   int64  `json:"updated_at"`
+}
+
+// MessageTemplatePreview 模板预览结果
+type MessageTemplatePreview struct {
+	Title            string   `json:"title"`
+	Content          string   `json:"content"`
+	Variables        []string `json:"variables"`                   // 模板中引用的变量
+	MissingVariables []string `json:"missing_variables,omitempty"` // 引用了但示例参数中没有的变量
+	Problems         []string `json:"problems,omitempty"`          // 发布校验会拦截的问题（语法错误、未知变量、缺少必需变量）
+}
@@ -19,6 +19,7 @@ type Order struct {
 	PromoDiscount       float64 `json:"promo_discount"`
 	UserPromoDiscount   float64 `json:"user_promo_discount"` // 用户优惠券折扣金额
 	CancellationFee     float64 `json:"cancellation_fee"`
+	TipAmount           float64 `json:"tip_amount"` // 已支付的小费，全额归司机，不计入平台抽成
 	Currency            string  `json:"currency"`
 
 	// 支付信息
@@ -63,6 +64,18 @@ type Order struct {
 	// 订单详情（兼容所有订单类型）
 	Details *OrderDetail `json:"details,omitempty"`
 
+	// 上车点等候费（司机到达后实时计算，行程开始后固定）
+	WaitingFare *WaitingFare `json:"waiting_fare,omitempty"`
+
+	// 途经点（按途经顺序）
+	Stops []*RideStop `json:"stops,omitempty"`
+
+	// 包裹配送详情（order_type=delivery时返回）
+	Delivery *DeliveryInfo `json:"delivery,omitempty"`
+
+	// 小费（乘客发起后返回，现金小费由司机随车费一起收取）
+	Tip *Tip `json:"tip,omitempty"`
+
 	Passenger        *User     `json:"passenger,omitempty"`
 	Driver           *User     `json:"driver,omitempty"`
 	Vehicle          *Vehicle  `json:"vehicle,omitempty"`
@@ -84,6 +97,7 @@ type OrderDetail struct {
 	PassengerPhone    string  `json:"passenger_phone,omitempty"`
 	PassengerCount    int     `json:"passenger_count,omitempty"`
 	RideType          string  `json:"ride_type,omitempty"`
+	PoolID            string  `json:"pool_id,omitempty"`
 	PickupAddress     string  `json:"pickup_address,omitempty"`
 	PickupLatitude    float64 `json:"pickup_latitude"`
 	PickupLongitude   float64 `json:"pickup_longitude"`
@@ -18,6 +18,9 @@ const (
 	ActionOrderStarted    = "order_started"
 	ActionOrderInProgress = "order_in_progress"
 	ActionOrderCompleted  = "order_completed"
+	ActionStopArrived     = "stop_arrived" // 到达途经点
+	ActionStopAdded       = "stop_added"   // 行程中新增途经点
+	ActionStopRemoved     = "stop_removed" // 行程中删除途经点
 
 	// 支付相关
 	ActionPaymentInitiated = "payment_initiated"
@@ -0,0 +1,66 @@
+package protocol
+
+// 常用地点类型：家和公司每个用户各一个，自定义地点可以有多个
+const (
+	SavedPlaceTypeHome   = "home"
+	SavedPlaceTypeWork   = "work"
+	SavedPlaceTypeCustom = "custom"
+)
+
+// SavePlaceRequest 新增或修改常用地点请求，PlaceID为空时新增
+type SavePlaceRequest struct {
+	UserID             string  `json:"user_id"` // 内部设置
+	PlaceID            string  `json:"place_id,omitempty"`
+	Type               string  `json:"type" binding:"required"` // home, work, custom
+	Label              string  `json:"label,omitempty"`         // 自定义地点必填，家和公司为空时使用默认名称
+	Latitude           float64 `json:"latitude" binding:"required"`
+	Longitude          float64 `json:"longitude" binding:"required"`
+	Address            string  `json:"address" binding:"required"`
+	BuildingName       string  `json:"building_name,omitempty"`
+	Floor              string  `json:"floor,omitempty"`
+	Unit               string  `json:"unit,omitempty"`
+	AccessInstructions string  `json:"access_instructions,omitempty"` // 到达指引，下单时作为地标带给司机
+}
+
+// PlaceIDRequest 常用地点ID请求
+type PlaceIDRequest struct {
+	UserID  string `json:"user_id"` // 内部设置
+	PlaceID string `json:"place_id" binding:"required"`
+}
+
+// SavedPlace 常用地点
+type SavedPlace struct {
+	PlaceID            string  `json:"place_id"`
+	Type               string  `json:"type"`
+	Label              string  `json:"label"`
+	Latitude           float64 `json:"latitude"`
+	Longitude          float64 `json:"longitude"`
+	Address            string  `json:"address"`
+	BuildingName       string  `json:"building_name,omitempty"`
+	Floor              string  `json:"floor,omitempty"`
+	Unit               string  `json:"unit,omitempty"`
+	AccessInstructions string  `json:"access_instructions,omitempty"`
+	UsageCount         int     `json:"usage_count"`
+	LastUsedAt         int64   `json:"last_used_at,omitempty"`
+	CreatedAt          int64   `json:"created_at"`
+}
+
+// RecentDestination 最近去过的目的地，来自已完成的行程
+type RecentDestination struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Address   string  `json:"address"`
+	Landmark  string  `json:"landmark,omitempty"`
+	OrderID   string  `json:"order_id"`             // 最近一次去该目的地的订单
+	VisitedAt int64   `json:"visited_at"`           // 最近一次到达时间（毫秒）
+	Visits    int     `json:"visits"`               // 统计范围内的到达次数
+	PlaceID   string  `json:"place_id,omitempty"`   // 与常用地点重合时返回该地点ID
+	PlaceType string  `json:"place_type,omitempty"` // 与常用地点重合时返回地点类型
+}
+
+// SavedPlacesResponse 常用地点列表
+type SavedPlacesResponse struct {
+	Home   *SavedPlace   `json:"home,omitempty"`
+	Work   *SavedPlace   `json:"work,omitempty"`
+	Custom []*SavedPlace `json:"custom"`
+}
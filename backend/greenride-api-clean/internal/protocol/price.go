@@ -39,6 +39,8 @@ type PriceBreakdown struct {
 	DistanceFare      float64 `json:"distance_fare"`       // 里程费
 	TimeFare          float64 `json:"time_fare"`           // 时长费
 	ServiceFee        float64 `json:"service_fee"`         // 服务费
+	WaitingFare       float64 `json:"waiting_fare"`        // 上车点等候费
+	ParcelFare        float64 `json:"parcel_fare"`         // 包裹费（按尺寸和重量）
 	SurgeFare         float64 `json:"surge_fare"`          // 高峰期费用
 	DiscountAmount    float64 `json:"discount_amount"`     // 总折扣金额
 	PromoDiscount     float64 `json:"promo_discount"`      // 优惠码折扣金额
@@ -47,6 +49,20 @@ type PriceBreakdown struct {
 	DiscountedFare    float64 `json:"discounted_fare"`     // 优惠后折扣费用 (应用所有优惠后的价格)
 }
 
+// WaitingFare 上车点等候费 - 司机到达后免费等候若干分钟，超出部分按分钟计费
+type WaitingFare struct {
+	RuleID            string  `json:"rule_id"`
+	FreeMinutes       int     `json:"free_minutes"`       // 免费等候分钟数
+	PerMinuteRate     float64 `json:"per_minute_rate"`    // 超时每分钟费用
+	ArrivedAt         int64   `json:"arrived_at"`         // 司机到达上车点时间
+	FreeUntil         int64   `json:"free_until"`         // 免费等候截止时间
+	WaitedSeconds     int64   `json:"waited_seconds"`     // 已等候秒数
+	ChargeableMinutes int     `json:"chargeable_minutes"` // 计费分钟数
+	Amount            float64 `json:"amount"`
+	Currency          string  `json:"currency"`
+	IsFinal           bool    `json:"is_final"` // 行程已开始，等候费不再变化
+}
+
 // UpdatePriceRuleRequest 更新价格规则请求
 type UpdatePriceRuleRequest struct {
 	UserID          string           `json:"user_id"`                    // 更新者用户ID
@@ -16,9 +16,11 @@ type Promotion struct {
 	MinOrderAmount    float64 `json:"min_order_amount"`
 
 	// 使用限制
-	UsageLimit     int `json:"usage_limit"`
-	UsageCount     int `json:"usage_count"`
-	UserUsageLimit int `json:"user_usage_limit"`
+	UsageLimit     int     `json:"usage_limit"`
+	UsageCount     int     `json:"usage_count"`
+	UserUsageLimit int     `json:"user_usage_limit"`
+	BudgetAmount   float64 `json:"budget_amount"` // 预算上限，0表示不限
+	SpentAmount    float64 `json:"spent_amount"`  // 已核销金额
 
 	// 时间信息
 	StartDate int64 `json:"start_date"`
@@ -264,3 +266,96 @@ type PromotionUsageDetail struct {
 	PaymentAmount   float64 `json:"payment_amount"`
 	UsedAt          int64   `json:"used_at"`
 }
+
+// PromotionCampaign 批量发券活动
+type PromotionCampaign struct {
+	CampaignID  string `json:"campaign_id"`
+	PromotionID string `json:"promotion_id"`
+	Name        string `json:"name"`
+
+	// 目标人群
+	TargetUserType string `json:"target_user_type,omitempty"`
+	TargetCity     string `json:"target_city,omitempty"`
+	LastRideAfter  *int64 `json:"last_ride_after,omitempty"`
+	LastRideBefore *int64 `json:"last_ride_before,omitempty"`
+	MinRides       *int   `json:"min_rides,omitempty"`
+	MaxRides       *int   `json:"max_rides,omitempty"`
+	UserListSize   int    `json:"user_list_size"` // CSV导入名单人数
+
+	ValidDays    int    `json:"valid_days"`
+	SendPush     bool   `json:"send_push"`
+	ExperimentID string `json:"experiment_id,omitempty"`
+
+	// 执行进度
+	Status         string  `json:"status"` // running, paused, completed, cancelled
+	TotalUsers     int     `json:"total_users"`
+	ProcessedUsers int     `json:"processed_users"`
+	IssuedCount    int     `json:"issued_count"`
+	SkippedCount   int     `json:"skipped_count"`
+	FailedCount    int     `json:"failed_count"`
+	Progress       float64 `json:"progress"` // 进度百分比
+	ErrorMessage   string  `json:"error_message,omitempty"`
+	StartedAt      int64   `json:"started_at"`
+	CompletedAt    int64   `json:"completed_at"`
+
+	CreatedBy string `json:"created_by"`
+	CreatedAt int64  `json:"created_at"`
+	UpdatedAt int64  `json:"updated_at"`
+}
+
+// PromotionExperimentVariant 优惠实验变体，覆盖基础优惠码的折扣设置
+type PromotionExperimentVariant struct {
+	VariantID         string   `json:"variant_id" binding:"required,max=64"`
+	Name              string   `json:"name,omitempty"`
+	DiscountType      string   `json:"discount_type" binding:"required,oneof=percentage fixed_amount"`
+	DiscountValue     float64  `json:"discount_value" binding:"required,gt=0"`
+	MaxDiscountAmount *float64 `json:"max_discount_amount,omitempty" binding:"omitempty,min=0"`
+	Weight            int      `json:"weight" binding:"required,min=1"` // 非对照组用户按权重分配
+}
+
+// PromotionExperiment 优惠A/B实验
+type PromotionExperiment struct {
+	ExperimentID         string                        `json:"experiment_id"`
+	PromotionID          string                        `json:"promotion_id"`
+	Name                 string                        `json:"name"`
+	Description          string                        `json:"description,omitempty"`
+	Variants             []*PromotionExperimentVariant `json:"variants"`
+	HoldoutPercent       int                           `json:"holdout_percent"`        // 对照组比例（不发券）
+	ConversionWindowDays int                           `json:"conversion_window_days"` // 转化观察窗口天数
+	Status               string                        `json:"status"`                 // pending(待审批), running, completed, rejected
+	StartedAt            int64                         `json:"started_at"`
+	EndedAt              int64                         `json:"ended_at"`
+	CreatedBy            string                        `json:"created_by"`
+	ApprovedBy           string                        `json:"approved_by,omitempty"` // 审批人（通过或拒绝）
+	ApprovedAt           int64                         `json:"approved_at,omitempty"`
+	ApprovalNotes        string                        `json:"approval_notes,omitempty"`
+	CreatedAt            int64                         `json:"created_at"`
+	UpdatedAt            int64                         `json:"updated_at"`
+}
+
+// PromotionExperimentVariantReport 实验变体效果统计
+type PromotionExperimentVariantReport struct {
+	VariantID      string  `json:"variant_id"` // 对照组为 holdout
+	Name           string  `json:"name"`
+	IsHoldout      bool    `json:"is_holdout"`
+	AssignedUsers  int64   `json:"assigned_users"`  // 分组用户数
+	IssuedCoupons  int64   `json:"issued_coupons"`  // 发放券数
+	RedeemedCount  int64   `json:"redeemed_count"`  // 核销券数
+	RedemptionRate float64 `json:"redemption_rate"` // 核销率 = 核销券数 / 发放券数
+	DiscountCost   float64 `json:"discount_cost"`   // 优惠总成本
+	ConvertedUsers int64   `json:"converted_users"` // 窗口期内完成至少一单的用户数
+	ConversionRate float64 `json:"conversion_rate"` // 转化率 = 转化用户数 / 分组用户数
+	Rides          int64   `json:"rides"`           // 窗口期内完成订单数
+	RidesPerUser   float64 `json:"rides_per_user"`
+	// IncrementalRides 相对对照组的增量订单数 = (人均订单 - 对照组人均订单) * 分组用户数
+	IncrementalRides float64 `json:"incremental_rides"`
+	// CostPerIncrementalRide 每个增量订单的优惠成本，增量不为正时为0
+	CostPerIncrementalRide float64 `json:"cost_per_incremental_ride"`
+}
+
+// PromotionExperimentReport 实验效果报告
+type PromotionExperimentReport struct {
+	Experiment  *PromotionExperiment                `json:"experiment"`
+	Variants    []*PromotionExperimentVariantReport `json:"variants"`
+	GeneratedAt int64                               `json:"generated_at"`
+}
@@ -0,0 +1,69 @@
+package protocol
+
+// 邀请计划类型
+const (
+	ReferralProgramPassenger = "passenger" // 乘客邀请乘客（或司机邀请乘客），奖励优惠券
+	ReferralProgramDriver    = "driver"    // 司机邀请司机，奖励入账邀请人钱包
+)
+
+// 邀请记录状态
+const (
+	ReferralStatusPending  = "pending"  // 等待被邀请人达成条件
+	ReferralStatusReview   = "review"   // 命中风控，待人工审核
+	ReferralStatusRewarded = "rewarded" // 已发放奖励
+	ReferralStatusRejected = "rejected" // 已拒绝（风控或人工）
+	ReferralStatusCapped   = "capped"   // 邀请人奖励次数已达上限
+	ReferralStatusExpired  = "expired"  // 超过期限未达成条件
+)
+
+// 邀请风控信号
+const (
+	ReferralFraudSelfReferral  = "self_referral"  // 邀请人与被邀请人手机号或邮箱相同
+	ReferralFraudSharedDevice  = "shared_device"  // 设备ID相同
+	ReferralFraudSharedFCM     = "shared_fcm"     // FCM推送设备ID相同
+	ReferralFraudPhonePrefix   = "phone_prefix"   // 手机号前缀相同
+	ReferralFraudSharedPayment = "shared_payment" // 支付账号相同
+)
+
+// Referral 邀请记录
+type Referral struct {
+	ReferralID         string   `json:"referral_id"`
+	InviterID          string   `json:"inviter_id"`
+	InviteeID          string   `json:"invitee_id"`
+	ProgramType        string   `json:"program_type"`                   // passenger, driver
+	Status             string   `json:"status"`                         // pending, review, rewarded, rejected, capped, expired
+	RequiredRides      int      `json:"required_rides"`                 // 被邀请人需完成的订单数
+	FraudSignals       []string `json:"fraud_signals,omitempty"`        // 命中的风控信号
+	InviterPromotionID string   `json:"inviter_promotion_id,omitempty"` // 邀请人获得的优惠券
+	InviteePromotionID string   `json:"invitee_promotion_id,omitempty"` // 被邀请人获得的优惠券
+	BonusAmount        float64  `json:"bonus_amount,omitempty"`         // 司机奖励金额
+	BonusTransactionID string   `json:"bonus_transaction_id,omitempty"` // 钱包入账流水
+	ExpireAt           int64    `json:"expire_at,omitempty"`
+	RewardedAt         int64    `json:"rewarded_at,omitempty"`
+	ReviewedBy         string   `json:"reviewed_by,omitempty"`
+	ReviewedAt         int64    `json:"reviewed_at,omitempty"`
+	Remark             string   `json:"remark,omitempty"`
+	CreatedAt          int64    `json:"created_at"`
+	UpdatedAt          int64    `json:"updated_at"`
+}
+
+// ReferralTreeNode 邀请树节点
+type ReferralTreeNode struct {
+	UserID       string              `json:"user_id"`
+	UserType     string              `json:"user_type"`
+	Name         string              `json:"name"`
+	Phone        string              `json:"phone"`
+	InviteCount  int                 `json:"invite_count"`
+	TotalRides   int                 `json:"total_rides"`
+	Referral     *Referral           `json:"referral,omitempty"` // 该用户作为被邀请人的邀请记录
+	Children     []*ReferralTreeNode `json:"children,omitempty"`
+	HasMore      bool                `json:"has_more,omitempty"` // 超过展开层数仍有下级
+	RegisteredAt int64               `json:"registered_at"`
+}
+
+// ReferralTree 邀请树，Ancestors 从直接邀请人向上排列
+type ReferralTree struct {
+	Ancestors []*ReferralTreeNode `json:"ancestors"`
+	Root      *ReferralTreeNode   `json:"root"`
+	Depth     int                 `json:"depth"`
+}
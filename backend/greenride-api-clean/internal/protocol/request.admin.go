@@ -240,6 +240,7 @@ type CreatePromotionRequest struct {
 	MinOrderAmount    *float64 `json:"min_order_amount,omitempty" binding:"omitempty,min=0"`
 	UsageLimit        *int     `json:"usage_limit,omitempty" binding:"omitempty,min=1"`
 	UserUsageLimit    *int     `json:"user_usage_limit,omitempty" binding:"omitempty,min=1"`
+	BudgetAmount      *float64 `json:"budget_amount,omitempty" binding:"omitempty,min=0"` // 优惠总预算，0表示不限
 	StartDate         *int64   `json:"start_date,omitempty"`
 	EndDate           *int64   `json:"end_date,omitempty"`
 	ValidDays         *int     `json:"valid_days,omitempty" binding:"omitempty,min=1"`
@@ -262,6 +263,7 @@ type UpdatePromotionRequest struct {
 	MinOrderAmount    *float64 `json:"min_order_amount,omitempty" binding:"omitempty,min=0"`
 	UsageLimit        *int     `json:"usage_limit,omitempty" binding:"omitempty,min=1"`
 	UserUsageLimit    *int     `json:"user_usage_limit,omitempty" binding:"omitempty,min=1"`
+	BudgetAmount      *float64 `json:"budget_amount,omitempty" binding:"omitempty,min=0"` // 优惠总预算，0表示不限
 	StartDate         *int64   `json:"start_date,omitempty"`
 	EndDate           *int64   `json:"end_date,omitempty"`
 	ValidCities       *string  `json:"valid_cities,omitempty"`
@@ -291,6 +293,337 @@ type PromotionUsageRequest struct {
 	EndDate     *int64 `json:"end_date,omitempty"`              // 结束时间
 }
 
+// CreatePromotionCampaignRequest 批量发券活动创建请求结构体
+// 目标人群条件之间为且关系；UserIDs 非空时只在名单内按条件筛选
+type CreatePromotionCampaignRequest struct {
+	UserID         string   `json:"user_id"` // 创建者ID（后端自动填充）
+	PromotionID    string   `json:"promotion_id" binding:"required"`
+	Name           string   `json:"name" binding:"required,max=255"`
+	Description    string   `json:"description,omitempty"`
+	TargetUserType string   `json:"target_user_type,omitempty" binding:"omitempty,oneof=passenger driver user"`
+	TargetCity     string   `json:"target_city,omitempty"`
+	LastRideAfter  *int64   `json:"last_ride_after,omitempty"`  // 最近一次完成行程晚于该时间 (时间戳毫秒)
+	LastRideBefore *int64   `json:"last_ride_before,omitempty"` // 最近一次完成行程早于该时间 (时间戳毫秒)
+	MinRides       *int     `json:"min_rides,omitempty" binding:"omitempty,min=0"`
+	MaxRides       *int     `json:"max_rides,omitempty" binding:"omitempty,min=0"`
+	UserIDs        []string `json:"user_ids,omitempty"`                                      // 用户ID名单，上传CSV时由后端填充
+	ValidDays      int      `json:"valid_days,omitempty" binding:"omitempty,min=1"`          // 券有效天数，不填跟随优惠码
+	SendPush       bool     `json:"send_push,omitempty"`                                     // 是否推送发券通知
+	ChunkSize      int      `json:"chunk_size,omitempty" binding:"omitempty,min=1,max=5000"` // 每批处理用户数，默认500
+	ExperimentID   string   `json:"experiment_id,omitempty"`                                 // 关联优惠实验，按实验分组发放不同变体
+}
+
+// PromotionCampaignSearchRequest 批量发券活动搜索请求结构体
+type PromotionCampaignSearchRequest struct {
+	PromotionID string `json:"promotion_id,omitempty"`
+	Status      string `json:"status,omitempty"`
+	Page        int    `json:"page,omitempty"`
+	Limit       int    `json:"limit,omitempty"`
+}
+
+// PromotionCampaignActionRequest 批量发券活动操作请求结构体（详情、暂停、继续、取消）
+type PromotionCampaignActionRequest struct {
+	UserID     string `json:"user_id,omitempty"` // 操作者ID（后端自动填充）
+	CampaignID string `json:"campaign_id" binding:"required"`
+}
+
+// CreatePromotionExperimentRequest 优惠实验创建请求结构体
+type CreatePromotionExperimentRequest struct {
+	UserID               string                        `json:"user_id"` // 创建者ID（后端自动填充）
+	PromotionID          string                        `json:"promotion_id" binding:"required"`
+	Name                 string                        `json:"name" binding:"required,max=255"`
+	Description          string                        `json:"description,omitempty"`
+	Variants             []*PromotionExperimentVariant `json:"variants" binding:"required,min=1,max=10,dive"`
+	HoldoutPercent       int                           `json:"holdout_percent" binding:"min=0,max=90"`                            // 对照组比例
+	ConversionWindowDays int                           `json:"conversion_window_days,omitempty" binding:"omitempty,min=1,max=90"` // 默认14天
+}
+
+// PromotionExperimentSearchRequest 优惠实验搜索请求结构体
+type PromotionExperimentSearchRequest struct {
+	PromotionID string `json:"promotion_id,omitempty"`
+	Status      string `json:"status,omitempty"`
+	Page        int    `json:"page,omitempty"`
+	Limit       int    `json:"limit,omitempty"`
+}
+
+// PromotionExperimentActionRequest 优惠实验操作请求结构体（详情、报告、结束）
+type PromotionExperimentActionRequest struct {
+	UserID       string `json:"user_id,omitempty"` // 操作者ID（后端自动填充）
+	ExperimentID string `json:"experiment_id" binding:"required"`
+}
+
+// ReviewPromotionExperimentRequest 实验审批请求，审批人不能是创建人
+type ReviewPromotionExperimentRequest struct {
+	UserID       string `json:"user_id,omitempty"` // 审批人ID（后端自动填充）
+	ExperimentID string `json:"experiment_id" binding:"required"`
+	Notes        string `json:"notes,omitempty"` // 审批备注
+}
+
+// ReferralSearchRequest 邀请记录搜索请求结构体
+type ReferralSearchRequest struct {
+	InviterID   string `json:"inviter_id,omitempty"`
+	InviteeID   string `json:"invitee_id,omitempty"`
+	ProgramType string `json:"program_type,omitempty"`
+	Status      string `json:"status,omitempty"`
+	Page        int    `json:"page,omitempty"`
+	Limit       int    `json:"limit,omitempty"`
+}
+
+// ReferralActionRequest 邀请记录操作请求结构体（详情、审核通过、拒绝）
+type ReferralActionRequest struct {
+	UserID     string `json:"user_id,omitempty"` // 操作者ID（后端自动填充）
+	ReferralID string `json:"referral_id" binding:"required"`
+	Notes      string `json:"notes,omitempty"`
+}
+
+// ReferralTreeRequest 邀请树查询请求结构体
+type ReferralTreeRequest struct {
+	TargetUserID string `json:"target_user_id" binding:"required"`
+	Depth        int    `json:"depth,omitempty"` // 向下展开层数，默认取配置
+}
+
+// CreateAnnouncementRequest 创建公告请求结构体
+type CreateAnnouncementRequest struct {
+	UserID             string                              `json:"user_id,omitempty"` // 操作者ID（后端自动填充）
+	Title              string                              `json:"title" binding:"required"`
+	Summary            string                              `json:"summary,omitempty"`
+	Content            string                              `json:"content" binding:"required"`
+	Type               string                              `json:"type,omitempty"`     // 默认 system
+	Level              string                              `json:"level,omitempty"`    // 默认 info
+	Language           string                              `json:"language,omitempty"` // 主语言，默认 en
+	Translations       map[string]*AnnouncementTranslation `json:"translations,omitempty"`
+	TargetAudience     string                              `json:"target_audience,omitempty"` // all, users, drivers, new_users
+	TargetCities       []string                            `json:"target_cities,omitempty"`
+	TargetServiceAreas []string                            `json:"target_service_areas,omitempty"`
+	ExcludedAreas      []string                            `json:"excluded_areas,omitempty"`
+	TargetUserIDs      []string                            `json:"target_user_ids,omitempty"`
+	ExcludedUserIDs    []string                            `json:"excluded_user_ids,omitempty"`
+	PublishTime        int64                               `json:"publish_time,omitempty"` // 定时发布时间，为空则审批通过后立即发布
+	StartTime          int64                               `json:"start_time,omitempty"`
+	EndTime            int64                               `json:"end_time,omitempty"`
+	IsSticky           bool                                `json:"is_sticky,omitempty"`
+	IsBanner           bool                                `json:"is_banner,omitempty"`
+	IsPopup            bool                                `json:"is_popup,omitempty"`
+	ShowOnce           bool                                `json:"show_once,omitempty"`
+	RequireConfirm     bool                                `json:"require_confirm,omitempty"`
+	DisplayPosition    string                              `json:"display_position,omitempty"`
+	Priority           int                                 `json:"priority,omitempty"`
+	ImageURL           string                              `json:"image_url,omitempty"`
+	BannerURL          string                              `json:"banner_url,omitempty"`
+	ActionType         string                              `json:"action_type,omitempty"`
+	ActionURL          string                              `json:"action_url,omitempty"`
+	ActionText         string                              `json:"action_text,omitempty"`
+	SendPush           bool                                `json:"send_push,omitempty"`
+	PushTitle          string                              `json:"push_title,omitempty"`
+	PushContent        string                              `json:"push_content,omitempty"`
+	PushDelay          int                                 `json:"push_delay,omitempty"` // 发布后延迟推送（分钟）
+	SendEmail          bool                                `json:"send_email,omitempty"`
+	EmailSubject       string                              `json:"email_subject,omitempty"`
+}
+
+// UpdateAnnouncementRequest 更新公告请求结构体，修改后需重新审批
+type UpdateAnnouncementRequest struct {
+	AnnouncementID string `json:"announcement_id" binding:"required"`
+	CreateAnnouncementRequest
+}
+
+// AnnouncementSearchRequest 公告搜索请求结构体
+type AnnouncementSearchRequest struct {
+	Keyword        string `json:"keyword,omitempty"`
+	Type           string `json:"type,omitempty"`
+	Status         string `json:"status,omitempty"`
+	ApprovalStatus string `json:"approval_status,omitempty"`
+	Page           int    `json:"page,omitempty"`
+	Limit          int    `json:"limit,omitempty"`
+}
+
+// AnnouncementActionRequest 公告操作请求结构体（详情、审批、暂停、删除）
+type AnnouncementActionRequest struct {
+	UserID         string `json:"user_id,omitempty"` // 操作者ID（后端自动填充）
+	AnnouncementID string `json:"announcement_id" binding:"required"`
+	Notes          string `json:"notes,omitempty"`
+}
+
+// CreateLocalAdvertisementRequest 创建本地广告请求结构体
+type CreateLocalAdvertisementRequest struct {
+	UserID       string   `json:"user_id,omitempty"` // 操作者ID（后端自动填充）
+	Name         string   `json:"name" binding:"required"`
+	Description  string   `json:"description,omitempty"`
+	Category     string   `json:"category,omitempty"` // cafe, bank, hotel, restaurant, other
+	Address      string   `json:"address,omitempty"`
+	City         string   `json:"city,omitempty"`
+	Region       string   `json:"region,omitempty"`
+	Country      string   `json:"country,omitempty"`
+	Latitude     float64  `json:"latitude,omitempty"`
+	Longitude    float64  `json:"longitude,omitempty"`
+	Phone        string   `json:"phone,omitempty"`
+	Email        string   `json:"email,omitempty"`
+	Website      string   `json:"website,omitempty"`
+	ImageURL     string   `json:"image_url,omitempty"`
+	LogoURL      string   `json:"logo_url,omitempty"`
+	RehostImages bool     `json:"rehost_images,omitempty"` // 将外部图片转存到S3
+	Status       string   `json:"status,omitempty"`        // active, inactive, pending，默认 pending
+	Priority     int      `json:"priority,omitempty"`      // 数字越大越靠前
+	DisplayOrder int      `json:"display_order,omitempty"` // 同优先级内的顺序
+	IsPromoted   bool     `json:"is_promoted,omitempty"`
+	IsFeatured   bool     `json:"is_featured,omitempty"`
+	StartAt      int64    `json:"start_at,omitempty"` // 开始展示时间（毫秒），为空表示立即
+	EndAt        int64    `json:"end_at,omitempty"`   // 结束展示时间（毫秒），为空表示不限
+	TargetCities []string `json:"target_cities,omitempty"`
+	Tags         []string `json:"tags,omitempty"`
+	Notes        string   `json:"notes,omitempty"`
+}
+
+// UpdateLocalAdvertisementRequest 修改本地广告请求结构体（整体替换可编辑字段）
+type UpdateLocalAdvertisementRequest struct {
+	AdID string `json:"ad_id" binding:"required"`
+	CreateLocalAdvertisementRequest
+}
+
+// LocalAdvertisementSearchRequest 本地广告搜索请求结构体
+type LocalAdvertisementSearchRequest struct {
+	Keyword  string `json:"keyword,omitempty"`
+	City     string `json:"city,omitempty"`
+	Category string `json:"category,omitempty"`
+	Status   string `json:"status,omitempty"`
+	Live     bool   `json:"live,omitempty"`    // 只看当前正在展示的广告
+	SortBy   string `json:"sort_by,omitempty"` // priority（默认）, views, clicks, ctr, created_at
+	Page     int    `json:"page,omitempty"`
+	Limit    int    `json:"limit,omitempty"`
+}
+
+// LocalAdvertisementActionRequest 本地广告操作请求结构体（详情、上下线、删除）
+type LocalAdvertisementActionRequest struct {
+	UserID string `json:"user_id,omitempty"` // 操作者ID（后端自动填充）
+	AdID   string `json:"ad_id" binding:"required"`
+	Status string `json:"status,omitempty"` // 上下线时使用：active, inactive
+}
+
+// ImportLocalAdvertisementsRequest 按地点名称从Google Places批量导入广告
+type ImportLocalAdvertisementsRequest struct {
+	UserID       string   `json:"user_id,omitempty"` // 操作者ID（后端自动填充）
+	Names        []string `json:"names" binding:"required,min=1,max=50"`
+	City         string   `json:"city,omitempty"`
+	Country      string   `json:"country,omitempty"`
+	Region       string   `json:"region,omitempty"`
+	Category     string   `json:"category,omitempty"`      // 无法从Google类型推断时使用，默认 other
+	Status       string   `json:"status,omitempty"`        // 导入后的状态，默认 pending（需人工上线）
+	RehostImages bool     `json:"rehost_images,omitempty"` // 转存Google照片到S3，未开启或转存失败时不导入图片
+}
+
+// LocalAdvertisementReportRequest 广告效果报表请求结构体
+type LocalAdvertisementReportRequest struct {
+	AdIDs    []string `json:"ad_ids,omitempty"`
+	City     string   `json:"city,omitempty"`
+	Category string   `json:"category,omitempty"`
+	Status   string   `json:"status,omitempty"`
+	SortBy   string   `json:"sort_by,omitempty"` // views（默认）, clicks, ctr
+	Page     int      `json:"page,omitempty"`
+	Limit    int      `json:"limit,omitempty"`
+}
+
+// CreateMessageTemplateRequest 创建消息模板请求（内容保存为第一个草稿版本）
+type CreateMessageTemplateRequest struct {
+	UserID      string `json:"user_id,omitempty"` // 操作者ID（后端自动填充）
+	Type        string `json:"type" binding:"required"`
+	Channel     string `json:"channel" binding:"required"` // email, sms, fcm
+	Language    string `json:"language,omitempty"`         // 为空表示所有语言通用
+	Region      string `json:"region,omitempty"`
+	Title       string `json:"title,omitempty"` // 邮件主题/推送标题，短信可为空
+	Content     string `json:"content" binding:"required"`
+	Description string `json:"description,omitempty"`
+	ChangeNote  string `json:"change_note,omitempty"`
+}
+
+// SaveMessageTemplateDraftRequest 保存草稿请求，已有草稿时覆盖，否则新建版本
+type SaveMessageTemplateDraftRequest struct {
+	UserID      string `json:"user_id,omitempty"` // 操作者ID（后端自动填充）
+	TemplateID  string `json:"template_id" binding:"required"`
+	Title       string `json:"title,omitempty"`
+	Content     string `json:"content" binding:"required"`
+	Description string `json:"description,omitempty"`
+	ChangeNote  string `json:"change_note,omitempty"`
+}
+
+// MessageTemplateSearchRequest 消息模板查询请求
+type MessageTemplateSearchRequest struct {
+	Type     string `json:"type,omitempty"`
+	Channel  string `json:"channel,omitempty"`
+	Language string `json:"language,omitempty"`
+	Status   string `json:"status,omitempty"`
+	Keyword  string `json:"keyword,omitempty"`
+	Page     int    `json:"page,omitempty"`
+	Limit    int    `json:"limit,omitempty"`
+}
+
+// MessageTemplateActionRequest 消息模板操作请求（详情、发布、启停、删除）
+type MessageTemplateActionRequest struct {
+	UserID     string `json:"user_id,omitempty"` // 操作者ID（后端自动填充）
+	TemplateID string `json:"template_id" binding:"required"`
+	VersionID  string `json:"version_id,omitempty"` // 发布时指定版本，为空则发布当前草稿；指定归档版本即回滚
+	Status     string `json:"status,omitempty"`     // 启停时使用：active, inactive
+}
+
+// MessageTemplatePreviewRequest 模板预览请求，可预览已保存的版本，也可直接预览未保存的内容
+type MessageTemplatePreviewRequest struct {
+	TemplateID string         `json:"template_id,omitempty"`
+	VersionID  string         `json:"version_id,omitempty"` // 为空时预览草稿，没有草稿则预览当前发布版本
+	Type       string         `json:"type,omitempty"`       // 直接预览内容时必填
+	Title      string         `json:"title,omitempty"`
+	Content    string         `json:"content,omitempty"`
+	Params     map[string]any `json:"params,omitempty"` // 示例参数，未提供的变量使用占位值
+}
+
+// TranslationSearchRequest 翻译查询请求
+type TranslationSearchRequest struct {
+	Namespace string `json:"namespace" binding:"required"` // common, errors
+	Language  string `json:"language" binding:"required"`
+	Keyword   string `json:"keyword,omitempty"` // 匹配key或内容
+	Source    string `json:"source,omitempty"`  // file, database
+	Missing   bool   `json:"missing,omitempty"` // 只看该语言缺少的key
+	Page      int    `json:"page,omitempty"`
+	Limit     int    `json:"limit,omitempty"`
+}
+
+// SaveTranslationRequest 保存翻译请求
+type SaveTranslationRequest struct {
+	UserID    string `json:"user_id,omitempty"` // 操作者ID（后端自动填充）
+	Namespace string `json:"namespace" binding:"required"`
+	Language  string `json:"language" binding:"required"`
+	Key       string `json:"key" binding:"required"`
+	Value     string `json:"value" binding:"required"`
+}
+
+// DeleteTranslationRequest 删除翻译请求，删除后回退到 locales 文件中的内容
+type DeleteTranslationRequest struct {
+	UserID    string `json:"user_id,omitempty"` // 操作者ID（后端自动填充）
+	Namespace string `json:"namespace" binding:"required"`
+	Language  string `json:"language" binding:"required"`
+	Key       string `json:"key" binding:"required"`
+}
+
+// ImportTranslationsRequest 导入翻译请求，entries 与 locales 文件格式相同
+type ImportTranslationsRequest struct {
+	UserID    string            `json:"user_id,omitempty"` // 操作者ID（后端自动填充）
+	Namespace string            `json:"namespace" binding:"required"`
+	Language  string            `json:"language" binding:"required"`
+	Entries   map[string]string `json:"entries" binding:"required"`
+	Overwrite bool              `json:"overwrite,omitempty"` // 是否覆盖已有内容不同的key
+}
+
+// ExportTranslationsRequest 导出翻译请求
+type ExportTranslationsRequest struct {
+	Namespace string `json:"namespace" binding:"required"`
+	Language  string `json:"language" binding:"required"`
+	Download  bool   `json:"download,omitempty"` // 为true时直接返回JSON文件
+}
+
+// TranslationReportRequest 翻译缺失报表请求
+type TranslationReportRequest struct {
+	Namespace string   `json:"namespace,omitempty"` // 为空表示全部
+	Languages []string `json:"languages,omitempty"` // 为空表示所有已有翻译的语言
+}
+
 // AdminUpdateRequest 管理员更新请求结构体
 type AdminUpdateRequest struct {
 	ID         string  `json:"id" binding:"required"` // 管理员ID
@@ -377,3 +710,38 @@ type AdminNotificationSearchRequest struct {
 type NotificationIDRequest struct {
 	NotificationID string `json:"notification_id" binding:"required"` // 通知ID
 }
+
+// ReceivableSearchRequest 应收欠款查询请求
+type ReceivableSearchRequest struct {
+	UserID    string `json:"user_id,omitempty"` // 乘客ID
+	OrderID   string `json:"order_id,omitempty"`
+	Type      string `json:"type,omitempty"`      // cancellation_fee, no_show_fee, ride_fare
+	Status    string `json:"status,omitempty"`    // 默认outstanding
+	Exhausted bool   `json:"exhausted,omitempty"` // 只看已停止自动重试的车费欠款
+	Page      int    `json:"page,omitempty"`
+	Limit     int    `json:"limit,omitempty"`
+}
+
+// DebtActionRequest 欠款操作请求（减免）
+type DebtActionRequest struct {
+	UserID string `json:"user_id,omitempty"` // 操作者ID（后端自动填充）
+	DebtID string `json:"debt_id" binding:"required"`
+	Notes  string `json:"notes,omitempty"`
+}
+
+// SafetyIncidentSearchRequest 安全事件查询请求
+type SafetyIncidentSearchRequest struct {
+	UserID   string `json:"user_id,omitempty"`
+	OrderID  string `json:"order_id,omitempty"`
+	Status   string `json:"status,omitempty"`    // open, acknowledged, resolved
+	UserType string `json:"user_type,omitempty"` // passenger, driver
+	Page     int    `json:"page,omitempty"`
+	Limit    int    `json:"limit,omitempty"`
+}
+
+// SafetyIncidentActionRequest 安全事件处理请求（响应、解决）
+type SafetyIncidentActionRequest struct {
+	UserID     string `json:"user_id,omitempty"` // 操作者ID（后端自动填充）
+	IncidentID string `json:"incident_id" binding:"required"`
+	Notes      string `json:"notes,omitempty"` // 处理说明
+}
@@ -85,17 +85,30 @@ type EstimateRequest struct {
 
 	// 行程信息
 	PassengerCount    int     `json:"passenger_count"`
-	PickupLatitude    float64 `json:"pickup_latitude" binding:"required"`
-	PickupLongitude   float64 `json:"pickup_longitude" binding:"required"`
+	PickupLatitude    float64 `json:"pickup_latitude"` // required unless pickup_place_id is given
+	PickupLongitude   float64 `json:"pickup_longitude"`
 	PickupAddress     string  `json:"pickup_address,omitempty"`
 	PickupLandmark    string  `json:"pickup_landmark,omitempty"`
-	DropoffLatitude   float64 `json:"dropoff_latitude" binding:"required"`
-	DropoffLongitude  float64 `json:"dropoff_longitude" binding:"required"`
+	DropoffLatitude   float64 `json:"dropoff_latitude"` // required unless dropoff_place_id is given
+	DropoffLongitude  float64 `json:"dropoff_longitude"`
 	DropoffAddress    string  `json:"dropoff_address,omitempty"`
 	DropoffLandmark   string  `json:"dropoff_landmark,omitempty"`
 	EstimatedDistance float64 `json:"estimated_distance"`
 	EstimatedDuration int     `json:"estimated_duration"`
 
+	// Saved place IDs used in place of raw pickup/dropoff coordinates
+	PickupPlaceID  string `json:"pickup_place_id,omitempty"`
+	DropoffPlaceID string `json:"dropoff_place_id,omitempty"`
+
+	// Intermediate stops between pickup and dropoff, in visiting order
+	Stops []*RideStop `json:"stops,omitempty"`
+
+	// Ride type: standard (default) or pool; pool rides share the vehicle at a discounted fare
+	RideType string `json:"ride_type,omitempty"`
+
+	// Parcel size and weight, required when order_type is delivery
+	Parcel *ParcelInfo `json:"parcel,omitempty"`
+
 	// 价格相关
 	Currency  string  `json:"currency"`   // 币种
 	BasePrice float64 `json:"base_price"` // 基础价格（内部计算用）
@@ -132,6 +145,16 @@ type CreateOrderRequest struct {
 	// - 为空：走自动派单
 	// - 不为空：将订单预分配给该司机，并仅向该司机发送派单
 	ProviderID string `json:"provider_id,omitempty"`
+
+	// Saved place IDs; optional, must match the pickup/dropoff of the price estimate when given
+	PickupPlaceID  string `json:"pickup_place_id,omitempty"`
+	DropoffPlaceID string `json:"dropoff_place_id,omitempty"`
+
+	// Intermediate stops; optional, must match the stops of the price estimate when given
+	Stops []*RideStop `json:"stops,omitempty"`
+
+	// Sender, recipient and parcel details, required for delivery orders
+	Delivery *DeliveryContact `json:"delivery,omitempty"`
 }
 
 // =============================================================================
@@ -211,6 +234,9 @@ type OrderPaymentRequest struct {
 	Email         string `json:"email,omitempty"`
 	AccountNo     string `json:"account_no,omitempty"`
 	AccountName   string `json:"account_name,omitempty"`
+
+	// 已保存的支付方式ID，为空且未指定支付方式时使用默认支付方式
+	PaymentMethodID string `json:"payment_method_id,omitempty"`
 }
 
 // OrderCashRequest passenger requests cash payment with a verification code.
@@ -221,10 +247,11 @@ type OrderCashRequest struct {
 }
 
 type OrderCashResponse struct {
-	OrderID       string `json:"order_id"`
-	Status        string `json:"status"`
-	PaymentMethod string `json:"payment_method"`
-	CashCode      string `json:"cash_code"`
+	OrderID       string  `json:"order_id"`
+	Status        string  `json:"status"`
+	PaymentMethod string  `json:"payment_method"`
+	CashCode      string  `json:"cash_code"`
+	TipAmount     float64 `json:"tip_amount,omitempty"` // 需随车费一起支付的现金小费
 }
 
 type OrderPaymentResult struct {
@@ -355,4 +382,69 @@ type OrderETAResponse struct {
 	PickupLongitude float64 `json:"pickup_longitude"`
 	Mode            string  `json:"mode"` // "rough" or "accurate"
 	UpdatedAt       int64   `json:"updated_at"`
+
+	// Multi-stop rides: while in progress ETAMinutes is to the next pending stop,
+	// DropoffETAMinutes is to the dropoff through all remaining stops
+	NextStop          *RideStop `json:"next_stop,omitempty"`
+	RemainingStops    int       `json:"remaining_stops"`
+	DropoffETAMinutes int       `json:"dropoff_eta_minutes,omitempty"`
+	DropoffDistanceKm float64   `json:"dropoff_distance_km,omitempty"`
+}
+
+// OrderStopAddRequest adds an intermediate stop to an active ride, the fare is re-priced
+type OrderStopAddRequest struct {
+	OrderID  string    `json:"order_id" binding:"required"`
+	Stop     *RideStop `json:"stop" binding:"required"`
+	Position int       `json:"position,omitempty"` // 1-based position among the stops, 0 appends after the last stop
+	UserID   string    `json:"-"`                  // set from auth context
+}
+
+// OrderStopActionRequest removes a stop (passenger) or marks arrival at a stop (driver)
+type OrderStopActionRequest struct {
+	OrderID string `json:"order_id" binding:"required"`
+	StopID  string `json:"stop_id" binding:"required"`
+	UserID  string `json:"-"` // set from auth context
+}
+
+// =============================================================================
+// Announcement Request
+// =============================================================================
+
+// UserAnnouncementListRequest request for active announcements; location narrows service-area targeted ones
+type UserAnnouncementListRequest struct {
+	Latitude        float64 `json:"latitude,omitempty"`
+	Longitude       float64 `json:"longitude,omitempty"`
+	City            string  `json:"city,omitempty"`             // defaults to the user's profile city
+	DisplayPosition string  `json:"display_position,omitempty"` // home, profile, ride, payment, notification
+}
+
+// UserAnnouncementActionRequest marks an announcement as read or confirmed
+type UserAnnouncementActionRequest struct {
+	AnnouncementID string `json:"announcement_id" binding:"required"`
+}
+
+// =============================================================================
+// Notification Inbox Request
+// =============================================================================
+
+// UserNotificationListRequest lists the current user's notifications, newest first
+type UserNotificationListRequest struct {
+	Category   string `json:"category,omitempty"` // trip, payment, marketing, system
+	UnreadOnly bool   `json:"unread_only,omitempty"`
+	Page       int    `json:"page,omitempty"`
+	Limit      int    `json:"limit,omitempty"`
+}
+
+// UserNotificationActionRequest marks notifications as read or deletes them;
+// mark-read with no notification_ids marks all notifications (optionally of one category), delete requires ids
+type UserNotificationActionRequest struct {
+	NotificationIDs []string `json:"notification_ids,omitempty"`
+	Category        string   `json:"category,omitempty"`
+}
+
+// UpdateNotificationPreferencesRequest updates push preferences; omitted categories keep their current value
+type UpdateNotificationPreferencesRequest struct {
+	Trip      *bool `json:"trip,omitempty"`
+	Payment   *bool `json:"payment,omitempty"`
+	Marketing *bool `json:"marketing,omitempty"`
 }
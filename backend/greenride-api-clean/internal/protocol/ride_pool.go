@@ -0,0 +1,57 @@
+package protocol
+
+// 行程类型
+const (
+	RideTypeStandard = "standard" // 独享
+	RideTypePool     = "pool"     // 拼车
+)
+
+// 拼车状态
+const (
+	RidePoolStatusOpen      = "open"      // 进行中，可继续加入乘客
+	RidePoolStatusCompleted = "completed" // 所有乘客已下车或离开
+)
+
+// 拼车路线节点类型
+const (
+	PoolStopTypePickup  = "pickup"
+	PoolStopTypeDropoff = "dropoff"
+)
+
+// 拼车路线节点状态
+const (
+	PoolStopStatusPending = "pending" // 待完成
+	PoolStopStatusDone    = "done"    // 已接到或已送达
+	PoolStopStatusRemoved = "removed" // 订单取消后移除
+)
+
+// PoolStop 拼车路线节点，司机按顺序依次完成各乘客的上车点和下车点
+type PoolStop struct {
+	OrderID   string  `json:"order_id"`
+	Type      string  `json:"type"` // pickup, dropoff
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Address   string  `json:"address,omitempty"`
+	Seats     int     `json:"seats"` // 该订单占用座位数
+	Status    string  `json:"status"`
+	DoneAt    int64   `json:"done_at,omitempty"`
+}
+
+// IsPending 节点是否待完成
+func (p *PoolStop) IsPending() bool {
+	return p.Status == PoolStopStatusPending
+}
+
+// RidePool 司机的拼车行程及接送计划
+type RidePool struct {
+	PoolID         string      `json:"pool_id"`
+	DriverID       string      `json:"driver_id"`
+	Status         string      `json:"status"`
+	SeatCapacity   int         `json:"seat_capacity"`
+	AvailableSeats int         `json:"available_seats"` // 当前剩余可售座位（按尚未下车的乘客计算）
+	OrderIDs       []string    `json:"order_ids"`
+	Plan           []*PoolStop `json:"plan"` // 按接送顺序排列，已完成节点在前
+	NextStop       *PoolStop   `json:"next_stop,omitempty"`
+	CreatedAt      int64       `json:"created_at"`
+	UpdatedAt      int64       `json:"updated_at"`
+}
@@ -0,0 +1,20 @@
+package protocol
+
+// 途经点状态
+const (
+	RideStopStatusPending = "pending" // 未到达
+	RideStopStatusArrived = "arrived" // 司机已到达
+	RideStopStatusRemoved = "removed" // 乘客已删除
+)
+
+// RideStop 行程途经点，按Seq顺序依次经过，最后到达下车点
+type RideStop struct {
+	StopID    string  `json:"stop_id,omitempty"`
+	Seq       int     `json:"seq"` // 途经顺序，从1开始
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Address   string  `json:"address,omitempty"`
+	Landmark  string  `json:"landmark,omitempty"`
+	Status    string  `json:"status,omitempty"`
+	ArrivedAt int64   `json:"arrived_at,omitempty"`
+}
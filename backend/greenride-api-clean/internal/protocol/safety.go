@@ -0,0 +1,128 @@
+package protocol
+
+// 安全事件状态
+const (
+	SafetyIncidentStatusOpen         = "open"         // 待处理
+	SafetyIncidentStatusAcknowledged = "acknowledged" // 已响应
+	SafetyIncidentStatusResolved     = "resolved"     // 已解决
+)
+
+// 行程分享来源
+const (
+	TripShareSourceManual = "manual" // 用户主动分享
+	TripShareSourceSOS    = "sos"    // 紧急求助时自动生成
+)
+
+// SOSRequest 紧急求助请求，未指定订单时使用用户当前进行中的订单
+type SOSRequest struct {
+	Language  string  `json:"-"`
+	UserID    string  `json:"user_id"`   // 内部设置
+	UserType  string  `json:"user_type"` // 内部设置
+	OrderID   string  `json:"order_id,omitempty"`
+	Latitude  float64 `json:"latitude,omitempty"` // 当前位置，为空时使用最近上报的位置
+	Longitude float64 `json:"longitude,omitempty"`
+	Message   string  `json:"message,omitempty" binding:"max=500"` // 补充说明
+}
+
+// SafetyIncident 安全事件（紧急求助记录）
+type SafetyIncident struct {
+	IncidentID     string         `json:"incident_id"`
+	UserID         string         `json:"user_id"`
+	UserType       string         `json:"user_type"`
+	OrderID        string         `json:"order_id,omitempty"`
+	Status         string         `json:"status"`   // open, acknowledged, resolved
+	Priority       string         `json:"priority"` // high
+	Latitude       float64        `json:"latitude"`
+	Longitude      float64        `json:"longitude"`
+	Message        string         `json:"message,omitempty"`
+	OrderSnapshot  map[string]any `json:"order_snapshot,omitempty"` // 求助时的订单快照，仅管理后台返回
+	ShareID        string         `json:"share_id,omitempty"`
+	HandledBy      string         `json:"handled_by,omitempty"`
+	AcknowledgedAt int64          `json:"acknowledged_at,omitempty"`
+	ResolvedAt     int64          `json:"resolved_at,omitempty"`
+	Resolution     string         `json:"resolution,omitempty"`
+	CreatedAt      int64          `json:"created_at"`
+	UpdatedAt      int64          `json:"updated_at"`
+}
+
+// SOSResult 紧急求助结果，有进行中的行程时附带分享链接
+type SOSResult struct {
+	Incident *SafetyIncident `json:"incident"`
+	Share    *TripShare      `json:"share,omitempty"`
+}
+
+// TripShareRequest 创建行程分享链接请求
+type TripShareRequest struct {
+	Language       string `json:"-"`
+	UserID         string `json:"user_id"` // 内部设置
+	OrderID        string `json:"order_id" binding:"required"`
+	NotifyContacts bool   `json:"notify_contacts,omitempty"` // 是否短信发送给紧急联系人
+}
+
+// TripShareIDRequest 行程分享ID请求
+type TripShareIDRequest struct {
+	UserID  string `json:"user_id"` // 内部设置
+	ShareID string `json:"share_id" binding:"required"`
+}
+
+// TripShare 行程分享链接
+type TripShare struct {
+	ShareID   string `json:"share_id"`
+	OrderID   string `json:"order_id"`
+	Source    string `json:"source"` // manual, sos
+	URL       string `json:"url"`
+	ExpiresAt int64  `json:"expires_at"`
+	RevokedAt int64  `json:"revoked_at,omitempty"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+// SharedTrip 公开行程分享页数据，不包含手机号等隐私信息，行程结束后不再返回司机位置
+type SharedTrip struct {
+	OrderID           string  `json:"order_id"`
+	Status            string  `json:"status"`
+	Live              bool    `json:"live"` // 行程是否进行中
+	PassengerName     string  `json:"passenger_name,omitempty"`
+	DriverName        string  `json:"driver_name,omitempty"`
+	DriverAvatar      string  `json:"driver_avatar,omitempty"`
+	DriverRating      float64 `json:"driver_rating,omitempty"`
+	VehicleBrand      string  `json:"vehicle_brand,omitempty"`
+	VehicleModel      string  `json:"vehicle_model,omitempty"`
+	VehicleColor      string  `json:"vehicle_color,omitempty"`
+	PlateNumber       string  `json:"plate_number,omitempty"`
+	DriverLatitude    float64 `json:"driver_latitude,omitempty"`
+	DriverLongitude   float64 `json:"driver_longitude,omitempty"`
+	DriverHeading     float64 `json:"driver_heading,omitempty"`
+	LocationUpdatedAt int64   `json:"location_updated_at,omitempty"`
+	PickupAddress     string  `json:"pickup_address,omitempty"`
+	PickupLatitude    float64 `json:"pickup_latitude"`
+	PickupLongitude   float64 `json:"pickup_longitude"`
+	DropoffAddress    string  `json:"dropoff_address,omitempty"`
+	DropoffLatitude   float64 `json:"dropoff_latitude"`
+	DropoffLongitude  float64 `json:"dropoff_longitude"`
+	StartedAt         int64   `json:"started_at,omitempty"`
+	EndedAt           int64   `json:"ended_at,omitempty"`
+	ExpiresAt         int64   `json:"expires_at"` // 分享链接过期时间
+}
+
+// TrustedContactRequest 添加紧急联系人请求
+type TrustedContactRequest struct {
+	UserID       string `json:"user_id"` // 内部设置
+	Name         string `json:"name" binding:"required,max=100"`
+	Phone        string `json:"phone" binding:"required"`
+	Relationship string `json:"relationship,omitempty" binding:"max=50"`
+}
+
+// TrustedContactIDRequest 紧急联系人ID请求
+type TrustedContactIDRequest struct {
+	UserID    string `json:"user_id"` // 内部设置
+	ContactID string `json:"contact_id" binding:"required"`
+}
+
+// TrustedContact 紧急联系人
+type TrustedContact struct {
+	ContactID    string `json:"contact_id"`
+	Name         string `json:"name"`
+	Phone        string `json:"phone"`
+	Relationship string `json:"relationship,omitempty"`
+	CreatedAt    int64  `json:"created_at"`
+}
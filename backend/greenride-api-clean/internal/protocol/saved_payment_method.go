@@ -0,0 +1,46 @@
+package protocol
+
+// 已保存支付方式的验证状态
+const (
+	PaymentMethodVerifyStatusPending  = "pending"  // 验证中：MoMo小额验证待确认或Stripe SetupIntent待完成
+	PaymentMethodVerifyStatusVerified = "verified" // 已验证，可设为默认并自动扣款
+	PaymentMethodVerifyStatusFailed   = "failed"   // 验证失败，可重新发起验证
+)
+
+// AddPaymentMethodRequest 添加支付方式请求
+type AddPaymentMethodRequest struct {
+	Language      string `json:"-"`
+	UserID        string `json:"user_id"`                           // 内部设置
+	PaymentMethod string `json:"payment_method" binding:"required"` // momo, card
+	Phone         string `json:"phone,omitempty"`                   // MoMo手机号，为空时使用账户手机号
+	AccountName   string `json:"account_name,omitempty"`
+	SetDefault    bool   `json:"set_default,omitempty"` // 验证通过后设为默认支付方式
+}
+
+// PaymentMethodIDRequest 已保存支付方式ID请求
+type PaymentMethodIDRequest struct {
+	Language string `json:"-"`
+	UserID   string `json:"user_id"` // 内部设置
+	MethodID string `json:"method_id" binding:"required"`
+}
+
+// SavedPaymentMethod 已保存的支付方式，只返回脱敏信息
+type SavedPaymentMethod struct {
+	MethodID      string `json:"method_id"`
+	PaymentMethod string `json:"payment_method"` // momo, card
+	Provider      string `json:"provider"`       // MTN, visa, mastercard ...
+	DisplayName   string `json:"display_name"`
+	MaskedNumber  string `json:"masked_number"`
+	ExpiryMonth   int    `json:"expiry_month,omitempty"`
+	ExpiryYear    int    `json:"expiry_year,omitempty"`
+	IsDefault     bool   `json:"is_default"`
+	VerifyStatus  string `json:"verify_status"` // pending, verified, failed
+	VerifyReason  string `json:"verify_reason,omitempty"`
+	VerifiedAt    int64  `json:"verified_at,omitempty"`
+	LastUsedAt    int64  `json:"last_used_at,omitempty"`
+	CreatedAt     int64  `json:"created_at"`
+
+	// 添加银行卡时返回，客户端用Stripe SDK确认SetupIntent后调用验证接口
+	ClientSecret   string `json:"client_secret,omitempty"`
+	PublishableKey string `json:"publishable_key,omitempty"`
+}
@@ -30,3 +30,23 @@ type SystemConfigUpdateRequest struct {
 	AndroidStoreURL     *string `json:"android_store_url"`
 	IOSStoreURL         *string `json:"ios_store_url"`
 }
+
+// RateLimitOffender 限流拦截排行
+type RateLimitOffender struct {
+	Policy       string `json:"policy"`        // 限流策略
+	IdentityType string `json:"identity_type"` // ip/user
+	Identity     string `json:"identity"`      // IP地址或用户ID
+	BlockedCount int64  `json:"blocked_count"` // 被拦截次数
+}
+
+// SMSProviderStats 短信服务商投递统计
+type SMSProviderStats struct {
+	Provider     string  `json:"provider"`
+	Total        int64   `json:"total"`         // 提交次数
+	Sent         int64   `json:"sent"`          // 已提交待回执
+	Delivered    int64   `json:"delivered"`     // 已送达
+	Failed       int64   `json:"failed"`        // 提交失败或回执失败
+	Expired      int64   `json:"expired"`       // 回执超时
+	SuccessRate  float64 `json:"success_rate"`  // 提交成功率(%)
+	DeliveryRate float64 `json:"delivery_rate"` // 送达率(%)，仅统计有回执结果的记录
+}
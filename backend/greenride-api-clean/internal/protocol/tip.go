@@ -0,0 +1,51 @@
+package protocol
+
+// 小费状态
+const (
+	TipStatusPending = "pending" // 待支付（线上支付处理中或等待司机确认现金）
+	TipStatusSuccess = "success" // 已支付并入账司机
+	TipStatusFailed  = "failed"  // 支付失败，可重新发起
+)
+
+// OrderTipRequest 乘客给司机小费请求
+type OrderTipRequest struct {
+	Language      string  `json:"-"`
+	OrderID       string  `json:"order_id" binding:"required"`
+	UserID        string  `json:"user_id"` // 内部设置
+	Amount        float64 `json:"amount" binding:"required"`
+	PaymentMethod string  `json:"payment_method" binding:"required"` // 与订单支付方式相同的渠道，cash 表示与车费一起付现金
+	Phone         string  `json:"phone,omitempty"`
+	Email         string  `json:"email,omitempty"`
+	AccountNo     string  `json:"account_no,omitempty"`
+	AccountName   string  `json:"account_name,omitempty"`
+}
+
+// Tip 行程小费
+type Tip struct {
+	TipID         string  `json:"tip_id"`
+	OrderID       string  `json:"order_id"`
+	UserID        string  `json:"user_id"`
+	DriverID      string  `json:"driver_id"`
+	Amount        float64 `json:"amount"`
+	Currency      string  `json:"currency"`
+	PaymentMethod string  `json:"payment_method"`
+	PaymentID     string  `json:"payment_id,omitempty"`
+	Status        string  `json:"status"` // pending, success, failed
+	Reason        string  `json:"reason,omitempty"`
+	RedirectURL   string  `json:"redirect_url,omitempty"`
+	PaidAt        int64   `json:"paid_at,omitempty"`
+	CreatedAt     int64   `json:"created_at"`
+}
+
+// TipOptions 评价页展示的小费选项
+type TipOptions struct {
+	OrderID       string    `json:"order_id"`
+	Currency      string    `json:"currency"`
+	CanTip        bool      `json:"can_tip"`
+	Deadline      int64     `json:"deadline,omitempty"` // 可给小费的截止时间（毫秒）
+	PresetAmounts []float64 `json:"preset_amounts,omitempty"`
+	MinAmount     float64   `json:"min_amount"`
+	MaxAmount     float64   `json:"max_amount"`
+	CashAllowed   bool      `json:"cash_allowed"` // 车费尚未以现金支付时，可将小费加入现金一起支付
+	Tip           *Tip      `json:"tip,omitempty"`
+}
@@ -0,0 +1,47 @@
+package protocol
+
+// 翻译来源
+const (
+	TranslationSourceFile     = "file"     // locales 文件
+	TranslationSourceDatabase = "database" // 管理后台维护，覆盖文件
+)
+
+// TranslationEntry 一条翻译
+type TranslationEntry struct {
+	Namespace    string `json:"namespace"`
+	Language     string `json:"language"`
+	Key          string `json:"key"`
+	Value        string `json:"value"`
+	DefaultValue string `json:"default_value,omitempty"` // 默认语言（英语）的内容，供翻译参考
+	Source       string `json:"source"`                  // file, database
+	UpdatedBy    string `json:"updated_by,omitempty"`
+	UpdatedAt    int64  `json:"updated_at,omitempty"`
+}
+
+// TranslationImportResult 翻译导入结果
+type TranslationImportResult struct {
+	Created int      `json:"created"`
+	Updated int      `json:"updated"`
+	Skipped int      `json:"skipped"`           // 已存在且未要求覆盖，或内容未变化
+	Invalid []string `json:"invalid,omitempty"` // 不合法的key及原因
+}
+
+// TranslationFallback 翻译降级统计
+type TranslationFallback struct {
+	Key            string `json:"key"`
+	ServedLanguage string `json:"served_language,omitempty"` // 实际使用的语言，为空表示返回了原始key
+	Count          int64  `json:"count"`
+	FirstSeenAt    int64  `json:"first_seen_at"`
+	LastSeenAt     int64  `json:"last_seen_at"`
+}
+
+// TranslationLanguageReport 某个命名空间下某个语言的翻译完成情况
+type TranslationLanguageReport struct {
+	Namespace    string                 `json:"namespace"`
+	Language     string                 `json:"language"`
+	Total        int                    `json:"total"` // 所有语言key的并集
+	Translated   int                    `json:"translated"`
+	MissingCount int                    `json:"missing_count"`
+	Missing      []string               `json:"missing,omitempty"`
+	Fallbacks    []*TranslationFallback `json:"fallbacks,omitempty"` // 线上实际发生的降级，次数多的优先补齐
+}
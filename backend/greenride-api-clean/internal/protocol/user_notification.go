@@ -0,0 +1,28 @@
+package protocol
+
+// UserNotification 用户站内通知
+type UserNotification struct {
+	NotificationID string `json:"notification_id"`
+	Type           string `json:"type"`
+	Category       string `json:"category"` // trip, payment, marketing, system
+	Title          string `json:"title"`
+	Content        string `json:"content"`
+	RelatedType    string `json:"related_type,omitempty"` // order, promotion
+	RelatedID      string `json:"related_id,omitempty"`
+	IsRead         bool   `json:"is_read"`
+	ReadAt         int64  `json:"read_at,omitempty"`
+	CreatedAt      int64  `json:"created_at"`
+}
+
+// NotificationUnreadCount 未读通知数
+type NotificationUnreadCount struct {
+	Total      int64            `json:"total"`
+	ByCategory map[string]int64 `json:"by_category"`
+}
+
+// NotificationPreferences 用户推送偏好，关闭后该分类不再推送，但仍会出现在通知列表中
+type NotificationPreferences struct {
+	Trip      bool `json:"trip"`
+	Payment   bool `json:"payment"`
+	Marketing bool `json:"marketing"`
+}
@@ -184,6 +184,7 @@ type VehicleUpdateRequest struct {
 	InsuranceCompany      *string `json:"insurance_company,omitempty"`       // 保险公司
 	InsurancePolicyNumber *string `json:"insurance_policy_number,omitempty"` // 保险单号
 	InsuranceExpiry       *string `json:"insurance_expiry,omitempty"`        // 保险到期日
+	InspectionExpiry      *string `json:"inspection_expiry,omitempty"`       // 车检到期日
 
 	// 维护信息（可选）
 	LastServiceDate *string `json:"last_service_date,omitempty"` // 最后保养日期
@@ -0,0 +1,36 @@
+package protocol
+
+// OTP防刷信号类型
+const (
+	OTPSignalBlockedPrefix  = "blocked_prefix"  // 命中禁止号段
+	OTPSignalIPVelocity     = "ip_velocity"     // IP发送过快
+	OTPSignalDeviceVelocity = "device_velocity" // 设备发送过快
+	OTPSignalPrefixBudget   = "prefix_budget"   // 号段预算用完
+	OTPSignalCountryBudget  = "country_budget"  // 国家预算用完
+	OTPSignalChallenge      = "challenge"       // 要求工作量证明
+	OTPSignalChallengeFail  = "challenge_fail"  // 工作量证明失败
+)
+
+// OTPChallenge 发送验证码前需要完成的工作量证明
+// 客户端需要找到nonce，使 sha256(challenge_id + ":" + nonce) 的前 difficulty 位为0
+type OTPChallenge struct {
+	ChallengeID string `json:"challenge_id"`
+	Difficulty  int    `json:"difficulty"`
+	ExpiresIn   int    `json:"expires_in"` // 秒
+}
+
+// OTPProviderSpend 单个服务商的发送量与成本
+type OTPProviderSpend struct {
+	Provider string  `json:"provider"`
+	Count    int64   `json:"count"`
+	Cost     float64 `json:"cost"` // USD
+}
+
+// OTPSpendDay 每日验证码发送成本统计
+type OTPSpendDay struct {
+	Date      string              `json:"date"` // yyyy-MM-dd
+	Providers []*OTPProviderSpend `json:"providers"`
+	Count     int64               `json:"count"`
+	Cost      float64             `json:"cost"`
+	Signals   map[string]int64    `json:"signals"` // 防刷拦截信号统计
+}
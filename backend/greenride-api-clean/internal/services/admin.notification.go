@@ -111,6 +111,25 @@ func (s *AdminNotificationService) SendNotification(req *protocol.AdminSendNotif
 	return protocol.Success
 }
 
+// AlertAdmins 创建面向全体管理员的站内通知（如紧急求助），计入管理员未读数
+func (s *AdminNotificationService) AlertAdmins(notificationType, title, content, priority, relatedType, relatedID string) protocol.ErrorCode {
+	notification := models.NewNotificationV2()
+	notification.UserType = utils.StringPtr("admin")
+	notification.SetType(notificationType).
+		SetTitle(title).
+		SetContent(content).
+		SetPriority(priority).
+		SetRelated(relatedType, relatedID).
+		SetScheduledTime(utils.TimeNowMilli())
+	notification.Category = utils.StringPtr(protocol.NotificationCategorySystem)
+
+	if err := s.db.Create(notification).Error; err != nil {
+		log.Printf("Failed to create admin alert %s for %s %s: %v", notificationType, relatedType, relatedID, err)
+		return protocol.DatabaseError
+	}
+	return protocol.Success
+}
+
 // SearchNotifications 搜索通知（管理员查看通知历史）
 func (s *AdminNotificationService) SearchNotifications(req *protocol.AdminNotificationSearchRequest) ([]*models.Notification, int64, protocol.ErrorCode) {
 	var notifications []*models.Notification
@@ -118,13 +118,15 @@ func (s *AdminService) CreatePromotion(req *protocol.CreatePromotionRequest) (*m
 		PromotionValues: &models.PromotionValues{},
 	}
 
-	// 设置基本信息
+	// 设置基本信息：新建优惠码需由其他管理员审批后才生效
 	promotion.SetCode(req.Code).
 		SetTitle(req.Title).
 		SetDiscountType(req.DiscountType).
+		SetDiscountValue(req.DiscountValue).
 		SetDescription(req.Description).
 		SetCreatedBy(req.UserID).
-		SetStatus(protocol.StatusActive)
+		SetStatus(protocol.StatusInactive).
+		MarkPendingApproval(req.UserID)
 
 	// 设置可选信息
 	if req.MaxDiscountAmount != nil {
@@ -139,6 +141,27 @@ func (s *AdminService) CreatePromotion(req *protocol.CreatePromotionRequest) (*m
 	if req.UserUsageLimit != nil {
 		promotion.UserUsageLimit = req.UserUsageLimit
 	}
+	if req.BudgetAmount != nil {
+		promotion.SetBudgetAmount(*req.BudgetAmount)
+	}
+	if req.StartDate != nil {
+		promotion.SetStartDate(*req.StartDate)
+	}
+	if req.EndDate != nil {
+		promotion.SetEndDate(*req.EndDate)
+	}
+	if req.ValidCities != "" {
+		promotion.SetValidCities(req.ValidCities)
+	}
+	if req.ValidVehicleTypes != "" {
+		promotion.SetValidVehicleTypes(req.ValidVehicleTypes)
+	}
+	if req.Priority != nil {
+		promotion.SetPriority(*req.Priority)
+	}
+	if req.Tags != "" {
+		promotion.SetTags(req.Tags)
+	}
 	// 保存到数据库
 	if err := s.db.Create(promotion).Error; err != nil {
 		return nil, protocol.PromotionCreationFailed
@@ -170,6 +193,10 @@ func (s *AdminService) UpdatePromotion(req *protocol.UpdatePromotionRequest) pro
 		return protocol.PromotionNotFound
 	}
 
+	// 优惠力度、使用限制和预算属于需审批的字段，修改后需重新审批
+	requiresApproval := req.DiscountValue != nil || req.MaxDiscountAmount != nil || req.MinOrderAmount != nil ||
+		req.UsageLimit != nil || req.UserUsageLimit != nil || req.BudgetAmount != nil
+
 	// 更新字段使用 PromotionValues 的 setter 方法
 	if req.Title != nil {
 		promoCode.SetTitle(*req.Title)
@@ -192,6 +219,9 @@ func (s *AdminService) UpdatePromotion(req *protocol.UpdatePromotionRequest) pro
 	if req.UserUsageLimit != nil {
 		promoCode.SetUserUsageLimit(*req.UserUsageLimit)
 	}
+	if req.BudgetAmount != nil {
+		promoCode.SetBudgetAmount(*req.BudgetAmount)
+	}
 	if req.StartDate != nil {
 		promoCode.SetStartDate(*req.StartDate)
 	}
@@ -210,6 +240,15 @@ func (s *AdminService) UpdatePromotion(req *protocol.UpdatePromotionRequest) pro
 	if req.Tags != nil {
 		promoCode.SetTags(*req.Tags)
 	}
+	promoCode.SetUpdatedBy(req.UserID)
+	if requiresApproval {
+		if promoCode.GetApprovalStatus() != protocol.StatusPending {
+			promoCode.SetStatus(protocol.StatusInactive).
+				SetStatusReason("pending re-approval")
+		}
+		// 提交者单独记录，不会被之后的状态变更覆盖
+		promoCode.MarkPendingApproval(req.UserID)
+	}
 
 	// 更新数据
 	if err := s.db.Save(&promoCode).Error; err != nil {
@@ -237,6 +276,16 @@ func (s *AdminService) UpdatePromoCodeStatus(req *protocol.UpdatePromotionStatus
 		return protocol.DatabaseError
 	}
 
+	// 只有审批通过且预算未用完的优惠码才能启用
+	if req.Status == protocol.StatusActive {
+		if !promoCode.IsApproved() {
+			return protocol.PromotionNotApproved
+		}
+		if promoCode.IsBudgetExhausted() {
+			return protocol.PromotionBudgetExceeded
+		}
+	}
+
 	// 更新状态和相关字段
 	promoCode.SetStatus(req.Status).SetUpdatedBy(req.UserID)
 
@@ -274,11 +323,24 @@ func (s *AdminService) ApprovePromotion(req *protocol.ApprovePromotionRequest) p
 		return protocol.DatabaseError
 	}
 
+	// 审批人与创建人分离（maker-checker）
+	if promoCode.GetApprovalStatus() != protocol.StatusPending {
+		return protocol.PromotionAlreadyReviewed
+	}
+	if promoCode.GetCreatedBy() == req.UserID || promoCode.IsApprovalSubmitter(req.UserID) {
+		return protocol.PromotionSelfApproval
+	}
+
 	// 批准：使用 PromotionValues 的方法
 	currentTime := time.Now().Unix()
 	promoCode.Approve(req.UserID, req.Notes).
 		SetStatus(protocol.StatusActive).
+		SetStatusReason("").
 		SetActivatedAt(currentTime)
+	if promoCode.IsBudgetExhausted() {
+		promoCode.SetStatus(protocol.StatusSuspended).
+			SetStatusReason(models.PromotionStatusReasonBudgetExhausted)
+	}
 
 	if err := s.db.Save(&promoCode).Error; err != nil {
 		return protocol.PromotionApprovalFailed
@@ -298,6 +360,13 @@ func (s *AdminService) RejectPromotion(req *protocol.ApprovePromotionRequest) pr
 		return protocol.DatabaseError
 	}
 
+	if promoCode.GetApprovalStatus() != protocol.StatusPending {
+		return protocol.PromotionAlreadyReviewed
+	}
+	if promoCode.GetCreatedBy() == req.UserID || promoCode.IsApprovalSubmitter(req.UserID) {
+		return protocol.PromotionSelfApproval
+	}
+
 	// 拒绝：使用 PromotionValues 的方法
 	promoCode.Reject(req.UserID, req.Notes).
 		SetStatus(protocol.StatusInactive)
@@ -325,6 +394,8 @@ func (s *AdminService) GetPromotionUsage(promoCodeID string, startDate, endDate
 		"title":          promoCode.GetTitle(),
 		"usage_count":    promoCode.GetUsageCount(),
 		"usage_limit":    promoCode.GetUsageLimit(),
+		"budget_amount":  promoCode.GetBudgetAmount(),
+		"spent_amount":   promoCode.GetSpentAmount(),
 		"total_discount": 0.0,
 		"order_count":    0,
 		"revenue_impact": 0.0,
@@ -434,6 +505,11 @@ func (s *AdminService) validatePromotionRequest(req *protocol.CreatePromotionReq
 		}
 	}
 
+	// 验证预算
+	if req.BudgetAmount != nil && *req.BudgetAmount < 0 {
+		return protocol.PromotionValueInvalid
+	}
+
 	// 验证使用限制
 	if req.UsageLimit != nil && *req.UsageLimit <= 0 {
 		return protocol.PromotionValueInvalid
@@ -480,6 +556,11 @@ func (s *AdminService) validatePromotionUpdateRequest(req *protocol.UpdatePromot
 		}
 	}
 
+	// 验证预算
+	if req.BudgetAmount != nil && *req.BudgetAmount < 0 {
+		return protocol.PromotionValueInvalid
+	}
+
 	// 验证使用限制
 	if req.UsageLimit != nil && *req.UsageLimit <= 0 {
 		return protocol.PromotionValueInvalid
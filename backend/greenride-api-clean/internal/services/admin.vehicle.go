@@ -8,6 +8,7 @@ import (
 	"log"
 	"strings"
 	"sync"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -78,6 +79,19 @@ func (s *AdminVehicleService) GetVehicleByID(vehicleID string) *models.Vehicle {
 	return models.FindVehicleByID(vehicleID)
 }
 
+// parseVehicleExpiry 解析YYYY-MM-DD格式的到期日，当天结束前仍有效；空字符串表示清除
+func parseVehicleExpiry(value string) (int64, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, true
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return 0, false
+	}
+	return t.Add(24*time.Hour - time.Millisecond).UnixMilli(), true
+}
+
 // UpdateVehicle 更新车辆信息
 func (s *AdminVehicleService) UpdateVehicle(req *protocol.VehicleUpdateRequest) protocol.ErrorCode {
 	vehicle := s.GetVehicleByID(req.VehicleID)
@@ -133,9 +147,11 @@ func (s *AdminVehicleService) UpdateVehicle(req *protocol.VehicleUpdateRequest)
 		vehicle.SetRegistrationNumber(*req.RegistrationNumber)
 	}
 	if req.RegistrationExpiry != nil {
-		// 将字符串日期转换为Unix时间戳（这里需要根据实际日期格式进行转换）
-		// 暂时跳过，需要具体的日期格式规范
-		// vehicle.SetRegistrationExpiry(*req.RegistrationExpiry)
+		expiry, ok := parseVehicleExpiry(*req.RegistrationExpiry)
+		if !ok {
+			return protocol.InvalidParams
+		}
+		vehicle.SetRegistrationExpiry(expiry)
 	}
 	if req.InsuranceCompany != nil {
 		vehicle.SetInsuranceCompany(*req.InsuranceCompany)
@@ -144,9 +160,18 @@ func (s *AdminVehicleService) UpdateVehicle(req *protocol.VehicleUpdateRequest)
 		vehicle.SetInsurancePolicyNumber(*req.InsurancePolicyNumber)
 	}
 	if req.InsuranceExpiry != nil {
-		// 将字符串日期转换为Unix时间戳（这里需要根据实际日期格式进行转换）
-		// 暂时跳过，需要具体的日期格式规范
-		// vehicle.SetInsuranceExpiry(*req.InsuranceExpiry)
+		expiry, ok := parseVehicleExpiry(*req.InsuranceExpiry)
+		if !ok {
+			return protocol.InvalidParams
+		}
+		vehicle.SetInsuranceExpiry(expiry)
+	}
+	if req.InspectionExpiry != nil {
+		expiry, ok := parseVehicleExpiry(*req.InspectionExpiry)
+		if !ok {
+			return protocol.InvalidParams
+		}
+		vehicle.SetInspectionExpiry(expiry)
 	}
 
 	// 更新维护信息 - 使用链式调用
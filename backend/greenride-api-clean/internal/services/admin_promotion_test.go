@@ -0,0 +1,158 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"greenride/internal/models"
+	"greenride/internal/protocol"
+
+	"gorm.io/gorm"
+)
+
+func createTestPromotion(t *testing.T, createdBy string, budget float64) *models.Promotion {
+	t.Helper()
+	promotion := models.NewPromotion()
+	promotion.SetCode("SAVE" + promotion.PromotionID[len(promotion.PromotionID)-6:]).
+		SetCreatedBy(createdBy).
+		SetBudgetAmount(budget)
+	if err := models.GetDB().Create(promotion).Error; err != nil {
+		t.Fatalf("create promotion: %v", err)
+	}
+	return promotion
+}
+
+func TestApprovePromotionMakerChecker(t *testing.T) {
+	db := setupTestDB(t, &models.Promotion{})
+	service := &AdminService{db: db}
+	promotion := createTestPromotion(t, "admin-maker", 0)
+
+	if got := service.ApprovePromotion(&protocol.ApprovePromotionRequest{UserID: "admin-maker", PromotionID: promotion.PromotionID}); got != protocol.PromotionSelfApproval {
+		t.Fatalf("creator approval = %s, want %s", got, protocol.PromotionSelfApproval)
+	}
+	if got := service.RejectPromotion(&protocol.ApprovePromotionRequest{UserID: "admin-maker", PromotionID: promotion.PromotionID}); got != protocol.PromotionSelfApproval {
+		t.Fatalf("creator rejection = %s, want %s", got, protocol.PromotionSelfApproval)
+	}
+	if stored := models.GetPromotionByID(promotion.PromotionID); stored.GetApprovalStatus() != protocol.StatusPending {
+		t.Fatalf("approval status after self approval = %s, want pending", stored.GetApprovalStatus())
+	}
+
+	if got := service.ApprovePromotion(&protocol.ApprovePromotionRequest{UserID: "admin-checker", PromotionID: promotion.PromotionID}); got != protocol.Success {
+		t.Fatalf("checker approval = %s, want success", got)
+	}
+	stored := models.GetPromotionByID(promotion.PromotionID)
+	if !stored.IsApproved() || stored.GetStatus() != protocol.StatusActive {
+		t.Fatalf("approved promotion: approval=%s status=%s", stored.GetApprovalStatus(), stored.GetStatus())
+	}
+
+	if got := service.ApprovePromotion(&protocol.ApprovePromotionRequest{UserID: "admin-other", PromotionID: promotion.PromotionID}); got != protocol.PromotionAlreadyReviewed {
+		t.Fatalf("second approval = %s, want %s", got, protocol.PromotionAlreadyReviewed)
+	}
+}
+
+func TestApprovePromotionBlocksChangeSubmitters(t *testing.T) {
+	db := setupTestDB(t, &models.Promotion{})
+	service := &AdminService{db: db}
+	promotion := createTestPromotion(t, "admin-maker", 0)
+	if got := service.ApprovePromotion(&protocol.ApprovePromotionRequest{UserID: "admin-checker", PromotionID: promotion.PromotionID}); got != protocol.Success {
+		t.Fatalf("initial approval = %s, want success", got)
+	}
+
+	// A修改优惠力度后，B只变更状态，A仍不能审批自己的修改
+	discount, budget := 20.0, 500.0
+	if got := service.UpdatePromotion(&protocol.UpdatePromotionRequest{UserID: "admin-a", PromotionID: promotion.PromotionID, DiscountValue: &discount}); got != protocol.Success {
+		t.Fatalf("material edit = %s, want success", got)
+	}
+	if got := service.UpdatePromoCodeStatus(&protocol.UpdatePromotionStatusRequest{UserID: "admin-b", PromotionID: promotion.PromotionID, Status: protocol.StatusSuspended}); got != protocol.Success {
+		t.Fatalf("status change = %s, want success", got)
+	}
+	if got := service.ApprovePromotion(&protocol.ApprovePromotionRequest{UserID: "admin-a", PromotionID: promotion.PromotionID}); got != protocol.PromotionSelfApproval {
+		t.Fatalf("submitter approval after status change = %s, want %s", got, protocol.PromotionSelfApproval)
+	}
+
+	// 待审批期间C再修改预算，A和C都不能审批
+	if got := service.UpdatePromotion(&protocol.UpdatePromotionRequest{UserID: "admin-c", PromotionID: promotion.PromotionID, BudgetAmount: &budget}); got != protocol.Success {
+		t.Fatalf("second material edit = %s, want success", got)
+	}
+	for _, submitter := range []string{"admin-a", "admin-c"} {
+		if got := service.RejectPromotion(&protocol.ApprovePromotionRequest{UserID: submitter, PromotionID: promotion.PromotionID}); got != protocol.PromotionSelfApproval {
+			t.Fatalf("%s rejection = %s, want %s", submitter, got, protocol.PromotionSelfApproval)
+		}
+	}
+	if got := service.ApprovePromotion(&protocol.ApprovePromotionRequest{UserID: "admin-b", PromotionID: promotion.PromotionID}); got != protocol.Success {
+		t.Fatalf("independent approval = %s, want success", got)
+	}
+	if stored := models.GetPromotionByID(promotion.PromotionID); !stored.IsApproved() || stored.GetDiscountValue() != discount {
+		t.Fatalf("approved change: approval=%s discount=%v", stored.GetApprovalStatus(), stored.GetDiscountValue())
+	}
+}
+
+func TestPromotionBudgetCap(t *testing.T) {
+	db := setupTestDB(t, &models.Promotion{}, &models.UserPromotion{}, &models.PromotionCampaign{})
+	service := &AdminService{db: db}
+	promotion := createTestPromotion(t, "admin-maker", 10)
+	if got := service.ApprovePromotion(&protocol.ApprovePromotionRequest{UserID: "admin-checker", PromotionID: promotion.PromotionID}); got != protocol.Success {
+		t.Fatalf("approve = %s", got)
+	}
+	promotion = models.GetPromotionByID(promotion.PromotionID)
+	for _, userID := range []string{"U1", "U2", "U3"} {
+		if err := db.Create(models.NewUserPromotion(userID, promotion)).Error; err != nil {
+			t.Fatalf("issue user promotion: %v", err)
+		}
+	}
+
+	redeem := func(userID, orderID string, amount float64) error {
+		return db.Transaction(func(tx *gorm.DB) error {
+			return models.UseUserPromotionByID(tx, userID, promotion.PromotionID, orderID, amount)
+		})
+	}
+	if err := redeem("U1", "O1", 6); err != nil {
+		t.Fatalf("first redemption: %v", err)
+	}
+	if stored := models.GetPromotionByID(promotion.PromotionID); !stored.IsValid() {
+		t.Fatalf("promotion should stay valid under budget, spent=%v", stored.GetSpentAmount())
+	}
+	if err := redeem("U2", "O2", 6); err != nil {
+		t.Fatalf("second redemption: %v", err)
+	}
+
+	// 预算用完：停用、不能再核销、不能再发券
+	stored := models.GetPromotionByID(promotion.PromotionID)
+	if stored.GetStatus() != protocol.StatusSuspended || stored.GetStatusReason() != models.PromotionStatusReasonBudgetExhausted {
+		t.Fatalf("exhausted promotion: status=%s reason=%s spent=%v", stored.GetStatus(), stored.GetStatusReason(), stored.GetSpentAmount())
+	}
+	if err := redeem("U3", "O3", 1); !errors.Is(err, models.ErrPromotionBudgetExceeded) {
+		t.Fatalf("redemption over budget: err=%v, want %v", err, models.ErrPromotionBudgetExceeded)
+	}
+	var u3 models.UserPromotion
+	db.Where("user_id = ? AND promotion_id = ?", "U3", promotion.PromotionID).First(&u3)
+	if u3.IsUsed() {
+		t.Fatal("rejected redemption must roll back the user promotion")
+	}
+	if !models.GetBudgetExhaustedPromotionIDs([]string{promotion.PromotionID})[promotion.PromotionID] {
+		t.Fatal("exhausted promotion should be excluded from available coupons")
+	}
+	_, errCode := GetPromotionCampaignService().CreateCampaign(&protocol.CreatePromotionCampaignRequest{
+		UserID: "admin-checker", PromotionID: promotion.PromotionID, Name: "winback",
+	})
+	if errCode != protocol.PromotionInactive {
+		t.Fatalf("campaign on exhausted promotion = %s, want %s", errCode, protocol.PromotionInactive)
+	}
+
+	// 订单取消退回预算后重新启用
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		return models.ResetUserPromotionsByIDs(tx, "O2", []string{promotion.PromotionID})
+	}); err != nil {
+		t.Fatalf("release on cancel: %v", err)
+	}
+	stored = models.GetPromotionByID(promotion.PromotionID)
+	if stored.GetSpentAmount() != 6 || stored.GetUsageCount() != 1 {
+		t.Fatalf("after release: spent=%v usage=%d, want 6/1", stored.GetSpentAmount(), stored.GetUsageCount())
+	}
+	if stored.GetStatus() != protocol.StatusActive || stored.GetStatusReason() != "" {
+		t.Fatalf("after release: status=%s reason=%s, want active", stored.GetStatus(), stored.GetStatusReason())
+	}
+	if err := redeem("U3", "O3", 1); err != nil {
+		t.Fatalf("redemption after release: %v", err)
+	}
+}
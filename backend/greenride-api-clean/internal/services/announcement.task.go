@@ -0,0 +1,45 @@
+package services
+
+import (
+	"context"
+
+	"greenride/internal/log"
+	"greenride/internal/models"
+	"greenride/internal/protocol"
+	"greenride/internal/task"
+)
+
+const (
+	// 公告任务常量
+	TaskAnnouncementScheduler = "announcement_scheduler"
+)
+
+// InitAnnouncementTaskHandlers 初始化公告任务处理器
+func InitAnnouncementTaskHandlers() {
+	task.RegisterHandler(TaskAnnouncementScheduler, AnnouncementSchedulerHandler)
+
+	schedulerTask := &models.Task{
+		TaskID:     "announcement_scheduler",
+		Name:       "公告定时发布与分发",
+		Type:       "announcement",
+		HandlerKey: TaskAnnouncementScheduler,
+		Cron:       "* * * * *", // 每分钟执行一次
+		Status:     protocol.TaskStatusEnabled,
+		MaxRetries: 1,
+		Timeout:    60,
+		Remark:     "发布到时间的定时公告、标记过期公告，并按批次发送公告推送和邮件",
+	}
+	task.InitTasks([]*models.Task{schedulerTask})
+}
+
+// AnnouncementSchedulerHandler 处理定时发布和推送/邮件分发
+func AnnouncementSchedulerHandler(ctx context.Context, params protocol.MapData) error {
+	service := GetAnnouncementService()
+	if published := service.PublishDueAnnouncements(); published > 0 {
+		log.Get().Infof("定时发布公告 %d 条", published)
+	}
+	if sent := service.DeliverPendingAnnouncements(ctx); sent > 0 {
+		log.Get().Infof("公告分发本轮完成，共发送 %d 条", sent)
+	}
+	return nil
+}
@@ -0,0 +1,680 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"greenride/internal/log"
+	"greenride/internal/models"
+	"greenride/internal/protocol"
+	"greenride/internal/utils"
+)
+
+const (
+	announcementLiveLimit     = 200              // 用户端每次参与筛选的生效公告上限
+	announcementTaskBatch     = 50               // 定时任务每轮处理的公告数
+	announcementDeliverBudget = 50 * time.Second // 每轮分发的时间预算，避免超过任务超时
+)
+
+// AnnouncementService 公告服务
+// 管理后台创建的公告需另一名管理员审批后才会发布（或按发布时间定时发布），发布后按设置推送/发邮件
+type AnnouncementService struct {
+}
+
+var (
+	announcementInstance *AnnouncementService
+	announcementOnce     sync.Once
+)
+
+func GetAnnouncementService() *AnnouncementService {
+	announcementOnce.Do(func() {
+		SetupAnnouncementService()
+	})
+	return announcementInstance
+}
+
+func SetupAnnouncementService() {
+	announcementInstance = &AnnouncementService{}
+}
+
+// applyAnnouncementRequest 将请求内容写入公告
+func applyAnnouncementRequest(values *models.AnnouncementValues, req *protocol.CreateAnnouncementRequest) {
+	values.SetTitle(req.Title).
+		SetContent(req.Content).
+		SetSummary(req.Summary).
+		SetTimeRange(req.PublishTime, req.StartTime, req.EndTime).
+		SetDisplaySettings(req.IsSticky, req.IsBanner, req.IsPopup).
+		SetAction(req.ActionType, req.ActionURL, req.ActionText).
+		SetPriority(req.Priority).
+		SetTranslationMap(req.Translations)
+	if req.Type != "" {
+		values.SetType(req.Type)
+	}
+	if req.Level != "" {
+		values.SetLevel(req.Level)
+	}
+	if req.Language != "" {
+		values.SetLanguage(req.Language)
+	}
+	if req.TargetAudience != "" {
+		values.SetTargetAudience(req.TargetAudience)
+	}
+	values.TargetCities = models.JoinAnnouncementList(req.TargetCities)
+	values.TargetServiceAreas = models.JoinAnnouncementList(req.TargetServiceAreas)
+	values.ExcludedAreas = models.JoinAnnouncementList(req.ExcludedAreas)
+	values.TargetUserIDs = models.JoinAnnouncementList(req.TargetUserIDs)
+	values.ExcludedUserIDs = models.JoinAnnouncementList(req.ExcludedUserIDs)
+	values.ShowOnce = utils.BoolPtr(req.ShowOnce)
+	values.RequireConfirm = utils.BoolPtr(req.RequireConfirm)
+	values.DisplayPosition = utils.StringPtr(req.DisplayPosition)
+	values.ImageURL = utils.StringPtr(req.ImageURL)
+	values.BannerURL = utils.StringPtr(req.BannerURL)
+	values.SendPush = utils.BoolPtr(req.SendPush)
+	values.PushTitle = utils.StringPtr(req.PushTitle)
+	values.PushContent = utils.StringPtr(req.PushContent)
+	values.PushDelay = utils.IntPtr(max(req.PushDelay, 0))
+	values.SendEmail = utils.BoolPtr(req.SendEmail)
+	values.EmailSubject = utils.StringPtr(req.EmailSubject)
+}
+
+// validateAnnouncementRequest 校验受众和时间范围
+func validateAnnouncementRequest(req *protocol.CreateAnnouncementRequest) protocol.ErrorCode {
+	switch req.TargetAudience {
+	case "", models.TargetAudienceAll, models.TargetAudienceUsers, models.TargetAudienceDrivers, models.TargetAudienceNewUsers:
+	default:
+		return protocol.InvalidParams
+	}
+	if req.StartTime > 0 && req.EndTime > 0 && req.EndTime <= req.StartTime {
+		return protocol.InvalidParams
+	}
+	if req.PublishTime > 0 && req.EndTime > 0 && req.EndTime <= req.PublishTime {
+		return protocol.InvalidParams
+	}
+	return protocol.Success
+}
+
+// CreateAnnouncement 创建公告（草稿，待审批）
+func (s *AnnouncementService) CreateAnnouncement(req *protocol.CreateAnnouncementRequest) (*protocol.Announcement, protocol.ErrorCode) {
+	if code := validateAnnouncementRequest(req); code != protocol.Success {
+		return nil, code
+	}
+	announcement := models.NewAnnouncementV2()
+	applyAnnouncementRequest(announcement.AnnouncementValues, req)
+	announcement.SetStatus(models.AnnouncementStatusDraft)
+	announcement.SetCreator(req.UserID, "admin", req.UserID)
+	announcement.ApprovalStatus = utils.StringPtr(models.AnnouncementApprovalStatusPending)
+
+	if err := models.GetDB().Create(announcement).Error; err != nil {
+		log.Get().Errorf("创建公告失败: %v", err)
+		return nil, protocol.DatabaseError
+	}
+	return announcement.Protocol(), protocol.Success
+}
+
+// UpdateAnnouncement 修改公告，仅草稿和暂停状态可修改，修改后需重新审批
+func (s *AnnouncementService) UpdateAnnouncement(req *protocol.UpdateAnnouncementRequest) (*protocol.Announcement, protocol.ErrorCode) {
+	if code := validateAnnouncementRequest(&req.CreateAnnouncementRequest); code != protocol.Success {
+		return nil, code
+	}
+	announcement := models.GetAnnouncementByID(req.AnnouncementID)
+	if announcement == nil {
+		return nil, protocol.AnnouncementNotFound
+	}
+	editable := []string{models.AnnouncementStatusDraft, models.AnnouncementStatusPaused}
+	if !slices.Contains(editable, announcement.GetStatus()) {
+		return nil, protocol.AnnouncementStatusInvalid
+	}
+
+	values := &models.AnnouncementValues{}
+	applyAnnouncementRequest(values, &req.CreateAnnouncementRequest)
+	values.SetStatus(models.AnnouncementStatusDraft)
+	values.IsActive = utils.BoolPtr(false)
+	values.ApprovalStatus = utils.StringPtr(models.AnnouncementApprovalStatusPending)
+	values.UpdatedBy = utils.StringPtr(req.UserID)
+	values.UpdatedAt = utils.TimeNowMilli()
+
+	ok, err := models.TransitionAnnouncementStatus(req.AnnouncementID, editable, values)
+	if err != nil {
+		log.Get().Errorf("修改公告 %s 失败: %v", req.AnnouncementID, err)
+		return nil, protocol.DatabaseError
+	}
+	if !ok {
+		return nil, protocol.AnnouncementStatusInvalid
+	}
+	return s.GetAnnouncement(req.AnnouncementID)
+}
+
+// GetAnnouncement 获取公告详情
+func (s *AnnouncementService) GetAnnouncement(announcementID string) (*protocol.Announcement, protocol.ErrorCode) {
+	announcement := models.GetAnnouncementByID(announcementID)
+	if announcement == nil {
+		return nil, protocol.AnnouncementNotFound
+	}
+	return announcement.Protocol(), protocol.Success
+}
+
+// SearchAnnouncements 分页查询公告
+func (s *AnnouncementService) SearchAnnouncements(req *protocol.AnnouncementSearchRequest) ([]*protocol.Announcement, int64) {
+	announcements, total := models.SearchAnnouncements(req)
+	list := make([]*protocol.Announcement, 0, len(announcements))
+	for _, announcement := range announcements {
+		list = append(list, announcement.Protocol())
+	}
+	return list, total
+}
+
+// ApproveAnnouncement 审批通过公告（审批人不能是创建人或最后修改人）
+// 发布时间在未来则进入定时发布，否则立即发布
+func (s *AnnouncementService) ApproveAnnouncement(req *protocol.AnnouncementActionRequest) protocol.ErrorCode {
+	announcement := models.GetAnnouncementByID(req.AnnouncementID)
+	if announcement == nil {
+		return protocol.AnnouncementNotFound
+	}
+	if !announcement.IsDraft() || announcement.GetApprovalStatus() != models.AnnouncementApprovalStatusPending {
+		return protocol.AnnouncementStatusInvalid
+	}
+	if utils.SafeStringDeref(announcement.CreatedBy) == req.UserID || utils.SafeStringDeref(announcement.UpdatedBy) == req.UserID {
+		return protocol.AnnouncementSelfApproval
+	}
+
+	now := utils.TimeNowMilli()
+	values := &models.AnnouncementValues{
+		ApprovalStatus: utils.StringPtr(models.AnnouncementApprovalStatusApproved),
+		ApprovedBy:     utils.StringPtr(req.UserID),
+		ApprovalNotes:  utils.StringPtr(req.Notes),
+		ApprovedAt:     utils.Int64Ptr(now),
+		UpdatedAt:      now,
+	}
+	if publishTime := announcement.GetPublishTime(); publishTime > now {
+		values.Schedule(publishTime)
+	} else {
+		publishAnnouncementValues(announcement, values)
+	}
+
+	ok, err := models.TransitionAnnouncementStatus(req.AnnouncementID, []string{models.AnnouncementStatusDraft}, values)
+	if err != nil {
+		log.Get().Errorf("审批公告 %s 失败: %v", req.AnnouncementID, err)
+		return protocol.DatabaseError
+	}
+	if !ok {
+		return protocol.AnnouncementStatusInvalid
+	}
+	return protocol.Success
+}
+
+// publishAnnouncementValues 设置发布状态；需要推送或邮件且尚未分发完成时进入待分发
+// 暂停后重新发布会从上次的游标继续，已发送的用户不会重复收到
+func publishAnnouncementValues(announcement *models.Announcement, values *models.AnnouncementValues) {
+	values.Publish()
+	if announcement.NeedsDelivery() && announcement.GetDeliveryStatus() != protocol.AnnouncementDeliveryCompleted {
+		values.DeliveryStatus = utils.StringPtr(protocol.AnnouncementDeliveryPending)
+	}
+}
+
+// RejectAnnouncement 审批拒绝公告，退回草稿
+func (s *AnnouncementService) RejectAnnouncement(req *protocol.AnnouncementActionRequest) protocol.ErrorCode {
+	announcement := models.GetAnnouncementByID(req.AnnouncementID)
+	if announcement == nil {
+		return protocol.AnnouncementNotFound
+	}
+	if !announcement.IsDraft() || announcement.GetApprovalStatus() != models.AnnouncementApprovalStatusPending {
+		return protocol.AnnouncementStatusInvalid
+	}
+
+	values := &models.AnnouncementValues{}
+	values.Reject(req.UserID, req.Notes)
+	values.UpdatedAt = utils.TimeNowMilli()
+	return s.transition(req.AnnouncementID, []string{models.AnnouncementStatusDraft}, values)
+}
+
+// PauseAnnouncement 暂停已发布或定时中的公告，同时停止分发
+func (s *AnnouncementService) PauseAnnouncement(req *protocol.AnnouncementActionRequest) protocol.ErrorCode {
+	if models.GetAnnouncementByID(req.AnnouncementID) == nil {
+		return protocol.AnnouncementNotFound
+	}
+	values := &models.AnnouncementValues{}
+	values.Pause()
+	values.UpdatedBy = utils.StringPtr(req.UserID)
+	values.UpdatedAt = utils.TimeNowMilli()
+	return s.transition(req.AnnouncementID, []string{models.AnnouncementStatusPublished, models.AnnouncementStatusScheduled}, values)
+}
+
+// DeleteAnnouncement 删除公告（软删除）
+func (s *AnnouncementService) DeleteAnnouncement(req *protocol.AnnouncementActionRequest) protocol.ErrorCode {
+	if models.GetAnnouncementByID(req.AnnouncementID) == nil {
+		return protocol.AnnouncementNotFound
+	}
+	values := &models.AnnouncementValues{}
+	values.Delete()
+	values.UpdatedBy = utils.StringPtr(req.UserID)
+	values.UpdatedAt = utils.TimeNowMilli()
+	return s.transition(req.AnnouncementID, []string{
+		models.AnnouncementStatusDraft,
+		models.AnnouncementStatusScheduled,
+		models.AnnouncementStatusPublished,
+		models.AnnouncementStatusPaused,
+		models.AnnouncementStatusExpired,
+	}, values)
+}
+
+func (s *AnnouncementService) transition(announcementID string, fromStatuses []string, values *models.AnnouncementValues) protocol.ErrorCode {
+	ok, err := models.TransitionAnnouncementStatus(announcementID, fromStatuses, values)
+	if err != nil {
+		log.Get().Errorf("更新公告 %s 状态失败: %v", announcementID, err)
+		return protocol.DatabaseError
+	}
+	if !ok {
+		return protocol.AnnouncementStatusInvalid
+	}
+	return protocol.Success
+}
+
+// announcementMatchesAudience 检查公告受众和指定/排除用户
+func announcementMatchesAudience(announcement *models.Announcement, user *models.User, now int64) bool {
+	if slices.Contains(announcement.GetExcludedUserIDList(), user.UserID) {
+		return false
+	}
+	if targets := announcement.GetTargetUserIDList(); len(targets) > 0 && !slices.Contains(targets, user.UserID) {
+		return false
+	}
+	switch announcement.GetTargetAudience() {
+	case models.TargetAudienceAll:
+		return true
+	case models.TargetAudienceUsers:
+		return user.IsPassenger()
+	case models.TargetAudienceDrivers:
+		return user.IsDriver()
+	case models.TargetAudienceNewUsers:
+		return user.CreatedAt >= now-models.AnnouncementNewUserDays*24*3600*1000
+	default:
+		return false
+	}
+}
+
+// announcementLocation 用户端请求的位置信息
+type announcementLocation struct {
+	City        string
+	Latitude    float64
+	Longitude   float64
+	HasLocation bool
+}
+
+// announcementMatchesLocation 检查城市和服务区域定向
+// 指定了服务区域的公告要求用户位置落在其中一个区域内，位于排除区域内的用户不展示
+func announcementMatchesLocation(announcement *models.Announcement, loc *announcementLocation, areas map[string]*models.ServiceArea) bool {
+	if cities := announcement.GetTargetCityList(); len(cities) > 0 {
+		if loc.City == "" || !slices.ContainsFunc(cities, func(city string) bool { return strings.EqualFold(city, loc.City) }) {
+			return false
+		}
+	}
+	inArea := func(areaID string) bool {
+		area := areas[areaID]
+		return area != nil && area.ServiceAreaValues != nil && area.IsLocationInPolygon(loc.Latitude, loc.Longitude)
+	}
+	if excluded := announcement.GetExcludedAreaList(); len(excluded) > 0 && loc.HasLocation {
+		if slices.ContainsFunc(excluded, inArea) {
+			return false
+		}
+	}
+	if targets := announcement.GetTargetServiceAreaList(); len(targets) > 0 {
+		if !loc.HasLocation || !slices.ContainsFunc(targets, inArea) {
+			return false
+		}
+	}
+	return true
+}
+
+// userLastKnownLocation 用户最后上报的位置，用于推送和邮件分发时的区域定向
+func userLastKnownLocation(user *models.User) *announcementLocation {
+	lat, lng := user.GetLatitude(), user.GetLongitude()
+	return &announcementLocation{
+		City:        user.GetCity(),
+		Latitude:    lat,
+		Longitude:   lng,
+		HasLocation: lat != 0 || lng != 0,
+	}
+}
+
+// filterAnnouncementDeliveryUsers 按服务区域和排除区域过滤一批分发对象
+// 与用户端展示使用相同的区域规则，指定了服务区域时没有位置的用户不推送
+func filterAnnouncementDeliveryUsers(announcement *models.Announcement, users []*models.User, areas map[string]*models.ServiceArea) []*models.User {
+	if len(announcement.GetTargetServiceAreaList()) == 0 && len(announcement.GetExcludedAreaList()) == 0 {
+		return users
+	}
+	matched := make([]*models.User, 0, len(users))
+	for _, user := range users {
+		if announcementMatchesLocation(announcement, userLastKnownLocation(user), areas) {
+			matched = append(matched, user)
+		}
+	}
+	return matched
+}
+
+// resolveAnnouncementTranslation 按语言取公告内容，缺少的字段回退到主语言
+func resolveAnnouncementTranslation(announcement *models.Announcement, lang string) *protocol.AnnouncementTranslation {
+	result := &protocol.AnnouncementTranslation{
+		Title:        announcement.GetTitle(),
+		Summary:      announcement.GetSummary(),
+		Content:      announcement.GetContent(),
+		ActionText:   utils.SafeStringDeref(announcement.ActionText),
+		PushTitle:    utils.SafeStringDeref(announcement.PushTitle),
+		PushContent:  utils.SafeStringDeref(announcement.PushContent),
+		EmailSubject: utils.SafeStringDeref(announcement.EmailSubject),
+	}
+	lang = strings.ToLower(lang)
+	if lang == "" || lang == strings.ToLower(announcement.GetLanguage()) {
+		return result
+	}
+	translations := announcement.GetTranslationMap()
+	translation := translations[lang]
+	if translation == nil {
+		// zh-CN 等带地区的语言回退到基础语言
+		if base, _, found := strings.Cut(lang, "-"); found {
+			translation = translations[base]
+		}
+	}
+	if translation == nil {
+		return result
+	}
+	override := func(target *string, value string) {
+		if value != "" {
+			*target = value
+		}
+	}
+	override(&result.Title, translation.Title)
+	override(&result.Summary, translation.Summary)
+	override(&result.Content, translation.Content)
+	override(&result.ActionText, translation.ActionText)
+	override(&result.PushTitle, translation.PushTitle)
+	override(&result.PushContent, translation.PushContent)
+	override(&result.EmailSubject, translation.EmailSubject)
+	return result
+}
+
+// toUserAnnouncement 转换为用户端公告
+func toUserAnnouncement(announcement *models.Announcement, lang string, read *models.AnnouncementRead) *protocol.UserAnnouncement {
+	content := resolveAnnouncementTranslation(announcement, lang)
+	return &protocol.UserAnnouncement{
+		AnnouncementID:  announcement.AnnouncementID,
+		Title:           content.Title,
+		Summary:         content.Summary,
+		Content:         content.Content,
+		Type:            announcement.GetType(),
+		Level:           announcement.GetLevel(),
+		IsSticky:        announcement.GetIsSticky(),
+		IsBanner:        announcement.GetIsBanner(),
+		IsPopup:         announcement.GetIsPopup(),
+		RequireConfirm:  announcement.GetRequireConfirm(),
+		DisplayPosition: utils.SafeStringDeref(announcement.DisplayPosition),
+		ImageURL:        utils.SafeStringDeref(announcement.ImageURL),
+		BannerURL:       utils.SafeStringDeref(announcement.BannerURL),
+		ActionType:      utils.SafeStringDeref(announcement.ActionType),
+		ActionURL:       utils.SafeStringDeref(announcement.ActionURL),
+		ActionText:      content.ActionText,
+		IsRead:          read != nil,
+		IsConfirmed:     read != nil && read.IsConfirmed(),
+		PublishedAt:     announcement.GetPublishedAt(),
+		EndTime:         announcement.GetEndTime(),
+	}
+}
+
+// GetUserAnnouncements 获取用户当前可见的公告，内容按用户语言返回
+func (s *AnnouncementService) GetUserAnnouncements(user *models.User, req *protocol.UserAnnouncementListRequest, lang string) []*protocol.UserAnnouncement {
+	now := utils.TimeNowMilli()
+	loc := &announcementLocation{
+		City:        req.City,
+		Latitude:    req.Latitude,
+		Longitude:   req.Longitude,
+		HasLocation: req.Latitude != 0 || req.Longitude != 0,
+	}
+	if loc.City == "" {
+		loc.City = user.GetCity()
+	}
+	if lang == "" {
+		lang = user.GetLanguage()
+	}
+
+	var candidates []*models.Announcement
+	var areaIDs []string
+	for _, announcement := range models.GetLiveAnnouncements(now, announcementLiveLimit) {
+		if req.DisplayPosition != "" {
+			if position := utils.SafeStringDeref(announcement.DisplayPosition); position != "" && position != req.DisplayPosition {
+				continue
+			}
+		}
+		if !announcementMatchesAudience(announcement, user, now) {
+			continue
+		}
+		candidates = append(candidates, announcement)
+		areaIDs = append(areaIDs, announcement.GetTargetServiceAreaList()...)
+		areaIDs = append(areaIDs, announcement.GetExcludedAreaList()...)
+	}
+	if len(candidates) == 0 {
+		return []*protocol.UserAnnouncement{}
+	}
+
+	areas := models.GetServiceAreasByIDs(areaIDs)
+	ids := make([]string, 0, len(candidates))
+	for _, announcement := range candidates {
+		ids = append(ids, announcement.AnnouncementID)
+	}
+	reads := models.GetAnnouncementReads(user.UserID, ids)
+
+	list := make([]*protocol.UserAnnouncement, 0, len(candidates))
+	for _, announcement := range candidates {
+		if !announcementMatchesLocation(announcement, loc, areas) {
+			continue
+		}
+		read := reads[announcement.AnnouncementID]
+		// 只显示一次的公告读过后不再返回，需要确认但尚未确认的仍然返回
+		if announcement.GetShowOnce() && read != nil && (!announcement.GetRequireConfirm() || read.IsConfirmed()) {
+			continue
+		}
+		list = append(list, toUserAnnouncement(announcement, lang, read))
+	}
+	return list
+}
+
+// getLiveAnnouncement 获取当前生效的公告
+func (s *AnnouncementService) getLiveAnnouncement(announcementID string) *models.Announcement {
+	announcement := models.GetAnnouncementByID(announcementID)
+	if announcement == nil || !announcement.IsVisible() || !announcement.IsApproved() {
+		return nil
+	}
+	return announcement
+}
+
+// MarkRead 记录用户阅读公告，首次阅读计入阅读数
+func (s *AnnouncementService) MarkRead(userID, announcementID string) protocol.ErrorCode {
+	if s.getLiveAnnouncement(announcementID) == nil {
+		return protocol.AnnouncementNotFound
+	}
+	firstRead, err := models.MarkAnnouncementRead(announcementID, userID)
+	if err != nil {
+		log.Get().Errorf("记录公告 %s 阅读失败, user=%s: %v", announcementID, userID, err)
+		return protocol.DatabaseError
+	}
+	if firstRead {
+		if err := models.IncrementAnnouncementCounter(announcementID, "view_count"); err != nil {
+			log.Get().Warnf("更新公告 %s 阅读数失败: %v", announcementID, err)
+		}
+	}
+	return protocol.Success
+}
+
+// MarkConfirmed 记录用户确认公告，首次确认计入确认数
+func (s *AnnouncementService) MarkConfirmed(userID, announcementID string) protocol.ErrorCode {
+	if s.getLiveAnnouncement(announcementID) == nil {
+		return protocol.AnnouncementNotFound
+	}
+	firstRead, firstConfirm, err := models.MarkAnnouncementConfirmed(announcementID, userID)
+	if err != nil {
+		log.Get().Errorf("记录公告 %s 确认失败, user=%s: %v", announcementID, userID, err)
+		return protocol.DatabaseError
+	}
+	if firstRead {
+		if err := models.IncrementAnnouncementCounter(announcementID, "view_count"); err != nil {
+			log.Get().Warnf("更新公告 %s 阅读数失败: %v", announcementID, err)
+		}
+	}
+	if firstConfirm {
+		if err := models.IncrementAnnouncementCounter(announcementID, "confirm_count"); err != nil {
+			log.Get().Warnf("更新公告 %s 确认数失败: %v", announcementID, err)
+		}
+	}
+	return protocol.Success
+}
+
+// PublishDueAnnouncements 发布已到时间的定时公告，并将已过结束时间的公告标记为过期
+func (s *AnnouncementService) PublishDueAnnouncements() int {
+	now := utils.TimeNowMilli()
+	published := 0
+	for _, announcement := range models.GetDueScheduledAnnouncements(now, announcementTaskBatch) {
+		values := &models.AnnouncementValues{UpdatedAt: now}
+		publishAnnouncementValues(announcement, values)
+		ok, err := models.TransitionAnnouncementStatus(announcement.AnnouncementID, []string{models.AnnouncementStatusScheduled}, values)
+		if err != nil {
+			log.Get().Errorf("定时发布公告 %s 失败: %v", announcement.AnnouncementID, err)
+			continue
+		}
+		if ok {
+			published++
+		}
+	}
+	if expired, err := models.ExpireEndedAnnouncements(now); err != nil {
+		log.Get().Errorf("公告过期处理失败: %v", err)
+	} else if expired > 0 {
+		log.Get().Infof("已将 %d 条公告标记为过期", expired)
+	}
+	return published
+}
+
+// DeliverPendingAnnouncements 按批次推送/发邮件，每批前重新读取公告以响应暂停和删除
+func (s *AnnouncementService) DeliverPendingAnnouncements(ctx context.Context) int {
+	deadline := time.Now().Add(announcementDeliverBudget)
+	sent := 0
+	for _, announcement := range models.GetAnnouncementsPendingDelivery(announcementTaskBatch) {
+		if ctx.Err() != nil || time.Now().After(deadline) {
+			break
+		}
+		// 推送延迟从发布时间起算
+		if startAt := announcement.GetPublishedAt() + int64(announcement.GetPushDelay())*60*1000; startAt > utils.TimeNowMilli() {
+			continue
+		}
+		count, err := s.deliverAnnouncement(ctx, announcement.AnnouncementID, deadline)
+		if err != nil {
+			log.Get().Errorf("公告 %s 分发失败: %v", announcement.AnnouncementID, err)
+		}
+		sent += count
+	}
+	return sent
+}
+
+func (s *AnnouncementService) deliverAnnouncement(ctx context.Context, announcementID string, deadline time.Time) (int, error) {
+	sent := 0
+	for time.Now().Before(deadline) && ctx.Err() == nil {
+		announcement := models.GetAnnouncementByID(announcementID)
+		if announcement == nil || !announcement.IsActive() {
+			return sent, nil
+		}
+		if announcement.GetDeliveryStatus() == protocol.AnnouncementDeliveryPending {
+			if _, err := models.TransitionAnnouncementStatus(announcementID, []string{models.AnnouncementStatusPublished}, &models.AnnouncementValues{
+				DeliveryStatus:    utils.StringPtr(protocol.AnnouncementDeliveryRunning),
+				DeliveryStartedAt: utils.Int64Ptr(utils.TimeNowMilli()),
+			}); err != nil {
+				return sent, err
+			}
+		}
+
+		users := models.GetAnnouncementDeliveryUsers(announcement, announcement.GetDeliveryCursor(), announcement.GetPushBatchSize())
+		if len(users) == 0 {
+			if err := models.AddAnnouncementDeliveryProgress(announcementID, announcement.GetDeliveryCursor(), 0, 0, true); err != nil {
+				return sent, err
+			}
+			log.Get().Infof("公告 %s 分发完成，推送 %d 条，邮件 %d 封", announcementID, announcement.GetPushSentCount(), announcement.GetEmailSentCount())
+			return sent, nil
+		}
+
+		pushSent, emailSent := 0, 0
+		areas := models.GetServiceAreasByIDs(append(announcement.GetTargetServiceAreaList(), announcement.GetExcludedAreaList()...))
+		for _, user := range filterAnnouncementDeliveryUsers(announcement, users, areas) {
+			if announcement.GetSendPush() && s.sendAnnouncementPush(announcement, user) {
+				pushSent++
+			}
+			if announcement.GetSendEmail() && s.sendAnnouncementEmail(announcement, user) {
+				emailSent++
+			}
+		}
+		if err := models.AddAnnouncementDeliveryProgress(announcementID, users[len(users)-1].ID, pushSent, emailSent, false); err != nil {
+			return sent, err
+		}
+		sent += pushSent + emailSent
+	}
+	return sent, nil
+}
+
+// sendAnnouncementPush 发送公告推送，推送标题和内容未单独设置时使用公告标题和摘要
+func (s *AnnouncementService) sendAnnouncementPush(announcement *models.Announcement, user *models.User) bool {
+	lang := getUserLanguage(user)
+	content := resolveAnnouncementTranslation(announcement, lang)
+	title, body := content.PushTitle, content.PushContent
+	if title == "" {
+		title = content.Title
+	}
+	if body == "" {
+		body = content.Summary
+	}
+	if body == "" {
+		body = content.Content
+	}
+	message := &Message{
+		Type:     protocol.MsgTypeAnnouncement,
+		Channels: []string{protocol.MsgChannelFcm},
+		Params: map[string]any{
+			"to":                  user.UserID,
+			"AnnouncementTitle":   title,
+			"AnnouncementContent": body,
+			"announcement_id":     announcement.AnnouncementID,
+			"msg_type":            protocol.FCMMessageTypeSystem,
+			"notification_type":   protocol.NotificationTypeAnnouncement,
+		},
+		Language: lang,
+	}
+	if err := GetMessageService().SendMessage(message); err != nil {
+		log.Get().Warnf("公告 %s 推送失败, user=%s: %v", announcement.AnnouncementID, user.UserID, err)
+		return false
+	}
+	return true
+}
+
+// sendAnnouncementEmail 发送公告邮件，没有邮箱的用户跳过
+func (s *AnnouncementService) sendAnnouncementEmail(announcement *models.Announcement, user *models.User) bool {
+	email := user.GetEmail()
+	if email == "" {
+		return false
+	}
+	lang := getUserLanguage(user)
+	content := resolveAnnouncementTranslation(announcement, lang)
+	subject := content.EmailSubject
+	if subject == "" {
+		subject = content.Title
+	}
+	message := &Message{
+		Type:     protocol.MsgTypeAnnouncement,
+		Channels: []string{protocol.MsgChannelEmail},
+		Params: map[string]any{
+			"to":                  email,
+			"AnnouncementTitle":   subject,
+			"AnnouncementContent": content.Content,
+		},
+		Language: lang,
+	}
+	if err := GetMessageService().SendMessage(message); err != nil {
+		log.Get().Warnf("公告 %s 邮件发送失败, user=%s: %v", announcement.AnnouncementID, user.UserID, err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"slices"
+	"testing"
+
+	"greenride/internal/models"
+	"greenride/internal/protocol"
+	"greenride/internal/utils"
+)
+
+func TestAnnouncementMatchesAudience(t *testing.T) {
+	now := int64(100 * 24 * 3600 * 1000)
+	newUser := func(userID, userType string, createdAt int64) *models.User {
+		user := models.NewUser()
+		user.UserID = userID
+		user.UserType = utils.StringPtr(userType)
+		user.CreatedAt = createdAt
+		return user
+	}
+	passenger := newUser("U1", protocol.UserTypePassenger, now-90*24*3600*1000)
+	driver := newUser("U2", protocol.UserTypeDriver, now-90*24*3600*1000)
+	fresh := newUser("U3", protocol.UserTypePassenger, now-24*3600*1000)
+
+	cases := []struct {
+		name     string
+		audience string
+		user     *models.User
+		want     bool
+	}{
+		{"all passenger", models.TargetAudienceAll, passenger, true},
+		{"all driver", models.TargetAudienceAll, driver, true},
+		{"users passenger", models.TargetAudienceUsers, passenger, true},
+		{"users driver", models.TargetAudienceUsers, driver, false},
+		{"drivers driver", models.TargetAudienceDrivers, driver, true},
+		{"drivers passenger", models.TargetAudienceDrivers, passenger, false},
+		{"new users fresh", models.TargetAudienceNewUsers, fresh, true},
+		{"new users old", models.TargetAudienceNewUsers, passenger, false},
+		{"admins never shown in app", models.TargetAudienceAdmins, passenger, false},
+	}
+	for _, c := range cases {
+		announcement := models.NewAnnouncementV2()
+		announcement.SetTargetAudience(c.audience)
+		if got := announcementMatchesAudience(announcement, c.user, now); got != c.want {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+
+	announcement := models.NewAnnouncementV2()
+	announcement.TargetUserIDs = models.JoinAnnouncementList([]string{"U1", " U3 "})
+	announcement.ExcludedUserIDs = models.JoinAnnouncementList([]string{"U3"})
+	if !announcementMatchesAudience(announcement, passenger, now) {
+		t.Errorf("targeted user U1 should match")
+	}
+	if announcementMatchesAudience(announcement, driver, now) {
+		t.Errorf("user U2 is not in the target list")
+	}
+	if announcementMatchesAudience(announcement, fresh, now) {
+		t.Errorf("excluded user U3 should not match even if targeted")
+	}
+}
+
+func TestAnnouncementMatchesLocation(t *testing.T) {
+	// 基加利市中心附近 5 公里半径的服务区域
+	areas := map[string]*models.ServiceArea{
+		"SA1": {ServiceAreaID: "SA1", ServiceAreaValues: &models.ServiceAreaValues{
+			CenterLat: utils.Float64Ptr(-1.9441), CenterLng: utils.Float64Ptr(30.0619), Radius: utils.Float64Ptr(5),
+		}},
+		"SA2": {ServiceAreaID: "SA2", ServiceAreaValues: &models.ServiceAreaValues{
+			CenterLat: utils.Float64Ptr(-1.9700), CenterLng: utils.Float64Ptr(30.0600), Radius: utils.Float64Ptr(1),
+		}},
+	}
+	inCenter := &announcementLocation{City: "Kigali", Latitude: -1.9441, Longitude: 30.0619, HasLocation: true}
+	inExcluded := &announcementLocation{City: "Kigali", Latitude: -1.9700, Longitude: 30.0600, HasLocation: true}
+	farAway := &announcementLocation{City: "Musanze", Latitude: -1.4998, Longitude: 29.6349, HasLocation: true}
+	noLocation := &announcementLocation{City: "kigali"}
+
+	open := models.NewAnnouncementV2()
+	if !announcementMatchesLocation(open, noLocation, areas) {
+		t.Errorf("announcement without geo targeting should match everyone")
+	}
+
+	byCity := models.NewAnnouncementV2()
+	byCity.TargetCities = models.JoinAnnouncementList([]string{"Kigali"})
+	if !announcementMatchesLocation(byCity, noLocation, areas) {
+		t.Errorf("city match should be case-insensitive")
+	}
+	if announcementMatchesLocation(byCity, farAway, areas) {
+		t.Errorf("user in Musanze should not see a Kigali announcement")
+	}
+
+	byArea := models.NewAnnouncementV2()
+	byArea.TargetServiceAreas = models.JoinAnnouncementList([]string{"SA1", "SA404"})
+	byArea.ExcludedAreas = models.JoinAnnouncementList([]string{"SA2"})
+	if !announcementMatchesLocation(byArea, inCenter, areas) {
+		t.Errorf("location inside SA1 should match")
+	}
+	if announcementMatchesLocation(byArea, inExcluded, areas) {
+		t.Errorf("location inside excluded SA2 should not match")
+	}
+	if announcementMatchesLocation(byArea, farAway, areas) {
+		t.Errorf("location outside every target area should not match")
+	}
+	if announcementMatchesLocation(byArea, noLocation, areas) {
+		t.Errorf("service-area announcement requires a location")
+	}
+}
+
+func TestFilterAnnouncementDeliveryUsers(t *testing.T) {
+	areas := map[string]*models.ServiceArea{
+		"SA1": {ServiceAreaID: "SA1", ServiceAreaValues: &models.ServiceAreaValues{
+			CenterLat: utils.Float64Ptr(-1.9441), CenterLng: utils.Float64Ptr(30.0619), Radius: utils.Float64Ptr(5),
+		}},
+		"SA2": {ServiceAreaID: "SA2", ServiceAreaValues: &models.ServiceAreaValues{
+			CenterLat: utils.Float64Ptr(-1.9700), CenterLng: utils.Float64Ptr(30.0600), Radius: utils.Float64Ptr(1),
+		}},
+	}
+	newUser := func(userID string, lat, lng float64) *models.User {
+		user := models.NewUser()
+		user.UserID = userID
+		if lat != 0 || lng != 0 {
+			user.SetLatitude(lat).SetLongitude(lng)
+		}
+		return user
+	}
+	users := []*models.User{
+		newUser("inside", -1.9441, 30.0619),
+		newUser("excluded", -1.9700, 30.0600),
+		newUser("far", -1.4998, 29.6349),
+		newUser("unknown", 0, 0),
+	}
+	userIDs := func(list []*models.User) []string {
+		ids := make([]string, 0, len(list))
+		for _, user := range list {
+			ids = append(ids, user.UserID)
+		}
+		return ids
+	}
+
+	open := models.NewAnnouncementV2()
+	if got := filterAnnouncementDeliveryUsers(open, users, nil); len(got) != len(users) {
+		t.Fatalf("announcement without areas delivered to %v, want everyone", userIDs(got))
+	}
+
+	byArea := models.NewAnnouncementV2()
+	byArea.TargetServiceAreas = models.JoinAnnouncementList([]string{"SA1"})
+	byArea.ExcludedAreas = models.JoinAnnouncementList([]string{"SA2"})
+	if got := userIDs(filterAnnouncementDeliveryUsers(byArea, users, areas)); len(got) != 1 || got[0] != "inside" {
+		t.Fatalf("area-targeted delivery = %v, want [inside]", got)
+	}
+
+	excludeOnly := models.NewAnnouncementV2()
+	excludeOnly.ExcludedAreas = models.JoinAnnouncementList([]string{"SA2"})
+	if got := userIDs(filterAnnouncementDeliveryUsers(excludeOnly, users, areas)); len(got) != 3 || slices.Contains(got, "excluded") {
+		t.Fatalf("exclude-only delivery = %v, want everyone except excluded", got)
+	}
+}
+
+func TestResolveAnnouncementTranslation(t *testing.T) {
+	announcement := models.NewAnnouncementV2()
+	announcement.SetTitle("Service update").SetContent("We are expanding to Musanze.").SetSummary("New city")
+	announcement.PushTitle = utils.StringPtr("Now in Musanze")
+	announcement.SetTranslationMap(map[string]*protocol.AnnouncementTranslation{
+		"fr": {Title: "Mise à jour du service", Content: "Nous arrivons à Musanze."},
+		"zh": {Title: "服务更新"},
+	})
+
+	en := resolveAnnouncementTranslation(announcement, "en")
+	if en.Title != "Service update" || en.PushTitle != "Now in Musanze" {
+		t.Fatalf("primary language should use main fields, got %+v", en)
+	}
+
+	fr := resolveAnnouncementTranslation(announcement, "FR")
+	if fr.Title != "Mise à jour du service" || fr.Content != "Nous arrivons à Musanze." {
+		t.Fatalf("french translation not applied: %+v", fr)
+	}
+	if fr.Summary != "New city" || fr.PushTitle != "Now in Musanze" {
+		t.Fatalf("missing translated fields should fall back to the primary language: %+v", fr)
+	}
+
+	if zh := resolveAnnouncementTranslation(announcement, "zh-CN"); zh.Title != "服务更新" || zh.Content != "We are expanding to Musanze." {
+		t.Fatalf("regional language should fall back to base translation: %+v", zh)
+	}
+	if rw := resolveAnnouncementTranslation(announcement, "rw"); rw.Title != "Service update" {
+		t.Fatalf("untranslated language should use primary content: %+v", rw)
+	}
+}
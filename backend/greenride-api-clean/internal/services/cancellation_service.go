@@ -0,0 +1,508 @@
+package services
+
+import (
+	"errors"
+	"slices"
+	"sync"
+
+	"greenride/internal/config"
+	"greenride/internal/log"
+	"greenride/internal/models"
+	"greenride/internal/protocol"
+	"greenride/internal/utils"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// CancellationService 取消费及乘客爽约费
+// 司机接单后超过免费期的乘客取消按时间或司机已行驶距离收取阶梯取消费；
+// 司机在上车点等待足够时间且定位可证明在上车点时，可以乘客未到场取消并收取爽约费。
+// 费用按比例分给司机和平台，钱包支付的订单直接从钱包扣款，其余订单通过乘客默认支付方式或订单的MoMo渠道扣款，
+// 无法扣款时记为乘客欠款。
+type CancellationService struct {
+}
+
+var (
+	cancellationInstance *CancellationService
+	cancellationOnce     sync.Once
+)
+
+func GetCancellationService() *CancellationService {
+	cancellationOnce.Do(func() {
+		SetupCancellationService()
+	})
+	return cancellationInstance
+}
+
+func SetupCancellationService() {
+	cancellationInstance = &CancellationService{}
+}
+
+// 接单后乘客取消需要收费的订单状态
+var cancellationFeeStatuses = []string{
+	protocol.StatusAccepted,
+	protocol.StatusDriverComing,
+	protocol.StatusDriverArrived,
+}
+
+// cancellationCharge 取消订单时向乘客收取的费用
+type cancellationCharge struct {
+	FeeType        string
+	Fee            decimal.Decimal
+	DriverShare    decimal.Decimal
+	PlatformShare  decimal.Decimal
+	FreeUntil      int64          // 免费取消截止时间，0表示不适用
+	ElapsedSeconds int64          // 司机接单后经过的秒数
+	TravelledKm    float64        // 司机接单后已行驶的公里数
+	Evidence       map[string]any // 爽约举证：等待时长、司机与上车点距离
+}
+
+// cancellationTierFee 取From不超过value的最高一档费用，阶梯无需有序
+func cancellationTierFee(tiers []*config.CancellationFeeTier, value float64) float64 {
+	fee, from := 0.0, -1.0
+	for _, tier := range tiers {
+		if tier == nil || value < tier.From || tier.From < from {
+			continue
+		}
+		fee, from = tier.Fee, tier.From
+	}
+	return fee
+}
+
+// calculateCancellationFee 免费期内不收费，超过后按时间和距离两种阶梯取较高者
+func calculateCancellationFee(cfg *config.CancellationConfig, elapsedSeconds int64, travelledKm float64) float64 {
+	if elapsedSeconds < int64(cfg.GracePeriodSeconds) {
+		return 0
+	}
+	fee := cancellationTierFee(cfg.TimeFeeTiers, float64(elapsedSeconds)/60)
+	if distanceFee := cancellationTierFee(cfg.DistanceFeeTiers, travelledKm); distanceFee > fee {
+		fee = distanceFee
+	}
+	return fee
+}
+
+// capCancellationFee 取消费不超过订单金额，订单金额未知时不封顶
+func capCancellationFee(fee, fare decimal.Decimal) decimal.Decimal {
+	if fare.IsPositive() && fee.GreaterThan(fare) {
+		return fare
+	}
+	return fee
+}
+
+// splitCancellationFee 按司机分成比例拆分，司机部分保留两位小数，余数归平台
+func splitCancellationFee(fee decimal.Decimal, driverSharePercent float64) (decimal.Decimal, decimal.Decimal) {
+	driverShare := fee.Mul(decimal.NewFromFloat(driverSharePercent)).Div(decimal.NewFromInt(100)).Round(2)
+	return driverShare, fee.Sub(driverShare)
+}
+
+// travelledDistanceKm 按定位轨迹累加行驶距离，忽略超过maxJumpKm的定位跳点
+func travelledDistanceKm(points []*models.UserLocationHistory, maxJumpKm float64) float64 {
+	total := 0.0
+	var last *models.UserLocationHistory
+	for _, point := range points {
+		if point == nil || point.UserLocationHistoryValues == nil {
+			continue
+		}
+		if last != nil {
+			step := utils.CalculateDistanceHaversine(last.GetLatitude(), last.GetLongitude(), point.GetLatitude(), point.GetLongitude())
+			if step > maxJumpKm {
+				continue
+			}
+			total += step
+		}
+		last = point
+	}
+	return total
+}
+
+// checkNoShowEvidence 校验司机是否在上车点等够时间且定位新鲜、距离上车点足够近
+func checkNoShowEvidence(cfg *config.CancellationConfig, waitedSeconds int64, distanceMeters float64, locationAgeSeconds int64) protocol.ErrorCode {
+	if waitedSeconds < int64(cfg.NoShowWaitMinutes)*60 {
+		return protocol.NoShowWaitNotElapsed
+	}
+	if locationAgeSeconds > int64(cfg.NoShowLocationMaxAge) || distanceMeters > cfg.NoShowRadiusMeters {
+		return protocol.NoShowNotAtPickup
+	}
+	return protocol.Success
+}
+
+// quoteCharge 计算取消订单时乘客应付的费用，返回nil表示免费取消
+// 司机以乘客未到场取消时校验等待时长和定位，不满足时返回错误码阻止取消
+func (s *CancellationService) quoteCharge(order *models.Order, userID, userType, reasonKey string) (*cancellationCharge, protocol.ErrorCode) {
+	cfg := config.GetCancellationConfig()
+	if !cfg.IsEnabled() || order == nil || order.GetOrderType() != protocol.RideOrder {
+		return nil, protocol.Success
+	}
+	if userType == protocol.UserTypeDriver && reasonKey == protocol.CancelReasonDriverPassengerNoShow {
+		if order.GetProviderID() != userID {
+			return nil, protocol.AccessDenied
+		}
+		return s.quoteNoShow(order, cfg)
+	}
+	if userType == protocol.UserTypePassenger && order.GetUserID() == userID && slices.Contains(cancellationFeeStatuses, order.GetStatus()) {
+		return s.quoteLateCancellation(order, cfg), protocol.Success
+	}
+	return nil, protocol.Success
+}
+
+// quoteLateCancellation 乘客在司机接单后取消
+func (s *CancellationService) quoteLateCancellation(order *models.Order, cfg *config.CancellationConfig) *cancellationCharge {
+	acceptedAt := order.GetAcceptedAt()
+	if acceptedAt <= 0 {
+		return nil
+	}
+	now := utils.TimeNowMilli()
+	charge := &cancellationCharge{
+		FeeType:        protocol.DebtTypeCancellationFee,
+		ElapsedSeconds: (now - acceptedAt) / 1000,
+	}
+	if charge.ElapsedSeconds < int64(cfg.GracePeriodSeconds) {
+		charge.FreeUntil = acceptedAt + int64(cfg.GracePeriodSeconds)*1000
+		return charge
+	}
+	if driverID := order.GetProviderID(); driverID != "" {
+		points, err := models.GetUserLocationHistoryByTimeRange(driverID, acceptedAt, now)
+		if err != nil {
+			log.Get().Warnf("查询司机 %s 接单后轨迹失败，订单 %s 按时间计算取消费: %v", driverID, order.OrderID, err)
+		}
+		charge.TravelledKm = travelledDistanceKm(points, cfg.MaxLocationJumpKm)
+	}
+	fee := decimal.NewFromFloat(calculateCancellationFee(cfg, charge.ElapsedSeconds, charge.TravelledKm))
+	s.applyFee(charge, fee, order, cfg)
+	return charge
+}
+
+// quoteNoShow 司机报乘客未到场，要求订单处于司机已到达状态并以定位举证
+func (s *CancellationService) quoteNoShow(order *models.Order, cfg *config.CancellationConfig) (*cancellationCharge, protocol.ErrorCode) {
+	if order.GetStatus() != protocol.StatusDriverArrived {
+		return nil, protocol.InvalidRideStatus
+	}
+	rideOrder := models.GetRideOrderByOrderID(order.OrderID)
+	driver := models.GetUserByID(order.GetProviderID())
+	if rideOrder == nil || driver == nil {
+		return nil, protocol.OrderNotFound
+	}
+	arrivedAt := rideOrder.GetArrivedAt()
+	if arrivedAt <= 0 {
+		return nil, protocol.NoShowWaitNotElapsed
+	}
+
+	now := utils.TimeNowMilli()
+	waitedSeconds := (now - arrivedAt) / 1000
+	distanceMeters := utils.CalculateDistanceHaversine(driver.GetLatitude(), driver.GetLongitude(), rideOrder.GetPickupLatitude(), rideOrder.GetPickupLongitude()) * 1000
+	locationAgeSeconds := (now - driver.GetLocationUpdatedAt()) / 1000
+	if errCode := checkNoShowEvidence(cfg, waitedSeconds, distanceMeters, locationAgeSeconds); errCode != protocol.Success {
+		log.Get().Infof("订单 %s 司机报乘客未到场被拒绝: 等待%d秒, 距上车点%.0f米, 定位%d秒前", order.OrderID, waitedSeconds, distanceMeters, locationAgeSeconds)
+		return nil, errCode
+	}
+
+	charge := &cancellationCharge{
+		FeeType:        protocol.DebtTypeNoShowFee,
+		ElapsedSeconds: (now - order.GetAcceptedAt()) / 1000,
+		Evidence: map[string]any{
+			"arrived_at":      arrivedAt,
+			"waited_seconds":  waitedSeconds,
+			"distance_meters": int64(distanceMeters),
+			"driver_location": []float64{driver.GetLatitude(), driver.GetLongitude()},
+			"located_at":      driver.GetLocationUpdatedAt(),
+		},
+	}
+	s.applyFee(charge, decimal.NewFromFloat(cfg.NoShowFee), order, cfg)
+	return charge, protocol.Success
+}
+
+// applyFee 封顶并拆分司机和平台分成
+func (s *CancellationService) applyFee(charge *cancellationCharge, fee decimal.Decimal, order *models.Order, cfg *config.CancellationConfig) {
+	if cfg.CapAtFare == config.StatusOn {
+		fee = capCancellationFee(fee, order.GetPaymentAmount())
+	}
+	charge.Fee = fee
+	charge.DriverShare, charge.PlatformShare = splitCancellationFee(fee, cfg.DriverSharePercent)
+}
+
+// PreviewCancellation 取消前查询需支付的取消费
+func (s *CancellationService) PreviewCancellation(req *protocol.CancelOrderRequest) (*protocol.CancellationQuote, protocol.ErrorCode) {
+	user := models.GetUserByID(req.UserID)
+	if user == nil {
+		return nil, protocol.UserNotFound
+	}
+	order := models.GetOrderByID(req.OrderID)
+	if order == nil || (order.GetUserID() != user.UserID && order.GetProviderID() != user.UserID) {
+		return nil, protocol.OrderNotFound
+	}
+	userType := protocol.UserTypePassenger
+	if user.IsDriver() {
+		userType = protocol.UserTypeDriver
+	}
+	charge, errCode := s.quoteCharge(order, user.UserID, userType, req.ReasonKey)
+	if errCode != protocol.Success {
+		return nil, errCode
+	}
+
+	quote := &protocol.CancellationQuote{
+		OrderID:  order.OrderID,
+		Currency: order.GetCurrency(),
+	}
+	if charge != nil {
+		quote.FreeUntil = charge.FreeUntil
+		quote.ElapsedSeconds = charge.ElapsedSeconds
+		quote.TravelledKm = charge.TravelledKm
+		if charge.Fee.IsPositive() {
+			quote.FeeType = charge.FeeType
+			quote.Fee, _ = charge.Fee.Float64()
+		}
+	}
+	return quote, protocol.Success
+}
+
+// recordCharge 在取消订单的事务中写入订单取消费并生成乘客欠款
+func (s *CancellationService) recordCharge(tx *gorm.DB, order *models.Order, values *models.OrderValues, charge *cancellationCharge) (*models.UserDebt, error) {
+	values.SetCancellationFee(charge.Fee).SetPlatformFee(charge.PlatformShare)
+	metadata := order.GetMetadata()
+	if metadata == nil {
+		metadata = map[string]any{}
+	}
+	metadata["cancellation_fee_type"] = charge.FeeType
+	if charge.Evidence != nil {
+		metadata["no_show_evidence"] = charge.Evidence
+	}
+	values.SetMetadata(metadata)
+
+	debt := models.NewUserDebt(order.GetUserID(), order.OrderID, charge.FeeType)
+	debt.SetAmounts(charge.Fee, charge.DriverShare, charge.PlatformShare, order.GetCurrency())
+	debt.DriverID = utils.StringPtr(order.GetProviderID())
+	if err := models.CreateUserDebt(tx, debt); err != nil {
+		return nil, err
+	}
+	return debt, nil
+}
+
+// CollectDebt 收取取消费或爽约费：钱包支付的订单直接从钱包扣款，其余订单通过支付渠道扣款，
+// 无可用渠道或扣款失败时保留为乘客欠款
+func (s *CancellationService) CollectDebt(debtID string) {
+	debt := models.GetUserDebtByID(debtID)
+	if debt == nil || debt.GetStatus() != protocol.DebtStatusOutstanding {
+		return
+	}
+	order := models.GetOrderByID(debt.GetOrderID())
+	if order == nil {
+		log.Get().Warnf("乘客欠款 %s 对应订单 %s 不存在，保留为欠款", debtID, debt.GetOrderID())
+		return
+	}
+	if order.GetPaymentMethod() == protocol.PaymentMethodWallet {
+		s.debitDebt(debt)
+		return
+	}
+	s.chargeDebt(order, debt)
+}
+
+// debtPaymentOrder 欠款走支付渠道扣款时使用的支付订单，以欠款ID作为订单号
+func debtPaymentOrder(order *models.Order, debt *models.UserDebt) *models.Order {
+	values := &models.OrderValues{}
+	values.SetOrderType(protocol.DebtOrder).
+		SetUserID(debt.GetUserID()).
+		SetStatus(protocol.StatusPending).
+		SetCurrency(debt.GetCurrency()).
+		SetPaymentAmount(debt.GetAmount())
+	values.Sandbox = order.Sandbox
+	return &models.Order{OrderID: debt.DebtID, OrderValues: values}
+}
+
+// chargeDebt 优先使用乘客默认支付方式扣款，没有默认支付方式时MoMo订单使用乘客手机号扣款，
+// 银行卡等无法免密扣款的渠道保留为欠款；异步结果由支付回调通过CheckDebtPayment处理
+func (s *CancellationService) chargeDebt(order *models.Order, debt *models.UserDebt) {
+	user := models.GetUserByID(debt.GetUserID())
+	if user == nil {
+		return
+	}
+	req := &ChannelPaymentRequest{
+		Order: debtPaymentOrder(order, debt),
+		User:  user,
+	}
+	savedMethodID, errCode := GetSavedPaymentMethodService().ApplySavedMethod(user.UserID, "", req)
+	if errCode != protocol.Success {
+		log.Get().Warnf("乘客 %s 默认支付方式不可用(%s)，%s %s 记为欠款", user.UserID, errCode, debt.GetType(), debt.DebtID)
+		s.markChargeFailed(debt, "["+string(errCode)+"]"+errCode.GetMessage())
+		return
+	}
+	if savedMethodID == "" {
+		if order.GetPaymentMethod() != protocol.PaymentMethodMomo || user.GetPhone() == "" {
+			log.Get().Infof("订单 %s 无可自动扣款的支付方式，%s %s 记为乘客 %s 欠款", order.OrderID, debt.GetType(), debt.GetAmount().String(), user.UserID)
+			return
+		}
+		req.PaymentMethod = protocol.PaymentMethodMomo
+		req.Phone = user.GetPhone()
+	}
+
+	result, errCode := GetPaymentService().OrderPayment(req)
+	if errCode != protocol.Success {
+		log.Get().Warnf("乘客欠款 %s 渠道扣款失败: %s", debt.DebtID, errCode)
+		s.markChargeFailed(debt, "["+string(errCode)+"]"+errCode.GetMessage())
+		return
+	}
+	if savedMethodID != "" {
+		amount, _ := debt.GetAmount().Float64()
+		if err := models.RecordUserPaymentMethodUsage(savedMethodID, amount, result.Status != protocol.StatusFailed); err != nil {
+			log.Get().Errorf("记录支付方式 %s 使用统计失败: %v", savedMethodID, err)
+		}
+	}
+	// 记录渠道支付单号，处理中的欠款不允许再从钱包支付，回调时按支付单号核对
+	if _, err := models.TransitionUserDebtStatus(debt.DebtID, []string{protocol.DebtStatusOutstanding}, map[string]any{
+		"payment_method": req.PaymentMethod,
+		"transaction_id": result.PaymentID,
+	}); err != nil {
+		log.Get().Errorf("记录乘客欠款 %s 支付单号 %s 失败: %v", debt.DebtID, result.PaymentID, err)
+		return
+	}
+	switch result.Status {
+	case protocol.StatusSuccess:
+		s.settleChannelDebt(debt, req.PaymentMethod, result.PaymentID)
+	case protocol.StatusFailed:
+		s.markChargeFailed(debt, "["+result.ResCode+"]"+result.ResMsg)
+	}
+}
+
+// CheckDebtPayment 支付回调后同步欠款渠道扣款结果
+func (s *CancellationService) CheckDebtPayment(debtID, paymentID string) {
+	debt := models.GetUserDebtByID(debtID)
+	if debt == nil {
+		return
+	}
+	payment := models.GetPaymentByID(paymentID)
+	if payment == nil {
+		return
+	}
+	if debt.GetStatus() != protocol.DebtStatusOutstanding || debt.GetTransactionID() != paymentID {
+		if payment.GetStatus() == protocol.StatusSuccess {
+			log.Get().Warnf("乘客欠款 %s 的旧支付 %s 回调成功，当前状态 %s，需人工核对", debtID, paymentID, debt.GetStatus())
+		}
+		return
+	}
+	switch payment.GetStatus() {
+	case protocol.StatusSuccess:
+		s.settleChannelDebt(debt, payment.GetPaymentMethod(), paymentID)
+	case protocol.StatusFailed:
+		s.markChargeFailed(debt, "["+payment.GetResCode()+"]"+payment.GetResMsg())
+	}
+}
+
+// settleChannelDebt 渠道扣款成功，条件更新状态保证司机分成只入账一次
+func (s *CancellationService) settleChannelDebt(debt *models.UserDebt, paymentMethod, paymentID string) {
+	ok, err := models.TransitionUserDebtStatus(debt.DebtID, []string{protocol.DebtStatusOutstanding}, map[string]any{
+		"status":         protocol.DebtStatusPaid,
+		"payment_method": paymentMethod,
+		"transaction_id": paymentID,
+		"paid_at":        utils.TimeNowMilli(),
+		"last_error":     "",
+	})
+	if err != nil {
+		log.Get().Errorf("更新乘客欠款 %s 支付成功状态失败: %v", debt.DebtID, err)
+		return
+	}
+	if ok {
+		s.payDriverShare(debt)
+	}
+}
+
+// markChargeFailed 渠道扣款失败，欠款保持未结清并清除支付单号，乘客可以再次支付
+func (s *CancellationService) markChargeFailed(debt *models.UserDebt, reason string) {
+	if len(reason) > 255 {
+		reason = reason[:255]
+	}
+	if _, err := models.TransitionUserDebtStatus(debt.DebtID, []string{protocol.DebtStatusOutstanding}, map[string]any{
+		"payment_method": nil,
+		"transaction_id": nil,
+		"last_error":     reason,
+	}); err != nil {
+		log.Get().Errorf("记录乘客欠款 %s 扣款失败原因出错: %v", debt.DebtID, err)
+	}
+}
+
+// PayDebtFromWallet 乘客主动从钱包结清取消费或爽约费欠款
+func (s *CancellationService) PayDebtFromWallet(debtID string) protocol.ErrorCode {
+	debt := models.GetUserDebtByID(debtID)
+	if debt == nil {
+		return protocol.DebtNotFound
+	}
+	if debt.GetStatus() != protocol.DebtStatusOutstanding {
+		return protocol.DebtNotPayable
+	}
+	if paymentID := debt.GetTransactionID(); paymentID != "" {
+		if payment := models.GetPaymentByID(paymentID); payment != nil && payment.GetStatus() == protocol.StatusPending {
+			return protocol.DebtPaymentPending
+		}
+	}
+	return s.debitDebt(debt)
+}
+
+// debitDebt 从乘客钱包扣除欠款，扣款失败时恢复为未结清
+func (s *CancellationService) debitDebt(debt *models.UserDebt) protocol.ErrorCode {
+	// 先占用欠款状态再扣款，避免并发重复扣款
+	now := utils.TimeNowMilli()
+	ok, err := models.TransitionUserDebtStatus(debt.DebtID, []string{protocol.DebtStatusOutstanding}, map[string]any{
+		"status":         protocol.DebtStatusPaid,
+		"payment_method": protocol.PaymentMethodWallet,
+		"paid_at":        now,
+	})
+	if err != nil {
+		log.Get().Errorf("更新乘客欠款 %s 状态失败: %v", debt.DebtID, err)
+		return protocol.DatabaseError
+	}
+	if !ok {
+		return protocol.DebtNotPayable
+	}
+
+	amount, _ := debt.GetAmount().Float64()
+	transaction, err := models.DebitWallet(debt.GetUserID(), amount, debtTransactionCategory(debt.GetType()), "order", debt.GetOrderID(), debtTransactionTitle(debt.GetType()))
+	if err != nil {
+		errCode := protocol.SystemError
+		if errors.Is(err, models.ErrInsufficientBalance) {
+			log.Get().Infof("乘客 %s 钱包余额不足，欠款 %s 保留待支付", debt.GetUserID(), debt.DebtID)
+			errCode = protocol.InsufficientFunds
+		} else {
+			log.Get().Errorf("乘客欠款 %s 钱包扣款失败: %v", debt.DebtID, err)
+		}
+		if _, err := models.TransitionUserDebtStatus(debt.DebtID, []string{protocol.DebtStatusPaid}, map[string]any{
+			"status":         protocol.DebtStatusOutstanding,
+			"payment_method": nil,
+			"paid_at":        nil,
+		}); err != nil {
+			log.Get().Errorf("回滚乘客欠款 %s 状态失败: %v", debt.DebtID, err)
+		}
+		return errCode
+	}
+	if _, err := models.TransitionUserDebtStatus(debt.DebtID, []string{protocol.DebtStatusPaid}, map[string]any{"transaction_id": transaction.TransactionID}); err != nil {
+		log.Get().Errorf("记录乘客欠款 %s 扣款流水失败: %v", debt.DebtID, err)
+	}
+	s.payDriverShare(debt)
+	return protocol.Success
+}
+
+// payDriverShare 欠款收取后把司机分成入账司机钱包
+func (s *CancellationService) payDriverShare(debt *models.UserDebt) {
+	driverID := debt.GetDriverID()
+	share, _ := debt.GetDriverShare().Float64()
+	if driverID == "" || share <= 0 {
+		return
+	}
+	if _, err := models.CreditWalletIncome(driverID, protocol.UserTypeDriver, debt.GetCurrency(), share, debtTransactionCategory(debt.GetType()), "user_debt", debt.DebtID, debtTransactionTitle(debt.GetType())); err != nil {
+		log.Get().Errorf("乘客欠款 %s 司机分成入账失败, driver=%s: %v", debt.DebtID, driverID, err)
+	}
+}
+
+// debtTransactionCategory 欠款类型对应的钱包流水分类
+func debtTransactionCategory(debtType string) string {
+	if debtType == protocol.DebtTypeNoShowFee {
+		return models.TransactionCategoryNoShowFee
+	}
+	return models.TransactionCategoryCancelFee
+}
+
+// debtTransactionTitle 欠款类型对应的钱包流水标题
+func debtTransactionTitle(debtType string) string {
+	if debtType == protocol.DebtTypeNoShowFee {
+		return "乘客爽约费"
+	}
+	return "行程取消费"
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"math"
+	"testing"
+
+	"greenride/internal/config"
+	"greenride/internal/models"
+	"greenride/internal/protocol"
+	"greenride/internal/utils"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestCalculateCancellationFee(t *testing.T) {
+	cfg := &config.CancellationConfig{}
+	cfg.Validate()
+
+	cases := []struct {
+		elapsedSeconds int64
+		travelledKm    float64
+		want           float64
+	}{
+		{60, 5, 0},       // 免费期内不收费，即使司机已行驶较远
+		{150, 0, 500},    // 超过免费期，按时间第一档
+		{400, 0.5, 1000}, // 按时间第二档
+		{150, 3.2, 1000}, // 距离档高于时间档
+		{130, 1.5, 500},  // 两档相同
+	}
+	for _, c := range cases {
+		if got := calculateCancellationFee(cfg, c.elapsedSeconds, c.travelledKm); got != c.want {
+			t.Errorf("elapsed=%d km=%.1f: fee = %v, want %v", c.elapsedSeconds, c.travelledKm, got, c.want)
+		}
+	}
+
+	unordered := []*config.CancellationFeeTier{{From: 10, Fee: 900}, {From: 0, Fee: 100}, {From: 5, Fee: 400}}
+	if got := cancellationTierFee(unordered, 7); got != 400 {
+		t.Errorf("unordered tiers fee = %v, want 400", got)
+	}
+	if got := cancellationTierFee(unordered, 12); got != 900 {
+		t.Errorf("unordered tiers fee = %v, want 900", got)
+	}
+}
+
+func TestSplitAndCapCancellationFee(t *testing.T) {
+	driverShare, platformShare := splitCancellationFee(decimal.NewFromInt(1000), 80)
+	if !driverShare.Equal(decimal.NewFromInt(800)) || !platformShare.Equal(decimal.NewFromInt(200)) {
+		t.Errorf("split = %s/%s, want 800/200", driverShare, platformShare)
+	}
+	driverShare, platformShare = splitCancellationFee(decimal.NewFromInt(1), 33.333)
+	if !driverShare.Add(platformShare).Equal(decimal.NewFromInt(1)) {
+		t.Errorf("split %s+%s should add up to fee", driverShare, platformShare)
+	}
+
+	if got := capCancellationFee(decimal.NewFromInt(1500), decimal.NewFromInt(1200)); !got.Equal(decimal.NewFromInt(1200)) {
+		t.Errorf("capped fee = %s, want 1200", got)
+	}
+	if got := capCancellationFee(decimal.NewFromInt(1500), decimal.Zero); !got.Equal(decimal.NewFromInt(1500)) {
+		t.Errorf("fee without fare = %s, want 1500", got)
+	}
+}
+
+func TestTravelledDistanceKm(t *testing.T) {
+	points := []*models.UserLocationHistory{
+		models.NewUserLocationHistory("D1", -1.9500, 30.0600, "busy", 1),
+		models.NewUserLocationHistory("D1", -1.9590, 30.0600, "busy", 2),
+		models.NewUserLocationHistory("D1", -2.5000, 30.0600, "busy", 3), // 定位跳点
+		models.NewUserLocationHistory("D1", -1.9680, 30.0600, "busy", 4),
+	}
+	got := travelledDistanceKm(points, 2)
+	if math.Abs(got-2.0) > 0.05 {
+		t.Errorf("travelled = %.3f km, want about 2 km", got)
+	}
+	if got := travelledDistanceKm(nil, 2); got != 0 {
+		t.Errorf("empty track = %v, want 0", got)
+	}
+}
+
+func TestCheckNoShowEvidence(t *testing.T) {
+	cfg := &config.CancellationConfig{}
+	cfg.Validate()
+
+	if code := checkNoShowEvidence(cfg, 240, 50, 10); code != protocol.NoShowWaitNotElapsed {
+		t.Errorf("short wait: code = %s", code)
+	}
+	if code := checkNoShowEvidence(cfg, 360, 500, 10); code != protocol.NoShowNotAtPickup {
+		t.Errorf("far from pickup: code = %s", code)
+	}
+	if code := checkNoShowEvidence(cfg, 360, 50, 600); code != protocol.NoShowNotAtPickup {
+		t.Errorf("stale location: code = %s", code)
+	}
+	if code := checkNoShowEvidence(cfg, 360, 50, 10); code != protocol.Success {
+		t.Errorf("valid no-show: code = %s", code)
+	}
+}
+
+func TestCheckDebtPayment(t *testing.T) {
+	db := setupTestDB(t, &models.UserDebt{}, &models.Payment{})
+
+	debt := models.NewUserDebt("U1", "O1", protocol.DebtTypeCancellationFee)
+	debt.SetAmounts(decimal.NewFromInt(500), decimal.Zero, decimal.NewFromInt(500), "RWF")
+	debt.TransactionID = utils.StringPtr("P1")
+	if err := models.CreateUserDebt(db, debt); err != nil {
+		t.Fatalf("create debt: %v", err)
+	}
+	newPayment := func(paymentID, status string) *models.Payment {
+		payment := models.NewPayment()
+		payment.PaymentID = paymentID
+		payment.SetOrderID(debt.DebtID).
+			SetOrderType(protocol.DebtOrder).
+			SetPaymentMethod(protocol.PaymentMethodMomo).
+			SetStatus(status).
+			SetResCode("FAILED").
+			SetResMsg("payer declined")
+		if err := db.Create(payment).Error; err != nil {
+			t.Fatalf("create payment: %v", err)
+		}
+		return payment
+	}
+	service := &CancellationService{}
+
+	// 渠道扣款处理中，不允许再从钱包支付
+	first := newPayment("P1", protocol.StatusPending)
+	if errCode := service.PayDebtFromWallet(debt.DebtID); errCode != protocol.DebtPaymentPending {
+		t.Fatalf("pay pending debt from wallet = %s, want %s", errCode, protocol.DebtPaymentPending)
+	}
+
+	// 扣款失败：保持未结清，清除支付单号并记录原因
+	db.Model(first).Update("status", protocol.StatusFailed)
+	service.CheckDebtPayment(debt.DebtID, "P1")
+	got := models.GetUserDebtByID(debt.DebtID)
+	if got.GetStatus() != protocol.DebtStatusOutstanding || got.GetTransactionID() != "" || got.GetLastError() != "[FAILED]payer declined" {
+		t.Fatalf("after failed payment: status=%s transaction=%s error=%s", got.GetStatus(), got.GetTransactionID(), got.GetLastError())
+	}
+
+	// 重新扣款成功：标记已支付并记录渠道和支付单号
+	newPayment("P2", protocol.StatusSuccess)
+	models.TransitionUserDebtStatus(debt.DebtID, []string{protocol.DebtStatusOutstanding}, map[string]any{"transaction_id": "P2"})
+	service.CheckDebtPayment(debt.DebtID, "P2")
+	got = models.GetUserDebtByID(debt.DebtID)
+	if got.GetStatus() != protocol.DebtStatusPaid || got.GetPaymentMethod() != protocol.PaymentMethodMomo || got.GetTransactionID() != "P2" || got.GetLastError() != "" {
+		t.Fatalf("after successful payment: status=%s method=%s transaction=%s error=%s", got.GetStatus(), got.GetPaymentMethod(), got.GetTransactionID(), got.GetLastError())
+	}
+
+	// 旧支付的回调不影响已结清的欠款
+	service.CheckDebtPayment(debt.DebtID, "P1")
+	if got = models.GetUserDebtByID(debt.DebtID); got.GetStatus() != protocol.DebtStatusPaid || got.GetTransactionID() != "P2" {
+		t.Fatalf("stale callback changed debt: status=%s transaction=%s", got.GetStatus(), got.GetTransactionID())
+	}
+}
@@ -191,7 +191,8 @@ type TopDriver struct {
 	DriverName    string  `json:"driver_name"`
 	Rating        float64 `json:"rating"`
 	TotalTrips    int64   `json:"total_trips"`
-	TotalEarnings float64 `json:"total_earnings"`
+	TotalEarnings float64 `json:"total_earnings"` // 含小费
+	TotalTips     float64 `json:"total_tips"`
 	Status        string  `json:"status"`
 }
 
@@ -452,12 +453,14 @@ func (s *DashboardService) getTopDrivers(limit int) ([]TopDriver, error) {
 		DriverID      string  `json:"driver_id"`
 		TotalTrips    int64   `json:"total_trips"`
 		TotalEarnings float64 `json:"total_earnings"`
+		TotalTips     float64 `json:"total_tips"`
 		AvgRating     float64 `json:"avg_rating"`
 	}
 
+	// 小费全额归司机，计入司机收入
 	var driverStats []DriverStats
 	if err := s.db.Model(&models.Order{}).
-		Select("provider_id as driver_id, COUNT(*) as total_trips, SUM(payment_amount) as total_earnings, 5.0 as avg_rating").
+		Select("provider_id as driver_id, COUNT(*) as total_trips, SUM(payment_amount) + COALESCE(SUM(tip_amount), 0) as total_earnings, COALESCE(SUM(tip_amount), 0) as total_tips, 5.0 as avg_rating").
 		Where("status = ? AND provider_id IS NOT NULL AND provider_id != ''", protocol.StatusCompleted).
 		Group("provider_id").
 		Order("total_earnings DESC").
@@ -481,6 +484,7 @@ func (s *DashboardService) getTopDrivers(limit int) ([]TopDriver, error) {
 			Rating:        stat.AvgRating,
 			TotalTrips:    stat.TotalTrips,
 			TotalEarnings: formatAmount(stat.TotalEarnings),
+			TotalTips:     formatAmount(stat.TotalTips),
 			Status:        "active",
 		}
 		drivers = append(drivers, driver)
@@ -0,0 +1,465 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"mime"
+	"slices"
+	"strings"
+	"sync"
+
+	"greenride/internal/config"
+	"greenride/internal/log"
+	"greenride/internal/models"
+	"greenride/internal/protocol"
+	"greenride/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+// DeliveryService 包裹配送服务
+// 配送订单复用主订单的派单、接单、取消及支付流程，取件到签收之间由配送状态细分：
+// 司机到达取件点 -> 取件（主订单进入in_progress）-> 到达收件点 -> 凭取件码或照片签收（主订单进入trip_ended）。
+type DeliveryService struct {
+}
+
+var (
+	deliveryInstance *DeliveryService
+	deliveryOnce     sync.Once
+)
+
+func GetDeliveryService() *DeliveryService {
+	deliveryOnce.Do(func() {
+		SetupDeliveryService()
+	})
+	return deliveryInstance
+}
+
+func SetupDeliveryService() {
+	deliveryInstance = &DeliveryService{}
+}
+
+// deliveryPreviousStatus 各配送状态的前置状态，配送只能按顺序推进
+var deliveryPreviousStatus = map[string]string{
+	protocol.DeliveryStatusArrivedPickup:  protocol.DeliveryStatusPending,
+	protocol.DeliveryStatusPickedUp:       protocol.DeliveryStatusArrivedPickup,
+	protocol.DeliveryStatusArrivedDropoff: protocol.DeliveryStatusPickedUp,
+	protocol.DeliveryStatusDelivered:      protocol.DeliveryStatusArrivedDropoff,
+}
+
+// canTransitDelivery 配送状态能否从from推进到to
+func canTransitDelivery(from, to string) bool {
+	previous, ok := deliveryPreviousStatus[to]
+	return ok && previous == from
+}
+
+// validateParcel 校验包裹尺寸和重量
+func validateParcel(parcel *protocol.ParcelInfo, maxWeightKg float64) protocol.ErrorCode {
+	if parcel == nil || parcel.WeightKg <= 0 {
+		return protocol.InvalidParcel
+	}
+	if !slices.Contains([]string{protocol.ParcelSizeSmall, protocol.ParcelSizeMedium, protocol.ParcelSizeLarge}, parcel.Size) {
+		return protocol.InvalidParcel
+	}
+	if parcel.WeightKg > maxWeightKg {
+		return protocol.ParcelTooHeavy
+	}
+	return protocol.Success
+}
+
+// resolveDeliveryProof 根据提交内容确定签收方式：填写了取件码时按取件码签收，否则需要签收照片
+func resolveDeliveryProof(code string, hasPhoto bool, cfg *config.DeliveryConfig) (string, protocol.ErrorCode) {
+	proofType := ""
+	switch {
+	case code != "":
+		proofType = protocol.DeliveryProofOTP
+	case hasPhoto:
+		proofType = protocol.DeliveryProofPhoto
+	default:
+		return "", protocol.DeliveryProofRequired
+	}
+	if !cfg.IsProofAllowed(proofType) {
+		return "", protocol.DeliveryProofNotAllowed
+	}
+	return proofType, protocol.Success
+}
+
+// ValidateEstimate 校验配送订单的预估请求，非配送订单直接通过
+func (s *DeliveryService) ValidateEstimate(req *protocol.EstimateRequest) protocol.ErrorCode {
+	if req.OrderType != protocol.DeliveryOrder {
+		return protocol.Success
+	}
+	cfg := config.GetDeliveryConfig()
+	if !cfg.IsEnabled() {
+		return protocol.DeliveryDisabled
+	}
+	// 配送订单不支持拼车和途经点
+	if len(req.Stops) > 0 || (req.RideType != "" && req.RideType != protocol.RideTypeStandard) {
+		return protocol.InvalidParams
+	}
+	return validateParcel(req.Parcel, cfg.MaxWeightKg)
+}
+
+// ValidateContact 校验下单时的寄件人、收件人及包裹照片，收件人电话规范化为E.164格式
+func (s *DeliveryService) ValidateContact(contact *protocol.DeliveryContact) protocol.ErrorCode {
+	cfg := config.GetDeliveryConfig()
+	if !cfg.IsEnabled() {
+		return protocol.DeliveryDisabled
+	}
+	if contact == nil || strings.TrimSpace(contact.RecipientName) == "" || strings.TrimSpace(contact.RecipientPhone) == "" {
+		return protocol.DeliveryInfoRequired
+	}
+	phone, ok := normalizeSMSPhone(contact.RecipientPhone)
+	if !ok {
+		return protocol.InvalidPhone
+	}
+	contact.RecipientPhone = phone
+	if len(contact.Photos) > cfg.MaxPhotos {
+		return protocol.TooManyParcelPhotos
+	}
+	return protocol.Success
+}
+
+// NewDeliveryOrder 根据价格快照和下单信息创建配送详情，寄件人未填写时使用下单用户的姓名和电话
+func (s *DeliveryService) NewDeliveryOrder(orderID string, price *models.PriceSnapshot, contact *protocol.DeliveryContact, sender *models.User) *models.DeliveryOrder {
+	meta := price.GetMetadata()
+	senderName, senderPhone := strings.TrimSpace(contact.SenderName), strings.TrimSpace(contact.SenderPhone)
+	if sender != nil {
+		if senderName == "" {
+			senderName = sender.GetFullName()
+		}
+		if senderPhone == "" {
+			senderPhone = sender.GetPhone()
+		}
+	}
+	deliveryOrder := models.NewDeliveryOrder(orderID)
+	deliveryOrder.SetVehicleCategory(price.GetVehicleCategory()).
+		SetVehicleLevel(price.GetVehicleLevel()).
+		SetEstimatedDistance(price.GetDistance()).
+		SetEstimatedDuration(price.GetDuration()).
+		SetSender(senderName, senderPhone).
+		SetRecipient(strings.TrimSpace(contact.RecipientName), strings.TrimSpace(contact.RecipientPhone)).
+		SetPickupLocation(meta.Get("pickup_address"), meta.Get("pickup_landmark"), meta.GetFloat64("pickup_latitude"), meta.GetFloat64("pickup_longitude")).
+		SetDropoffLocation(meta.Get("dropoff_address"), meta.Get("dropoff_landmark"), meta.GetFloat64("dropoff_latitude"), meta.GetFloat64("dropoff_longitude")).
+		SetParcel(meta.Get("parcel_size"), meta.GetFloat64("parcel_weight"), contact.Description, contact.Photos).
+		SetInstructions(contact.Instructions).
+		SetDeliveryCode(utils.GenerateDeliveryCode(config.GetDeliveryConfig().CodeLength))
+	return deliveryOrder
+}
+
+// SendRecipientCode 短信发送取件码给收件人，计入短信防刷预算，超出预算时不发送（寄件人仍可在订单中查看取件码）
+func (s *DeliveryService) SendRecipientCode(orderID string) {
+	deliveryOrder := models.GetDeliveryOrderByOrderID(orderID)
+	if deliveryOrder == nil || deliveryOrder.GetRecipientPhone() == "" {
+		return
+	}
+	verifyCodeService := GetVerifyCodeService()
+	if errCode := verifyCodeService.checkSMSBudget(nil, deliveryOrder.GetRecipientPhone()); errCode != protocol.Success {
+		log.Get().Warnf("配送订单 %s 取件码短信超出发送限制: %s", orderID, errCode)
+		return
+	}
+	language := protocol.LangEnglish
+	if order := models.GetOrderByID(orderID); order != nil {
+		if sender := models.GetUserByID(order.GetUserID()); sender != nil && sender.GetLanguage() != "" {
+			language = sender.GetLanguage()
+		}
+	}
+	msg := &Message{
+		Type:     protocol.MsgTypeRecipientDeliveryCode,
+		Channels: []string{protocol.MsgChannelSms},
+		Language: language,
+		To:       deliveryOrder.GetRecipientPhone(),
+		Params: map[string]any{
+			"to":          deliveryOrder.GetRecipientPhone(),
+			"code":        deliveryOrder.GetDeliveryCode(),
+			"sender_name": deliveryOrder.GetSenderName(),
+			"order_id":    orderID,
+		},
+	}
+	if err := GetMessageService().SendMessage(msg); err != nil {
+		log.Get().Errorf("配送订单 %s 发送取件码失败: %v", orderID, err)
+		return
+	}
+	verifyCodeService.recordSMSBudget(deliveryOrder.GetRecipientPhone())
+}
+
+// checkCodeResend 检查重发次数上限和发送间隔
+func checkCodeResend(deliveryOrder *models.DeliveryOrder, cfg *config.DeliveryConfig, now int64) protocol.ErrorCode {
+	if deliveryOrder.GetCodeResends() >= cfg.MaxCodeResends {
+		return protocol.TooManyAttempts
+	}
+	if deliveryOrder.GetCodeSentAt() > now-int64(cfg.ResendInterval)*1000 {
+		return protocol.VerificationCooldown
+	}
+	return protocol.Success
+}
+
+// ResendRecipientCode 寄件人重新生成并发送取件码，签收前可用，受每单次数上限、发送间隔和短信预算限制
+func (s *DeliveryService) ResendRecipientCode(orderID, userID string) protocol.ErrorCode {
+	order := models.GetOrderByID(orderID)
+	if order == nil || order.GetOrderType() != protocol.DeliveryOrder || order.GetUserID() != userID {
+		return protocol.OrderNotFound
+	}
+	deliveryOrder := models.GetDeliveryOrderByOrderID(orderID)
+	if deliveryOrder == nil {
+		return protocol.OrderNotFound
+	}
+	if deliveryOrder.GetDeliveryStatus() == protocol.DeliveryStatusDelivered || order.GetStatus() == protocol.StatusCancelled {
+		return protocol.InvalidDeliveryStatus
+	}
+	cfg := config.GetDeliveryConfig()
+	now := utils.TimeNowMilli()
+	if errCode := checkCodeResend(deliveryOrder, cfg, now); errCode != protocol.Success {
+		return errCode
+	}
+	if errCode := GetVerifyCodeService().checkSMSBudget(nil, deliveryOrder.GetRecipientPhone()); errCode != protocol.Success {
+		return errCode
+	}
+	ok, err := models.ResetDeliveryCode(orderID, utils.GenerateDeliveryCode(cfg.CodeLength), cfg.MaxCodeResends, now-int64(cfg.ResendInterval)*1000)
+	if err != nil {
+		log.Get().Errorf("配送订单 %s 重置取件码失败: %v", orderID, err)
+		return protocol.DatabaseError
+	}
+	if !ok {
+		return protocol.VerificationCooldown
+	}
+	go s.SendRecipientCode(orderID)
+	return protocol.Success
+}
+
+// GetDeliveryInfo 获取配送详情，不含取件码
+func (s *DeliveryService) GetDeliveryInfo(orderID string) *protocol.DeliveryInfo {
+	deliveryOrder := models.GetDeliveryOrderByOrderID(orderID)
+	if deliveryOrder == nil {
+		return nil
+	}
+	return deliveryOrder.Protocol()
+}
+
+// GetDeliveryCode 寄件人查看取件码，签收后不再返回
+func (s *DeliveryService) GetDeliveryCode(orderID string) string {
+	deliveryOrder := models.GetDeliveryOrderByOrderID(orderID)
+	if deliveryOrder == nil || deliveryOrder.GetDeliveryStatus() == protocol.DeliveryStatusDelivered {
+		return ""
+	}
+	return deliveryOrder.GetDeliveryCode()
+}
+
+// UploadParcelPhoto 寄件人下单前上传包裹照片，返回照片地址供下单时提交
+func (s *DeliveryService) UploadParcelPhoto(ctx context.Context, userID string, file *DocumentFile) (*protocol.DeliveryPhoto, protocol.ErrorCode) {
+	if !config.GetDeliveryConfig().IsEnabled() {
+		return nil, protocol.DeliveryDisabled
+	}
+	objectKey := fmt.Sprintf("delivery/parcels/%s/%s%s", userID, utils.GenerateUUID(), file.Extension)
+	url, errCode := s.uploadPhoto(ctx, objectKey, file)
+	if errCode != protocol.Success {
+		return nil, errCode
+	}
+	return &protocol.DeliveryPhoto{URL: url}, protocol.Success
+}
+
+// uploadPhoto 上传配送照片到S3
+func (s *DeliveryService) uploadPhoto(ctx context.Context, objectKey string, file *DocumentFile) (string, protocol.ErrorCode) {
+	awsService, available := GetAWSServiceSafe()
+	if !available {
+		return "", protocol.ServiceUnavail
+	}
+	contentType := mime.TypeByExtension(file.Extension)
+	if contentType == "" {
+		contentType = "image/jpeg"
+	}
+	url, err := awsService.UploadFromReader(ctx, file.Reader, objectKey, contentType)
+	if err != nil {
+		log.Get().Errorf("上传配送照片 %s 失败: %v", objectKey, err)
+		return "", protocol.DeliveryPhotoUploadFailed
+	}
+	return url, protocol.Success
+}
+
+// getCourierOrder 获取司机本人承运的配送订单
+func (s *DeliveryService) getCourierOrder(orderID, userID string) (*models.Order, *models.DeliveryOrder, protocol.ErrorCode) {
+	user := models.GetUserByID(userID)
+	if user == nil || !user.IsDriver() {
+		return nil, nil, protocol.AccessDenied
+	}
+	if orderID == "" {
+		return nil, nil, protocol.InvalidParams
+	}
+	order := models.GetOrderByID(orderID)
+	if order == nil || order.GetOrderType() != protocol.DeliveryOrder || order.GetProviderID() != userID {
+		return nil, nil, protocol.OrderNotFound
+	}
+	deliveryOrder := models.GetDeliveryOrderByOrderID(orderID)
+	if deliveryOrder == nil {
+		return nil, nil, protocol.OrderNotFound
+	}
+	return order, deliveryOrder, protocol.Success
+}
+
+// transit 推进配送状态，同时更新主订单；状态已是目标状态时视为成功
+func (s *DeliveryService) transit(order *models.Order, deliveryOrder *models.DeliveryOrder, to string, values map[string]any, orderValues *models.OrderValues) protocol.ErrorCode {
+	current := deliveryOrder.GetDeliveryStatus()
+	if current == to {
+		return protocol.Success
+	}
+	if !canTransitDelivery(current, to) {
+		return protocol.InvalidDeliveryStatus
+	}
+	values["delivery_status"] = to
+	transited := true
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		ok, err := models.TransitDeliveryStatus(tx, order.OrderID, current, values)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			transited = false
+			return nil
+		}
+		if orderValues == nil {
+			return nil
+		}
+		return models.UpdateOrder(tx, order, orderValues)
+	})
+	if err != nil {
+		log.Get().Errorf("配送订单 %s 更新配送状态 %s 失败: %v", order.OrderID, to, err)
+		return protocol.DatabaseError
+	}
+	if !transited {
+		return protocol.InvalidDeliveryStatus
+	}
+	models.RefreshOrderCache(order.OrderID)
+	go GetUserService().RefreshDriverOrderQueue(order.GetProviderID())
+	go GetUserService().RefreshUserOrderQueue(order.GetUserID())
+	return protocol.Success
+}
+
+// ArrivedPickup 司机到达取件点
+func (s *DeliveryService) ArrivedPickup(req *protocol.OrderActionRequest) protocol.ErrorCode {
+	order, deliveryOrder, errCode := s.getCourierOrder(req.OrderID, req.UserID)
+	if errCode != protocol.Success {
+		return errCode
+	}
+	if deliveryOrder.GetDeliveryStatus() == protocol.DeliveryStatusPending &&
+		!slices.Contains([]string{protocol.StatusAccepted, protocol.StatusDriverComing}, order.GetStatus()) {
+		return protocol.InvalidRideStatus
+	}
+	// 司机有其他在途订单时不能开始取件
+	if GetRidePoolService().CountActiveRideOrdersOutsidePool(req.UserID, order.OrderID) > 0 {
+		return protocol.DriverHasActiveOrderInProgress
+	}
+	orderValues := &models.OrderValues{}
+	orderValues.SetStatus(protocol.StatusDriverArrived)
+	errCode = s.transit(order, deliveryOrder, protocol.DeliveryStatusArrivedPickup, map[string]any{
+		"arrived_at": utils.TimeNowMilli(),
+	}, orderValues)
+	if errCode == protocol.Success {
+		go GetOrderService().NotifyDriverArrived(order.OrderID)
+	}
+	return errCode
+}
+
+// PickupParcel 司机取件，可附带取件时拍摄的包裹照片，主订单进入行程中
+func (s *DeliveryService) PickupParcel(ctx context.Context, req *protocol.OrderActionRequest, photo *DocumentFile) protocol.ErrorCode {
+	order, deliveryOrder, errCode := s.getCourierOrder(req.OrderID, req.UserID)
+	if errCode != protocol.Success {
+		return errCode
+	}
+	if order.GetStatus() == protocol.StatusCancelled {
+		return protocol.RideAlreadyCancelled
+	}
+	values := map[string]any{"picked_up_at": utils.TimeNowMilli()}
+	if photo != nil && deliveryOrder.GetDeliveryStatus() == protocol.DeliveryStatusArrivedPickup {
+		objectKey := fmt.Sprintf("delivery/%s/pickup%s", order.OrderID, photo.Extension)
+		url, errCode := s.uploadPhoto(ctx, objectKey, photo)
+		if errCode != protocol.Success {
+			return errCode
+		}
+		values["pickup_photo_url"] = url
+	}
+	orderValues := &models.OrderValues{}
+	orderValues.StartOrder()
+	errCode = s.transit(order, deliveryOrder, protocol.DeliveryStatusPickedUp, values, orderValues)
+	if errCode != protocol.Success {
+		return errCode
+	}
+	go GetOrderService().NotifyTripStarted(order.OrderID)
+	go func() {
+		if updatedOrder := models.GetOrderByID(order.OrderID); updatedOrder != nil {
+			GetOrderHistoryService().RecordOrderStarted(order, updatedOrder, req.UserID)
+		}
+	}()
+	return protocol.Success
+}
+
+// ArrivedDropoff 司机到达收件点
+func (s *DeliveryService) ArrivedDropoff(req *protocol.OrderActionRequest) protocol.ErrorCode {
+	order, deliveryOrder, errCode := s.getCourierOrder(req.OrderID, req.UserID)
+	if errCode != protocol.Success {
+		return errCode
+	}
+	return s.transit(order, deliveryOrder, protocol.DeliveryStatusArrivedDropoff, map[string]any{
+		"arrived_dropoff_at": utils.TimeNowMilli(),
+	}, nil)
+}
+
+// CompleteDelivery 司机凭收件人取件码或签收照片完成配送，主订单进入待支付
+func (s *DeliveryService) CompleteDelivery(ctx context.Context, req *protocol.OrderActionRequest, code string, photo *DocumentFile) protocol.ErrorCode {
+	order, deliveryOrder, errCode := s.getCourierOrder(req.OrderID, req.UserID)
+	if errCode != protocol.Success {
+		return errCode
+	}
+	if deliveryOrder.GetDeliveryStatus() == protocol.DeliveryStatusDelivered {
+		return protocol.Success
+	}
+	if deliveryOrder.GetDeliveryStatus() != protocol.DeliveryStatusArrivedDropoff {
+		return protocol.InvalidDeliveryStatus
+	}
+	cfg := config.GetDeliveryConfig()
+	code = strings.TrimSpace(code)
+	proofType, errCode := resolveDeliveryProof(code, photo != nil, cfg)
+	if errCode != protocol.Success {
+		return errCode
+	}
+
+	values := map[string]any{
+		"delivered_at": utils.TimeNowMilli(),
+		"proof_type":   proofType,
+	}
+	switch proofType {
+	case protocol.DeliveryProofOTP:
+		if deliveryOrder.GetCodeAttempts() >= cfg.MaxCodeAttempts {
+			return protocol.DeliveryCodeLocked
+		}
+		if subtle.ConstantTimeCompare([]byte(code), []byte(deliveryOrder.GetDeliveryCode())) != 1 {
+			if err := models.IncrDeliveryCodeAttempts(order.OrderID); err != nil {
+				log.Get().Errorf("配送订单 %s 记录取件码错误次数失败: %v", order.OrderID, err)
+			}
+			return protocol.DeliveryCodeInvalid
+		}
+	case protocol.DeliveryProofPhoto:
+		objectKey := fmt.Sprintf("delivery/%s/proof%s", order.OrderID, photo.Extension)
+		url, errCode := s.uploadPhoto(ctx, objectKey, photo)
+		if errCode != protocol.Success {
+			return errCode
+		}
+		values["proof_photo_url"] = url
+	}
+
+	orderValues := &models.OrderValues{}
+	orderValues.FinishOrder()
+	errCode = s.transit(order, deliveryOrder, protocol.DeliveryStatusDelivered, values, orderValues)
+	if errCode != protocol.Success {
+		return errCode
+	}
+	log.Get().Infof("配送订单 %s 已签收，签收方式=%s", order.OrderID, proofType)
+	go GetOrderService().NotifyTripEnded(order.OrderID)
+	go GetSavedPaymentMethodService().ChargeDefault(order.OrderID)
+	go func() {
+		if updatedOrder := models.GetOrderByID(order.OrderID); updatedOrder != nil {
+			GetOrderHistoryService().RecordOrderFinished(order, updatedOrder, req.UserID)
+		}
+	}()
+	return protocol.Success
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"testing"
+
+	"greenride/internal/config"
+	"greenride/internal/models"
+	"greenride/internal/protocol"
+	"greenride/internal/utils"
+)
+
+func TestCanTransitDelivery(t *testing.T) {
+	flow := []string{
+		protocol.DeliveryStatusPending,
+		protocol.DeliveryStatusArrivedPickup,
+		protocol.DeliveryStatusPickedUp,
+		protocol.DeliveryStatusArrivedDropoff,
+		protocol.DeliveryStatusDelivered,
+	}
+	for i := 1; i < len(flow); i++ {
+		if !canTransitDelivery(flow[i-1], flow[i]) {
+			t.Errorf("%s -> %s should be allowed", flow[i-1], flow[i])
+		}
+	}
+	// 不能跳过环节或回退
+	if canTransitDelivery(protocol.DeliveryStatusPending, protocol.DeliveryStatusPickedUp) {
+		t.Error("pickup before arriving at the pickup point should be rejected")
+	}
+	if canTransitDelivery(protocol.DeliveryStatusPickedUp, protocol.DeliveryStatusDelivered) {
+		t.Error("delivery before arriving at the dropoff point should be rejected")
+	}
+	if canTransitDelivery(protocol.DeliveryStatusDelivered, protocol.DeliveryStatusPending) {
+		t.Error("delivered parcels cannot go back to pending")
+	}
+}
+
+func TestValidateParcel(t *testing.T) {
+	cases := []struct {
+		parcel *protocol.ParcelInfo
+		want   protocol.ErrorCode
+	}{
+		{&protocol.ParcelInfo{Size: protocol.ParcelSizeSmall, WeightKg: 2}, protocol.Success},
+		{&protocol.ParcelInfo{Size: protocol.ParcelSizeLarge, WeightKg: 30}, protocol.Success},
+		{&protocol.ParcelInfo{Size: protocol.ParcelSizeLarge, WeightKg: 31}, protocol.ParcelTooHeavy},
+		{&protocol.ParcelInfo{Size: "huge", WeightKg: 2}, protocol.InvalidParcel},
+		{&protocol.ParcelInfo{Size: protocol.ParcelSizeMedium}, protocol.InvalidParcel},
+		{nil, protocol.InvalidParcel},
+	}
+	for _, c := range cases {
+		if got := validateParcel(c.parcel, 30); got != c.want {
+			t.Errorf("validateParcel(%+v) = %s, want %s", c.parcel, got, c.want)
+		}
+	}
+}
+
+func TestResolveDeliveryProof(t *testing.T) {
+	cfg := &config.DeliveryConfig{}
+	cfg.Validate()
+
+	if proof, errCode := resolveDeliveryProof("1234", true, cfg); errCode != protocol.Success || proof != protocol.DeliveryProofOTP {
+		t.Errorf("code should take precedence over photo, got %s %s", proof, errCode)
+	}
+	if proof, errCode := resolveDeliveryProof("", true, cfg); errCode != protocol.Success || proof != protocol.DeliveryProofPhoto {
+		t.Errorf("photo proof expected, got %s %s", proof, errCode)
+	}
+	if _, errCode := resolveDeliveryProof("", false, cfg); errCode != protocol.DeliveryProofRequired {
+		t.Errorf("missing proof should be rejected, got %s", errCode)
+	}
+
+	cfg.ProofTypes = []string{protocol.DeliveryProofOTP}
+	if _, errCode := resolveDeliveryProof("", true, cfg); errCode != protocol.DeliveryProofNotAllowed {
+		t.Errorf("photo proof should be rejected when only otp is allowed, got %s", errCode)
+	}
+}
+
+func TestValidateContactNormalizesRecipientPhone(t *testing.T) {
+	contact := &protocol.DeliveryContact{RecipientName: "Alice", RecipientPhone: "0788 123 456"}
+	if errCode := GetDeliveryService().ValidateContact(contact); errCode != protocol.Success {
+		t.Fatalf("ValidateContact() = %s, want success", errCode)
+	}
+	if contact.RecipientPhone != "+250788123456" {
+		t.Fatalf("recipient phone = %s, want +250788123456", contact.RecipientPhone)
+	}
+
+	contact = &protocol.DeliveryContact{RecipientName: "Alice", RecipientPhone: "call me"}
+	if errCode := GetDeliveryService().ValidateContact(contact); errCode != protocol.InvalidPhone {
+		t.Fatalf("ValidateContact() with invalid phone = %s, want %s", errCode, protocol.InvalidPhone)
+	}
+}
+
+func TestCheckCodeResend(t *testing.T) {
+	cfg := &config.DeliveryConfig{}
+	cfg.Validate()
+	now := int64(1_000_000_000)
+	newOrder := func(resends int, sentAt int64) *models.DeliveryOrder {
+		deliveryOrder := models.NewDeliveryOrder("O1")
+		deliveryOrder.CodeResends = utils.IntPtr(resends)
+		deliveryOrder.CodeSentAt = utils.Int64Ptr(sentAt)
+		return deliveryOrder
+	}
+
+	cases := []struct {
+		name  string
+		order *models.DeliveryOrder
+		want  protocol.ErrorCode
+	}{
+		{"after interval", newOrder(0, now-int64(cfg.ResendInterval)*1000), protocol.Success},
+		{"within interval", newOrder(0, now-1000), protocol.VerificationCooldown},
+		{"last allowed resend", newOrder(cfg.MaxCodeResends-1, 0), protocol.Success},
+		{"resend cap reached", newOrder(cfg.MaxCodeResends, 0), protocol.TooManyAttempts},
+	}
+	for _, c := range cases {
+		if got := checkCodeResend(c.order, cfg, now); got != c.want {
+			t.Errorf("%s: got %s, want %s", c.name, got, c.want)
+		}
+	}
+}
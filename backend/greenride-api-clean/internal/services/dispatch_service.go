@@ -89,8 +89,11 @@ func (s *DispatchService) StartAutoDispatch(order *protocol.Order) (result *prot
 	log.Get().Infof("[Dispatch] Order %s: %d/%d drivers eligible after evaluation",
 		order.OrderID, len(eligible_drivers), len(runtime_list))
 
-	// 2. 司机按评分排序
+	// 2. 司机按评分排序，拼车订单优先派给可加入的拼车司机
 	sort.Slice(eligible_drivers, func(i, j int) bool {
+		if eligible_drivers[i].PoolMatched != eligible_drivers[j].PoolMatched {
+			return eligible_drivers[i].PoolMatched
+		}
 		return eligible_drivers[i].FinalScore > eligible_drivers[j].FinalScore
 	})
 
@@ -144,13 +147,15 @@ func (s *DispatchService) EvaluateDriverForOrder(rt *protocol.DriverRuntime, ord
 		driver.RejectReason = "Driver not available"
 		return
 	}
-	// 2. Mandatory: must have no active ride (one ride at a time)
-	if rt.HasCurrentOrder() {
+	// 2. Mandatory: must have no active ride (one ride at a time), unless a pool order fits the driver's open pool
+	poolMatched := GetRidePoolService().CanJoinDriverPool(rt, order)
+	if rt.HasCurrentOrder() && !poolMatched {
 		driver.RejectReason = "Driver has active ride"
 		return
 	}
-	// 3. Optional: queue capacity (if configured)
-	if !rt.CanAcceptMoreOrders() {
+	driver.PoolMatched = poolMatched
+	// 3. Optional: queue capacity (if configured); seats of a matched pool are checked by the pool plan
+	if !poolMatched && !rt.CanAcceptMoreOrders() {
 		driver.RejectReason = "Driver queue is full"
 		return
 	}
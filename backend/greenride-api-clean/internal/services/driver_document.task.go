@@ -0,0 +1,44 @@
+package services
+
+import (
+	"context"
+
+	"greenride/internal/log"
+	"greenride/internal/models"
+	"greenride/internal/protocol"
+	"greenride/internal/task"
+)
+
+const (
+	// 证件到期巡检任务常量
+	TaskDocumentExpiryMonitor = "document_expiry_monitor"
+)
+
+// InitDocumentTaskHandlers 初始化司机证件相关任务处理器
+func InitDocumentTaskHandlers() {
+	task.RegisterHandler(TaskDocumentExpiryMonitor, DocumentExpiryMonitorHandler)
+
+	documentExpiryTask := &models.Task{
+		TaskID:     "document_expiry_monitor_scheduler",
+		Name:       "司机证件到期巡检",
+		Type:       "document",
+		HandlerKey: TaskDocumentExpiryMonitor,
+		Cron:       "0 6 * * *", // 每天6点执行
+		Status:     protocol.TaskStatusEnabled,
+		MaxRetries: 1,
+		Timeout:    600,
+		Remark:     "证件到期前30/7/1天提醒司机，到期后停用司机或车辆",
+	}
+	task.InitTasks([]*models.Task{documentExpiryTask})
+}
+
+// DocumentExpiryMonitorHandler 证件到期提醒与自动停用
+func DocumentExpiryMonitorHandler(ctx context.Context, params protocol.MapData) error {
+	warned, expired, err := GetDriverDocumentService().CheckDocumentExpiry(ctx)
+	if err != nil {
+		log.Get().Errorf("证件到期巡检失败: %v", err)
+		return err
+	}
+	log.Get().Infof("证件到期巡检完成，提醒 %d 份，到期处理 %d 份", warned, expired)
+	return nil
+}
@@ -0,0 +1,683 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"greenride/internal/config"
+	"greenride/internal/log"
+	"greenride/internal/models"
+	"greenride/internal/protocol"
+	"greenride/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+// 证件图片面
+const (
+	DocumentSideFront  = "front"
+	DocumentSideBack   = "back"
+	DocumentSideSelfie = "selfie"
+)
+
+// DriverDocumentService 司机证件上传与审核服务
+type DriverDocumentService struct {
+}
+
+var (
+	driverDocumentInstance *DriverDocumentService
+	driverDocumentOnce     sync.Once
+)
+
+func GetDriverDocumentService() *DriverDocumentService {
+	driverDocumentOnce.Do(func() {
+		SetupDriverDocumentService()
+	})
+	return driverDocumentInstance
+}
+
+func SetupDriverDocumentService() {
+	driverDocumentInstance = &DriverDocumentService{}
+}
+
+// DocumentFile 待上传的证件图片
+type DocumentFile struct {
+	Reader    io.Reader
+	Extension string
+}
+
+// UploadDocumentRequest 司机上传证件请求
+type UploadDocumentRequest struct {
+	UserID         string
+	DocumentType   string
+	DocumentNumber string
+	ExpiresAt      int64                    // 证件过期时间(毫秒)，可选
+	Files          map[string]*DocumentFile // front必填，back/selfie可选
+}
+
+func (s *DriverDocumentService) kycConfig() *config.KYCConfig {
+	if cfg := config.Get(); cfg != nil && cfg.KYC != nil {
+		return cfg.KYC
+	}
+	cfg := &config.KYCConfig{}
+	cfg.Validate()
+	return cfg
+}
+
+// UploadDocument 上传证件图片到S3并提交审核，同类型待审核的旧证件会被取代
+func (s *DriverDocumentService) UploadDocument(ctx context.Context, req *UploadDocumentRequest) (*protocol.DriverDocument, protocol.ErrorCode) {
+	if !s.kycConfig().IsSupportedDocument(req.DocumentType) {
+		return nil, protocol.InvalidDocumentType
+	}
+	if req.Files[DocumentSideFront] == nil {
+		return nil, protocol.MissingParams
+	}
+	awsService, available := GetAWSServiceSafe()
+	if !available {
+		return nil, protocol.ServiceUnavail
+	}
+
+	identity := models.NewDriverDocument(req.UserID, req.DocumentType)
+	urls := make(map[string]string)
+	for _, side := range []string{DocumentSideFront, DocumentSideBack, DocumentSideSelfie} {
+		file := req.Files[side]
+		if file == nil {
+			continue
+		}
+		contentType := mime.TypeByExtension(file.Extension)
+		if contentType == "" {
+			contentType = "image/jpeg"
+		}
+		objectKey := fmt.Sprintf("kyc/%s/%s/%s_%s%s", req.UserID, req.DocumentType, identity.IdentityID, side, file.Extension)
+		url, err := awsService.UploadFromReader(ctx, file.Reader, objectKey, contentType)
+		if err != nil {
+			log.Get().Errorf("upload %s %s for driver %s failed: %v", req.DocumentType, side, req.UserID, err)
+			return nil, protocol.DocumentUploadFailed
+		}
+		urls[side] = url
+	}
+	identity.SetImageURLs(urls[DocumentSideFront], urls[DocumentSideBack], urls[DocumentSideSelfie])
+	if req.DocumentNumber != "" {
+		identity.IDNumber = utils.StringPtr(req.DocumentNumber)
+	}
+	if req.ExpiresAt > 0 {
+		identity.SetExpiryDate(req.ExpiresAt)
+	}
+	identity.NextStep()
+
+	err := models.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Identity{}).
+			Where("user_id = ? AND id_type = ? AND status = ?", req.UserID, req.DocumentType, models.IdentityStatusPending).
+			Update("status", models.IdentityStatusReplaced).Error; err != nil {
+			return err
+		}
+		return tx.Create(identity).Error
+	})
+	if err != nil {
+		log.Get().Errorf("save %s for driver %s failed: %v", req.DocumentType, req.UserID, err)
+		return nil, protocol.DatabaseError
+	}
+	return s.toProtocol(identity, utils.TimeNowMilli()), protocol.Success
+}
+
+// GetDriverDocumentStatus 获取司机各类证件最新记录及是否满足上线要求
+func (s *DriverDocumentService) GetDriverDocumentStatus(userID string) (*protocol.DriverDocumentStatus, protocol.ErrorCode) {
+	identities, err := s.listDocuments(userID)
+	if err != nil {
+		return nil, protocol.DatabaseError
+	}
+	return s.evaluate(identities, utils.TimeNowMilli()), protocol.Success
+}
+
+// listDocuments 司机未被取代的证件记录，按创建时间倒序
+func (s *DriverDocumentService) listDocuments(userID string) ([]*models.Identity, error) {
+	var identities []*models.Identity
+	err := models.GetDB().
+		Where("user_id = ? AND status <> ?", userID, models.IdentityStatusReplaced).
+		Order("created_at DESC").
+		Find(&identities).Error
+	return identities, err
+}
+
+// CheckDriverCanGoOnline 检查司机证件是否满足上线要求
+func (s *DriverDocumentService) CheckDriverCanGoOnline(userID string) protocol.ErrorCode {
+	if !s.kycConfig().IsEnforced() {
+		return protocol.Success
+	}
+	status, errCode := s.GetDriverDocumentStatus(userID)
+	if errCode != protocol.Success {
+		return errCode
+	}
+	if !status.Compliant {
+		return protocol.DriverDocumentsIncomplete
+	}
+	return protocol.Success
+}
+
+// evaluate 按证件类型取最新记录（按创建时间倒序传入）并汇总审核状态
+// 重新上传的证件待审核或被拒绝时，仍有效的已通过旧证件继续生效，续期不影响司机上线
+func (s *DriverDocumentService) evaluate(identities []*models.Identity, now int64) *protocol.DriverDocumentStatus {
+	required := s.kycConfig().RequiredDocuments
+	status := &protocol.DriverDocumentStatus{
+		Required:  required,
+		Missing:   []string{},
+		Pending:   []string{},
+		Rejected:  []string{},
+		Expired:   []string{},
+		Documents: []*protocol.DriverDocument{},
+	}
+
+	latest := make(map[string]*models.Identity)
+	valid := make(map[string]*models.Identity)
+	for _, identity := range identities {
+		docType := identity.GetIDType()
+		if _, ok := valid[docType]; !ok && identity.IsApproved() && !identity.IsExpiredAt(now) {
+			valid[docType] = identity
+		}
+		if _, ok := latest[docType]; ok {
+			continue
+		}
+		latest[docType] = identity
+		status.Documents = append(status.Documents, s.toProtocol(identity, now))
+	}
+
+	for _, docType := range required {
+		identity, ok := latest[docType]
+		switch {
+		case !ok:
+			status.Missing = append(status.Missing, docType)
+		case valid[docType] != nil:
+			// 有仍有效的已通过证件
+		case identity.IsExpiredAt(now):
+			status.Expired = append(status.Expired, docType)
+		case identity.IsPending():
+			status.Pending = append(status.Pending, docType)
+		case identity.IsRejected():
+			status.Rejected = append(status.Rejected, docType)
+		}
+	}
+	status.Compliant = len(status.Missing)+len(status.Pending)+len(status.Rejected)+len(status.Expired) == 0
+	return status
+}
+
+// lapsedDocumentTypes 曾审核通过但已过期、且没有仍有效的已通过证件的类型，待审核的续期证件不算有效
+func lapsedDocumentTypes(identities []*models.Identity, now int64) []string {
+	lapsed := []string{}
+	valid := make(map[string]bool)
+	expired := make(map[string]bool)
+	for _, identity := range identities {
+		docType := identity.GetIDType()
+		switch {
+		case identity.IsApproved() && !identity.IsExpiredAt(now):
+			valid[docType] = true
+		case identity.IsApproved() || identity.IsExpired():
+			if !expired[docType] {
+				expired[docType] = true
+				lapsed = append(lapsed, docType)
+			}
+		}
+	}
+	return slices.DeleteFunc(lapsed, func(docType string) bool { return valid[docType] })
+}
+
+// SearchDocuments 管理端证件审核队列
+func (s *DriverDocumentService) SearchDocuments(req *protocol.DocumentSearchRequest) ([]*protocol.DriverDocument, int64, protocol.ErrorCode) {
+	query := models.GetDB().Model(&models.Identity{}).Where("user_type = ?", protocol.UserTypeDriver)
+	if req.Status != "" {
+		query = query.Where("status = ?", req.Status)
+	}
+	if req.DocumentType != "" {
+		query = query.Where("id_type = ?", req.DocumentType)
+	}
+	if req.UserID != "" {
+		query = query.Where("user_id = ?", req.UserID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, protocol.DatabaseError
+	}
+	var identities []*models.Identity
+	offset := (req.Page - 1) * req.Limit
+	// 待审核按提交先后处理
+	if err := query.Order("created_at ASC").Offset(offset).Limit(req.Limit).Find(&identities).Error; err != nil {
+		return nil, 0, protocol.DatabaseError
+	}
+
+	now := utils.TimeNowMilli()
+	list := make([]*protocol.DriverDocument, 0, len(identities))
+	for _, identity := range identities {
+		doc := s.toProtocol(identity, now)
+		if driver := models.GetUserByID(identity.GetUserID()); driver != nil {
+			doc.Driver = driver.Protocol()
+		}
+		list = append(list, doc)
+	}
+	return list, total, protocol.Success
+}
+
+// GetDocument 获取证件详情
+func (s *DriverDocumentService) GetDocument(identityID string) (*protocol.DriverDocument, protocol.ErrorCode) {
+	identity := s.findIdentity(identityID)
+	if identity == nil {
+		return nil, protocol.DocumentNotFound
+	}
+	doc := s.toProtocol(identity, utils.TimeNowMilli())
+	if driver := models.GetUserByID(identity.GetUserID()); driver != nil {
+		doc.Driver = driver.Protocol()
+	}
+	return doc, protocol.Success
+}
+
+// ApproveDocument 审核通过证件
+func (s *DriverDocumentService) ApproveDocument(req *protocol.DocumentApproveRequest, adminID string) protocol.ErrorCode {
+	identity := s.findIdentity(req.IdentityID)
+	if identity == nil {
+		return protocol.DocumentNotFound
+	}
+	if !identity.IsPending() {
+		return protocol.DocumentAlreadyReviewed
+	}
+	values := &models.IdentityValues{}
+	values.ApproveIdentity(adminID)
+	if req.ExpiresAt > 0 {
+		values.SetExpiryDate(req.ExpiresAt)
+	}
+	if req.Comment != "" {
+		values.ReviewComment = utils.StringPtr(req.Comment)
+	}
+	if errCode := s.review(identity, values); errCode != protocol.Success {
+		return errCode
+	}
+	// 因证件过期被停用的司机，新证件审核通过且证件齐全后自动恢复
+	s.reinstateDriver(identity.GetUserID())
+	return protocol.Success
+}
+
+// RejectDocument 拒绝证件，拒绝原因会展示给司机
+func (s *DriverDocumentService) RejectDocument(req *protocol.DocumentRejectRequest, adminID string) protocol.ErrorCode {
+	identity := s.findIdentity(req.IdentityID)
+	if identity == nil {
+		return protocol.DocumentNotFound
+	}
+	if !identity.IsPending() {
+		return protocol.DocumentAlreadyReviewed
+	}
+	values := &models.IdentityValues{}
+	values.RejectIdentity(adminID, req.Reason)
+	if req.Comment != "" {
+		values.ReviewComment = utils.StringPtr(req.Comment)
+	}
+	return s.review(identity, values)
+}
+
+func (s *DriverDocumentService) review(identity *models.Identity, values *models.IdentityValues) protocol.ErrorCode {
+	result := models.GetDB().Model(&models.Identity{}).
+		Where("identity_id = ? AND status = ?", identity.IdentityID, models.IdentityStatusPending).
+		Updates(values)
+	if result.Error != nil {
+		log.Get().Errorf("review document %s failed: %v", identity.IdentityID, result.Error)
+		return protocol.DatabaseError
+	}
+	if result.RowsAffected == 0 {
+		return protocol.DocumentAlreadyReviewed
+	}
+	return protocol.Success
+}
+
+func (s *DriverDocumentService) findIdentity(identityID string) *models.Identity {
+	var identity models.Identity
+	if err := models.GetDB().Where("identity_id = ?", identityID).First(&identity).Error; err != nil {
+		return nil
+	}
+	return &identity
+}
+
+func (s *DriverDocumentService) toProtocol(identity *models.Identity, now int64) *protocol.DriverDocument {
+	doc := &protocol.DriverDocument{
+		IdentityID:     identity.IdentityID,
+		UserID:         identity.GetUserID(),
+		DocumentType:   identity.GetIDType(),
+		DocumentNumber: identity.GetIDNumber(),
+		Status:         identity.GetStatus(),
+		ExpiresAt:      identity.GetExpiresAt(),
+		IsExpired:      identity.IsExpiredAt(now),
+		RejectReason:   identity.GetRejectReason(),
+		CreatedAt:      identity.CreatedAt,
+		UpdatedAt:      identity.UpdatedAt,
+	}
+	if identity.FrontImageURL != nil {
+		doc.FrontImageURL = *identity.FrontImageURL
+	}
+	if identity.BackImageURL != nil {
+		doc.BackImageURL = *identity.BackImageURL
+	}
+	if identity.SelfieURL != nil {
+		doc.SelfieURL = *identity.SelfieURL
+	}
+	if identity.ReviewComment != nil {
+		doc.ReviewComment = *identity.ReviewComment
+	}
+	if identity.ReviewedBy != nil {
+		doc.ReviewedBy = *identity.ReviewedBy
+	}
+	if identity.ReviewedAt != nil {
+		doc.ReviewedAt = *identity.ReviewedAt
+	}
+	return doc
+}
+
+// expiryWarningThreshold 返回剩余天数对应的提醒档位（不小于剩余天数的最小档位），不需要提醒时返回0
+func expiryWarningThreshold(daysLeft int, thresholds []int) int {
+	threshold := 0
+	for _, days := range thresholds {
+		if days >= daysLeft && (threshold == 0 || days < threshold) {
+			threshold = days
+		}
+	}
+	return threshold
+}
+
+// daysUntil 距离到期的剩余天数，不足一天按一天计算，已过期返回负数或0
+func daysUntil(expiresAt, now int64) int {
+	diff := expiresAt - now
+	if diff <= 0 {
+		return int(diff / dayMillis)
+	}
+	return int((diff + dayMillis - 1) / dayMillis)
+}
+
+const dayMillis = int64(24 * time.Hour / time.Millisecond)
+
+// ListExpiringDocuments 查询未来days天内到期（含已过期未处理）的司机和车辆证件，按到期时间升序
+func (s *DriverDocumentService) ListExpiringDocuments(days int) ([]*protocol.ExpiringDocument, protocol.ErrorCode) {
+	if days <= 0 {
+		days = s.kycConfig().MaxExpiryWarningDays()
+	}
+	now := utils.TimeNowMilli()
+	docs, err := s.collectExpiringDocuments(now, now+int64(days)*dayMillis)
+	if err != nil {
+		log.Get().Errorf("list expiring documents failed: %v", err)
+		return nil, protocol.DatabaseError
+	}
+
+	driverIDs := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		driverIDs = append(driverIDs, doc.DriverID)
+	}
+	var users []*models.User
+	if len(driverIDs) > 0 {
+		if err := models.GetDB().Where("user_id IN ?", driverIDs).Find(&users).Error; err != nil {
+			log.Get().Warnf("load drivers for expiring documents failed: %v", err)
+		}
+	}
+	names := make(map[string]string, len(users))
+	for _, user := range users {
+		names[user.UserID] = user.GetFullName()
+	}
+	for _, doc := range docs {
+		doc.DriverName = names[doc.DriverID]
+	}
+	return docs, protocol.Success
+}
+
+// collectExpiringDocuments 收集到期时间不晚于horizon的有效证件
+// 已审核通过的司机证件如果已有同类型的新证件（待审核或已通过，已过期的只看已通过）则不再计入
+func (s *DriverDocumentService) collectExpiringDocuments(now, horizon int64) ([]*protocol.ExpiringDocument, error) {
+	var identities []*models.Identity
+	if err := models.GetDB().
+		Where("user_type = ? AND status = ?", protocol.UserTypeDriver, models.IdentityStatusApproved).
+		Where("expires_at > 0 AND expires_at <= ?", horizon).
+		Find(&identities).Error; err != nil {
+		return nil, err
+	}
+
+	docs := make([]*protocol.ExpiringDocument, 0, len(identities))
+	for _, identity := range identities {
+		// 已过期的证件只有新证件审核通过才算续期，新证件待审核期间照常停用
+		renewalStatuses := []string{models.IdentityStatusPending, models.IdentityStatusApproved}
+		if identity.GetExpiresAt() <= now {
+			renewalStatuses = []string{models.IdentityStatusApproved}
+		}
+		if s.hasRenewal(identity, renewalStatuses) {
+			continue
+		}
+		docs = append(docs, &protocol.ExpiringDocument{
+			Source:       protocol.ExpiringSourceIdentity,
+			DocumentID:   identity.IdentityID,
+			DocumentType: identity.GetIDType(),
+			DriverID:     identity.GetUserID(),
+			ExpiresAt:    identity.GetExpiresAt(),
+			DaysLeft:     daysUntil(identity.GetExpiresAt(), now),
+		})
+	}
+
+	var vehicles []*models.Vehicle
+	if err := models.GetDB().
+		Where("driver_id IS NOT NULL AND driver_id <> ''").
+		Where("status <> ?", protocol.StatusRetired).
+		Where("(insurance_expiry > 0 AND insurance_expiry <= ?) OR (registration_expiry > 0 AND registration_expiry <= ?) OR (inspection_expiry > 0 AND inspection_expiry <= ?)",
+			horizon, horizon, horizon).
+		Find(&vehicles).Error; err != nil {
+		return nil, err
+	}
+	for _, vehicle := range vehicles {
+		expiries := []struct {
+			docType   string
+			expiresAt int64
+		}{
+			{config.DocumentTypeInsurance, vehicle.GetInsuranceExpiry()},
+			{config.DocumentTypeVehicleRegistration, vehicle.GetRegistrationExpiry()},
+			{config.DocumentTypeVehicleInspection, vehicle.GetInspectionExpiry()},
+		}
+		for _, item := range expiries {
+			if item.expiresAt <= 0 || item.expiresAt > horizon {
+				continue
+			}
+			docs = append(docs, &protocol.ExpiringDocument{
+				Source:       protocol.ExpiringSourceVehicle,
+				DocumentID:   vehicle.VehicleID,
+				DocumentType: item.docType,
+				DriverID:     vehicle.GetDriverID(),
+				VehicleID:    vehicle.VehicleID,
+				PlateNumber:  vehicle.GetPlateNumber(),
+				ExpiresAt:    item.expiresAt,
+				DaysLeft:     daysUntil(item.expiresAt, now),
+			})
+		}
+	}
+
+	sort.SliceStable(docs, func(i, j int) bool {
+		return docs[i].ExpiresAt < docs[j].ExpiresAt
+	})
+	return docs, nil
+}
+
+// hasRenewal 司机是否已上传同类型且处于指定状态的新证件
+func (s *DriverDocumentService) hasRenewal(identity *models.Identity, statuses []string) bool {
+	var count int64
+	models.GetDB().Model(&models.Identity{}).
+		Where("user_id = ? AND id_type = ? AND id > ?", identity.GetUserID(), identity.GetIDType(), identity.ID).
+		Where("status IN ?", statuses).
+		Count(&count)
+	return count > 0
+}
+
+// CheckDocumentExpiry 证件到期巡检：到期前按配置档位提醒司机，到期后停用司机或车辆
+func (s *DriverDocumentService) CheckDocumentExpiry(ctx context.Context) (warned, expired int, err error) {
+	cfg := s.kycConfig()
+	now := utils.TimeNowMilli()
+	docs, err := s.collectExpiringDocuments(now, now+int64(cfg.MaxExpiryWarningDays())*dayMillis)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for _, doc := range docs {
+		if ctx.Err() != nil {
+			return warned, expired, ctx.Err()
+		}
+		if doc.ExpiresAt <= now {
+			if s.expireDocument(doc) {
+				expired++
+			}
+			continue
+		}
+		threshold := expiryWarningThreshold(doc.DaysLeft, cfg.ExpiryWarningDays)
+		if threshold == 0 || !s.claimExpiryWarning(doc, threshold) {
+			continue
+		}
+		if err := s.notifyDocumentExpiry(doc, protocol.NotificationTypeDocumentExpiring); err != nil {
+			log.Get().Warnf("notify driver %s document %s expiring failed: %v", doc.DriverID, doc.DocumentType, err)
+			continue
+		}
+		warned++
+	}
+	return warned, expired, nil
+}
+
+// claimExpiryWarning 同一证件同一档位只提醒一次，Redis不可用时仅在剩余天数恰好等于档位时提醒
+func (s *DriverDocumentService) claimExpiryWarning(doc *protocol.ExpiringDocument, threshold int) bool {
+	if models.Redis == nil {
+		return doc.DaysLeft == threshold
+	}
+	key := models.FormatCacheKey("doc_expiry:warn:%s:%s:%d:%d", doc.DocumentID, doc.DocumentType, doc.ExpiresAt, threshold)
+	ok, err := models.SetNX(key, 1, time.Duration(threshold+1)*24*time.Hour)
+	if err != nil {
+		log.Get().Warnf("claim expiry warning %s failed: %v", key, err)
+		return doc.DaysLeft == threshold
+	}
+	return ok
+}
+
+// expireDocument 处理已到期证件，返回是否由本次巡检完成处理
+func (s *DriverDocumentService) expireDocument(doc *protocol.ExpiringDocument) bool {
+	db := models.GetDB()
+	switch doc.Source {
+	case protocol.ExpiringSourceIdentity:
+		result := db.Model(&models.Identity{}).
+			Where("identity_id = ? AND status = ?", doc.DocumentID, models.IdentityStatusApproved).
+			Update("status", models.IdentityStatusExpired)
+		if result.Error != nil {
+			log.Get().Errorf("expire document %s failed: %v", doc.DocumentID, result.Error)
+			return false
+		}
+		if result.RowsAffected == 0 {
+			return false
+		}
+		// 证件过期停用不依赖上线校验开关，enforce关闭时过期的驾照、保险同样不能继续接单
+		if s.kycConfig().IsExpirySuspendEnabled() {
+			identities, err := s.listDocuments(doc.DriverID)
+			if err != nil {
+				log.Get().Errorf("load documents of driver %s failed: %v", doc.DriverID, err)
+			} else if slices.Contains(lapsedDocumentTypes(identities, utils.TimeNowMilli()), doc.DocumentType) {
+				s.suspendDriver(doc.DriverID)
+			}
+		}
+	case protocol.ExpiringSourceVehicle:
+		result := db.Model(&models.Vehicle{}).
+			Where("vehicle_id = ? AND status = ?", doc.VehicleID, protocol.StatusActive).
+			Update("status", protocol.StatusSuspended)
+		if result.Error != nil {
+			log.Get().Errorf("suspend vehicle %s failed: %v", doc.VehicleID, result.Error)
+			return false
+		}
+		if result.RowsAffected == 0 {
+			return false
+		}
+		log.Get().Infof("vehicle %s suspended: %s expired", doc.VehicleID, doc.DocumentType)
+	default:
+		return false
+	}
+
+	if err := s.notifyDocumentExpiry(doc, protocol.NotificationTypeDocumentExpired); err != nil {
+		log.Get().Warnf("notify driver %s document %s expired failed: %v", doc.DriverID, doc.DocumentType, err)
+	}
+	return true
+}
+
+// suspendDriver 因证件过期停用司机并强制下线
+func (s *DriverDocumentService) suspendDriver(driverID string) {
+	values := &models.UserValues{}
+	values.SetStatus(protocol.StatusSuspended).
+		SetSuspendReason(models.SuspendReasonDocumentExpired).
+		SetOnlineStatus(protocol.StatusOffline)
+	result := models.GetDB().Model(&models.User{}).
+		Where("user_id = ? AND status = ?", driverID, protocol.StatusActive).
+		UpdateColumns(values)
+	if result.Error != nil {
+		log.Get().Errorf("suspend driver %s failed: %v", driverID, result.Error)
+		return
+	}
+	if result.RowsAffected > 0 {
+		log.Get().Infof("driver %s suspended: documents expired", driverID)
+		GetUserService().RefreshDriverRuntimeCache(driverID)
+	}
+}
+
+// reinstateDriver 证件重新齐全后恢复因证件过期被停用的司机
+func (s *DriverDocumentService) reinstateDriver(driverID string) {
+	user := models.GetUserByID(driverID)
+	if user == nil || !user.IsSuspendedForDocuments() {
+		return
+	}
+	identities, err := s.listDocuments(driverID)
+	if err != nil {
+		return
+	}
+	// 过期证件都已有审核通过的新证件；开启上线校验时还需证件齐全
+	now := utils.TimeNowMilli()
+	if len(lapsedDocumentTypes(identities, now)) > 0 {
+		return
+	}
+	if s.kycConfig().IsEnforced() && !s.evaluate(identities, now).Compliant {
+		return
+	}
+	values := &models.UserValues{}
+	values.SetStatus(protocol.StatusActive).SetSuspendReason("")
+	result := models.GetDB().Model(&models.User{}).
+		Where("user_id = ? AND status = ? AND suspend_reason = ?", driverID, protocol.StatusSuspended, models.SuspendReasonDocumentExpired).
+		UpdateColumns(values)
+	if result.Error != nil {
+		log.Get().Errorf("reinstate driver %s failed: %v", driverID, result.Error)
+		return
+	}
+	if result.RowsAffected > 0 {
+		log.Get().Infof("driver %s reinstated after document approval", driverID)
+	}
+}
+
+// notifyDocumentExpiry 通过消息服务推送证件到期提醒
+func (s *DriverDocumentService) notifyDocumentExpiry(doc *protocol.ExpiringDocument, notificationType string) error {
+	driver := models.GetUserByID(doc.DriverID)
+	if driver == nil {
+		return fmt.Errorf("driver %s not found", doc.DriverID)
+	}
+
+	msgType := protocol.MsgTypeDriverDocumentExpiring
+	if notificationType == protocol.NotificationTypeDocumentExpired {
+		msgType = protocol.MsgTypeDriverDocumentExpired
+	}
+	message := &Message{
+		Type:     msgType,
+		Channels: []string{protocol.MsgChannelFcm},
+		Params: map[string]any{
+			"to":                doc.DriverID,
+			"DocumentType":      strings.ReplaceAll(doc.DocumentType, "_", " "),
+			"ExpiryDate":        utils.MilliToTime(doc.ExpiresAt).Format(time.DateOnly),
+			"DaysLeft":          doc.DaysLeft,
+			"PlateNumber":       doc.PlateNumber,
+			"document_type":     doc.DocumentType,
+			"msg_type":          protocol.FCMMessageTypeDriver,
+			"notification_type": notificationType,
+		},
+		Language: getUserLanguage(driver),
+	}
+	return GetUserNotificationService().Deliver(driver, message)
+}
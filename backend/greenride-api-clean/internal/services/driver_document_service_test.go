@@ -0,0 +1,120 @@
+package services
+
+import (
+	"slices"
+	"testing"
+
+	"greenride/internal/config"
+	"greenride/internal/models"
+)
+
+func newTestDocument(docType, status string, expiresAt int64) *models.Identity {
+	identity := models.NewDriverDocument("D1", docType)
+	identity.SetStatus(status)
+	if expiresAt > 0 {
+		identity.SetExpiryDate(expiresAt)
+	}
+	return identity
+}
+
+func TestEvaluateDriverDocuments(t *testing.T) {
+	now := int64(1_700_000_000_000)
+	s := &DriverDocumentService{}
+
+	approved := []*models.Identity{
+		newTestDocument(config.DocumentTypeDriverLicense, models.IdentityStatusApproved, now+1000),
+		newTestDocument(config.DocumentTypeNationalID, models.IdentityStatusApproved, 0),
+		newTestDocument(config.DocumentTypeInsurance, models.IdentityStatusApproved, now+1000),
+		newTestDocument(config.DocumentTypeVehicleRegistration, models.IdentityStatusApproved, now+1000),
+	}
+	if status := s.evaluate(approved, now); !status.Compliant {
+		t.Fatalf("all approved documents should be compliant: %+v", status)
+	}
+
+	// 续期的保险待审核或被拒绝时，仍有效的已通过旧证件继续生效，最新记录仍展示给司机
+	for _, renewalStatus := range []string{models.IdentityStatusPending, models.IdentityStatusRejected} {
+		withRenewal := append([]*models.Identity{
+			newTestDocument(config.DocumentTypeInsurance, renewalStatus, now+5000),
+		}, approved...)
+		status := s.evaluate(withRenewal, now)
+		if !status.Compliant {
+			t.Fatalf("%s renewal should not block a valid approved insurance: %+v", renewalStatus, status)
+		}
+		if len(status.Documents) != 4 || status.Documents[0].Status != renewalStatus {
+			t.Fatalf("latest insurance should be listed: %+v", status.Documents)
+		}
+	}
+
+	// 旧证件已过期时以待审核的新证件为准
+	lapsed := []*models.Identity{
+		newTestDocument(config.DocumentTypeInsurance, models.IdentityStatusPending, now+5000),
+		newTestDocument(config.DocumentTypeInsurance, models.IdentityStatusApproved, now-1),
+	}
+	status := s.evaluate(append(lapsed, approved[0], approved[1], approved[3]), now)
+	if status.Compliant || len(status.Pending) != 1 || status.Pending[0] != config.DocumentTypeInsurance {
+		t.Fatalf("pending insurance should block once the approved one expired: %+v", status)
+	}
+
+	expired := []*models.Identity{
+		newTestDocument(config.DocumentTypeDriverLicense, models.IdentityStatusApproved, now-1),
+		newTestDocument(config.DocumentTypeNationalID, models.IdentityStatusRejected, 0),
+	}
+	status = s.evaluate(expired, now)
+	if status.Compliant {
+		t.Fatal("expired license should not be compliant")
+	}
+	if len(status.Expired) != 1 || len(status.Rejected) != 1 || len(status.Missing) != 2 {
+		t.Fatalf("unexpected summary: expired=%v rejected=%v missing=%v", status.Expired, status.Rejected, status.Missing)
+	}
+}
+
+func TestLapsedDocumentTypes(t *testing.T) {
+	now := int64(1_700_000_000_000)
+	identities := []*models.Identity{
+		// 驾照过期，续期待审核：仍视为过期
+		newTestDocument(config.DocumentTypeDriverLicense, models.IdentityStatusPending, now+5000),
+		newTestDocument(config.DocumentTypeDriverLicense, models.IdentityStatusExpired, now-1000),
+		// 保险过期后新证件已通过：已续期
+		newTestDocument(config.DocumentTypeInsurance, models.IdentityStatusApproved, now+5000),
+		newTestDocument(config.DocumentTypeInsurance, models.IdentityStatusApproved, now-1000),
+		// 行驶证已到期但巡检尚未处理
+		newTestDocument(config.DocumentTypeVehicleRegistration, models.IdentityStatusApproved, now),
+		// 从未通过的证件不算过期
+		newTestDocument(config.DocumentTypeNationalID, models.IdentityStatusRejected, now-1000),
+	}
+	got := lapsedDocumentTypes(identities, now)
+	want := []string{config.DocumentTypeDriverLicense, config.DocumentTypeVehicleRegistration}
+	if !slices.Equal(got, want) {
+		t.Fatalf("lapsedDocumentTypes = %v, want %v", got, want)
+	}
+}
+
+func TestExpiryWarningThreshold(t *testing.T) {
+	thresholds := []int{30, 7, 1}
+	cases := map[int]int{
+		45: 0,
+		30: 30,
+		12: 30,
+		7:  7,
+		2:  7,
+		1:  1,
+	}
+	for daysLeft, want := range cases {
+		if got := expiryWarningThreshold(daysLeft, thresholds); got != want {
+			t.Errorf("expiryWarningThreshold(%d) = %d, want %d", daysLeft, got, want)
+		}
+	}
+}
+
+func TestDaysUntil(t *testing.T) {
+	now := int64(1_700_000_000_000)
+	if got := daysUntil(now+dayMillis, now); got != 1 {
+		t.Errorf("exactly one day left = %d, want 1", got)
+	}
+	if got := daysUntil(now+dayMillis+1, now); got != 2 {
+		t.Errorf("just over one day left = %d, want 2", got)
+	}
+	if got := daysUntil(now-2*dayMillis, now); got != -2 {
+		t.Errorf("expired two days ago = %d, want -2", got)
+	}
+}
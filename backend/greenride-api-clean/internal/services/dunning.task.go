@@ -0,0 +1,42 @@
+package services
+
+import (
+	"context"
+
+	"greenride/internal/log"
+	"greenride/internal/models"
+	"greenride/internal/protocol"
+	"greenride/internal/task"
+)
+
+const (
+	// 车费催收任务常量
+	TaskFareDunning = "fare_dunning"
+)
+
+// InitDunningTaskHandlers 初始化车费催收任务处理器
+func InitDunningTaskHandlers() {
+	task.RegisterHandler(TaskFareDunning, FareDunningHandler)
+
+	dunningTask := &models.Task{
+		TaskID:     "fare_dunning_scheduler",
+		Name:       "行程车费催收",
+		Type:       "payment",
+		HandlerKey: TaskFareDunning,
+		Cron:       "*/5 * * * *", // 每5分钟执行
+		Status:     protocol.TaskStatusEnabled,
+		MaxRetries: 1,
+		Timeout:    600,
+		Remark:     "未支付的行程记为车费欠款，按退避间隔使用默认支付方式重试扣款并提醒乘客",
+	}
+	task.InitTasks([]*models.Task{dunningTask})
+}
+
+// FareDunningHandler 车费欠款自动重试扣款
+func FareDunningHandler(ctx context.Context, params protocol.MapData) error {
+	if err := GetDunningService().RunDunning(ctx); err != nil {
+		log.Get().Errorf("车费催收任务失败: %v", err)
+		return err
+	}
+	return nil
+}
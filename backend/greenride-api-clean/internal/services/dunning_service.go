@@ -0,0 +1,440 @@
+package services
+
+import (
+	"context"
+	"sync"
+
+	"greenride/internal/config"
+	"greenride/internal/log"
+	"greenride/internal/models"
+	"greenride/internal/protocol"
+	"greenride/internal/utils"
+
+	"github.com/shopspring/decimal"
+)
+
+// DunningService 行程车费催收：自动扣款失败或超过宽限期仍未支付的订单记为车费欠款，
+// 按配置的退避间隔使用默认支付方式重试扣款并提醒乘客，欠款超过上限时限制乘客下单
+type DunningService struct {
+	queryPayment func(payment *models.Payment) *protocol.ChannelResult // 向支付渠道查询支付状态
+}
+
+var (
+	dunningServiceInstance *DunningService
+	dunningServiceOnce     sync.Once
+)
+
+// GetDunningService 获取催收服务实例
+func GetDunningService() *DunningService {
+	dunningServiceOnce.Do(func() {
+		SetupDunningService()
+	})
+	return dunningServiceInstance
+}
+
+// SetupDunningService 初始化催收服务
+func SetupDunningService() {
+	dunningServiceInstance = &DunningService{queryPayment: queryChannelPayment}
+}
+
+// queryChannelPayment 通过支付记录的渠道账户查询渠道侧的支付状态
+func queryChannelPayment(payment *models.Payment) *protocol.ChannelResult {
+	router, errCode := GetPaymentService().GetChannelRouter(payment.GetChannelAccountID())
+	if errCode != protocol.Success {
+		return nil
+	}
+	return router.ChannelService.Status(payment)
+}
+
+// nextChargeAt 第attempts次扣款后的下次重试时间，重试次数用完返回0
+func nextChargeAt(attempts int, from int64, intervals []int) int64 {
+	if attempts < 1 || attempts > len(intervals) {
+		return 0
+	}
+	return from + int64(intervals[attempts-1])*60*1000
+}
+
+// overBlockThreshold 欠款超过上限时限制下单
+func overBlockThreshold(outstanding decimal.Decimal, threshold float64) bool {
+	return outstanding.GreaterThan(decimal.NewFromFloat(threshold))
+}
+
+// OpenFareDebt 把订单未收取的车费记为欠款，attempts为已发起的扣款次数（0表示尚未扣款，下次任务立即处理）
+func (s *DunningService) OpenFareDebt(order *models.Order, attempts int, reason string) *models.UserDebt {
+	if existing := models.GetUserDebtByOrder(order.OrderID, protocol.DebtTypeRideFare); existing != nil {
+		return existing
+	}
+	cfg := config.GetDunningConfig()
+	now := utils.TimeNowMilli()
+	next := now
+	if attempts > 0 {
+		next = nextChargeAt(attempts, now, cfg.RetryIntervals)
+	}
+
+	debt := models.NewUserDebt(order.GetUserID(), order.OrderID, protocol.DebtTypeRideFare)
+	debt.SetAmounts(order.GetPaymentAmount(), decimal.Zero, decimal.Zero, order.GetCurrency())
+	debt.DriverID = utils.StringPtr(order.GetProviderID())
+	debt.Attempts = &attempts
+	debt.NextChargeAt = &next
+	if reason != "" {
+		debt.LastError = utils.StringPtr(reason)
+	}
+	if err := models.CreateUserDebt(models.GetDB(), debt); err != nil {
+		// 并发创建时以唯一索引为准
+		if existing := models.GetUserDebtByOrder(order.OrderID, protocol.DebtTypeRideFare); existing != nil {
+			return existing
+		}
+		log.Get().Errorf("创建订单 %s 车费欠款失败: %v", order.OrderID, err)
+		return nil
+	}
+	log.Get().Infof("订单 %s 车费 %s %s 记为乘客 %s 欠款，已扣款 %d 次", order.OrderID, debt.GetAmount().String(), debt.GetCurrency(), debt.GetUserID(), attempts)
+	return debt
+}
+
+// RecordChargeFailure 订单扣款失败时记录车费欠款，首次失败通知乘客，已有欠款时只更新失败原因
+func (s *DunningService) RecordChargeFailure(orderID, reason string) {
+	order := models.GetOrderByID(orderID)
+	if order == nil || (order.GetOrderType() != protocol.RideOrder && order.GetOrderType() != protocol.DeliveryOrder) {
+		return
+	}
+	if debt := models.GetUserDebtByOrder(orderID, protocol.DebtTypeRideFare); debt != nil {
+		if debt.GetStatus() == protocol.DebtStatusOutstanding && reason != "" {
+			if _, err := models.TransitionUserDebtStatus(debt.DebtID, []string{protocol.DebtStatusOutstanding}, map[string]any{"last_error": reason}); err != nil {
+				log.Get().Errorf("更新车费欠款 %s 失败原因失败: %v", debt.DebtID, err)
+			}
+		}
+		return
+	}
+	if debt := s.OpenFareDebt(order, 1, reason); debt != nil {
+		s.notifyRider(debt, protocol.MsgTypePassengerPaymentFailed, protocol.NotificationTypePaymentFailed)
+	}
+}
+
+// SettleFareDebt 订单支付成功后结清车费欠款
+func (s *DunningService) SettleFareDebt(orderID, paymentMethod string) {
+	debt := models.GetUserDebtByOrder(orderID, protocol.DebtTypeRideFare)
+	if debt == nil || debt.GetStatus() != protocol.DebtStatusOutstanding {
+		return
+	}
+	ok, err := models.TransitionUserDebtStatus(debt.DebtID, []string{protocol.DebtStatusOutstanding}, map[string]any{
+		"status":         protocol.DebtStatusPaid,
+		"payment_method": paymentMethod,
+		"paid_at":        utils.TimeNowMilli(),
+		"next_charge_at": 0,
+	})
+	if err != nil {
+		log.Get().Errorf("结清车费欠款 %s 失败: %v", debt.DebtID, err)
+		return
+	}
+	if ok {
+		log.Get().Infof("订单 %s 已支付，车费欠款 %s 已结清", orderID, debt.DebtID)
+	}
+}
+
+// RunDunning 催收任务：先把超过宽限期仍未支付的订单记为欠款，再处理到期的重试
+func (s *DunningService) RunDunning(ctx context.Context) error {
+	cfg := config.GetDunningConfig()
+	now := utils.TimeNowMilli()
+
+	orderIDs, err := models.ListUncollectedOrderIDs(now-int64(cfg.GraceMinutes)*60*1000, cfg.BatchSize)
+	if err != nil {
+		return err
+	}
+	for _, orderID := range orderIDs {
+		if order := models.GetOrderByID(orderID); order != nil {
+			s.OpenFareDebt(order, 0, "")
+		}
+	}
+
+	debts, err := models.ListDueFareDebts(now, cfg.BatchSize)
+	if err != nil {
+		return err
+	}
+	for _, debt := range debts {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		s.retryCharge(debt, cfg)
+	}
+	if len(orderIDs) > 0 || len(debts) > 0 {
+		log.Get().Infof("车费催收任务完成：新增欠款订单 %d 个，重试欠款 %d 笔", len(orderIDs), len(debts))
+	}
+	return nil
+}
+
+// retryCharge 使用默认支付方式重试扣款，没有默认支付方式时只提醒乘客；重试用完后停止自动扣款
+func (s *DunningService) retryCharge(debt *models.UserDebt, cfg *config.DunningConfig) {
+	order := models.GetOrderByID(debt.GetOrderID())
+	if order == nil {
+		s.reschedule(debt, debt.GetAttempts(), 0, "order not found")
+		return
+	}
+	if order.GetPaymentStatus() == protocol.StatusSuccess {
+		s.SettleFareDebt(order.OrderID, order.GetPaymentMethod())
+		return
+	}
+
+	// 暂停自动扣款时不计入扣款次数，按第一档间隔再检查；现金收款确认后由上面的支付成功分支结清
+	if reason := chargePausedReason(order, config.GetSavedPaymentMethodConfig().IsAutoChargeEnabled()); reason != "" {
+		log.Get().Debugf("车费欠款 %s 暂不自动扣款: %s", debt.DebtID, reason)
+		s.reschedule(debt, debt.GetAttempts(), utils.TimeNowMilli()+int64(cfg.RetryIntervals[0])*60*1000, "")
+		return
+	}
+
+	// 上次扣款仍在处理中时OrderPayment会直接返回旧的支付记录，需先确认其结果；
+	// 暂不能重新扣款时不计入扣款次数，按第一档间隔再检查
+	if !s.releaseStalePayment(order) {
+		s.reschedule(debt, debt.GetAttempts(), utils.TimeNowMilli()+int64(cfg.RetryIntervals[0])*60*1000, "")
+		return
+	}
+
+	reason := "no default payment method"
+	if method := models.GetUserDefaultPaymentMethod(debt.GetUserID()); method != nil {
+		result, errCode := GetOrderService().OrderPayment(&protocol.OrderPaymentRequest{
+			OrderID:         order.OrderID,
+			UserID:          debt.GetUserID(),
+			PaymentMethodID: method.UserPaymentMethodID,
+		})
+		switch {
+		case errCode != protocol.Success:
+			reason = "[" + string(errCode) + "]" + errCode.GetMessage()
+		case result.Status == protocol.StatusSuccess:
+			// 支付成功时已在OrderPayment中结清欠款
+			return
+		case result.Status == protocol.StatusFailed:
+			reason = result.Reason
+		default:
+			// 扣款处理中，结果由支付回调更新
+			reason = ""
+		}
+	}
+
+	attempts := debt.GetAttempts() + 1
+	next := nextChargeAt(attempts, utils.TimeNowMilli(), cfg.RetryIntervals)
+	s.reschedule(debt, attempts, next, reason)
+	switch {
+	case next == 0:
+		s.notifyRider(debt, protocol.MsgTypePassengerPaymentOverdue, protocol.NotificationTypePaymentOverdue)
+	case reason != "":
+		s.notifyRider(debt, protocol.MsgTypePassengerPaymentFailed, protocol.NotificationTypePaymentFailed)
+	}
+}
+
+// chargePausedReason 返回不能自动扣款的原因：关闭了自动扣款，或乘客已改为现金支付等待司机确认收款
+func chargePausedReason(order *models.Order, autoCharge bool) string {
+	switch {
+	case !autoCharge:
+		return "auto charge disabled"
+	case order.GetPaymentMethod() == protocol.PaymentMethodCash:
+		return "cash payment pending"
+	}
+	return ""
+}
+
+// releaseStalePayment 处理订单遗留的处理中支付记录，返回是否可以重新发起扣款：
+// 渠道确认成功时按回调流程结清订单，确认失败或记录已过期时标记为失败后重新扣款；
+// 渠道仍在处理或无法查询时本轮不扣款，避免重复扣款
+func (s *DunningService) releaseStalePayment(order *models.Order) bool {
+	payment := models.GetNotFailedPaymentByOrderID(order.OrderID)
+	if payment == nil || payment.GetStatus() != protocol.StatusPending {
+		return true
+	}
+	if payment.GetChannelPaymentID() != "" {
+		result := s.queryPayment(payment)
+		if result == nil || result.ChannelPaymentID == "" {
+			log.Get().Warnf("订单 %s 的支付 %s 渠道状态查询失败，本轮不重新扣款", order.OrderID, payment.PaymentID)
+			return false
+		}
+		if result.Status == protocol.StatusSuccess || result.Status == protocol.StatusFailed {
+			values := &models.PaymentValues{}
+			values.SetStatus(result.Status).
+				SetChannelStatus(result.ChannelStatus).
+				SetResCode(result.ResCode).
+				SetResMsg(result.ResMsg).
+				SetCompletedAt(utils.TimeNowMilli())
+			if err := models.UpdatePaymentValues(models.DB, payment, values); err != nil {
+				log.Get().Errorf("同步订单 %s 的支付 %s 渠道状态失败: %v", order.OrderID, payment.PaymentID, err)
+				return false
+			}
+			if result.Status == protocol.StatusSuccess {
+				GetOrderService().CheckOrderPayment(order.OrderID, payment.PaymentID)
+				return false
+			}
+			return true
+		}
+	}
+	if expiredAt := payment.GetExpiredAt(); expiredAt == 0 || utils.TimeNowMilli() < expiredAt {
+		return false
+	}
+	values := &models.PaymentValues{}
+	values.SetStatus(protocol.StatusFailed).
+		SetResCode(protocol.ResCodeRequestTimeout).
+		SetResMsg("Pending payment expired; retried by dunning")
+	if err := models.UpdatePaymentValues(models.DB, payment, values); err != nil {
+		log.Get().Errorf("过期支付记录标记失败: order_id=%s payment_id=%s error=%v", order.OrderID, payment.PaymentID, err)
+		return false
+	}
+	log.Get().Infof("催收重试回收过期支付记录: order_id=%s old_payment_id=%s", order.OrderID, payment.PaymentID)
+	return true
+}
+
+// reschedule 记录扣款次数和下次重试时间
+func (s *DunningService) reschedule(debt *models.UserDebt, attempts int, next int64, reason string) {
+	updates := map[string]any{
+		"attempts":       attempts,
+		"next_charge_at": next,
+	}
+	if reason != "" {
+		updates["last_error"] = reason
+	}
+	if _, err := models.TransitionUserDebtStatus(debt.DebtID, []string{protocol.DebtStatusOutstanding}, updates); err != nil {
+		log.Get().Errorf("更新车费欠款 %s 重试计划失败: %v", debt.DebtID, err)
+	}
+}
+
+// CheckRideAllowed 乘客欠款超过上限时不能下单
+func (s *DunningService) CheckRideAllowed(userID string) protocol.ErrorCode {
+	cfg := config.GetDunningConfig()
+	if overBlockThreshold(models.SumUserOutstandingDebt(userID, cfg.Currency), cfg.BlockThreshold) {
+		log.Get().Infof("乘客 %s 欠款超过上限 %v %s，限制下单", userID, cfg.BlockThreshold, cfg.Currency)
+		return protocol.OutstandingBalanceExceeded
+	}
+	return protocol.Success
+}
+
+// GetOutstandingBalance 获取乘客未结清的欠款
+func (s *DunningService) GetOutstandingBalance(userID string) (*protocol.OutstandingBalance, protocol.ErrorCode) {
+	debts, err := models.ListUserOutstandingDebts(userID)
+	if err != nil {
+		log.Get().Errorf("查询乘客 %s 欠款失败: %v", userID, err)
+		return nil, protocol.DatabaseError
+	}
+	cfg := config.GetDunningConfig()
+	total := decimal.Zero
+	list := make([]*protocol.UserDebt, 0, len(debts))
+	for _, debt := range debts {
+		if debt.GetCurrency() == cfg.Currency {
+			total = total.Add(debt.GetAmount())
+		}
+		list = append(list, debt.Protocol())
+	}
+	amount, _ := total.Float64()
+	return &protocol.OutstandingBalance{
+		Amount:    amount,
+		Currency:  cfg.Currency,
+		Threshold: cfg.BlockThreshold,
+		Blocked:   overBlockThreshold(total, cfg.BlockThreshold),
+		Debts:     list,
+	}, protocol.Success
+}
+
+// PayDebt 乘客结清欠款：车费欠款通过订单支付，取消费和爽约费从钱包扣款
+func (s *DunningService) PayDebt(req *protocol.PayDebtRequest) (*protocol.PayDebtResult, protocol.ErrorCode) {
+	debt := models.GetUserDebtByID(req.DebtID)
+	if debt == nil || debt.GetUserID() != req.UserID {
+		return nil, protocol.DebtNotFound
+	}
+	if debt.GetStatus() != protocol.DebtStatusOutstanding {
+		return nil, protocol.DebtNotPayable
+	}
+
+	result := &protocol.PayDebtResult{}
+	if debt.GetType() == protocol.DebtTypeRideFare {
+		payment, errCode := GetOrderService().OrderPayment(&protocol.OrderPaymentRequest{
+			Language:        req.Language,
+			OrderID:         debt.GetOrderID(),
+			UserID:          req.UserID,
+			PaymentMethod:   req.PaymentMethod,
+			PaymentMethodID: req.PaymentMethodID,
+			Phone:           req.Phone,
+			Email:           req.Email,
+			AccountName:     req.AccountName,
+		})
+		if errCode != protocol.Success {
+			return nil, errCode
+		}
+		result.Payment = payment
+	} else {
+		if err := GetCancellationService().PayDebtFromWallet(debt.DebtID); err != protocol.Success {
+			return nil, err
+		}
+	}
+
+	if debt = models.GetUserDebtByID(req.DebtID); debt != nil {
+		result.Debt = debt.Protocol()
+	}
+	return result, protocol.Success
+}
+
+// SearchReceivables 管理后台分页查询应收欠款
+func (s *DunningService) SearchReceivables(req *protocol.ReceivableSearchRequest) ([]*protocol.UserDebt, int64) {
+	debts, total := models.SearchReceivables(req)
+	list := make([]*protocol.UserDebt, 0, len(debts))
+	for _, debt := range debts {
+		list = append(list, debt.Protocol())
+	}
+	return list, total
+}
+
+// GetReceivableSummary 按币种汇总未结清的欠款
+func (s *DunningService) GetReceivableSummary() ([]*protocol.ReceivableSummary, protocol.ErrorCode) {
+	summaries, err := models.SummarizeReceivables()
+	if err != nil {
+		log.Get().Errorf("汇总应收欠款失败: %v", err)
+		return nil, protocol.DatabaseError
+	}
+	return summaries, protocol.Success
+}
+
+// WaiveDebt 管理员减免欠款，停止自动扣款
+func (s *DunningService) WaiveDebt(req *protocol.DebtActionRequest) protocol.ErrorCode {
+	debt := models.GetUserDebtByID(req.DebtID)
+	if debt == nil {
+		return protocol.DebtNotFound
+	}
+	remark := "waived by " + req.UserID
+	if req.Notes != "" {
+		remark += ": " + req.Notes
+	}
+	ok, err := models.TransitionUserDebtStatus(debt.DebtID, []string{protocol.DebtStatusOutstanding}, map[string]any{
+		"status":         protocol.DebtStatusWaived,
+		"next_charge_at": 0,
+		"remark":         remark,
+	})
+	if err != nil {
+		log.Get().Errorf("减免乘客欠款 %s 失败: %v", debt.DebtID, err)
+		return protocol.DatabaseError
+	}
+	if !ok {
+		return protocol.DebtNotPayable
+	}
+	log.Get().Infof("管理员 %s 减免乘客 %s 欠款 %s", req.UserID, debt.GetUserID(), debt.DebtID)
+	return protocol.Success
+}
+
+// notifyRider 推送扣款失败或欠款逾期通知
+func (s *DunningService) notifyRider(debt *models.UserDebt, msgType, notificationType string) {
+	user := models.GetUserByID(debt.GetUserID())
+	if user == nil {
+		return
+	}
+	message := &Message{
+		Type:     msgType,
+		Channels: []string{protocol.MsgChannelFcm},
+		Params: map[string]any{
+			"to":                user.UserID,
+			"OrderID":           debt.GetOrderID(),
+			"DebtID":            debt.DebtID,
+			"Amount":            debt.GetAmount().StringFixed(2),
+			"Currency":          debt.GetCurrency(),
+			"msg_type":          protocol.FCMMessageTypePayment,
+			"notification_type": notificationType,
+		},
+		Language: getUserLanguage(user),
+	}
+	if err := GetUserNotificationService().Deliver(user, message); err != nil {
+		log.Get().Warnf("通知乘客 %s 欠款 %s 失败: %v", user.UserID, debt.DebtID, err)
+	}
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"testing"
+
+	"greenride/internal/config"
+	"greenride/internal/models"
+	"greenride/internal/protocol"
+	"greenride/internal/utils"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestNextChargeAtBackoff(t *testing.T) {
+	intervals := []int{30, 360, 1440}
+	const from int64 = 1_000_000
+	cases := []struct {
+		attempts int
+		want     int64
+	}{
+		{1, from + 30*60*1000},
+		{2, from + 360*60*1000},
+		{3, from + 1440*60*1000},
+		{4, 0}, // 重试次数用完
+		{0, 0},
+	}
+	for _, c := range cases {
+		if got := nextChargeAt(c.attempts, from, intervals); got != c.want {
+			t.Errorf("attempts=%d: got %d, want %d", c.attempts, got, c.want)
+		}
+	}
+	if got := nextChargeAt(1, from, nil); got != 0 {
+		t.Errorf("no intervals: got %d, want 0", got)
+	}
+}
+
+func TestOverBlockThreshold(t *testing.T) {
+	cases := []struct {
+		outstanding string
+		threshold   float64
+		want        bool
+	}{
+		{"0", 5000, false},
+		{"5000", 5000, false},
+		{"5000.01", 5000, true},
+		{"12000", 5000, true},
+	}
+	for _, c := range cases {
+		if got := overBlockThreshold(decimal.RequireFromString(c.outstanding), c.threshold); got != c.want {
+			t.Errorf("outstanding=%s threshold=%v: got %v, want %v", c.outstanding, c.threshold, got, c.want)
+		}
+	}
+}
+
+func TestReleaseStalePayment(t *testing.T) {
+	db := setupTestDB(t, &models.Payment{})
+	now := utils.TimeNowMilli()
+
+	cases := []struct {
+		name             string
+		channelPaymentID string
+		expiredAt        int64
+		query            *protocol.ChannelResult
+		want             bool
+		wantStatus       string
+	}{
+		{"未到达渠道且已过期", "", now - 1000, nil, true, protocol.StatusFailed},
+		{"未到达渠道且未过期", "", now + 60_000, nil, false, protocol.StatusPending},
+		{"渠道查询失败", "pi_1", now - 1000, nil, false, protocol.StatusPending},
+		{"渠道仍在处理且未过期", "pi_2", now + 60_000, &protocol.ChannelResult{Status: protocol.StatusPending, ChannelPaymentID: "pi_2"}, false, protocol.StatusPending},
+		{"渠道仍在处理但已过期", "pi_3", now - 1000, &protocol.ChannelResult{Status: protocol.StatusPending, ChannelPaymentID: "pi_3"}, true, protocol.StatusFailed},
+		{"渠道确认失败", "pi_4", now + 60_000, &protocol.ChannelResult{Status: protocol.StatusFailed, ChannelPaymentID: "pi_4", ResCode: "card_declined"}, true, protocol.StatusFailed},
+	}
+	for i, c := range cases {
+		orderID := "O" + string(rune('A'+i))
+		payment := models.NewPayment()
+		payment.SetOrderID(orderID).
+			SetPaymentMethod(protocol.PaymentMethodCard).
+			SetStatus(protocol.StatusPending).
+			SetChannelPaymentID(c.channelPaymentID).
+			SetExpiredAt(c.expiredAt)
+		if err := db.Create(payment).Error; err != nil {
+			t.Fatalf("%s: create payment: %v", c.name, err)
+		}
+		queried := false
+		service := &DunningService{queryPayment: func(*models.Payment) *protocol.ChannelResult {
+			queried = true
+			return c.query
+		}}
+
+		if got := service.releaseStalePayment(&models.Order{OrderID: orderID}); got != c.want {
+			t.Errorf("%s: releaseStalePayment = %v, want %v", c.name, got, c.want)
+		}
+		if queried != (c.channelPaymentID != "") {
+			t.Errorf("%s: queried channel = %v", c.name, queried)
+		}
+		if status := models.GetPaymentByID(payment.PaymentID).GetStatus(); status != c.wantStatus {
+			t.Errorf("%s: payment status = %s, want %s", c.name, status, c.wantStatus)
+		}
+	}
+
+	// 没有处理中的支付记录时直接扣款
+	if !(&DunningService{}).releaseStalePayment(&models.Order{OrderID: "O-none"}) {
+		t.Error("releaseStalePayment without pending payment = false, want true")
+	}
+}
+
+func TestRetryChargeSkipsCashOrders(t *testing.T) {
+	db := setupTestDB(t, &models.Order{}, &models.UserDebt{}, &models.Payment{})
+	cfg := config.GetDunningConfig()
+
+	values := &models.OrderValues{}
+	values.SetUserID("U1").
+		SetPaymentMethod(protocol.PaymentMethodCash).
+		SetPaymentStatus(protocol.StatusPending)
+	order := &models.Order{OrderID: "O-cash", OrderValues: values}
+	if err := db.Create(order).Error; err != nil {
+		t.Fatalf("create order: %v", err)
+	}
+	debt := models.NewUserDebt("U1", order.OrderID, protocol.DebtTypeRideFare)
+	debt.SetAmounts(decimal.NewFromInt(2000), decimal.Zero, decimal.Zero, "RWF")
+	debt.Attempts = utils.IntPtr(1)
+	debt.NextChargeAt = utils.Int64Ptr(1)
+	if err := models.CreateUserDebt(db, debt); err != nil {
+		t.Fatalf("create debt: %v", err)
+	}
+
+	before := utils.TimeNowMilli()
+	(&DunningService{}).retryCharge(debt, cfg)
+	stored := models.GetUserDebtByOrder(order.OrderID, protocol.DebtTypeRideFare)
+	if stored.GetAttempts() != 1 {
+		t.Errorf("attempts after cash skip = %d, want 1", stored.GetAttempts())
+	}
+	if next := stored.GetNextChargeAt(); next < before+int64(cfg.RetryIntervals[0])*60*1000 {
+		t.Errorf("next charge at = %d, want pushed back by the first retry interval", next)
+	}
+
+	if got := chargePausedReason(&models.Order{OrderValues: &models.OrderValues{}}, false); got == "" {
+		t.Error("auto charge disabled should pause dunning charges")
+	}
+	if got := chargePausedReason(&models.Order{OrderValues: &models.OrderValues{}}, true); got != "" {
+		t.Errorf("online order with auto charge = %q, want no pause", got)
+	}
+}
@@ -525,7 +525,7 @@ func (g *GoogleService) SearchPlacesByName(ctx context.Context, name, city, coun
 	}
 
 	// 构建请求URL
-	baseURL := "https://maps.googleapis.com/maps/api/place/textsearch/json"
+	baseURL := g.placesURL("textsearch/json")
 	params := url.Values{}
 	params.Set("query", query)
 	params.Set("key", g.config.MapsAPIKey)
@@ -602,7 +602,7 @@ func (g *GoogleService) GetPlaceDetails(ctx context.Context, placeID string) (*P
 	}
 
 	// 构建请求URL
-	baseURL := "https://maps.googleapis.com/maps/api/place/details/json"
+	baseURL := g.placesURL("details/json")
 	params := url.Values{}
 	params.Set("place_id", placeID)
 	params.Set("key", g.config.MapsAPIKey)
@@ -675,8 +675,17 @@ func (g *GoogleService) GetPlacePhotoURL(photoReference string, maxWidth, maxHei
 		maxHeight = 400
 	}
 
-	return fmt.Sprintf("https://maps.googleapis.com/maps/api/place/photo?maxwidth=%d&maxheight=%d&photoreference=%s&key=%s",
-		maxWidth, maxHeight, photoReference, g.config.MapsAPIKey)
+	return fmt.Sprintf("%s?maxwidth=%d&maxheight=%d&photoreference=%s&key=%s",
+		g.placesURL("photo"), maxWidth, maxHeight, photoReference, g.config.MapsAPIKey)
+}
+
+// placesURL 拼接 Places API 地址
+func (g *GoogleService) placesURL(path string) string {
+	baseURL := g.config.PlacesBaseURL
+	if baseURL == "" {
+		baseURL = "https://maps.googleapis.com/maps/api/place"
+	}
+	return baseURL + "/" + path
 }
 
 // ExtractCompleteGoogleData 提取完整的Google地点数据
@@ -82,17 +82,28 @@ type InnoPaaSSMSResponse struct {
 	} `json:"data"`
 }
 
+// OTPOnly InnoPaaS OTP API can only deliver verification codes, not free-form SMS
+func (s *InnoPaaSService) OTPOnly() bool {
+	return true
+}
+
 // SendSmsMessage sends an OTP using InnoPaaS OTP API v3.0
-// Uses WhatsApp (type "1") by default, falls back to SMS (type "3") on failure
 func (s *InnoPaaSService) SendSmsMessage(message *Message) error {
+	_, err := s.SendTrackedSmsMessage(message)
+	return err
+}
+
+// SendTrackedSmsMessage sends an OTP and returns the InnoPaaS message ID for delivery tracking
+// Uses WhatsApp (type "1") by default, falls back to SMS (type "3") on failure
+func (s *InnoPaaSService) SendTrackedSmsMessage(message *Message) (string, error) {
 	cfg := config.Get().InnoPaaS
 	if cfg == nil {
-		return fmt.Errorf("InnoPaaS configuration is missing")
+		return "", fmt.Errorf("InnoPaaS configuration is missing")
 	}
 
 	to, ok := message.Params["to"].(string)
 	if !ok || to == "" {
-		return fmt.Errorf("missing recipient phone number")
+		return "", fmt.Errorf("missing recipient phone number")
 	}
 
 	var code string
@@ -101,15 +112,15 @@ func (s *InnoPaaSService) SendSmsMessage(message *Message) error {
 	} else {
 		content, ok := message.Params["content"].(string)
 		if !ok || content == "" {
-			return fmt.Errorf("missing OTP code in message params")
+			return "", fmt.Errorf("missing OTP code in message params")
 		}
 		code = extractCodeFromContent(content)
 		if code == "" {
-			return fmt.Errorf("failed to extract OTP code from message content")
+			return "", fmt.Errorf("failed to extract OTP code from message content")
 		}
 	}
 	if code == "" {
-		return fmt.Errorf("missing OTP code")
+		return "", fmt.Errorf("missing OTP code")
 	}
 
 	// OTP v3.0: to in international format e.g. "+12025551234"
@@ -128,34 +139,34 @@ func (s *InnoPaaSService) SendSmsMessage(message *Message) error {
 	}
 
 	// Try primary delivery method
-	err := s.sendOTP(cfg, toE164, code, primaryType)
+	messageID, err := s.sendOTP(cfg, toE164, code, primaryType)
 	if err == nil {
 		log.Get().Infof("[InnoPaaS] OTP sent via %s to %s", otpTypeName(primaryType), toE164)
-		return nil
+		return messageID, nil
 	}
 
 	// Primary failed — try OTP fallback channel
 	log.Get().Warnf("[InnoPaaS] %s OTP failed for %s: %v — falling back to %s", otpTypeName(primaryType), toE164, err, otpTypeName(fallbackType))
-	fbErr := s.sendOTP(cfg, toE164, code, fallbackType)
+	messageID, fbErr := s.sendOTP(cfg, toE164, code, fallbackType)
 	if fbErr == nil {
 		log.Get().Infof("[InnoPaaS] OTP sent via %s (fallback) to %s", otpTypeName(fallbackType), toE164)
-		return nil
+		return messageID, nil
 	}
 	log.Get().Warnf("[InnoPaaS] %s OTP fallback also failed for %s: %v — trying SMS API", otpTypeName(fallbackType), toE164, fbErr)
 
 	// Final fallback: send as plain SMS via the SMS API (separate credit pool)
-	smsErr := s.sendSMSAPI(cfg, cleanPhoneNumber(to), code)
+	messageID, smsErr := s.sendSMSAPI(cfg, cleanPhoneNumber(to), code)
 	if smsErr == nil {
 		log.Get().Infof("[InnoPaaS] OTP sent via SMS API (final fallback) to %s", toE164)
-		return nil
+		return messageID, nil
 	}
 	log.Get().Errorf("[InnoPaaS] All delivery methods failed for %s: OTP %s: %v, OTP %s: %v, SMS API: %v",
 		toE164, otpTypeName(primaryType), err, otpTypeName(fallbackType), fbErr, smsErr)
-	return fmt.Errorf("all OTP delivery failed for %s", toE164)
+	return "", fmt.Errorf("all OTP delivery failed for %s", toE164)
 }
 
-// sendOTP sends a single OTP request with the specified type
-func (s *InnoPaaSService) sendOTP(cfg *config.InnoPaaSConfig, toE164, code, otpType string) error {
+// sendOTP sends a single OTP request with the specified type and returns the message ID
+func (s *InnoPaaSService) sendOTP(cfg *config.InnoPaaSConfig, toE164, code, otpType string) (string, error) {
 	reqBody := InnoPaaSRequest{
 		Type:     otpType,
 		Language: "en",
@@ -171,12 +182,12 @@ func (s *InnoPaaSService) sendOTP(cfg *config.InnoPaaSConfig, toE164, code, otpT
 
 	jsonBody, err := json.Marshal(reqBody)
 	if err != nil {
-		return fmt.Errorf("failed to marshal request body: %v", err)
+		return "", fmt.Errorf("failed to marshal request body: %v", err)
 	}
 
 	req, err := http.NewRequest("POST", cfg.Endpoint, bytes.NewBuffer(jsonBody))
 	if err != nil {
-		return fmt.Errorf("failed to create request: %v", err)
+		return "", fmt.Errorf("failed to create request: %v", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json;charset=utf-8")
@@ -198,32 +209,32 @@ func (s *InnoPaaSService) sendOTP(cfg *config.InnoPaaSConfig, toE164, code, otpT
 
 	resp, err := s.client.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to send request to InnoPaaS: %v", err)
+		return "", fmt.Errorf("failed to send request to InnoPaaS: %v", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("failed to read response body: %v", err)
+		return "", fmt.Errorf("failed to read response body: %v", err)
 	}
 
 	var apiResp InnoPaaSResponse
 	if err := json.Unmarshal(body, &apiResp); err != nil {
 		log.Get().Errorf("InnoPaaS raw response: %s", string(body))
-		return fmt.Errorf("failed to parse response: %v", err)
+		return "", fmt.Errorf("failed to parse response: %v", err)
 	}
 
 	// v3.0 success is code == "000000"
 	if apiResp.Code != "000000" {
-		return fmt.Errorf("InnoPaaS API error: %s (code: %s)", apiResp.Message, apiResp.Code)
+		return "", fmt.Errorf("InnoPaaS API error: %s (code: %s)", apiResp.Message, apiResp.Code)
 	}
 
-	return nil
+	return apiResp.Data, nil
 }
 
 // sendSMSAPI sends an OTP code as a plain SMS via InnoPaaS SMS API v3.0.
 // This is a separate product from the OTP API and may have its own credit pool.
-func (s *InnoPaaSService) sendSMSAPI(cfg *config.InnoPaaSConfig, mobile, code string) error {
+func (s *InnoPaaSService) sendSMSAPI(cfg *config.InnoPaaSConfig, mobile, code string) (string, error) {
 	smsBody := InnoPaaSSMSRequest{
 		Mobile: mobile,
 		Msg:    fmt.Sprintf("Your GreenRide verification code is %s. Valid for 5 minutes.", code),
@@ -237,12 +248,12 @@ func (s *InnoPaaSService) sendSMSAPI(cfg *config.InnoPaaSConfig, mobile, code st
 
 	jsonBody, err := json.Marshal(smsBody)
 	if err != nil {
-		return fmt.Errorf("failed to marshal SMS request: %v", err)
+		return "", fmt.Errorf("failed to marshal SMS request: %v", err)
 	}
 
 	req, err := http.NewRequest("POST", cfg.SMSEndpoint, bytes.NewBuffer(jsonBody))
 	if err != nil {
-		return fmt.Errorf("failed to create SMS request: %v", err)
+		return "", fmt.Errorf("failed to create SMS request: %v", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json;charset=utf-8")
@@ -251,28 +262,28 @@ func (s *InnoPaaSService) sendSMSAPI(cfg *config.InnoPaaSConfig, mobile, code st
 
 	resp, err := s.client.Do(req)
 	if err != nil {
-		return fmt.Errorf("SMS API request failed: %v", err)
+		return "", fmt.Errorf("SMS API request failed: %v", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("failed to read SMS API response: %v", err)
+		return "", fmt.Errorf("failed to read SMS API response: %v", err)
 	}
 
 	var apiResp InnoPaaSSMSResponse
 	if err := json.Unmarshal(body, &apiResp); err != nil {
 		log.Get().Errorf("[InnoPaaS] SMS API raw response: %s", string(body))
-		return fmt.Errorf("failed to parse SMS API response: %v", err)
+		return "", fmt.Errorf("failed to parse SMS API response: %v", err)
 	}
 
 	// SMS API success code is "0" (different from OTP's "000000")
 	if apiResp.Code != "0" {
-		return fmt.Errorf("SMS API error: %s (code: %s)", apiResp.Message, apiResp.Code)
+		return "", fmt.Errorf("SMS API error: %s (code: %s)", apiResp.Message, apiResp.Code)
 	}
 
 	log.Get().Infof("[InnoPaaS] SMS API message sent, messageId: %s", apiResp.Data.MessageID)
-	return nil
+	return apiResp.Data.MessageID, nil
 }
 
 // otpTypeName returns a human-readable name for the OTP type
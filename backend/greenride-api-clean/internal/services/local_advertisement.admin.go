@@ -0,0 +1,388 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"greenride/internal/log"
+	"greenride/internal/models"
+	"greenride/internal/protocol"
+	"greenride/internal/utils"
+)
+
+// 广告图片在S3中的目录
+const localAdImageFolder = "ads"
+
+var localAdCategories = []string{
+	protocol.LocalAdCategoryCafe,
+	protocol.LocalAdCategoryBank,
+	protocol.LocalAdCategoryHotel,
+	protocol.LocalAdCategoryRestaurant,
+	protocol.LocalAdCategoryOther,
+}
+
+// validateLocalAdvertisementRequest 校验类别、状态和展示时间，并补齐默认值
+func validateLocalAdvertisementRequest(req *protocol.CreateLocalAdvertisementRequest) protocol.ErrorCode {
+	if req.Category == "" {
+		req.Category = protocol.LocalAdCategoryOther
+	}
+	if !slices.Contains(localAdCategories, req.Category) {
+		return protocol.InvalidParams
+	}
+	if req.Status == "" {
+		req.Status = protocol.StatusPending
+	}
+	if !slices.Contains([]string{protocol.StatusActive, protocol.StatusInactive, protocol.StatusPending}, req.Status) {
+		return protocol.InvalidParams
+	}
+	if req.StartAt > 0 && req.EndAt > 0 && req.EndAt <= req.StartAt {
+		return protocol.InvalidParams
+	}
+	return protocol.Success
+}
+
+// localAdvertisementValuesFromRequest 由请求构建广告字段，未设置的展示时间存为空（不限）
+func localAdvertisementValuesFromRequest(req *protocol.CreateLocalAdvertisementRequest) *models.LocalAdvertisementValues {
+	values := &models.LocalAdvertisementValues{
+		Name:         utils.StringPtr(strings.TrimSpace(req.Name)),
+		Description:  utils.StringPtr(req.Description),
+		Category:     utils.StringPtr(req.Category),
+		Address:      utils.StringPtr(req.Address),
+		City:         utils.StringPtr(req.City),
+		Region:       utils.StringPtr(req.Region),
+		Country:      utils.StringPtr(req.Country),
+		Phone:        utils.StringPtr(req.Phone),
+		Email:        utils.StringPtr(req.Email),
+		Website:      utils.StringPtr(req.Website),
+		ImageURL:     utils.StringPtr(req.ImageURL),
+		LogoURL:      utils.StringPtr(req.LogoURL),
+		Status:       utils.StringPtr(req.Status),
+		Priority:     utils.IntPtr(req.Priority),
+		DisplayOrder: utils.IntPtr(req.DisplayOrder),
+		IsPromoted:   utils.BoolPtr(req.IsPromoted),
+		IsFeatured:   utils.BoolPtr(req.IsFeatured),
+		TargetCities: utils.StringPtr(strings.Join(req.TargetCities, ",")),
+		Tags:         utils.StringPtr(strings.Join(req.Tags, ",")),
+		Notes:        utils.StringPtr(req.Notes),
+	}
+	if req.Latitude != 0 || req.Longitude != 0 {
+		values.Latitude = utils.Float64Ptr(req.Latitude)
+		values.Longitude = utils.Float64Ptr(req.Longitude)
+	}
+	if req.StartAt > 0 {
+		values.StartAt = utils.Int64Ptr(req.StartAt)
+	}
+	if req.EndAt > 0 {
+		values.EndAt = utils.Int64Ptr(req.EndAt)
+	}
+	return values
+}
+
+// rehostLocalAdImage 将外部图片转存到S3，返回新地址；已在S3上或转存失败时返回原地址
+func rehostLocalAdImage(ctx context.Context, imageURL, adID, name string) (string, error) {
+	if imageURL == "" {
+		return "", nil
+	}
+	awsService, available := GetAWSServiceSafe()
+	if !available {
+		return imageURL, fmt.Errorf("S3 storage is not available")
+	}
+	if strings.HasPrefix(imageURL, awsService.GenerateObjectURL(localAdImageFolder+"/")) {
+		return imageURL, nil
+	}
+	hosted, err := awsService.UploadImageFromURL(ctx, imageURL, localAdImageFolder, adID+"/"+name)
+	if err != nil {
+		return imageURL, err
+	}
+	return hosted, nil
+}
+
+// rehostLocalAdImages 转存主图和Logo，失败时保留原地址并记录日志
+func rehostLocalAdImages(ctx context.Context, adID string, values *models.LocalAdvertisementValues) {
+	for name, field := range map[string]**string{"image": &values.ImageURL, "logo": &values.LogoURL} {
+		source := utils.SafeStringDeref(*field)
+		hosted, err := rehostLocalAdImage(ctx, source, adID, name)
+		if err != nil {
+			log.Get().Warnf("广告 %s 图片转存失败, url=%s: %v", adID, source, err)
+			continue
+		}
+		*field = utils.StringPtr(hosted)
+	}
+}
+
+// CreateLocalAdvertisement 管理后台创建广告
+func (s *LocalAdvertisementService) CreateLocalAdvertisement(ctx context.Context, req *protocol.CreateLocalAdvertisementRequest) (*protocol.AdminLocalAdvertisement, protocol.ErrorCode) {
+	if code := validateLocalAdvertisementRequest(req); code != protocol.Success {
+		return nil, code
+	}
+	ad := models.NewLocalAdvertisement()
+	values := localAdvertisementValuesFromRequest(req)
+	values.ViewCount = utils.IntPtr(0)
+	values.ClickCount = utils.IntPtr(0)
+	values.CallCount = utils.IntPtr(0)
+	values.CreatedBy = utils.StringPtr(req.UserID)
+	values.UpdatedBy = utils.StringPtr(req.UserID)
+	ad.LocalAdvertisementValues = values
+	if req.RehostImages {
+		rehostLocalAdImages(ctx, ad.AdID, ad.LocalAdvertisementValues)
+	}
+
+	if err := models.DB.Create(ad).Error; err != nil {
+		log.Get().Errorf("创建广告失败: %v", err)
+		return nil, protocol.DatabaseError
+	}
+	return ad.AdminProtocol(), protocol.Success
+}
+
+// UpdateLocalAdvertisement 管理后台修改广告，整体替换可编辑字段，统计数据和Google信息保留
+func (s *LocalAdvertisementService) UpdateLocalAdvertisement(ctx context.Context, req *protocol.UpdateLocalAdvertisementRequest) (*protocol.AdminLocalAdvertisement, protocol.ErrorCode) {
+	if code := validateLocalAdvertisementRequest(&req.CreateLocalAdvertisementRequest); code != protocol.Success {
+		return nil, code
+	}
+	if s.getAdminLocalAdvertisement(req.AdID) == nil {
+		return nil, protocol.AdvertisementNotFound
+	}
+
+	values := localAdvertisementValuesFromRequest(&req.CreateLocalAdvertisementRequest)
+	values.UpdatedBy = utils.StringPtr(req.UserID)
+	if req.RehostImages {
+		rehostLocalAdImages(ctx, req.AdID, values)
+	}
+	ok, err := models.UpdateLocalAdvertisement(req.AdID, values, models.LocalAdvertisementEditableColumns)
+	if err != nil {
+		log.Get().Errorf("修改广告 %s 失败: %v", req.AdID, err)
+		return nil, protocol.DatabaseError
+	}
+	if !ok {
+		return nil, protocol.AdvertisementNotFound
+	}
+	return s.GetAdminLocalAdvertisement(req.AdID)
+}
+
+func (s *LocalAdvertisementService) getAdminLocalAdvertisement(adID string) *models.LocalAdvertisement {
+	ad, err := models.GetLocalAdvertisementByID(adID)
+	if err != nil || ad.GetStatus() == protocol.StatusDeleted {
+		return nil
+	}
+	return ad
+}
+
+// GetAdminLocalAdvertisement 管理后台获取广告详情
+func (s *LocalAdvertisementService) GetAdminLocalAdvertisement(adID string) (*protocol.AdminLocalAdvertisement, protocol.ErrorCode) {
+	ad := s.getAdminLocalAdvertisement(adID)
+	if ad == nil {
+		return nil, protocol.AdvertisementNotFound
+	}
+	return ad.AdminProtocol(), protocol.Success
+}
+
+// SearchLocalAdvertisements 管理后台分页查询广告
+func (s *LocalAdvertisementService) SearchLocalAdvertisements(req *protocol.LocalAdvertisementSearchRequest) ([]*protocol.AdminLocalAdvertisement, int64) {
+	ads, total := models.SearchLocalAdvertisements(req)
+	list := make([]*protocol.AdminLocalAdvertisement, 0, len(ads))
+	for _, ad := range ads {
+		list = append(list, ad.AdminProtocol())
+	}
+	return list, total
+}
+
+// SetLocalAdvertisementStatus 上线或下线广告
+func (s *LocalAdvertisementService) SetLocalAdvertisementStatus(req *protocol.LocalAdvertisementActionRequest) protocol.ErrorCode {
+	if req.Status != protocol.StatusActive && req.Status != protocol.StatusInactive {
+		return protocol.InvalidParams
+	}
+	return s.updateStatus(req.AdID, req.Status, req.UserID)
+}
+
+// DeleteLocalAdvertisement 删除广告（软删除，统计数据保留）
+func (s *LocalAdvertisementService) DeleteLocalAdvertisement(req *protocol.LocalAdvertisementActionRequest) protocol.ErrorCode {
+	return s.updateStatus(req.AdID, protocol.StatusDeleted, req.UserID)
+}
+
+func (s *LocalAdvertisementService) updateStatus(adID, status, operatorID string) protocol.ErrorCode {
+	values := &models.LocalAdvertisementValues{
+		Status:    utils.StringPtr(status),
+		UpdatedBy: utils.StringPtr(operatorID),
+	}
+	ok, err := models.UpdateLocalAdvertisement(adID, values, []string{"status", "updated_by", "updated_at"})
+	if err != nil {
+		log.Get().Errorf("更新广告 %s 状态失败: %v", adID, err)
+		return protocol.DatabaseError
+	}
+	if !ok {
+		return protocol.AdvertisementNotFound
+	}
+	return protocol.Success
+}
+
+// localAdCategoryFromPlaceTypes 根据Google地点类型推断广告类别
+func localAdCategoryFromPlaceTypes(types []string, fallback string) string {
+	mapping := map[string]string{
+		"cafe":          protocol.LocalAdCategoryCafe,
+		"bakery":        protocol.LocalAdCategoryCafe,
+		"bank":          protocol.LocalAdCategoryBank,
+		"atm":           protocol.LocalAdCategoryBank,
+		"lodging":       protocol.LocalAdCategoryHotel,
+		"restaurant":    protocol.LocalAdCategoryRestaurant,
+		"meal_takeaway": protocol.LocalAdCategoryRestaurant,
+	}
+	// Google按相关度排列类型，取第一个能识别的
+	for _, placeType := range types {
+		if category, ok := mapping[placeType]; ok {
+			return category
+		}
+	}
+	if slices.Contains(localAdCategories, fallback) {
+		return fallback
+	}
+	return protocol.LocalAdCategoryOther
+}
+
+// localAdvertisementFromPlace 由Google地点详情生成广告，图片需转存后另行设置
+func localAdvertisementFromPlace(details *PlaceDetailsResult, req *protocol.ImportLocalAdvertisementsRequest) *models.LocalAdvertisement {
+	ad := models.NewLocalAdvertisement()
+	ad.SetName(details.Name).
+		SetCategory(localAdCategoryFromPlaceTypes(details.Types, req.Category)).
+		SetLocation(details.FormattedAddress, req.City, req.Region, req.Country,
+			details.Geometry.Location.Lat, details.Geometry.Location.Lng).
+		SetContact(details.InternationalPhoneNumber, "", details.Website).
+		SetGoogleInfo(details.PlaceID, details.Rating, details.UserRatingsTotal).
+		SetStatus(req.Status).
+		SetCreator(req.UserID)
+	ad.UpdatedBy = utils.StringPtr(req.UserID)
+	if details.PriceLevel > 0 {
+		ad.PriceLevel = utils.IntPtr(details.PriceLevel)
+	}
+	if len(details.Types) > 0 {
+		ad.Tags = utils.StringPtr(strings.Join(details.Types, ","))
+	}
+	if len(details.OpeningHours.WeekdayText) > 0 {
+		hoursJSON, _ := json.Marshal(map[string]any{
+			"weekday_text": details.OpeningHours.WeekdayText,
+			"open_now":     details.OpeningHours.OpenNow,
+		})
+		ad.OpeningHours = utils.StringPtr(string(hoursJSON))
+	}
+	return ad
+}
+
+// ImportLocalAdvertisements 按地点名称从Google Places批量导入广告
+// 已有相同Place ID的广告跳过；Google图片地址带API Key，开启转存后改用S3地址
+func (s *LocalAdvertisementService) ImportLocalAdvertisements(ctx context.Context, req *protocol.ImportLocalAdvertisementsRequest) (*protocol.LocalAdvertisementImportResult, protocol.ErrorCode) {
+	if req.Status == "" {
+		req.Status = protocol.StatusPending
+	}
+	if !slices.Contains([]string{protocol.StatusActive, protocol.StatusInactive, protocol.StatusPending}, req.Status) {
+		return nil, protocol.InvalidParams
+	}
+	googleService := GetGoogleService()
+
+	result := &protocol.LocalAdvertisementImportResult{Items: make([]*protocol.LocalAdvertisementImportItem, 0, len(req.Names))}
+	for _, name := range req.Names {
+		if ctx.Err() != nil {
+			break
+		}
+		item := s.importPlace(ctx, googleService, strings.TrimSpace(name), req)
+		switch item.Result {
+		case protocol.LocalAdImportCreated:
+			result.Created++
+		case protocol.LocalAdImportSkipped:
+			result.Skipped++
+		default:
+			result.Failed++
+		}
+		result.Items = append(result.Items, item)
+	}
+	log.Get().Infof("广告导入完成: 创建 %d, 跳过 %d, 失败 %d, operator=%s", result.Created, result.Skipped, result.Failed, req.UserID)
+	return result, protocol.Success
+}
+
+func (s *LocalAdvertisementService) importPlace(ctx context.Context, googleService *GoogleService, name string, req *protocol.ImportLocalAdvertisementsRequest) *protocol.LocalAdvertisementImportItem {
+	item := &protocol.LocalAdvertisementImportItem{Name: name, Result: protocol.LocalAdImportFailed}
+	if name == "" {
+		item.Error = "empty place name"
+		return item
+	}
+
+	place, err := googleService.SearchPlacesByName(ctx, name, req.City, req.Country)
+	if err != nil {
+		item.Error = err.Error()
+		return item
+	}
+	item.GooglePlaceID = place.PlaceID
+	if existing := models.GetLocalAdvertisementByPlaceID(place.PlaceID); existing != nil {
+		item.Result = protocol.LocalAdImportSkipped
+		item.AdID = existing.AdID
+		return item
+	}
+
+	details, err := googleService.GetPlaceDetails(ctx, place.PlaceID)
+	if err != nil {
+		item.Error = err.Error()
+		return item
+	}
+	ad := localAdvertisementFromPlace(details, req)
+	// Google照片地址带有 Maps API Key，只保存转存到S3后的地址，转存失败时不设置图片
+	if req.RehostImages && len(details.Photos) > 0 {
+		if photoURL := googleService.GetPlacePhotoURL(details.Photos[0].PhotoReference, 800, 600); photoURL != "" {
+			hosted, err := rehostLocalAdImage(ctx, photoURL, ad.AdID, "image")
+			if err != nil {
+				log.Get().Warnf("导入广告 %s 图片转存失败，不保存图片: %v", name, err)
+			} else {
+				ad.SetImages(hosted, "")
+				item.ImageRehosted = true
+			}
+		}
+	}
+
+	if err := models.DB.Create(ad).Error; err != nil {
+		log.Get().Errorf("导入广告 %s 保存失败: %v", name, err)
+		item.Error = "failed to save advertisement"
+		return item
+	}
+	item.Result = protocol.LocalAdImportCreated
+	item.AdID = ad.AdID
+	return item
+}
+
+// percentOf 计算百分比，保留两位小数
+func percentOf(part, whole int64) float64 {
+	if whole <= 0 {
+		return 0
+	}
+	return utils.RoundToTwoDecimal(float64(part) * 100 / float64(whole))
+}
+
+// localAdvertisementReport 生成单个广告的统计
+func localAdvertisementReport(ad *models.LocalAdvertisement) *protocol.LocalAdvertisementReport {
+	views := int64(ad.GetViewCount())
+	return &protocol.LocalAdvertisementReport{
+		AdID:       ad.AdID,
+		Name:       ad.GetName(),
+		Category:   ad.GetCategory(),
+		City:       ad.GetCity(),
+		Status:     ad.GetStatus(),
+		IsLive:     ad.IsVisible(),
+		ViewCount:  ad.GetViewCount(),
+		ClickCount: ad.GetClickCount(),
+		CallCount:  ad.GetCallCount(),
+		ClickRate:  percentOf(int64(ad.GetClickCount()), views),
+		CallRate:   percentOf(int64(ad.GetCallCount()), views),
+	}
+}
+
+// GetLocalAdvertisementReports 广告展示/点击报表
+func (s *LocalAdvertisementService) GetLocalAdvertisementReports(req *protocol.LocalAdvertisementReportRequest) ([]*protocol.LocalAdvertisementReport, int64, *protocol.LocalAdvertisementReportSummary) {
+	ads, total, summary := models.GetLocalAdvertisementReports(req)
+	summary.ClickRate = percentOf(summary.TotalClicks, summary.TotalViews)
+	summary.CallRate = percentOf(summary.TotalCalls, summary.TotalViews)
+
+	list := make([]*protocol.LocalAdvertisementReport, 0, len(ads))
+	for _, ad := range ads {
+		list = append(list, localAdvertisementReport(ad))
+	}
+	return list, total, summary
+}
@@ -2,10 +2,13 @@ package services
 
 import (
 	"context"
+	"greenride/internal/config"
 	"greenride/internal/log"
 	"greenride/internal/models"
 	"greenride/internal/protocol"
+	"slices"
 	"sync"
+	"time"
 )
 
 // LocalAdvertisementService 本地广告服务
@@ -31,31 +34,102 @@ func SetupLocalAdvertisementService() {
 }
 
 // GetLocalAdvertisements 获取本地广告列表
+// 按与上车点（行程中为目的地）的距离、营业状态、优先级/精选/推广标记和点击率排序，并按用户每日展示频次过滤
 func (s *LocalAdvertisementService) GetLocalAdvertisements(req *protocol.LocalAdvertisementListRequest) ([]protocol.LocalAdvertisement, protocol.ErrorCode) {
 	if req == nil {
 		req = &protocol.LocalAdvertisementListRequest{}
 	}
+	cfg := config.GetAdsConfig()
+
+	filter := &models.LocalAdvertisementFilter{
+		City:      req.City,
+		Region:    req.Region,
+		Category:  req.Category,
+		Latitude:  req.Latitude,
+		Longitude: req.Longitude,
+		RadiusKm:  cfg.RadiusKm,
+	}
+	rankCity := req.City
+	if req.Placement == protocol.LocalAdPlacementTrip {
+		if cfg.TripDestinationAds != config.StatusOn {
+			return []protocol.LocalAdvertisement{}, protocol.Success
+		}
+		lat, lng, ok := s.tripDestination(req)
+		if !ok {
+			return []protocol.LocalAdvertisement{}, protocol.Success
+		}
+		// 目的地可能在其他城市，只按距离筛选
+		filter = &models.LocalAdvertisementFilter{
+			Category:        req.Category,
+			Latitude:        lat,
+			Longitude:       lng,
+			RadiusKm:        cfg.RadiusKm,
+			RequireLocation: true,
+		}
+		rankCity = ""
+	}
 
-	// 查询广告，现在支持category参数
-	ads, err := models.GetAllActiveLocalAdvertisements(req.City, req.Region, req.Category)
+	ads, err := models.GetLocalAdvertisementCandidates(filter)
 	if err != nil {
 		log.Get().Errorf("获取本地广告列表失败: %v", err)
 		return nil, protocol.DatabaseError
 	}
 
-	// 转换为响应格式
-	var responses []protocol.LocalAdvertisement
-	for _, ad := range ads {
-		response := ad.Protocol()
-		responses = append(responses, response)
+	now := time.Now().In(getRwandaTimezone())
+	views := map[string]int{}
+	if cfg.FrequencyCap > 0 && req.UserID != "" {
+		if views, err = models.GetLocalAdUserViews(req.UserID, now.Format("20060102")); err != nil {
+			log.Get().Warnf("获取用户广告展示次数失败, 不做频次控制: user_id=%s, error=%v", req.UserID, err)
+		}
 	}
+	ranked := rankLocalAdvertisements(ads, &localAdRankContext{
+		Latitude:     filter.Latitude,
+		Longitude:    filter.Longitude,
+		RadiusKm:     cfg.RadiusKm,
+		City:         rankCity,
+		Now:          now,
+		Views:        views,
+		FrequencyCap: cfg.FrequencyCap,
+	})
 
-	log.Get().Infof("获取本地广告列表成功: city=%s, region=%s, category=%s, total=%d",
-		req.City, req.Region, req.Category, len(responses))
+	limit := cfg.MaxResults
+	if req.Limit > 0 && req.Limit < limit {
+		limit = req.Limit
+	}
+	responses := make([]protocol.LocalAdvertisement, 0, min(limit, len(ranked)))
+	for _, item := range ranked {
+		if len(responses) >= limit {
+			break
+		}
+		responses = append(responses, item.Protocol())
+	}
+
+	log.Get().Infof("获取本地广告列表成功: city=%s, region=%s, category=%s, placement=%s, candidates=%d, total=%d",
+		req.City, req.Region, req.Category, req.Placement, len(ads), len(responses))
 
 	return responses, protocol.Success
 }
 
+// tripDestination 行程中页面的推荐参考点：优先取当前订单的目的地，否则使用客户端传入的目的地
+func (s *LocalAdvertisementService) tripDestination(req *protocol.LocalAdvertisementListRequest) (float64, float64, bool) {
+	if req.OrderID == "" {
+		return req.DropoffLatitude, req.DropoffLongitude, req.DropoffLatitude != 0 || req.DropoffLongitude != 0
+	}
+	order := models.GetOrderByID(req.OrderID)
+	if order == nil || order.GetUserID() != req.UserID {
+		return 0, 0, false
+	}
+	if !slices.Contains([]string{protocol.StatusAccepted, protocol.StatusDriverComing, protocol.StatusDriverArrived, protocol.StatusInProgress}, order.GetStatus()) {
+		return 0, 0, false
+	}
+	ride := models.GetRideOrderByOrderID(req.OrderID)
+	if ride == nil {
+		return 0, 0, false
+	}
+	lat, lng := ride.GetDropoffLatitude(), ride.GetDropoffLongitude()
+	return lat, lng, lat != 0 || lng != 0
+}
+
 // UpdateAdvertisementStats 更新广告统计信息
 func (s *LocalAdvertisementService) UpdateAdvertisementStats(req *protocol.LocalAdvertisementStatsRequest) protocol.ErrorCode {
 	if req == nil || req.AdID == "" || req.StatsType == "" {
@@ -74,6 +148,14 @@ func (s *LocalAdvertisementService) UpdateAdvertisementStats(req *protocol.Local
 		return protocol.DatabaseError
 	}
 
+	// 记录用户当天的展示次数，用于频次控制
+	if req.StatsType == "view" && req.UserID != "" {
+		day := time.Now().In(getRwandaTimezone()).Format("20060102")
+		if err := models.IncrLocalAdUserViews(req.UserID, req.AdID, day); err != nil {
+			log.Get().Warnf("记录用户广告展示次数失败: ad_id=%s, user_id=%s, error=%v", req.AdID, req.UserID, err)
+		}
+	}
+
 	log.Get().Infof("更新广告统计成功: ad_id=%s, stats_type=%s, user_id=%s", req.AdID, req.StatsType, req.UserID)
 	return protocol.Success
 }
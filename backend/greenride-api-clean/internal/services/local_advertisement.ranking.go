@@ -0,0 +1,249 @@
+package services
+
+import (
+	"encoding/json"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"greenride/internal/models"
+	"greenride/internal/protocol"
+	"greenride/internal/utils"
+)
+
+// 广告排序得分权重
+const (
+	localAdDistanceWeight = 40.0 // 参考点上的广告得满分，半径边缘为0
+	localAdOpenBonus      = 10.0
+	localAdClosedPenalty  = 30.0 // 已打烊的商家排到后面，但不隐藏
+	localAdPriorityWeight = 2.0  // 每级优先级加分
+	localAdPriorityMax    = 20.0
+	localAdFeaturedBonus  = 15.0
+	localAdPromotedBonus  = 10.0
+	localAdCTRWeight      = 15.0
+	localAdCTRSaturation  = 0.10 // 点击率达到10%时点击率得分封顶
+	localAdCTRPriorClicks = 1.0  // 平滑：展示次数少的广告向先验点击率靠拢
+	localAdCTRPriorViews  = 50.0
+)
+
+// 营业状态
+type localAdOpenState int
+
+const (
+	localAdOpenUnknown localAdOpenState = iota
+	localAdOpen
+	localAdClosed
+)
+
+// localAdRankContext 排序参数
+type localAdRankContext struct {
+	Latitude     float64 // 参考点（上车点或目的地），为0时不按距离排序
+	Longitude    float64
+	RadiusKm     float64
+	City         string         // 请求城市，用于匹配广告的投放城市
+	Now          time.Time      // 当地时间，用于判断是否营业
+	Views        map[string]int // 用户当天各广告已展示次数
+	FrequencyCap int            // 每天最多展示次数，<=0表示不限
+}
+
+// rankedLocalAd 排序后的广告
+type rankedLocalAd struct {
+	Ad          *models.LocalAdvertisement
+	Score       float64
+	DistanceKm  float64
+	HasDistance bool
+	OpenState   localAdOpenState
+}
+
+// Protocol 转换为协议对象，附带距离和营业状态
+func (r *rankedLocalAd) Protocol() protocol.LocalAdvertisement {
+	result := r.Ad.Protocol()
+	if r.HasDistance {
+		result.DistanceKm = utils.RoundToTwoDecimal(r.DistanceKm)
+	}
+	if r.OpenState != localAdOpenUnknown {
+		result.OpenNow = utils.BoolPtr(r.OpenState == localAdOpen)
+	}
+	return result
+}
+
+// rankLocalAdvertisements 过滤超出半径、非投放城市和已达展示频次的广告，并按得分从高到低排序
+func rankLocalAdvertisements(ads []*models.LocalAdvertisement, rc *localAdRankContext) []*rankedLocalAd {
+	hasReference := rc.Latitude != 0 || rc.Longitude != 0
+	ranked := make([]*rankedLocalAd, 0, len(ads))
+	for _, ad := range ads {
+		if rc.FrequencyCap > 0 && rc.Views[ad.AdID] >= rc.FrequencyCap {
+			continue
+		}
+		if targets := ad.GetTargetCityList(); rc.City != "" && len(targets) > 0 &&
+			!slices.ContainsFunc(targets, func(city string) bool { return strings.EqualFold(city, rc.City) }) {
+			continue
+		}
+
+		item := &rankedLocalAd{Ad: ad, OpenState: localAdOpenStateAt(ad.GetOpeningHours(), rc.Now)}
+		if hasReference && (ad.GetLatitude() != 0 || ad.GetLongitude() != 0) {
+			item.DistanceKm = utils.CalculateDistanceHaversine(rc.Latitude, rc.Longitude, ad.GetLatitude(), ad.GetLongitude())
+			item.HasDistance = true
+			if item.DistanceKm > rc.RadiusKm {
+				continue
+			}
+			item.Score += localAdDistanceWeight * (1 - item.DistanceKm/rc.RadiusKm)
+		}
+		item.Score += localAdStaticScore(ad, item.OpenState)
+		ranked = append(ranked, item)
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].Ad.GetDisplayOrder() < ranked[j].Ad.GetDisplayOrder()
+	})
+	return ranked
+}
+
+// localAdStaticScore 与位置无关的得分：营业状态、优先级、精选/推广标记和点击率
+func localAdStaticScore(ad *models.LocalAdvertisement, openState localAdOpenState) float64 {
+	var score float64
+	switch openState {
+	case localAdOpen:
+		score += localAdOpenBonus
+	case localAdClosed:
+		score -= localAdClosedPenalty
+	}
+	score += min(float64(max(ad.GetPriority(), 0))*localAdPriorityWeight, localAdPriorityMax)
+	if ad.IsFeaturedAd() {
+		score += localAdFeaturedBonus
+	}
+	if ad.IsPromotedAd() {
+		score += localAdPromotedBonus
+	}
+	ctr := (float64(ad.GetClickCount()) + localAdCTRPriorClicks) / (float64(ad.GetViewCount()) + localAdCTRPriorViews)
+	score += min(ctr/localAdCTRSaturation, 1) * localAdCTRWeight
+	return score
+}
+
+// openingRange 一段营业时间，单位为当天分钟数；End<=Start 表示营业到次日
+type openingRange struct {
+	Start int
+	End   int
+}
+
+// localAdOpenStateAt 根据Google营业时间（weekday_text）判断指定时刻是否营业，包括前一天跨夜营业的时段
+func localAdOpenStateAt(openingHours string, now time.Time) localAdOpenState {
+	if openingHours == "" {
+		return localAdOpenUnknown
+	}
+	var hours struct {
+		WeekdayText []string `json:"weekday_text"`
+	}
+	if err := json.Unmarshal([]byte(openingHours), &hours); err != nil || len(hours.WeekdayText) == 0 {
+		return localAdOpenUnknown
+	}
+
+	byDay := make(map[time.Weekday][]openingRange)
+	for _, line := range hours.WeekdayText {
+		dayName, text, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		for day := time.Sunday; day <= time.Saturday; day++ {
+			if strings.EqualFold(strings.TrimSpace(dayName), day.String()) {
+				if ranges, ok := parseOpeningRanges(text); ok {
+					byDay[day] = ranges
+				}
+				break
+			}
+		}
+	}
+	today, ok := byDay[now.Weekday()]
+	if !ok {
+		return localAdOpenUnknown
+	}
+
+	minute := now.Hour()*60 + now.Minute()
+	for _, r := range today {
+		if minute >= r.Start && (minute < r.End || r.End <= r.Start) {
+			return localAdOpen
+		}
+	}
+	for _, r := range byDay[(now.Weekday()+6)%7] {
+		if r.End <= r.Start && minute < r.End {
+			return localAdOpen
+		}
+	}
+	return localAdClosed
+}
+
+// parseOpeningRanges 解析一天的营业时间，如 "7:00 AM – 10:00 PM"、"8 AM – 12 PM, 1 – 5 PM"、"Open 24 hours"、"Closed"
+func parseOpeningRanges(text string) ([]openingRange, bool) {
+	text = strings.NewReplacer("\u202f", " ", "\u2009", " ", "\u00a0", " ", "–", "-", "—", "-").Replace(text)
+	text = strings.ToUpper(strings.TrimSpace(text))
+	switch text {
+	case "CLOSED":
+		return []openingRange{}, true
+	case "OPEN 24 HOURS":
+		return []openingRange{{Start: 0, End: 24 * 60}}, true
+	}
+
+	var ranges []openingRange
+	for _, part := range strings.Split(text, ",") {
+		startText, endText, found := strings.Cut(part, "-")
+		if !found {
+			return nil, false
+		}
+		end, meridiem, ok := parseClockMinutes(endText, "")
+		if !ok {
+			return nil, false
+		}
+		// "1:00 – 5:00 PM" 起始时间省略了上下午，沿用结束时间的
+		start, _, ok := parseClockMinutes(startText, meridiem)
+		if !ok {
+			return nil, false
+		}
+		ranges = append(ranges, openingRange{Start: start, End: end})
+	}
+	return ranges, len(ranges) > 0
+}
+
+// parseClockMinutes 解析 "7:00 AM"、"7 PM"、"19:00" 为当天分钟数，返回使用的上下午标记
+func parseClockMinutes(text, defaultMeridiem string) (int, string, bool) {
+	text = strings.TrimSpace(text)
+	meridiem := defaultMeridiem
+	for _, suffix := range []string{"AM", "PM"} {
+		if strings.HasSuffix(text, suffix) {
+			meridiem = suffix
+			text = strings.TrimSpace(strings.TrimSuffix(text, suffix))
+			break
+		}
+	}
+
+	hourText, minuteText, _ := strings.Cut(text, ":")
+	hour, err := strconv.Atoi(hourText)
+	if err != nil {
+		return 0, "", false
+	}
+	minute := 0
+	if minuteText != "" {
+		if minute, err = strconv.Atoi(minuteText); err != nil || minute < 0 || minute > 59 {
+			return 0, "", false
+		}
+	}
+	switch meridiem {
+	case "AM", "PM":
+		if hour < 1 || hour > 12 {
+			return 0, "", false
+		}
+		hour %= 12
+		if meridiem == "PM" {
+			hour += 12
+		}
+	default:
+		if hour < 0 || hour > 24 {
+			return 0, "", false
+		}
+	}
+	return hour*60 + minute, meridiem, true
+}
@@ -0,0 +1,289 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"greenride/internal/config"
+	"greenride/internal/models"
+	"greenride/internal/protocol"
+	"greenride/internal/utils"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+)
+
+// newStubPlacesServer 模拟Google Places的搜索和详情接口
+func newStubPlacesServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("key") != "test-key" {
+			http.Error(w, "missing key", http.StatusForbidden)
+			return
+		}
+		var body any
+		switch r.URL.Path {
+		case "/textsearch/json":
+			if !strings.HasPrefix(r.URL.Query().Get("query"), "Java House") {
+				body = map[string]any{"status": "ZERO_RESULTS", "results": []any{}}
+				break
+			}
+			body = map[string]any{"status": "OK", "results": []map[string]any{
+				{"place_id": "place-java", "name": "Java House", "formatted_address": "KN 4 Ave, Kigali"},
+			}}
+		case "/details/json":
+			body = map[string]any{"status": "OK", "result": map[string]any{
+				"place_id":                   r.URL.Query().Get("place_id"),
+				"name":                       "Java House",
+				"formatted_address":          "KN 4 Ave, Kigali",
+				"international_phone_number": "+250 788 000 111",
+				"website":                    "https://javahouse.example",
+				"rating":                     4.4,
+				"user_ratings_total":         320,
+				"price_level":                2,
+				"types":                      []string{"cafe", "restaurant", "food"},
+				"opening_hours":              map[string]any{"open_now": true, "weekday_text": []string{"Monday: 7AM-9PM"}},
+				"geometry":                   map[string]any{"location": map[string]any{"lat": -1.9441, "lng": 30.0619}},
+				"photos":                     []map[string]any{{"photo_reference": "ref-1", "width": 800, "height": 600}},
+			}}
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(body)
+	}))
+}
+
+func TestLocalAdvertisementFromPlace(t *testing.T) {
+	srv := newStubPlacesServer(t)
+	defer srv.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	google := &GoogleService{
+		config:      &config.GoogleConfig{MapsAPIKey: "test-key", PlacesBaseURL: srv.URL},
+		httpClient:  srv.Client(),
+		rateLimiter: rate.NewLimiter(rate.Inf, 1),
+		logger:      logger,
+	}
+	ctx := context.Background()
+
+	if _, err := google.SearchPlacesByName(ctx, "Unknown Place", "Kigali", "RW"); err == nil {
+		t.Fatal("expected error for place with no results")
+	}
+	place, err := google.SearchPlacesByName(ctx, "Java House", "Kigali", "RW")
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	details, err := google.GetPlaceDetails(ctx, place.PlaceID)
+	if err != nil {
+		t.Fatalf("details failed: %v", err)
+	}
+	photoURL := google.GetPlacePhotoURL(details.Photos[0].PhotoReference, 800, 600)
+	if !strings.HasPrefix(photoURL, srv.URL+"/photo?") {
+		t.Fatalf("photo url should use configured base url, got %s", photoURL)
+	}
+
+	req := &protocol.ImportLocalAdvertisementsRequest{
+		UserID:   "A1",
+		City:     "Kigali",
+		Country:  "RW",
+		Category: protocol.LocalAdCategoryOther,
+		Status:   protocol.StatusPending,
+	}
+	ad := localAdvertisementFromPlace(details, req)
+	if ad.GetGooglePlaceID() != "place-java" || ad.GetName() != "Java House" {
+		t.Fatalf("unexpected place info: id=%s name=%s", ad.GetGooglePlaceID(), ad.GetName())
+	}
+	if ad.GetCategory() != protocol.LocalAdCategoryCafe {
+		t.Errorf("category = %s, want cafe", ad.GetCategory())
+	}
+	if ad.GetCity() != "Kigali" || ad.GetCountry() != "RW" || ad.GetPhone() != "+250 788 000 111" {
+		t.Errorf("unexpected location/contact: city=%s country=%s phone=%s", ad.GetCity(), ad.GetCountry(), ad.GetPhone())
+	}
+	if ad.GetStatus() != protocol.StatusPending || utils.SafeStringDeref(ad.CreatedBy) != "A1" {
+		t.Errorf("status=%s created_by=%s", ad.GetStatus(), utils.SafeStringDeref(ad.CreatedBy))
+	}
+	// 照片地址带有API Key，未转存前不能写入广告
+	if ad.GetImageURL() != "" || !strings.Contains(ad.GetOpeningHours(), "Monday") {
+		t.Errorf("image=%s opening_hours=%s", ad.GetImageURL(), ad.GetOpeningHours())
+	}
+}
+
+func TestLocalAdCategoryFromPlaceTypes(t *testing.T) {
+	cases := []struct {
+		types    []string
+		fallback string
+		want     string
+	}{
+		{[]string{"lodging", "restaurant"}, "", protocol.LocalAdCategoryHotel},
+		{[]string{"atm", "finance"}, "", protocol.LocalAdCategoryBank},
+		{[]string{"point_of_interest"}, protocol.LocalAdCategoryRestaurant, protocol.LocalAdCategoryRestaurant},
+		{[]string{"point_of_interest"}, "unknown", protocol.LocalAdCategoryOther},
+		{nil, "", protocol.LocalAdCategoryOther},
+	}
+	for _, c := range cases {
+		if got := localAdCategoryFromPlaceTypes(c.types, c.fallback); got != c.want {
+			t.Errorf("localAdCategoryFromPlaceTypes(%v, %q) = %s, want %s", c.types, c.fallback, got, c.want)
+		}
+	}
+}
+
+func TestLocalAdvertisementReport(t *testing.T) {
+	ad := models.NewLocalAdvertisement()
+	ad.SetName("Java House").SetStatus(protocol.StatusActive)
+	ad.ViewCount = utils.IntPtr(300)
+	ad.ClickCount = utils.IntPtr(12)
+	ad.CallCount = utils.IntPtr(1)
+
+	report := localAdvertisementReport(ad)
+	if report.ClickRate != 4 || report.CallRate != 0.33 {
+		t.Errorf("click_rate=%v call_rate=%v, want 4 and 0.33", report.ClickRate, report.CallRate)
+	}
+	if !report.IsLive {
+		t.Error("active ad without time window should be live")
+	}
+	if percentOf(5, 0) != 0 {
+		t.Error("rate with no views should be 0")
+	}
+}
+
+func TestValidateLocalAdvertisementRequest(t *testing.T) {
+	req := &protocol.CreateLocalAdvertisementRequest{Name: "Shop"}
+	if code := validateLocalAdvertisementRequest(req); code != protocol.Success {
+		t.Fatalf("defaults should be valid, got %v", code)
+	}
+	if req.Category != protocol.LocalAdCategoryOther || req.Status != protocol.StatusPending {
+		t.Errorf("defaults not applied: category=%s status=%s", req.Category, req.Status)
+	}
+	for _, bad := range []*protocol.CreateLocalAdvertisementRequest{
+		{Name: "Shop", Category: "casino"},
+		{Name: "Shop", Status: protocol.StatusDeleted},
+		{Name: "Shop", StartAt: 2000, EndAt: 1000},
+	} {
+		if code := validateLocalAdvertisementRequest(bad); code != protocol.InvalidParams {
+			t.Errorf("%+v should be rejected, got %v", bad, code)
+		}
+	}
+
+	values := localAdvertisementValuesFromRequest(&protocol.CreateLocalAdvertisementRequest{Name: " Shop ", TargetCities: []string{"Kigali", "Musanze"}})
+	if values.StartAt != nil || values.EndAt != nil || values.Latitude != nil {
+		t.Error("unset time window and location should be stored as NULL")
+	}
+	if *values.Name != "Shop" || *values.TargetCities != "Kigali,Musanze" {
+		t.Errorf("name=%q target_cities=%q", *values.Name, *values.TargetCities)
+	}
+}
+
+func TestLocalAdOpenStateAt(t *testing.T) {
+	hours := `{"weekday_text":[
+		"Monday: 7:00 AM – 10:00 PM",
+		"Tuesday: 8:00 AM – 12:00 PM, 1:00 – 5:00 PM",
+		"Wednesday: Open 24 hours",
+		"Thursday: 7:00 AM – 10:00 PM",
+		"Friday: 6:00 PM – 2:00 AM",
+		"Saturday: Closed",
+		"Sunday: Closed"]}`
+	at := func(day, hour, minute int) time.Time {
+		// 2026-10-12 是周一
+		return time.Date(2026, 10, 12+day, hour, minute, 0, 0, time.UTC)
+	}
+	cases := []struct {
+		name string
+		now  time.Time
+		want localAdOpenState
+	}{
+		{"monday morning", at(0, 8, 0), localAdOpen},
+		{"monday late night", at(0, 23, 0), localAdClosed},
+		{"tuesday lunch break", at(1, 12, 30), localAdClosed},
+		{"tuesday afternoon", at(1, 13, 30), localAdOpen},
+		{"wednesday midnight", at(2, 0, 5), localAdOpen},
+		{"friday evening", at(4, 21, 0), localAdOpen},
+		{"friday overnight into saturday", at(5, 1, 30), localAdOpen},
+		{"saturday afternoon", at(5, 15, 0), localAdClosed},
+	}
+	for _, c := range cases {
+		if got := localAdOpenStateAt(hours, c.now); got != c.want {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+
+	if got := localAdOpenStateAt("", at(0, 8, 0)); got != localAdOpenUnknown {
+		t.Errorf("empty hours should be unknown, got %v", got)
+	}
+	if got := localAdOpenStateAt(`{"weekday_text":["Monday: by appointment"]}`, at(0, 8, 0)); got != localAdOpenUnknown {
+		t.Errorf("unparseable hours should be unknown, got %v", got)
+	}
+}
+
+func TestRankLocalAdvertisements(t *testing.T) {
+	newAd := func(adID string, lat, lng float64) *models.LocalAdvertisement {
+		ad := models.NewLocalAdvertisement()
+		ad.AdID = adID
+		ad.SetName(adID).SetStatus(protocol.StatusActive)
+		if lat != 0 || lng != 0 {
+			ad.Latitude, ad.Longitude = utils.Float64Ptr(lat), utils.Float64Ptr(lng)
+		}
+		return ad
+	}
+	pickupLat, pickupLng := -1.9441, 30.0619
+
+	near := newAd("near", -1.9450, 30.0620)
+	farPromoted := newAd("far-promoted", -1.9900, 30.1000) // 约6.6公里
+	farPromoted.IsPromoted, farPromoted.IsFeatured = utils.BoolPtr(true), utils.BoolPtr(true)
+	outside := newAd("outside", -1.5000, 29.6000) // 超出半径
+	noLocation := newAd("no-location", 0, 0)
+	capped := newAd("capped", -1.9442, 30.0619)
+	otherCity := newAd("other-city", -1.9443, 30.0619)
+	otherCity.TargetCities = utils.StringPtr("Musanze,Huye")
+	closed := newAd("closed", -1.9441, 30.0619)
+	closed.OpeningHours = utils.StringPtr(`{"weekday_text":["Monday: Closed"]}`)
+
+	ranked := rankLocalAdvertisements(
+		[]*models.LocalAdvertisement{noLocation, farPromoted, outside, capped, otherCity, closed, near},
+		&localAdRankContext{
+			Latitude:     pickupLat,
+			Longitude:    pickupLng,
+			RadiusKm:     10,
+			City:         "Kigali",
+			Now:          time.Date(2026, 10, 12, 10, 0, 0, 0, time.UTC),
+			Views:        map[string]int{"capped": 5, "near": 2},
+			FrequencyCap: 5,
+		})
+
+	var order []string
+	for _, item := range ranked {
+		order = append(order, item.Ad.AdID)
+	}
+	// 已打烊的广告虽然最近也排在营业中的广告之后；没有坐标的广告不计距离分
+	want := []string{"near", "far-promoted", "closed", "no-location"}
+	if !slices.Equal(order, want) {
+		t.Fatalf("ranking = %v, want %v", order, want)
+	}
+	if !ranked[0].HasDistance || ranked[0].DistanceKm > 0.2 {
+		t.Errorf("nearest ad distance = %v", ranked[0].DistanceKm)
+	}
+	if p := ranked[2].Protocol(); p.OpenNow == nil || *p.OpenNow {
+		t.Errorf("closed ad should report open_now=false, got %v", p.OpenNow)
+	}
+	if p := ranked[3].Protocol(); p.OpenNow != nil || p.DistanceKm != 0 {
+		t.Errorf("ad without hours/location should omit open_now and distance, got %+v", p)
+	}
+
+	// 点击率高的广告在其他条件相同时排在前面
+	popular := newAd("popular", 0, 0)
+	popular.ViewCount, popular.ClickCount = utils.IntPtr(1000), utils.IntPtr(120)
+	unpopular := newAd("unpopular", 0, 0)
+	unpopular.ViewCount, unpopular.ClickCount = utils.IntPtr(1000), utils.IntPtr(2)
+	ranked = rankLocalAdvertisements([]*models.LocalAdvertisement{unpopular, popular}, &localAdRankContext{RadiusKm: 10, Now: time.Now()})
+	if ranked[0].Ad.AdID != "popular" {
+		t.Errorf("higher CTR should rank first, got %s", ranked[0].Ad.AdID)
+	}
+}
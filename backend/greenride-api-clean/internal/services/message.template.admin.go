@@ -0,0 +1,411 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"slices"
+	"sort"
+	"text/template/parse"
+
+	"greenride/internal/log"
+	"greenride/internal/models"
+	"greenride/internal/protocol"
+	"greenride/internal/utils"
+)
+
+// 可编辑模板的发送渠道
+var messageTemplateChannels = []string{
+	protocol.MsgChannelEmail,
+	protocol.MsgChannelSms,
+	protocol.MsgChannelFcm,
+}
+
+// 各消息类型发布时必须引用的变量，缺少会导致消息失去意义（如验证码短信不含验证码）
+var messageTemplateRequiredVariables = map[string][]string{
+	protocol.MsgTypeVerifyCode:            {"code"},
+	protocol.MsgTypeGeneric:               {"content"},
+	protocol.MsgTypeAnnouncement:          {"AnnouncementContent"},
+	protocol.MsgTypeRecipientDeliveryCode: {"code"},
+}
+
+// messageTemplateAllowedVariables 消息类型可用的变量：系统模板中出现过的变量加上默认参数；
+// 没有系统模板的类型返回nil，这类消息不会被发送
+func messageTemplateAllowedVariables(msgType string) []string {
+	seen := map[string]struct{}{}
+	found := false
+	for _, t := range builtinMessageTemplates() {
+		if t.Type != msgType {
+			continue
+		}
+		found = true
+		for _, text := range []string{t.Title, t.Content} {
+			vars, err := templateVariables(text)
+			if err != nil {
+				continue
+			}
+			for _, v := range vars {
+				seen[v] = struct{}{}
+			}
+		}
+	}
+	if !found {
+		return nil
+	}
+	for key := range DefaultParams {
+		seen[key] = struct{}{}
+	}
+	return sortedKeys(seen)
+}
+
+// templateVariables 解析模板并返回引用的顶层变量（{{.Name}}、{{$.Name}}），按字母排序
+func templateVariables(text string) ([]string, error) {
+	if text == "" {
+		return nil, nil
+	}
+	tmpl, err := template.New("template").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]struct{}{}
+	for _, t := range tmpl.Templates() {
+		if t.Tree != nil {
+			collectTemplateVariables(t.Tree.Root, seen)
+		}
+	}
+	return sortedKeys(seen), nil
+}
+
+// collectTemplateVariables 遍历语法树收集字段；range/with 内部的 . 已不是参数本身，不计入
+func collectTemplateVariables(node parse.Node, seen map[string]struct{}) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			collectTemplateVariables(child, seen)
+		}
+	case *parse.ActionNode:
+		collectTemplateVariables(n.Pipe, seen)
+	case *parse.IfNode:
+		collectTemplateVariables(n.Pipe, seen)
+		collectTemplateVariables(n.List, seen)
+		collectTemplateVariables(n.ElseList, seen)
+	case *parse.RangeNode:
+		collectTemplateVariables(n.Pipe, seen)
+		collectTemplateVariables(n.ElseList, seen)
+	case *parse.WithNode:
+		collectTemplateVariables(n.Pipe, seen)
+		collectTemplateVariables(n.ElseList, seen)
+	case *parse.TemplateNode:
+		collectTemplateVariables(n.Pipe, seen)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			collectTemplateVariables(cmd, seen)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			collectTemplateVariables(arg, seen)
+		}
+	case *parse.ChainNode:
+		collectTemplateVariables(n.Node, seen)
+	case *parse.FieldNode:
+		seen[n.Ident[0]] = struct{}{}
+	case *parse.VariableNode:
+		if n.Ident[0] == "$" && len(n.Ident) > 1 {
+			seen[n.Ident[1]] = struct{}{}
+		}
+	}
+}
+
+func sortedKeys(m map[string]struct{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// validateMessageTemplate 发布前校验：语法、未知变量、必需变量以及邮件/推送标题
+// 返回模板引用的全部变量和发现的问题
+func validateMessageTemplate(msgType, channel, title, content string) ([]string, []string) {
+	var problems []string
+	allowed := messageTemplateAllowedVariables(msgType)
+
+	seen := map[string]struct{}{}
+	for _, part := range []struct {
+		name string
+		text string
+	}{{"title", title}, {"content", content}} {
+		vars, err := templateVariables(part.text)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", part.name, err))
+			continue
+		}
+		for _, v := range vars {
+			seen[v] = struct{}{}
+			// 渲染内容时标题已作为 title 参数传入
+			if slices.Contains(allowed, v) || (part.name == "content" && v == "title") {
+				continue
+			}
+			problems = append(problems, fmt.Sprintf("%s: unknown variable %q", part.name, v))
+		}
+	}
+	for _, v := range messageTemplateRequiredVariables[msgType] {
+		if _, ok := seen[v]; !ok {
+			problems = append(problems, fmt.Sprintf("missing required variable %q", v))
+		}
+	}
+	if content == "" {
+		problems = append(problems, "content is empty")
+	}
+	if title == "" && (channel == protocol.MsgChannelEmail || channel == protocol.MsgChannelFcm) {
+		problems = append(problems, fmt.Sprintf("title is required for %s", channel))
+	}
+	return sortedKeys(seen), problems
+}
+
+// renderMessageTemplatePreview 按 PrepareMessage 的方式渲染：先渲染标题并作为 title 参数，再渲染内容；
+// 未提供的变量用 [变量名] 占位
+func renderMessageTemplatePreview(msgType, channel, title, content string, sample map[string]any) *protocol.MessageTemplatePreview {
+	variables, problems := validateMessageTemplate(msgType, channel, title, content)
+	preview := &protocol.MessageTemplatePreview{Variables: variables, Problems: problems}
+
+	params := make(map[string]any, len(DefaultParams)+len(sample)+len(variables))
+	for k, v := range DefaultParams {
+		params[k] = v
+	}
+	for k, v := range sample {
+		params[k] = v
+	}
+	for _, v := range variables {
+		if _, ok := params[v]; !ok && v != "title" {
+			params[v] = "[" + v + "]"
+			preview.MissingVariables = append(preview.MissingVariables, v)
+		}
+	}
+
+	render := func(name, text string) string {
+		tmpl, err := parseTemplate(text)
+		if err != nil || tmpl == nil {
+			return ""
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, params); err != nil {
+			preview.Problems = append(preview.Problems, fmt.Sprintf("%s: %v", name, err))
+			return ""
+		}
+		return buf.String()
+	}
+	preview.Title = render("title", title)
+	params["title"] = preview.Title
+	preview.Content = render("content", content)
+	return preview
+}
+
+// CreateTemplate 创建模板，内容保存为第一个草稿版本，发布前不影响发送
+func (m *MessageTemplateService) CreateTemplate(req *protocol.CreateMessageTemplateRequest) (*protocol.AdminMessageTemplate, protocol.ErrorCode) {
+	if !slices.Contains(messageTemplateChannels, req.Channel) || messageTemplateAllowedVariables(req.Type) == nil {
+		return nil, protocol.InvalidParams
+	}
+	if models.FindMessageTemplate(req.Type, req.Channel, req.Language, req.Region) != nil {
+		return nil, protocol.MessageTemplateExists
+	}
+
+	tmpl := &models.MessageTemplate{
+		TemplateID: utils.GenerateMessageTemplateID(),
+		Type:       req.Type,
+		Channel:    req.Channel,
+		Language:   req.Language,
+		Region:     req.Region,
+		Status:     protocol.StatusDraft,
+		CreatedBy:  req.UserID,
+		UpdatedBy:  req.UserID,
+	}
+	draft := models.NewMessageTemplateVersion(tmpl.TemplateID, 1)
+	draft.Title = req.Title
+	draft.Content = req.Content
+	draft.Description = req.Description
+	draft.ChangeNote = req.ChangeNote
+	draft.CreatedBy = req.UserID
+	draft.UpdatedBy = req.UserID
+	if err := models.CreateMessageTemplateWithDraft(tmpl, draft); err != nil {
+		log.Get().Errorf("创建消息模板失败: %v", err)
+		return nil, protocol.DatabaseError
+	}
+	return m.GetTemplateDetail(tmpl.TemplateID)
+}
+
+// SaveDraft 保存草稿：已有草稿时覆盖，否则基于最大版本号新建草稿
+func (m *MessageTemplateService) SaveDraft(req *protocol.SaveMessageTemplateDraftRequest) (*protocol.AdminMessageTemplate, protocol.ErrorCode) {
+	tmpl := models.GetMessageTemplateByID(req.TemplateID)
+	if tmpl == nil {
+		return nil, protocol.MessageTemplateNotFound
+	}
+
+	draft := models.GetMessageTemplateDraft(tmpl.TemplateID)
+	if draft == nil {
+		draft = models.NewMessageTemplateVersion(tmpl.TemplateID, models.GetMaxMessageTemplateVersion(tmpl.TemplateID)+1)
+		draft.CreatedBy = req.UserID
+	}
+	draft.Title = req.Title
+	draft.Content = req.Content
+	draft.Description = req.Description
+	draft.ChangeNote = req.ChangeNote
+	draft.UpdatedBy = req.UserID
+	if err := models.GetDB().Save(draft).Error; err != nil {
+		log.Get().Errorf("保存消息模板草稿失败, template_id=%s: %v", tmpl.TemplateID, err)
+		return nil, protocol.DatabaseError
+	}
+	return m.GetTemplateDetail(tmpl.TemplateID)
+}
+
+// PublishTemplate 发布草稿或指定版本（指定归档版本即回滚），校验不通过时返回问题列表
+func (m *MessageTemplateService) PublishTemplate(req *protocol.MessageTemplateActionRequest) ([]string, protocol.ErrorCode) {
+	tmpl := models.GetMessageTemplateByID(req.TemplateID)
+	if tmpl == nil {
+		return nil, protocol.MessageTemplateNotFound
+	}
+
+	var version *models.MessageTemplateVersion
+	if req.VersionID != "" {
+		if version = models.GetMessageTemplateVersion(tmpl.TemplateID, req.VersionID); version == nil {
+			return nil, protocol.MessageTemplateNotFound
+		}
+	} else if version = models.GetMessageTemplateDraft(tmpl.TemplateID); version == nil {
+		return nil, protocol.MessageTemplateStateInvalid
+	}
+	if version.Status == protocol.StatusActive && tmpl.Status == protocol.StatusActive {
+		return nil, protocol.MessageTemplateStateInvalid
+	}
+
+	if _, problems := validateMessageTemplate(tmpl.Type, tmpl.Channel, version.Title, version.Content); len(problems) > 0 {
+		return problems, protocol.MessageTemplateInvalid
+	}
+
+	if err := models.PublishMessageTemplateVersion(tmpl, version, req.UserID, utils.TimeNowMilli()); err != nil {
+		log.Get().Errorf("发布消息模板失败, template_id=%s, version=%d: %v", tmpl.TemplateID, version.Version, err)
+		return nil, protocol.DatabaseError
+	}
+	log.Get().Infof("消息模板已发布, template_id=%s, version=%d, operator=%s", tmpl.TemplateID, version.Version, req.UserID)
+	m.reload()
+	return nil, protocol.Success
+}
+
+// SetTemplateStatus 启用/停用已发布的模板，停用后回退到系统模板
+func (m *MessageTemplateService) SetTemplateStatus(req *protocol.MessageTemplateActionRequest) protocol.ErrorCode {
+	tmpl := models.GetMessageTemplateByID(req.TemplateID)
+	if tmpl == nil {
+		return protocol.MessageTemplateNotFound
+	}
+
+	var from []string
+	switch req.Status {
+	case protocol.StatusActive:
+		if tmpl.Version == 0 {
+			return protocol.MessageTemplateStateInvalid
+		}
+		from = []string{protocol.StatusInactive}
+	case protocol.StatusInactive:
+		from = []string{"", protocol.StatusActive}
+	default:
+		return protocol.InvalidParams
+	}
+
+	updated, err := models.UpdateMessageTemplateStatus(tmpl.TemplateID, from, req.Status, req.UserID)
+	if err != nil {
+		log.Get().Errorf("更新消息模板状态失败, template_id=%s: %v", tmpl.TemplateID, err)
+		return protocol.DatabaseError
+	}
+	if !updated {
+		return protocol.MessageTemplateStateInvalid
+	}
+	m.reload()
+	return protocol.Success
+}
+
+// DeleteTemplate 软删除模板，版本记录保留
+func (m *MessageTemplateService) DeleteTemplate(req *protocol.MessageTemplateActionRequest) protocol.ErrorCode {
+	tmpl := models.GetMessageTemplateByID(req.TemplateID)
+	if tmpl == nil {
+		return protocol.MessageTemplateNotFound
+	}
+	from := []string{"", protocol.StatusDraft, protocol.StatusActive, protocol.StatusInactive}
+	if _, err := models.UpdateMessageTemplateStatus(tmpl.TemplateID, from, protocol.StatusDeleted, req.UserID); err != nil {
+		log.Get().Errorf("删除消息模板失败, template_id=%s: %v", tmpl.TemplateID, err)
+		return protocol.DatabaseError
+	}
+	m.reload()
+	return protocol.Success
+}
+
+// SearchTemplates 分页查询模板
+func (m *MessageTemplateService) SearchTemplates(req *protocol.MessageTemplateSearchRequest) ([]*protocol.AdminMessageTemplate, int64) {
+	templates, total := models.SearchMessageTemplates(req)
+	list := make([]*protocol.AdminMessageTemplate, 0, len(templates))
+	for _, tmpl := range templates {
+		list = append(list, tmpl.Protocol())
+	}
+	return list, total
+}
+
+// GetTemplateDetail 模板详情，包括草稿、历史版本和可用变量
+func (m *MessageTemplateService) GetTemplateDetail(templateID string) (*protocol.AdminMessageTemplate, protocol.ErrorCode) {
+	tmpl := models.GetMessageTemplateByID(templateID)
+	if tmpl == nil {
+		return nil, protocol.MessageTemplateNotFound
+	}
+	result := tmpl.Protocol()
+	for _, version := range models.GetMessageTemplateVersions(templateID) {
+		if version.Status == protocol.StatusDraft && result.Draft == nil {
+			result.Draft = version.Protocol()
+			continue
+		}
+		result.Versions = append(result.Versions, version.Protocol())
+	}
+	result.Variables = messageTemplateAllowedVariables(tmpl.Type)
+	return result, protocol.Success
+}
+
+// PreviewTemplate 使用示例参数渲染模板，可预览已保存的版本或直接预览提交的内容
+func (m *MessageTemplateService) PreviewTemplate(req *protocol.MessageTemplatePreviewRequest) (*protocol.MessageTemplatePreview, protocol.ErrorCode) {
+	msgType, channel, title, content := req.Type, "", req.Title, req.Content
+	if req.TemplateID != "" {
+		tmpl := models.GetMessageTemplateByID(req.TemplateID)
+		if tmpl == nil {
+			return nil, protocol.MessageTemplateNotFound
+		}
+		msgType, channel = tmpl.Type, tmpl.Channel
+		// 未提交内容时预览已保存的版本：指定版本、草稿或当前发布版本
+		if req.Content == "" {
+			title, content = tmpl.Title, tmpl.Content
+			var version *models.MessageTemplateVersion
+			if req.VersionID != "" {
+				if version = models.GetMessageTemplateVersion(tmpl.TemplateID, req.VersionID); version == nil {
+					return nil, protocol.MessageTemplateNotFound
+				}
+			} else {
+				version = models.GetMessageTemplateDraft(tmpl.TemplateID)
+			}
+			if version != nil {
+				title, content = version.Title, version.Content
+			}
+		}
+	}
+	if messageTemplateAllowedVariables(msgType) == nil || content == "" {
+		return nil, protocol.InvalidParams
+	}
+	return renderMessageTemplatePreview(msgType, channel, title, content, req.Params), protocol.Success
+}
+
+// reload 本实例立即重新加载，其他实例由 watch 热加载
+func (m *MessageTemplateService) reload() {
+	if err := m.LoadTemplates(); err != nil {
+		log.Get().Errorf("重新加载消息模板失败: %v", err)
+	}
+}
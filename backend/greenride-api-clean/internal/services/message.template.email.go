@@ -91,6 +91,17 @@ var (
 		Description: "Registration success email template - Kinyarwanda",
 	}
 
+	// 公告邮件，内容由发送方按用户语言取好
+	DefaultAnnouncementEmail = &models.MessageTemplate{
+		Type:        protocol.MsgTypeAnnouncement,
+		Channel:     protocol.MsgChannelEmail,
+		Language:    protocol.Default,
+		Title:       "{{.AnnouncementTitle}}",
+		Content:     "{{.AnnouncementContent}}",
+		Status:      protocol.StatusActive,
+		Description: "Announcement email (localized by sender)",
+	}
+
 	// 默认Email模板集合
 	DefaultEmailTemplates = []*models.MessageTemplate{
 		// 英文模板
@@ -108,5 +119,8 @@ var (
 		// 卢旺达语模板
 		DefaultVerifyCodeEmailRW,
 		DefaultRegisterSuccessEmailRW,
+
+		// 公告模板
+		DefaultAnnouncementEmail,
 	}
 )
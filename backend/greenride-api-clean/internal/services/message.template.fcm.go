@@ -58,6 +58,36 @@ var (
 		Description: "Notification when ride is cancelled",
 	}
 
+	DefaultPassengerCouponIssuedFcmEN = &models.MessageTemplate{
+		Type:        protocol.MsgTypePassengerCouponIssued,
+		Channel:     protocol.MsgChannelFcm,
+		Language:    protocol.LangEnglish,
+		Title:       "New Coupon",
+		Content:     "You received a new coupon: {{.PromotionTitle}}. It is already in your account, apply it on your next ride.",
+		Status:      protocol.StatusActive,
+		Description: "Notification when a coupon is issued by a campaign",
+	}
+
+	DefaultPassengerPaymentFailedFcmEN = &models.MessageTemplate{
+		Type:        protocol.MsgTypePassengerPaymentFailed,
+		Channel:     protocol.MsgChannelFcm,
+		Language:    protocol.LangEnglish,
+		Title:       "Payment Failed",
+		Content:     "We could not charge {{.Amount}} {{.Currency}} for your trip. We will try again automatically, or you can pay now in the app.",
+		Status:      protocol.StatusActive,
+		Description: "Notification when an automatic trip charge fails and will be retried",
+	}
+
+	DefaultPassengerPaymentOverdueFcmEN = &models.MessageTemplate{
+		Type:        protocol.MsgTypePassengerPaymentOverdue,
+		Channel:     protocol.MsgChannelFcm,
+		Language:    protocol.LangEnglish,
+		Title:       "Payment Overdue",
+		Content:     "Your trip payment of {{.Amount}} {{.Currency}} is still unpaid. Please pay in the app; unpaid balances may prevent new bookings.",
+		Status:      protocol.StatusActive,
+		Description: "Notification when automatic trip charge retries are exhausted",
+	}
+
 	DefaultDriverNewOrderFcmEN = &models.MessageTemplate{
 		Type:        protocol.MsgTypeDriverNewOrder,
 		Channel:     protocol.MsgChannelFcm,
@@ -88,6 +118,16 @@ var (
 		Description: "Notification when payment is confirmed",
 	}
 
+	DefaultDriverTipReceivedFcmEN = &models.MessageTemplate{
+		Type:        protocol.MsgTypeDriverTipReceived,
+		Channel:     protocol.MsgChannelFcm,
+		Language:    protocol.LangEnglish,
+		Title:       "Tip Received",
+		Content:     "{{.PassengerName}} tipped you {{.Amount}} {{.Currency}}. It has been added to your wallet.",
+		Status:      protocol.StatusActive,
+		Description: "Notification when passenger tips the driver",
+	}
+
 	DefaultDriverOrderCancelledFcmEN = &models.MessageTemplate{
 		Type:        protocol.MsgTypeDriverOrderCancelled,
 		Channel:     protocol.MsgChannelFcm,
@@ -98,6 +138,37 @@ var (
 		Description: "Notification when ride is cancelled",
 	}
 
+	DefaultDriverDocumentExpiringFcmEN = &models.MessageTemplate{
+		Type:        protocol.MsgTypeDriverDocumentExpiring,
+		Channel:     protocol.MsgChannelFcm,
+		Language:    protocol.LangEnglish,
+		Title:       "Document Expiring Soon",
+		Content:     "Your {{.DocumentType}} expires on {{.ExpiryDate}} ({{.DaysLeft}} days left). Please upload a renewed copy to keep driving.",
+		Status:      protocol.StatusActive,
+		Description: "Notification when a driver document is about to expire",
+	}
+
+	DefaultDriverDocumentExpiredFcmEN = &models.MessageTemplate{
+		Type:        protocol.MsgTypeDriverDocumentExpired,
+		Channel:     protocol.MsgChannelFcm,
+		Language:    protocol.LangEnglish,
+		Title:       "Document Expired",
+		Content:     "Your {{.DocumentType}} expired on {{.ExpiryDate}}. You cannot receive rides until a renewed copy is approved.",
+		Status:      protocol.StatusActive,
+		Description: "Notification when a driver document has expired",
+	}
+
+	// 公告内容由发送方按用户语言取好，模板只做透传，不区分语言
+	DefaultAnnouncementFcm = &models.MessageTemplate{
+		Type:        protocol.MsgTypeAnnouncement,
+		Channel:     protocol.MsgChannelFcm,
+		Language:    protocol.Default,
+		Title:       "{{.AnnouncementTitle}}",
+		Content:     "{{.AnnouncementContent}}",
+		Status:      protocol.StatusActive,
+		Description: "Announcement push notification (localized by sender)",
+	}
+
 	// 法语FCM模板
 	DefaultPassengerOrderAcceptedFcmFR = &models.MessageTemplate{
 		Type:        protocol.MsgTypePassengerOrderAccepted,
@@ -149,6 +220,36 @@ var (
 		Description: "Notification when ride is cancelled (French)",
 	}
 
+	DefaultPassengerCouponIssuedFcmFR = &models.MessageTemplate{
+		Type:        protocol.MsgTypePassengerCouponIssued,
+		Channel:     protocol.MsgChannelFcm,
+		Language:    protocol.LangFrench,
+		Title:       "Nouveau coupon",
+		Content:     "Vous avez reçu un nouveau coupon : {{.PromotionTitle}}. Il est déjà dans votre compte, utilisez-le lors de votre prochaine course.",
+		Status:      protocol.StatusActive,
+		Description: "Notification when a coupon is issued by a campaign (French)",
+	}
+
+	DefaultPassengerPaymentFailedFcmFR = &models.MessageTemplate{
+		Type:        protocol.MsgTypePassengerPaymentFailed,
+		Channel:     protocol.MsgChannelFcm,
+		Language:    protocol.LangFrench,
+		Title:       "Échec du paiement",
+		Content:     "Nous n'avons pas pu débiter {{.Amount}} {{.Currency}} pour votre course. Nous réessaierons automatiquement, ou vous pouvez payer maintenant dans l'application.",
+		Status:      protocol.StatusActive,
+		Description: "Notification when an automatic trip charge fails and will be retried (French)",
+	}
+
+	DefaultPassengerPaymentOverdueFcmFR = &models.MessageTemplate{
+		Type:        protocol.MsgTypePassengerPaymentOverdue,
+		Channel:     protocol.MsgChannelFcm,
+		Language:    protocol.LangFrench,
+		Title:       "Paiement en retard",
+		Content:     "Le paiement de {{.Amount}} {{.Currency}} pour votre course est toujours impayé. Veuillez payer dans l'application ; un solde impayé peut bloquer de nouvelles réservations.",
+		Status:      protocol.StatusActive,
+		Description: "Notification when automatic trip charge retries are exhausted (French)",
+	}
+
 	DefaultDriverNewOrderFcmFR = &models.MessageTemplate{
 		Type:        protocol.MsgTypeDriverNewOrder,
 		Channel:     protocol.MsgChannelFcm,
@@ -179,6 +280,16 @@ var (
 		Description: "Notification when payment is confirmed (French)",
 	}
 
+	DefaultDriverTipReceivedFcmFR = &models.MessageTemplate{
+		Type:        protocol.MsgTypeDriverTipReceived,
+		Channel:     protocol.MsgChannelFcm,
+		Language:    protocol.LangFrench,
+		Title:       "Pourboire reçu",
+		Content:     "{{.PassengerName}} vous a laissé un pourboire de {{.Amount}} {{.Currency}}. Il a été ajouté à votre portefeuille.",
+		Status:      protocol.StatusActive,
+		Description: "Notification when passenger tips the driver (French)",
+	}
+
 	DefaultDriverOrderCancelledFcmFR = &models.MessageTemplate{
 		Type:        protocol.MsgTypeDriverOrderCancelled,
 		Channel:     protocol.MsgChannelFcm,
@@ -189,6 +300,26 @@ var (
 		Description: "Notification when ride is cancelled (French)",
 	}
 
+	DefaultDriverDocumentExpiringFcmFR = &models.MessageTemplate{
+		Type:        protocol.MsgTypeDriverDocumentExpiring,
+		Channel:     protocol.MsgChannelFcm,
+		Language:    protocol.LangFrench,
+		Title:       "Document bientôt expiré",
+		Content:     "Votre {{.DocumentType}} expire le {{.ExpiryDate}} (encore {{.DaysLeft}} jours). Veuillez téléverser une copie renouvelée pour continuer à conduire.",
+		Status:      protocol.StatusActive,
+		Description: "Notification when a driver document is about to expire (French)",
+	}
+
+	DefaultDriverDocumentExpiredFcmFR = &models.MessageTemplate{
+		Type:        protocol.MsgTypeDriverDocumentExpired,
+		Channel:     protocol.MsgChannelFcm,
+		Language:    protocol.LangFrench,
+		Title:       "Document expiré",
+		Content:     "Votre {{.DocumentType}} a expiré le {{.ExpiryDate}}. Vous ne pouvez plus recevoir de courses tant qu'une copie renouvelée n'est pas approuvée.",
+		Status:      protocol.StatusActive,
+		Description: "Notification when a driver document has expired (French)",
+	}
+
 	// 中文FCM模板
 	DefaultPassengerOrderAcceptedFcmZH = &models.MessageTemplate{
 		Type:        protocol.MsgTypePassengerOrderAccepted,
@@ -240,6 +371,36 @@ var (
 		Description: "Notification when ride is cancelled (Chinese)",
 	}
 
+	DefaultPassengerCouponIssuedFcmZH = &models.MessageTemplate{
+		Type:        protocol.MsgTypePassengerCouponIssued,
+		Channel:     protocol.MsgChannelFcm,
+		Language:    protocol.LangChinese,
+		Title:       "获得新优惠券",
+		Content:     "您获得了一张新优惠券：{{.PromotionTitle}}，已放入您的账户，下次乘车即可使用。",
+		Status:      protocol.StatusActive,
+		Description: "Notification when a coupon is issued by a campaign (Chinese)",
+	}
+
+	DefaultPassengerPaymentFailedFcmZH = &models.MessageTemplate{
+		Type:        protocol.MsgTypePassengerPaymentFailed,
+		Channel:     protocol.MsgChannelFcm,
+		Language:    protocol.LangChinese,
+		Title:       "扣款失败",
+		Content:     "您的行程费用{{.Amount}}{{.Currency}}扣款失败，我们会自动重试，您也可以在应用内立即支付。",
+		Status:      protocol.StatusActive,
+		Description: "Notification when an automatic trip charge fails and will be retried (Chinese)",
+	}
+
+	DefaultPassengerPaymentOverdueFcmZH = &models.MessageTemplate{
+		Type:        protocol.MsgTypePassengerPaymentOverdue,
+		Channel:     protocol.MsgChannelFcm,
+		Language:    protocol.LangChinese,
+		Title:       "行程费用待支付",
+		Content:     "您的行程费用{{.Amount}}{{.Currency}}仍未支付，请在应用内支付，未结清的欠款可能导致无法下单。",
+		Status:      protocol.StatusActive,
+		Description: "Notification when automatic trip charge retries are exhausted (Chinese)",
+	}
+
 	DefaultDriverNewOrderFcmZH = &models.MessageTemplate{
 		Type:        protocol.MsgTypeDriverNewOrder,
 		Channel:     protocol.MsgChannelFcm,
@@ -270,6 +431,16 @@ var (
 		Description: "Notification when payment is confirmed (Chinese)",
 	}
 
+	DefaultDriverTipReceivedFcmZH = &models.MessageTemplate{
+		Type:        protocol.MsgTypeDriverTipReceived,
+		Channel:     protocol.MsgChannelFcm,
+		Language:    protocol.LangChinese,
+		Title:       "收到小费",
+		Content:     "乘客{{.PassengerName}}给了您{{.Amount}}{{.Currency}}小费，已全额计入您的收入",
+		Status:      protocol.StatusActive,
+		Description: "Notification when passenger tips the driver (Chinese)",
+	}
+
 	DefaultDriverOrderCancelledFcmZH = &models.MessageTemplate{
 		Type:        protocol.MsgTypeDriverOrderCancelled,
 		Channel:     protocol.MsgChannelFcm,
@@ -280,6 +451,26 @@ var (
 		Description: "Notification when ride is cancelled (Chinese)",
 	}
 
+	DefaultDriverDocumentExpiringFcmZH = &models.MessageTemplate{
+		Type:        protocol.MsgTypeDriverDocumentExpiring,
+		Channel:     protocol.MsgChannelFcm,
+		Language:    protocol.LangChinese,
+		Title:       "证件即将过期",
+		Content:     "您的{{.DocumentType}}将于{{.ExpiryDate}}过期（剩余{{.DaysLeft}}天），请尽快上传更新后的证件",
+		Status:      protocol.StatusActive,
+		Description: "Notification when a driver document is about to expire (Chinese)",
+	}
+
+	DefaultDriverDocumentExpiredFcmZH = &models.MessageTemplate{
+		Type:        protocol.MsgTypeDriverDocumentExpired,
+		Channel:     protocol.MsgChannelFcm,
+		Language:    protocol.LangChinese,
+		Title:       "证件已过期",
+		Content:     "您的{{.DocumentType}}已于{{.ExpiryDate}}过期，新证件审核通过前将无法接单",
+		Status:      protocol.StatusActive,
+		Description: "Notification when a driver document has expired (Chinese)",
+	}
+
 	// 默认FCM模板集合
 	DefaultFcmTemplates = []*models.MessageTemplate{
 		// 英文模板
@@ -288,10 +479,16 @@ var (
 		DefaultPassengerTripStartedFcmEN,
 		DefaultPassengerTripEndedFcmEN,
 		DefaultPassengerOrderCancelledFcmEN,
+		DefaultPassengerCouponIssuedFcmEN,
+		DefaultPassengerPaymentFailedFcmEN,
+		DefaultPassengerPaymentOverdueFcmEN,
 		DefaultDriverNewOrderFcmEN,
 		DefaultDriverTripEndedFcmEN,
 		DefaultDriverPaymentConfirmedFcmEN,
+		DefaultDriverTipReceivedFcmEN,
 		DefaultDriverOrderCancelledFcmEN,
+		DefaultDriverDocumentExpiringFcmEN,
+		DefaultDriverDocumentExpiredFcmEN,
 
 		// 法语模板
 		DefaultPassengerOrderAcceptedFcmFR,
@@ -299,10 +496,16 @@ var (
 		DefaultPassengerTripStartedFcmFR,
 		DefaultPassengerTripEndedFcmFR,
 		DefaultPassengerOrderCancelledFcmFR,
+		DefaultPassengerCouponIssuedFcmFR,
+		DefaultPassengerPaymentFailedFcmFR,
+		DefaultPassengerPaymentOverdueFcmFR,
 		DefaultDriverNewOrderFcmFR,
 		DefaultDriverTripEndedFcmFR,
 		DefaultDriverPaymentConfirmedFcmFR,
+		DefaultDriverTipReceivedFcmFR,
 		DefaultDriverOrderCancelledFcmFR,
+		DefaultDriverDocumentExpiringFcmFR,
+		DefaultDriverDocumentExpiredFcmFR,
 
 		// 中文模板
 		DefaultPassengerOrderAcceptedFcmZH,
@@ -310,9 +513,18 @@ var (
 		DefaultPassengerTripStartedFcmZH,
 		DefaultPassengerTripEndedFcmZH,
 		DefaultPassengerOrderCancelledFcmZH,
+		DefaultPassengerCouponIssuedFcmZH,
+		DefaultPassengerPaymentFailedFcmZH,
+		DefaultPassengerPaymentOverdueFcmZH,
 		DefaultDriverNewOrderFcmZH,
 		DefaultDriverTripEndedFcmZH,
 		DefaultDriverPaymentConfirmedFcmZH,
+		DefaultDriverTipReceivedFcmZH,
 		DefaultDriverOrderCancelledFcmZH,
+		DefaultDriverDocumentExpiringFcmZH,
+		DefaultDriverDocumentExpiredFcmZH,
+
+		// 公告模板
+		DefaultAnnouncementFcm,
 	}
 )
@@ -7,6 +7,7 @@ import (
 	"greenride/internal/protocol"
 	"html/template"
 	"os"
+	"sync"
 	"time"
 )
 
@@ -38,9 +39,13 @@ var (
 	}
 )
 
+// 模板热加载检查间隔：其他实例发布或停用模板后，本实例在该间隔内重新加载
+const messageTemplateRefreshInterval = 30 * time.Second
+
 type MessageTemplateService struct {
 	TemplateLib map[string]*protocol.MessageTemplate
 	lastUpdate  int64
+	mu          sync.RWMutex
 }
 
 func SetupMessageTemplateService() {
@@ -50,6 +55,18 @@ func SetupMessageTemplateService() {
 	if err := templateService.LoadTemplates(); err != nil {
 		log.Get().Errorf("Failed to load message templates: %v", err)
 	}
+	go templateService.watch(messageTemplateRefreshInterval)
+}
+
+// watch 定期检查模板表更新时间，有变化时重新加载
+func (m *MessageTemplateService) watch(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := m.RefreshIfNeeded(); err != nil {
+			log.Get().Warnf("Failed to refresh message templates: %v", err)
+		}
+	}
 }
 
 func GetMessageTemplateService() *MessageTemplateService {
@@ -60,6 +77,8 @@ func GetMessageTemplateService() *MessageTemplateService {
 }
 
 func (m *MessageTemplateService) GetTemplateByMessage(message *Message, channel string) *protocol.MessageTemplate {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	var matchTemplate *protocol.MessageTemplate
 	var maxScore int
 	for _, template := range m.TemplateLib {
@@ -85,19 +104,19 @@ func (m *MessageTemplateService) GetTemplateByMessage(message *Message, channel
 }
 
 func (m *MessageTemplateService) LoadTemplates() error {
-	var templates []*models.MessageTemplate
-	if err := models.DB.Find(&templates).Error; err != nil {
+	// 先记录更新时间再读取，避免读取期间的修改被漏掉
+	lastUpdate, err := models.GetMessageTemplatesLastUpdatedAt()
+	if err != nil {
+		log.Get().Errorf("Failed to get message template update time: %v", err)
+	}
+	dbTemplates, err := models.GetLiveMessageTemplates()
+	if err != nil {
 		log.Get().Errorf("Failed to load message templates from database: %v", err)
 	}
 
-	// 添加默认的Email模板
-	templates = append(templates, DefaultEmailTemplates...)
-
-	// 添加默认的SMS模板
-	templates = append(templates, DefaultSmsTemplates...)
-
-	// 添加默认的FCM模板
-	templates = append(templates, DefaultFcmTemplates...)
+	// 系统自带模板在前，数据库中发布的同类型/渠道/语言/地区模板覆盖系统模板
+	templates := builtinMessageTemplates()
+	templates = append(templates, dbTemplates...)
 
 	newTemplates := map[string]*protocol.MessageTemplate{}
 	for _, t := range templates {
@@ -127,11 +146,22 @@ func (m *MessageTemplateService) LoadTemplates() error {
 		newTemplates[key] = pt
 	}
 
+	m.mu.Lock()
 	m.TemplateLib = newTemplates
-	m.lastUpdate = time.Now().UnixMilli()
+	m.lastUpdate = lastUpdate
+	m.mu.Unlock()
 	return nil
 }
 
+// builtinMessageTemplates 系统自带的Email、SMS和FCM模板
+func builtinMessageTemplates() []*models.MessageTemplate {
+	templates := make([]*models.MessageTemplate, 0, len(DefaultEmailTemplates)+len(DefaultSmsTemplates)+len(DefaultFcmTemplates))
+	templates = append(templates, DefaultEmailTemplates...)
+	templates = append(templates, DefaultSmsTemplates...)
+	templates = append(templates, DefaultFcmTemplates...)
+	return templates
+}
+
 // parseTemplate 封装模板解析逻辑
 func parseTemplate(content string) (*template.Template, error) {
 	if content == "" {
@@ -175,13 +205,15 @@ func (m *MessageTemplateService) LoadTemplate(mt *models.MessageTemplate) (*prot
 
 // RefreshIfNeeded 检查是否需要刷新模板缓存
 func (m *MessageTemplateService) RefreshIfNeeded() error {
-	var lastUpdated models.MessageTemplate
-	err := models.DB.Select([]string{"updated_at"}).Order("updated_at desc").First(&lastUpdated).Error
+	lastUpdated, err := models.GetMessageTemplatesLastUpdatedAt()
 	if err != nil {
 		return err
 	}
 
-	if m.lastUpdate < lastUpdated.UpdatedAt {
+	m.mu.RLock()
+	stale := m.lastUpdate < lastUpdated
+	m.mu.RUnlock()
+	if stale {
 		return m.LoadTemplates()
 	}
 	return nil
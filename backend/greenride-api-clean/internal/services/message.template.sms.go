@@ -26,6 +26,33 @@ var (
 		Description: "Generic SMS template - English",
 	}
 
+	DefaultDeliveryCodeSmsEN = &models.MessageTemplate{
+		Type:        protocol.MsgTypeRecipientDeliveryCode,
+		Channel:     protocol.MsgChannelSms,
+		Language:    protocol.LangEnglish,
+		Content:     "{{.sender_name}} is sending you a parcel with {{.app_name}}. Give the courier code {{.code}} when you receive it.",
+		Status:      protocol.StatusActive,
+		Description: "Parcel delivery code SMS template - English",
+	}
+
+	DefaultSafetySOSAlertSmsEN = &models.MessageTemplate{
+		Type:        protocol.MsgTypeSafetySOSAlert,
+		Channel:     protocol.MsgChannelSms,
+		Language:    protocol.LangEnglish,
+		Content:     "[{{.app_name}}] SOS from {{.user_name}} ({{.user_phone}}), trip {{.order_id}}. Location: {{.map_url}} Live trip: {{.share_url}}",
+		Status:      protocol.StatusActive,
+		Description: "Safety SOS alert SMS template - English",
+	}
+
+	DefaultTripSharedSmsEN = &models.MessageTemplate{
+		Type:        protocol.MsgTypeTripShared,
+		Channel:     protocol.MsgChannelSms,
+		Language:    protocol.LangEnglish,
+		Content:     "{{.user_name}} shared a {{.app_name}} trip with you. Follow it live: {{.share_url}}",
+		Status:      protocol.StatusActive,
+		Description: "Trip share SMS template - English",
+	}
+
 	// 中文SMS模板
 	DefaultVerifyCodeSmsZH = &models.MessageTemplate{
 		Type:        protocol.MsgTypeVerifyCode,
@@ -45,6 +72,33 @@ var (
 		Description: "通用短信模板 - 中文",
 	}
 
+	DefaultDeliveryCodeSmsZH = &models.MessageTemplate{
+		Type:        protocol.MsgTypeRecipientDeliveryCode,
+		Channel:     protocol.MsgChannelSms,
+		Language:    protocol.LangChinese,
+		Content:     "【{{.app_name}}】{{.sender_name}}给您寄了一个包裹，签收时请向司机出示取件码{{.code}}。",
+		Status:      protocol.StatusActive,
+		Description: "包裹取件码短信模板 - 中文",
+	}
+
+	DefaultSafetySOSAlertSmsZH = &models.MessageTemplate{
+		Type:        protocol.MsgTypeSafetySOSAlert,
+		Channel:     protocol.MsgChannelSms,
+		Language:    protocol.LangChinese,
+		Content:     "【{{.app_name}}】{{.user_name}}（{{.user_phone}}）发起紧急求助，订单{{.order_id}}，位置：{{.map_url}} 实时行程：{{.share_url}}",
+		Status:      protocol.StatusActive,
+		Description: "紧急求助短信模板 - 中文",
+	}
+
+	DefaultTripSharedSmsZH = &models.MessageTemplate{
+		Type:        protocol.MsgTypeTripShared,
+		Channel:     protocol.MsgChannelSms,
+		Language:    protocol.LangChinese,
+		Content:     "【{{.app_name}}】{{.user_name}}与您分享了行程，点击查看实时位置：{{.share_url}}",
+		Status:      protocol.StatusActive,
+		Description: "行程分享短信模板 - 中文",
+	}
+
 	// 法语SMS模板
 	DefaultVerifyCodeSmsFR = &models.MessageTemplate{
 		Type:        protocol.MsgTypeVerifyCode,
@@ -64,6 +118,33 @@ var (
 		Description: "Modèle SMS générique - Français",
 	}
 
+	DefaultDeliveryCodeSmsFR = &models.MessageTemplate{
+		Type:        protocol.MsgTypeRecipientDeliveryCode,
+		Channel:     protocol.MsgChannelSms,
+		Language:    protocol.LangFrench,
+		Content:     "{{.sender_name}} vous envoie un colis avec {{.app_name}}. Donnez le code {{.code}} au livreur à la réception.",
+		Status:      protocol.StatusActive,
+		Description: "Modèle SMS de code de livraison - Français",
+	}
+
+	DefaultSafetySOSAlertSmsFR = &models.MessageTemplate{
+		Type:        protocol.MsgTypeSafetySOSAlert,
+		Channel:     protocol.MsgChannelSms,
+		Language:    protocol.LangFrench,
+		Content:     "[{{.app_name}}] SOS de {{.user_name}} ({{.user_phone}}), course {{.order_id}}. Position : {{.map_url}} Trajet en direct : {{.share_url}}",
+		Status:      protocol.StatusActive,
+		Description: "Modèle SMS d'alerte SOS - Français",
+	}
+
+	DefaultTripSharedSmsFR = &models.MessageTemplate{
+		Type:        protocol.MsgTypeTripShared,
+		Channel:     protocol.MsgChannelSms,
+		Language:    protocol.LangFrench,
+		Content:     "{{.user_name}} partage un trajet {{.app_name}} avec vous. Suivez-le en direct : {{.share_url}}",
+		Status:      protocol.StatusActive,
+		Description: "Modèle SMS de partage de trajet - Français",
+	}
+
 	// 卢旺达语SMS模板
 	DefaultVerifyCodeSmsRW = &models.MessageTemplate{
 		Type:        protocol.MsgTypeVerifyCode,
@@ -83,22 +164,61 @@ var (
 		Description: "Generic SMS template - Kinyarwanda",
 	}
 
+	DefaultDeliveryCodeSmsRW = &models.MessageTemplate{
+		Type:        protocol.MsgTypeRecipientDeliveryCode,
+		Channel:     protocol.MsgChannelSms,
+		Language:    protocol.LangKinyarwanda,
+		Content:     "{{.sender_name}} akoherereje ipaki akoresheje {{.app_name}}. Uhe umushoferi kode {{.code}} uyakira.",
+		Status:      protocol.StatusActive,
+		Description: "Parcel delivery code SMS template - Kinyarwanda",
+	}
+
+	DefaultSafetySOSAlertSmsRW = &models.MessageTemplate{
+		Type:        protocol.MsgTypeSafetySOSAlert,
+		Channel:     protocol.MsgChannelSms,
+		Language:    protocol.LangKinyarwanda,
+		Content:     "[{{.app_name}}] {{.user_name}} ({{.user_phone}}) arasaba ubutabazi, urugendo {{.order_id}}. Aho ari: {{.map_url}} Kurikirana urugendo: {{.share_url}}",
+		Status:      protocol.StatusActive,
+		Description: "Safety SOS alert SMS template - Kinyarwanda",
+	}
+
+	DefaultTripSharedSmsRW = &models.MessageTemplate{
+		Type:        protocol.MsgTypeTripShared,
+		Channel:     protocol.MsgChannelSms,
+		Language:    protocol.LangKinyarwanda,
+		Content:     "{{.user_name}} agusangije urugendo rwa {{.app_name}}. Rukurikirane: {{.share_url}}",
+		Status:      protocol.StatusActive,
+		Description: "Trip share SMS template - Kinyarwanda",
+	}
+
 	// 默认SMS模板集合
 	DefaultSmsTemplates = []*models.MessageTemplate{
 		// 英文模板
 		DefaultVerifyCodeSmsEN,
 		DefaultGenericSmsEN,
+		DefaultDeliveryCodeSmsEN,
+		DefaultSafetySOSAlertSmsEN,
+		DefaultTripSharedSmsEN,
 
 		// 中文模板
 		DefaultVerifyCodeSmsZH,
 		DefaultGenericSmsZH,
+		DefaultDeliveryCodeSmsZH,
+		DefaultSafetySOSAlertSmsZH,
+		DefaultTripSharedSmsZH,
 
 		// 法语模板
 		DefaultVerifyCodeSmsFR,
 		DefaultGenericSmsFR,
+		DefaultDeliveryCodeSmsFR,
+		DefaultSafetySOSAlertSmsFR,
+		DefaultTripSharedSmsFR,
 
 		// 卢旺达语模板
 		DefaultVerifyCodeSmsRW,
 		DefaultGenericSmsRW,
+		DefaultDeliveryCodeSmsRW,
+		DefaultSafetySOSAlertSmsRW,
+		DefaultTripSharedSmsRW,
 	}
 )
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"greenride/internal/protocol"
+)
+
+func TestTemplateVariables(t *testing.T) {
+	cases := []struct {
+		text string
+		want []string
+	}{
+		{"Your code is {{.code}}", []string{"code"}},
+		{"{{if .CancelReason}}Reason: {{.CancelReason}}{{else}}{{$.app_name}}{{end}}", []string{"CancelReason", "app_name"}},
+		{"{{range .Items}}{{.Name}}{{end}} {{with .Order}}{{.ID}}{{end}}", []string{"Items", "Order"}},
+		{"{{printf \"%s %s\" .Amount .Currency | html}}", []string{"Amount", "Currency"}},
+		{"plain text", []string{}},
+	}
+	for _, c := range cases {
+		got, err := templateVariables(c.text)
+		if err != nil {
+			t.Fatalf("%q: unexpected error %v", c.text, err)
+		}
+		if !slices.Equal(got, c.want) {
+			t.Errorf("%q: got %v, want %v", c.text, got, c.want)
+		}
+	}
+
+	if _, err := templateVariables("{{.code"); err == nil {
+		t.Error("expected parse error for unclosed action")
+	}
+}
+
+func TestValidateMessageTemplate(t *testing.T) {
+	cases := []struct {
+		name    string
+		msgType string
+		channel string
+		title   string
+		content string
+		want    []string // 期望出现在问题列表中的片段，为空表示校验通过
+	}{
+		{"valid sms", protocol.MsgTypeVerifyCode, protocol.MsgChannelSms, "", "{{.app_name}} code: {{.code}}", nil},
+		{"content may use title", protocol.MsgTypeGeneric, protocol.MsgChannelEmail, "Hi", "<h1>{{.title}}</h1>{{.content}}", nil},
+		{"missing required", protocol.MsgTypeVerifyCode, protocol.MsgChannelSms, "", "Welcome to {{.app_name}}", []string{`missing required variable "code"`}},
+		{"unknown variable", protocol.MsgTypePassengerTripEnded, protocol.MsgChannelFcm, "Trip Ended", "Fare {{.Fare}}", []string{`content: unknown variable "Fare"`}},
+		{"syntax error", protocol.MsgTypeVerifyCode, protocol.MsgChannelSms, "", "code {{.code", []string{"content: template"}},
+		{"email needs title", protocol.MsgTypeVerifyCode, protocol.MsgChannelEmail, "", "{{.code}}", []string{"title is required for email"}},
+	}
+	for _, c := range cases {
+		_, problems := validateMessageTemplate(c.msgType, c.channel, c.title, c.content)
+		if len(c.want) == 0 && len(problems) > 0 {
+			t.Errorf("%s: unexpected problems %v", c.name, problems)
+		}
+		for _, want := range c.want {
+			if !slices.ContainsFunc(problems, func(p string) bool { return strings.Contains(p, want) }) {
+				t.Errorf("%s: problems %v missing %q", c.name, problems, want)
+			}
+		}
+	}
+
+	if messageTemplateAllowedVariables("no_such_type") != nil {
+		t.Error("types without system templates should not be editable")
+	}
+}
+
+func TestRenderMessageTemplatePreview(t *testing.T) {
+	preview := renderMessageTemplatePreview(protocol.MsgTypePassengerTripEnded, protocol.MsgChannelFcm,
+		"Trip to {{.DropoffAddress}}", "{{.title}}: {{.Amount}} {{.Currency}}",
+		map[string]any{"Amount": 2500})
+
+	if preview.Title != "Trip to [DropoffAddress]" {
+		t.Errorf("title = %q", preview.Title)
+	}
+	if preview.Content != "Trip to [DropoffAddress]: 2500 [Currency]" {
+		t.Errorf("content = %q", preview.Content)
+	}
+	if !slices.Equal(preview.MissingVariables, []string{"Currency", "DropoffAddress"}) {
+		t.Errorf("missing = %v", preview.MissingVariables)
+	}
+	if len(preview.Problems) != 0 {
+		t.Errorf("unexpected problems %v", preview.Problems)
+	}
+
+	// 示例参数按 html/template 转义，与实际发送一致
+	preview = renderMessageTemplatePreview(protocol.MsgTypeGeneric, protocol.MsgChannelEmail,
+		"{{.app_name}}", "<p>{{.content}}</p>", map[string]any{"content": "<b>hi</b>"})
+	if preview.Title != "Greenride" || preview.Content != "<p>&lt;b&gt;hi&lt;/b&gt;</p>" {
+		t.Errorf("escaped preview = %q / %q", preview.Title, preview.Content)
+	}
+}
@@ -6,4 +6,11 @@ func SetupService() {
 	InitUserTaskHandlers()
 	InitPaymentChannelHandlers()
 	InitOrderTaskHandlers()
+	InitSMSTaskHandlers()
+	InitDocumentTaskHandlers()
+	InitPromotionCampaignTaskHandlers()
+	InitAnnouncementTaskHandlers()
+	InitDunningTaskHandlers()
+	InitTipTaskHandlers()
+	SetupTranslationService()
 }
@@ -27,6 +27,7 @@ func InitOrderTaskHandlers() {
 	// 注册订单超时取消任务处理器
 	task.RegisterHandler(TaskOrderTimeoutCancel, OrderTimeoutCancelHandler)
 	task.RegisterHandler(TaskStuckOrderCancel, StuckOrderCancelHandler)
+	task.RegisterHandler(protocol.OrderCompleteHandler, OrderCompleteHandler)
 
 	// 注册定时任务
 	RegisterOrderTasks()
@@ -127,6 +128,24 @@ func OrderTimeoutCancelHandler(ctx context.Context, params protocol.MapData) err
 	return nil
 }
 
+// OrderCompleteHandler 处理订单完成信号：检查乘客和司机的邀请奖励条件
+func OrderCompleteHandler(ctx context.Context, params protocol.MapData) error {
+	orderID := params.Get("biz_id")
+	order := models.GetOrderByID(orderID)
+	if order == nil {
+		return fmt.Errorf("order not found: %v", orderID)
+	}
+	for _, userID := range []string{order.GetUserID(), order.GetProviderID()} {
+		if userID == "" {
+			continue
+		}
+		if err := GetReferralService().EvaluateReferral(userID); err != nil {
+			log.Get().Errorf("检查用户 %s 邀请奖励失败: %v", userID, err)
+		}
+	}
+	return nil
+}
+
 // processTimeoutOrders 处理超时的预约订单
 func processTimeoutOrders(ctx context.Context, timeoutDuration time.Duration) (int, error) {
 	// 计算超时时间点
@@ -137,7 +156,7 @@ func processTimeoutOrders(ctx context.Context, timeoutDuration time.Duration) (i
 	err := models.DB.WithContext(ctx).
 		Model(&models.Order{}).
 		Select("order_id").
-		Where("order_type = ?", protocol.RideOrder).
+		Where("order_type IN ?", []string{protocol.RideOrder, protocol.DeliveryOrder}).
 		Where("status = ?", protocol.StatusRequested).
 		Where("scheduled_at < ?", timeoutTimestamp).
 		Where("(provider_id IS NULL OR provider_id = '')").
@@ -144,7 +144,9 @@ func (s *OrderRatingService) GetRatingsByOrder(req *protocol.OrderIDRequest) []*
 	if err != nil {
 		return nil
 	}
-	return ratings.Protocol()
+	list := ratings.Protocol()
+	attachRatingTip(order, list)
+	return list
 }
 
 // GetRatingsByRatee 获取用户收到的评价
@@ -16,6 +16,7 @@ import (
 	"greenride/internal/protocol"
 	"greenride/internal/utils"
 
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 )
 
@@ -123,8 +124,13 @@ func (s *OrderService) GetOrdersByUser(req *protocol.UserRidesRequest) ([]*proto
 
 // CancelOrder 取消订单
 func (s *OrderService) CancelOrder(orderID, cancelledBy, reason string) protocol.ErrorCode {
+	return s.cancelOrder(orderID, cancelledBy, reason, nil)
+}
+
+// cancelOrder 取消订单，charge不为空时同时记录取消费并向乘客收取
+func (s *OrderService) cancelOrder(orderID, cancelledBy, reason string, charge *cancellationCharge) protocol.ErrorCode {
 	order := models.GetOrderByID(orderID)
-	if order == nil || order.GetOrderType() != protocol.RideOrder {
+	if order == nil || !isDispatchOrderType(order.GetOrderType()) {
 		return protocol.OrderNotFound
 	}
 	// 检查订单是否可以取消
@@ -133,12 +139,22 @@ func (s *OrderService) CancelOrder(orderID, cancelledBy, reason string) protocol
 	}
 
 	// 使用事务处理取消逻辑
+	var debt *models.UserDebt
 	err := models.DB.Transaction(func(tx *gorm.DB) error {
 		// 更新订单状态
 		values := &models.OrderValues{}
 		values.CancelOrder(cancelledBy, reason).
 			SetCompletedAt(utils.TimeNowMilli())
 
+		// 记录取消费并生成乘客欠款
+		if charge != nil && charge.Fee.IsPositive() {
+			var err error
+			if debt, err = GetCancellationService().recordCharge(tx, order, values, charge); err != nil {
+				log.Get().Errorf("订单 %s 记录取消费失败: %v", orderID, err)
+				return err
+			}
+		}
+
 		if err := models.UpdateOrder(tx, order, values); err != nil {
 			return err
 		}
@@ -151,7 +167,7 @@ func (s *OrderService) CancelOrder(orderID, cancelledBy, reason string) protocol
 		}
 
 		// 恢复用户优惠券状态
-		if err := models.ResetUserPromotionsByIDs(tx, order.GetUserPromotionIDs()); err != nil {
+		if err := models.ResetUserPromotionsByIDs(tx, order.OrderID, order.GetUserPromotionIDs()); err != nil {
 			log.Get().Errorf("取消订单时恢复用户优惠券失败: %v", err)
 			//return err
 		}
@@ -163,6 +179,12 @@ func (s *OrderService) CancelOrder(orderID, cancelledBy, reason string) protocol
 		return protocol.DatabaseError
 	}
 
+	// 按订单支付方式收取取消费
+	if debt != nil {
+		go GetCancellationService().CollectDebt(debt.DebtID)
+	}
+	GetRidePoolService().LeavePool(orderID)
+
 	// 发送FCM通知
 	go s.NotifyOrderCancelled(orderID)
 
@@ -172,7 +194,7 @@ func (s *OrderService) CancelOrder(orderID, cancelledBy, reason string) protocol
 // ReleaseOrder 释放订单
 func (s *OrderService) ReleaseOrder(orderID, releasedBy, reason string) protocol.ErrorCode {
 	order := models.GetOrderByID(orderID)
-	if order == nil || order.GetOrderType() != protocol.RideOrder {
+	if order == nil || !isDispatchOrderType(order.GetOrderType()) {
 		return protocol.OrderNotFound
 	}
 	// 检查订单是否可以取消
@@ -192,7 +214,7 @@ func (s *OrderService) ReleaseOrder(orderID, releasedBy, reason string) protocol
 		}
 
 		// 恢复用户优惠券状态
-		if err := models.ResetUserPromotionsByIDs(tx, order.GetUserPromotionIDs()); err != nil {
+		if err := models.ResetUserPromotionsByIDs(tx, order.OrderID, order.GetUserPromotionIDs()); err != nil {
 			log.Get().Errorf("取消订单时恢复用户优惠券失败: %v", err)
 			//return err
 		}
@@ -203,6 +225,7 @@ func (s *OrderService) ReleaseOrder(orderID, releasedBy, reason string) protocol
 	if err != nil {
 		return protocol.DatabaseError
 	}
+	GetRidePoolService().LeavePool(orderID)
 
 	// 发送FCM通知
 	go s.NotifyOrderCancelled(orderID)
@@ -232,8 +255,14 @@ func (s *OrderService) CancelOrderRequest(req *protocol.CancelOrderRequest) prot
 		return protocol.OrderNotFound
 	}
 
+	// 计算取消费，司机报乘客未到场时校验等待时长和定位
+	charge, errCode := GetCancellationService().quoteCharge(oldOrder, req.UserID, userType, req.ReasonKey)
+	if errCode != protocol.Success {
+		return errCode
+	}
+
 	// 调用取消订单的内部方法
-	errCode := s.CancelOrder(req.OrderID, req.UserID, reason)
+	errCode = s.cancelOrder(req.OrderID, req.UserID, reason, charge)
 
 	// 如果取消成功，记录历史
 	if errCode == protocol.Success {
@@ -283,6 +312,11 @@ func (s *OrderService) CanCancelOrder(status string) bool {
 	return slices.Contains(cancellableStates, status)
 }
 
+// isDispatchOrderType 是否为走派单及接单流程的订单类型
+func isDispatchOrderType(orderType string) bool {
+	return orderType == protocol.RideOrder || orderType == protocol.DeliveryOrder
+}
+
 func (s *OrderService) GetOrderInfoByID(orderId string) *protocol.Order {
 	order := models.GetOrderByID(orderId)
 	if order == nil {
@@ -312,6 +346,16 @@ func (s *OrderService) GetOrderInfo(order *models.Order) *protocol.Order {
 			info.Driver = provider.Protocol()
 		}
 	}
+	info.WaitingFare = s.getOrderWaitingFare(order)
+	switch order.GetOrderType() {
+	case protocol.RideOrder:
+		info.Stops = GetRideStopService().GetOrderStops(order.OrderID)
+	case protocol.DeliveryOrder:
+		info.Delivery = GetDeliveryService().GetDeliveryInfo(order.OrderID)
+	}
+	if tip := models.GetOrderTipByOrderID(order.OrderID); tip != nil {
+		info.Tip = tip.Protocol()
+	}
 	ratings := models.GetRatingsByOrderID(order.OrderID)
 	for _, rating := range ratings {
 		switch rating.RaterType {
@@ -321,10 +365,26 @@ func (s *OrderService) GetOrderInfo(order *models.Order) *protocol.Order {
 			info.DriverRatings = append(info.DriverRatings, rating.Protocol())
 		}
 	}
+	attachRatingTip(order, info.PassengerRatings)
 
 	return info
 }
 
+// getOrderWaitingFare 获取订单等候费：等候中及行程中实时计算，行程结束后取结束时记录的金额
+func (s *OrderService) getOrderWaitingFare(order *models.Order) *protocol.WaitingFare {
+	switch order.GetStatus() {
+	case protocol.StatusDriverArrived, protocol.StatusInProgress:
+		return GetPriceRuleService().CalculateWaitingFare(order)
+	}
+	data := protocol.NewMapData(order.GetMetadata()).GetMapData("waiting_fare")
+	if len(data) == 0 {
+		return nil
+	}
+	fare := &protocol.WaitingFare{}
+	data.ToObject(fare)
+	return fare
+}
+
 // GetOrderInfoSanitized returns order info with phone numbers masked based on
 // who is requesting. Only the assigned driver (after acceptance) can see the
 // passenger phone, and only the passenger (after a driver accepts) can see the
@@ -371,6 +431,28 @@ func (s *OrderService) GetOrderInfoSanitized(order *models.Order, requesterID, r
 		}
 	}
 
+	// 配送订单：收寄件人电话只对寄件人和承运司机可见，取件码只对寄件人可见
+	if info.Delivery != nil {
+		if !isPassenger && !(isAssignedDriver && revealStatuses[orderStatus]) {
+			info.Delivery.SenderPhone = utils.MaskPhone(info.Delivery.SenderPhone)
+			info.Delivery.RecipientPhone = utils.MaskPhone(info.Delivery.RecipientPhone)
+		}
+		if isPassenger {
+			info.Delivery.DeliveryCode = GetDeliveryService().GetDeliveryCode(order.OrderID)
+		}
+	}
+
+	// 小费：司机只能看到已支付或待收取的现金小费，不返回乘客的支付信息
+	if info.Tip != nil && !isPassenger {
+		if info.Tip.Status == protocol.TipStatusFailed {
+			info.Tip = nil
+		} else {
+			info.Tip.PaymentID = ""
+			info.Tip.RedirectURL = ""
+			info.Tip.Reason = ""
+		}
+	}
+
 	return info
 }
 
@@ -378,7 +460,7 @@ func (s *OrderService) GetOrderInfoSanitized(order *models.Order, requesterID, r
 // only if the requester is the assigned driver or the passenger on an active order.
 func (s *OrderService) GetOrderContactInfo(req *protocol.OrderContactRequest) (*protocol.OrderContactResponse, protocol.ErrorCode) {
 	order := models.GetOrderByID(req.OrderID)
-	if order == nil || order.GetOrderType() != protocol.RideOrder {
+	if order == nil || !isDispatchOrderType(order.GetOrderType()) {
 		return nil, protocol.OrderNotFound
 	}
 
@@ -470,9 +552,23 @@ func (s *OrderService) GetOrderETA(req *protocol.OrderETARequest) (*protocol.Ord
 		destLat = detail.GetPickupLatitude()
 		destLng = detail.GetPickupLongitude()
 	case protocol.StatusInProgress:
-		// Driver heading to dropoff
+		// Driver heading to dropoff, through the stops not yet reached
 		destLat = detail.GetDropoffLatitude()
 		destLng = detail.GetDropoffLongitude()
+		var pending []*protocol.RideStop
+		for _, stop := range GetRideStopService().GetOrderStops(order.OrderID) {
+			if stop.Status == protocol.RideStopStatusPending {
+				pending = append(pending, stop)
+			}
+		}
+		resp.RemainingStops = len(pending)
+		if len(pending) > 0 {
+			GetRideStopService().FillTripETA(resp,
+				routePoint{Lat: driver.GetLatitude(), Lng: driver.GetLongitude()},
+				routePoint{Lat: destLat, Lng: destLng},
+				pending)
+			return resp, protocol.Success
+		}
 	default:
 		return resp, protocol.Success
 	}
@@ -523,8 +619,24 @@ func (s *OrderService) EstimateOrder(req *protocol.EstimateRequest) (*protocol.O
 		req.VehicleLevel = "economy" // 默认经济型
 	}
 
-	// 1. 使用 GoogleService 获取准确的路线信息
-	if req.EstimatedDistance == 0 || req.EstimatedDuration == 0 {
+	// 常用地点ID代替坐标时先换成地点坐标
+	if errCode := GetSavedPlaceService().ResolveEstimatePlaces(req); errCode != protocol.Success {
+		return nil, errCode
+	}
+	if errCode := GetDeliveryService().ValidateEstimate(req); errCode != protocol.Success {
+		return nil, errCode
+	}
+	if errCode := GetRidePoolService().ValidateRideType(req); errCode != protocol.Success {
+		return nil, errCode
+	}
+
+	// 1. 使用 GoogleService 获取准确的路线信息，有途经点时按途经顺序分段计算
+	if len(req.Stops) > 0 {
+		if errCode := GetRideStopService().ValidateStops(req.Stops); errCode != protocol.Success {
+			return nil, errCode
+		}
+		GetRideStopService().EnrichRoute(req)
+	} else if req.EstimatedDistance == 0 || req.EstimatedDuration == 0 {
 		s.EnrichRouteByGoogleMap(req)
 	}
 
@@ -629,8 +741,8 @@ func (s *OrderService) ArrivedOrder(req *protocol.OrderActionRequest) protocol.E
 	if order.GetStatus() != protocol.StatusDriverComing && order.GetStatus() != protocol.StatusAccepted {
 		return protocol.InvalidRideStatus // 司机状态不正确，无法标记到达
 	}
-	//检查当前司机是否有其他进行中的订单
-	if s.CountActiveRideOrdersByDriver(user.UserID, order.OrderID) > 0 {
+	//检查当前司机是否有其他进行中的订单（同一拼车中的订单除外）
+	if GetRidePoolService().CountActiveRideOrdersOutsidePool(user.UserID, order.OrderID) > 0 {
 		return protocol.DriverHasActiveOrderInProgress // 司机有在途订单，不能开启新行程
 	}
 	err := models.DB.Transaction(func(tx *gorm.DB) error {
@@ -669,8 +781,8 @@ func (s *OrderService) StartOrder(req *protocol.OrderActionRequest) protocol.Err
 	if !slices.Contains([]string{protocol.StatusDriverArrived, protocol.StatusAccepted}, order.GetStatus()) {
 		return protocol.InvalidRideStatus
 	}
-	//检查当前司机是否有其他进行中的订单
-	if s.CountActiveRideOrdersByDriver(user.UserID, order.OrderID) > 0 {
+	//检查当前司机是否有其他进行中的订单（同一拼车中的订单除外）
+	if GetRidePoolService().CountActiveRideOrdersOutsidePool(user.UserID, order.OrderID) > 0 {
 		return protocol.DriverHasActiveOrderInProgress // 司机有在途订单，不能开启新行程
 	}
 	orderValues := models.OrderValues{}
@@ -678,6 +790,7 @@ func (s *OrderService) StartOrder(req *protocol.OrderActionRequest) protocol.Err
 	if err := models.UpdateOrder(models.DB, order, &orderValues); err != nil {
 		return protocol.DatabaseError
 	}
+	GetRidePoolService().CompleteStop(order.OrderID, protocol.PoolStopTypePickup)
 	go GetUserService().RefreshDriverOrderQueue(req.UserID)
 	// 发送FCM通知
 	go s.NotifyTripStarted(req.OrderID)
@@ -712,18 +825,38 @@ func (s *OrderService) FinishOrder(req *protocol.OrderActionRequest) protocol.Er
 	if order.GetStatus() != protocol.StatusInProgress {
 		return protocol.RideNotStarted // 行程还没开始
 	}
+	// 司机到达至开始行程之间的等候费计入最终金额
+	waitingFare := GetPriceRuleService().CalculateWaitingFare(order)
 	err := models.DB.Transaction(func(tx *gorm.DB) error {
 		orderValues := models.OrderValues{}
 		orderValues.FinishOrder()
+		if waitingFare != nil && waitingFare.Amount > 0 {
+			amount := decimal.NewFromFloat(waitingFare.Amount)
+			metadata := order.GetMetadata()
+			metadata["waiting_fare"] = waitingFare
+			orderValues.SetOriginalAmount(order.GetOriginalAmount().Add(amount)).
+				SetDiscountedAmount(order.GetDiscountedAmount().Add(amount)).
+				SetPaymentAmount(order.GetPaymentAmount().Add(amount)).
+				SetMetadata(metadata)
+			priceID, _ := metadata["price_id"].(string)
+			breakdown := GetPriceRuleService().WaitingFareBreakdown(waitingFare)
+			if err := models.AddPriceSnapshotWaitingFare(tx, priceID, breakdown); err != nil {
+				return err
+			}
+		}
 		return models.UpdateOrder(tx, order, &orderValues)
 	})
 	if err != nil {
+		log.Get().Errorf("结束订单失败, order_id=%s, err=%v", order.OrderID, err)
 		return protocol.DatabaseError
 	}
 
+	GetRidePoolService().CompleteStop(order.OrderID, protocol.PoolStopTypeDropoff)
 	go GetUserService().RefreshDriverOrderQueue(req.UserID)
 	// 发送FCM通知
 	go s.NotifyTripEnded(req.OrderID)
+	// 使用乘客的默认支付方式自动扣款
+	go GetSavedPaymentMethodService().ChargeDefault(req.OrderID)
 
 	// Increment ride counts for both driver and passenger
 	go s.incrementRideCountsForOrder(order)
@@ -753,7 +886,7 @@ func (s *OrderService) GetActiveRideOrderByUser(userID string) (*models.Order, e
 // CountActiveRideOrdersByUser counts active ride orders for a user
 func (s *OrderService) CountActiveRideOrdersByUser(userID string) int64 {
 	var count int64
-	err := models.DB.Model(&models.Order{}).Where("user_id = ? AND status IN (?)", userID, []string{protocol.StatusRequested, protocol.StatusPending, protocol.StatusAccepted, protocol.StatusInProgress}).Count(&count).Error
+	err := models.DB.Model(&models.Order{}).Where("user_id = ? AND order_type = ? AND status IN (?)", userID, protocol.RideOrder, []string{protocol.StatusRequested, protocol.StatusPending, protocol.StatusAccepted, protocol.StatusInProgress}).Count(&count).Error
 	if err != nil {
 		return 0
 	}
@@ -772,6 +905,8 @@ func (s *OrderService) GenerateOrderIDByType(orderType string) string {
 	switch orderType {
 	case protocol.RideOrder:
 		return utils.GenerateRideOrderID()
+	case protocol.DeliveryOrder:
+		return utils.GenerateOrderIDByType(orderType)
 	default:
 		return utils.GenerateOrderID()
 	}
@@ -781,6 +916,11 @@ func (s *OrderService) GenerateOrderIDByType(orderType string) string {
 func (s *OrderService) CreateOrder(req *protocol.CreateOrderRequest) (*protocol.Order, protocol.ErrorCode) {
 	log.Get().Infof("OrderService.CreateOrder: 开始创建订单，UserID=%s, PriceID=%s", req.UserID, req.PriceID)
 
+	// 欠款超过上限的乘客结清前不能下单
+	if errCode := GetDunningService().CheckRideAllowed(req.UserID); errCode != protocol.Success {
+		return nil, errCode
+	}
+
 	// 调用价格验证和锁定逻辑
 	pricingService := GetPriceRuleService()
 	price, errCode := pricingService.ValidateAndLockPriceID(req.PriceID)
@@ -802,6 +942,11 @@ func (s *OrderService) CreateOrder(req *protocol.CreateOrderRequest) (*protocol.
 			return nil, protocol.RideInProgress
 		}
 		log.Get().Infof("OrderService.CreateOrder: 用户没有活跃订单，继续创建")
+	case protocol.DeliveryOrder:
+		// 配送订单不占用寄件人的行程，只校验收件人和包裹信息
+		if errCode := GetDeliveryService().ValidateContact(req.Delivery); errCode != protocol.Success {
+			return nil, errCode
+		}
 	default:
 		log.Get().Errorf("OrderService.CreateOrder: 不支持的订单类型=%s", price.GetOrderType())
 		// 其他订单类型暂时不支持
@@ -809,6 +954,15 @@ func (s *OrderService) CreateOrder(req *protocol.CreateOrderRequest) (*protocol.
 	}
 	// 从快照metadata获取业务参数
 	snapshotMeta := price.GetMetadata()
+	// 途经点以预估快照为准，下单时传入的途经点必须与预估一致
+	stops := GetRideStopService().GetSnapshotStops(price)
+	if len(req.Stops) > 0 && !stopsMatch(req.Stops, stops) {
+		return nil, protocol.RideStopsMismatch
+	}
+	placeIDs, errCode := GetSavedPlaceService().CheckOrderPlaces(req, price)
+	if errCode != protocol.Success {
+		return nil, errCode
+	}
 	nowtime := utils.TimeNowMilli()
 	// 创建订单metadata，只记录必要信息
 	metadata := map[string]any{}
@@ -840,7 +994,11 @@ func (s *OrderService) CreateOrder(req *protocol.CreateOrderRequest) (*protocol.
 		userID = price.GetUserID()
 	}
 	orderConfig := config.Get().Order
-	expiredAt := time.Now().Add(time.Duration(orderConfig.RideOrder.ExpireMinutes) * time.Minute).UnixMilli()
+	expireMinutes := orderConfig.RideOrder.ExpireMinutes
+	if price.GetOrderType() == protocol.DeliveryOrder {
+		expireMinutes = config.GetDeliveryConfig().ExpireMinutes
+	}
+	expiredAt := time.Now().Add(time.Duration(expireMinutes) * time.Minute).UnixMilli()
 
 	order.SetScheduleType(protocol.ScheduleTypeInstant).
 		SetStatus(protocol.StatusRequested).
@@ -916,6 +1074,7 @@ func (s *OrderService) CreateOrder(req *protocol.CreateOrderRequest) (*protocol.
 				SetEstimatedDistance(price.GetDistance()).
 				SetEstimatedDuration(price.GetDuration()).
 				SetPassengerCount(snapshotMeta.GetInt("passenger_count")).
+				SetRideType(snapshotMeta.Get("ride_type")).
 				SetPickupAddress(snapshotMeta.Get("pickup_address")).
 				SetPickupLatitude(snapshotMeta.GetFloat64("pickup_latitude")).
 				SetPickupLongitude(snapshotMeta.GetFloat64("pickup_longitude")).
@@ -928,6 +1087,14 @@ func (s *OrderService) CreateOrder(req *protocol.CreateOrderRequest) (*protocol.
 			if err := tx.Create(rideOrder).Error; err != nil {
 				return err
 			}
+			if err := models.CreateRideOrderStops(tx, order.OrderID, stops); err != nil {
+				return err
+			}
+		case protocol.DeliveryOrder:
+			deliveryOrder := GetDeliveryService().NewDeliveryOrder(order.OrderID, price, req.Delivery, user)
+			if err := tx.Create(deliveryOrder).Error; err != nil {
+				return err
+			}
 		}
 		// If manually selecting a driver, create exactly one dispatch record for that driver.
 		if selectedProviderID != "" {
@@ -958,19 +1125,25 @@ func (s *OrderService) CreateOrder(req *protocol.CreateOrderRequest) (*protocol.
 			if item.Category != protocol.PriceRuleCategoryUserPromotion {
 				continue
 			}
-			if err := models.UseUserPromotionByID(tx, item.RuleID, order.OrderID, item.Amount); err != nil {
+			if err := models.UseUserPromotionByID(tx, order.GetUserID(), item.RuleID, order.OrderID, item.Amount); err != nil {
 				return err
 			}
 		}
 
 		return nil
 	})
+	if errors.Is(err, models.ErrPromotionBudgetExceeded) {
+		return nil, protocol.PromotionBudgetExceeded
+	}
 	if err != nil {
 		return nil, protocol.DatabaseError
 	}
 	orderInfo := s.GetOrderInfo(order)
 
 	go GetUserService().RefreshUserOrderQueue(req.UserID)
+	if order.GetOrderType() == protocol.DeliveryOrder {
+		go GetDeliveryService().SendRecipientCode(order.OrderID)
+	}
 	// Dispatch:
 	// - manual: notify selected provider only
 	// - auto: start auto dispatch
@@ -982,6 +1155,9 @@ func (s *OrderService) CreateOrder(req *protocol.CreateOrderRequest) (*protocol.
 	}
 	// 记录订单创建历史
 	go GetOrderHistoryService().RecordOrderCreated(order, req.UserID)
+	if len(placeIDs) > 0 {
+		go GetSavedPlaceService().RecordPlaceUsage(placeIDs)
+	}
 
 	return orderInfo, protocol.Success
 }
@@ -1034,7 +1210,7 @@ func (s *OrderService) AcceptOrder(req *protocol.OrderActionRequest) protocol.Er
 		order = models.GetOrderByID(req.OrderID)
 	}
 	// 先获取要接受的订单信息，确定订单类型
-	if order == nil || order.GetOrderType() != protocol.RideOrder {
+	if order == nil || !isDispatchOrderType(order.GetOrderType()) {
 		return protocol.OrderNotFound
 	}
 
@@ -1082,11 +1258,21 @@ func (s *OrderService) AcceptOrder(req *protocol.OrderActionRequest) protocol.Er
 			return nil
 		}
 
-		// 更新网约车订单表的车辆ID
-		return tx.Model(&models.RideOrder{}).
-			Where("order_id = ?", req.OrderID).
-			Update("vehicle_id", vehicle.VehicleID).Error
+		// 更新订单详情表的车辆ID
+		if err := tx.Table(models.GetTableNameByOrderType(order.GetOrderType())).
+			Where("order_id = ?", order.OrderID).
+			Update("vehicle_id", vehicle.VehicleID).Error; err != nil {
+			return err
+		}
+		// 拼车订单加入司机的拼车，无法加入时回滚接单
+		return GetRidePoolService().JoinPool(tx, user, vehicle, order.OrderID)
 	})
+	if errors.Is(err, errPoolNotCompatible) {
+		return protocol.PoolNotCompatible
+	}
+	if errors.Is(err, errPoolSeatsExceeded) {
+		return protocol.PoolSeatsExceeded
+	}
 	if err != nil {
 		return protocol.DatabaseError
 	}
@@ -1129,7 +1315,7 @@ func (s *OrderService) RejectOrder(req *protocol.OrderActionRequest) protocol.Er
 		order = models.GetOrderByID(req.OrderID)
 	}
 	// 先获取要接受的订单信息，确定订单类型
-	if order == nil || order.GetOrderType() != protocol.RideOrder {
+	if order == nil || !isDispatchOrderType(order.GetOrderType()) {
 		return protocol.OrderNotFound
 	}
 
@@ -1292,11 +1478,13 @@ func (s *OrderService) PrepareCashPayment(req *protocol.OrderCashRequest) (*prot
 		return nil, protocol.DatabaseError
 	}
 
+	tipAmount, _ := GetTipService().GetPendingCashTip(order.OrderID).Float64()
 	return &protocol.OrderCashResponse{
 		OrderID:       order.OrderID,
 		Status:        protocol.StatusPending,
 		PaymentMethod: protocol.PaymentMethodCash,
 		CashCode:      code,
+		TipAmount:     tipAmount,
 	}, protocol.Success
 }
 
@@ -1335,6 +1523,24 @@ func (s *OrderService) OrderPayment(req *protocol.OrderPaymentRequest) (result *
 			}
 		}
 	}
+	channelReq := &ChannelPaymentRequest{
+		Phone:         req.Phone,
+		Email:         req.Email,
+		AccountNo:     req.AccountNo,
+		AccountName:   req.AccountName,
+		PaymentMethod: req.PaymentMethod,
+		Order:         order,
+		User:          user,
+	}
+	// 指定了已保存的支付方式，或乘客未选择支付方式时使用默认支付方式
+	savedMethodID := ""
+	if req.PaymentMethodID != "" || (req.PaymentMethod == "" && order.GetPaymentMethod() == "" && user.IsPassenger()) {
+		savedMethodID, errCode = GetSavedPaymentMethodService().ApplySavedMethod(user.UserID, req.PaymentMethodID, channelReq)
+		if errCode != protocol.Success {
+			return
+		}
+		req.PaymentMethod = channelReq.PaymentMethod
+	}
 	if order.GetPaymentStatus() == protocol.StatusSuccess {
 		result = &protocol.OrderPaymentResult{
 			OrderID: order.OrderID,
@@ -1353,18 +1559,16 @@ func (s *OrderService) OrderPayment(req *protocol.OrderPaymentRequest) (result *
 	}
 	values := &models.OrderValues{}
 	values.SetPaymentMethod(req.PaymentMethod)
-	cresult, errCode := GetPaymentService().OrderPayment(&ChannelPaymentRequest{
-		Phone:         req.Phone,
-		Email:         req.Email,
-		AccountNo:     req.AccountNo,
-		AccountName:   req.AccountName,
-		PaymentMethod: req.PaymentMethod,
-		Order:         order,
-		User:          user,
-	})
+	cresult, errCode := GetPaymentService().OrderPayment(channelReq)
 	if errCode != protocol.Success {
 		return
 	}
+	if savedMethodID != "" {
+		amount, _ := order.GetPaymentAmount().Float64()
+		if err := models.RecordUserPaymentMethodUsage(savedMethodID, amount, cresult.Status != protocol.StatusFailed); err != nil {
+			log.Get().Warnf("记录支付方式 %s 使用情况失败: %v", savedMethodID, err)
+		}
+	}
 
 	values.SetPaymentMethod(req.PaymentMethod).
 		SetPaymentID(cresult.PaymentID).
@@ -1401,8 +1605,15 @@ func (s *OrderService) OrderPayment(req *protocol.OrderPaymentRequest) (result *
 	}
 	// 支付成功时，发送支付确认消息
 	if order.GetPaymentStatus() == protocol.StatusSuccess {
+		GetDunningService().SettleFareDebt(order.OrderID, req.PaymentMethod)
 		go s.NotifyPaymentConfirmed(req.OrderID)
 		go s.incrementRideCountsForOrder(order)
+		// 现金小费随车费一起收取
+		if req.PaymentMethod == protocol.PaymentMethodCash {
+			go GetTipService().ConfirmCashTip(order.OrderID)
+		}
+	} else if cresult.Status == protocol.StatusFailed {
+		go GetDunningService().RecordChargeFailure(order.OrderID, order.GetPaymentResult())
 	}
 
 	result = &protocol.OrderPaymentResult{
@@ -1415,6 +1626,26 @@ func (s *OrderService) OrderPayment(req *protocol.OrderPaymentRequest) (result *
 }
 
 func (s *OrderService) CheckOrderPayment(order_id, payment_id string) {
+	payment := models.GetPaymentByID(payment_id)
+	if payment == nil {
+		payment = models.GetLastPaymentByOrderID(order_id)
+	}
+	if payment == nil || payment.GetOrderID() != order_id {
+		return
+	}
+	// 小费支付记录以小费ID作为订单号，支付方式验证记录以用户支付方式ID作为订单号，欠款扣款记录以欠款ID作为订单号，按支付记录的订单类型分发
+	switch payment.GetOrderType() {
+	case protocol.TipOrder:
+		GetTipService().CheckTipPayment(order_id, payment.PaymentID)
+		return
+	case protocol.PaymentMethodVerifyOrder:
+		GetSavedPaymentMethodService().CheckVerifyPayment(order_id, payment.PaymentID)
+		return
+	case protocol.DebtOrder:
+		GetCancellationService().CheckDebtPayment(order_id, payment.PaymentID)
+		return
+	}
+
 	order := models.GetOrderByID(order_id)
 	if order == nil {
 		return
@@ -1425,13 +1656,6 @@ func (s *OrderService) CheckOrderPayment(order_id, payment_id string) {
 	if order.GetPaymentMethod() == protocol.PaymentMethodCash {
 		return
 	}
-	payment := models.GetPaymentByID(payment_id)
-	if payment == nil {
-		payment = models.GetLastPaymentByOrderID(order_id)
-	}
-	if payment == nil {
-		return
-	}
 	if order.GetPaymentStatus() == payment.GetStatus() {
 		return
 	}
@@ -1455,9 +1679,13 @@ func (s *OrderService) CheckOrderPayment(order_id, payment_id string) {
 		return
 	}
 	// 支付成功时，发送支付确认消息
-	if order.GetPaymentStatus() == protocol.StatusSuccess {
+	switch order.GetPaymentStatus() {
+	case protocol.StatusSuccess:
+		GetDunningService().SettleFareDebt(order.OrderID, order.GetPaymentMethod())
 		go s.NotifyPaymentConfirmed(order.OrderID)
 		go s.incrementRideCountsForOrder(order)
+	case protocol.StatusFailed:
+		go GetDunningService().RecordChargeFailure(order.OrderID, order.GetPaymentResult())
 	}
 	log.Get().Info("OrderService.CheckOrderPayment: 订单支付状态已更新", "OrderID", order.OrderID, "PaymentStatus", order.GetPaymentStatus())
 }
@@ -1497,6 +1725,10 @@ func (s *OrderService) incrementRideCountsForOrder(order *models.Order) {
 			}
 		}
 	}
+	// 行程计数更新后再发送订单完成信号，邀请奖励等依赖最新的完成单数
+	if err := SendSignal(protocol.SignalOrderComplete, order.OrderID); err != nil {
+		log.Get().Warnf("Failed to send order complete signal for %s: %v", order.OrderID, err)
+	}
 }
 
 // ============================================================================
@@ -1610,8 +1842,8 @@ func (s *OrderService) NotifyPassenger(order *models.Order, notificationType str
 		Language: getUserLanguage(passenger), // 使用用户偏好语言
 	}
 
-	// 使用消息服务发送
-	return GetMessageService().SendMessage(message)
+	// 写入站内通知并按用户偏好推送
+	return GetUserNotificationService().Deliver(passenger, message)
 }
 
 // NotifyDriver 通知司机 - 接收订单对象作为参数
@@ -1687,8 +1919,8 @@ func (s *OrderService) NotifyDriver(order *models.Order, notificationType string
 		Language: getUserLanguage(driver), // 使用用户偏好语言
 	}
 
-	// 使用消息服务发送
-	return GetMessageService().SendMessage(message)
+	// 写入站内通知并按用户偏好推送
+	return GetUserNotificationService().Deliver(driver, message)
 }
 
 // NotifyOrderAccepted 通知乘客订单已被接单（司机触发）
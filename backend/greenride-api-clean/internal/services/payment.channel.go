@@ -14,6 +14,12 @@ type ChannelPaymentRequest struct {
 	AuthToken     string
 	Order         *models.Order
 	User          *models.User
+
+	// 使用已保存的支付方式扣款时设置：CardID为用户支付方式ID，ChannelAccountID指定保存时的渠道账户，
+	// Metadata携带渠道令牌（如Stripe客户和卡的ID）
+	CardID           string
+	ChannelAccountID string
+	Metadata         protocol.MapData
 }
 
 // PaymentChannel 支付渠道接口
@@ -182,6 +182,8 @@ func (s *PaymentService) OrderPayment(req *ChannelPaymentRequest) (*protocol.Cha
 		SetAccountNo(req.AccountNo).
 		SetAccountName(req.AccountName).
 		SetAmount(paymentAmount).
+		SetCardID(req.CardID).
+		SetMetadata(req.Metadata).
 		SetReturnURL(fmt.Sprintf("%v?user_id=%v&checkout_id=%v", cfg.ReturnURL, order.GetUserID(), s.GetCheckoutID(payment)))
 	// 5. 创建支付记录
 	if err := models.DB.Create(payment).Error; err != nil {
@@ -244,7 +246,14 @@ func (s *PaymentService) OrderPayment(req *ChannelPaymentRequest) (*protocol.Cha
 			Amount:        paymentAmount.String(),
 		}
 
-		routerInfo, errorCode := s.GetPaymentRouter(routeRequest)
+		var routerInfo *RouterInfo
+		var errorCode protocol.ErrorCode
+		if req.ChannelAccountID != "" {
+			// 已保存的令牌只在保存时的渠道账户下有效，不走路由表
+			routerInfo, errorCode = s.GetChannelRouter(req.ChannelAccountID)
+		} else {
+			routerInfo, errorCode = s.GetPaymentRouter(routeRequest)
+		}
 		if errorCode != protocol.Success {
 			log.Get().Errorf("获取支付路由失败: order_id=%s, payment_method=%s, currency=%s, region=%s, amount=%s, error_code=%s",
 				order.OrderID, req.PaymentMethod, order.GetCurrency(), user.GetCountryCode(), paymentAmount.String(), errorCode)
@@ -533,6 +542,29 @@ func (s *PaymentService) GetPaymentRouter(req *protocol.PaymentRouteRequest) (*R
 	return nil, protocol.NoAvailablePaymentService
 }
 
+// GetChannelRouter 按渠道账户ID获取支付服务
+func (s *PaymentService) GetChannelRouter(channelAccountID string) (*RouterInfo, protocol.ErrorCode) {
+	channel, exists := PaymentChannels[channelAccountID]
+	if !exists || channel == nil {
+		log.Get().Errorf("未找到支付渠道服务: channel_account_id=%s", channelAccountID)
+		return nil, protocol.NoAvailablePaymentService
+	}
+	channelCode := ""
+	switch channel.(type) {
+	case *StripeService:
+		channelCode = protocol.PaymentChannelStripe
+	case *MoMoService:
+		channelCode = protocol.PaymentChannelMoMo
+	case *KPayService:
+		channelCode = protocol.PaymentChannelKPay
+	}
+	return &RouterInfo{
+		ChannelService:   channel,
+		ChannelCode:      channelCode,
+		ChannelAccountID: channelAccountID,
+	}, protocol.Success
+}
+
 func (s *PaymentService) CancelPayment(req *protocol.CancelPaymentRequest) protocol.ErrorCode {
 	order := models.GetOrderByID(req.OrderID)
 	// 如果提供了用户ID，检查订单归属
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"math"
 	"slices"
 	"strings"
 	"sync"
@@ -28,6 +29,9 @@ var SupportedRuleCategories = []string{
 	protocol.PriceRuleCategoryDistanceFare,
 	protocol.PriceRuleCategoryTimeFare,
 	protocol.PriceRuleCategoryServiceFee,
+	protocol.PriceRuleCategoryWaitingFare,
+	protocol.PriceRuleCategoryPoolDiscount,
+	protocol.PriceRuleCategoryParcelFare,
 }
 var (
 	OncePriceRuleCategories = []string{
@@ -40,6 +44,9 @@ var (
 		protocol.PriceRuleCategorySpecialOffer,
 		protocol.PriceRuleCategoryTimeFare,
 		protocol.PriceRuleCategoryServiceFee,
+		protocol.PriceRuleCategoryWaitingFare,
+		protocol.PriceRuleCategoryPoolDiscount,
+		protocol.PriceRuleCategoryParcelFare,
 	}
 )
 
@@ -174,12 +181,18 @@ func (s *PriceRuleService) IsRuleApplied(ctx *PriceContext) (bool, string) {
 		return false, fmt.Sprintf("Not applicable to user category: %v", req.UserCategory)
 	}
 
-	// 5. Status check
+	// 5. Order type restriction check
+	applicableRides := rule.GetApplicableRides()
+	if len(applicableRides) > 0 && !slices.Contains(applicableRides, requestOrderType(req)) {
+		return false, fmt.Sprintf("Not applicable to order type: %v", requestOrderType(req))
+	}
+
+	// 6. Status check
 	if rule.GetStatus() != protocol.StatusActive {
 		return false, "Rule not activated"
 	}
 
-	// 6. Special checks based on rule category
+	// 7. Special checks based on rule category
 	category := rule.GetCategory()
 	switch category {
 	case protocol.PriceRuleCategorySurgePricing:
@@ -221,11 +234,46 @@ func (s *PriceRuleService) IsRuleApplied(ctx *PriceContext) (bool, string) {
 			// }
 		}
 	case protocol.PriceRuleCategoryUserPromotion:
+	case protocol.PriceRuleCategoryPoolDiscount:
+		// Pool discount only applies to pool rides
+		if req.RideType != protocol.RideTypePool {
+			return false, "Not a pool ride"
+		}
+	case protocol.PriceRuleCategoryParcelFare:
+		// Parcel fare only applies to delivery orders with parcel details
+		if requestOrderType(req) != protocol.DeliveryOrder || req.Parcel == nil {
+			return false, "Not a parcel delivery"
+		}
 	}
 
 	return true, ""
 }
 
+// requestOrderType returns the order type of an estimate request, ride orders by default
+func requestOrderType(req *protocol.EstimateRequest) string {
+	if req.OrderType == "" {
+		return protocol.RideOrder
+	}
+	return req.OrderType
+}
+
+// sortRulesByOrderType moves rules restricted to the order type ahead of generic rules, keeping priority order
+// within each group, so that delivery-specific rules win over generic ones for once-only categories
+func sortRulesByOrderType(rules []*models.PriceRule, orderType string) {
+	slices.SortStableFunc(rules, func(a, b *models.PriceRule) int {
+		aSpecific := slices.Contains(a.GetApplicableRides(), orderType)
+		bSpecific := slices.Contains(b.GetApplicableRides(), orderType)
+		switch {
+		case aSpecific == bSpecific:
+			return 0
+		case aSpecific:
+			return -1
+		default:
+			return 1
+		}
+	})
+}
+
 // CalculateRule unified rule calculation entry point - includes applicability judgment and price calculation
 func (s *PriceRuleService) CalculateRule(ctx *PriceContext) (result *protocol.PriceRuleResult) {
 	rule := ctx.Rule
@@ -312,6 +360,20 @@ func (s *PriceRuleService) CalculateRule(ctx *PriceContext) (result *protocol.Pr
 		result = s.CalculateServiceFee(ctx)
 		ctx.Snapshot.SetServiceFee(ctx.Snapshot.GetServiceFee().Add(decimal.NewFromFloat(result.Amount)))
 		return
+	case protocol.PriceRuleCategoryWaitingFare:
+		// Waiting fare depends on the actual wait at pickup, see CalculateWaitingFare
+		result.Applied = false
+		result.Reason = "Waiting fare is charged after pickup, not estimated"
+		return
+	case protocol.PriceRuleCategoryPoolDiscount:
+		result = s.CalculatePoolDiscount(ctx)
+		newAmount := ctx.Snapshot.GetDiscountAmount().Add(decimal.NewFromFloat(result.Amount))
+		ctx.Snapshot.SetDiscountAmount(newAmount)
+		return
+	case protocol.PriceRuleCategoryParcelFare:
+		result = s.CalculateParcelFare(ctx)
+		ctx.Snapshot.SetParcelFare(ctx.Snapshot.GetParcelFare().Add(decimal.NewFromFloat(result.Amount)))
+		return
 	default:
 		result.Applied = false
 		result.Reason = fmt.Sprintf("Unsupported rule category: %v (supported types: %v)", category, SupportedRuleCategories)
@@ -909,6 +971,116 @@ func (s *PriceRuleService) CalculateDiscount(ctx *PriceContext) (result *protoco
 	return result
 }
 
+// CalculatePoolDiscount calculates the pool ride discount on the fare of all other charges
+func (s *PriceRuleService) CalculatePoolDiscount(ctx *PriceContext) (result *protocol.PriceRuleResult) {
+	rule := ctx.Rule
+	req := ctx.Request
+	result = &protocol.PriceRuleResult{
+		RuleID:      rule.RuleID,
+		RuleName:    rule.GetRuleName(),
+		Category:    rule.GetCategory(),
+		DisplayName: rule.GetDisplayName(),
+		Applied:     true,
+	}
+
+	snapshot := ctx.Snapshot
+	fare := snapshot.GetBaseFare().Add(snapshot.GetSurgeFare()).Add(snapshot.GetDistanceFare()).
+		Add(snapshot.GetTimeFare()).Add(snapshot.GetServiceFee())
+	percent, fixed, maxDiscount := 0.0, 0.0, 0.0
+	switch rule.GetRuleType() {
+	case protocol.PriceRuleTypePercentage:
+		if rule.DiscountPercent == nil {
+			result.Applied = false
+			result.Reason = "Pool discount rule missing percentage configuration"
+			return result
+		}
+		percent = *rule.DiscountPercent
+	case protocol.PriceRuleTypeFixedAmount:
+		if rule.DiscountAmount == nil {
+			result.Applied = false
+			result.Reason = "Pool discount rule missing discount amount configuration"
+			return result
+		}
+		fixed = *rule.DiscountAmount
+	default:
+		result.Applied = false
+		result.Reason = fmt.Sprintf("Pool discount does not support rule type: %v", rule.GetRuleType())
+		return result
+	}
+	if rule.MaxDiscount != nil {
+		maxDiscount = *rule.MaxDiscount
+	}
+
+	amount := calculatePoolDiscount(utils.DecimalToFloat64(fare), percent, fixed, maxDiscount)
+	result.Amount = -amount
+	result.Description = fmt.Sprintf("Pool discount: %v%.2f off fare %v%.2f", req.Currency, amount, req.Currency, utils.DecimalToFloat64(fare))
+	return result
+}
+
+// calculatePoolDiscount returns the discount as a positive amount, capped by maxDiscount when set and never above the fare
+func calculatePoolDiscount(fare, percent, fixed, maxDiscount float64) float64 {
+	if fare <= 0 {
+		return 0
+	}
+	amount := fixed
+	if percent > 0 {
+		amount = fare * percent / 100
+	}
+	if maxDiscount > 0 && amount > maxDiscount {
+		amount = maxDiscount
+	}
+	if amount > fare {
+		amount = fare
+	}
+	if amount < 0 {
+		amount = 0
+	}
+	return utils.RoundToTwoDecimal(amount)
+}
+
+// CalculateParcelFare calculates the parcel fare of a delivery order.
+// The size fee comes from metadata.size_fees (e.g. {"small": 500, "large": 2000}) and falls back to base_rate;
+// weight above metadata.free_weight_kg is charged per started kg at metadata.per_kg_rate, capped at maximum_fare.
+func (s *PriceRuleService) CalculateParcelFare(ctx *PriceContext) (result *protocol.PriceRuleResult) {
+	rule := ctx.Rule
+	req := ctx.Request
+	result = &protocol.PriceRuleResult{
+		RuleID:      rule.RuleID,
+		RuleName:    rule.GetRuleName(),
+		Category:    rule.GetCategory(),
+		DisplayName: rule.GetDisplayName(),
+		Applied:     true,
+	}
+
+	metadata := protocol.NewMapData(rule.Metadata)
+	sizeFee := rule.GetBaseRate()
+	if sizeFees := metadata.GetMapData("size_fees"); sizeFees.Has(req.Parcel.Size) {
+		sizeFee = sizeFees.GetFloat64(req.Parcel.Size)
+	}
+	perKgRate := metadata.GetFloat64("per_kg_rate")
+	freeWeight := metadata.GetFloat64("free_weight_kg")
+
+	amount := calculateParcelFare(sizeFee, req.Parcel.WeightKg, freeWeight, perKgRate, rule.GetMaximumFare())
+	result.Amount = amount
+	result.Description = fmt.Sprintf("Parcel fare: %s %.2fkg, %v%.2f", req.Parcel.Size, req.Parcel.WeightKg, req.Currency, amount)
+	return result
+}
+
+// calculateParcelFare adds the per kg charge for every started kg above the free weight to the size fee, capped by maxFare when set
+func calculateParcelFare(sizeFee, weightKg, freeWeightKg, perKgRate, maxFare float64) float64 {
+	amount := sizeFee
+	if over := weightKg - freeWeightKg; over > 0 && perKgRate > 0 {
+		amount += math.Ceil(over) * perKgRate
+	}
+	if maxFare > 0 && amount > maxFare {
+		amount = maxFare
+	}
+	if amount < 0 {
+		amount = 0
+	}
+	return utils.RoundToTwoDecimal(amount)
+}
+
 // CalculatePromotion calculates promotion discount
 func (s *PriceRuleService) CalculatePromotion(ctx *PriceContext) (result *protocol.PriceRuleResult) {
 	result = &protocol.PriceRuleResult{
@@ -2126,6 +2298,22 @@ func CreatePriceContext(req *protocol.EstimateRequest) *PriceContext {
 	if req.PaymentMethod != "" {
 		metadata.Set("payment_method", req.PaymentMethod)
 	}
+	if req.PickupPlaceID != "" {
+		metadata.Set("pickup_place_id", req.PickupPlaceID)
+	}
+	if req.DropoffPlaceID != "" {
+		metadata.Set("dropoff_place_id", req.DropoffPlaceID)
+	}
+	if len(req.Stops) > 0 {
+		metadata.Set("stops", req.Stops)
+	}
+	if req.RideType != "" {
+		metadata.Set("ride_type", req.RideType)
+	}
+	if req.Parcel != nil {
+		metadata.Set("parcel_size", req.Parcel.Size)
+		metadata.Set("parcel_weight", req.Parcel.WeightKg)
+	}
 	if len(req.PromoCodes) > 0 {
 		metadata.Set("promo_codes", req.PromoCodes)
 		snapshot.SetPromoCodes(req.PromoCodes)
@@ -2177,13 +2365,20 @@ func (s *PriceRuleService) EstimatePrice(req *protocol.EstimateRequest) *models.
 
 	// 获取活跃的价格规则
 	rules := models.GetActivePriceRules()
+	sortRulesByOrderType(rules, requestOrderType(req))
 	baseRules := []*models.PriceRule{}
 	otherRules := []*models.PriceRule{}
+	poolRules := []*models.PriceRule{}
 	for _, rule := range rules {
 		if rule.GetCategory() == protocol.PriceRuleCategoryBasePricing {
 			baseRules = append(baseRules, rule)
 			continue
 		}
+		// 拼车折扣按其余费用合计计算，放在系统规则之后
+		if rule.GetCategory() == protocol.PriceRuleCategoryPoolDiscount {
+			poolRules = append(poolRules, rule)
+			continue
+		}
 		otherRules = append(otherRules, rule)
 	}
 
@@ -2218,6 +2413,10 @@ func (s *PriceRuleService) EstimatePrice(req *protocol.EstimateRequest) *models.
 		ctx.Rule = rule
 		s.CalculateRule(ctx)
 	}
+	for _, rule := range poolRules {
+		ctx.Rule = rule
+		s.CalculateRule(ctx)
+	}
 
 	for _, rule := range userRules {
 		// 使用现有的价格规则计算逻辑
@@ -2240,7 +2439,7 @@ func FinalizeSnapshot(ctx *PriceContext) {
 	}
 
 	// 计算原始价格 - 包含所有费用项，优惠前的价格
-	originalFareBeforeDiscount := snapshot.GetBaseFare().Add(snapshot.GetSurgeFare()).Add(snapshot.GetDistanceFare()).Add(snapshot.GetTimeFare()).Add(snapshot.GetServiceFee())
+	originalFareBeforeDiscount := snapshot.GetBaseFare().Add(snapshot.GetSurgeFare()).Add(snapshot.GetDistanceFare()).Add(snapshot.GetTimeFare()).Add(snapshot.GetServiceFee()).Add(snapshot.GetParcelFare())
 
 	// 优惠后折扣价格 = 原始价格 - 折扣 - 促销优惠 - 用户优惠券折扣
 	discountedFareAfterPromotions := originalFareBeforeDiscount.Add(snapshot.GetDiscountAmount()).
@@ -2256,6 +2455,7 @@ func FinalizeSnapshot(ctx *PriceContext) {
 	snapshot.SetTimeFare(snapshot.GetTimeFare().Round(2))
 	snapshot.SetServiceFee(snapshot.GetServiceFee().Round(2))
 	snapshot.SetSurgeFare(snapshot.GetSurgeFare().Round(2))
+	snapshot.SetParcelFare(snapshot.GetParcelFare().Round(2))
 	snapshot.SetOriginalFare(originalFareBeforeDiscount.Round(2))      // 优惠前原始价格
 	snapshot.SetDiscountedFare(discountedFareAfterPromotions.Round(2)) // 优惠后折扣价格
 
@@ -2329,3 +2529,101 @@ func (s *PriceRuleService) ValidateAndLockPriceID(priceID string) (*models.Price
 
 	return snapshot, protocol.Success
 }
+
+// GetWaitingFareRule returns the highest priority active waiting fare rule applicable to the request
+func (s *PriceRuleService) GetWaitingFareRule(req *protocol.EstimateRequest) *models.PriceRule {
+	for _, rule := range models.GetActivePriceRules() {
+		if rule.GetCategory() != protocol.PriceRuleCategoryWaitingFare {
+			continue
+		}
+		if applied, _ := s.IsRuleApplied(&PriceContext{Request: req, Rule: rule}); applied {
+			return rule
+		}
+	}
+	return nil
+}
+
+// CalculateWaitingFare calculates the waiting fare of a ride order from driver arrival to trip start.
+// Before the trip starts the fare is calculated up to now, so the rider can watch it grow.
+// Returns nil when the driver has not arrived or no waiting fare rule applies.
+func (s *PriceRuleService) CalculateWaitingFare(order *models.Order) *protocol.WaitingFare {
+	if order == nil || order.GetOrderType() != protocol.RideOrder {
+		return nil
+	}
+	rideOrder := models.GetRideOrderByOrderID(order.OrderID)
+	if rideOrder == nil || rideOrder.GetArrivedAt() == 0 {
+		return nil
+	}
+
+	req := &protocol.EstimateRequest{
+		UserID:          order.GetUserID(),
+		OrderType:       order.GetOrderType(),
+		VehicleCategory: rideOrder.GetVehicleCategory(),
+		VehicleLevel:    rideOrder.GetVehicleLevel(),
+		Currency:        order.GetCurrency(),
+	}
+	if priceID, ok := order.GetMetadata()["price_id"].(string); ok {
+		if snapshot := models.GetPriceSnapshotByID(priceID); snapshot != nil {
+			req.ServiceArea = snapshot.Metadata.Get("service_area")
+		}
+	}
+	rule := s.GetWaitingFareRule(req)
+	if rule == nil {
+		return nil
+	}
+
+	arrivedAt := rideOrder.GetArrivedAt()
+	endAt := order.GetStartedAt()
+	isFinal := endAt > 0
+	if !isFinal {
+		endAt = utils.TimeNowMilli()
+	}
+	waitedSeconds := max((endAt-arrivedAt)/1000, 0)
+	freeMinutes := rule.GetMinDuration()
+	chargeableMinutes, amount := calculateWaitingFare(waitedSeconds, freeMinutes, rule.GetPerMinuteRate(), rule.GetMaximumFare())
+
+	return &protocol.WaitingFare{
+		RuleID:            rule.RuleID,
+		FreeMinutes:       freeMinutes,
+		PerMinuteRate:     rule.GetPerMinuteRate(),
+		ArrivedAt:         arrivedAt,
+		FreeUntil:         arrivedAt + int64(freeMinutes)*60*1000,
+		WaitedSeconds:     waitedSeconds,
+		ChargeableMinutes: chargeableMinutes,
+		Amount:            amount,
+		Currency:          order.GetCurrency(),
+		IsFinal:           isFinal,
+	}
+}
+
+// WaitingFareBreakdown builds the price breakdown line for a waiting fare
+func (s *PriceRuleService) WaitingFareBreakdown(fare *protocol.WaitingFare) *protocol.PriceRuleResult {
+	result := &protocol.PriceRuleResult{
+		Applied:     true,
+		RuleID:      fare.RuleID,
+		Category:    protocol.PriceRuleCategoryWaitingFare,
+		DisplayName: "Waiting fare",
+		Amount:      fare.Amount,
+		Ccy:         fare.Currency,
+		Description: fmt.Sprintf("Waiting fare: %v%.2f/min × %dmin (first %dmin free)", fare.Currency, fare.PerMinuteRate, fare.ChargeableMinutes, fare.FreeMinutes),
+	}
+	if rule := models.GetPriceRuleByID(fare.RuleID); rule != nil {
+		result.RuleName = rule.GetRuleName()
+		result.DisplayName = rule.GetDisplayName()
+	}
+	return result
+}
+
+// calculateWaitingFare charges every started minute beyond the free minutes, capped at maxFare when set
+func calculateWaitingFare(waitedSeconds int64, freeMinutes int, perMinuteRate, maxFare float64) (int, float64) {
+	overSeconds := waitedSeconds - int64(freeMinutes)*60
+	if overSeconds <= 0 || perMinuteRate <= 0 {
+		return 0, 0
+	}
+	minutes := int((overSeconds + 59) / 60)
+	amount := perMinuteRate * float64(minutes)
+	if maxFare > 0 && amount > maxFare {
+		amount = maxFare
+	}
+	return minutes, utils.RoundToTwoDecimal(amount)
+}
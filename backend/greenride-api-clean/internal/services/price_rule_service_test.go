@@ -0,0 +1,88 @@
+package services
+
+import (
+	"testing"
+
+	"greenride/internal/models"
+	"greenride/internal/protocol"
+)
+
+func TestCalculateWaitingFare(t *testing.T) {
+	cases := []struct {
+		waitedSeconds int64
+		freeMinutes   int
+		rate          float64
+		maxFare       float64
+		wantMinutes   int
+		wantAmount    float64
+	}{
+		{240, 5, 50, 0, 0, 0},         // 免费时间内
+		{300, 5, 50, 0, 0, 0},         // 恰好免费时间结束
+		{301, 5, 50, 0, 1, 50},        // 超出1秒按1分钟计
+		{540, 5, 50, 0, 4, 200},       // 超出4分钟
+		{3600, 5, 50, 1000, 55, 1000}, // 封顶
+		{600, 0, 12.5, 0, 10, 125},    // 无免费时间
+		{600, 5, 0, 0, 0, 0},          // 未配置费率
+	}
+	for _, c := range cases {
+		minutes, amount := calculateWaitingFare(c.waitedSeconds, c.freeMinutes, c.rate, c.maxFare)
+		if minutes != c.wantMinutes || amount != c.wantAmount {
+			t.Errorf("waited=%ds free=%d: got %d min/%v, want %d min/%v", c.waitedSeconds, c.freeMinutes, minutes, amount, c.wantMinutes, c.wantAmount)
+		}
+	}
+}
+
+func TestCalculatePoolDiscount(t *testing.T) {
+	cases := []struct {
+		fare, percent, fixed, maxDiscount float64
+		want                              float64
+	}{
+		{3000, 25, 0, 0, 750},   // 按比例
+		{3000, 25, 0, 500, 500}, // 最大折扣封顶
+		{3000, 0, 400, 0, 400},  // 固定金额
+		{300, 0, 400, 0, 300},   // 不超过车费
+		{0, 25, 0, 0, 0},        // 无车费
+	}
+	for _, c := range cases {
+		if got := calculatePoolDiscount(c.fare, c.percent, c.fixed, c.maxDiscount); got != c.want {
+			t.Errorf("calculatePoolDiscount(%v, %v, %v, %v) = %v, want %v", c.fare, c.percent, c.fixed, c.maxDiscount, got, c.want)
+		}
+	}
+}
+
+func TestCalculateParcelFare(t *testing.T) {
+	cases := []struct {
+		sizeFee, weight, freeWeight, perKg, maxFare float64
+		want                                        float64
+	}{
+		{1000, 3, 5, 200, 0, 1000},     // 未超重
+		{1000, 6.2, 5, 200, 0, 1400},   // 超重1.2公斤按2公斤计
+		{1000, 20, 5, 200, 3000, 3000}, // 最高收费封顶
+		{1000, 20, 5, 0, 0, 1000},      // 未配置每公斤价格
+	}
+	for _, c := range cases {
+		if got := calculateParcelFare(c.sizeFee, c.weight, c.freeWeight, c.perKg, c.maxFare); got != c.want {
+			t.Errorf("calculateParcelFare(%v, %v, %v, %v, %v) = %v, want %v", c.sizeFee, c.weight, c.freeWeight, c.perKg, c.maxFare, got, c.want)
+		}
+	}
+}
+
+func TestSortRulesByOrderType(t *testing.T) {
+	newRule := func(id string, rides ...string) *models.PriceRule {
+		return &models.PriceRule{RuleID: id, PriceRuleValues: &models.PriceRuleValues{ApplicableRides: rides}}
+	}
+	rules := []*models.PriceRule{
+		newRule("generic1"),
+		newRule("ride", protocol.RideOrder),
+		newRule("delivery", protocol.DeliveryOrder),
+		newRule("generic2"),
+	}
+	sortRulesByOrderType(rules, protocol.DeliveryOrder)
+	got := ""
+	for _, rule := range rules {
+		got += rule.RuleID + ","
+	}
+	if want := "delivery,generic1,ride,generic2,"; got != want {
+		t.Errorf("sortRulesByOrderType = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,42 @@
+package services
+
+import (
+	"context"
+
+	"greenride/internal/log"
+	"greenride/internal/models"
+	"greenride/internal/protocol"
+	"greenride/internal/task"
+)
+
+const (
+	// 批量发券任务常量
+	TaskPromotionCampaignRunner = "promotion_campaign_runner"
+)
+
+// InitPromotionCampaignTaskHandlers 初始化批量发券任务处理器
+func InitPromotionCampaignTaskHandlers() {
+	task.RegisterHandler(TaskPromotionCampaignRunner, PromotionCampaignRunnerHandler)
+
+	campaignRunnerTask := &models.Task{
+		TaskID:     "promotion_campaign_runner_scheduler",
+		Name:       "批量发券活动执行",
+		Type:       "promotion",
+		HandlerKey: TaskPromotionCampaignRunner,
+		Cron:       "* * * * *", // 每分钟执行一次
+		Status:     protocol.TaskStatusEnabled,
+		MaxRetries: 1,
+		Timeout:    60,
+		Remark:     "按游标分批向活动目标人群发券，中断后下一轮从游标处继续",
+	}
+	task.InitTasks([]*models.Task{campaignRunnerTask})
+}
+
+// PromotionCampaignRunnerHandler 处理执行中的发券活动
+func PromotionCampaignRunnerHandler(ctx context.Context, params protocol.MapData) error {
+	issued := GetPromotionCampaignService().ProcessRunningCampaigns(ctx)
+	if issued > 0 {
+		log.Get().Infof("批量发券本轮完成，共发放 %d 张", issued)
+	}
+	return nil
+}
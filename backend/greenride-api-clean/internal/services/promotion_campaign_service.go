@@ -0,0 +1,395 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"greenride/internal/log"
+	"greenride/internal/models"
+	"greenride/internal/protocol"
+	"greenride/internal/utils"
+)
+
+const (
+	// MaxCampaignUserListSize CSV名单最多支持的用户数
+	MaxCampaignUserListSize = 100000
+	// campaignRunBudget 单次任务的处理时长上限，未处理完的批次留给下一轮继续
+	campaignRunBudget = 50 * time.Second
+	// campaignBatchLimit 单次任务最多处理的活动数
+	campaignBatchLimit = 10
+)
+
+// PromotionCampaignService 批量发券活动服务
+type PromotionCampaignService struct {
+}
+
+var (
+	promotionCampaignInstance *PromotionCampaignService
+	promotionCampaignOnce     sync.Once
+)
+
+func GetPromotionCampaignService() *PromotionCampaignService {
+	promotionCampaignOnce.Do(func() {
+		SetupPromotionCampaignService()
+	})
+	return promotionCampaignInstance
+}
+
+func SetupPromotionCampaignService() {
+	promotionCampaignInstance = &PromotionCampaignService{}
+}
+
+// ParseCampaignUserIDs 解析上传的用户ID名单CSV，取每行第一列，忽略表头、空行和重复ID
+func ParseCampaignUserIDs(r io.Reader) ([]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	seen := make(map[string]bool)
+	userIDs := make([]string, 0)
+	for line := 0; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid csv: %w", err)
+		}
+		if len(record) == 0 {
+			continue
+		}
+		userID := strings.TrimSpace(strings.TrimPrefix(record[0], "\ufeff"))
+		if userID == "" {
+			continue
+		}
+		if line == 0 && strings.EqualFold(userID, "user_id") {
+			continue
+		}
+		if seen[userID] {
+			continue
+		}
+		seen[userID] = true
+		userIDs = append(userIDs, userID)
+		if len(userIDs) > MaxCampaignUserListSize {
+			return nil, fmt.Errorf("csv exceeds %d users", MaxCampaignUserListSize)
+		}
+	}
+	return userIDs, nil
+}
+
+// CreateCampaign 创建批量发券活动，创建后即进入执行中，由后台任务分批发放
+func (s *PromotionCampaignService) CreateCampaign(req *protocol.CreatePromotionCampaignRequest) (*models.PromotionCampaign, protocol.ErrorCode) {
+	promotion := models.GetPromotionByID(req.PromotionID)
+	if promotion == nil {
+		return nil, protocol.PromotionNotFound
+	}
+	if !promotion.IsApproved() {
+		return nil, protocol.PromotionNotApproved
+	}
+	if !promotion.IsValid() {
+		return nil, protocol.PromotionInactive
+	}
+	if req.MinRides != nil && req.MaxRides != nil && *req.MinRides > *req.MaxRides {
+		return nil, protocol.CampaignSegmentInvalid
+	}
+	if req.LastRideAfter != nil && req.LastRideBefore != nil && *req.LastRideAfter >= *req.LastRideBefore {
+		return nil, protocol.CampaignSegmentInvalid
+	}
+	if len(req.UserIDs) > MaxCampaignUserListSize {
+		return nil, protocol.CampaignSegmentInvalid
+	}
+	if req.ExperimentID != "" {
+		experiment := models.GetPromotionExperimentByID(req.ExperimentID)
+		if experiment == nil || experiment.GetPromotionID() != req.PromotionID {
+			return nil, protocol.ExperimentNotFound
+		}
+		if !experiment.IsRunning() {
+			return nil, protocol.ExperimentNotRunning
+		}
+	}
+
+	campaign := models.NewPromotionCampaign()
+	campaign.PromotionID = &req.PromotionID
+	campaign.Name = &req.Name
+	campaign.Description = &req.Description
+	campaign.CreatedBy = &req.UserID
+	campaign.SendPush = &req.SendPush
+	if req.TargetUserType != "" {
+		campaign.TargetUserType = &req.TargetUserType
+	}
+	if req.TargetCity != "" {
+		campaign.TargetCity = &req.TargetCity
+	}
+	campaign.LastRideAfter = req.LastRideAfter
+	campaign.LastRideBefore = req.LastRideBefore
+	campaign.MinRides = req.MinRides
+	campaign.MaxRides = req.MaxRides
+	campaign.UserIDs = req.UserIDs
+	if req.ValidDays > 0 {
+		campaign.ValidDays = &req.ValidDays
+	}
+	if req.ChunkSize > 0 {
+		campaign.ChunkSize = &req.ChunkSize
+	}
+	if req.ExperimentID != "" {
+		campaign.ExperimentID = &req.ExperimentID
+	}
+
+	total := models.CountPromotionCampaignUsers(campaign)
+	if total == 0 {
+		return nil, protocol.CampaignSegmentInvalid
+	}
+	campaign.TotalUsers = &total
+
+	if err := models.GetDB().Create(campaign).Error; err != nil {
+		log.Get().Errorf("创建发券活动失败: %v", err)
+		return nil, protocol.DatabaseError
+	}
+	log.Get().Infof("发券活动 %s 已创建，优惠码 %s，目标人数 %d", campaign.CampaignID, req.PromotionID, total)
+	return campaign, protocol.Success
+}
+
+// GetCampaign 获取活动详情（含进度）
+func (s *PromotionCampaignService) GetCampaign(campaignID string) *protocol.PromotionCampaign {
+	campaign := models.GetPromotionCampaignByID(campaignID)
+	if campaign == nil {
+		return nil
+	}
+	return campaign.Protocol()
+}
+
+// SearchCampaigns 分页查询活动
+func (s *PromotionCampaignService) SearchCampaigns(req *protocol.PromotionCampaignSearchRequest) ([]*protocol.PromotionCampaign, int64) {
+	campaigns, total := models.SearchPromotionCampaigns(req.PromotionID, req.Status, req.Page, req.Limit)
+	list := make([]*protocol.PromotionCampaign, 0, len(campaigns))
+	for _, campaign := range campaigns {
+		list = append(list, campaign.Protocol())
+	}
+	return list, total
+}
+
+// PauseCampaign 暂停执行中的活动，已发放的券不受影响
+func (s *PromotionCampaignService) PauseCampaign(campaignID, adminID string) protocol.ErrorCode {
+	return s.transition(campaignID, []string{protocol.StatusRunning}, map[string]any{
+		"status":     protocol.StatusPaused,
+		"updated_by": adminID,
+	})
+}
+
+// ResumeCampaign 从上次的游标继续执行已暂停的活动
+func (s *PromotionCampaignService) ResumeCampaign(campaignID, adminID string) protocol.ErrorCode {
+	campaign := models.GetPromotionCampaignByID(campaignID)
+	if campaign == nil {
+		return protocol.CampaignNotFound
+	}
+	promotion := models.GetPromotionByID(campaign.GetPromotionID())
+	if promotion == nil {
+		return protocol.PromotionNotFound
+	}
+	if !promotion.IsValid() {
+		return protocol.PromotionInactive
+	}
+	return s.transition(campaignID, []string{protocol.StatusPaused}, map[string]any{
+		"status":        protocol.StatusRunning,
+		"error_message": "",
+		"updated_by":    adminID,
+	})
+}
+
+// CancelCampaign 取消活动，剩余用户不再发放
+func (s *PromotionCampaignService) CancelCampaign(campaignID, adminID string) protocol.ErrorCode {
+	return s.transition(campaignID, []string{protocol.StatusRunning, protocol.StatusPaused}, map[string]any{
+		"status":       protocol.StatusCancelled,
+		"completed_at": utils.TimeNowMilli(),
+		"updated_by":   adminID,
+	})
+}
+
+func (s *PromotionCampaignService) transition(campaignID string, fromStatuses []string, updates map[string]any) protocol.ErrorCode {
+	if models.GetPromotionCampaignByID(campaignID) == nil {
+		return protocol.CampaignNotFound
+	}
+	ok, err := models.TransitionPromotionCampaignStatus(campaignID, fromStatuses, updates)
+	if err != nil {
+		log.Get().Errorf("更新发券活动 %s 状态失败: %v", campaignID, err)
+		return protocol.DatabaseError
+	}
+	if !ok {
+		return protocol.CampaignStatusInvalid
+	}
+	return protocol.Success
+}
+
+// ProcessRunningCampaigns 处理所有执行中的活动，返回本轮发放的券数
+func (s *PromotionCampaignService) ProcessRunningCampaigns(ctx context.Context) int {
+	deadline := time.Now().Add(campaignRunBudget)
+	issued := 0
+	for _, campaign := range models.GetRunningPromotionCampaigns(campaignBatchLimit) {
+		if ctx.Err() != nil || time.Now().After(deadline) {
+			break
+		}
+		count, err := s.runCampaign(ctx, campaign.CampaignID, deadline)
+		if err != nil {
+			log.Get().Errorf("发券活动 %s 执行失败: %v", campaign.CampaignID, err)
+		}
+		issued += count
+	}
+	return issued
+}
+
+// runCampaign 在截止时间前逐批处理一个活动；每批开始前重新读取活动，以便及时响应暂停和取消
+func (s *PromotionCampaignService) runCampaign(ctx context.Context, campaignID string, deadline time.Time) (int, error) {
+	issued := 0
+	for time.Now().Before(deadline) && ctx.Err() == nil {
+		campaign := models.GetPromotionCampaignByID(campaignID)
+		if campaign == nil || !campaign.IsRunning() {
+			return issued, nil
+		}
+
+		promotion := models.GetPromotionByID(campaign.GetPromotionID())
+		if promotion == nil || !promotion.IsValid() {
+			s.pauseWithError(campaignID, "promotion is no longer active or its budget is exhausted")
+			return issued, nil
+		}
+
+		var experiment *models.PromotionExperiment
+		if experimentID := campaign.GetExperimentID(); experimentID != "" {
+			experiment = models.GetPromotionExperimentByID(experimentID)
+			if experiment == nil || !experiment.IsRunning() {
+				s.pauseWithError(campaignID, "promotion experiment is no longer running")
+				return issued, nil
+			}
+		}
+
+		users, nextCursor, scanned := models.GetPromotionCampaignUserBatch(campaign)
+		if scanned == 0 {
+			if _, err := models.TransitionPromotionCampaignStatus(campaignID, []string{protocol.StatusRunning}, map[string]any{
+				"status":       protocol.StatusCompleted,
+				"completed_at": utils.TimeNowMilli(),
+			}); err != nil {
+				return issued, err
+			}
+			log.Get().Infof("发券活动 %s 已完成，共发放 %d 张", campaignID, campaign.GetIssuedCount())
+			return issued, nil
+		}
+
+		batchIssued, failed, stopped := s.issueBatch(campaign, promotion, experiment, users)
+		skipped := scanned - batchIssued - failed
+		if stopped {
+			// 优惠码中途不可用时不推进游标，恢复后从本批开头重新处理，已发放的用户会被跳过
+			if err := models.AddPromotionCampaignProgress(campaignID, campaign.GetCursor(), 0, batchIssued, 0, 0); err != nil {
+				return issued, err
+			}
+			s.pauseWithError(campaignID, "promotion became unavailable while issuing coupons")
+			return issued + batchIssued, nil
+		}
+		if err := models.AddPromotionCampaignProgress(campaignID, nextCursor, scanned, batchIssued, skipped, failed); err != nil {
+			return issued, err
+		}
+		issued += batchIssued
+	}
+	return issued, nil
+}
+
+// issueBatch 向一批用户发券；已领取过本活动券的用户跳过，保证重复执行同一批时不会重复发放
+// 关联实验时先记录用户分组，对照组用户不发券，其余用户按所在变体覆盖折扣
+func (s *PromotionCampaignService) issueBatch(campaign *models.PromotionCampaign, promotion *models.Promotion, experiment *models.PromotionExperiment, users []*models.User) (issued, failed int, stopped bool) {
+	expiredAt := int64(0)
+	if days := campaign.GetValidDays(); days > 0 {
+		expiredAt = utils.TimeNowMilli() + int64(days)*24*3600*1000
+	} else if promotion.GetEndDate() > 0 {
+		expiredAt = promotion.GetEndDate()
+	}
+
+	for _, user := range users {
+		if models.CheckUserHasBatchCoupon(user.UserID, campaign.CampaignID) {
+			continue
+		}
+
+		var variant *protocol.PromotionExperimentVariant
+		if experiment != nil {
+			variantID := experiment.AssignVariant(user.UserID)
+			if err := models.SavePromotionExperimentAssignment(experiment.ExperimentID, user.UserID, variantID); err != nil {
+				log.Get().Warnf("实验 %s 记录用户 %s 分组失败: %v", experiment.ExperimentID, user.UserID, err)
+				failed++
+				continue
+			}
+			if variant = experiment.GetVariant(variantID); variant == nil {
+				continue
+			}
+		}
+
+		userPromotion := models.NewUserPromotionWithSource(
+			user.UserID,
+			promotion,
+			protocol.UserPromotionSourceAdmin,
+			campaign.CampaignID,
+			campaign.GetName(),
+			campaign.GetCreatedBy(),
+		)
+		userPromotion.SetBatchID(campaign.CampaignID)
+		if deviceType := user.GetDeviceType(); deviceType != "" {
+			userPromotion.SetChannel(deviceType)
+		}
+		if city := user.GetCity(); city != "" {
+			userPromotion.SetCityCode(city)
+		}
+		if expiredAt > 0 {
+			userPromotion.SetExpiredAt(expiredAt)
+		}
+		if variant != nil {
+			applyExperimentVariant(userPromotion, experiment.ExperimentID, variant)
+		}
+
+		if err := models.CreateUserPromotionInDB(userPromotion); err != nil {
+			if errors.Is(err, models.ErrPromotionUnavailable) {
+				return issued, failed, true
+			}
+			log.Get().Warnf("发券活动 %s 向用户 %s 发券失败: %v", campaign.CampaignID, user.UserID, err)
+			failed++
+			continue
+		}
+		issued++
+
+		if campaign.GetSendPush() {
+			if err := s.notifyCouponIssued(user, promotion); err != nil {
+				log.Get().Warnf("发券活动 %s 推送用户 %s 失败: %v", campaign.CampaignID, user.UserID, err)
+			}
+		}
+	}
+	return issued, failed, false
+}
+
+func (s *PromotionCampaignService) pauseWithError(campaignID, reason string) {
+	if _, err := models.TransitionPromotionCampaignStatus(campaignID, []string{protocol.StatusRunning}, map[string]any{
+		"status":        protocol.StatusPaused,
+		"error_message": reason,
+	}); err != nil {
+		log.Get().Errorf("暂停发券活动 %s 失败: %v", campaignID, err)
+		return
+	}
+	log.Get().Warnf("发券活动 %s 已自动暂停: %s", campaignID, reason)
+}
+
+func (s *PromotionCampaignService) notifyCouponIssued(user *models.User, promotion *models.Promotion) error {
+	message := &Message{
+		Type:     protocol.MsgTypePassengerCouponIssued,
+		Channels: []string{protocol.MsgChannelFcm},
+		Params: map[string]any{
+			"to":                user.UserID,
+			"PromotionTitle":    promotion.GetTitle(),
+			"PromotionCode":     promotion.GetCode(),
+			"promotion_id":      promotion.PromotionID,
+			"msg_type":          protocol.FCMMessageTypePromotion,
+			"notification_type": protocol.NotificationTypeCouponIssued,
+		},
+		Language: getUserLanguage(user),
+	}
+	return GetUserNotificationService().Deliver(user, message)
+}
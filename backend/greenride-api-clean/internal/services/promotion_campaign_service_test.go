@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"greenride/internal/models"
+	"greenride/internal/protocol"
+)
+
+func TestParseCampaignUserIDs(t *testing.T) {
+	input := "\ufeffuser_id,name\nU001,Alice\n\n U002 \nU001,Alice again\nU003\n"
+	userIDs, err := ParseCampaignUserIDs(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"U001", "U002", "U003"}
+	if !reflect.DeepEqual(userIDs, want) {
+		t.Fatalf("got %v, want %v", userIDs, want)
+	}
+
+	// 没有表头时第一行也是用户ID
+	userIDs, err = ParseCampaignUserIDs(strings.NewReader("U100\nU200"))
+	if err != nil || !reflect.DeepEqual(userIDs, []string{"U100", "U200"}) {
+		t.Fatalf("headerless csv: got %v, %v", userIDs, err)
+	}
+
+	if _, err := ParseCampaignUserIDs(strings.NewReader("\"U1\nU2")); err == nil {
+		t.Fatal("malformed csv should fail")
+	}
+}
+
+// setupCampaignTest 准备一个已审批的优惠码和n个乘客，另加一个不在目标人群内的司机
+func setupCampaignTest(t *testing.T, budget float64, n int) (*models.Promotion, []*models.User) {
+	t.Helper()
+	db := setupTestDB(t, &models.Promotion{}, &models.UserPromotion{}, &models.PromotionCampaign{}, &models.User{})
+	promotion := createTestPromotion(t, "admin-maker", budget)
+	if got := (&AdminService{db: db}).ApprovePromotion(&protocol.ApprovePromotionRequest{UserID: "admin-checker", PromotionID: promotion.PromotionID}); got != protocol.Success {
+		t.Fatalf("approve promotion = %s", got)
+	}
+
+	users := make([]*models.User, 0, n)
+	for i := 0; i <= n; i++ {
+		user := models.NewUser()
+		if i == n {
+			user.SetUserType(protocol.UserTypeDriver)
+		}
+		if err := db.Create(user).Error; err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+		if i < n {
+			users = append(users, user)
+		}
+	}
+	return models.GetPromotionByID(promotion.PromotionID), users
+}
+
+func createTestCampaign(t *testing.T, promotionID string, chunkSize int) *models.PromotionCampaign {
+	t.Helper()
+	campaign, errCode := GetPromotionCampaignService().CreateCampaign(&protocol.CreatePromotionCampaignRequest{
+		UserID:         "admin-maker",
+		PromotionID:    promotionID,
+		Name:           "winback",
+		TargetUserType: protocol.UserTypePassenger,
+		ChunkSize:      chunkSize,
+	})
+	if errCode != protocol.Success {
+		t.Fatalf("create campaign = %s", errCode)
+	}
+	return campaign
+}
+
+func issueTestCoupon(t *testing.T, userID string, promotion *models.Promotion, campaignID string) {
+	t.Helper()
+	userPromotion := models.NewUserPromotion(userID, promotion)
+	userPromotion.SetBatchID(campaignID)
+	if err := models.CreateUserPromotionInDB(userPromotion); err != nil {
+		t.Fatalf("issue coupon: %v", err)
+	}
+}
+
+func countCampaignCoupons(t *testing.T, userID, campaignID string) int64 {
+	t.Helper()
+	var count int64
+	if err := models.GetDB().Model(&models.UserPromotion{}).Where("user_id = ? AND batch_id = ?", userID, campaignID).Count(&count).Error; err != nil {
+		t.Fatalf("count coupons: %v", err)
+	}
+	return count
+}
+
+func TestRunCampaignResumesFromCursorInChunks(t *testing.T) {
+	promotion, users := setupCampaignTest(t, 0, 5)
+	service := GetPromotionCampaignService()
+	campaign := createTestCampaign(t, promotion.PromotionID, 2)
+	if campaign.GetTotalUsers() != 5 {
+		t.Fatalf("total users = %d, want 5 passengers", campaign.GetTotalUsers())
+	}
+
+	// 按用户主键分批，每批不超过chunk_size
+	batch, nextCursor, scanned := models.GetPromotionCampaignUserBatch(campaign)
+	if scanned != 2 || batch[0].UserID != users[0].UserID || batch[1].UserID != users[1].UserID || nextCursor != users[1].ID {
+		t.Fatalf("first chunk: scanned=%d cursor=%d", scanned, nextCursor)
+	}
+
+	// 模拟上次执行处理完第一个用户后被暂停，第四个用户已在其他途径领到本活动的券
+	issueTestCoupon(t, users[0].UserID, promotion, campaign.CampaignID)
+	issueTestCoupon(t, users[3].UserID, promotion, campaign.CampaignID)
+	if err := models.AddPromotionCampaignProgress(campaign.CampaignID, users[0].ID, 1, 1, 0, 0); err != nil {
+		t.Fatalf("record progress: %v", err)
+	}
+	if got := service.PauseCampaign(campaign.CampaignID, "admin-maker"); got != protocol.Success {
+		t.Fatalf("pause = %s", got)
+	}
+	if issued, err := service.runCampaign(context.Background(), campaign.CampaignID, time.Now().Add(time.Minute)); err != nil || issued != 0 {
+		t.Fatalf("paused campaign issued %d coupons, err=%v", issued, err)
+	}
+	if got := service.ResumeCampaign(campaign.CampaignID, "admin-maker"); got != protocol.Success {
+		t.Fatalf("resume = %s", got)
+	}
+
+	issued, err := service.runCampaign(context.Background(), campaign.CampaignID, time.Now().Add(time.Minute))
+	if err != nil || issued != 3 {
+		t.Fatalf("resumed run issued %d coupons, err=%v, want 3", issued, err)
+	}
+	for _, user := range users {
+		if count := countCampaignCoupons(t, user.UserID, campaign.CampaignID); count != 1 {
+			t.Errorf("user %s has %d coupons, want 1", user.UserID, count)
+		}
+	}
+	stored := models.GetPromotionCampaignByID(campaign.CampaignID)
+	if stored.GetStatus() != protocol.StatusCompleted || stored.GetProcessedUsers() != 5 || stored.GetIssuedCount() != 4 || stored.GetSkippedCount() != 1 {
+		t.Fatalf("campaign: status=%s processed=%d issued=%d skipped=%d", stored.GetStatus(), stored.GetProcessedUsers(), stored.GetIssuedCount(), stored.GetSkippedCount())
+	}
+}
+
+func TestRunCampaignPausesWhenBudgetExhausted(t *testing.T) {
+	promotion, users := setupCampaignTest(t, 10, 3)
+	service := GetPromotionCampaignService()
+	campaign := createTestCampaign(t, promotion.PromotionID, 2)
+
+	// 发券前预算已被核销用完
+	if err := models.GetDB().Model(&models.Promotion{}).Where("promotion_id = ?", promotion.PromotionID).
+		Update("spent_amount", 10).Error; err != nil {
+		t.Fatalf("spend budget: %v", err)
+	}
+	// 批次进行中预算用完时立即停止本批
+	if issued, failed, stopped := service.issueBatch(campaign, promotion, nil, users); !stopped || issued != 0 || failed != 0 {
+		t.Fatalf("issueBatch after budget ran out: issued=%d failed=%d stopped=%v", issued, failed, stopped)
+	}
+	issued, err := service.runCampaign(context.Background(), campaign.CampaignID, time.Now().Add(time.Minute))
+	if err != nil || issued != 0 {
+		t.Fatalf("exhausted budget issued %d coupons, err=%v", issued, err)
+	}
+	stored := models.GetPromotionCampaignByID(campaign.CampaignID)
+	if stored.GetStatus() != protocol.StatusPaused || stored.GetErrorMessage() == "" || stored.GetCursor() != 0 {
+		t.Fatalf("campaign: status=%s error=%q cursor=%d", stored.GetStatus(), stored.GetErrorMessage(), stored.GetCursor())
+	}
+	for _, user := range users {
+		if count := countCampaignCoupons(t, user.UserID, campaign.CampaignID); count != 0 {
+			t.Errorf("user %s got %d coupons after budget ran out", user.UserID, count)
+		}
+	}
+	if got := service.ResumeCampaign(campaign.CampaignID, "admin-maker"); got != protocol.PromotionInactive {
+		t.Fatalf("resume with exhausted budget = %s, want %s", got, protocol.PromotionInactive)
+	}
+}
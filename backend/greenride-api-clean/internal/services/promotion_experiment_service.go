@@ -0,0 +1,241 @@
+package services
+
+import (
+	"sync"
+
+	"greenride/internal/log"
+	"greenride/internal/models"
+	"greenride/internal/protocol"
+	"greenride/internal/utils"
+)
+
+// PromotionExperimentService 优惠A/B实验服务
+// 实验只定义变体和对照组，发券通过批量发券活动关联实验完成，每个用户按哈希稳定分到同一组
+type PromotionExperimentService struct {
+}
+
+var (
+	promotionExperimentInstance *PromotionExperimentService
+	promotionExperimentOnce     sync.Once
+)
+
+func GetPromotionExperimentService() *PromotionExperimentService {
+	promotionExperimentOnce.Do(func() {
+		SetupPromotionExperimentService()
+	})
+	return promotionExperimentInstance
+}
+
+func SetupPromotionExperimentService() {
+	promotionExperimentInstance = &PromotionExperimentService{}
+}
+
+// CreateExperiment 创建优惠实验，只能基于已审批的优惠码
+// 变体折扣会覆盖优惠码的折扣，实验创建后处于待审批状态，需另一位管理员审批通过才能发券
+func (s *PromotionExperimentService) CreateExperiment(req *protocol.CreatePromotionExperimentRequest) (*models.PromotionExperiment, protocol.ErrorCode) {
+	promotion := models.GetPromotionByID(req.PromotionID)
+	if promotion == nil {
+		return nil, protocol.PromotionNotFound
+	}
+	if !promotion.IsApproved() {
+		return nil, protocol.PromotionNotApproved
+	}
+	if errCode := validateExperimentVariants(req.Variants); errCode != protocol.Success {
+		return nil, errCode
+	}
+
+	experiment := models.NewPromotionExperiment()
+	experiment.PromotionID = &req.PromotionID
+	experiment.Name = &req.Name
+	experiment.Description = &req.Description
+	experiment.Variants = req.Variants
+	experiment.HoldoutPercent = &req.HoldoutPercent
+	experiment.CreatedBy = &req.UserID
+	if req.ConversionWindowDays > 0 {
+		experiment.ConversionWindowDays = &req.ConversionWindowDays
+	}
+
+	if err := models.GetDB().Create(experiment).Error; err != nil {
+		log.Get().Errorf("创建优惠实验失败: %v", err)
+		return nil, protocol.DatabaseError
+	}
+	return experiment, protocol.Success
+}
+
+// validateExperimentVariants 校验变体ID唯一且不与对照组冲突，百分比折扣不超过100
+func validateExperimentVariants(variants []*protocol.PromotionExperimentVariant) protocol.ErrorCode {
+	if len(variants) == 0 {
+		return protocol.ExperimentVariantInvalid
+	}
+	seen := make(map[string]bool)
+	for _, variant := range variants {
+		if variant == nil || variant.VariantID == "" || variant.VariantID == models.ExperimentHoldoutVariant || seen[variant.VariantID] {
+			return protocol.ExperimentVariantInvalid
+		}
+		seen[variant.VariantID] = true
+		if variant.Weight <= 0 || variant.DiscountValue <= 0 {
+			return protocol.ExperimentVariantInvalid
+		}
+		if variant.DiscountType == protocol.PromoDiscountTypePercentage && variant.DiscountValue > 100 {
+			return protocol.ExperimentVariantInvalid
+		}
+	}
+	return protocol.Success
+}
+
+// ApproveExperiment 审批通过实验变体折扣，审批人与创建人分离（maker-checker）
+func (s *PromotionExperimentService) ApproveExperiment(req *protocol.ReviewPromotionExperimentRequest) protocol.ErrorCode {
+	experiment, errCode := s.getReviewableExperiment(req)
+	if errCode != protocol.Success {
+		return errCode
+	}
+	promotion := models.GetPromotionByID(experiment.GetPromotionID())
+	if promotion == nil {
+		return protocol.PromotionNotFound
+	}
+	if !promotion.IsApproved() {
+		return protocol.PromotionNotApproved
+	}
+
+	ok, err := models.ApprovePromotionExperiment(req.ExperimentID, req.UserID, req.Notes)
+	if err != nil {
+		log.Get().Errorf("审批优惠实验 %s 失败: %v", req.ExperimentID, err)
+		return protocol.DatabaseError
+	}
+	if !ok {
+		return protocol.PromotionAlreadyReviewed
+	}
+	return protocol.Success
+}
+
+// RejectExperiment 拒绝实验变体折扣
+func (s *PromotionExperimentService) RejectExperiment(req *protocol.ReviewPromotionExperimentRequest) protocol.ErrorCode {
+	if _, errCode := s.getReviewableExperiment(req); errCode != protocol.Success {
+		return errCode
+	}
+	ok, err := models.RejectPromotionExperiment(req.ExperimentID, req.UserID, req.Notes)
+	if err != nil {
+		log.Get().Errorf("拒绝优惠实验 %s 失败: %v", req.ExperimentID, err)
+		return protocol.DatabaseError
+	}
+	if !ok {
+		return protocol.PromotionAlreadyReviewed
+	}
+	return protocol.Success
+}
+
+// getReviewableExperiment 获取待审批实验，创建人不能审批自己的实验
+func (s *PromotionExperimentService) getReviewableExperiment(req *protocol.ReviewPromotionExperimentRequest) (*models.PromotionExperiment, protocol.ErrorCode) {
+	experiment := models.GetPromotionExperimentByID(req.ExperimentID)
+	if experiment == nil {
+		return nil, protocol.ExperimentNotFound
+	}
+	if !experiment.IsPending() {
+		return nil, protocol.PromotionAlreadyReviewed
+	}
+	if experiment.GetCreatedBy() == req.UserID {
+		return nil, protocol.PromotionSelfApproval
+	}
+	return experiment, protocol.Success
+}
+
+// GetExperiment 获取实验详情
+func (s *PromotionExperimentService) GetExperiment(experimentID string) *protocol.PromotionExperiment {
+	experiment := models.GetPromotionExperimentByID(experimentID)
+	if experiment == nil {
+		return nil
+	}
+	return experiment.Protocol()
+}
+
+// SearchExperiments 分页查询实验
+func (s *PromotionExperimentService) SearchExperiments(req *protocol.PromotionExperimentSearchRequest) ([]*protocol.PromotionExperiment, int64) {
+	experiments, total := models.SearchPromotionExperiments(req.PromotionID, req.Status, req.Page, req.Limit)
+	list := make([]*protocol.PromotionExperiment, 0, len(experiments))
+	for _, experiment := range experiments {
+		list = append(list, experiment.Protocol())
+	}
+	return list, total
+}
+
+// CompleteExperiment 结束实验，关联的发券活动会在下一批自动暂停
+func (s *PromotionExperimentService) CompleteExperiment(experimentID string) protocol.ErrorCode {
+	if models.GetPromotionExperimentByID(experimentID) == nil {
+		return protocol.ExperimentNotFound
+	}
+	ok, err := models.CompletePromotionExperiment(experimentID)
+	if err != nil {
+		log.Get().Errorf("结束优惠实验 %s 失败: %v", experimentID, err)
+		return protocol.DatabaseError
+	}
+	if !ok {
+		return protocol.ExperimentNotRunning
+	}
+	return protocol.Success
+}
+
+// GetExperimentReport 生成实验效果报告
+func (s *PromotionExperimentService) GetExperimentReport(experimentID string) (*protocol.PromotionExperimentReport, protocol.ErrorCode) {
+	experiment := models.GetPromotionExperimentByID(experimentID)
+	if experiment == nil {
+		return nil, protocol.ExperimentNotFound
+	}
+	stats := models.GetPromotionExperimentStats(experiment)
+	return &protocol.PromotionExperimentReport{
+		Experiment:  experiment.Protocol(),
+		Variants:    buildExperimentVariantReports(experiment.Variants, stats),
+		GeneratedAt: utils.TimeNowMilli(),
+	}, protocol.Success
+}
+
+// buildExperimentVariantReports 按变体汇总统计并计算各项比率，对照组排在最前作为基线
+func buildExperimentVariantReports(variants []*protocol.PromotionExperimentVariant, stats map[string]*models.ExperimentVariantStats) []*protocol.PromotionExperimentVariantReport {
+	build := func(variantID, name string, isHoldout bool) *protocol.PromotionExperimentVariantReport {
+		report := &protocol.PromotionExperimentVariantReport{
+			VariantID: variantID,
+			Name:      name,
+			IsHoldout: isHoldout,
+		}
+		if s := stats[variantID]; s != nil {
+			report.AssignedUsers = s.AssignedUsers
+			report.IssuedCoupons = s.IssuedCoupons
+			report.RedeemedCount = s.RedeemedCount
+			report.DiscountCost = utils.RoundToTwoDecimal(s.DiscountCost)
+			report.ConvertedUsers = s.ConvertedUsers
+			report.Rides = s.Rides
+		}
+		if report.IssuedCoupons > 0 {
+			report.RedemptionRate = float64(report.RedeemedCount) / float64(report.IssuedCoupons)
+		}
+		if report.AssignedUsers > 0 {
+			report.ConversionRate = float64(report.ConvertedUsers) / float64(report.AssignedUsers)
+			report.RidesPerUser = float64(report.Rides) / float64(report.AssignedUsers)
+		}
+		return report
+	}
+
+	holdout := build(models.ExperimentHoldoutVariant, "Holdout", true)
+	reports := []*protocol.PromotionExperimentVariantReport{holdout}
+	for _, variant := range variants {
+		report := build(variant.VariantID, variant.Name, false)
+		if holdout.AssignedUsers > 0 {
+			report.IncrementalRides = (report.RidesPerUser - holdout.RidesPerUser) * float64(report.AssignedUsers)
+			if report.IncrementalRides > 0 {
+				report.CostPerIncrementalRide = utils.RoundToTwoDecimal(report.DiscountCost / report.IncrementalRides)
+			}
+		}
+		reports = append(reports, report)
+	}
+	return reports
+}
+
+// applyExperimentVariant 用变体的折扣设置覆盖券快照并打上实验标记
+func applyExperimentVariant(userPromotion *models.UserPromotion, experimentID string, variant *protocol.PromotionExperimentVariant) {
+	userPromotion.SetDiscountType(variant.DiscountType)
+	userPromotion.SetDiscountValue(variant.DiscountValue)
+	if variant.MaxDiscountAmount != nil {
+		userPromotion.SetMaxDiscountAmount(*variant.MaxDiscountAmount)
+	}
+	userPromotion.SetExperimentID(experimentID)
+	userPromotion.SetVariantID(variant.VariantID)
+}
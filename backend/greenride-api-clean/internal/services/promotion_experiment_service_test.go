@@ -0,0 +1,175 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"greenride/internal/models"
+	"greenride/internal/protocol"
+)
+
+func newTestExperiment(holdout int, weights ...int) *models.PromotionExperiment {
+	experiment := models.NewPromotionExperiment()
+	experiment.HoldoutPercent = &holdout
+	for i, weight := range weights {
+		experiment.Variants = append(experiment.Variants, &protocol.PromotionExperimentVariant{
+			VariantID:     fmt.Sprintf("v%d", i+1),
+			DiscountType:  protocol.PromoDiscountTypeFixedAmount,
+			DiscountValue: float64(500 * (i + 1)),
+			Weight:        weight,
+		})
+	}
+	return experiment
+}
+
+func TestExperimentAssignVariant(t *testing.T) {
+	experiment := newTestExperiment(20, 1, 1)
+
+	counts := make(map[string]int)
+	for i := 0; i < 20000; i++ {
+		userID := fmt.Sprintf("U%06d", i)
+		variant := experiment.AssignVariant(userID)
+		if again := experiment.AssignVariant(userID); again != variant {
+			t.Fatalf("assignment for %s is not stable: %s vs %s", userID, variant, again)
+		}
+		counts[variant]++
+	}
+
+	// 20% 对照组，其余两个变体各约 40%
+	expect := map[string]float64{models.ExperimentHoldoutVariant: 0.2, "v1": 0.4, "v2": 0.4}
+	for variant, share := range expect {
+		got := float64(counts[variant]) / 20000
+		if math.Abs(got-share) > 0.02 {
+			t.Fatalf("variant %s share %.3f, want about %.2f (%v)", variant, got, share, counts)
+		}
+	}
+
+	// 不同实验之间分组相互独立
+	other := newTestExperiment(20, 1, 1)
+	same := 0
+	for i := 0; i < 1000; i++ {
+		userID := fmt.Sprintf("U%06d", i)
+		if experiment.AssignVariant(userID) == other.AssignVariant(userID) {
+			same++
+		}
+	}
+	if same > 600 {
+		t.Fatalf("assignments across experiments look correlated: %d/1000 identical", same)
+	}
+}
+
+func TestValidateExperimentVariants(t *testing.T) {
+	valid := newTestExperiment(10, 1, 2).Variants
+	if code := validateExperimentVariants(valid); code != protocol.Success {
+		t.Fatalf("valid variants rejected: %v", code)
+	}
+
+	duplicate := append(newTestExperiment(10, 1).Variants, newTestExperiment(10, 1).Variants...)
+	if code := validateExperimentVariants(duplicate); code != protocol.ExperimentVariantInvalid {
+		t.Fatalf("duplicate variant ids should be rejected")
+	}
+
+	holdout := newTestExperiment(10, 1).Variants
+	holdout[0].VariantID = models.ExperimentHoldoutVariant
+	if code := validateExperimentVariants(holdout); code != protocol.ExperimentVariantInvalid {
+		t.Fatalf("variant named holdout should be rejected")
+	}
+
+	percent := newTestExperiment(10, 1).Variants
+	percent[0].DiscountType = protocol.PromoDiscountTypePercentage
+	percent[0].DiscountValue = 120
+	if code := validateExperimentVariants(percent); code != protocol.ExperimentVariantInvalid {
+		t.Fatalf("percentage over 100 should be rejected")
+	}
+}
+
+func TestBuildExperimentVariantReports(t *testing.T) {
+	variants := newTestExperiment(10, 1, 1).Variants
+	stats := map[string]*models.ExperimentVariantStats{
+		models.ExperimentHoldoutVariant: {AssignedUsers: 100, ConvertedUsers: 10, Rides: 20},
+		"v1":                            {AssignedUsers: 400, IssuedCoupons: 400, RedeemedCount: 100, DiscountCost: 50000, ConvertedUsers: 80, Rides: 200},
+		"v2":                            {AssignedUsers: 400, IssuedCoupons: 400, RedeemedCount: 40, DiscountCost: 20000, ConvertedUsers: 30, Rides: 60},
+	}
+
+	reports := buildExperimentVariantReports(variants, stats)
+	if len(reports) != 3 || !reports[0].IsHoldout {
+		t.Fatalf("holdout should come first: %+v", reports)
+	}
+
+	v1 := reports[1]
+	if v1.RedemptionRate != 0.25 || v1.ConversionRate != 0.2 || v1.RidesPerUser != 0.5 {
+		t.Fatalf("unexpected v1 rates: %+v", v1)
+	}
+	// (0.5 - 0.2) * 400 = 120 个增量订单
+	if math.Abs(v1.IncrementalRides-120) > 1e-9 || v1.CostPerIncrementalRide != 416.67 {
+		t.Fatalf("unexpected v1 incremental: %+v", v1)
+	}
+
+	// 人均订单低于对照组时增量为负，不计算单均成本
+	v2 := reports[2]
+	if v2.IncrementalRides >= 0 || v2.CostPerIncrementalRide != 0 {
+		t.Fatalf("unexpected v2 incremental: %+v", v2)
+	}
+}
+
+func TestCreateExperimentRequiresApproval(t *testing.T) {
+	db := setupTestDB(t, &models.Promotion{}, &models.PromotionExperiment{}, &models.PromotionCampaign{})
+	service := GetPromotionExperimentService()
+	promotion := createTestPromotion(t, "admin-maker", 0)
+	req := &protocol.CreatePromotionExperimentRequest{
+		UserID:      "admin-maker",
+		PromotionID: promotion.PromotionID,
+		Name:        "discount depth",
+		Variants: []*protocol.PromotionExperimentVariant{
+			{VariantID: "v1", DiscountType: protocol.PromoDiscountTypePercentage, DiscountValue: 90, Weight: 1},
+		},
+	}
+
+	if _, got := service.CreateExperiment(req); got != protocol.PromotionNotApproved {
+		t.Fatalf("experiment on pending promotion = %s, want %s", got, protocol.PromotionNotApproved)
+	}
+	if got := (&AdminService{db: db}).ApprovePromotion(&protocol.ApprovePromotionRequest{UserID: "admin-checker", PromotionID: promotion.PromotionID}); got != protocol.Success {
+		t.Fatalf("approve promotion = %s", got)
+	}
+
+	experiment, errCode := service.CreateExperiment(req)
+	if errCode != protocol.Success {
+		t.Fatalf("create experiment = %s", errCode)
+	}
+	if !experiment.IsPending() {
+		t.Fatalf("new experiment status = %s, want pending", experiment.GetStatus())
+	}
+
+	// 未审批的变体折扣不能用于发券
+	_, errCode = GetPromotionCampaignService().CreateCampaign(&protocol.CreatePromotionCampaignRequest{
+		UserID:         "admin-maker",
+		PromotionID:    promotion.PromotionID,
+		ExperimentID:   experiment.ExperimentID,
+		Name:           "experiment",
+		TargetUserType: protocol.UserTypePassenger,
+	})
+	if errCode != protocol.ExperimentNotRunning {
+		t.Fatalf("campaign with pending experiment = %s, want %s", errCode, protocol.ExperimentNotRunning)
+	}
+
+	review := &protocol.ReviewPromotionExperimentRequest{UserID: "admin-maker", ExperimentID: experiment.ExperimentID}
+	if got := service.ApproveExperiment(review); got != protocol.PromotionSelfApproval {
+		t.Fatalf("creator approval = %s, want %s", got, protocol.PromotionSelfApproval)
+	}
+	if got := service.RejectExperiment(review); got != protocol.PromotionSelfApproval {
+		t.Fatalf("creator rejection = %s, want %s", got, protocol.PromotionSelfApproval)
+	}
+
+	review.UserID = "admin-checker"
+	if got := service.ApproveExperiment(review); got != protocol.Success {
+		t.Fatalf("checker approval = %s, want success", got)
+	}
+	stored := models.GetPromotionExperimentByID(experiment.ExperimentID)
+	if !stored.IsRunning() || stored.GetApprovedBy() != "admin-checker" || stored.GetStartedAt() == 0 {
+		t.Fatalf("approved experiment = %s by %q started %d, want running by admin-checker", stored.GetStatus(), stored.GetApprovedBy(), stored.GetStartedAt())
+	}
+	if got := service.RejectExperiment(review); got != protocol.PromotionAlreadyReviewed {
+		t.Fatalf("rejection after approval = %s, want %s", got, protocol.PromotionAlreadyReviewed)
+	}
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"greenride/internal/config"
+	"greenride/internal/log"
+	"greenride/internal/models"
+	"greenride/internal/protocol"
+)
+
+const (
+	rateLimitKeyPrefix      = "ratelimit"
+	rateLimitOffendersKey   = "ratelimit:offenders:%s" // 按天统计被拦截次数
+	rateLimitOffendersTTL   = 7 * 24 * time.Hour
+	rateLimitOffenderFormat = "20060102"
+)
+
+// SlidingWindowFunc 滑动窗口计数，Redis不可用时应放行
+type SlidingWindowFunc func(key string, limit int, window time.Duration) *models.SlidingWindowResult
+
+// RateLimitService 接口限流服务（Redis滑动窗口，多副本共享）
+type RateLimitService struct {
+	slidingWindow SlidingWindowFunc
+}
+
+var rateLimitServiceInstance *RateLimitService
+
+// GetRateLimitService 获取限流服务实例
+func GetRateLimitService() *RateLimitService {
+	if rateLimitServiceInstance == nil {
+		rateLimitServiceInstance = NewRateLimitService(nil)
+	}
+	return rateLimitServiceInstance
+}
+
+// NewRateLimitService 创建限流服务，slidingWindow为空时使用Redis滑动窗口
+func NewRateLimitService(slidingWindow SlidingWindowFunc) *RateLimitService {
+	if slidingWindow == nil {
+		slidingWindow = models.SlidingWindowAllow
+	}
+	return &RateLimitService{slidingWindow: slidingWindow}
+}
+
+// SetupRateLimitService 替换限流服务实例
+func SetupRateLimitService(service *RateLimitService) {
+	rateLimitServiceInstance = service
+}
+
+// RateLimitDecision 限流判定结果
+type RateLimitDecision struct {
+	Policy    string
+	Limit     int
+	Remaining int
+	ResetAt   time.Time
+	Allowed   bool
+}
+
+// RetryAfter 距离下次可请求的秒数
+func (d *RateLimitDecision) RetryAfter() int64 {
+	seconds := int64(time.Until(d.ResetAt).Seconds() + 0.999)
+	if seconds < 1 {
+		seconds = 1
+	}
+	return seconds
+}
+
+// Allow 按策略对身份标识（ip:xxx / user:xxx）进行限流判定，未配置策略时返回nil
+func (s *RateLimitService) Allow(policyName, identity string) *RateLimitDecision {
+	cfg := config.Get()
+	if cfg == nil || cfg.RateLimit == nil || !cfg.RateLimit.IsEnabled() {
+		return nil
+	}
+	policy := cfg.RateLimit.GetPolicy(policyName)
+	if policy == nil {
+		return nil
+	}
+
+	window := time.Duration(policy.Window) * time.Second
+	key := models.FormatCacheKey("%s:%s:%s", rateLimitKeyPrefix, policyName, identity)
+	result := s.slidingWindow(key, policy.Limit, window)
+
+	remaining := policy.Limit - int(result.Count)
+	if remaining < 0 {
+		remaining = 0
+	}
+	decision := &RateLimitDecision{
+		Policy:    policyName,
+		Limit:     policy.Limit,
+		Remaining: remaining,
+		ResetAt:   result.ResetAt,
+		Allowed:   result.Allowed,
+	}
+	if !decision.Allowed {
+		s.recordOffender(policyName, identity)
+	}
+	return decision
+}
+
+// recordOffender 记录被拦截的身份标识，用于管理端排行
+func (s *RateLimitService) recordOffender(policyName, identity string) {
+	rdb := models.GetRedis()
+	if rdb == nil {
+		return
+	}
+	ctx := context.Background()
+	key := models.FormatCacheKey(rateLimitOffendersKey, time.Now().Format(rateLimitOffenderFormat))
+	pipe := rdb.TxPipeline()
+	pipe.ZIncrBy(ctx, key, 1, policyName+"|"+identity)
+	pipe.Expire(ctx, key, rateLimitOffendersTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Warnf("Failed to record rate limit offender %s/%s: %v", policyName, identity, err)
+	}
+}
+
+// GetTopOffenders 获取指定日期（yyyyMMdd，默认今天）被拦截次数最多的身份标识
+func (s *RateLimitService) GetTopOffenders(date string, limit int) ([]*protocol.RateLimitOffender, protocol.ErrorCode) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	if date == "" {
+		date = time.Now().Format(rateLimitOffenderFormat)
+	} else if _, err := time.Parse(rateLimitOffenderFormat, date); err != nil {
+		return nil, protocol.InvalidParams
+	}
+
+	offenders := make([]*protocol.RateLimitOffender, 0)
+	rdb := models.GetRedis()
+	if rdb == nil {
+		return offenders, protocol.Success
+	}
+
+	key := models.FormatCacheKey(rateLimitOffendersKey, date)
+	entries, err := rdb.ZRevRangeWithScores(context.Background(), key, 0, int64(limit-1)).Result()
+	if err != nil {
+		log.Errorf("Failed to load rate limit offenders: %v", err)
+		return nil, protocol.CacheError
+	}
+	for _, entry := range entries {
+		member := fmt.Sprint(entry.Member)
+		policy, identity, _ := strings.Cut(member, "|")
+		identityType, identityValue, _ := strings.Cut(identity, ":")
+		offenders = append(offenders, &protocol.RateLimitOffender{
+			Policy:       policy,
+			IdentityType: identityType,
+			Identity:     identityValue,
+			BlockedCount: int64(entry.Score),
+		})
+	}
+	return offenders, protocol.Success
+}
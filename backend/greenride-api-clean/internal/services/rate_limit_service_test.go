@@ -0,0 +1,143 @@
+package services
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"greenride/internal/config"
+	"greenride/internal/models"
+)
+
+// fakeSlidingWindow 内存滑动窗口，available=false 时模拟Redis不可用
+type fakeSlidingWindow struct {
+	available bool
+	hits      map[string][]time.Time
+	now       time.Time
+}
+
+func newFakeSlidingWindow() *fakeSlidingWindow {
+	return &fakeSlidingWindow{available: true, hits: make(map[string][]time.Time), now: time.Now()}
+}
+
+func (f *fakeSlidingWindow) allow(key string, limit int, window time.Duration) *models.SlidingWindowResult {
+	if !f.available {
+		return &models.SlidingWindowResult{Allowed: true, ResetAt: f.now.Add(window)}
+	}
+	kept := f.hits[key][:0]
+	for _, at := range f.hits[key] {
+		if f.now.Sub(at) < window {
+			kept = append(kept, at)
+		}
+	}
+	allowed := len(kept) < limit
+	if allowed {
+		kept = append(kept, f.now)
+	}
+	f.hits[key] = kept
+	return &models.SlidingWindowResult{
+		Allowed:   allowed,
+		Count:     int64(len(kept)),
+		ResetAt:   kept[0].Add(window),
+		Available: true,
+	}
+}
+
+func setupRateLimitConfig(t *testing.T, rateLimit *config.RateLimitConfig) {
+	t.Helper()
+	previous := config.Get()
+	t.Cleanup(func() { config.Set(previous) })
+	rateLimit.Validate()
+	config.Set(&config.Config{Log: &config.LogConfig{Path: os.TempDir()}, RateLimit: rateLimit})
+}
+
+func TestRateLimitPolicyDefaults(t *testing.T) {
+	cfg := &config.RateLimitConfig{Policies: map[string]*config.RateLimitPolicy{
+		config.RateLimitPolicyOTP: {Limit: 3, By: "bogus"},
+	}}
+	cfg.Validate()
+
+	if !cfg.IsEnabled() {
+		t.Fatal("rate limit should default to on")
+	}
+	otp := cfg.GetPolicy(config.RateLimitPolicyOTP)
+	if otp.Limit != 3 || otp.Window != 60 || otp.By != config.RateLimitByIP {
+		t.Errorf("otp policy = %+v, want override limit with default window/by", otp)
+	}
+	if user := cfg.GetPolicy(config.RateLimitPolicyUser); user == nil || user.By != config.RateLimitByUser {
+		t.Errorf("user policy = %+v, want by user", user)
+	}
+	if webhook := cfg.GetPolicy(config.RateLimitPolicyWebhook); webhook == nil || webhook.Limit < 1000 {
+		t.Errorf("webhook policy = %+v, want a high limit for payment callbacks", webhook)
+	}
+	if cfg.GetPolicy("missing") != nil {
+		t.Error("unknown policy should be nil")
+	}
+	var nilCfg *config.RateLimitConfig
+	if nilCfg.GetPolicy(config.RateLimitPolicyOTP) != nil {
+		t.Error("nil config should have no policies")
+	}
+}
+
+func TestRateLimitAllow(t *testing.T) {
+	setupRateLimitConfig(t, &config.RateLimitConfig{Policies: map[string]*config.RateLimitPolicy{
+		config.RateLimitPolicyOTP: {Limit: 2, Window: 60, By: config.RateLimitByIP},
+	}})
+	window := newFakeSlidingWindow()
+	service := NewRateLimitService(window.allow)
+
+	cases := []struct {
+		identity      string
+		wantAllowed   bool
+		wantRemaining int
+	}{
+		{"ip:1.1.1.1", true, 1},
+		{"ip:1.1.1.1", true, 0},
+		{"ip:1.1.1.1", false, 0},
+		{"ip:2.2.2.2", true, 1}, // 不同身份独立计数
+	}
+	for i, tc := range cases {
+		decision := service.Allow(config.RateLimitPolicyOTP, tc.identity)
+		if decision == nil {
+			t.Fatalf("case %d: decision is nil", i)
+		}
+		if decision.Allowed != tc.wantAllowed || decision.Remaining != tc.wantRemaining || decision.Limit != 2 {
+			t.Errorf("case %d: got allowed=%v remaining=%d limit=%d, want allowed=%v remaining=%d",
+				i, decision.Allowed, decision.Remaining, decision.Limit, tc.wantAllowed, tc.wantRemaining)
+		}
+	}
+
+	// 窗口滑过后恢复
+	window.now = window.now.Add(61 * time.Second)
+	if decision := service.Allow(config.RateLimitPolicyOTP, "ip:1.1.1.1"); !decision.Allowed {
+		t.Error("request should be allowed after the window slides")
+	}
+}
+
+func TestRateLimitFailOpen(t *testing.T) {
+	setupRateLimitConfig(t, &config.RateLimitConfig{Policies: map[string]*config.RateLimitPolicy{
+		config.RateLimitPolicyOTP: {Limit: 1, Window: 60, By: config.RateLimitByIP},
+	}})
+	window := newFakeSlidingWindow()
+	window.available = false
+	service := NewRateLimitService(window.allow)
+
+	for i := 0; i < 5; i++ {
+		if decision := service.Allow(config.RateLimitPolicyOTP, "ip:1.1.1.1"); decision == nil || !decision.Allowed {
+			t.Fatalf("request %d should be allowed when redis is unavailable", i)
+		}
+	}
+}
+
+func TestRateLimitDisabledOrUnknownPolicy(t *testing.T) {
+	setupRateLimitConfig(t, &config.RateLimitConfig{Enabled: config.StatusOff})
+	service := NewRateLimitService(newFakeSlidingWindow().allow)
+	if decision := service.Allow(config.RateLimitPolicyOTP, "ip:1.1.1.1"); decision != nil {
+		t.Errorf("disabled rate limit should return nil, got %+v", decision)
+	}
+
+	setupRateLimitConfig(t, &config.RateLimitConfig{})
+	if decision := service.Allow("missing", "ip:1.1.1.1"); decision != nil {
+		t.Errorf("unknown policy should return nil, got %+v", decision)
+	}
+}
@@ -0,0 +1,465 @@
+package services
+
+import (
+	"slices"
+	"strings"
+	"sync"
+
+	"greenride/internal/config"
+	"greenride/internal/log"
+	"greenride/internal/models"
+	"greenride/internal/protocol"
+	"greenride/internal/utils"
+)
+
+// ReferralService 邀请奖励服务
+// 注册时记录邀请关系并做风控检查，被邀请人完成指定订单数后给双方发奖励（司机邀请司机奖励入账钱包）
+type ReferralService struct {
+}
+
+var (
+	referralInstance *ReferralService
+	referralOnce     sync.Once
+)
+
+func GetReferralService() *ReferralService {
+	referralOnce.Do(func() {
+		SetupReferralService()
+	})
+	return referralInstance
+}
+
+func SetupReferralService() {
+	referralInstance = &ReferralService{}
+}
+
+// referralFraudProfile 风控比对用的用户特征
+type referralFraudProfile struct {
+	UserID          string
+	Phone           string // 去掉国家码和前导0后的号码
+	Email           string
+	DeviceID        string
+	FCMDeviceIDs    []string
+	PaymentAccounts []string
+}
+
+// loadReferralFraudProfile 加载用户的设备和支付账号特征
+func loadReferralFraudProfile(user *models.User, cfg *config.ReferralConfig) *referralFraudProfile {
+	profile := &referralFraudProfile{
+		UserID: user.UserID,
+		Phone:  nationalPhoneDigits(user.GetPhone(), user.GetCountryCode()),
+		Email:  strings.ToLower(strings.TrimSpace(user.GetEmail())),
+	}
+	if cfg.CheckSharedDevice == config.StatusOn {
+		profile.DeviceID = user.GetDeviceID()
+		profile.FCMDeviceIDs = models.GetUserFCMDeviceIDs(user.UserID)
+	}
+	if cfg.CheckSharedPaymentAccount == config.StatusOn {
+		profile.PaymentAccounts = models.GetUserPaymentAccounts(user.UserID)
+	}
+	return profile
+}
+
+// nationalPhoneDigits 只保留数字，并去掉国家码和前导0，便于不同录入格式的号码比对
+func nationalPhoneDigits(phone, countryCode string) string {
+	digits := onlyDigits(phone)
+	if code := onlyDigits(countryCode); code != "" && len(digits) > len(code)+6 {
+		digits = strings.TrimPrefix(digits, code)
+	}
+	return strings.TrimLeft(digits, "0")
+}
+
+func onlyDigits(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// detectReferralFraud 比对邀请人与被邀请人特征，返回命中的风控信号
+func detectReferralFraud(inviter, invitee *referralFraudProfile, phonePrefixLength int) []string {
+	var signals []string
+	if inviter.UserID == invitee.UserID ||
+		(inviter.Phone != "" && inviter.Phone == invitee.Phone) ||
+		(inviter.Email != "" && inviter.Email == invitee.Email) {
+		signals = append(signals, protocol.ReferralFraudSelfReferral)
+	}
+	if inviter.DeviceID != "" && inviter.DeviceID == invitee.DeviceID {
+		signals = append(signals, protocol.ReferralFraudSharedDevice)
+	}
+	if hasCommonValue(inviter.FCMDeviceIDs, invitee.FCMDeviceIDs) {
+		signals = append(signals, protocol.ReferralFraudSharedFCM)
+	}
+	if phonePrefixLength > 0 && inviter.Phone != invitee.Phone &&
+		len(inviter.Phone) >= phonePrefixLength && len(invitee.Phone) >= phonePrefixLength &&
+		inviter.Phone[:phonePrefixLength] == invitee.Phone[:phonePrefixLength] {
+		signals = append(signals, protocol.ReferralFraudPhonePrefix)
+	}
+	if hasCommonValue(inviter.PaymentAccounts, invitee.PaymentAccounts) {
+		signals = append(signals, protocol.ReferralFraudSharedPayment)
+	}
+	return signals
+}
+
+func hasCommonValue(a, b []string) bool {
+	for _, v := range a {
+		if v != "" && slices.Contains(b, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeFraudSignals 合并风控信号并去重
+func mergeFraudSignals(existing, signals []string) []string {
+	merged := slices.Clone(existing)
+	for _, signal := range signals {
+		if !slices.Contains(merged, signal) {
+			merged = append(merged, signal)
+		}
+	}
+	return merged
+}
+
+// referralProgramType 判断邀请适用的计划：被邀请人是乘客走乘客计划，司机邀请司机走司机计划，其余不奖励
+func referralProgramType(inviter, invitee *models.User) string {
+	switch {
+	case invitee.IsPassenger():
+		return protocol.ReferralProgramPassenger
+	case invitee.IsDriver() && inviter.IsDriver():
+		return protocol.ReferralProgramDriver
+	}
+	return ""
+}
+
+// RecordReferral 注册后记录邀请关系并做首次风控检查
+func (s *ReferralService) RecordReferral(inviteeID string) error {
+	cfg := config.GetReferralConfig()
+	if !cfg.IsEnabled() {
+		return nil
+	}
+	invitee := models.GetUserByID(inviteeID)
+	if invitee == nil || invitee.GetInvitedBy() == "" {
+		return nil
+	}
+	if models.GetReferralByInviteeID(inviteeID) != nil {
+		return nil
+	}
+	inviter := models.GetUserByID(invitee.GetInvitedBy())
+	if inviter == nil {
+		log.Get().Warnf("邀请人 %s 不存在，跳过邀请记录", invitee.GetInvitedBy())
+		return nil
+	}
+	programType := referralProgramType(inviter, invitee)
+	if programType == "" {
+		log.Get().Infof("用户 %s(%s) 邀请 %s(%s) 不在奖励计划内", inviter.UserID, inviter.GetUserType(), inviteeID, invitee.GetUserType())
+		return nil
+	}
+
+	referral := models.NewReferral(inviter.UserID, inviteeID)
+	referral.ProgramType = &programType
+	if programType == protocol.ReferralProgramDriver {
+		referral.RequiredRides = utils.IntPtr(cfg.DriverRequiredRides)
+		referral.BonusAmount = utils.Float64Ptr(cfg.DriverBonusAmount)
+	} else {
+		referral.RequiredRides = utils.IntPtr(cfg.RequiredRides)
+	}
+	if cfg.RewardExpireDays > 0 {
+		referral.ExpireAt = utils.Int64Ptr(utils.TimeNowMilli() + int64(cfg.RewardExpireDays)*24*3600*1000)
+	}
+
+	signals := detectReferralFraud(loadReferralFraudProfile(inviter, cfg), loadReferralFraudProfile(invitee, cfg), cfg.PhonePrefixLength)
+	if len(signals) > 0 {
+		referral.FraudSignals = signals
+		referral.Status = utils.StringPtr(fraudStatus(cfg))
+		log.Get().Warnf("邀请 %s -> %s 命中风控: %v", inviter.UserID, inviteeID, signals)
+	}
+
+	if err := models.GetDB().Create(referral).Error; err != nil {
+		log.Get().Errorf("创建邀请记录失败 invitee=%s: %v", inviteeID, err)
+		return err
+	}
+	return nil
+}
+
+// fraudStatus 命中风控后的状态
+func fraudStatus(cfg *config.ReferralConfig) string {
+	if cfg.FraudAction == config.ReferralFraudActionReject {
+		return protocol.ReferralStatusRejected
+	}
+	return protocol.ReferralStatusReview
+}
+
+// EvaluateReferral 被邀请人完成订单后检查是否达成奖励条件，达成则复查风控、检查上限并发放奖励
+func (s *ReferralService) EvaluateReferral(inviteeID string) error {
+	cfg := config.GetReferralConfig()
+	if !cfg.IsEnabled() {
+		return nil
+	}
+	referral := models.GetReferralByInviteeID(inviteeID)
+	if referral == nil || referral.GetStatus() != protocol.ReferralStatusPending {
+		return nil
+	}
+	pending := []string{protocol.ReferralStatusPending}
+
+	now := utils.TimeNowMilli()
+	if referral.IsExpired(now) {
+		_, err := models.TransitionReferralStatus(referral.ReferralID, pending, map[string]any{"status": protocol.ReferralStatusExpired})
+		return err
+	}
+
+	invitee := models.GetUserByID(inviteeID)
+	inviter := models.GetUserByID(referral.GetInviterID())
+	if invitee == nil || inviter == nil {
+		return nil
+	}
+	if invitee.GetTotalRides() < referral.GetRequiredRides() {
+		return nil
+	}
+
+	// 达成条件时设备和支付账号信息更完整，复查一次风控
+	if !referral.GetFraudApproved() {
+		signals := detectReferralFraud(loadReferralFraudProfile(inviter, cfg), loadReferralFraudProfile(invitee, cfg), cfg.PhonePrefixLength)
+		if len(signals) > 0 {
+			log.Get().Warnf("邀请 %s 发奖前命中风控: %v", referral.ReferralID, signals)
+			_, err := models.FlagReferral(referral.ReferralID, protocol.ReferralStatusPending, fraudStatus(cfg), mergeFraudSignals(referral.FraudSignals, signals))
+			return err
+		}
+	}
+
+	if cfg.MaxRewardsPerInviter > 0 && models.CountRewardedReferrals(inviter.UserID) >= int64(cfg.MaxRewardsPerInviter) {
+		_, err := models.TransitionReferralStatus(referral.ReferralID, pending, map[string]any{
+			"status": protocol.ReferralStatusCapped,
+			"remark": "邀请人奖励次数已达上限",
+		})
+		return err
+	}
+
+	// 先抢占状态再发奖，防止并发重复发放
+	ok, err := models.TransitionReferralStatus(referral.ReferralID, pending, map[string]any{
+		"status":      protocol.ReferralStatusRewarded,
+		"rewarded_at": now,
+	})
+	if err != nil || !ok {
+		return err
+	}
+
+	rewards := s.issueRewards(referral, inviter, invitee, cfg)
+	if err := models.UpdateReferralRewards(referral.ReferralID, rewards); err != nil {
+		log.Get().Errorf("更新邀请奖励记录 %s 失败: %v", referral.ReferralID, err)
+	}
+	if err := models.IncrementUserInviteCount(inviter.UserID); err != nil {
+		log.Get().Warnf("更新邀请人 %s 邀请数失败: %v", inviter.UserID, err)
+	}
+	return nil
+}
+
+// issueRewards 按计划发放奖励，失败项记录在备注中便于人工补发
+func (s *ReferralService) issueRewards(referral *models.Referral, inviter, invitee *models.User, cfg *config.ReferralConfig) *models.ReferralValues {
+	rewards := &models.ReferralValues{}
+	var failures []string
+
+	if referral.GetProgramType() == protocol.ReferralProgramDriver {
+		if amount := referral.GetBonusAmount(); amount > 0 {
+			transaction, err := models.CreditWalletBonus(inviter.UserID, inviter.GetUserType(), cfg.DriverBonusCurrency, amount, "referral", referral.ReferralID, "推荐司机奖励")
+			if err != nil {
+				log.Get().Errorf("司机推荐奖励入账失败 referral=%s: %v", referral.ReferralID, err)
+				failures = append(failures, "driver bonus")
+			} else {
+				rewards.BonusTransactionID = &transaction.TransactionID
+			}
+		}
+	} else {
+		promotionConfig := config.GetPromotionConfig()
+		if inviter.IsPassenger() && promotionConfig.EnableReferralCoupon == config.StatusOn {
+			promotion := models.GetReferralPromotionTemplate(promotionConfig.ReferralCouponCode)
+			if promotion == nil {
+				promotion = models.GetOrCreateDefaultReferralPromotion()
+			}
+			if err := issueReferralCoupon(inviter.UserID, invitee.UserID, promotion, promotionConfig.ReferralCouponValidDays); err != nil {
+				log.Get().Errorf("邀请人 %s 奖励券发放失败: %v", inviter.UserID, err)
+				failures = append(failures, "inviter coupon")
+			} else {
+				rewards.InviterPromotionID = &promotion.PromotionID
+			}
+		}
+		if cfg.InviteeCouponCode != "" {
+			promotion := models.GetReferralPromotionTemplate(cfg.InviteeCouponCode)
+			if err := issueReferralCoupon(invitee.UserID, inviter.UserID, promotion, cfg.InviteeCouponValidDays); err != nil {
+				log.Get().Errorf("被邀请人 %s 奖励券发放失败: %v", invitee.UserID, err)
+				failures = append(failures, "invitee coupon")
+			} else {
+				rewards.InviteePromotionID = &promotion.PromotionID
+			}
+		}
+	}
+
+	if len(failures) > 0 {
+		rewards.Remark = utils.StringPtr("发放失败: " + strings.Join(failures, ", "))
+	}
+	return rewards
+}
+
+// issueReferralCoupon 发放邀请奖励券
+func issueReferralCoupon(userID, referrerID string, promotion *models.Promotion, validDays int) error {
+	if promotion == nil {
+		return models.ErrPromotionUnavailable
+	}
+	userPromotion := models.CreateReferralPromoForUser(userID, referrerID, promotion)
+	if validDays > 0 {
+		userPromotion.SetExpiredAt(utils.TimeNowMilli() + int64(validDays)*24*3600*1000)
+	}
+	return models.CreateUserPromotionInDB(userPromotion)
+}
+
+// GetReferral 获取邀请记录详情
+func (s *ReferralService) GetReferral(referralID string) *protocol.Referral {
+	referral := models.GetReferralByID(referralID)
+	if referral == nil {
+		return nil
+	}
+	return referral.Protocol()
+}
+
+// SearchReferrals 分页查询邀请记录
+func (s *ReferralService) SearchReferrals(req *protocol.ReferralSearchRequest) ([]*protocol.Referral, int64) {
+	referrals, total := models.SearchReferrals(req)
+	list := make([]*protocol.Referral, 0, len(referrals))
+	for _, referral := range referrals {
+		list = append(list, referral.Protocol())
+	}
+	return list, total
+}
+
+// ApproveReferral 人工审核通过命中风控的邀请，已达成条件的立即发奖
+func (s *ReferralService) ApproveReferral(req *protocol.ReferralActionRequest) protocol.ErrorCode {
+	referral := models.GetReferralByID(req.ReferralID)
+	if referral == nil {
+		return protocol.ReferralNotFound
+	}
+	ok, err := models.TransitionReferralStatus(referral.ReferralID, []string{protocol.ReferralStatusReview, protocol.ReferralStatusRejected}, map[string]any{
+		"status":         protocol.ReferralStatusPending,
+		"fraud_approved": true,
+		"reviewed_by":    req.UserID,
+		"reviewed_at":    utils.TimeNowMilli(),
+		"remark":         req.Notes,
+	})
+	if err != nil {
+		log.Get().Errorf("审核通过邀请 %s 失败: %v", referral.ReferralID, err)
+		return protocol.DatabaseError
+	}
+	if !ok {
+		return protocol.ReferralStatusInvalid
+	}
+	if err := s.EvaluateReferral(referral.InviteeID); err != nil {
+		log.Get().Errorf("审核通过后发放邀请奖励 %s 失败: %v", referral.ReferralID, err)
+	}
+	return protocol.Success
+}
+
+// RejectReferral 人工拒绝邀请奖励
+func (s *ReferralService) RejectReferral(req *protocol.ReferralActionRequest) protocol.ErrorCode {
+	referral := models.GetReferralByID(req.ReferralID)
+	if referral == nil {
+		return protocol.ReferralNotFound
+	}
+	ok, err := models.TransitionReferralStatus(referral.ReferralID, []string{protocol.ReferralStatusPending, protocol.ReferralStatusReview}, map[string]any{
+		"status":      protocol.ReferralStatusRejected,
+		"reviewed_by": req.UserID,
+		"reviewed_at": utils.TimeNowMilli(),
+		"remark":      req.Notes,
+	})
+	if err != nil {
+		log.Get().Errorf("拒绝邀请 %s 失败: %v", referral.ReferralID, err)
+		return protocol.DatabaseError
+	}
+	if !ok {
+		return protocol.ReferralStatusInvalid
+	}
+	return protocol.Success
+}
+
+// GetReferralTree 获取用户的邀请树：向上的邀请链和向下 depth 层被邀请人
+func (s *ReferralService) GetReferralTree(userID string, depth int) (*protocol.ReferralTree, protocol.ErrorCode) {
+	cfg := config.GetReferralConfig()
+	if depth <= 0 || depth > cfg.MaxTreeDepth {
+		depth = cfg.MaxTreeDepth
+	}
+	root := models.GetUserByID(userID)
+	if root == nil {
+		return nil, protocol.UserNotFound
+	}
+
+	// 向上查找邀请链，visited 防止脏数据成环
+	var ancestorUsers []*models.User
+	visited := map[string]bool{root.UserID: true}
+	for current := root; len(ancestorUsers) < cfg.MaxTreeDepth && current.GetInvitedBy() != ""; {
+		parent := models.GetUserByID(current.GetInvitedBy())
+		if parent == nil || visited[parent.UserID] {
+			break
+		}
+		visited[parent.UserID] = true
+		ancestorUsers = append(ancestorUsers, parent)
+		current = parent
+	}
+
+	// 逐层向下查找，多查一层用于标记是否还有下级
+	childrenByParent := make(map[string][]*models.User)
+	userIDs := []string{root.UserID}
+	parentIDs := []string{root.UserID}
+	for level := 0; level <= depth && len(parentIDs) > 0; level++ {
+		children := models.GetUsersInvitedBy(parentIDs)
+		parentIDs = parentIDs[:0]
+		for _, child := range children {
+			if visited[child.UserID] {
+				continue
+			}
+			visited[child.UserID] = true
+			childrenByParent[child.GetInvitedBy()] = append(childrenByParent[child.GetInvitedBy()], child)
+			parentIDs = append(parentIDs, child.UserID)
+			userIDs = append(userIDs, child.UserID)
+		}
+	}
+	for _, ancestor := range ancestorUsers {
+		userIDs = append(userIDs, ancestor.UserID)
+	}
+	referrals := models.GetReferralsByInviteeIDs(userIDs)
+
+	tree := &protocol.ReferralTree{
+		Ancestors: make([]*protocol.ReferralTreeNode, 0, len(ancestorUsers)),
+		Root:      buildReferralTreeNode(root, childrenByParent, referrals, depth),
+		Depth:     depth,
+	}
+	for _, ancestor := range ancestorUsers {
+		tree.Ancestors = append(tree.Ancestors, buildReferralTreeNode(ancestor, nil, referrals, 0))
+	}
+	return tree, protocol.Success
+}
+
+// buildReferralTreeNode 递归构建邀请树节点，depth 为剩余展开层数
+func buildReferralTreeNode(user *models.User, childrenByParent map[string][]*models.User, referrals map[string]*models.Referral, depth int) *protocol.ReferralTreeNode {
+	node := &protocol.ReferralTreeNode{
+		UserID:       user.UserID,
+		UserType:     user.GetUserType(),
+		Name:         user.GetFullName(),
+		Phone:        user.GetPhone(),
+		InviteCount:  user.GetInviteCount(),
+		TotalRides:   user.GetTotalRides(),
+		RegisteredAt: user.CreatedAt,
+	}
+	if referral := referrals[user.UserID]; referral != nil {
+		node.Referral = referral.Protocol()
+	}
+	children := childrenByParent[user.UserID]
+	if depth <= 0 {
+		node.HasMore = len(children) > 0
+		return node
+	}
+	for _, child := range children {
+		node.Children = append(node.Children, buildReferralTreeNode(child, childrenByParent, referrals, depth-1))
+	}
+	return node
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"slices"
+	"testing"
+
+	"greenride/internal/models"
+	"greenride/internal/protocol"
+)
+
+func TestNationalPhoneDigits(t *testing.T) {
+	cases := []struct {
+		phone, countryCode, want string
+	}{
+		{"+250 788 123 456", "+250", "788123456"},
+		{"0788123456", "+250", "788123456"},
+		{"250788123456", "250", "788123456"},
+		{"788-123-456", "", "788123456"},
+		{"", "+250", ""},
+	}
+	for _, c := range cases {
+		if got := nationalPhoneDigits(c.phone, c.countryCode); got != c.want {
+			t.Errorf("nationalPhoneDigits(%q, %q) = %q, want %q", c.phone, c.countryCode, got, c.want)
+		}
+	}
+}
+
+func TestDetectReferralFraud(t *testing.T) {
+	inviter := &referralFraudProfile{
+		UserID:          "U1",
+		Phone:           "788123456",
+		Email:           "alice@example.com",
+		DeviceID:        "dev-1",
+		FCMDeviceIDs:    []string{"fcm-1"},
+		PaymentAccounts: []string{"250788123456"},
+	}
+
+	clean := &referralFraudProfile{UserID: "U2", Phone: "722999888", Email: "bob@example.com", DeviceID: "dev-2"}
+	if signals := detectReferralFraud(inviter, clean, 5); len(signals) != 0 {
+		t.Fatalf("unexpected signals for unrelated users: %v", signals)
+	}
+
+	cases := []struct {
+		name    string
+		invitee *referralFraudProfile
+		want    string
+	}{
+		{"same phone", &referralFraudProfile{UserID: "U2", Phone: "788123456"}, protocol.ReferralFraudSelfReferral},
+		{"same email", &referralFraudProfile{UserID: "U2", Email: "alice@example.com"}, protocol.ReferralFraudSelfReferral},
+		{"shared device", &referralFraudProfile{UserID: "U2", DeviceID: "dev-1"}, protocol.ReferralFraudSharedDevice},
+		{"shared fcm device", &referralFraudProfile{UserID: "U2", FCMDeviceIDs: []string{"fcm-9", "fcm-1"}}, protocol.ReferralFraudSharedFCM},
+		{"phone prefix", &referralFraudProfile{UserID: "U2", Phone: "788123999"}, protocol.ReferralFraudPhonePrefix},
+		{"shared payment", &referralFraudProfile{UserID: "U2", PaymentAccounts: []string{"250788123456"}}, protocol.ReferralFraudSharedPayment},
+	}
+	for _, c := range cases {
+		signals := detectReferralFraud(inviter, c.invitee, 5)
+		if !slices.Contains(signals, c.want) {
+			t.Errorf("%s: signals %v missing %s", c.name, signals, c.want)
+		}
+	}
+
+	// 相同号码只记为自我邀请，不重复记为前缀相同；前缀检查关闭时不命中
+	if signals := detectReferralFraud(inviter, &referralFraudProfile{UserID: "U2", Phone: "788123456"}, 5); slices.Contains(signals, protocol.ReferralFraudPhonePrefix) {
+		t.Errorf("identical phone should not be reported as prefix match: %v", signals)
+	}
+	if signals := detectReferralFraud(inviter, &referralFraudProfile{UserID: "U2", Phone: "788123999"}, 0); len(signals) != 0 {
+		t.Errorf("prefix check disabled but got %v", signals)
+	}
+}
+
+func TestBuildReferralTreeNode(t *testing.T) {
+	newUser := func(userID, invitedBy string) *models.User {
+		user := models.NewUser()
+		user.UserID = userID
+		if invitedBy != "" {
+			user.SetInvitedBy(invitedBy)
+		}
+		return user
+	}
+	root := newUser("U1", "")
+	a, b := newUser("U2", "U1"), newUser("U3", "U1")
+	grandchild := newUser("U4", "U2")
+	childrenByParent := map[string][]*models.User{
+		"U1": {a, b},
+		"U2": {grandchild},
+	}
+	referral := models.NewReferral("U1", "U2")
+	referral.FraudSignals = []string{protocol.ReferralFraudSharedDevice}
+	referrals := map[string]*models.Referral{"U2": referral}
+
+	node := buildReferralTreeNode(root, childrenByParent, referrals, 1)
+	if len(node.Children) != 2 || node.HasMore {
+		t.Fatalf("root should have 2 children and nothing hidden, got %d children, has_more=%v", len(node.Children), node.HasMore)
+	}
+	first := node.Children[0]
+	if first.UserID != "U2" || first.Referral == nil || first.Referral.FraudSignals[0] != protocol.ReferralFraudSharedDevice {
+		t.Fatalf("first child should carry its referral record: %+v", first)
+	}
+	if len(first.Children) != 0 || !first.HasMore {
+		t.Fatalf("depth limit reached, U2 should be collapsed with has_more, got %+v", first)
+	}
+	if second := node.Children[1]; second.HasMore || second.Referral != nil {
+		t.Fatalf("U3 has no children and no referral record, got %+v", second)
+	}
+
+	full := buildReferralTreeNode(root, childrenByParent, referrals, 2)
+	if len(full.Children[0].Children) != 1 || full.Children[0].Children[0].UserID != "U4" {
+		t.Fatalf("depth 2 should expand grandchild, got %+v", full.Children[0])
+	}
+}
@@ -0,0 +1,427 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"sync"
+
+	"greenride/internal/config"
+	"greenride/internal/log"
+	"greenride/internal/models"
+	"greenride/internal/protocol"
+	"greenride/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+// RidePoolService 拼车服务
+// 同向的拼车订单在座位及绕路限制内派给同一辆车：司机接第一单时创建拼车，
+// 之后可加入的订单按新增里程最少的位置插入接送计划，上车、下车或取消时更新计划。
+type RidePoolService struct {
+}
+
+var (
+	ridePoolInstance *RidePoolService
+	ridePoolOnce     sync.Once
+)
+
+var (
+	errPoolNotCompatible = errors.New("order cannot join the driver's pool")
+	errPoolSeatsExceeded = errors.New("pool seats exceeded")
+)
+
+func GetRidePoolService() *RidePoolService {
+	ridePoolOnce.Do(func() {
+		SetupRidePoolService()
+	})
+	return ridePoolInstance
+}
+
+func SetupRidePoolService() {
+	ridePoolInstance = &RidePoolService{}
+}
+
+// poolInsertion 新乘客加入后的接送计划
+type poolInsertion struct {
+	Plan    []*protocol.PoolStop // 已完成节点在前，之后为按顺序排列的待完成节点
+	RouteKm float64              // 从起点依次经过待完成节点的里程
+}
+
+// poolStopPoint 节点坐标
+func poolStopPoint(stop *protocol.PoolStop) routePoint {
+	return routePoint{Lat: stop.Latitude, Lng: stop.Longitude}
+}
+
+// poolDistanceKm 两点直线距离
+func poolDistanceKm(from, to routePoint) float64 {
+	return utils.CalculateDistanceHaversine(from.Lat, from.Lng, to.Lat, to.Lng)
+}
+
+// poolRouteKm 从起点依次经过各节点的直线里程
+func poolRouteKm(start routePoint, stops []*protocol.PoolStop) float64 {
+	total := 0.0
+	current := start
+	for _, stop := range stops {
+		next := poolStopPoint(stop)
+		total += poolDistanceKm(current, next)
+		current = next
+	}
+	return total
+}
+
+// bearingDiff 两个方位角之间的夹角，取值[0, 180]
+func bearingDiff(a, b float64) float64 {
+	diff := math.Mod(math.Abs(a-b), 360)
+	if diff > 180 {
+		diff = 360 - diff
+	}
+	return diff
+}
+
+// poolSeatsFit 按接送顺序检查车上乘客座位是否始终不超过可售座位，onboard为起点时已在车上的座位数
+func poolSeatsFit(route []*protocol.PoolStop, onboard, seatCapacity int) bool {
+	seats := onboard
+	for _, stop := range route {
+		switch stop.Type {
+		case protocol.PoolStopTypePickup:
+			seats += stop.Seats
+			if seats > seatCapacity {
+				return false
+			}
+		case protocol.PoolStopTypeDropoff:
+			seats -= stop.Seats
+		}
+	}
+	return true
+}
+
+// poolDetourFit 检查每位乘客在路线上的行程距离不超过直达距离的maxRatio倍
+// 已上车的乘客从起点开始计算
+func poolDetourFit(start routePoint, route []*protocol.PoolStop, maxRatio float64) bool {
+	type boarding struct {
+		point routePoint
+		km    float64 // 上车时路线累计里程
+	}
+	boarded := map[string]boarding{}
+	traveled := 0.0
+	current := start
+	for _, stop := range route {
+		next := poolStopPoint(stop)
+		traveled += poolDistanceKm(current, next)
+		current = next
+		if stop.Type == protocol.PoolStopTypePickup {
+			boarded[stop.OrderID] = boarding{point: next, km: traveled}
+			continue
+		}
+		from, ok := boarded[stop.OrderID]
+		if !ok {
+			from = boarding{point: start}
+		}
+		direct := poolDistanceKm(from.point, next)
+		if direct > 0 && traveled-from.km > direct*maxRatio {
+			return false
+		}
+	}
+	return true
+}
+
+// planPoolInsertion 在待完成节点中为新乘客寻找新增里程最少、且满足座位和绕路限制的上下车位置
+func planPoolInsertion(start routePoint, plan []*protocol.PoolStop, pickup, dropoff *protocol.PoolStop, seatCapacity int, maxDetourRatio, maxDetourKm float64) *poolInsertion {
+	var done, pending []*protocol.PoolStop
+	for _, stop := range plan {
+		if stop.IsPending() {
+			pending = append(pending, stop)
+		} else {
+			done = append(done, stop)
+		}
+	}
+	// 起点时已在车上的乘客：下车点待完成但上车点已完成
+	onboard := 0
+	for _, stop := range pending {
+		if stop.Type != protocol.PoolStopTypeDropoff {
+			continue
+		}
+		waiting := slices.ContainsFunc(pending, func(item *protocol.PoolStop) bool {
+			return item.OrderID == stop.OrderID && item.Type == protocol.PoolStopTypePickup
+		})
+		if !waiting {
+			onboard += stop.Seats
+		}
+	}
+
+	baseKm := poolRouteKm(start, pending)
+	directKm := poolDistanceKm(poolStopPoint(pickup), poolStopPoint(dropoff))
+	var best *poolInsertion
+	for i := 0; i <= len(pending); i++ {
+		for j := i; j <= len(pending); j++ {
+			route := make([]*protocol.PoolStop, 0, len(pending)+2)
+			route = append(route, pending[:i]...)
+			route = append(route, pickup)
+			route = append(route, pending[i:j]...)
+			route = append(route, dropoff)
+			route = append(route, pending[j:]...)
+			if !poolSeatsFit(route, onboard, seatCapacity) || !poolDetourFit(start, route, maxDetourRatio) {
+				continue
+			}
+			routeKm := poolRouteKm(start, route)
+			if maxDetourKm > 0 && routeKm-baseKm-directKm > maxDetourKm {
+				continue
+			}
+			if best == nil || routeKm < best.RouteKm {
+				best = &poolInsertion{RouteKm: routeKm, Plan: route}
+			}
+		}
+	}
+	if best != nil {
+		best.Plan = append(slices.Clone(done), best.Plan...)
+	}
+	return best
+}
+
+// matchPoolPlan 判断新乘客能否加入拼车：与车上及待接乘客同向、上车点在当前路线附近，且存在满足限制的插入位置
+func matchPoolPlan(start routePoint, plan []*protocol.PoolStop, pickup, dropoff *protocol.PoolStop, seatCapacity int, cfg *config.PoolConfig) *poolInsertion {
+	if pickup.Seats > seatCapacity {
+		return nil
+	}
+	bearing := utils.CalculateBearing(pickup.Latitude, pickup.Longitude, dropoff.Latitude, dropoff.Longitude)
+	near := poolDistanceKm(start, poolStopPoint(pickup)) <= cfg.MaxPickupDistanceKm
+	for _, stop := range plan {
+		if !stop.IsPending() {
+			continue
+		}
+		if !near && poolDistanceKm(poolStopPoint(stop), poolStopPoint(pickup)) <= cfg.MaxPickupDistanceKm {
+			near = true
+		}
+		if stop.Type != protocol.PoolStopTypeDropoff {
+			continue
+		}
+		// 以该乘客上车点到下车点的方向判断是否同向
+		index := slices.IndexFunc(plan, func(item *protocol.PoolStop) bool {
+			return item.OrderID == stop.OrderID && item.Type == protocol.PoolStopTypePickup
+		})
+		if index < 0 {
+			continue
+		}
+		other := plan[index]
+		otherBearing := utils.CalculateBearing(other.Latitude, other.Longitude, stop.Latitude, stop.Longitude)
+		if bearingDiff(bearing, otherBearing) > cfg.MaxBearingDiff {
+			return nil
+		}
+	}
+	if !near {
+		return nil
+	}
+	return planPoolInsertion(start, plan, pickup, dropoff, seatCapacity, cfg.MaxDetourRatio, cfg.MaxDetourKm)
+}
+
+// newPoolStops 根据订单上下车点生成拼车节点
+func newPoolStops(orderID string, detail *protocol.OrderDetail) (*protocol.PoolStop, *protocol.PoolStop) {
+	seats := max(detail.PassengerCount, 1)
+	pickup := &protocol.PoolStop{
+		OrderID:   orderID,
+		Type:      protocol.PoolStopTypePickup,
+		Latitude:  detail.PickupLatitude,
+		Longitude: detail.PickupLongitude,
+		Address:   detail.PickupAddress,
+		Seats:     seats,
+		Status:    protocol.PoolStopStatusPending,
+	}
+	dropoff := &protocol.PoolStop{
+		OrderID:   orderID,
+		Type:      protocol.PoolStopTypeDropoff,
+		Latitude:  detail.DropoffLatitude,
+		Longitude: detail.DropoffLongitude,
+		Address:   detail.DropoffAddress,
+		Seats:     seats,
+		Status:    protocol.PoolStopStatusPending,
+	}
+	return pickup, dropoff
+}
+
+// poolStartPoint 计算插入位置的起点：优先使用司机当前位置，没有定位时使用下一个待完成节点
+func poolStartPoint(lat, lng float64, plan []*protocol.PoolStop, pickup *protocol.PoolStop) routePoint {
+	if lat != 0 || lng != 0 {
+		return routePoint{Lat: lat, Lng: lng}
+	}
+	for _, stop := range plan {
+		if stop.IsPending() {
+			return poolStopPoint(stop)
+		}
+	}
+	return poolStopPoint(pickup)
+}
+
+// ValidateRideType 校验预估请求的行程类型
+func (s *RidePoolService) ValidateRideType(req *protocol.EstimateRequest) protocol.ErrorCode {
+	switch req.RideType {
+	case "", protocol.RideTypeStandard:
+		return protocol.Success
+	case protocol.RideTypePool:
+	default:
+		return protocol.InvalidParams
+	}
+	cfg := config.GetPoolConfig()
+	if !cfg.IsEnabled() {
+		return protocol.PoolRideDisabled
+	}
+	if len(req.Stops) > 0 {
+		return protocol.PoolStopsNotSupported
+	}
+	if req.PassengerCount > cfg.SeatCapacity {
+		return protocol.PoolSeatsExceeded
+	}
+	return protocol.Success
+}
+
+// CanJoinDriverPool 派单时判断拼车订单能否加入司机进行中的拼车
+func (s *RidePoolService) CanJoinDriverPool(rt *protocol.DriverRuntime, order *protocol.Order) bool {
+	if rt.PoolID == "" || order.Details == nil || order.Details.RideType != protocol.RideTypePool {
+		return false
+	}
+	pool := models.GetRidePoolByID(rt.PoolID)
+	if pool == nil || !pool.IsOpen() || pool.GetDriverID() != rt.DriverID {
+		return false
+	}
+	pickup, dropoff := newPoolStops(order.OrderID, order.Details)
+	start := poolStartPoint(rt.Latitude, rt.Longitude, pool.GetPlan(), pickup)
+	return matchPoolPlan(start, pool.GetPlan(), pickup, dropoff, pool.GetSeatCapacity(), config.GetPoolConfig()) != nil
+}
+
+// JoinPool 司机接拼车订单时在事务中加入其进行中的拼车，没有则新建拼车
+// 非拼车订单直接返回；无法加入时返回errPoolNotCompatible或errPoolSeatsExceeded
+func (s *RidePoolService) JoinPool(tx *gorm.DB, driver *models.User, vehicle *models.Vehicle, orderID string) error {
+	rideOrder := models.GetRideOrderByOrderID(orderID)
+	if rideOrder == nil || !rideOrder.IsPool() {
+		return nil
+	}
+	cfg := config.GetPoolConfig()
+	pickup, dropoff := newPoolStops(orderID, rideOrder.ToOrderDetail())
+
+	pool := models.GetOpenRidePoolByDriver(driver.UserID)
+	if pool == nil {
+		seatCapacity := cfg.SeatCapacity
+		// 车辆座位数包含司机座位
+		if vehicleSeats := vehicle.GetSeatCapacity() - 1; vehicleSeats > 0 && vehicleSeats < seatCapacity {
+			seatCapacity = vehicleSeats
+		}
+		if pickup.Seats > seatCapacity {
+			return errPoolSeatsExceeded
+		}
+		pool = models.NewRidePool(driver.UserID, vehicle.VehicleID, seatCapacity)
+		pool.Plan = []*protocol.PoolStop{pickup, dropoff}
+		if err := tx.Create(pool).Error; err != nil {
+			return err
+		}
+	} else {
+		locked := models.LockRidePool(tx, pool.PoolID)
+		if locked == nil || !locked.IsOpen() {
+			return errPoolNotCompatible
+		}
+		start := poolStartPoint(driver.GetLatitude(), driver.GetLongitude(), locked.GetPlan(), pickup)
+		inserted := matchPoolPlan(start, locked.GetPlan(), pickup, dropoff, locked.GetSeatCapacity(), cfg)
+		if inserted == nil {
+			return errPoolNotCompatible
+		}
+		locked.Plan = inserted.Plan
+		if err := models.SaveRidePoolPlan(tx, locked); err != nil {
+			return err
+		}
+		pool = locked
+	}
+	log.Get().Infof("拼车 %s 加入订单 %s，司机=%s", pool.PoolID, orderID, driver.UserID)
+	return models.SetRideOrderPoolID(tx, orderID, pool.PoolID)
+}
+
+// CompleteStop 乘客上车或下车后将对应节点标记为已完成，所有节点完成后拼车结束
+func (s *RidePoolService) CompleteStop(orderID, stopType string) {
+	s.updateOrderStops(orderID, func(stop *protocol.PoolStop) bool {
+		if stop.Type != stopType {
+			return false
+		}
+		stop.Status = protocol.PoolStopStatusDone
+		stop.DoneAt = utils.TimeNowMilli()
+		return true
+	})
+}
+
+// LeavePool 订单取消后从接送计划中移除其未完成节点
+func (s *RidePoolService) LeavePool(orderID string) {
+	driverID := s.updateOrderStops(orderID, func(stop *protocol.PoolStop) bool {
+		stop.Status = protocol.PoolStopStatusRemoved
+		return true
+	})
+	if driverID != "" {
+		go GetUserService().RefreshDriverRuntimeCache(driverID)
+	}
+}
+
+// updateOrderStops 在事务中修改订单的待完成节点并保存计划，返回拼车司机ID（未修改时为空）
+func (s *RidePoolService) updateOrderStops(orderID string, update func(stop *protocol.PoolStop) bool) string {
+	rideOrder := models.GetRideOrderByOrderID(orderID)
+	if rideOrder == nil || rideOrder.GetPoolID() == "" {
+		return ""
+	}
+	driverID := ""
+	err := models.GetDB().Transaction(func(tx *gorm.DB) error {
+		pool := models.LockRidePool(tx, rideOrder.GetPoolID())
+		if pool == nil || !pool.IsOpen() {
+			return nil
+		}
+		changed := false
+		for _, stop := range pool.GetPlan() {
+			if stop.OrderID == orderID && stop.IsPending() && update(stop) {
+				changed = true
+			}
+		}
+		if !changed {
+			return nil
+		}
+		driverID = pool.GetDriverID()
+		return models.SaveRidePoolPlan(tx, pool)
+	})
+	if err != nil {
+		log.Get().Errorf("更新拼车 %s 接送计划失败, order_id=%s, err=%v", rideOrder.GetPoolID(), orderID, err)
+		return ""
+	}
+	return driverID
+}
+
+// GetDriverPool 获取司机进行中的拼车及接送计划，没有拼车时返回nil
+func (s *RidePoolService) GetDriverPool(driverID string) (*protocol.RidePool, protocol.ErrorCode) {
+	user := models.GetUserByID(driverID)
+	if user == nil || !user.IsDriver() {
+		return nil, protocol.AccessDenied
+	}
+	pool := models.GetOpenRidePoolByDriver(driverID)
+	if pool == nil {
+		return nil, protocol.Success
+	}
+	return pool.Protocol(), protocol.Success
+}
+
+// CountActiveRideOrdersOutsidePool 统计司机其他在途订单数，同一拼车中的订单不计入
+func (s *RidePoolService) CountActiveRideOrdersOutsidePool(driverID, orderID string) int64 {
+	rideOrder := models.GetRideOrderByOrderID(orderID)
+	if rideOrder == nil || rideOrder.GetPoolID() == "" {
+		return GetOrderService().CountActiveRideOrdersByDriver(driverID, orderID)
+	}
+	var orderIDs []string
+	err := models.GetDB().Model(&models.Order{}).
+		Where("provider_id = ? AND status IN (?) AND order_id != ?", driverID, []string{protocol.StatusDriverArrived, protocol.StatusInProgress}, orderID).
+		Pluck("order_id", &orderIDs).Error
+	if err != nil {
+		return 0
+	}
+	poolOrderIDs := []string{}
+	if pool := models.GetRidePoolByID(rideOrder.GetPoolID()); pool != nil {
+		poolOrderIDs = pool.GetOrderIDs()
+	}
+	var count int64
+	for _, id := range orderIDs {
+		if !slices.Contains(poolOrderIDs, id) {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"testing"
+
+	"greenride/internal/config"
+	"greenride/internal/protocol"
+)
+
+func newTestPoolStops(orderID string, seats int, fromLng, toLng float64) (*protocol.PoolStop, *protocol.PoolStop) {
+	return newPoolStops(orderID, &protocol.OrderDetail{
+		PassengerCount:   seats,
+		PickupLatitude:   -1.95,
+		PickupLongitude:  fromLng,
+		DropoffLatitude:  -1.95,
+		DropoffLongitude: toLng,
+	})
+}
+
+func poolPlanKeys(plan []*protocol.PoolStop) string {
+	result := ""
+	for _, stop := range plan {
+		result += stop.OrderID + stop.Type[:1]
+	}
+	return result
+}
+
+func TestPlanPoolInsertion(t *testing.T) {
+	cfg := &config.PoolConfig{}
+	cfg.Validate()
+	start := routePoint{Lat: -1.95, Lng: 30.05}
+
+	// A 向东行驶约11公里，B 的行程完全在 A 的路线上
+	aPickup, aDropoff := newTestPoolStops("A", 1, 30.06, 30.16)
+	bPickup, bDropoff := newTestPoolStops("B", 1, 30.08, 30.14)
+	plan := []*protocol.PoolStop{aPickup, aDropoff}
+
+	inserted := matchPoolPlan(start, plan, bPickup, bDropoff, 3, cfg)
+	if inserted == nil {
+		t.Fatal("same-direction rider on the route should join the pool")
+	}
+	if got := poolPlanKeys(inserted.Plan); got != "ApBpBdAd" {
+		t.Errorf("plan = %s, want ApBpBdAd", got)
+	}
+	if got := poolPlanKeys(plan); got != "ApAd" {
+		t.Errorf("original plan modified: %s", got)
+	}
+
+	// 反方向的乘客不能加入
+	cPickup, cDropoff := newTestPoolStops("C", 1, 30.14, 30.07)
+	if matchPoolPlan(start, plan, cPickup, cDropoff, 3, cfg) != nil {
+		t.Error("opposite-direction rider should not join the pool")
+	}
+
+	// 座位不足
+	dPickup, dDropoff := newTestPoolStops("D", 2, 30.08, 30.14)
+	if matchPoolPlan(start, plan, dPickup, dDropoff, 2, cfg) != nil {
+		t.Error("rider should not join when seats are exceeded")
+	}
+
+	// 上车点离路线太远
+	ePickup, eDropoff := newTestPoolStops("E", 1, 30.30, 30.40)
+	if matchPoolPlan(start, plan, ePickup, eDropoff, 3, cfg) != nil {
+		t.Error("rider far from the route should not join the pool")
+	}
+}
+
+func TestPlanPoolInsertionKeepsDoneStops(t *testing.T) {
+	aPickup, aDropoff := newTestPoolStops("A", 2, 30.06, 30.16)
+	aPickup.Status = protocol.PoolStopStatusDone
+	bPickup, bDropoff := newTestPoolStops("B", 1, 30.08, 30.12)
+	plan := []*protocol.PoolStop{aPickup, aDropoff}
+	start := routePoint{Lat: -1.95, Lng: 30.07}
+
+	inserted := planPoolInsertion(start, plan, bPickup, bDropoff, 3, 1.5, 5)
+	if inserted == nil {
+		t.Fatal("rider should join while A is on board")
+	}
+	if got := poolPlanKeys(inserted.Plan); got != "ApBpBdAd" {
+		t.Errorf("plan = %s, want ApBpBdAd", got)
+	}
+	// A 已上车占用2座，剩余1座不够 C 的2人
+	cPickup, cDropoff := newTestPoolStops("C", 2, 30.08, 30.12)
+	if planPoolInsertion(start, plan, cPickup, cDropoff, 3, 1.5, 5) != nil {
+		t.Error("on-board seats should count against capacity")
+	}
+	// A 下车前座位不足，D 只能在 A 下车后上车
+	dPickup, dDropoff := newTestPoolStops("D", 2, 30.17, 30.20)
+	inserted = planPoolInsertion(start, plan, dPickup, dDropoff, 3, 1.5, 5)
+	if inserted == nil {
+		t.Fatal("rider should join after A drops off")
+	}
+	if got := poolPlanKeys(inserted.Plan); got != "ApAdDpDd" {
+		t.Errorf("plan = %s, want ApAdDpDd", got)
+	}
+}
+
+func TestBearingDiff(t *testing.T) {
+	cases := []struct{ a, b, want float64 }{
+		{10, 350, 20},
+		{90, 270, 180},
+		{45, 45, 0},
+		{300, 30, 90},
+	}
+	for _, c := range cases {
+		if got := bearingDiff(c.a, c.b); got != c.want {
+			t.Errorf("bearingDiff(%v, %v) = %v, want %v", c.a, c.b, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,518 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"greenride/internal/config"
+	"greenride/internal/log"
+	"greenride/internal/models"
+	"greenride/internal/protocol"
+	"greenride/internal/utils"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// RideStopService 行程途经点服务
+// 预估和下单时支持上下车点之间的多个途经点，按顺序分段调用Google路线计算距离和时长；
+// 行程中乘客可增删未到达的途经点并按新路线重新计价，司机到达途经点时记录订单历史。
+type RideStopService struct {
+}
+
+var (
+	rideStopInstance *RideStopService
+	rideStopOnce     sync.Once
+)
+
+func GetRideStopService() *RideStopService {
+	rideStopOnce.Do(func() {
+		SetupRideStopService()
+	})
+	return rideStopInstance
+}
+
+func SetupRideStopService() {
+	rideStopInstance = &RideStopService{}
+}
+
+// 可以增删途经点的订单状态
+var rideStopEditableStatuses = []string{
+	protocol.StatusAccepted,
+	protocol.StatusDriverComing,
+	protocol.StatusDriverArrived,
+	protocol.StatusInProgress,
+}
+
+// 重新计价时保留原快照中的优惠明细，其余费用明细按新路线计算
+var repriceDiscountCategories = []string{
+	protocol.PriceRuleCategoryDiscount,
+	protocol.PriceRuleCategoryPromotion,
+	protocol.PriceRuleCategoryUserPromotion,
+	protocol.PriceRuleCategorySpecialOffer,
+}
+
+// 无法调用Google路线时按城市路况约2.5分钟/公里估算时长
+const stopFallbackSecondsPerKm = 150
+
+// routePoint 路线上的一个坐标点
+type routePoint struct {
+	Lat float64
+	Lng float64
+}
+
+// routeLeg 相邻两点之间的一段路线
+type routeLeg struct {
+	DistanceKm      float64
+	DurationSeconds int
+	Accurate        bool // 是否来自Google路线，否则为直线距离估算
+}
+
+// stopRoutePoints 起点、途经点、终点依次组成的路线坐标
+func stopRoutePoints(origin routePoint, stops []*protocol.RideStop, destination routePoint) []routePoint {
+	points := []routePoint{origin}
+	for _, stop := range stops {
+		points = append(points, routePoint{Lat: stop.Latitude, Lng: stop.Longitude})
+	}
+	return append(points, destination)
+}
+
+// sumRouteLegs 汇总分段路线的距离（公里）、时长（秒），以及是否全部来自Google路线
+func sumRouteLegs(legs []*routeLeg) (float64, int, bool) {
+	distanceKm, durationSeconds, accurate := 0.0, 0, len(legs) > 0
+	for _, leg := range legs {
+		distanceKm += leg.DistanceKm
+		durationSeconds += leg.DurationSeconds
+		accurate = accurate && leg.Accurate
+	}
+	return distanceKm, durationSeconds, accurate
+}
+
+// fallbackRouteLeg 按直线距离估算一段路线
+func fallbackRouteLeg(from, to routePoint) *routeLeg {
+	distanceKm := utils.CalculateDistanceHaversine(from.Lat, from.Lng, to.Lat, to.Lng)
+	return &routeLeg{
+		DistanceKm:      distanceKm,
+		DurationSeconds: int(math.Round(distanceKm * stopFallbackSecondsPerKm)),
+	}
+}
+
+// stopsMatch 比较两组途经点的顺序和坐标是否一致
+func stopsMatch(a, b []*protocol.RideStop) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	const epsilon = 1e-6
+	for i := range a {
+		if math.Abs(a[i].Latitude-b[i].Latitude) > epsilon || math.Abs(a[i].Longitude-b[i].Longitude) > epsilon {
+			return false
+		}
+	}
+	return true
+}
+
+// insertStop 将途经点插入到position（从1开始）位置，0或超出范围时追加到末尾；不能插入到已到达的途经点之前
+func insertStop(stops []*protocol.RideStop, stop *protocol.RideStop, position int) []*protocol.RideStop {
+	index := len(stops)
+	if position > 0 && position <= len(stops) {
+		index = position - 1
+	}
+	for i, item := range stops {
+		if item.Status == protocol.RideStopStatusArrived && index <= i {
+			index = i + 1
+		}
+	}
+	return slices.Insert(slices.Clone(stops), index, stop)
+}
+
+// ValidateStops 校验途经点数量和坐标
+func (s *RideStopService) ValidateStops(stops []*protocol.RideStop) protocol.ErrorCode {
+	if len(stops) > config.Get().Order.RideOrder.MaxStops {
+		return protocol.RideStopsExceeded
+	}
+	for _, stop := range stops {
+		if stop == nil || stop.Latitude == 0 || stop.Longitude == 0 {
+			return protocol.InvalidParams
+		}
+	}
+	return protocol.Success
+}
+
+// CalculateRouteLegs 按坐标顺序分段计算路线，Google计算失败的路段按直线距离估算
+func (s *RideStopService) CalculateRouteLegs(points []routePoint, avoidTolls bool) []*routeLeg {
+	if len(points) < 2 {
+		return nil
+	}
+	legs := make([]*routeLeg, len(points)-1)
+
+	var responses []*protocol.RouteResponse
+	if googleService := GetGoogleService(); googleService != nil {
+		requests := make([]*protocol.RouteRequest, len(legs))
+		for i := range legs {
+			requests[i] = &protocol.RouteRequest{
+				Origin:      fmt.Sprintf("%f,%f", points[i].Lat, points[i].Lng),
+				Destination: fmt.Sprintf("%f,%f", points[i+1].Lat, points[i+1].Lng),
+				Mode:        "driving",
+				Language:    "en",
+				Units:       "metric",
+				Region:      "RW",
+			}
+			if avoidTolls {
+				requests[i].Avoid = "tolls"
+			}
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 6*time.Second)
+		responses, _ = googleService.CalculateMultipleRoutes(ctx, requests)
+		cancel()
+	}
+
+	for i := range legs {
+		if i < len(responses) {
+			resp := responses[i]
+			if resp != nil && resp.Success && resp.Distance != nil && resp.Duration != nil {
+				legs[i] = &routeLeg{
+					DistanceKm:      float64(resp.Distance.Value) / 1000.0,
+					DurationSeconds: resp.Duration.Value,
+					Accurate:        true,
+				}
+				continue
+			}
+		}
+		legs[i] = fallbackRouteLeg(points[i], points[i+1])
+	}
+	return legs
+}
+
+// EnrichRoute 有途经点时按上车点→途经点→下车点分段计算预估距离和时长
+func (s *RideStopService) EnrichRoute(req *protocol.EstimateRequest) {
+	points := stopRoutePoints(
+		routePoint{Lat: req.PickupLatitude, Lng: req.PickupLongitude},
+		req.Stops,
+		routePoint{Lat: req.DropoffLatitude, Lng: req.DropoffLongitude},
+	)
+	legs := s.CalculateRouteLegs(points, req.VehicleLevel == "economy")
+	distanceKm, durationSeconds, accurate := sumRouteLegs(legs)
+	req.EstimatedDistance = distanceKm
+	req.EstimatedDuration = max(durationSeconds/60, 1)
+	if !accurate {
+		log.Get().Infof("多途经点路线部分使用直线距离估算, stops=%d, distance=%.2f km, duration=%d min", len(req.Stops), req.EstimatedDistance, req.EstimatedDuration)
+	}
+}
+
+// GetSnapshotStops 从价格快照中读取预估时的途经点
+func (s *RideStopService) GetSnapshotStops(snapshot *models.PriceSnapshot) []*protocol.RideStop {
+	value, ok := snapshot.GetMetadata()["stops"]
+	if !ok || value == nil {
+		return nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	var stops []*protocol.RideStop
+	if err := json.Unmarshal(data, &stops); err != nil {
+		log.Get().Warnf("价格快照途经点解析失败, snapshot_id=%s, err=%v", snapshot.SnapshotID, err)
+		return nil
+	}
+	return stops
+}
+
+// GetOrderStops 获取订单的途经点
+func (s *RideStopService) GetOrderStops(orderID string) []*protocol.RideStop {
+	var stops []*protocol.RideStop
+	for _, stop := range models.GetRideOrderStops(orderID) {
+		stops = append(stops, stop.Protocol())
+	}
+	return stops
+}
+
+// AddStop 乘客在行程中新增途经点并重新计价
+func (s *RideStopService) AddStop(req *protocol.OrderStopAddRequest) (*protocol.Order, protocol.ErrorCode) {
+	order, rideOrder, errCode := s.getEditableOrder(req.OrderID, req.UserID)
+	if errCode != protocol.Success {
+		return nil, errCode
+	}
+	if rideOrder.IsPool() {
+		return nil, protocol.PoolStopsNotSupported
+	}
+	existing := models.GetRideOrderStops(order.OrderID)
+	stops := make([]*protocol.RideStop, 0, len(existing)+1)
+	for _, item := range existing {
+		stops = append(stops, item.Protocol())
+	}
+	if len(stops) >= config.Get().Order.RideOrder.MaxStops {
+		return nil, protocol.RideStopsExceeded
+	}
+	if errCode := s.ValidateStops([]*protocol.RideStop{req.Stop}); errCode != protocol.Success {
+		return nil, errCode
+	}
+
+	stop := &protocol.RideStop{
+		Latitude:  req.Stop.Latitude,
+		Longitude: req.Stop.Longitude,
+		Address:   req.Stop.Address,
+		Landmark:  req.Stop.Landmark,
+		Status:    protocol.RideStopStatusPending,
+	}
+	stops = insertStop(stops, stop, req.Position)
+	seq := slices.Index(stops, stop) + 1
+
+	repriced, errCode := s.reprice(order, rideOrder, stops)
+	if errCode != protocol.Success {
+		return nil, errCode
+	}
+	err := models.GetDB().Transaction(func(tx *gorm.DB) error {
+		created := models.NewRideOrderStop(order.OrderID, seq, stop)
+		if err := tx.Create(created).Error; err != nil {
+			return err
+		}
+		if err := models.ResequenceRideOrderStops(tx, slices.Insert(existing, seq-1, created)); err != nil {
+			return err
+		}
+		return s.applyReprice(tx, order, repriced)
+	})
+	if err != nil {
+		log.Get().Errorf("新增途经点失败, order_id=%s, err=%v", order.OrderID, err)
+		return nil, protocol.DatabaseError
+	}
+
+	reason := fmt.Sprintf("Stop %d added: %s", seq, stop.Address)
+	go s.recordStopHistory(protocol.ActionStopAdded, order, req.UserID, protocol.UserTypePassenger, reason)
+	return GetOrderService().GetOrderInfoByID(order.OrderID), protocol.Success
+}
+
+// RemoveStop 乘客删除未到达的途经点并重新计价
+func (s *RideStopService) RemoveStop(req *protocol.OrderStopActionRequest) (*protocol.Order, protocol.ErrorCode) {
+	order, rideOrder, errCode := s.getEditableOrder(req.OrderID, req.UserID)
+	if errCode != protocol.Success {
+		return nil, errCode
+	}
+	target := models.GetRideOrderStop(order.OrderID, req.StopID)
+	if target == nil {
+		return nil, protocol.RideStopNotFound
+	}
+	if target.IsArrived() {
+		return nil, protocol.RideStopAlreadyArrived
+	}
+
+	remaining := slices.DeleteFunc(models.GetRideOrderStops(order.OrderID), func(item *models.RideOrderStop) bool {
+		return item.StopID == target.StopID
+	})
+	stops := make([]*protocol.RideStop, 0, len(remaining))
+	for _, item := range remaining {
+		stops = append(stops, item.Protocol())
+	}
+
+	repriced, errCode := s.reprice(order, rideOrder, stops)
+	if errCode != protocol.Success {
+		return nil, errCode
+	}
+	removed := false
+	err := models.GetDB().Transaction(func(tx *gorm.DB) error {
+		var err error
+		if removed, err = models.RemoveRideOrderStop(tx, target.StopID); err != nil || !removed {
+			return err
+		}
+		if err := models.ResequenceRideOrderStops(tx, remaining); err != nil {
+			return err
+		}
+		return s.applyReprice(tx, order, repriced)
+	})
+	if err != nil {
+		log.Get().Errorf("删除途经点失败, order_id=%s, stop_id=%s, err=%v", order.OrderID, target.StopID, err)
+		return nil, protocol.DatabaseError
+	}
+	if !removed {
+		// 并发情况下司机已到达该途经点
+		return nil, protocol.RideStopAlreadyArrived
+	}
+
+	reason := fmt.Sprintf("Stop %d removed: %s", target.GetSeq(), target.GetAddress())
+	go s.recordStopHistory(protocol.ActionStopRemoved, order, req.UserID, protocol.UserTypePassenger, reason)
+	return GetOrderService().GetOrderInfoByID(order.OrderID), protocol.Success
+}
+
+// ArrivedStop 司机到达途经点
+func (s *RideStopService) ArrivedStop(req *protocol.OrderStopActionRequest) protocol.ErrorCode {
+	order := models.GetOrderByID(req.OrderID)
+	if order == nil || order.GetOrderType() != protocol.RideOrder || order.GetProviderID() != req.UserID {
+		return protocol.OrderNotFound
+	}
+	if order.GetStatus() != protocol.StatusInProgress {
+		return protocol.RideNotStarted
+	}
+	stop := models.GetRideOrderStop(order.OrderID, req.StopID)
+	if stop == nil {
+		return protocol.RideStopNotFound
+	}
+	if stop.IsArrived() {
+		return protocol.Success
+	}
+	updated, err := models.MarkRideOrderStopArrived(stop.StopID)
+	if err != nil {
+		log.Get().Errorf("标记到达途经点失败, order_id=%s, stop_id=%s, err=%v", order.OrderID, stop.StopID, err)
+		return protocol.DatabaseError
+	}
+	if updated {
+		reason := fmt.Sprintf("Driver arrived at stop %d: %s", stop.GetSeq(), stop.GetAddress())
+		go s.recordStopHistory(protocol.ActionStopArrived, order, req.UserID, protocol.UserTypeDriver, reason)
+	}
+	return protocol.Success
+}
+
+// FillTripETA 行程中有未到达的途经点时，按司机→各途经点→下车点分段计算ETA
+func (s *RideStopService) FillTripETA(resp *protocol.OrderETAResponse, driver routePoint, dropoff routePoint, pending []*protocol.RideStop) {
+	legs := s.CalculateRouteLegs(stopRoutePoints(driver, pending, dropoff), false)
+	if len(legs) == 0 {
+		return
+	}
+	resp.NextStop = pending[0]
+	resp.DistanceKm = legs[0].DistanceKm
+	resp.ETAMinutes = max((legs[0].DurationSeconds+59)/60, 1)
+
+	distanceKm, durationSeconds, accurate := sumRouteLegs(legs)
+	resp.DropoffDistanceKm = distanceKm
+	resp.DropoffETAMinutes = max((durationSeconds+59)/60, 1)
+	if accurate {
+		resp.Mode = "accurate"
+	}
+}
+
+// getEditableOrder 获取乘客本人可增删途经点的网约车订单
+func (s *RideStopService) getEditableOrder(orderID, userID string) (*models.Order, *models.RideOrder, protocol.ErrorCode) {
+	order := models.GetOrderByID(orderID)
+	if order == nil || order.GetOrderType() != protocol.RideOrder || order.GetUserID() != userID {
+		return nil, nil, protocol.OrderNotFound
+	}
+	if !slices.Contains(rideStopEditableStatuses, order.GetStatus()) {
+		return nil, nil, protocol.InvalidRideStatus
+	}
+	rideOrder := models.GetRideOrderByOrderID(order.OrderID)
+	if rideOrder == nil {
+		return nil, nil, protocol.OrderNotFound
+	}
+	return order, rideOrder, protocol.Success
+}
+
+// stopReprice 途经点变更后的重新计价结果
+type stopReprice struct {
+	SnapshotID string
+	Snapshot   *models.PriceSnapshotValues
+	Delta      decimal.Decimal // 优惠前价格变化，订单各金额按此调整
+}
+
+// reprice 按新的途经点重新计算路线和价格，优惠金额保持下单时不变
+func (s *RideStopService) reprice(order *models.Order, rideOrder *models.RideOrder, stops []*protocol.RideStop) (*stopReprice, protocol.ErrorCode) {
+	priceID, _ := order.GetMetadata()["price_id"].(string)
+	snapshot := models.GetPriceSnapshotByID(priceID)
+	if snapshot == nil {
+		return nil, protocol.PriceIDNotFound
+	}
+	meta := snapshot.GetMetadata()
+	req := &protocol.EstimateRequest{
+		UserID:           order.GetUserID(),
+		VehicleCategory:  rideOrder.GetVehicleCategory(),
+		VehicleLevel:     rideOrder.GetVehicleLevel(),
+		OrderType:        order.GetOrderType(),
+		UserCategory:     meta.Get("user_category"),
+		PassengerCount:   rideOrder.GetPassengerCount(),
+		PickupLatitude:   rideOrder.GetPickupLatitude(),
+		PickupLongitude:  rideOrder.GetPickupLongitude(),
+		PickupAddress:    rideOrder.GetPickupAddress(),
+		DropoffLatitude:  rideOrder.GetDropoffLatitude(),
+		DropoffLongitude: rideOrder.GetDropoffLongitude(),
+		DropoffAddress:   rideOrder.GetDropoffAddress(),
+		Stops:            stops,
+		Currency:         order.GetCurrency(),
+		ScheduledAt:      snapshot.GetScheduledAt(),
+		ServiceArea:      meta.Get("service_area"),
+		PaymentMethod:    meta.Get("payment_method"),
+	}
+	if len(stops) > 0 {
+		s.EnrichRoute(req)
+	} else {
+		GetOrderService().EnrichRouteByGoogleMap(req)
+	}
+	fresh := GetPriceRuleService().EstimatePrice(req)
+	if fresh == nil {
+		return nil, protocol.SystemError
+	}
+	values, delta := repriceSnapshot(snapshot, fresh, stops)
+	return &stopReprice{SnapshotID: snapshot.SnapshotID, Snapshot: values, Delta: delta}, protocol.Success
+}
+
+// repriceSnapshot 用新路线的费用替换原快照的费用项，保留原快照的优惠明细，返回更新值和优惠前价格变化
+func repriceSnapshot(old, fresh *models.PriceSnapshot, stops []*protocol.RideStop) (*models.PriceSnapshotValues, decimal.Decimal) {
+	delta := fresh.GetOriginalFare().Sub(old.GetOriginalFare())
+	discounted := old.GetDiscountedFare().Add(delta)
+	if discounted.LessThan(decimal.Zero) {
+		discounted = decimal.Zero
+	}
+
+	breakdowns := []*protocol.PriceRuleResult{}
+	for _, item := range fresh.GetBreakdowns() {
+		if !slices.Contains(repriceDiscountCategories, item.Category) {
+			breakdowns = append(breakdowns, item)
+		}
+	}
+	for _, item := range old.GetBreakdowns() {
+		if slices.Contains(repriceDiscountCategories, item.Category) {
+			breakdowns = append(breakdowns, item)
+		}
+	}
+
+	metadata := protocol.MapData{}
+	metadata.Copy(old.GetMetadata())
+	metadata.Set("stops", stops)
+
+	values := &models.PriceSnapshotValues{}
+	values.SetDistance(fresh.GetDistance()).
+		SetDuration(fresh.GetDuration()).
+		SetBaseFare(fresh.GetBaseFare()).
+		SetDistanceFare(fresh.GetDistanceFare()).
+		SetTimeFare(fresh.GetTimeFare()).
+		SetSurgeFare(fresh.GetSurgeFare()).
+		SetServiceFee(fresh.GetServiceFee()).
+		SetOriginalFare(fresh.GetOriginalFare()).
+		SetDiscountedFare(discounted).
+		SetBreakdowns(breakdowns).
+		SetMetadata(metadata)
+	return values, delta
+}
+
+// applyReprice 在事务中更新价格快照、订单金额和行程预估距离时长
+func (s *RideStopService) applyReprice(tx *gorm.DB, order *models.Order, repriced *stopReprice) error {
+	if err := models.UpdatePriceSnapshot(tx, repriced.SnapshotID, repriced.Snapshot); err != nil {
+		return err
+	}
+	rideValues := models.RideOrderValues{}
+	rideValues.SetEstimatedDistance(repriced.Snapshot.GetDistance()).
+		SetEstimatedDuration(repriced.Snapshot.GetDuration())
+	if err := tx.Model(&models.RideOrder{}).Where("order_id = ?", order.OrderID).UpdateColumns(rideValues).Error; err != nil {
+		return err
+	}
+	if repriced.Delta.IsZero() {
+		return nil
+	}
+	nonNegative := func(amount decimal.Decimal) decimal.Decimal {
+		return decimal.Max(amount.Add(repriced.Delta), decimal.Zero)
+	}
+	orderValues := models.OrderValues{}
+	orderValues.SetOriginalAmount(nonNegative(order.GetOriginalAmount())).
+		SetDiscountedAmount(nonNegative(order.GetDiscountedAmount())).
+		SetPaymentAmount(nonNegative(order.GetPaymentAmount()))
+	return models.UpdateOrder(tx, order, &orderValues)
+}
+
+// recordStopHistory 记录途经点相关的订单历史
+func (s *RideStopService) recordStopHistory(actionType string, order *models.Order, operatorID, operatorType, reason string) {
+	updated := models.GetOrderByID(order.OrderID)
+	if err := GetOrderHistoryService().RecordHistory(actionType, order, updated, operatorID, operatorType, reason); err != nil {
+		log.Get().Warnf("记录途经点订单历史失败, order_id=%s, action=%s, err=%v", order.OrderID, actionType, err)
+	}
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"testing"
+
+	"greenride/internal/models"
+	"greenride/internal/protocol"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestInsertStop(t *testing.T) {
+	a := &protocol.RideStop{Address: "A", Status: protocol.RideStopStatusArrived}
+	b := &protocol.RideStop{Address: "B", Status: protocol.RideStopStatusPending}
+	c := &protocol.RideStop{Address: "C", Status: protocol.RideStopStatusPending}
+	stops := []*protocol.RideStop{a, b}
+
+	addresses := func(list []*protocol.RideStop) string {
+		result := ""
+		for _, stop := range list {
+			result += stop.Address
+		}
+		return result
+	}
+
+	if got := addresses(insertStop(stops, c, 0)); got != "ABC" {
+		t.Errorf("append = %s, want ABC", got)
+	}
+	if got := addresses(insertStop(stops, c, 2)); got != "ACB" {
+		t.Errorf("insert at 2 = %s, want ACB", got)
+	}
+	if got := addresses(insertStop(stops, c, 1)); got != "ACB" {
+		t.Errorf("insert before arrived stop = %s, want ACB", got)
+	}
+	if got := addresses(stops); got != "AB" {
+		t.Errorf("original stops modified: %s", got)
+	}
+}
+
+func TestStopsMatchAndRouteLegs(t *testing.T) {
+	a := []*protocol.RideStop{{Latitude: -1.95, Longitude: 30.06}, {Latitude: -1.96, Longitude: 30.07}}
+	b := []*protocol.RideStop{{Latitude: -1.95, Longitude: 30.06, Address: "x"}, {Latitude: -1.96, Longitude: 30.07}}
+	if !stopsMatch(a, b) {
+		t.Error("stops with same coordinates should match")
+	}
+	if stopsMatch(a, b[:1]) || stopsMatch(a, []*protocol.RideStop{a[1], a[0]}) {
+		t.Error("stops with different count or order should not match")
+	}
+
+	points := stopRoutePoints(routePoint{Lat: -1.94, Lng: 30.05}, a, routePoint{Lat: -1.97, Lng: 30.08})
+	if len(points) != 4 || points[1].Lat != -1.95 || points[3].Lng != 30.08 {
+		t.Fatalf("unexpected route points: %+v", points)
+	}
+
+	legs := []*routeLeg{
+		{DistanceKm: 1.5, DurationSeconds: 240, Accurate: true},
+		fallbackRouteLeg(points[1], points[2]),
+	}
+	distanceKm, durationSeconds, accurate := sumRouteLegs(legs)
+	if accurate {
+		t.Error("legs with a fallback estimate should not be accurate")
+	}
+	if distanceKm <= 1.5 || durationSeconds <= 240 {
+		t.Errorf("sum = %.2f km / %d s, want more than the first leg", distanceKm, durationSeconds)
+	}
+}
+
+func TestRepriceSnapshot(t *testing.T) {
+	old := models.NewPriceSnapshot("U1")
+	old.SetOriginalFare(decimal.NewFromInt(3000)).
+		SetDiscountedFare(decimal.NewFromInt(2500)).
+		SetBreakdowns([]*protocol.PriceRuleResult{
+			{Category: protocol.PriceRuleCategoryBasePricing, Amount: 1000},
+			{Category: protocol.PriceRuleCategoryDistanceFare, Amount: 2000},
+			{Category: protocol.PriceRuleCategoryUserPromotion, Amount: -500},
+		})
+	old.Metadata.Set("price_id_source", "estimate")
+
+	fresh := models.NewPriceSnapshot("U1")
+	fresh.SetOriginalFare(decimal.NewFromInt(4200)).
+		SetDistance(9.5).
+		SetBreakdowns([]*protocol.PriceRuleResult{
+			{Category: protocol.PriceRuleCategoryBasePricing, Amount: 1000},
+			{Category: protocol.PriceRuleCategoryDistanceFare, Amount: 3200},
+			{Category: protocol.PriceRuleCategoryDiscount, Amount: -100},
+		})
+
+	stops := []*protocol.RideStop{{Latitude: -1.95, Longitude: 30.06}}
+	values, delta := repriceSnapshot(old, fresh, stops)
+	if !delta.Equal(decimal.NewFromInt(1200)) {
+		t.Errorf("delta = %s, want 1200", delta)
+	}
+	if !values.GetDiscountedFare().Equal(decimal.NewFromInt(3700)) {
+		t.Errorf("discounted fare = %s, want 3700", values.GetDiscountedFare())
+	}
+	if values.GetDistance() != 9.5 {
+		t.Errorf("distance = %v, want 9.5", values.GetDistance())
+	}
+
+	var categories []string
+	for _, item := range values.GetBreakdowns() {
+		categories = append(categories, item.Category)
+	}
+	want := []string{protocol.PriceRuleCategoryBasePricing, protocol.PriceRuleCategoryDistanceFare, protocol.PriceRuleCategoryUserPromotion}
+	if len(categories) != len(want) {
+		t.Fatalf("breakdown categories = %v, want %v", categories, want)
+	}
+	for i := range want {
+		if categories[i] != want[i] {
+			t.Errorf("breakdown categories = %v, want %v", categories, want)
+			break
+		}
+	}
+	if values.GetMetadata().Get("price_id_source") != "estimate" || values.GetMetadata()["stops"] == nil {
+		t.Error("metadata should keep old values and record the new stops")
+	}
+}
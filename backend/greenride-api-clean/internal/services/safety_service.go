@@ -0,0 +1,429 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"greenride/internal/config"
+	"greenride/internal/log"
+	"greenride/internal/models"
+	"greenride/internal/protocol"
+	"greenride/internal/utils"
+)
+
+// SafetyService 行程安全：紧急求助、行程分享链接和紧急联系人
+type SafetyService struct{}
+
+var (
+	safetyServiceInstance *SafetyService
+	safetyServiceOnce     sync.Once
+)
+
+// GetSafetyService 获取行程安全服务实例
+func GetSafetyService() *SafetyService {
+	safetyServiceOnce.Do(func() {
+		SetupSafetyService()
+	})
+	return safetyServiceInstance
+}
+
+// SetupSafetyService 初始化行程安全服务
+func SetupSafetyService() {
+	safetyServiceInstance = &SafetyService{}
+}
+
+// tripShareLiveStatuses 司机已接单到行程结束前，分享页返回司机实时位置
+var tripShareLiveStatuses = []string{
+	protocol.StatusAccepted,
+	protocol.StatusDriverComing,
+	protocol.StatusDriverArrived,
+	protocol.StatusInProgress,
+}
+
+var contactPhonePattern = regexp.MustCompile(`^\+?[0-9]{8,15}$`)
+
+// tripShareLive 订单状态是否处于可实时分享的行程中
+func tripShareLive(status string) bool {
+	return slices.Contains(tripShareLiveStatuses, status)
+}
+
+// tripShareURL 分享链接地址
+func tripShareURL(baseURL, token string) string {
+	return strings.TrimRight(baseURL, "/") + "/" + token
+}
+
+// mapLink 求助位置的地图链接
+func mapLink(latitude, longitude float64) string {
+	return fmt.Sprintf("https://maps.google.com/?q=%.6f,%.6f", latitude, longitude)
+}
+
+// normalizeContactPhone 去掉号码中的空格和分隔符，格式不正确时返回空
+func normalizeContactPhone(phone string) string {
+	phone = strings.NewReplacer(" ", "", "-", "", "(", "", ")", "").Replace(strings.TrimSpace(phone))
+	if !contactPhonePattern.MatchString(phone) {
+		return ""
+	}
+	return phone
+}
+
+// TriggerSOS 发起紧急求助：记录求助位置和订单快照，行程进行中时生成分享链接，并通知管理员、响应人员和紧急联系人
+func (s *SafetyService) TriggerSOS(req *protocol.SOSRequest) (*protocol.SOSResult, protocol.ErrorCode) {
+	user := models.GetUserByID(req.UserID)
+	if user == nil {
+		return nil, protocol.UserNotFound
+	}
+	order, errCode := s.resolveOrder(user, req.OrderID)
+	if errCode != protocol.Success {
+		return nil, errCode
+	}
+
+	incident := models.NewSafetyIncident(user.UserID, user.GetUserType())
+	latitude, longitude := req.Latitude, req.Longitude
+	if latitude == 0 && longitude == 0 {
+		latitude, longitude = user.GetLatitude(), user.GetLongitude()
+	}
+	incident.SetLocation(latitude, longitude)
+	if message := strings.TrimSpace(req.Message); message != "" {
+		incident.SetMessage(message)
+	}
+
+	result := &protocol.SOSResult{}
+	var share *models.TripShare
+	if order != nil {
+		incident.SetOrderID(order.OrderID)
+		snapshot := protocol.MapData{}
+		snapshot.CopyObject(GetOrderService().GetOrderInfo(order))
+		incident.OrderSnapshot = snapshot
+		if tripShareLive(order.GetStatus()) {
+			if share = s.createShare(user.UserID, order.OrderID, protocol.TripShareSourceSOS); share != nil {
+				incident.SetShareID(share.ShareID)
+				result.Share = share.Protocol(tripShareURL(config.GetSafetyConfig().ShareBaseURL, share.GetToken()))
+			}
+		}
+	}
+	if err := models.CreateSafetyIncident(incident); err != nil {
+		log.Get().Errorf("保存用户 %s 紧急求助失败: %v", user.UserID, err)
+		return nil, protocol.DatabaseError
+	}
+	log.Get().Warnf("用户 %s(%s) 发起紧急求助 %s，订单=%s，位置=%.6f,%.6f", user.UserID, user.GetUserType(), incident.IncidentID, incident.GetOrderID(), latitude, longitude)
+
+	go s.dispatchSOSAlerts(incident, user, share)
+	result.Incident = incident.Protocol(false)
+	return result, protocol.Success
+}
+
+// resolveOrder 获取求助关联的订单：指定订单须是本人作为乘客或司机的订单，未指定时取当前进行中的订单
+func (s *SafetyService) resolveOrder(user *models.User, orderID string) (*models.Order, protocol.ErrorCode) {
+	if orderID != "" {
+		order := models.GetOrderByID(orderID)
+		if order == nil || (order.GetUserID() != user.UserID && order.GetProviderID() != user.UserID) {
+			return nil, protocol.OrderNotFound
+		}
+		return order, protocol.Success
+	}
+	if currentOrderID := user.GetCurrentOrderID(); currentOrderID != "" {
+		if order := models.GetOrderByID(currentOrderID); order != nil {
+			return order, protocol.Success
+		}
+	}
+	if !user.IsDriver() {
+		if order, err := GetOrderService().GetActiveRideOrderByUser(user.UserID); err == nil {
+			return order, protocol.Success
+		}
+	}
+	return nil, protocol.Success
+}
+
+// dispatchSOSAlerts 通知管理员（站内通知）、短信通知响应人员和紧急联系人
+func (s *SafetyService) dispatchSOSAlerts(incident *models.SafetyIncident, user *models.User, share *models.TripShare) {
+	cfg := config.GetSafetyConfig()
+	orderID := incident.GetOrderID()
+	if orderID == "" {
+		orderID = "-"
+	}
+	location := mapLink(incident.GetLatitude(), incident.GetLongitude())
+	shareURL := location
+	if share != nil {
+		shareURL = tripShareURL(cfg.ShareBaseURL, share.GetToken())
+	}
+
+	title := fmt.Sprintf("SOS: %s (%s)", user.GetFullName(), user.GetUserType())
+	content := fmt.Sprintf("%s (%s) triggered an SOS. Order: %s. Location: %s", user.GetFullName(), user.GetPhone(), orderID, location)
+	if message := incident.GetMessage(); message != "" {
+		content += ". Message: " + message
+	}
+	if errCode := GetAdminNotificationService().AlertAdmins(protocol.NotificationTypeSafetySOS, title, content, models.NotificationPriorityUrgent, "safety_incident", incident.IncidentID); errCode != protocol.Success {
+		log.Get().Errorf("紧急求助 %s 通知管理员失败: %s", incident.IncidentID, errCode)
+	}
+
+	params := map[string]any{
+		"user_name":   user.GetFullName(),
+		"user_phone":  user.GetPhone(),
+		"order_id":    orderID,
+		"map_url":     location,
+		"share_url":   shareURL,
+		"incident_id": incident.IncidentID,
+	}
+	for _, phone := range cfg.ResponderPhones {
+		s.sendSMS(protocol.MsgTypeSafetySOSAlert, phone, protocol.LangEnglish, params)
+	}
+	contacts, err := models.ListTrustedContacts(user.UserID)
+	if err != nil {
+		log.Get().Errorf("查询用户 %s 紧急联系人失败: %v", user.UserID, err)
+		return
+	}
+	for _, contact := range contacts {
+		s.sendSMS(protocol.MsgTypeSafetySOSAlert, contact.GetPhone(), getUserLanguage(user), params)
+	}
+}
+
+// sendSMS 发送安全相关短信，每个收件人使用独立的参数副本
+func (s *SafetyService) sendSMS(msgType, phone, language string, params map[string]any) {
+	msgParams := make(map[string]any, len(params)+1)
+	for k, v := range params {
+		msgParams[k] = v
+	}
+	msgParams["to"] = phone
+	msg := &Message{
+		Type:     msgType,
+		Channels: []string{protocol.MsgChannelSms},
+		Language: language,
+		To:       phone,
+		Params:   msgParams,
+	}
+	if err := GetMessageService().SendMessage(msg); err != nil {
+		log.Get().Errorf("安全短信 %s 发送到 %s 失败: %v", msgType, utils.MaskPhone(phone), err)
+	}
+}
+
+// createShare 生成行程分享链接
+func (s *SafetyService) createShare(userID, orderID, source string) *models.TripShare {
+	ttl := int64(config.GetSafetyConfig().ShareTTLMinutes) * 60 * 1000
+	share := models.NewTripShare(userID, orderID, source, utils.TimeNowMilli()+ttl)
+	if err := models.CreateTripShare(share); err != nil {
+		log.Get().Errorf("生成订单 %s 行程分享失败: %v", orderID, err)
+		return nil
+	}
+	return share
+}
+
+// CreateTripShare 为进行中的行程生成限时分享链接，可选短信发送给紧急联系人
+func (s *SafetyService) CreateTripShare(req *protocol.TripShareRequest) (*protocol.TripShare, protocol.ErrorCode) {
+	user := models.GetUserByID(req.UserID)
+	if user == nil {
+		return nil, protocol.UserNotFound
+	}
+	order, errCode := s.resolveOrder(user, req.OrderID)
+	if errCode != protocol.Success {
+		return nil, errCode
+	}
+	if !tripShareLive(order.GetStatus()) {
+		return nil, protocol.InvalidOrderStatus
+	}
+	share := s.createShare(user.UserID, order.OrderID, protocol.TripShareSourceManual)
+	if share == nil {
+		return nil, protocol.DatabaseError
+	}
+	url := tripShareURL(config.GetSafetyConfig().ShareBaseURL, share.GetToken())
+	if req.NotifyContacts {
+		go s.sendShareToContacts(user, url)
+	}
+	return share.Protocol(url), protocol.Success
+}
+
+// sendShareToContacts 短信发送行程分享链接给紧急联系人
+func (s *SafetyService) sendShareToContacts(user *models.User, url string) {
+	contacts, err := models.ListTrustedContacts(user.UserID)
+	if err != nil {
+		log.Get().Errorf("查询用户 %s 紧急联系人失败: %v", user.UserID, err)
+		return
+	}
+	params := map[string]any{
+		"user_name": user.GetFullName(),
+		"share_url": url,
+	}
+	for _, contact := range contacts {
+		s.sendSMS(protocol.MsgTypeTripShared, contact.GetPhone(), getUserLanguage(user), params)
+	}
+}
+
+// RevokeTripShare 撤销分享链接
+func (s *SafetyService) RevokeTripShare(req *protocol.TripShareIDRequest) protocol.ErrorCode {
+	share := models.GetTripShareByID(req.ShareID)
+	if share == nil || share.GetUserID() != req.UserID {
+		return protocol.TripShareNotFound
+	}
+	if err := models.RevokeTripShare(share.ShareID, utils.TimeNowMilli()); err != nil {
+		log.Get().Errorf("撤销行程分享 %s 失败: %v", share.ShareID, err)
+		return protocol.DatabaseError
+	}
+	return protocol.Success
+}
+
+// GetSharedTrip 公开分享页数据：只返回姓名、车辆和位置，不返回手机号，行程结束后不再返回司机位置
+func (s *SafetyService) GetSharedTrip(token string) (*protocol.SharedTrip, protocol.ErrorCode) {
+	share := models.GetTripShareByToken(token)
+	if share == nil || !share.IsValid(utils.TimeNowMilli()) {
+		return nil, protocol.TripShareNotFound
+	}
+	order := models.GetOrderByID(share.GetOrderID())
+	if order == nil {
+		return nil, protocol.TripShareNotFound
+	}
+
+	trip := &protocol.SharedTrip{
+		OrderID:   order.OrderID,
+		Status:    order.GetStatus(),
+		Live:      tripShareLive(order.GetStatus()),
+		StartedAt: order.GetStartedAt(),
+		EndedAt:   order.GetEndedAt(),
+		ExpiresAt: share.GetExpiresAt(),
+	}
+	if passenger := models.GetUserByID(order.GetUserID()); passenger != nil {
+		trip.PassengerName = passenger.GetDisplayName()
+	}
+	if detail := models.GetOrderDetail(order.OrderID, order.GetOrderType()); detail != nil {
+		trip.PickupAddress = detail.GetPickupAddress()
+		trip.PickupLatitude = detail.GetPickupLatitude()
+		trip.PickupLongitude = detail.GetPickupLongitude()
+		trip.DropoffAddress = detail.GetDropoffAddress()
+		trip.DropoffLatitude = detail.GetDropoffLatitude()
+		trip.DropoffLongitude = detail.GetDropoffLongitude()
+		if vehicleID := detail.GetVehicleID(); vehicleID != "" {
+			if vehicle := models.GetVehicleByID(vehicleID); vehicle != nil {
+				trip.VehicleBrand = vehicle.GetBrand()
+				trip.VehicleModel = vehicle.GetModel()
+				trip.VehicleColor = vehicle.GetColor()
+				trip.PlateNumber = vehicle.GetPlateNumber()
+			}
+		}
+	}
+	if driverID := order.GetProviderID(); driverID != "" {
+		if driver := models.GetUserByID(driverID); driver != nil {
+			trip.DriverName = driver.GetDisplayName()
+			trip.DriverAvatar = driver.GetAvatar()
+			trip.DriverRating = driver.GetRating()
+		}
+		if trip.Live {
+			if runtime := GetUserService().GetDriverRuntime(driverID); runtime != nil {
+				trip.DriverLatitude = runtime.Latitude
+				trip.DriverLongitude = runtime.Longitude
+				trip.DriverHeading = runtime.Heading
+				trip.LocationUpdatedAt = runtime.LocationUpdatedAt
+			}
+		}
+	}
+	return trip, protocol.Success
+}
+
+// ListTrustedContacts 获取紧急联系人
+func (s *SafetyService) ListTrustedContacts(userID string) ([]*protocol.TrustedContact, protocol.ErrorCode) {
+	contacts, err := models.ListTrustedContacts(userID)
+	if err != nil {
+		log.Get().Errorf("查询用户 %s 紧急联系人失败: %v", userID, err)
+		return nil, protocol.DatabaseError
+	}
+	list := make([]*protocol.TrustedContact, 0, len(contacts))
+	for _, contact := range contacts {
+		list = append(list, contact.Protocol())
+	}
+	return list, protocol.Success
+}
+
+// AddTrustedContact 添加紧急联系人，同一号码重复添加时返回已有联系人
+func (s *SafetyService) AddTrustedContact(req *protocol.TrustedContactRequest) (*protocol.TrustedContact, protocol.ErrorCode) {
+	phone := normalizeContactPhone(req.Phone)
+	if phone == "" {
+		return nil, protocol.InvalidPhone
+	}
+	if existing := models.GetTrustedContactByPhone(req.UserID, phone); existing != nil {
+		return existing.Protocol(), protocol.Success
+	}
+	if models.CountTrustedContacts(req.UserID) >= int64(config.GetSafetyConfig().MaxTrustedContacts) {
+		return nil, protocol.TrustedContactLimit
+	}
+	contact := models.NewTrustedContact(req.UserID, strings.TrimSpace(req.Name), phone, strings.TrimSpace(req.Relationship))
+	if err := models.CreateTrustedContact(contact); err != nil {
+		log.Get().Errorf("保存用户 %s 紧急联系人失败: %v", req.UserID, err)
+		return nil, protocol.DatabaseError
+	}
+	return contact.Protocol(), protocol.Success
+}
+
+// RemoveTrustedContact 删除紧急联系人
+func (s *SafetyService) RemoveTrustedContact(req *protocol.TrustedContactIDRequest) protocol.ErrorCode {
+	ok, err := models.DeleteTrustedContact(req.UserID, req.ContactID)
+	if err != nil {
+		log.Get().Errorf("删除用户 %s 紧急联系人 %s 失败: %v", req.UserID, req.ContactID, err)
+		return protocol.DatabaseError
+	}
+	if !ok {
+		return protocol.TrustedContactNotFound
+	}
+	return protocol.Success
+}
+
+// SearchIncidents 管理后台分页查询安全事件，未处理的排在前面
+func (s *SafetyService) SearchIncidents(req *protocol.SafetyIncidentSearchRequest) ([]*protocol.SafetyIncident, int64) {
+	incidents, total := models.SearchSafetyIncidents(req)
+	list := make([]*protocol.SafetyIncident, 0, len(incidents))
+	for _, incident := range incidents {
+		list = append(list, incident.Protocol(false))
+	}
+	return list, total
+}
+
+// GetIncident 管理后台查看安全事件详情（含订单快照）
+func (s *SafetyService) GetIncident(incidentID string) (*protocol.SafetyIncident, protocol.ErrorCode) {
+	incident := models.GetSafetyIncidentByID(incidentID)
+	if incident == nil {
+		return nil, protocol.SafetyIncidentNotFound
+	}
+	return incident.Protocol(true), protocol.Success
+}
+
+// AcknowledgeIncident 管理员响应安全事件
+func (s *SafetyService) AcknowledgeIncident(req *protocol.SafetyIncidentActionRequest) protocol.ErrorCode {
+	updates := map[string]any{
+		"status":          protocol.SafetyIncidentStatusAcknowledged,
+		"handled_by":      req.UserID,
+		"acknowledged_at": utils.TimeNowMilli(),
+	}
+	if req.Notes != "" {
+		updates["resolution"] = req.Notes
+	}
+	return s.transitionIncident(req.IncidentID, []string{protocol.SafetyIncidentStatusOpen}, updates)
+}
+
+// ResolveIncident 管理员关闭安全事件
+func (s *SafetyService) ResolveIncident(req *protocol.SafetyIncidentActionRequest) protocol.ErrorCode {
+	updates := map[string]any{
+		"status":      protocol.SafetyIncidentStatusResolved,
+		"handled_by":  req.UserID,
+		"resolved_at": utils.TimeNowMilli(),
+	}
+	if req.Notes != "" {
+		updates["resolution"] = req.Notes
+	}
+	return s.transitionIncident(req.IncidentID, []string{protocol.SafetyIncidentStatusOpen, protocol.SafetyIncidentStatusAcknowledged}, updates)
+}
+
+// transitionIncident 按当前状态更新安全事件
+func (s *SafetyService) transitionIncident(incidentID string, fromStatuses []string, updates map[string]any) protocol.ErrorCode {
+	if models.GetSafetyIncidentByID(incidentID) == nil {
+		return protocol.SafetyIncidentNotFound
+	}
+	ok, err := models.TransitionSafetyIncidentStatus(incidentID, fromStatuses, updates)
+	if err != nil {
+		log.Get().Errorf("更新安全事件 %s 失败: %v", incidentID, err)
+		return protocol.DatabaseError
+	}
+	if !ok {
+		return protocol.SafetyIncidentStatusInvalid
+	}
+	log.Get().Infof("管理员 %s 将安全事件 %s 更新为 %v", updates["handled_by"], incidentID, updates["status"])
+	return protocol.Success
+}
@@ -0,0 +1,52 @@
+package services
+
+import "testing"
+
+func TestTripShareLive(t *testing.T) {
+	for status, want := range map[string]bool{
+		"accepted":       true,
+		"driver_coming":  true,
+		"driver_arrived": true,
+		"in_progress":    true,
+		"requested":      false,
+		"trip_ended":     false,
+		"completed":      false,
+		"cancelled":      false,
+	} {
+		if got := tripShareLive(status); got != want {
+			t.Errorf("tripShareLive(%q) = %v, want %v", status, got, want)
+		}
+	}
+}
+
+func TestTripShareURL(t *testing.T) {
+	if got := tripShareURL("https://greenride.rw/trip/", "abc"); got != "https://greenride.rw/trip/abc" {
+		t.Errorf("tripShareURL = %q", got)
+	}
+	if got := tripShareURL("https://greenride.rw/trip", "abc"); got != "https://greenride.rw/trip/abc" {
+		t.Errorf("tripShareURL = %q", got)
+	}
+}
+
+func TestMapLink(t *testing.T) {
+	if got := mapLink(-1.9441, 30.0619); got != "https://maps.google.com/?q=-1.944100,30.061900" {
+		t.Errorf("mapLink = %q", got)
+	}
+}
+
+func TestNormalizeContactPhone(t *testing.T) {
+	cases := map[string]string{
+		"+250 788 123 456":  "+250788123456",
+		"(0788) 123-456":    "0788123456",
+		"250788123456":      "250788123456",
+		"1234":              "", // 太短
+		"+250 788 abc 456":  "",
+		"":                  "",
+		"+1234567890123456": "", // 太长
+	}
+	for in, want := range cases {
+		if got := normalizeContactPhone(in); got != want {
+			t.Errorf("normalizeContactPhone(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
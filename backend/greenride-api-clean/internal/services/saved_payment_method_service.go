@@ -0,0 +1,678 @@
+package services
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"greenride/internal/config"
+	"greenride/internal/log"
+	"greenride/internal/models"
+	"greenride/internal/protocol"
+	"greenride/internal/utils"
+
+	"github.com/shopspring/decimal"
+	"github.com/stripe/stripe-go/v76"
+	"gorm.io/gorm"
+)
+
+// SavedPaymentMethodService 乘客已保存的支付方式
+// MoMo号码通过一笔小额扣款验证，验证成功后金额退回乘客钱包；银行卡通过Stripe SetupIntent验证，只保存Stripe令牌和脱敏卡号。
+// 已验证的默认支付方式在行程结束后自动扣款，乘客支付订单时也可以指定已保存的支付方式。
+type SavedPaymentMethodService struct {
+}
+
+var (
+	savedPaymentMethodServiceInstance *SavedPaymentMethodService
+	savedPaymentMethodServiceOnce     sync.Once
+)
+
+func GetSavedPaymentMethodService() *SavedPaymentMethodService {
+	savedPaymentMethodServiceOnce.Do(func() {
+		SetupSavedPaymentMethodService()
+	})
+	return savedPaymentMethodServiceInstance
+}
+
+func SetupSavedPaymentMethodService() {
+	savedPaymentMethodServiceInstance = &SavedPaymentMethodService{}
+}
+
+// savedMomoCarrier 保存的MoMo号码所属运营商
+const savedMomoCarrier = "MTN"
+
+// savedMethodVerifyStatus 支付方式关联状态对应的验证状态
+func savedMethodVerifyStatus(status string, verified bool) string {
+	switch {
+	case status == models.UserPaymentMethodStatusActive && verified:
+		return protocol.PaymentMethodVerifyStatusVerified
+	case status == models.UserPaymentMethodStatusFailed:
+		return protocol.PaymentMethodVerifyStatusFailed
+	default:
+		return protocol.PaymentMethodVerifyStatusPending
+	}
+}
+
+// savedMethodType 支付方式记录对应的订单支付方式，不支持保存的类型返回空
+func savedMethodType(method *models.PaymentMethod) string {
+	switch {
+	case method.IsMobileMoney():
+		return protocol.PaymentMethodMomo
+	case method.IsBankCard():
+		return protocol.PaymentMethodCard
+	}
+	return ""
+}
+
+// toSavedPaymentMethod 转换为返回给客户端的支付方式，只包含脱敏信息
+func toSavedPaymentMethod(link *models.UserPaymentMethod, method *models.PaymentMethod) *protocol.SavedPaymentMethod {
+	verifyStatus := savedMethodVerifyStatus(link.GetStatus(), link.GetIsVerified())
+	saved := &protocol.SavedPaymentMethod{
+		MethodID:      link.UserPaymentMethodID,
+		PaymentMethod: savedMethodType(method),
+		Provider:      method.GetProviderName(),
+		DisplayName:   method.GetDisplayName(),
+		MaskedNumber:  method.GetMaskedNumber(),
+		ExpiryMonth:   method.GetExpiryMonth(),
+		ExpiryYear:    method.GetExpiryYear(),
+		IsDefault:     link.GetIsDefault(),
+		VerifyStatus:  verifyStatus,
+		VerifiedAt:    link.GetVerifiedAt(),
+		LastUsedAt:    link.GetLastUsedAt(),
+		CreatedAt:     link.CreatedAt,
+	}
+	if verifyStatus == protocol.PaymentMethodVerifyStatusFailed {
+		saved.VerifyReason = link.GetNotes()
+	}
+	if method.IsBankCard() && method.GetSubType() != "" {
+		saved.Provider = method.GetSubType()
+	}
+	return saved
+}
+
+// pickStripeAccount 选择保存银行卡的Stripe渠道账户：优先使用配置的账户，否则按账户ID排序取第一个
+func pickStripeAccount(configured string, accounts []string) string {
+	if len(accounts) == 0 {
+		return ""
+	}
+	sorted := append([]string(nil), accounts...)
+	sort.Strings(sorted)
+	if configured == "" {
+		return sorted[0]
+	}
+	for _, account := range sorted {
+		if account == configured {
+			return account
+		}
+	}
+	return ""
+}
+
+// verifyPaymentOrder MoMo小额验证复用订单支付渠道，支付记录以用户支付方式ID作为订单号
+func verifyPaymentOrder(user *models.User, methodID string, amount decimal.Decimal, currency string) *models.Order {
+	values := &models.OrderValues{}
+	values.SetOrderType(protocol.PaymentMethodVerifyOrder).
+		SetUserID(user.UserID).
+		SetStatus(protocol.StatusPending).
+		SetCurrency(currency).
+		SetPaymentAmount(amount)
+	if user.IsSandbox() {
+		values.SetSandbox(1)
+	}
+	return &models.Order{OrderID: methodID, OrderValues: values}
+}
+
+// autoChargeable 行程结束且乘客尚未发起支付的订单才自动扣款，已选择现金或已发起过支付时不处理
+func autoChargeable(status, paymentStatus, paymentMethod string) bool {
+	return status == protocol.StatusTripEnded && paymentStatus != protocol.StatusSuccess && paymentMethod == ""
+}
+
+// ListPaymentMethods 获取乘客已保存的支付方式，默认支付方式排在最前
+func (s *SavedPaymentMethodService) ListPaymentMethods(userID string) ([]*protocol.SavedPaymentMethod, protocol.ErrorCode) {
+	links, err := models.ListUserPaymentMethods(userID)
+	if err != nil {
+		log.Get().Errorf("查询用户 %s 支付方式失败: %v", userID, err)
+		return nil, protocol.DatabaseError
+	}
+	list := make([]*protocol.SavedPaymentMethod, 0, len(links))
+	for _, link := range links {
+		method := models.GetPaymentMethodByID(link.GetPaymentMethodID())
+		if method == nil || savedMethodType(method) == "" {
+			continue
+		}
+		list = append(list, toSavedPaymentMethod(link, method))
+	}
+	return list, protocol.Success
+}
+
+// AddPaymentMethod 添加支付方式并发起验证：MoMo立即发起小额扣款，银行卡返回SetupIntent由客户端确认
+func (s *SavedPaymentMethodService) AddPaymentMethod(req *protocol.AddPaymentMethodRequest) (*protocol.SavedPaymentMethod, protocol.ErrorCode) {
+	user := models.GetUserByID(req.UserID)
+	if user == nil {
+		return nil, protocol.UserNotFound
+	}
+	if !user.IsPassenger() {
+		return nil, protocol.PermissionDenied
+	}
+	if req.PaymentMethod != protocol.PaymentMethodMomo && req.PaymentMethod != protocol.PaymentMethodCard {
+		return nil, protocol.InvalidPaymentMethod
+	}
+	cfg := config.GetSavedPaymentMethodConfig()
+	count := models.CountUserPaymentMethods(user.UserID)
+	if count >= int64(cfg.MaxMethods) {
+		return nil, protocol.PaymentMethodLimit
+	}
+
+	// 第一个支付方式验证通过后自动设为默认
+	metadata := protocol.MapData{"set_default": req.SetDefault || count == 0}
+	if req.PaymentMethod == protocol.PaymentMethodMomo {
+		return s.addMomo(user, req, metadata)
+	}
+	return s.addCard(user, metadata)
+}
+
+// addMomo 保存MoMo号码并发起小额验证，同一号码重复添加时返回已有记录
+func (s *SavedPaymentMethodService) addMomo(user *models.User, req *protocol.AddPaymentMethodRequest, metadata protocol.MapData) (*protocol.SavedPaymentMethod, protocol.ErrorCode) {
+	phone := strings.TrimSpace(req.Phone)
+	if phone == "" {
+		phone = user.GetPhone()
+	}
+	if phone == "" {
+		return nil, protocol.InvalidParams
+	}
+	if link, method := s.findUserMomo(user.UserID, phone); link != nil {
+		if link.GetStatus() == models.UserPaymentMethodStatusFailed {
+			return s.VerifyPaymentMethod(&protocol.PaymentMethodIDRequest{UserID: user.UserID, MethodID: link.UserPaymentMethodID})
+		}
+		return toSavedPaymentMethod(link, method), protocol.Success
+	}
+
+	method := models.NewMobileMoneyPaymentMethod(user.UserID, savedMomoCarrier, phone)
+	if name := strings.TrimSpace(req.AccountName); name != "" {
+		method.AccountName = &name
+	}
+	method.SetMetadata(metadata)
+	link := s.newPendingLink(user.UserID, method.PaymentMethodID)
+	if errCode := s.create(link, method); errCode != protocol.Success {
+		return nil, errCode
+	}
+	s.startMomoVerify(user, link, method)
+	return s.reload(user.UserID, link.UserPaymentMethodID)
+}
+
+// addCard 创建Stripe SetupIntent，客户端确认后调用验证接口保存卡的令牌
+func (s *SavedPaymentMethodService) addCard(user *models.User, metadata protocol.MapData) (*protocol.SavedPaymentMethod, protocol.ErrorCode) {
+	accountID, stripeService := s.stripeChannel()
+	if stripeService == nil {
+		return nil, protocol.NoAvailablePaymentService
+	}
+	method := models.NewPaymentMethod()
+	method.SetUserID(user.UserID).
+		SetPaymentType(models.PaymentTypeCreditCard).
+		SetProviderName("Stripe")
+	metadata["channel_account_id"] = accountID
+	link := s.newPendingLink(user.UserID, method.PaymentMethodID)
+
+	// 沙盒用户不请求Stripe，直接保存测试卡
+	if GetPaymentService().config.IsSandbox() && user.IsSandbox() {
+		method.SetStripeCard("", "", "visa", "4242", 12, time.Now().Year()+3)
+		method.SetMetadata(metadata)
+		if errCode := s.create(link, method); errCode != protocol.Success {
+			return nil, errCode
+		}
+		s.markVerified(link, method)
+		return s.reload(user.UserID, link.UserPaymentMethodID)
+	}
+
+	customerID := models.GetUserStripeCustomerID(user.UserID, accountID)
+	if customerID == "" {
+		id, err := stripeService.CreateCustomer(user.UserID, user.GetEmail(), user.GetFullName())
+		if err != nil {
+			log.Get().Errorf("为用户 %s 创建Stripe客户失败: %v", user.UserID, err)
+			return nil, protocol.ThirdPartyError
+		}
+		customerID = id
+	}
+	method.StripeCustomerID = &customerID
+	intent, err := stripeService.CreateSetupIntent(customerID, user.UserID, link.UserPaymentMethodID)
+	if err != nil {
+		log.Get().Errorf("为用户 %s 创建Stripe SetupIntent失败: %v", user.UserID, err)
+		return nil, protocol.ThirdPartyError
+	}
+	metadata["setup_intent_id"] = intent.ID
+	method.SetMetadata(metadata)
+	if errCode := s.create(link, method); errCode != protocol.Success {
+		return nil, errCode
+	}
+	saved := toSavedPaymentMethod(link, method)
+	saved.ClientSecret = intent.ClientSecret
+	saved.PublishableKey = stripeService.GetPublishableKey()
+	return saved, protocol.Success
+}
+
+// VerifyPaymentMethod 查询或重新发起验证：待验证时同步渠道结果，验证失败时重新发起
+func (s *SavedPaymentMethodService) VerifyPaymentMethod(req *protocol.PaymentMethodIDRequest) (*protocol.SavedPaymentMethod, protocol.ErrorCode) {
+	link := models.GetUserPaymentMethodByID(req.UserID, req.MethodID)
+	if link == nil {
+		return nil, protocol.PaymentMethodNotFound
+	}
+	method := models.GetPaymentMethodByID(link.GetPaymentMethodID())
+	if method == nil {
+		return nil, protocol.PaymentMethodNotFound
+	}
+	switch link.GetStatus() {
+	case models.UserPaymentMethodStatusPending:
+		if method.IsBankCard() {
+			s.syncSetupIntent(link, method)
+		} else if paymentID := method.GetMetadata().Get("verify_payment_id"); paymentID != "" {
+			s.CheckVerifyPayment(link.UserPaymentMethodID, paymentID)
+		}
+	case models.UserPaymentMethodStatusFailed:
+		ok, err := models.TransitionUserPaymentMethodStatus(link.UserPaymentMethodID, []string{models.UserPaymentMethodStatusFailed}, map[string]any{
+			"status": models.UserPaymentMethodStatusPending,
+			"notes":  "",
+		})
+		if err != nil {
+			log.Get().Errorf("重新验证支付方式 %s 失败: %v", link.UserPaymentMethodID, err)
+			return nil, protocol.DatabaseError
+		}
+		if ok {
+			if method.IsBankCard() {
+				return s.restartSetupIntent(link, method)
+			}
+			if user := models.GetUserByID(req.UserID); user != nil {
+				s.startMomoVerify(user, link, method)
+			}
+		}
+	}
+	return s.reload(req.UserID, link.UserPaymentMethodID)
+}
+
+// SetDefaultPaymentMethod 设置默认支付方式，只有已验证的支付方式可以设为默认
+func (s *SavedPaymentMethodService) SetDefaultPaymentMethod(req *protocol.PaymentMethodIDRequest) protocol.ErrorCode {
+	link := models.GetUserPaymentMethodByID(req.UserID, req.MethodID)
+	if link == nil {
+		return protocol.PaymentMethodNotFound
+	}
+	if link.GetStatus() != models.UserPaymentMethodStatusActive || !link.GetIsVerified() {
+		return protocol.PaymentMethodNotVerified
+	}
+	if err := models.SetUserDefaultPaymentMethod(req.UserID, link.UserPaymentMethodID); err != nil {
+		log.Get().Errorf("设置用户 %s 默认支付方式 %s 失败: %v", req.UserID, link.UserPaymentMethodID, err)
+		return protocol.DatabaseError
+	}
+	return protocol.Success
+}
+
+// RemovePaymentMethod 删除支付方式，银行卡同时从Stripe客户解绑；删除默认支付方式时改用最近验证的其他支付方式
+func (s *SavedPaymentMethodService) RemovePaymentMethod(req *protocol.PaymentMethodIDRequest) protocol.ErrorCode {
+	link := models.GetUserPaymentMethodByID(req.UserID, req.MethodID)
+	if link == nil {
+		return protocol.PaymentMethodNotFound
+	}
+	ok, err := models.TransitionUserPaymentMethodStatus(link.UserPaymentMethodID, []string{link.GetStatus()}, map[string]any{
+		"status":     models.UserPaymentMethodStatusDeleted,
+		"is_default": false,
+	})
+	if err != nil {
+		log.Get().Errorf("删除支付方式 %s 失败: %v", link.UserPaymentMethodID, err)
+		return protocol.DatabaseError
+	}
+	if !ok {
+		return protocol.Success
+	}
+
+	if method := models.GetPaymentMethodByID(link.GetPaymentMethodID()); method != nil && method.GetStripePaymentMethodID() != "" {
+		go s.detachCard(method)
+	}
+	if link.GetIsDefault() {
+		s.promoteDefault(req.UserID)
+	}
+	return protocol.Success
+}
+
+// ApplySavedMethod 使用指定或默认的已保存支付方式填充支付请求，返回使用的支付方式ID
+// 未指定且没有默认支付方式时不修改请求，返回空ID
+func (s *SavedPaymentMethodService) ApplySavedMethod(userID, methodID string, req *ChannelPaymentRequest) (string, protocol.ErrorCode) {
+	var link *models.UserPaymentMethod
+	if methodID != "" {
+		link = models.GetUserPaymentMethodByID(userID, methodID)
+		if link == nil {
+			return "", protocol.PaymentMethodNotFound
+		}
+		if link.GetStatus() != models.UserPaymentMethodStatusActive || !link.GetIsVerified() {
+			return "", protocol.PaymentMethodNotVerified
+		}
+	} else if link = models.GetUserDefaultPaymentMethod(userID); link == nil {
+		return "", protocol.Success
+	}
+	method := models.GetPaymentMethodByID(link.GetPaymentMethodID())
+	if method == nil {
+		return "", protocol.PaymentMethodNotFound
+	}
+
+	switch savedMethodType(method) {
+	case protocol.PaymentMethodMomo:
+		req.PaymentMethod = protocol.PaymentMethodMomo
+		req.Phone = method.GetPhoneNumber()
+		if method.GetAccountName() != "" {
+			req.AccountName = method.GetAccountName()
+		}
+	case protocol.PaymentMethodCard:
+		metadata := method.GetMetadata()
+		req.PaymentMethod = protocol.PaymentMethodCard
+		req.CardID = link.UserPaymentMethodID
+		req.Metadata = protocol.MapData{
+			"stripe_customer_id":       method.GetStripeCustomerID(),
+			"stripe_payment_method_id": method.GetStripePaymentMethodID(),
+		}
+		if method.GetStripePaymentMethodID() != "" {
+			req.ChannelAccountID = metadata.Get("channel_account_id")
+		}
+	default:
+		return "", protocol.InvalidPaymentMethod
+	}
+	return link.UserPaymentMethodID, protocol.Success
+}
+
+// ChargeDefault 行程结束后使用乘客的默认支付方式自动扣款，失败时记为车费欠款由催收任务重试，乘客也可手动支付
+func (s *SavedPaymentMethodService) ChargeDefault(orderID string) {
+	if !config.GetSavedPaymentMethodConfig().IsAutoChargeEnabled() {
+		return
+	}
+	order := models.GetOrderByID(orderID)
+	if order == nil || !autoChargeable(order.GetStatus(), order.GetPaymentStatus(), order.GetPaymentMethod()) {
+		return
+	}
+	if models.GetUserDefaultPaymentMethod(order.GetUserID()) == nil {
+		return
+	}
+	result, errCode := GetOrderService().OrderPayment(&protocol.OrderPaymentRequest{
+		OrderID: orderID,
+		UserID:  order.GetUserID(),
+	})
+	if errCode != protocol.Success {
+		log.Get().Warnf("订单 %s 使用默认支付方式自动扣款失败: %s", orderID, errCode)
+		GetDunningService().RecordChargeFailure(orderID, "["+string(errCode)+"]"+errCode.GetMessage())
+		return
+	}
+	log.Get().Infof("订单 %s 已使用默认支付方式自动扣款，支付状态=%s", orderID, result.Status)
+}
+
+// CheckVerifyPayment 支付回调后同步MoMo小额验证结果，支付记录以用户支付方式ID作为订单号
+func (s *SavedPaymentMethodService) CheckVerifyPayment(methodID, paymentID string) {
+	payment := models.GetPaymentByID(paymentID)
+	if payment == nil || payment.GetOrderType() != protocol.PaymentMethodVerifyOrder {
+		return
+	}
+	link := models.GetUserPaymentMethodByID(payment.GetUserID(), methodID)
+	if link == nil || link.GetStatus() != models.UserPaymentMethodStatusPending {
+		return
+	}
+	method := models.GetPaymentMethodByID(link.GetPaymentMethodID())
+	if method == nil || method.GetMetadata().Get("verify_payment_id") != paymentID {
+		return
+	}
+	switch payment.GetStatus() {
+	case protocol.StatusSuccess:
+		s.markVerified(link, method)
+		s.refundVerifyAmount(link, payment)
+	case protocol.StatusFailed:
+		s.markFailed(link, "["+payment.GetResCode()+"]"+payment.GetResMsg())
+	}
+}
+
+// startMomoVerify 发起MoMo小额验证扣款，异步结果由支付回调通过CheckVerifyPayment处理
+func (s *SavedPaymentMethodService) startMomoVerify(user *models.User, link *models.UserPaymentMethod, method *models.PaymentMethod) {
+	cfg := config.GetSavedPaymentMethodConfig()
+	result, errCode := GetPaymentService().OrderPayment(&ChannelPaymentRequest{
+		Phone:         method.GetPhoneNumber(),
+		AccountName:   method.GetAccountName(),
+		PaymentMethod: protocol.PaymentMethodMomo,
+		Order:         verifyPaymentOrder(user, link.UserPaymentMethodID, decimal.NewFromFloat(cfg.MomoVerifyAmount), cfg.VerifyCurrency),
+		User:          user,
+	})
+	if errCode != protocol.Success {
+		s.markFailed(link, string(errCode))
+		return
+	}
+
+	metadata := method.GetMetadata()
+	metadata["verify_payment_id"] = result.PaymentID
+	values := &models.PaymentMethodValues{}
+	values.SetMetadata(metadata)
+	if err := models.UpdatePaymentMethod(method, values); err != nil {
+		log.Get().Errorf("记录支付方式 %s 验证支付 %s 失败: %v", link.UserPaymentMethodID, result.PaymentID, err)
+		return
+	}
+	switch result.Status {
+	case protocol.StatusSuccess:
+		s.markVerified(link, method)
+		if payment := models.GetPaymentByID(result.PaymentID); payment != nil {
+			s.refundVerifyAmount(link, payment)
+		}
+	case protocol.StatusFailed:
+		s.markFailed(link, "["+result.ResCode+"]"+result.ResMsg)
+	}
+}
+
+// syncSetupIntent 查询SetupIntent结果：成功时保存卡的令牌和脱敏信息，取消或验证出错时标记失败
+func (s *SavedPaymentMethodService) syncSetupIntent(link *models.UserPaymentMethod, method *models.PaymentMethod) {
+	metadata := method.GetMetadata()
+	stripeService := s.stripeService(metadata.Get("channel_account_id"))
+	intentID := metadata.Get("setup_intent_id")
+	if stripeService == nil || intentID == "" {
+		return
+	}
+	intent, err := stripeService.GetSetupIntent(intentID)
+	if err != nil {
+		log.Get().Errorf("查询支付方式 %s 的SetupIntent %s 失败: %v", link.UserPaymentMethodID, intentID, err)
+		return
+	}
+	switch intent.Status {
+	case stripe.SetupIntentStatusSucceeded:
+		if intent.PaymentMethod == nil || intent.PaymentMethod.Card == nil {
+			return
+		}
+		card := intent.PaymentMethod.Card
+		values := &models.PaymentMethodValues{}
+		values.SetStripeCard(method.GetStripeCustomerID(), intent.PaymentMethod.ID, string(card.Brand), card.Last4, int(card.ExpMonth), int(card.ExpYear))
+		if err := models.UpdatePaymentMethod(method, values); err != nil {
+			log.Get().Errorf("保存支付方式 %s 的卡信息失败: %v", link.UserPaymentMethodID, err)
+			return
+		}
+		s.markVerified(link, method)
+	case stripe.SetupIntentStatusCanceled:
+		s.markFailed(link, "setup_intent canceled")
+	case stripe.SetupIntentStatusRequiresPaymentMethod:
+		if intent.LastSetupError != nil {
+			s.markFailed(link, intent.LastSetupError.Msg)
+		}
+	}
+}
+
+// restartSetupIntent 银行卡验证失败后重新创建SetupIntent
+func (s *SavedPaymentMethodService) restartSetupIntent(link *models.UserPaymentMethod, method *models.PaymentMethod) (*protocol.SavedPaymentMethod, protocol.ErrorCode) {
+	metadata := method.GetMetadata()
+	stripeService := s.stripeService(metadata.Get("channel_account_id"))
+	if stripeService == nil {
+		return nil, protocol.NoAvailablePaymentService
+	}
+	intent, err := stripeService.CreateSetupIntent(method.GetStripeCustomerID(), link.GetUserID(), link.UserPaymentMethodID)
+	if err != nil {
+		log.Get().Errorf("为支付方式 %s 重新创建SetupIntent失败: %v", link.UserPaymentMethodID, err)
+		s.markFailed(link, err.Error())
+		return nil, protocol.ThirdPartyError
+	}
+	metadata["setup_intent_id"] = intent.ID
+	values := &models.PaymentMethodValues{}
+	values.SetMetadata(metadata)
+	if err := models.UpdatePaymentMethod(method, values); err != nil {
+		log.Get().Errorf("记录支付方式 %s 的SetupIntent失败: %v", link.UserPaymentMethodID, err)
+		return nil, protocol.DatabaseError
+	}
+	saved, errCode := s.reload(link.GetUserID(), link.UserPaymentMethodID)
+	if errCode != protocol.Success {
+		return nil, errCode
+	}
+	saved.ClientSecret = intent.ClientSecret
+	saved.PublishableKey = stripeService.GetPublishableKey()
+	return saved, protocol.Success
+}
+
+// markVerified 验证通过：条件更新状态保证只处理一次，需要时设为默认支付方式
+func (s *SavedPaymentMethodService) markVerified(link *models.UserPaymentMethod, method *models.PaymentMethod) {
+	ok, err := models.TransitionUserPaymentMethodStatus(link.UserPaymentMethodID, []string{models.UserPaymentMethodStatusPending}, map[string]any{
+		"status":              models.UserPaymentMethodStatusActive,
+		"is_verified":         true,
+		"verification_method": models.UserPaymentVerificationAutomatic,
+		"verified_at":         utils.TimeNowMilli(),
+		"notes":               "",
+	})
+	if err != nil || !ok {
+		if err != nil {
+			log.Get().Errorf("更新支付方式 %s 验证成功状态失败: %v", link.UserPaymentMethodID, err)
+		}
+		return
+	}
+
+	values := &models.PaymentMethodValues{}
+	values.SetVerified(true)
+	if err := models.UpdatePaymentMethod(method, values); err != nil {
+		log.Get().Errorf("更新支付方式 %s 验证信息失败: %v", method.PaymentMethodID, err)
+	}
+	if method.GetMetadata().GetBool("set_default") || models.GetUserDefaultPaymentMethod(link.GetUserID()) == nil {
+		if err := models.SetUserDefaultPaymentMethod(link.GetUserID(), link.UserPaymentMethodID); err != nil {
+			log.Get().Errorf("设置用户 %s 默认支付方式 %s 失败: %v", link.GetUserID(), link.UserPaymentMethodID, err)
+		}
+	}
+	log.Get().Infof("用户 %s 的支付方式 %s 已验证", link.GetUserID(), link.UserPaymentMethodID)
+}
+
+// markFailed 验证失败，乘客可以重新发起验证
+func (s *SavedPaymentMethodService) markFailed(link *models.UserPaymentMethod, reason string) {
+	if len(reason) > 255 {
+		reason = reason[:255]
+	}
+	if _, err := models.TransitionUserPaymentMethodStatus(link.UserPaymentMethodID, []string{models.UserPaymentMethodStatusPending}, map[string]any{
+		"status": models.UserPaymentMethodStatusFailed,
+		"notes":  reason,
+	}); err != nil {
+		log.Get().Errorf("更新支付方式 %s 验证失败状态出错: %v", link.UserPaymentMethodID, err)
+	}
+}
+
+// refundVerifyAmount MoMo不支持退款，小额验证金额退回乘客钱包；沙盒支付未实际扣款，不退回
+func (s *SavedPaymentMethodService) refundVerifyAmount(link *models.UserPaymentMethod, payment *models.Payment) {
+	if payment.GetChannelCode() == protocol.PaymentChannelSandbox {
+		return
+	}
+	amount, _ := payment.GetAmount().Float64()
+	if _, err := models.CreditWalletIncome(link.GetUserID(), protocol.UserTypePassenger, payment.GetCurrency(), amount, models.TransactionCategoryRefund, protocol.PaymentMethodVerifyOrder, link.UserPaymentMethodID, "支付方式验证退款"); err != nil {
+		log.Get().Errorf("支付方式 %s 验证金额退回用户 %s 钱包失败: %v", link.UserPaymentMethodID, link.GetUserID(), err)
+	}
+}
+
+// promoteDefault 默认支付方式被删除后，改用最近验证的其他支付方式作为默认
+func (s *SavedPaymentMethodService) promoteDefault(userID string) {
+	links, err := models.ListUserPaymentMethods(userID)
+	if err != nil {
+		return
+	}
+	for _, link := range links {
+		if link.GetStatus() == models.UserPaymentMethodStatusActive && link.GetIsVerified() {
+			if err := models.SetUserDefaultPaymentMethod(userID, link.UserPaymentMethodID); err != nil {
+				log.Get().Errorf("设置用户 %s 默认支付方式 %s 失败: %v", userID, link.UserPaymentMethodID, err)
+			}
+			return
+		}
+	}
+}
+
+// detachCard 删除银行卡后从Stripe客户解绑，失败只记录日志
+func (s *SavedPaymentMethodService) detachCard(method *models.PaymentMethod) {
+	stripeService := s.stripeService(method.GetMetadata().Get("channel_account_id"))
+	if stripeService == nil {
+		return
+	}
+	if err := stripeService.DetachPaymentMethod(method.GetStripePaymentMethodID()); err != nil {
+		log.Get().Warnf("解绑Stripe支付方式 %s 失败: %v", method.GetStripePaymentMethodID(), err)
+	}
+}
+
+// findUserMomo 查找用户未删除的同一MoMo号码
+func (s *SavedPaymentMethodService) findUserMomo(userID, phone string) (*models.UserPaymentMethod, *models.PaymentMethod) {
+	links, err := models.ListUserPaymentMethods(userID)
+	if err != nil {
+		return nil, nil
+	}
+	for _, link := range links {
+		method := models.GetPaymentMethodByID(link.GetPaymentMethodID())
+		if method != nil && method.IsMobileMoney() && method.GetPhoneNumber() == phone {
+			return link, method
+		}
+	}
+	return nil, nil
+}
+
+// newPendingLink 创建待验证的用户支付方式关联
+func (s *SavedPaymentMethodService) newPendingLink(userID, paymentMethodID string) *models.UserPaymentMethod {
+	link := models.NewUserPaymentMethodV2()
+	link.SetUserID(userID).
+		SetPaymentMethodID(paymentMethodID).
+		SetStatus(models.UserPaymentMethodStatusPending).
+		SetBoundInfo(userID, models.UserPaymentBoundMethodManual, models.UserPaymentBoundSourceApp)
+	return link
+}
+
+// create 保存支付方式及用户关联
+func (s *SavedPaymentMethodService) create(link *models.UserPaymentMethod, method *models.PaymentMethod) protocol.ErrorCode {
+	err := models.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(method).Error; err != nil {
+			return err
+		}
+		return tx.Create(link).Error
+	})
+	if err != nil {
+		log.Get().Errorf("保存用户 %s 支付方式失败: %v", link.GetUserID(), err)
+		return protocol.DatabaseError
+	}
+	return protocol.Success
+}
+
+// reload 重新读取支付方式的最新状态
+func (s *SavedPaymentMethodService) reload(userID, methodID string) (*protocol.SavedPaymentMethod, protocol.ErrorCode) {
+	link := models.GetUserPaymentMethodByID(userID, methodID)
+	if link == nil {
+		return nil, protocol.PaymentMethodNotFound
+	}
+	method := models.GetPaymentMethodByID(link.GetPaymentMethodID())
+	if method == nil {
+		return nil, protocol.PaymentMethodNotFound
+	}
+	return toSavedPaymentMethod(link, method), protocol.Success
+}
+
+// stripeChannel 保存银行卡使用的Stripe渠道
+func (s *SavedPaymentMethodService) stripeChannel() (string, *StripeService) {
+	var accounts []string
+	for accountID, channel := range PaymentChannels {
+		if _, ok := channel.(*StripeService); ok {
+			accounts = append(accounts, accountID)
+		}
+	}
+	accountID := pickStripeAccount(config.GetSavedPaymentMethodConfig().StripeAccountID, accounts)
+	if accountID == "" {
+		return "", nil
+	}
+	return accountID, s.stripeService(accountID)
+}
+
+// stripeService 按渠道账户ID获取Stripe服务
+func (s *SavedPaymentMethodService) stripeService(accountID string) *StripeService {
+	if stripeService, ok := PaymentChannels[accountID].(*StripeService); ok {
+		return stripeService
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"testing"
+
+	"greenride/internal/models"
+	"greenride/internal/protocol"
+	"greenride/internal/utils"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestSavedMethodVerifyStatus(t *testing.T) {
+	cases := []struct {
+		status   string
+		verified bool
+		want     string
+	}{
+		{models.UserPaymentMethodStatusActive, true, protocol.PaymentMethodVerifyStatusVerified},
+		{models.UserPaymentMethodStatusActive, false, protocol.PaymentMethodVerifyStatusPending},
+		{models.UserPaymentMethodStatusPending, false, protocol.PaymentMethodVerifyStatusPending},
+		{models.UserPaymentMethodStatusFailed, false, protocol.PaymentMethodVerifyStatusFailed},
+	}
+	for _, c := range cases {
+		if got := savedMethodVerifyStatus(c.status, c.verified); got != c.want {
+			t.Errorf("status=%s verified=%v: got %s, want %s", c.status, c.verified, got, c.want)
+		}
+	}
+}
+
+func TestToSavedPaymentMethodMasksCard(t *testing.T) {
+	method := models.NewPaymentMethod()
+	method.SetPaymentType(models.PaymentTypeCreditCard)
+	method.SetStripeCard("cus_1", "pm_1", "visa", "4242", 12, 2030)
+	link := models.NewUserPaymentMethodV2()
+	link.SetStatus(models.UserPaymentMethodStatusActive).SetVerified(true, models.UserPaymentVerificationAutomatic)
+	link.SetDefault(true)
+
+	saved := toSavedPaymentMethod(link, method)
+	if saved.PaymentMethod != protocol.PaymentMethodCard || saved.Provider != "visa" {
+		t.Errorf("unexpected card type %s provider %s", saved.PaymentMethod, saved.Provider)
+	}
+	if saved.MaskedNumber != "****4242" || saved.DisplayName != "Visa ****4242" {
+		t.Errorf("unexpected masked card %q %q", saved.MaskedNumber, saved.DisplayName)
+	}
+	if !saved.IsDefault || saved.VerifyStatus != protocol.PaymentMethodVerifyStatusVerified {
+		t.Errorf("card should be default and verified, got %v %s", saved.IsDefault, saved.VerifyStatus)
+	}
+}
+
+func TestToSavedPaymentMethodMasksMomo(t *testing.T) {
+	method := models.NewMobileMoneyPaymentMethod("U1", savedMomoCarrier, "250788123456")
+	link := models.NewUserPaymentMethodV2()
+	link.SetStatus(models.UserPaymentMethodStatusFailed)
+	link.Notes = utils.StringPtr("[PAYER_NOT_FOUND]")
+
+	saved := toSavedPaymentMethod(link, method)
+	if saved.PaymentMethod != protocol.PaymentMethodMomo || saved.MaskedNumber == "250788123456" {
+		t.Errorf("momo number should be masked, got %s %s", saved.PaymentMethod, saved.MaskedNumber)
+	}
+	if saved.VerifyStatus != protocol.PaymentMethodVerifyStatusFailed || saved.VerifyReason != "[PAYER_NOT_FOUND]" {
+		t.Errorf("failed verification should carry the reason, got %s %q", saved.VerifyStatus, saved.VerifyReason)
+	}
+}
+
+func TestVerifyPaymentOrder(t *testing.T) {
+	user := &models.User{UserID: "U1", UserValues: &models.UserValues{}}
+	user.SetSandbox(1)
+
+	order := verifyPaymentOrder(user, "UPM1", decimal.NewFromInt(100), "RWF")
+	if order.OrderID != "UPM1" || order.GetOrderType() != protocol.PaymentMethodVerifyOrder {
+		t.Errorf("payment order should use the method id and verify type, got %s %s", order.OrderID, order.GetOrderType())
+	}
+	if !order.GetPaymentAmount().Equal(decimal.NewFromInt(100)) || order.GetStatus() != protocol.StatusPending || order.GetUserID() != "U1" {
+		t.Errorf("unexpected payment order amount %s status %s user %s", order.GetPaymentAmount(), order.GetStatus(), order.GetUserID())
+	}
+	if !order.IsSandbox() {
+		t.Error("sandbox flag should follow the user")
+	}
+}
+
+func TestPickStripeAccount(t *testing.T) {
+	accounts := []string{"stripe_b", "stripe_a"}
+	if got := pickStripeAccount("", accounts); got != "stripe_a" {
+		t.Errorf("should pick the first account by id, got %s", got)
+	}
+	if got := pickStripeAccount("stripe_b", accounts); got != "stripe_b" {
+		t.Errorf("should pick the configured account, got %s", got)
+	}
+	if got := pickStripeAccount("stripe_c", accounts); got != "" {
+		t.Errorf("unknown configured account should not fall back, got %s", got)
+	}
+	if got := pickStripeAccount("", nil); got != "" {
+		t.Errorf("no stripe channel, got %s", got)
+	}
+}
+
+func TestAutoChargeable(t *testing.T) {
+	cases := []struct {
+		status, paymentStatus, paymentMethod string
+		want                                 bool
+	}{
+		{protocol.StatusTripEnded, protocol.StatusPending, "", true},
+		{protocol.StatusTripEnded, protocol.StatusPending, protocol.PaymentMethodCash, false}, // 乘客已选择现金
+		{protocol.StatusTripEnded, protocol.StatusFailed, protocol.PaymentMethodMomo, false},  // 已发起过支付
+		{protocol.StatusCompleted, protocol.StatusSuccess, "", false},
+		{protocol.StatusInProgress, protocol.StatusPending, "", false},
+	}
+	for _, c := range cases {
+		if got := autoChargeable(c.status, c.paymentStatus, c.paymentMethod); got != c.want {
+			t.Errorf("status=%s payment=%s/%s: got %v, want %v", c.status, c.paymentStatus, c.paymentMethod, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,351 @@
+package services
+
+import (
+	"math"
+	"strings"
+	"sync"
+
+	"greenride/internal/config"
+	"greenride/internal/log"
+	"greenride/internal/models"
+	"greenride/internal/protocol"
+	"greenride/internal/utils"
+)
+
+// SavedPlaceService 乘客常用地点
+// 基于用户地址表管理家、公司和自定义地点，并从已完成的行程中统计最近目的地；
+// 预估和下单时可以直接传常用地点ID代替坐标，下单成功后累计地点使用次数。
+type SavedPlaceService struct {
+}
+
+var (
+	savedPlaceInstance *SavedPlaceService
+	savedPlaceOnce     sync.Once
+)
+
+func GetSavedPlaceService() *SavedPlaceService {
+	savedPlaceOnce.Do(func() {
+		SetupSavedPlaceService()
+	})
+	return savedPlaceInstance
+}
+
+func SetupSavedPlaceService() {
+	savedPlaceInstance = &SavedPlaceService{}
+}
+
+// 家和公司未填写名称时使用的默认名称
+var defaultPlaceLabels = map[string]string{
+	protocol.SavedPlaceTypeHome: "Home",
+	protocol.SavedPlaceTypeWork: "Work",
+}
+
+// 常用地点名称最大长度，与地址表label字段一致
+const savedPlaceLabelMaxLength = 100
+
+// recentTrip 已完成行程的下车点
+type recentTrip struct {
+	OrderID          string
+	DropoffLatitude  float64
+	DropoffLongitude float64
+	DropoffAddress   string
+	DropoffLandmark  string
+	CompletedAt      int64
+}
+
+// validateSavedPlace 校验常用地点类型、名称和坐标，返回整理后的名称
+func validateSavedPlace(req *protocol.SavePlaceRequest) (string, protocol.ErrorCode) {
+	label := strings.TrimSpace(req.Label)
+	switch req.Type {
+	case protocol.SavedPlaceTypeHome, protocol.SavedPlaceTypeWork:
+		if label == "" {
+			label = defaultPlaceLabels[req.Type]
+		}
+	case protocol.SavedPlaceTypeCustom:
+		if label == "" {
+			return "", protocol.InvalidSavedPlace
+		}
+	default:
+		return "", protocol.InvalidSavedPlace
+	}
+	if len(label) > savedPlaceLabelMaxLength || strings.TrimSpace(req.Address) == "" {
+		return "", protocol.InvalidSavedPlace
+	}
+	if !validCoordinate(req.Latitude, req.Longitude) {
+		return "", protocol.InvalidSavedPlace
+	}
+	return label, protocol.Success
+}
+
+// validCoordinate 坐标在合法范围内且不是未填写的(0,0)
+func validCoordinate(lat, lng float64) bool {
+	return !(lat == 0 && lng == 0) && lat >= -90 && lat <= 90 && lng >= -180 && lng <= 180
+}
+
+// placeAtLocation 常用地点坐标与给定坐标是否一致
+func placeAtLocation(place *protocol.SavedPlace, lat, lng float64) bool {
+	const epsilon = 1e-6
+	return math.Abs(place.Latitude-lat) <= epsilon && math.Abs(place.Longitude-lng) <= epsilon
+}
+
+// placeLandmark 下单时带给司机的地标：优先到达指引，其次建筑物名称
+func placeLandmark(place *protocol.SavedPlace) string {
+	if place.AccessInstructions != "" {
+		return place.AccessInstructions
+	}
+	return place.BuildingName
+}
+
+// mergeRecentDestinations 按时间倒序合并距离相近的下车点，统计到达次数，并标记与常用地点重合的目的地
+func mergeRecentDestinations(trips []*recentTrip, places []*protocol.SavedPlace, radiusMeters float64, limit int) []*protocol.RecentDestination {
+	radiusKm := radiusMeters / 1000
+	result := []*protocol.RecentDestination{}
+	for _, trip := range trips {
+		if !validCoordinate(trip.DropoffLatitude, trip.DropoffLongitude) {
+			continue
+		}
+		var existing *protocol.RecentDestination
+		for _, item := range result {
+			if utils.CalculateDistanceHaversine(item.Latitude, item.Longitude, trip.DropoffLatitude, trip.DropoffLongitude) <= radiusKm {
+				existing = item
+				break
+			}
+		}
+		if existing != nil {
+			existing.Visits++
+			continue
+		}
+		if len(result) >= limit {
+			continue
+		}
+		destination := &protocol.RecentDestination{
+			Latitude:  trip.DropoffLatitude,
+			Longitude: trip.DropoffLongitude,
+			Address:   trip.DropoffAddress,
+			Landmark:  trip.DropoffLandmark,
+			OrderID:   trip.OrderID,
+			VisitedAt: trip.CompletedAt,
+			Visits:    1,
+		}
+		for _, place := range places {
+			if utils.CalculateDistanceHaversine(place.Latitude, place.Longitude, trip.DropoffLatitude, trip.DropoffLongitude) <= radiusKm {
+				destination.PlaceID = place.PlaceID
+				destination.PlaceType = place.Type
+				break
+			}
+		}
+		result = append(result, destination)
+	}
+	return result
+}
+
+// ListSavedPlaces 获取乘客的家、公司和自定义地点
+func (s *SavedPlaceService) ListSavedPlaces(userID string) (*protocol.SavedPlacesResponse, protocol.ErrorCode) {
+	addresses, err := models.ListUserSavedPlaces(userID)
+	if err != nil {
+		log.Get().Errorf("获取用户 %s 常用地点失败: %v", userID, err)
+		return nil, protocol.DatabaseError
+	}
+	resp := &protocol.SavedPlacesResponse{Custom: []*protocol.SavedPlace{}}
+	for _, address := range addresses {
+		place := address.Protocol()
+		switch place.Type {
+		case protocol.SavedPlaceTypeHome:
+			if resp.Home == nil {
+				resp.Home = place
+			}
+		case protocol.SavedPlaceTypeWork:
+			if resp.Work == nil {
+				resp.Work = place
+			}
+		default:
+			resp.Custom = append(resp.Custom, place)
+		}
+	}
+	return resp, protocol.Success
+}
+
+// SavePlace 新增或修改常用地点；家和公司每个用户只保留一个，重复保存时覆盖原地址
+func (s *SavedPlaceService) SavePlace(req *protocol.SavePlaceRequest) (*protocol.SavedPlace, protocol.ErrorCode) {
+	label, errCode := validateSavedPlace(req)
+	if errCode != protocol.Success {
+		return nil, errCode
+	}
+
+	var address *models.UserAddress
+	if req.PlaceID != "" {
+		address = models.GetUserSavedPlace(req.UserID, req.PlaceID)
+		if address == nil {
+			return nil, protocol.SavedPlaceNotFound
+		}
+	}
+
+	// 家和公司唯一：新增时直接覆盖已有地址，修改为家或公司时移除原来的那一个
+	var replaced *models.UserAddress
+	if req.Type != protocol.SavedPlaceTypeCustom {
+		if existing := models.GetUserSavedPlaceByType(req.UserID, req.Type); existing != nil {
+			if address == nil {
+				address = existing
+			} else if existing.UserAddressID != address.UserAddressID {
+				replaced = existing
+			}
+		}
+	}
+	if req.Type == protocol.SavedPlaceTypeCustom && (address == nil || address.GetAddressType() != protocol.SavedPlaceTypeCustom) {
+		if models.CountUserSavedPlaces(req.UserID, models.AddressTypeCustom) >= int64(config.GetSavedPlaceConfig().MaxCustomPlaces) {
+			return nil, protocol.SavedPlaceLimitExceeded
+		}
+	}
+
+	if address == nil {
+		address = models.NewUserAddressV2()
+		address.SetUserID(req.UserID)
+	}
+	iconType, priority := models.IconTypeCustom, 100
+	switch req.Type {
+	case protocol.SavedPlaceTypeHome:
+		iconType, priority = models.IconTypeHome, 1
+	case protocol.SavedPlaceTypeWork:
+		iconType, priority = models.IconTypeWork, 2
+	}
+	address.SetAddressType(req.Type).
+		SetLabel(label).
+		SetLocation(req.Latitude, req.Longitude).
+		SetFormattedAddress(strings.TrimSpace(req.Address)).
+		SetBuildingInfo(req.BuildingName, req.Floor, req.Unit).
+		SetAccessInstructions(req.AccessInstructions).
+		SetFavorite(true).
+		SetPersonalization(label, iconType, "", priority)
+	address.UpdateCompletionRate()
+	address.UpdateQualityScore()
+
+	db := models.GetDB()
+	if replaced != nil {
+		if err := db.Model(replaced).Update("status", models.AddressStatusDeleted).Error; err != nil {
+			log.Get().Errorf("移除用户 %s 原%s地址 %s 失败: %v", req.UserID, req.Type, replaced.UserAddressID, err)
+			return nil, protocol.DatabaseError
+		}
+	}
+	if err := db.Save(address).Error; err != nil {
+		log.Get().Errorf("保存用户 %s 常用地点失败: %v", req.UserID, err)
+		return nil, protocol.DatabaseError
+	}
+	return address.Protocol(), protocol.Success
+}
+
+// DeletePlace 删除常用地点（软删除，历史订单不受影响）
+func (s *SavedPlaceService) DeletePlace(req *protocol.PlaceIDRequest) protocol.ErrorCode {
+	address := models.GetUserSavedPlace(req.UserID, req.PlaceID)
+	if address == nil {
+		return protocol.SavedPlaceNotFound
+	}
+	if err := models.GetDB().Model(address).Update("status", models.AddressStatusDeleted).Error; err != nil {
+		log.Get().Errorf("删除常用地点 %s 失败: %v", req.PlaceID, err)
+		return protocol.DatabaseError
+	}
+	return protocol.Success
+}
+
+// GetRecentDestinations 从最近已完成的网约车行程中统计去过的目的地
+func (s *SavedPlaceService) GetRecentDestinations(userID string) ([]*protocol.RecentDestination, protocol.ErrorCode) {
+	cfg := config.GetSavedPlaceConfig()
+	var trips []*recentTrip
+	err := models.GetDB().Table(models.Order{}.TableName()+" AS o").
+		Select("o.order_id, r.dropoff_latitude, r.dropoff_longitude, r.dropoff_address, r.dropoff_landmark, o.completed_at").
+		Joins("JOIN "+models.RideOrder{}.TableName()+" AS r ON r.order_id = o.order_id").
+		Where("o.user_id = ? AND o.order_type = ? AND o.status = ?", userID, protocol.RideOrder, protocol.StatusCompleted).
+		Order("o.completed_at DESC").
+		Limit(cfg.RecentScanOrders).
+		Scan(&trips).Error
+	if err != nil {
+		log.Get().Errorf("获取用户 %s 最近目的地失败: %v", userID, err)
+		return nil, protocol.DatabaseError
+	}
+
+	addresses, err := models.ListUserSavedPlaces(userID)
+	if err != nil {
+		log.Get().Errorf("获取用户 %s 常用地点失败: %v", userID, err)
+		return nil, protocol.DatabaseError
+	}
+	places := make([]*protocol.SavedPlace, 0, len(addresses))
+	for _, address := range addresses {
+		places = append(places, address.Protocol())
+	}
+	return mergeRecentDestinations(trips, places, cfg.MergeRadiusMeters, cfg.RecentLimit), protocol.Success
+}
+
+// ResolveEstimatePlaces 预估请求传了常用地点ID时用地点的坐标和地址替换请求中的上下车点，并校验坐标
+func (s *SavedPlaceService) ResolveEstimatePlaces(req *protocol.EstimateRequest) protocol.ErrorCode {
+	if req.PickupPlaceID != "" {
+		address := models.GetUserSavedPlace(req.UserID, req.PickupPlaceID)
+		if address == nil {
+			return protocol.SavedPlaceNotFound
+		}
+		place := address.Protocol()
+		req.PickupLatitude, req.PickupLongitude = place.Latitude, place.Longitude
+		if req.PickupAddress == "" {
+			req.PickupAddress = place.Address
+		}
+		if req.PickupLandmark == "" {
+			req.PickupLandmark = placeLandmark(place)
+		}
+	}
+	if req.DropoffPlaceID != "" {
+		address := models.GetUserSavedPlace(req.UserID, req.DropoffPlaceID)
+		if address == nil {
+			return protocol.SavedPlaceNotFound
+		}
+		place := address.Protocol()
+		req.DropoffLatitude, req.DropoffLongitude = place.Latitude, place.Longitude
+		if req.DropoffAddress == "" {
+			req.DropoffAddress = place.Address
+		}
+		if req.DropoffLandmark == "" {
+			req.DropoffLandmark = placeLandmark(place)
+		}
+	}
+	if !validCoordinate(req.PickupLatitude, req.PickupLongitude) || !validCoordinate(req.DropoffLatitude, req.DropoffLongitude) {
+		return protocol.InvalidLocation
+	}
+	return protocol.Success
+}
+
+// CheckOrderPlaces 下单时传入的常用地点须与价格快照中的上下车点一致，返回本次下单使用的地点ID
+func (s *SavedPlaceService) CheckOrderPlaces(req *protocol.CreateOrderRequest, price *models.PriceSnapshot) ([]string, protocol.ErrorCode) {
+	meta := price.GetMetadata()
+	checks := []struct {
+		placeID  string
+		snapshot string
+		lat, lng float64
+	}{
+		{req.PickupPlaceID, meta.Get("pickup_place_id"), meta.GetFloat64("pickup_latitude"), meta.GetFloat64("pickup_longitude")},
+		{req.DropoffPlaceID, meta.Get("dropoff_place_id"), meta.GetFloat64("dropoff_latitude"), meta.GetFloat64("dropoff_longitude")},
+	}
+	placeIDs := []string{}
+	for _, item := range checks {
+		if item.placeID == "" {
+			if item.snapshot != "" {
+				placeIDs = append(placeIDs, item.snapshot)
+			}
+			continue
+		}
+		address := models.GetUserSavedPlace(req.UserID, item.placeID)
+		if address == nil {
+			return nil, protocol.SavedPlaceNotFound
+		}
+		if !placeAtLocation(address.Protocol(), item.lat, item.lng) {
+			return nil, protocol.SavedPlaceMismatch
+		}
+		placeIDs = append(placeIDs, item.placeID)
+	}
+	return placeIDs, protocol.Success
+}
+
+// RecordPlaceUsage 下单成功后累计常用地点使用次数
+func (s *SavedPlaceService) RecordPlaceUsage(placeIDs []string) {
+	for _, placeID := range placeIDs {
+		if err := models.IncrementUserAddressUsage(placeID); err != nil {
+			log.Get().Warnf("更新常用地点 %s 使用次数失败: %v", placeID, err)
+		}
+	}
+}
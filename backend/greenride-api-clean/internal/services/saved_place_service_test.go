@@ -0,0 +1,74 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"greenride/internal/protocol"
+)
+
+func TestValidateSavedPlace(t *testing.T) {
+	base := func(placeType, label string) *protocol.SavePlaceRequest {
+		return &protocol.SavePlaceRequest{Type: placeType, Label: label, Latitude: -1.9441, Longitude: 30.0619, Address: "KG 7 Ave, Kigali"}
+	}
+
+	label, errCode := validateSavedPlace(base(protocol.SavedPlaceTypeHome, "  "))
+	if errCode != protocol.Success || label != "Home" {
+		t.Errorf("home without label: got %q %s, want Home", label, errCode)
+	}
+	if label, errCode = validateSavedPlace(base(protocol.SavedPlaceTypeCustom, " Gym ")); errCode != protocol.Success || label != "Gym" {
+		t.Errorf("custom label: got %q %s, want Gym", label, errCode)
+	}
+
+	invalid := []*protocol.SavePlaceRequest{
+		base(protocol.SavedPlaceTypeCustom, ""),                                             // 自定义地点必须有名称
+		base("school", "School"),                                                            // 不支持的类型
+		base(protocol.SavedPlaceTypeCustom, strings.Repeat("a", 101)),                       // 名称过长
+		{Type: protocol.SavedPlaceTypeWork, Address: "Kigali"},                              // 未填写坐标
+		{Type: protocol.SavedPlaceTypeWork, Latitude: 95, Longitude: 30, Address: "Kigali"}, // 纬度越界
+		{Type: protocol.SavedPlaceTypeWork, Latitude: -1.94, Longitude: 30.06},              // 没有地址
+	}
+	for i, req := range invalid {
+		if _, errCode := validateSavedPlace(req); errCode != protocol.InvalidSavedPlace {
+			t.Errorf("case %d: got %s, want InvalidSavedPlace", i, errCode)
+		}
+	}
+}
+
+func TestMergeRecentDestinations(t *testing.T) {
+	trips := []*recentTrip{
+		{OrderID: "R4", DropoffLatitude: -1.9500, DropoffLongitude: 30.0600, DropoffAddress: "Office", CompletedAt: 4000},
+		{OrderID: "R3", DropoffLatitude: -1.9700, DropoffLongitude: 30.1000, DropoffAddress: "Market", CompletedAt: 3000},
+		{OrderID: "R2", DropoffLatitude: -1.9503, DropoffLongitude: 30.0602, DropoffAddress: "Office gate", CompletedAt: 2000}, // 距R4约40米
+		{OrderID: "R1", DropoffLatitude: 0, DropoffLongitude: 0, CompletedAt: 1000},                                            // 坐标缺失
+		{OrderID: "R0", DropoffLatitude: -1.9000, DropoffLongitude: 30.2000, DropoffAddress: "Airport", CompletedAt: 500},
+	}
+	places := []*protocol.SavedPlace{
+		{PlaceID: "A1", Type: protocol.SavedPlaceTypeWork, Latitude: -1.9501, Longitude: 30.0601},
+	}
+
+	got := mergeRecentDestinations(trips, places, 100, 2)
+	if len(got) != 2 {
+		t.Fatalf("got %d destinations, want 2", len(got))
+	}
+	office := got[0]
+	if office.OrderID != "R4" || office.Visits != 2 || office.VisitedAt != 4000 || office.Address != "Office" {
+		t.Errorf("office: got %+v, want latest trip R4 with 2 visits", office)
+	}
+	if office.PlaceID != "A1" || office.PlaceType != protocol.SavedPlaceTypeWork {
+		t.Errorf("office should match saved place A1, got %q %q", office.PlaceID, office.PlaceType)
+	}
+	if got[1].OrderID != "R3" || got[1].Visits != 1 || got[1].PlaceID != "" {
+		t.Errorf("market: got %+v", got[1])
+	}
+}
+
+func TestPlaceAtLocation(t *testing.T) {
+	place := &protocol.SavedPlace{Latitude: -1.9441, Longitude: 30.0619}
+	if !placeAtLocation(place, -1.9441, 30.0619) {
+		t.Error("same coordinates should match")
+	}
+	if placeAtLocation(place, -1.9442, 30.0619) {
+		t.Error("different coordinates should not match")
+	}
+}
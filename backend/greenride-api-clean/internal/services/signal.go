@@ -9,6 +9,9 @@ import (
 
 // SendSignal 发送信号到Redis（通用方法）
 func SendSignal(signalType string, bizID string) error {
+	if models.GetRedis() == nil {
+		return nil // 开发环境可能没有Redis
+	}
 	return models.GetRedis().SAdd(
 		context.Background(),
 		signalType,
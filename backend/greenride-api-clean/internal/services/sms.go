@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"strings"
 	"sync"
-	"time"
 
 	"greenride/internal/config"
 	"greenride/internal/log"
@@ -80,11 +79,7 @@ func GetSMSServiceHandler() SmsMessage {
 // SendSMS sends an SMS message
 // from: optional phone number(s) to send from (comma-separated for priority list)
 func (s *SMSService) SendSMS(to string, message string, from string) error {
-	if s.Handler == nil {
-		return fmt.Errorf("SMS service not properly initialized")
-	}
-
-	// Create a Message object to pass to SendSmsMessage
+	// Create a Message object to pass to SendMessage
 	msg := &Message{
 		Type:     protocol.MsgTypeGeneric, // Use generic message type
 		Channels: []string{protocol.MsgChannelSms},
@@ -95,13 +90,10 @@ func (s *SMSService) SendSMS(to string, message string, from string) error {
 		},
 	}
 
-	// Send via SMS service handler
-	err := s.Handler.SendSmsMessage(msg)
-	if err != nil {
+	if err := s.SendMessage(msg); err != nil {
 		log.Get().Errorf("Failed to send SMS: %v", err)
 		return err
 	}
-
 	return nil
 }
 
@@ -111,49 +103,10 @@ func (s *SMSService) ServiceName() string {
 }
 
 // SendMessage sends an SMS using a Message object
+// Provider selection and failover (by country and priority) are handled by the SMS router
 func (s *SMSService) SendMessage(message *Message) error {
 	if message == nil {
 		return fmt.Errorf("message cannot be nil")
 	}
-
-	// Resolve handler defensively in case service init didn't run as expected.
-	primary := s.Handler
-	if primary == nil {
-		primary = GetSMSServiceHandler()
-	}
-	if primary == nil {
-		return fmt.Errorf("SMS service not properly initialized")
-	}
-
-	cfg := config.Get().SMS
-	// OTP failover policy:
-	// - If primary is Twilio and this is a verify_code message, attempt Twilio (fast-timeout client).
-	// - If Twilio errors (including timeout), fall back to InnoPaaS.
-	if cfg != nil && cfg.ServiceName == "twilio" && message.Type == protocol.MsgTypeVerifyCode {
-		start := time.Now()
-		err := primary.SendSmsMessage(message)
-		elapsed := time.Since(start)
-		if err == nil {
-			log.Get().Infof("OTP sent via twilio in %s", elapsed)
-			return nil
-		}
-
-		log.Get().Warnf("[SMS] OTP via Twilio FAILED after %s: %v — attempting InnoPaaS fallback", elapsed, err)
-		fallback := GetInnoPaaSService()
-		if fallback == nil {
-			log.Get().Error("[SMS] InnoPaaS fallback is nil — no fallback available, OTP will NOT be delivered")
-			return err
-		}
-		startFb := time.Now()
-		fbErr := fallback.SendSmsMessage(message)
-		fbElapsed := time.Since(startFb)
-		if fbErr == nil {
-			log.Get().Infof("OTP sent via innopaas in %s (after twilio failure)", fbElapsed)
-			return nil
-		}
-		log.Get().Errorf("OTP send via innopaas also failed after %s: %v", fbElapsed, fbErr)
-		return fmt.Errorf("twilio otp failed: %v; innopaas otp failed: %v", err, fbErr)
-	}
-
-	return primary.SendSmsMessage(message)
+	return GetSMSRouter().Send(message)
 }
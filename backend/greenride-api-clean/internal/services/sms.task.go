@@ -0,0 +1,46 @@
+package services
+
+import (
+	"context"
+
+	"greenride/internal/log"
+	"greenride/internal/models"
+	"greenride/internal/protocol"
+	"greenride/internal/task"
+)
+
+const (
+	// 短信回执超时切换任务常量
+	TaskSMSReceiptFailover = "sms_receipt_failover"
+)
+
+// InitSMSTaskHandlers 初始化短信相关任务处理器
+func InitSMSTaskHandlers() {
+	task.RegisterHandler(TaskSMSReceiptFailover, SMSReceiptFailoverHandler)
+
+	smsReceiptFailoverTask := &models.Task{
+		TaskID:     "sms_receipt_failover_scheduler",
+		Name:       "短信回执超时切换服务商",
+		Type:       "sms",
+		HandlerKey: TaskSMSReceiptFailover,
+		Cron:       "* * * * *", // 每分钟执行一次
+		Status:     protocol.TaskStatusEnabled,
+		MaxRetries: 1,
+		Timeout:    60,
+		Remark:     "超时未收到送达回执的短信标记为过期，并切换下一家服务商重发",
+	}
+	task.InitTasks([]*models.Task{smsReceiptFailoverTask})
+}
+
+// SMSReceiptFailoverHandler 处理回执超时的短信
+func SMSReceiptFailoverHandler(ctx context.Context, params protocol.MapData) error {
+	count, err := GetSMSRouter().CheckPendingReceipts(ctx)
+	if err != nil {
+		log.Get().Errorf("短信回执超时检查失败: %v", err)
+		return err
+	}
+	if count > 0 {
+		log.Get().Infof("短信回执超时处理完成，共 %d 条", count)
+	}
+	return nil
+}
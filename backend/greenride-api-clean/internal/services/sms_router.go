@@ -0,0 +1,450 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"greenride/internal/config"
+	"greenride/internal/log"
+	"greenride/internal/models"
+	"greenride/internal/protocol"
+	"greenride/internal/utils"
+)
+
+const (
+	smsPayloadTTL          = 2 * time.Hour // 短信内容保留时长，用于回执超时后切换服务商重发
+	smsReceiptBatchSize    = 100
+	smsDeliveryStatusSent  = "sent"
+	smsDeliveryStatusDeliv = "delivered"
+	smsParamCode           = "code"     // 验证码
+	smsParamCodeKey        = "code_key" // 验证码缓存键，重发时读取验证码
+)
+
+// SmsProvider 可返回服务商消息ID的短信服务，用于投递跟踪
+type SmsProvider interface {
+	SmsMessage
+	SendTrackedSmsMessage(message *Message) (string, error)
+}
+
+// otpOnlySmsProvider 只能发送验证码的服务商（如InnoPaaS OTP接口），不参与其他类型短信的路由
+type otpOnlySmsProvider interface {
+	OTPOnly() bool
+}
+
+// smsProviderSupports 服务商是否能发送该类型的短信
+func smsProviderSupports(provider SmsProvider, messageType string) bool {
+	if p, ok := provider.(otpOnlySmsProvider); ok && p.OTPOnly() {
+		return messageType == protocol.MsgTypeVerifyCode
+	}
+	return true
+}
+
+// smsStatusCount 按服务商和状态的投递计数
+type smsStatusCount struct {
+	Provider string
+	Status   string
+	Total    int64
+}
+
+// smsDeliveryStore 短信投递记录存储
+type smsDeliveryStore interface {
+	Create(delivery *models.SMSDelivery) error
+	// Transition 仅当记录处于fromStatus时更新，返回是否更新成功
+	Transition(deliveryID, fromStatus string, values *models.SMSDeliveryValues) (bool, error)
+	FindByProviderMessageID(provider, providerMessageID string) (*models.SMSDelivery, error)
+	ListReceiptOverdue(now int64, limit int) ([]*models.SMSDelivery, error)
+	CountByProviderStatus(since int64) ([]*smsStatusCount, error)
+	SavePayload(requestID string, message *Message) error
+	LoadPayload(requestID string) (*Message, error)
+}
+
+// SMSRouter 短信服务商路由：按国家区号和优先级选择服务商，提交失败或回执超时时切换下一家
+type SMSRouter struct {
+	cfg       *config.SMSConfig
+	store     smsDeliveryStore
+	providers map[string]SmsProvider
+	now       func() time.Time
+}
+
+var (
+	smsRouterInstance *SMSRouter
+	smsRouterOnce     sync.Once
+)
+
+// GetSMSRouter 获取短信路由单例
+func GetSMSRouter() *SMSRouter {
+	smsRouterOnce.Do(func() {
+		providers := []SmsProvider{GetInnoPaaSService()}
+		// 未配置Twilio时返回的是nil指针，不能直接作为接口注册，否则切换到twilio时会空指针
+		if twilio := GetTwilioService(); twilio != nil {
+			providers = append(providers, twilio)
+		}
+		smsRouterInstance = NewSMSRouter(config.Get().SMS, &dbSMSDeliveryStore{}, providers...)
+	})
+	return smsRouterInstance
+}
+
+// NewSMSRouter 创建短信路由
+func NewSMSRouter(cfg *config.SMSConfig, store smsDeliveryStore, providers ...SmsProvider) *SMSRouter {
+	if cfg == nil {
+		cfg = &config.SMSConfig{}
+		cfg.Validate()
+	}
+	r := &SMSRouter{
+		cfg:       cfg,
+		store:     store,
+		providers: make(map[string]SmsProvider),
+		now:       time.Now,
+	}
+	for _, p := range providers {
+		r.RegisterProvider(p)
+	}
+	return r
+}
+
+// RegisterProvider 注册短信服务商
+func (r *SMSRouter) RegisterProvider(provider SmsProvider) {
+	if provider == nil {
+		return
+	}
+	r.providers[provider.ServiceName()] = provider
+}
+
+// Candidates 按优先级返回号码可用且支持该短信类型的服务商
+func (r *SMSRouter) Candidates(phone, messageType string) []SmsProvider {
+	var result []SmsProvider
+	for _, name := range r.cfg.GetRouteProviders(callingCodeOf(phone)) {
+		if p, ok := r.providers[name]; ok && smsProviderSupports(p, messageType) {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// Send 发送短信，提交失败时按优先级切换服务商
+func (r *SMSRouter) Send(message *Message) error {
+	if message == nil {
+		return fmt.Errorf("message cannot be nil")
+	}
+	phone := fmt.Sprintf("%v", message.Params["to"])
+	candidates := r.Candidates(phone, message.Type)
+	if len(candidates) == 0 {
+		return fmt.Errorf("no SMS provider configured for %s (%s)", utils.MaskPhone(phone), message.Type)
+	}
+
+	requestID := utils.GenerateID()
+	if err := r.store.SavePayload(requestID, message); err != nil {
+		log.Get().Warnf("[SMS] save payload for %s failed, receipt failover disabled: %v", requestID, err)
+	}
+	return r.sendVia(requestID, phone, message, candidates, 1)
+}
+
+// sendVia 依次尝试服务商，首个提交成功即返回
+func (r *SMSRouter) sendVia(requestID, phone string, message *Message, providers []SmsProvider, attempt int) error {
+	var errs []string
+	for _, provider := range providers {
+		name := provider.ServiceName()
+		delivery := models.NewSMSDelivery()
+		delivery.RequestID = utils.StringPtr(requestID)
+		delivery.Provider = utils.StringPtr(name)
+		delivery.Phone = utils.StringPtr(phone)
+		delivery.CountryCode = utils.StringPtr(callingCodeOf(phone))
+		delivery.MessageType = utils.StringPtr(message.Type)
+		delivery.Attempt = utils.IntPtr(attempt)
+		if err := r.store.Create(delivery); err != nil {
+			log.Get().Warnf("[SMS] create delivery record failed: %v", err)
+		}
+
+		start := r.now()
+		messageID, err := provider.SendTrackedSmsMessage(message)
+		now := r.now()
+		if err != nil {
+			log.Get().Warnf("[SMS] send via %s failed after %s (attempt %d): %v", name, now.Sub(start), attempt, err)
+			r.transition(delivery.DeliveryID, protocol.StatusPending, &models.SMSDeliveryValues{
+				Status:       utils.StringPtr(protocol.StatusFailed),
+				ErrorMessage: utils.StringPtr(err.Error()),
+				FailedAt:     utils.Int64Ptr(now.UnixMilli()),
+			})
+			errs = append(errs, fmt.Sprintf("%s: %v", name, err))
+			attempt++
+			continue
+		}
+
+		values := &models.SMSDeliveryValues{
+			Status:            utils.StringPtr(smsDeliveryStatusSent),
+			ProviderMessageID: utils.StringPtr(messageID),
+			SentAt:            utils.Int64Ptr(now.UnixMilli()),
+		}
+		if messageID != "" && r.cfg.IsReceiptTracked(name) {
+			values.ReceiptDeadline = utils.Int64Ptr(now.Add(time.Duration(r.cfg.ReceiptTimeout) * time.Second).UnixMilli())
+		}
+		r.transition(delivery.DeliveryID, protocol.StatusPending, values)
+		log.Get().Infof("[SMS] %s sent via %s in %s (attempt %d)", message.Type, name, now.Sub(start), attempt)
+		if message.Type == protocol.MsgTypeVerifyCode {
+			recordOTPSpend(name)
+		}
+		return nil
+	}
+	return fmt.Errorf("all SMS providers failed: %s", strings.Join(errs, "; "))
+}
+
+// HandleReceipt 处理服务商送达回执，回执失败时切换下一家服务商重发
+func (r *SMSRouter) HandleReceipt(provider, providerMessageID, rawStatus, errMsg string) error {
+	if providerMessageID == "" {
+		return fmt.Errorf("missing provider message id")
+	}
+	status := normalizeSMSReceiptStatus(rawStatus)
+	if status == "" {
+		return nil // 中间状态（queued/sending等）不处理
+	}
+	delivery, err := r.store.FindByProviderMessageID(provider, providerMessageID)
+	if err != nil {
+		return err
+	}
+	if delivery == nil {
+		log.Get().Warnf("[SMS] receipt for unknown %s message %s", provider, providerMessageID)
+		return nil
+	}
+
+	now := r.now().UnixMilli()
+	values := &models.SMSDeliveryValues{
+		Status:          utils.StringPtr(status),
+		ReceiptDeadline: utils.Int64Ptr(0),
+	}
+	if status == smsDeliveryStatusDeliv {
+		values.DeliveredAt = utils.Int64Ptr(now)
+	} else {
+		values.FailedAt = utils.Int64Ptr(now)
+		if errMsg == "" {
+			errMsg = rawStatus
+		}
+		values.ErrorMessage = utils.StringPtr(errMsg)
+	}
+
+	// 只有仍在等待回执的记录才会触发切换，避免与超时任务重复重发
+	updated, err := r.store.Transition(delivery.DeliveryID, smsDeliveryStatusSent, values)
+	if err != nil || !updated {
+		if !updated && status == smsDeliveryStatusDeliv && delivery.GetStatus() == protocol.StatusExpired {
+			// 超时后才到达的送达回执仍然记录，便于统计
+			_, err = r.store.Transition(delivery.DeliveryID, protocol.StatusExpired, values)
+		}
+		return err
+	}
+	if status == protocol.StatusFailed {
+		return r.failover(delivery)
+	}
+	return nil
+}
+
+// CheckPendingReceipts 将回执超时的投递标记为过期，并切换下一家服务商重发
+func (r *SMSRouter) CheckPendingReceipts(ctx context.Context) (int, error) {
+	deliveries, err := r.store.ListReceiptOverdue(r.now().UnixMilli(), smsReceiptBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, delivery := range deliveries {
+		if ctx.Err() != nil {
+			return count, ctx.Err()
+		}
+		updated, err := r.store.Transition(delivery.DeliveryID, smsDeliveryStatusSent, &models.SMSDeliveryValues{
+			Status:          utils.StringPtr(protocol.StatusExpired),
+			ErrorMessage:    utils.StringPtr("delivery receipt timeout"),
+			ReceiptDeadline: utils.Int64Ptr(0),
+		})
+		if err != nil || !updated {
+			continue
+		}
+		count++
+		if err := r.failover(delivery); err != nil {
+			log.Get().Warnf("[SMS] receipt timeout failover for %s failed: %v", delivery.GetRequestID(), err)
+		}
+	}
+	return count, nil
+}
+
+// failover 使用路由中排在该服务商之后的服务商重发
+func (r *SMSRouter) failover(delivery *models.SMSDelivery) error {
+	message, err := r.store.LoadPayload(delivery.GetRequestID())
+	if err != nil || message == nil {
+		return fmt.Errorf("SMS payload for %s not available: %v", delivery.GetRequestID(), err)
+	}
+	phone := fmt.Sprintf("%v", message.Params["to"])
+	candidates := r.Candidates(phone, message.Type)
+	for i, p := range candidates {
+		if p.ServiceName() == delivery.GetProvider() {
+			if i+1 >= len(candidates) {
+				break
+			}
+			log.Get().Warnf("[SMS] %s did not deliver %s, failing over", delivery.GetProvider(), delivery.GetRequestID())
+			return r.sendVia(delivery.GetRequestID(), phone, message, candidates[i+1:], delivery.GetAttempt()+1)
+		}
+	}
+	return fmt.Errorf("no fallback SMS provider after %s", delivery.GetProvider())
+}
+
+// GetProviderStats 获取最近days天各服务商投递统计
+func (r *SMSRouter) GetProviderStats(days int) ([]*protocol.SMSProviderStats, error) {
+	if days <= 0 {
+		days = 7
+	}
+	since := r.now().AddDate(0, 0, -days).UnixMilli()
+	counts, err := r.store.CountByProviderStatus(since)
+	if err != nil {
+		return nil, err
+	}
+
+	byProvider := make(map[string]*protocol.SMSProviderStats)
+	var result []*protocol.SMSProviderStats
+	for _, c := range counts {
+		stats, ok := byProvider[c.Provider]
+		if !ok {
+			stats = &protocol.SMSProviderStats{Provider: c.Provider}
+			byProvider[c.Provider] = stats
+			result = append(result, stats)
+		}
+		stats.Total += c.Total
+		switch c.Status {
+		case smsDeliveryStatusSent:
+			stats.Sent += c.Total
+		case smsDeliveryStatusDeliv:
+			stats.Delivered += c.Total
+		case protocol.StatusFailed:
+			stats.Failed += c.Total
+		case protocol.StatusExpired:
+			stats.Expired += c.Total
+		}
+	}
+	for _, stats := range result {
+		if stats.Total > 0 {
+			stats.SuccessRate = smsRate(stats.Total-stats.Failed, stats.Total)
+		}
+		if settled := stats.Delivered + stats.Failed + stats.Expired; settled > 0 {
+			stats.DeliveryRate = smsRate(stats.Delivered, settled)
+		}
+	}
+	return result, nil
+}
+
+func (r *SMSRouter) transition(deliveryID, fromStatus string, values *models.SMSDeliveryValues) {
+	if _, err := r.store.Transition(deliveryID, fromStatus, values); err != nil {
+		log.Get().Warnf("[SMS] update delivery %s failed: %v", deliveryID, err)
+	}
+}
+
+func smsRate(part, total int64) float64 {
+	return math.Round(float64(part)/float64(total)*10000) / 100
+}
+
+// normalizeSMSReceiptStatus 将服务商回执状态统一为delivered/failed，中间状态返回空
+func normalizeSMSReceiptStatus(raw string) string {
+	switch strings.ToUpper(strings.TrimSpace(raw)) {
+	case "DELIVRD", "DELIVERED", "SUCCESS", "READ":
+		return smsDeliveryStatusDeliv
+	case "UNDELIV", "UNDELIVERED", "FAILED", "FAIL", "REJECTD", "REJECTED", "EXPIRED", "CANCELED":
+		return protocol.StatusFailed
+	}
+	return ""
+}
+
+// dbSMSDeliveryStore 数据库存储投递记录，Redis保存重发所需的短信内容
+type dbSMSDeliveryStore struct{}
+
+func (s *dbSMSDeliveryStore) Create(delivery *models.SMSDelivery) error {
+	return models.GetDB().Create(delivery).Error
+}
+
+func (s *dbSMSDeliveryStore) Transition(deliveryID, fromStatus string, values *models.SMSDeliveryValues) (bool, error) {
+	result := models.GetDB().Model(&models.SMSDelivery{}).
+		Where("delivery_id = ? AND status = ?", deliveryID, fromStatus).
+		Updates(values)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (s *dbSMSDeliveryStore) FindByProviderMessageID(provider, providerMessageID string) (*models.SMSDelivery, error) {
+	var deliveries []*models.SMSDelivery
+	query := models.GetDB().Where("provider_message_id = ?", providerMessageID)
+	if provider != "" {
+		query = query.Where("provider = ?", provider)
+	}
+	if err := query.Order("id DESC").Limit(1).Find(&deliveries).Error; err != nil {
+		return nil, err
+	}
+	if len(deliveries) == 0 {
+		return nil, nil
+	}
+	return deliveries[0], nil
+}
+
+func (s *dbSMSDeliveryStore) ListReceiptOverdue(now int64, limit int) ([]*models.SMSDelivery, error) {
+	var deliveries []*models.SMSDelivery
+	err := models.GetDB().
+		Where("status = ? AND receipt_deadline > 0 AND receipt_deadline <= ?", smsDeliveryStatusSent, now).
+		Order("receipt_deadline ASC").Limit(limit).Find(&deliveries).Error
+	return deliveries, err
+}
+
+func (s *dbSMSDeliveryStore) CountByProviderStatus(since int64) ([]*smsStatusCount, error) {
+	var counts []*smsStatusCount
+	err := models.GetDB().Model(&models.SMSDelivery{}).
+		Select("provider, status, COUNT(*) AS total").
+		Where("created_at >= ?", since).
+		Group("provider, status").
+		Scan(&counts).Error
+	return counts, err
+}
+
+func (s *dbSMSDeliveryStore) SavePayload(requestID string, message *Message) error {
+	return models.SetObjectCache(smsPayloadKey(requestID), smsPayloadForResend(message), smsPayloadTTL)
+}
+
+func (s *dbSMSDeliveryStore) LoadPayload(requestID string) (*Message, error) {
+	message, err := models.GetObjectFromCache[Message](smsPayloadKey(requestID))
+	if err != nil || message == nil {
+		return message, err
+	}
+	if err := restoreSMSPayloadCode(message, models.GetCache); err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+// smsPayloadForResend 重发用的短信内容，不保存明文验证码，只保留验证码的缓存键
+func smsPayloadForResend(message *Message) *Message {
+	payload := *message
+	payload.Params = make(map[string]any, len(message.Params))
+	for key, value := range message.Params {
+		if key != smsParamCode {
+			payload.Params[key] = value
+		}
+	}
+	return &payload
+}
+
+// restoreSMSPayloadCode 重发验证码短信时从缓存读取验证码，验证码已过期或已使用时不再重发
+func restoreSMSPayloadCode(message *Message, lookup func(key string) (string, error)) error {
+	if message.Type != protocol.MsgTypeVerifyCode {
+		return nil
+	}
+	codeKey, _ := message.Params[smsParamCodeKey].(string)
+	if codeKey == "" {
+		return fmt.Errorf("verification code reference missing")
+	}
+	code, err := lookup(codeKey)
+	if err != nil || code == "" {
+		return fmt.Errorf("verification code no longer valid")
+	}
+	if message.Params == nil {
+		message.Params = make(map[string]any)
+	}
+	message.Params[smsParamCode] = code
+	return nil
+}
+
+func smsPayloadKey(requestID string) string {
+	return models.FormatCacheKey("sms:payload:%s", requestID)
+}
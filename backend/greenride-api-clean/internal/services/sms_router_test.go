@@ -0,0 +1,343 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"greenride/internal/config"
+	"greenride/internal/models"
+	"greenride/internal/protocol"
+)
+
+// fakeSmsProvider 本地模拟短信服务商
+type fakeSmsProvider struct {
+	name    string
+	err     error
+	sent    []string
+	seq     int
+	otpOnly bool
+}
+
+func (f *fakeSmsProvider) ServiceName() string { return f.name }
+
+func (f *fakeSmsProvider) OTPOnly() bool { return f.otpOnly }
+
+func (f *fakeSmsProvider) SendSmsMessage(message *Message) error {
+	_, err := f.SendTrackedSmsMessage(message)
+	return err
+}
+
+func (f *fakeSmsProvider) SendTrackedSmsMessage(message *Message) (string, error) {
+	if f.err != nil {
+		return "", f.err
+	}
+	f.seq++
+	f.sent = append(f.sent, message.Params["to"].(string))
+	return f.name + "-" + strconv.Itoa(f.seq), nil
+}
+
+// memorySMSDeliveryStore 内存投递记录存储
+type memorySMSDeliveryStore struct {
+	deliveries []*models.SMSDelivery
+	payloads   map[string][]byte
+}
+
+func newMemorySMSDeliveryStore() *memorySMSDeliveryStore {
+	return &memorySMSDeliveryStore{payloads: make(map[string][]byte)}
+}
+
+func (m *memorySMSDeliveryStore) Create(delivery *models.SMSDelivery) error {
+	delivery.CreatedAt = time.Now().UnixMilli()
+	m.deliveries = append(m.deliveries, delivery)
+	return nil
+}
+
+func (m *memorySMSDeliveryStore) Transition(deliveryID, fromStatus string, values *models.SMSDeliveryValues) (bool, error) {
+	for _, d := range m.deliveries {
+		if d.DeliveryID != deliveryID || d.GetStatus() != fromStatus {
+			continue
+		}
+		data, _ := json.Marshal(values)
+		var patch map[string]any
+		_ = json.Unmarshal(data, &patch)
+		for k, v := range patch {
+			if v == nil {
+				delete(patch, k)
+			}
+		}
+		data, _ = json.Marshal(patch)
+		_ = json.Unmarshal(data, d.SMSDeliveryValues)
+		return true, nil
+	}
+	return false, nil
+}
+
+func (m *memorySMSDeliveryStore) FindByProviderMessageID(provider, providerMessageID string) (*models.SMSDelivery, error) {
+	for _, d := range m.deliveries {
+		if d.GetProvider() == provider && d.ProviderMessageID != nil && *d.ProviderMessageID == providerMessageID {
+			return d, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memorySMSDeliveryStore) ListReceiptOverdue(now int64, limit int) ([]*models.SMSDelivery, error) {
+	var result []*models.SMSDelivery
+	for _, d := range m.deliveries {
+		if d.GetStatus() == smsDeliveryStatusSent && *d.ReceiptDeadline > 0 && *d.ReceiptDeadline <= now {
+			result = append(result, d)
+		}
+	}
+	return result, nil
+}
+
+func (m *memorySMSDeliveryStore) CountByProviderStatus(since int64) ([]*smsStatusCount, error) {
+	var counts []*smsStatusCount
+	index := make(map[string]*smsStatusCount)
+	for _, d := range m.deliveries {
+		key := d.GetProvider() + "|" + d.GetStatus()
+		if c, ok := index[key]; ok {
+			c.Total++
+			continue
+		}
+		c := &smsStatusCount{Provider: d.GetProvider(), Status: d.GetStatus(), Total: 1}
+		index[key] = c
+		counts = append(counts, c)
+	}
+	return counts, nil
+}
+
+func (m *memorySMSDeliveryStore) SavePayload(requestID string, message *Message) error {
+	data, err := json.Marshal(message)
+	m.payloads[requestID] = data
+	return err
+}
+
+func (m *memorySMSDeliveryStore) LoadPayload(requestID string) (*Message, error) {
+	data, ok := m.payloads[requestID]
+	if !ok {
+		return nil, errors.New("payload not found")
+	}
+	var message Message
+	err := json.Unmarshal(data, &message)
+	return &message, err
+}
+
+func setupSMSRouterTest(t *testing.T, routes []config.SMSRouteConfig, providers ...SmsProvider) (*SMSRouter, *memorySMSDeliveryStore) {
+	t.Helper()
+	if config.Get() == nil {
+		config.Set(&config.Config{Log: &config.LogConfig{Path: os.TempDir()}})
+	}
+	cfg := &config.SMSConfig{ServiceName: "twilio", Routes: routes, ReceiptTimeout: 60}
+	cfg.Validate()
+	store := newMemorySMSDeliveryStore()
+	return NewSMSRouter(cfg, store, providers...), store
+}
+
+func newTestSMS(to string) *Message {
+	return &Message{
+		Type:     protocol.MsgTypeGeneric,
+		Channels: []string{protocol.MsgChannelSms},
+		Params:   map[string]any{"to": to, "content": "hello"},
+	}
+}
+
+func TestSMSRouterFailoverOnError(t *testing.T) {
+	twilio := &fakeSmsProvider{name: "twilio", err: errors.New("timeout")}
+	innopaas := &fakeSmsProvider{name: "innopaas"}
+	router, store := setupSMSRouterTest(t, nil, twilio, innopaas)
+
+	if err := router.Send(newTestSMS("+250784928786")); err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+	if len(innopaas.sent) != 1 {
+		t.Fatalf("expected fallback provider to send once, got %d", len(innopaas.sent))
+	}
+	if len(store.deliveries) != 2 {
+		t.Fatalf("expected 2 delivery records, got %d", len(store.deliveries))
+	}
+	if got := store.deliveries[0].GetStatus(); got != protocol.StatusFailed {
+		t.Fatalf("first attempt status: got=%s want=failed", got)
+	}
+	if got := store.deliveries[1].GetAttempt(); got != 2 {
+		t.Fatalf("second attempt number: got=%d want=2", got)
+	}
+
+	innopaas.err = errors.New("down")
+	if err := router.Send(newTestSMS("+250784928786")); err == nil {
+		t.Fatal("expected error when all providers fail")
+	}
+}
+
+func TestSMSRouterCountryRouting(t *testing.T) {
+	twilio := &fakeSmsProvider{name: "twilio"}
+	innopaas := &fakeSmsProvider{name: "innopaas"}
+	routes := []config.SMSRouteConfig{
+		{Country: "250", Providers: []string{"innopaas", "twilio"}},
+		{Country: "*", Providers: []string{"twilio"}},
+	}
+	router, _ := setupSMSRouterTest(t, routes, twilio, innopaas)
+
+	_ = router.Send(newTestSMS("+250784928786"))
+	_ = router.Send(newTestSMS("+14155550123"))
+	if len(innopaas.sent) != 1 || innopaas.sent[0] != "+250784928786" {
+		t.Fatalf("rwanda number should route to innopaas, got %v", innopaas.sent)
+	}
+	if len(twilio.sent) != 1 || twilio.sent[0] != "+14155550123" {
+		t.Fatalf("other numbers should use default route, got %v", twilio.sent)
+	}
+}
+
+func TestSMSRouterReceiptTimeoutFailover(t *testing.T) {
+	twilio := &fakeSmsProvider{name: "twilio"}
+	innopaas := &fakeSmsProvider{name: "innopaas"}
+	routes := []config.SMSRouteConfig{{Country: "*", Providers: []string{"innopaas", "twilio"}}}
+	router, store := setupSMSRouterTest(t, routes, twilio, innopaas)
+
+	now := time.Now()
+	router.now = func() time.Time { return now }
+	if err := router.Send(newTestSMS("+250784928786")); err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+
+	// 回执未超时，不切换
+	if count, _ := router.CheckPendingReceipts(context.Background()); count != 0 {
+		t.Fatalf("expected no overdue receipts, got %d", count)
+	}
+
+	now = now.Add(2 * time.Minute)
+	count, err := router.CheckPendingReceipts(context.Background())
+	if err != nil || count != 1 {
+		t.Fatalf("expected 1 overdue receipt, got %d (%v)", count, err)
+	}
+	if got := store.deliveries[0].GetStatus(); got != protocol.StatusExpired {
+		t.Fatalf("timed out delivery status: got=%s want=expired", got)
+	}
+	if len(twilio.sent) != 1 {
+		t.Fatalf("expected failover to twilio after receipt timeout, got %d sends", len(twilio.sent))
+	}
+
+	// 已处理的记录不会重复切换
+	if count, _ := router.CheckPendingReceipts(context.Background()); count != 0 {
+		t.Fatalf("expected no further failover, got %d", count)
+	}
+}
+
+func TestSMSRouterHandleReceipt(t *testing.T) {
+	twilio := &fakeSmsProvider{name: "twilio"}
+	innopaas := &fakeSmsProvider{name: "innopaas"}
+	routes := []config.SMSRouteConfig{{Country: "*", Providers: []string{"innopaas", "twilio"}}}
+	router, store := setupSMSRouterTest(t, routes, twilio, innopaas)
+
+	_ = router.Send(newTestSMS("+250784928786"))
+	_ = router.Send(newTestSMS("+250784928787"))
+
+	if err := router.HandleReceipt("innopaas", "innopaas-1", "DELIVRD", ""); err != nil {
+		t.Fatalf("handle receipt failed: %v", err)
+	}
+	if got := store.deliveries[0].GetStatus(); got != smsDeliveryStatusDeliv {
+		t.Fatalf("delivered receipt status: got=%s want=delivered", got)
+	}
+
+	if err := router.HandleReceipt("innopaas", "innopaas-2", "UNDELIV", ""); err != nil {
+		t.Fatalf("handle failed receipt: %v", err)
+	}
+	if len(twilio.sent) != 1 || twilio.sent[0] != "+250784928787" {
+		t.Fatalf("failed receipt should fail over to twilio, got %v", twilio.sent)
+	}
+
+	stats, err := router.GetProviderStats(7)
+	if err != nil {
+		t.Fatalf("stats failed: %v", err)
+	}
+	for _, s := range stats {
+		if s.Provider == "innopaas" && (s.Total != 2 || s.Delivered != 1 || s.Failed != 1 || s.DeliveryRate != 50) {
+			t.Fatalf("unexpected innopaas stats: %+v", s)
+		}
+	}
+}
+
+func TestSMSRouterSkipsOTPOnlyProviderForOtherMessages(t *testing.T) {
+	twilio := &fakeSmsProvider{name: "twilio", err: errors.New("timeout")}
+	innopaas := &fakeSmsProvider{name: "innopaas", otpOnly: true}
+	router, _ := setupSMSRouterTest(t, nil, twilio, innopaas)
+
+	if err := router.Send(newTestSMS("+250784928786")); err == nil {
+		t.Fatal("expected error when only an OTP provider is left for a generic SMS")
+	}
+	if len(innopaas.sent) != 0 {
+		t.Fatalf("OTP-only provider must not send generic SMS, got %v", innopaas.sent)
+	}
+
+	otp := &Message{
+		Type:     protocol.MsgTypeVerifyCode,
+		Channels: []string{protocol.MsgChannelSms},
+		Params:   map[string]any{"to": "+250784928786", "code": "1234"},
+	}
+	if err := router.Send(otp); err != nil {
+		t.Fatalf("verify code should fail over to OTP provider: %v", err)
+	}
+	if len(innopaas.sent) != 1 {
+		t.Fatalf("expected OTP provider to send verify code once, got %d", len(innopaas.sent))
+	}
+}
+
+func TestSMSPayloadForResend(t *testing.T) {
+	message := &Message{
+		Type:   protocol.MsgTypeVerifyCode,
+		Params: map[string]any{"to": "+250784928786", "code": "4821", smsParamCodeKey: "sms_verify_code_login_user_+250784928786"},
+	}
+	payload := smsPayloadForResend(message)
+	if _, ok := payload.Params["code"]; ok {
+		t.Fatal("stored payload must not contain the plaintext code")
+	}
+	if message.Params["code"] != "4821" {
+		t.Fatal("original message params must not be modified")
+	}
+
+	data, _ := json.Marshal(payload)
+	var loaded Message
+	_ = json.Unmarshal(data, &loaded)
+	lookup := func(key string) (string, error) {
+		if key == "sms_verify_code_login_user_+250784928786" {
+			return "4821", nil
+		}
+		return "", errors.New("not found")
+	}
+	if err := restoreSMSPayloadCode(&loaded, lookup); err != nil || loaded.Params["code"] != "4821" {
+		t.Fatalf("restore code: got %v (%v), want 4821", loaded.Params["code"], err)
+	}
+
+	expired := func(string) (string, error) { return "", errors.New("redis: nil") }
+	_ = json.Unmarshal(data, &loaded)
+	delete(loaded.Params, "code")
+	if err := restoreSMSPayloadCode(&loaded, expired); err == nil {
+		t.Fatal("expired code must not be re-sent")
+	}
+
+	generic := newTestSMS("+250784928786")
+	if err := restoreSMSPayloadCode(smsPayloadForResend(generic), expired); err != nil {
+		t.Fatalf("generic SMS needs no code: %v", err)
+	}
+}
+
+func TestNormalizeSMSReceiptStatus(t *testing.T) {
+	tests := map[string]string{
+		"DELIVRD":     smsDeliveryStatusDeliv,
+		"delivered":   smsDeliveryStatusDeliv,
+		"undelivered": protocol.StatusFailed,
+		"REJECTD":     protocol.StatusFailed,
+		"queued":      "",
+		"sent":        "",
+	}
+	for raw, want := range tests {
+		if got := normalizeSMSReceiptStatus(raw); got != want {
+			t.Fatalf("status %q: got=%q want=%q", raw, got, want)
+		}
+	}
+}
@@ -136,6 +136,16 @@ func (s *StripeService) Pay(payment *models.Payment) *protocol.ChannelResult {
 		},
 	}
 
+	// Saved card: confirm off-session with the customer's tokenized payment method
+	if methodID := payment.GetMetadata().Get("stripe_payment_method_id"); methodID != "" {
+		params.Customer = stripe.String(payment.GetMetadata().Get("stripe_customer_id"))
+		params.PaymentMethod = stripe.String(methodID)
+		params.PaymentMethodTypes = stripe.StringSlice([]string{"card"})
+		params.AutomaticPaymentMethods = nil
+		params.OffSession = stripe.Bool(true)
+		params.Confirm = stripe.Bool(true)
+	}
+
 	// Add description
 	if payment.GetOrderSku() != "" {
 		params.Description = stripe.String(payment.GetOrderSku())
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"github.com/stripe/stripe-go/v76"
+	"github.com/stripe/stripe-go/v76/customer"
+	"github.com/stripe/stripe-go/v76/paymentmethod"
+	"github.com/stripe/stripe-go/v76/setupintent"
+)
+
+// CreateCustomer creates a Stripe customer that saved cards are attached to
+func (s *StripeService) CreateCustomer(userID, email, name string) (string, error) {
+	stripe.Key = s.config.SecretKey
+	params := &stripe.CustomerParams{
+		Metadata: map[string]string{"user_id": userID},
+	}
+	if email != "" {
+		params.Email = stripe.String(email)
+	}
+	if name != "" {
+		params.Name = stripe.String(name)
+	}
+	c, err := customer.New(params)
+	if err != nil {
+		return "", err
+	}
+	return c.ID, nil
+}
+
+// CreateSetupIntent creates an off-session SetupIntent; the client confirms it with the returned client_secret
+func (s *StripeService) CreateSetupIntent(customerID, userID, methodID string) (*stripe.SetupIntent, error) {
+	stripe.Key = s.config.SecretKey
+	params := &stripe.SetupIntentParams{
+		Customer:           stripe.String(customerID),
+		Usage:              stripe.String(string(stripe.SetupIntentUsageOffSession)),
+		PaymentMethodTypes: stripe.StringSlice([]string{"card"}),
+		Metadata: map[string]string{
+			"user_id":   userID,
+			"method_id": methodID,
+		},
+	}
+	return setupintent.New(params)
+}
+
+// GetSetupIntent retrieves a SetupIntent with its payment method expanded
+func (s *StripeService) GetSetupIntent(setupIntentID string) (*stripe.SetupIntent, error) {
+	stripe.Key = s.config.SecretKey
+	params := &stripe.SetupIntentParams{}
+	params.AddExpand("payment_method")
+	return setupintent.Get(setupIntentID, params)
+}
+
+// DetachPaymentMethod detaches a saved card from its customer so it can no longer be charged
+func (s *StripeService) DetachPaymentMethod(paymentMethodID string) error {
+	stripe.Key = s.config.SecretKey
+	_, err := paymentmethod.Detach(paymentMethodID, nil)
+	return err
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"database/sql/driver"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"greenride/internal/config"
+	"greenride/internal/models"
+
+	"github.com/glebarez/go-sqlite"
+	gormsqlite "github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var registerTestSQLFunctions sync.Once
+
+// setupTestDB 使用内存SQLite替换 models.DB，测试结束后恢复
+func setupTestDB(t *testing.T, tables ...any) *gorm.DB {
+	t.Helper()
+	// SQLite没有MySQL的GREATEST，注册一个同语义的函数
+	registerTestSQLFunctions.Do(func() {
+		sqlite.MustRegisterDeterministicScalarFunction("greatest", -1, func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+			var result driver.Value
+			var best float64
+			for i, arg := range args {
+				var value float64
+				switch v := arg.(type) {
+				case int64:
+					value = float64(v)
+				case float64:
+					value = v
+				case nil:
+					return nil, nil
+				}
+				if i == 0 || value > best {
+					best, result = value, arg
+				}
+			}
+			return result, nil
+		})
+	})
+	if config.Get() == nil {
+		config.Set(&config.Config{Log: &config.LogConfig{Path: os.TempDir()}})
+	}
+
+	dsn := "file:" + strings.ReplaceAll(t.Name(), "/", "_") + "?mode=memory&cache=shared"
+	db, err := gorm.Open(gormsqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open test db: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(tables...); err != nil {
+		t.Fatalf("migrate test db: %v", err)
+	}
+
+	previous := models.DB
+	models.DB = db
+	t.Cleanup(func() {
+		models.DB = previous
+		_ = sqlDB.Close()
+	})
+	return db
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"context"
+
+	"greenride/internal/log"
+	"greenride/internal/models"
+	"greenride/internal/protocol"
+	"greenride/internal/task"
+)
+
+const (
+	// 小费入账任务常量
+	TaskTipCreditRetry = "tip_credit_retry"
+)
+
+// InitTipTaskHandlers 初始化小费任务处理器
+func InitTipTaskHandlers() {
+	task.RegisterHandler(TaskTipCreditRetry, TipCreditRetryHandler)
+
+	retryTask := &models.Task{
+		TaskID:     "tip_credit_retry_scheduler",
+		Name:       "小费入账重试",
+		Type:       "payment",
+		HandlerKey: TaskTipCreditRetry,
+		Cron:       "*/10 * * * *", // 每10分钟执行
+		Status:     protocol.TaskStatusEnabled,
+		MaxRetries: 1,
+		Timeout:    300,
+		Remark:     "乘客已支付但入账司机钱包失败的线上小费，重试入账",
+	}
+	task.InitTasks([]*models.Task{retryTask})
+}
+
+// TipCreditRetryHandler 重试入账已支付未入账的小费
+func TipCreditRetryHandler(ctx context.Context, params protocol.MapData) error {
+	if credited := GetTipService().RetryUncreditedTips(ctx); credited > 0 {
+		log.Get().Infof("小费入账重试完成，入账 %d 笔", credited)
+	}
+	return nil
+}
@@ -0,0 +1,395 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"greenride/internal/config"
+	"greenride/internal/log"
+	"greenride/internal/models"
+	"greenride/internal/protocol"
+	"greenride/internal/utils"
+
+	"github.com/shopspring/decimal"
+)
+
+// TipService 行程小费
+// 乘客在行程结束后的时限内可以给司机小费：线上支付复用订单的支付渠道，钱包支付直接扣款，
+// 车费尚未以现金结清时可把小费加入现金一起支付。小费全额归司机，不计入订单金额和平台抽成。
+type TipService struct {
+}
+
+var (
+	tipServiceInstance *TipService
+	tipServiceOnce     sync.Once
+)
+
+func GetTipService() *TipService {
+	tipServiceOnce.Do(func() {
+		SetupTipService()
+	})
+	return tipServiceInstance
+}
+
+func SetupTipService() {
+	tipServiceInstance = &TipService{}
+}
+
+const (
+	tipCreditRetryBatch = 100
+	tipCreditRetryDelay = 5 * time.Minute // 支付成功后超过该时间仍未入账的小费才重试
+)
+
+// 可以给小费的订单状态：行程已结束待支付或已完成
+var tippableStatuses = map[string]bool{
+	protocol.StatusTripEnded: true,
+	protocol.StatusCompleted: true,
+}
+
+// tipDeadline 行程结束后可给小费的截止时间，行程未结束时返回0
+func tipDeadline(endedAt int64, windowMinutes int) int64 {
+	if endedAt <= 0 {
+		return 0
+	}
+	return endedAt + int64(windowMinutes)*60*1000
+}
+
+// validateTipAmount 小费金额需在配置的上下限之间
+func validateTipAmount(amount float64, cfg *config.TipConfig) protocol.ErrorCode {
+	if amount < cfg.MinAmount || amount > cfg.MaxAmount {
+		return protocol.InvalidTipAmount
+	}
+	return protocol.Success
+}
+
+// checkTippable 校验订单是否在可给小费的状态和时限内
+func checkTippable(status string, endedAt, now int64, cfg *config.TipConfig) protocol.ErrorCode {
+	deadline := tipDeadline(endedAt, cfg.WindowMinutes)
+	if !tippableStatuses[status] || deadline <= 0 {
+		return protocol.TipNotAllowed
+	}
+	if now > deadline {
+		return protocol.TipWindowClosed
+	}
+	return protocol.Success
+}
+
+// cashTipAllowed 现金小费由司机随车费一起收取，车费结清后不能再选现金
+func cashTipAllowed(order *models.Order) bool {
+	return order.GetStatus() == protocol.StatusTripEnded && order.GetPaymentStatus() != protocol.StatusSuccess
+}
+
+// tipPaymentOrder 小费复用订单支付渠道，支付记录以小费ID作为订单号、小费金额作为支付金额
+func tipPaymentOrder(order *models.Order, tip *models.OrderTip) *models.Order {
+	values := &models.OrderValues{}
+	values.SetOrderType(protocol.TipOrder).
+		SetUserID(tip.GetUserID()).
+		SetProviderID(tip.GetDriverID()).
+		SetStatus(protocol.StatusPending).
+		SetCurrency(tip.GetCurrency()).
+		SetPaymentAmount(tip.GetAmount())
+	values.Sandbox = order.Sandbox
+	return &models.Order{OrderID: tip.TipID, OrderValues: values}
+}
+
+// GetTipOptions 评价页展示的小费选项及当前小费状态
+func (s *TipService) GetTipOptions(req *protocol.OrderIDRequest) (*protocol.TipOptions, protocol.ErrorCode) {
+	order := models.GetOrderByID(req.OrderID)
+	if order == nil || order.GetUserID() != req.UserID {
+		return nil, protocol.OrderNotFound
+	}
+	cfg := config.GetTipConfig()
+	options := &protocol.TipOptions{
+		OrderID:       order.OrderID,
+		Currency:      order.GetCurrency(),
+		Deadline:      tipDeadline(order.GetEndedAt(), cfg.WindowMinutes),
+		PresetAmounts: cfg.PresetAmounts,
+		MinAmount:     cfg.MinAmount,
+		MaxAmount:     cfg.MaxAmount,
+		CashAllowed:   cashTipAllowed(order),
+	}
+	tip := models.GetOrderTipByOrderID(order.OrderID)
+	if tip != nil {
+		options.Tip = tip.Protocol()
+	}
+	options.CanTip = cfg.IsEnabled() && order.GetProviderID() != "" &&
+		checkTippable(order.GetStatus(), order.GetEndedAt(), utils.TimeNowMilli(), cfg) == protocol.Success &&
+		(tip == nil || tip.GetStatus() != protocol.TipStatusSuccess)
+	return options, protocol.Success
+}
+
+// CreateTip 乘客给司机小费
+func (s *TipService) CreateTip(req *protocol.OrderTipRequest) (*protocol.Tip, protocol.ErrorCode) {
+	cfg := config.GetTipConfig()
+	if !cfg.IsEnabled() {
+		return nil, protocol.TipDisabled
+	}
+	user := models.GetUserByID(req.UserID)
+	if user == nil {
+		return nil, protocol.UserNotFound
+	}
+	if !user.IsPassenger() {
+		return nil, protocol.PermissionDenied
+	}
+	order := models.GetOrderByID(req.OrderID)
+	if order == nil || order.GetUserID() != user.UserID {
+		return nil, protocol.OrderNotFound
+	}
+	if order.GetProviderID() == "" {
+		return nil, protocol.TipNotAllowed
+	}
+	if errCode := checkTippable(order.GetStatus(), order.GetEndedAt(), utils.TimeNowMilli(), cfg); errCode != protocol.Success {
+		return nil, errCode
+	}
+	if errCode := validateTipAmount(req.Amount, cfg); errCode != protocol.Success {
+		return nil, errCode
+	}
+	if req.PaymentMethod == protocol.PaymentMethodCash && !cashTipAllowed(order) {
+		return nil, protocol.TipCashNotAvailable
+	}
+
+	tip := models.GetOrderTipByOrderID(order.OrderID)
+	if tip != nil {
+		switch tip.GetStatus() {
+		case protocol.TipStatusSuccess:
+			return nil, protocol.TipAlreadyPaid
+		case protocol.TipStatusPending:
+			// 线上支付处理中，等待支付结果，避免重复扣款；现金小费尚未收取，可以修改
+			if tip.GetPaymentID() != "" {
+				return tip.Protocol(), protocol.Success
+			}
+		}
+	} else {
+		tip = models.NewOrderTip(order.OrderID, user.UserID, order.GetProviderID())
+	}
+	tip.SetAmount(decimal.NewFromFloat(req.Amount).Round(2), order.GetCurrency()).
+		SetPaymentMethod(req.PaymentMethod).
+		SetStatus(protocol.TipStatusPending)
+	if err := models.SaveOrderTip(models.DB, tip); err != nil {
+		log.Get().Errorf("保存订单 %s 小费失败: %v", order.OrderID, err)
+		return nil, protocol.DatabaseError
+	}
+
+	switch req.PaymentMethod {
+	case protocol.PaymentMethodCash:
+		log.Get().Infof("订单 %s 现金小费 %s 待司机随车费一起收取", order.OrderID, tip.GetAmount().String())
+	case protocol.PaymentMethodWallet:
+		if errCode := s.payByWallet(tip); errCode != protocol.Success {
+			return nil, errCode
+		}
+	default:
+		if errCode := s.payByChannel(order, tip, user, req); errCode != protocol.Success {
+			return nil, errCode
+		}
+	}
+
+	if latest := models.GetOrderTipByID(tip.TipID); latest != nil {
+		tip = latest
+	}
+	return tip.Protocol(), protocol.Success
+}
+
+// payByWallet 从乘客钱包扣除小费
+func (s *TipService) payByWallet(tip *models.OrderTip) protocol.ErrorCode {
+	amount, _ := tip.GetAmount().Float64()
+	if _, err := models.DebitWallet(tip.GetUserID(), amount, models.TransactionCategoryTip, "order_tip", tip.TipID, "行程小费"); err != nil {
+		s.markFailed(tip, err.Error())
+		if errors.Is(err, models.ErrInsufficientBalance) {
+			return protocol.InsufficientFunds
+		}
+		log.Get().Errorf("小费 %s 钱包扣款失败: %v", tip.TipID, err)
+		return protocol.DatabaseError
+	}
+	s.settle(tip)
+	return protocol.Success
+}
+
+// payByChannel 通过支付渠道支付小费，异步结果由支付回调通过CheckTipPayment处理
+func (s *TipService) payByChannel(order *models.Order, tip *models.OrderTip, user *models.User, req *protocol.OrderTipRequest) protocol.ErrorCode {
+	result, errCode := GetPaymentService().OrderPayment(&ChannelPaymentRequest{
+		Phone:         req.Phone,
+		Email:         req.Email,
+		AccountNo:     req.AccountNo,
+		AccountName:   req.AccountName,
+		PaymentMethod: req.PaymentMethod,
+		Order:         tipPaymentOrder(order, tip),
+		User:          user,
+	})
+	if errCode != protocol.Success {
+		s.markFailed(tip, string(errCode))
+		return errCode
+	}
+
+	updates := map[string]any{
+		"payment_id":   result.PaymentID,
+		"redirect_url": result.RedirectURL,
+	}
+	if _, err := models.TransitionOrderTipStatus(tip.TipID, []string{protocol.TipStatusPending}, updates); err != nil {
+		log.Get().Errorf("记录小费 %s 支付信息失败: %v", tip.TipID, err)
+		return protocol.DatabaseError
+	}
+	switch result.Status {
+	case protocol.StatusSuccess:
+		s.settle(tip)
+	case protocol.StatusFailed:
+		s.markFailed(tip, GetOrderService().getTranslatedPaymentResult(result.ResCode, result.ResMsg, req.Language))
+	}
+	return protocol.Success
+}
+
+// CheckTipPayment 支付回调后同步小费支付结果
+func (s *TipService) CheckTipPayment(tipID, paymentID string) {
+	tip := models.GetOrderTipByID(tipID)
+	if tip == nil {
+		return
+	}
+	payment := models.GetPaymentByID(paymentID)
+	if payment == nil {
+		return
+	}
+	if tip.GetStatus() != protocol.TipStatusPending || tip.GetPaymentID() != paymentID {
+		if payment.GetStatus() == protocol.StatusSuccess {
+			log.Get().Warnf("小费 %s 的旧支付 %s 回调成功，当前状态 %s，需人工核对", tipID, paymentID, tip.GetStatus())
+		}
+		return
+	}
+	switch payment.GetStatus() {
+	case protocol.StatusSuccess:
+		s.settle(tip)
+	case protocol.StatusFailed:
+		s.markFailed(tip, "["+payment.GetResCode()+"]"+payment.GetResMsg())
+	}
+}
+
+// ConfirmCashTip 司机确认收到现金车费时一并确认现金小费
+func (s *TipService) ConfirmCashTip(orderID string) {
+	tip := models.GetOrderTipByOrderID(orderID)
+	if tip == nil || tip.GetStatus() != protocol.TipStatusPending || tip.GetPaymentMethod() != protocol.PaymentMethodCash {
+		return
+	}
+	s.settle(tip)
+}
+
+// GetPendingCashTip 待随现金车费一起收取的小费金额
+func (s *TipService) GetPendingCashTip(orderID string) decimal.Decimal {
+	tip := models.GetOrderTipByOrderID(orderID)
+	if tip == nil || tip.GetStatus() != protocol.TipStatusPending || tip.GetPaymentMethod() != protocol.PaymentMethodCash {
+		return decimal.Zero
+	}
+	return tip.GetAmount()
+}
+
+// markFailed 小费支付失败，乘客可以重新发起
+func (s *TipService) markFailed(tip *models.OrderTip, reason string) {
+	if len(reason) > 255 {
+		reason = reason[:255]
+	}
+	if _, err := models.TransitionOrderTipStatus(tip.TipID, []string{protocol.TipStatusPending}, map[string]any{
+		"status": protocol.TipStatusFailed,
+		"reason": reason,
+	}); err != nil {
+		log.Get().Errorf("更新小费 %s 失败状态出错: %v", tip.TipID, err)
+	}
+}
+
+// settle 小费支付成功：全额入账司机钱包并记录到订单，条件更新状态保证只处理一次
+// 现金小费已由司机直接收取，只记录到订单，不再入账钱包；线上小费入账失败时保持已支付待入账，由定时任务重试
+func (s *TipService) settle(tip *models.OrderTip) {
+	ok, err := models.TransitionOrderTipStatus(tip.TipID, []string{protocol.TipStatusPending}, map[string]any{
+		"status":  protocol.TipStatusSuccess,
+		"reason":  "",
+		"paid_at": utils.TimeNowMilli(),
+	})
+	if err != nil || !ok {
+		if err != nil {
+			log.Get().Errorf("更新小费 %s 支付成功状态失败: %v", tip.TipID, err)
+		}
+		return
+	}
+
+	if tip.GetPaymentMethod() != protocol.PaymentMethodCash {
+		s.credit(tip)
+	}
+
+	order := models.GetOrderByID(tip.GetOrderID())
+	if order == nil {
+		return
+	}
+	values := &models.OrderValues{}
+	values.SetTipAmount(tip.GetAmount())
+	if err := models.UpdateOrder(models.DB, order, values); err != nil {
+		log.Get().Errorf("记录订单 %s 小费金额失败: %v", order.OrderID, err)
+	}
+	log.Get().Infof("订单 %s 小费 %s %s 已支付给司机 %s", order.OrderID, tip.GetAmount().String(), tip.GetCurrency(), tip.GetDriverID())
+	go s.notifyDriver(order, tip)
+}
+
+// credit 已支付的线上小费入账司机钱包，返回是否入账成功
+func (s *TipService) credit(tip *models.OrderTip) bool {
+	if _, err := models.CreditOrderTip(tip); err != nil {
+		if !errors.Is(err, models.ErrOrderTipCredited) {
+			log.Get().Errorf("小费 %s 入账司机 %s 钱包失败，等待重试: %v", tip.TipID, tip.GetDriverID(), err)
+		}
+		return false
+	}
+	return true
+}
+
+// RetryUncreditedTips 重试入账已支付但未入账司机钱包的线上小费，跳过刚支付、可能仍在入账中的小费
+func (s *TipService) RetryUncreditedTips(ctx context.Context) int {
+	credited := 0
+	for _, tip := range models.GetUncreditedOrderTips(utils.TimeNowMilli()-tipCreditRetryDelay.Milliseconds(), tipCreditRetryBatch) {
+		if ctx.Err() != nil {
+			break
+		}
+		if s.credit(tip) {
+			credited++
+			log.Get().Infof("小费 %s 重试入账司机 %s 钱包成功", tip.TipID, tip.GetDriverID())
+		}
+	}
+	return credited
+}
+
+// notifyDriver 通知司机收到小费
+func (s *TipService) notifyDriver(order *models.Order, tip *models.OrderTip) {
+	driver := models.GetUserByID(tip.GetDriverID())
+	if driver == nil {
+		return
+	}
+	passengerName := "Passenger"
+	if passenger := models.GetUserByID(tip.GetUserID()); passenger != nil {
+		passengerName = passenger.GetFullName()
+	}
+	message := &Message{
+		Type:     protocol.MsgTypeDriverTipReceived,
+		Channels: []string{protocol.MsgChannelFcm},
+		Params: map[string]any{
+			"to":                driver.UserID,
+			"OrderID":           order.OrderID,
+			"PassengerName":     passengerName,
+			"Amount":            tip.GetAmount().StringFixed(2),
+			"Currency":          tip.GetCurrency(),
+			"msg_type":          protocol.FCMMessageTypePayment,
+			"notification_type": protocol.NotificationTypeTipReceived,
+		},
+		Language: getUserLanguage(driver),
+	}
+	if err := GetUserNotificationService().Deliver(driver, message); err != nil {
+		log.Get().Warnf("通知司机 %s 收到小费失败: %v", driver.UserID, err)
+	}
+}
+
+// attachRatingTip 乘客的评价附带该行程的小费金额，评价页一并展示
+func attachRatingTip(order *models.Order, ratings []*protocol.Rating) {
+	tipAmount, _ := order.GetTipAmount().Float64()
+	if tipAmount <= 0 {
+		return
+	}
+	for _, rating := range ratings {
+		if rating != nil && rating.RaterID == order.GetUserID() {
+			rating.TipAmount = tipAmount
+		}
+	}
+}
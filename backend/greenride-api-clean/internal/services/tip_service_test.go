@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"greenride/internal/config"
+	"greenride/internal/models"
+	"greenride/internal/protocol"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestCheckTippable(t *testing.T) {
+	cfg := &config.TipConfig{}
+	cfg.Validate()
+	endedAt := int64(1_700_000_000_000)
+	window := int64(cfg.WindowMinutes) * 60 * 1000
+
+	cases := []struct {
+		status  string
+		endedAt int64
+		now     int64
+		want    protocol.ErrorCode
+	}{
+		{protocol.StatusTripEnded, endedAt, endedAt + 1000, protocol.Success},
+		{protocol.StatusCompleted, endedAt, endedAt + window, protocol.Success}, // 截止时间当刻仍可给
+		{protocol.StatusCompleted, endedAt, endedAt + window + 1, protocol.TipWindowClosed},
+		{protocol.StatusInProgress, endedAt, endedAt + 1000, protocol.TipNotAllowed}, // 行程未结束
+		{protocol.StatusCancelled, endedAt, endedAt + 1000, protocol.TipNotAllowed},
+		{protocol.StatusCompleted, 0, endedAt, protocol.TipNotAllowed}, // 没有结束时间
+	}
+	for _, c := range cases {
+		if got := checkTippable(c.status, c.endedAt, c.now, cfg); got != c.want {
+			t.Errorf("status=%s now-ended=%d: got %s, want %s", c.status, c.now-c.endedAt, got, c.want)
+		}
+	}
+}
+
+func TestValidateTipAmount(t *testing.T) {
+	cfg := &config.TipConfig{MinAmount: 100, MaxAmount: 5000}
+	cfg.Validate()
+
+	for amount, want := range map[float64]protocol.ErrorCode{
+		100:  protocol.Success,
+		5000: protocol.Success,
+		99:   protocol.InvalidTipAmount,
+		5001: protocol.InvalidTipAmount,
+		0:    protocol.InvalidTipAmount,
+		-200: protocol.InvalidTipAmount,
+	} {
+		if got := validateTipAmount(amount, cfg); got != want {
+			t.Errorf("validateTipAmount(%v) = %s, want %s", amount, got, want)
+		}
+	}
+}
+
+func TestAttachRatingTip(t *testing.T) {
+	order := &models.Order{OrderID: "O1", OrderValues: &models.OrderValues{}}
+	order.SetUserID("passenger").SetProviderID("driver").SetTipAmount(decimal.NewFromInt(500))
+	ratings := []*protocol.Rating{
+		{RaterID: "passenger", RateeID: "driver"},
+		{RaterID: "driver", RateeID: "passenger"},
+	}
+	attachRatingTip(order, ratings)
+	if ratings[0].TipAmount != 500 {
+		t.Errorf("passenger rating tip = %v, want 500", ratings[0].TipAmount)
+	}
+	if ratings[1].TipAmount != 0 {
+		t.Errorf("driver rating should not carry the tip, got %v", ratings[1].TipAmount)
+	}
+}
+
+func TestTipPaymentOrder(t *testing.T) {
+	order := &models.Order{OrderID: "O1", OrderValues: &models.OrderValues{}}
+	order.SetSandbox(1)
+	tip := models.NewOrderTip("O1", "passenger", "driver")
+	tip.SetAmount(decimal.NewFromInt(300), "RWF")
+
+	paymentOrder := tipPaymentOrder(order, tip)
+	if paymentOrder.OrderID != tip.TipID || paymentOrder.GetOrderType() != protocol.TipOrder {
+		t.Errorf("payment order should use the tip id and tip type, got %s %s", paymentOrder.OrderID, paymentOrder.GetOrderType())
+	}
+	if !paymentOrder.GetPaymentAmount().Equal(decimal.NewFromInt(300)) || paymentOrder.GetStatus() != protocol.StatusPending {
+		t.Errorf("unexpected payment order amount %s status %s", paymentOrder.GetPaymentAmount(), paymentOrder.GetStatus())
+	}
+	if !paymentOrder.IsSandbox() {
+		t.Error("sandbox flag should follow the trip order")
+	}
+}
+
+func TestCreditOrderTipOnceAndRetry(t *testing.T) {
+	db := setupTestDB(t, &models.OrderTip{}, &models.Wallet{}, &models.WalletTransaction{})
+	newPaidTip := func(orderID string, paidAt int64) *models.OrderTip {
+		tip := models.NewOrderTip(orderID, "passenger-1", "driver-1")
+		tip.SetAmount(decimal.NewFromInt(500), "RWF").
+			SetPaymentMethod(protocol.PaymentMethodMomo).
+			SetStatus(protocol.TipStatusSuccess)
+		tip.PaidAt = &paidAt
+		if err := db.Create(tip).Error; err != nil {
+			t.Fatalf("create tip: %v", err)
+		}
+		return tip
+	}
+	driverEarnings := func() float64 {
+		var wallet models.Wallet
+		if err := db.Where("user_id = ?", "driver-1").First(&wallet).Error; err != nil {
+			return 0
+		}
+		return wallet.GetTotalEarnings()
+	}
+
+	tip := newPaidTip("order-1", 1)
+	if !GetTipService().credit(tip) {
+		t.Fatal("first credit should succeed")
+	}
+	if _, err := models.CreditOrderTip(tip); !errors.Is(err, models.ErrOrderTipCredited) {
+		t.Fatalf("second credit error = %v, want ErrOrderTipCredited", err)
+	}
+	if got := driverEarnings(); got != 500 {
+		t.Fatalf("driver earnings after duplicate credit = %v, want 500", got)
+	}
+	if stored := models.GetOrderTipByID(tip.TipID); stored.GetTransactionID() == "" {
+		t.Fatal("credited tip should record its wallet transaction")
+	}
+
+	// 入账失败后保持已支付待入账，重试任务只处理超过等待时间的小费
+	newPaidTip("order-2", 1)
+	newPaidTip("order-3", 1<<62)
+	if credited := GetTipService().RetryUncreditedTips(context.Background()); credited != 1 {
+		t.Fatalf("retry credited %d tips, want 1", credited)
+	}
+	if got := driverEarnings(); got != 1000 {
+		t.Fatalf("driver earnings after retry = %v, want 1000", got)
+	}
+	if credited := GetTipService().RetryUncreditedTips(context.Background()); credited != 0 {
+		t.Fatalf("second retry credited %d tips, want 0", credited)
+	}
+}
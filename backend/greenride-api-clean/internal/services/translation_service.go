@@ -0,0 +1,464 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"greenride/internal/config"
+	"greenride/internal/i18n"
+	"greenride/internal/log"
+	"greenride/internal/models"
+	"greenride/internal/protocol"
+)
+
+// 翻译热加载及降级记录落库的间隔
+const translationRefreshInterval = 30 * time.Second
+
+const translationKeyMaxLength = 128
+
+// translationFormatVerb 匹配 fmt 占位符，如 %s、%d、%.2f，不含 %%
+var translationFormatVerb = regexp.MustCompile(`%[-+# 0]*[0-9]*(?:\.[0-9]+)?[a-zA-Z]`)
+
+// TranslationService 翻译管理服务：locales 文件为基础，数据库中的翻译覆盖同名key
+type TranslationService struct {
+	mu            sync.Mutex
+	count         int64 // 已加载的数据库翻译条数
+	lastUpdatedAt int64 // 已加载的数据库翻译最近更新时间
+}
+
+var (
+	translationServiceInstance *TranslationService
+	translationServiceOnce     sync.Once
+)
+
+// GetTranslationService 获取翻译管理服务实例
+func GetTranslationService() *TranslationService {
+	translationServiceOnce.Do(func() {
+		translationServiceInstance = &TranslationService{}
+	})
+	return translationServiceInstance
+}
+
+// SetupTranslationService 加载数据库中的翻译，并启动本实例的热加载和降级记录
+func SetupTranslationService() {
+	service := GetTranslationService()
+	if err := service.Reload(); err != nil {
+		log.Get().Errorf("加载数据库翻译失败: %v", err)
+	}
+	go service.watch(translationRefreshInterval)
+}
+
+// watch 定期将本实例的降级记录写库，并在其他实例修改翻译后重新加载
+func (s *TranslationService) watch(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.flushFallbacks()
+		if err := s.RefreshIfNeeded(); err != nil {
+			log.Get().Warnf("刷新翻译失败: %v", err)
+		}
+	}
+}
+
+func (s *TranslationService) translator() *i18n.FileTranslator {
+	translator, _ := i18n.GetGlobalTranslator().(*i18n.FileTranslator)
+	return translator
+}
+
+func (s *TranslationService) localesDir() string {
+	if cfg := config.Get(); cfg != nil && cfg.I18n != nil {
+		return cfg.I18n.LocalesDir
+	}
+	return ""
+}
+
+// Reload 重新读取 locales 文件并叠加数据库中的翻译
+func (s *TranslationService) Reload() error {
+	translator := s.translator()
+	if translator == nil {
+		return fmt.Errorf("global translator does not support reloading")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 先记录版本再读取，避免读取期间的修改被漏掉
+	count, lastUpdatedAt, err := models.GetTranslationsVersion()
+	if err != nil {
+		return err
+	}
+	records, err := models.GetAllTranslations()
+	if err != nil {
+		return err
+	}
+
+	translations := make(map[string]map[string]string)
+	errorTranslations := make(map[string]map[string]string)
+	if dir := s.localesDir(); dir != "" {
+		if translations, errorTranslations, err = i18n.ReadLocaleFiles(dir); err != nil {
+			log.Get().Warnf("读取翻译文件失败, dir=%s: %v", dir, err)
+			translations = make(map[string]map[string]string)
+			errorTranslations = make(map[string]map[string]string)
+		}
+	}
+	for _, record := range records {
+		target := translations
+		if record.Namespace == i18n.NamespaceErrors {
+			target = errorTranslations
+		}
+		if target[record.Language] == nil {
+			target[record.Language] = make(map[string]string)
+		}
+		target[record.Language][record.Key] = record.Value
+	}
+
+	translator.ReplaceTranslations(translations, errorTranslations)
+	s.count, s.lastUpdatedAt = count, lastUpdatedAt
+	return nil
+}
+
+// RefreshIfNeeded 数据库翻译有新增、修改或删除时重新加载
+func (s *TranslationService) RefreshIfNeeded() error {
+	count, lastUpdatedAt, err := models.GetTranslationsVersion()
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	stale := count != s.count || lastUpdatedAt != s.lastUpdatedAt
+	s.mu.Unlock()
+	if stale {
+		return s.Reload()
+	}
+	return nil
+}
+
+// flushFallbacks 将本实例记录的降级写入数据库
+func (s *TranslationService) flushFallbacks() {
+	translator := s.translator()
+	if translator == nil {
+		return
+	}
+	if err := models.RecordTranslationFallbacks(translator.DrainFallbacks()); err != nil {
+		log.Get().Warnf("记录翻译降级失败: %v", err)
+	}
+}
+
+// reload 修改后本实例立即重新加载，其他实例由 watch 热加载
+func (s *TranslationService) reload() {
+	if err := s.Reload(); err != nil {
+		log.Get().Errorf("重新加载翻译失败: %v", err)
+	}
+}
+
+// namespaceMessages 当前生效的某个命名空间的翻译 [language][key]message
+func (s *TranslationService) namespaceMessages(namespace string) map[string]map[string]string {
+	translator := s.translator()
+	if translator == nil {
+		return map[string]map[string]string{}
+	}
+	translations, errorTranslations := translator.Snapshot()
+	if namespace == i18n.NamespaceErrors {
+		return errorTranslations
+	}
+	return translations
+}
+
+// validateTranslationTarget 校验命名空间和语言
+func validateTranslationTarget(namespace, language string) protocol.ErrorCode {
+	if namespace != i18n.NamespaceCommon && namespace != i18n.NamespaceErrors {
+		return protocol.InvalidParams
+	}
+	if !i18n.IsValidLanguage(language) {
+		return protocol.InvalidParams
+	}
+	return protocol.Success
+}
+
+// checkTranslationValue 校验翻译内容：key长度，以及占位符需与默认语言一致，否则格式化时参数错位
+func checkTranslationValue(key, value, reference string) error {
+	if key == "" || len(key) > translationKeyMaxLength {
+		return fmt.Errorf("key must be 1-%d characters", translationKeyMaxLength)
+	}
+	if strings.TrimSpace(value) == "" {
+		return fmt.Errorf("value is empty")
+	}
+	if reference == "" {
+		return nil
+	}
+	want := translationFormatVerb.FindAllString(strings.ReplaceAll(reference, "%%", ""), -1)
+	got := translationFormatVerb.FindAllString(strings.ReplaceAll(value, "%%", ""), -1)
+	if !slices.Equal(want, got) {
+		return fmt.Errorf("placeholders %v do not match %s %v", got, i18n.DefaultLanguage, want)
+	}
+	return nil
+}
+
+// SearchTranslations 查询翻译，列出所有语言出现过的key，该语言缺少的key内容为空
+func (s *TranslationService) SearchTranslations(req *protocol.TranslationSearchRequest) ([]*protocol.TranslationEntry, int64, protocol.ErrorCode) {
+	if errCode := validateTranslationTarget(req.Namespace, req.Language); errCode != protocol.Success {
+		return nil, 0, errCode
+	}
+	overrides, err := models.GetTranslationsByLanguage(req.Namespace, req.Language)
+	if err != nil {
+		log.Get().Errorf("查询数据库翻译失败: %v", err)
+		return nil, 0, protocol.DatabaseError
+	}
+
+	messages := s.namespaceMessages(req.Namespace)
+	current, reference := messages[req.Language], messages[i18n.DefaultLanguage]
+	keyword := strings.ToLower(req.Keyword)
+	var list []*protocol.TranslationEntry
+	for _, key := range translationKeys(messages) {
+		value, exists := current[key]
+		entry := &protocol.TranslationEntry{
+			Namespace:    req.Namespace,
+			Language:     req.Language,
+			Key:          key,
+			Value:        value,
+			DefaultValue: reference[key],
+		}
+		if override, ok := overrides[key]; ok {
+			entry.Source = protocol.TranslationSourceDatabase
+			entry.UpdatedBy = override.UpdatedBy
+			entry.UpdatedAt = override.UpdatedAt
+		} else if exists {
+			entry.Source = protocol.TranslationSourceFile
+		}
+
+		if req.Missing && exists {
+			continue
+		}
+		if req.Source != "" && req.Source != entry.Source {
+			continue
+		}
+		if keyword != "" && !strings.Contains(strings.ToLower(key), keyword) &&
+			!strings.Contains(strings.ToLower(value), keyword) && !strings.Contains(strings.ToLower(entry.DefaultValue), keyword) {
+			continue
+		}
+		list = append(list, entry)
+	}
+
+	total := int64(len(list))
+	start := min((req.Page-1)*req.Limit, len(list))
+	end := min(start+req.Limit, len(list))
+	return list[start:end], total, protocol.Success
+}
+
+// SaveTranslation 新增或修改翻译，保存后立即生效；内容不合法时返回原因
+func (s *TranslationService) SaveTranslation(req *protocol.SaveTranslationRequest) (string, protocol.ErrorCode) {
+	if errCode := validateTranslationTarget(req.Namespace, req.Language); errCode != protocol.Success {
+		return "", errCode
+	}
+	reference := ""
+	if req.Language != i18n.DefaultLanguage {
+		reference = s.namespaceMessages(req.Namespace)[i18n.DefaultLanguage][req.Key]
+	}
+	if err := checkTranslationValue(req.Key, req.Value, reference); err != nil {
+		return err.Error(), protocol.TranslationInvalid
+	}
+
+	record := &models.Translation{
+		Namespace: req.Namespace,
+		Language:  req.Language,
+		Key:       req.Key,
+		Value:     req.Value,
+		UpdatedBy: req.UserID,
+	}
+	if err := models.SaveTranslations([]*models.Translation{record}); err != nil {
+		log.Get().Errorf("保存翻译失败, %s/%s/%s: %v", req.Namespace, req.Language, req.Key, err)
+		return "", protocol.DatabaseError
+	}
+	s.reload()
+	return "", protocol.Success
+}
+
+// DeleteTranslation 删除数据库中的翻译，回退到 locales 文件中的内容
+func (s *TranslationService) DeleteTranslation(req *protocol.DeleteTranslationRequest) protocol.ErrorCode {
+	if errCode := validateTranslationTarget(req.Namespace, req.Language); errCode != protocol.Success {
+		return errCode
+	}
+	deleted, err := models.DeleteTranslation(req.Namespace, req.Language, req.Key)
+	if err != nil {
+		log.Get().Errorf("删除翻译失败, %s/%s/%s: %v", req.Namespace, req.Language, req.Key, err)
+		return protocol.DatabaseError
+	}
+	if !deleted {
+		return protocol.TranslationNotFound
+	}
+	log.Get().Infof("翻译已删除, %s/%s/%s, operator=%s", req.Namespace, req.Language, req.Key, req.UserID)
+	s.reload()
+	return protocol.Success
+}
+
+// ImportTranslations 导入 locales 文件格式的翻译；内容与当前生效内容不同的key只有在 overwrite 时才覆盖
+func (s *TranslationService) ImportTranslations(req *protocol.ImportTranslationsRequest) (*protocol.TranslationImportResult, protocol.ErrorCode) {
+	if errCode := validateTranslationTarget(req.Namespace, req.Language); errCode != protocol.Success {
+		return nil, errCode
+	}
+
+	messages := s.namespaceMessages(req.Namespace)
+	current, reference := messages[req.Language], messages[i18n.DefaultLanguage]
+	if req.Language == i18n.DefaultLanguage {
+		reference = nil
+	}
+
+	result := &protocol.TranslationImportResult{}
+	var records []*models.Translation
+	keys := make([]string, 0, len(req.Entries))
+	for key := range req.Entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := req.Entries[key]
+		if err := checkTranslationValue(key, value, reference[key]); err != nil {
+			result.Invalid = append(result.Invalid, fmt.Sprintf("%s: %v", key, err))
+			continue
+		}
+		existing, exists := current[key]
+		switch {
+		case exists && existing == value:
+			result.Skipped++
+			continue
+		case exists && !req.Overwrite:
+			result.Skipped++
+			continue
+		case exists:
+			result.Updated++
+		default:
+			result.Created++
+		}
+		records = append(records, &models.Translation{
+			Namespace: req.Namespace,
+			Language:  req.Language,
+			Key:       key,
+			Value:     value,
+			UpdatedBy: req.UserID,
+		})
+	}
+
+	if err := models.SaveTranslations(records); err != nil {
+		log.Get().Errorf("导入翻译失败, %s/%s: %v", req.Namespace, req.Language, err)
+		return nil, protocol.DatabaseError
+	}
+	log.Get().Infof("翻译导入完成, %s/%s, 新增=%d, 更新=%d, 跳过=%d, 不合法=%d, operator=%s",
+		req.Namespace, req.Language, result.Created, result.Updated, result.Skipped, len(result.Invalid), req.UserID)
+	if len(records) > 0 {
+		s.reload()
+	}
+	return result, protocol.Success
+}
+
+// ExportTranslations 导出当前生效的翻译（文件与数据库合并后），格式与 locales 文件相同
+func (s *TranslationService) ExportTranslations(req *protocol.ExportTranslationsRequest) (map[string]string, protocol.ErrorCode) {
+	if errCode := validateTranslationTarget(req.Namespace, req.Language); errCode != protocol.Success {
+		return nil, errCode
+	}
+	entries := s.namespaceMessages(req.Namespace)[req.Language]
+	if entries == nil {
+		entries = map[string]string{}
+	}
+	return entries, protocol.Success
+}
+
+// GetTranslationReport 各语言缺少的key及线上发生的降级
+func (s *TranslationService) GetTranslationReport(req *protocol.TranslationReportRequest) ([]*protocol.TranslationLanguageReport, protocol.ErrorCode) {
+	namespaces := []string{i18n.NamespaceCommon, i18n.NamespaceErrors}
+	if req.Namespace != "" {
+		if !slices.Contains(namespaces, req.Namespace) {
+			return nil, protocol.InvalidParams
+		}
+		namespaces = []string{req.Namespace}
+	}
+
+	// 先写入本实例的降级记录，报表尽量实时
+	s.flushFallbacks()
+	fallbacks, err := models.GetTranslationFallbacks(req.Namespace, req.Languages)
+	if err != nil {
+		log.Get().Errorf("查询翻译降级记录失败: %v", err)
+		return nil, protocol.DatabaseError
+	}
+
+	var reports []*protocol.TranslationLanguageReport
+	for _, namespace := range namespaces {
+		messages := s.namespaceMessages(namespace)
+		languages := req.Languages
+		if len(languages) == 0 {
+			languages = translationLanguages(messages)
+		}
+		for _, report := range buildTranslationReport(namespace, messages, languages) {
+			for _, f := range fallbacks {
+				if f.Namespace != namespace || f.Language != report.Language {
+					continue
+				}
+				// 已补齐的key不再列出
+				if _, translated := messages[report.Language][f.Key]; translated {
+					continue
+				}
+				report.Fallbacks = append(report.Fallbacks, &protocol.TranslationFallback{
+					Key:            f.Key,
+					ServedLanguage: f.ServedLanguage,
+					Count:          f.Count,
+					FirstSeenAt:    f.FirstSeenAt,
+					LastSeenAt:     f.LastSeenAt,
+				})
+			}
+			reports = append(reports, report)
+		}
+	}
+	return reports, protocol.Success
+}
+
+// buildTranslationReport 以所有语言key的并集为准，统计每个语言缺少的key
+func buildTranslationReport(namespace string, messages map[string]map[string]string, languages []string) []*protocol.TranslationLanguageReport {
+	keys := translationKeys(messages)
+	reports := make([]*protocol.TranslationLanguageReport, 0, len(languages))
+	for _, lang := range languages {
+		report := &protocol.TranslationLanguageReport{
+			Namespace: namespace,
+			Language:  lang,
+			Total:     len(keys),
+		}
+		for _, key := range keys {
+			if _, ok := messages[lang][key]; ok {
+				report.Translated++
+			} else {
+				report.Missing = append(report.Missing, key)
+			}
+		}
+		report.MissingCount = len(report.Missing)
+		reports = append(reports, report)
+	}
+	return reports
+}
+
+// translationKeys 所有语言key的并集，按字母排序
+func translationKeys(messages map[string]map[string]string) []string {
+	seen := map[string]struct{}{}
+	for _, entries := range messages {
+		for key := range entries {
+			seen[key] = struct{}{}
+		}
+	}
+	return sortedKeys(seen)
+}
+
+// translationLanguages 已有翻译的语言，默认语言在前
+func translationLanguages(messages map[string]map[string]string) []string {
+	languages := make([]string, 0, len(messages))
+	for lang := range messages {
+		languages = append(languages, lang)
+	}
+	sort.Slice(languages, func(i, j int) bool {
+		if (languages[i] == i18n.DefaultLanguage) != (languages[j] == i18n.DefaultLanguage) {
+			return languages[i] == i18n.DefaultLanguage
+		}
+		return languages[i] < languages[j]
+	})
+	return languages
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"slices"
+	"testing"
+
+	"greenride/internal/i18n"
+)
+
+func TestCheckTranslationValue(t *testing.T) {
+	cases := []struct {
+		name      string
+		key       string
+		value     string
+		reference string
+		wantErr   bool
+	}{
+		{"matching placeholder", "10038", "Modèle invalide : %s", "Message template is invalid: %s", false},
+		{"no reference", "new_key", "Murakoze", "", false},
+		{"escaped percent ignored", "discount", "%d%% off", "%d%% discount", false},
+		{"missing placeholder", "10038", "Modèle invalide", "Message template is invalid: %s", true},
+		{"wrong verb", "fare", "Igiciro: %s", "Fare: %.2f", true},
+		{"empty value", "10040", "  ", "Translation not found", true},
+		{"empty key", "", "x", "", true},
+	}
+	for _, c := range cases {
+		err := checkTranslationValue(c.key, c.value, c.reference)
+		if (err != nil) != c.wantErr {
+			t.Errorf("%s: err = %v, wantErr %v", c.name, err, c.wantErr)
+		}
+	}
+}
+
+func TestBuildTranslationReport(t *testing.T) {
+	messages := map[string]map[string]string{
+		"en": {"a": "A", "b": "B", "c": "C"},
+		"fr": {"a": "A", "c": "C"},
+		"rw": {"a": "A", "d": "D"},
+	}
+	reports := buildTranslationReport(i18n.NamespaceErrors, messages, translationLanguages(messages))
+	if len(reports) != 3 || reports[0].Language != "en" {
+		t.Fatalf("unexpected reports %+v", reports)
+	}
+	want := map[string][]string{"en": {"d"}, "fr": {"b", "d"}, "rw": {"b", "c"}}
+	for _, r := range reports {
+		if r.Total != 4 || r.Translated+r.MissingCount != r.Total {
+			t.Errorf("%s: total=%d translated=%d missing=%d", r.Language, r.Total, r.Translated, r.MissingCount)
+		}
+		if !slices.Equal(r.Missing, want[r.Language]) {
+			t.Errorf("%s: missing = %v, want %v", r.Language, r.Missing, want[r.Language])
+		}
+	}
+}
+
+func TestTranslatorRecordsFallbacks(t *testing.T) {
+	translator := i18n.NewFileTranslator()
+	translator.ReplaceTranslations(
+		map[string]map[string]string{"en": {"hello": "Hello"}, "rw": {}},
+		map[string]map[string]string{"en": {"1001": "Bad"}, "rw": {"1002": "Nabi"}},
+	)
+
+	if got := translator.Translate("1001", "rw"); got != "Bad" {
+		t.Errorf("Translate fallback = %q", got)
+	}
+	translator.Translate("1001", "rw")
+	translator.Translate("1002", "rw")
+	translator.TranslateMessage("hello", "rw")
+	translator.TranslateMessage("unknown", "en")
+	translator.TranslateMessage("hello", "de") // 没有任何翻译的语言不记录
+
+	fallbacks := translator.DrainFallbacks()
+	want := map[i18n.Fallback]int64{
+		{Namespace: i18n.NamespaceErrors, Language: "rw", Key: "1001", ServedLanguage: "en"}:  2,
+		{Namespace: i18n.NamespaceCommon, Language: "rw", Key: "hello", ServedLanguage: "en"}: 1,
+		{Namespace: i18n.NamespaceCommon, Language: "en", Key: "unknown", ServedLanguage: ""}: 1,
+	}
+	if len(fallbacks) != len(want) {
+		t.Fatalf("fallbacks = %v", fallbacks)
+	}
+	for f, count := range want {
+		if fallbacks[f] != count {
+			t.Errorf("%+v: count = %d, want %d", f, fallbacks[f], count)
+		}
+	}
+	if len(translator.DrainFallbacks()) != 0 {
+		t.Error("fallbacks should be cleared after drain")
+	}
+}
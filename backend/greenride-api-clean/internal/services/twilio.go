@@ -92,10 +92,16 @@ func SetupTwilioService() {
 // from: optional sender phone number, will use the first available if not specified
 // If a prioritized list of numbers is provided, it will try them in order
 func (t *TwilioService) SendSMS(to string, message string, from string) error {
+	_, err := t.sendSMS(to, message, from)
+	return err
+}
+
+// sendSMS sends an SMS via Twilio Message API and returns the message SID
+func (t *TwilioService) sendSMS(to string, message string, from string) (string, error) {
 	// Get the appropriate account and phone number
 	account, fromNumber := t.getAccountAndPhone(from)
 	if account == nil {
-		return fmt.Errorf("no valid Twilio account found for sending SMS")
+		return "", fmt.Errorf("no valid Twilio account found for sending SMS")
 	}
 
 	// Create the message parameters
@@ -103,19 +109,25 @@ func (t *TwilioService) SendSMS(to string, message string, from string) error {
 	params.SetTo(to)
 	params.SetFrom(fromNumber)
 	params.SetBody(message)
+	// Delivery receipts are posted to the status callback when configured
+	if cfg := config.Get().Twilio; cfg != nil && cfg.StatusCallback != "" {
+		params.SetStatusCallback(cfg.StatusCallback)
+	}
 
 	// Send the message
 	resp, err := account.Client.Api.CreateMessage(params)
 	if err != nil {
-		return fmt.Errorf("failed to send SMS via Twilio: %v", err)
+		return "", fmt.Errorf("failed to send SMS via Twilio: %v", err)
 	}
 
 	// Log the message SID for tracking
+	sid := ""
 	if resp.Sid != nil {
-		log.Get().Infof("SMS sent via Twilio with SID: %s", *resp.Sid)
+		sid = *resp.Sid
+		log.Get().Infof("SMS sent via Twilio with SID: %s", sid)
 	}
 
-	return nil
+	return sid, nil
 }
 
 // ServiceName returns the service name
@@ -126,9 +138,15 @@ func (t *TwilioService) ServiceName() string {
 // SendCustomVerificationCode sends a custom verification code using Twilio Verify API with custom content
 // Uses Twilio Verify API's customization options for better security and compliance
 func (t *TwilioService) SendCustomVerificationCode(to, code, locale string) error {
+	_, err := t.sendCustomVerificationCode(to, code, locale)
+	return err
+}
+
+// sendCustomVerificationCode sends a verification code via Twilio Verify API and returns the verification SID
+func (t *TwilioService) sendCustomVerificationCode(to, code, locale string) (string, error) {
 	account := t.defaultAccount
 	if account == nil || account.ServiceSID == "" {
-		return fmt.Errorf("no Twilio Verify service configured")
+		return "", fmt.Errorf("no Twilio Verify service configured")
 	}
 
 	// Create verification parameters
@@ -149,11 +167,15 @@ func (t *TwilioService) SendCustomVerificationCode(to, code, locale string) erro
 	// Send verification code using Verify API
 	resp, err := account.Client.VerifyV2.CreateVerification(account.ServiceSID, params)
 	if err != nil {
-		return fmt.Errorf("failed to send custom verification code: %v", err)
+		return "", fmt.Errorf("failed to send custom verification code: %v", err)
 	}
 
-	log.Get().Infof("Custom verification code [%v] sent to %s with locale %s, SID: %s", code, to, locale, *resp.Sid)
-	return nil
+	sid := ""
+	if resp.Sid != nil {
+		sid = *resp.Sid
+	}
+	log.Get().Infof("Custom verification code [%v] sent to %s with locale %s, SID: %s", code, to, locale, sid)
+	return sid, nil
 }
 
 // VerifyCode verifies a code using Twilio Verify API
@@ -42,9 +42,9 @@ func HandleUserRegistered(ctx context.Context, params protocol.MapData) error {
 		// 错误不中断流程，继续处理邀请关系
 	}
 
-	// 2. 处理邀请关系 - 给邀请人发放推荐优惠券
-	if err := IssueReferralCouponForInviter(userID); err != nil {
-		logger.Errorf("Failed to issue referral coupon to inviter: %v", err)
+	// 2. 处理邀请关系 - 记录邀请并做风控检查，奖励在被邀请人完成订单后发放
+	if err := GetReferralService().RecordReferral(userID); err != nil {
+		logger.Errorf("Failed to record referral: %v", err)
 		// 错误记录日志但不影响整体流程
 	}
 
@@ -113,77 +113,3 @@ func IssueWelcomeCouponForUser(userID string) error {
 	logger.Infof("Welcome coupon issued successfully: %s", userPromotion.GetCode())
 	return nil
 }
-
-// IssueReferralCouponForInviter 为邀请人发放推荐优惠券
-func IssueReferralCouponForInviter(invitedUserID string) error {
-	logger := log.Get().WithField("invited_user_id", invitedUserID)
-
-	// 1. 获取配置
-	promotionConfig := config.GetPromotionConfig()
-
-	// 2. 检查是否启用推荐优惠券
-	if promotionConfig.EnableReferralCoupon != config.StatusOn {
-		logger.Debug("Referral coupon issuance is disabled")
-		return nil
-	}
-
-	// 3. 获取新用户信息
-	invitedUser := models.GetUserByID(invitedUserID)
-	if invitedUser == nil {
-		return fmt.Errorf("invited user not found: %s", invitedUserID)
-	}
-
-	// 5. 获取邀请人信息
-	inviter := models.GetUserByID(invitedUser.GetInvitedBy())
-	if inviter == nil {
-		logger.Warnf("Inviter not found for user: %s", invitedUser.GetInvitedBy())
-		return nil
-	}
-
-	// 只有乘客才能获得推荐优惠券
-	if !inviter.IsPassenger() {
-		logger.Debug("Skip referral coupon issuance for non-passenger inviter")
-		return nil
-	}
-
-	logger = logger.WithField("inviter_id", inviter.UserID)
-
-	// 6. 获取或创建推荐优惠券模板
-	var promotion *models.Promotion
-	if promotionConfig.ReferralCouponCode != "" {
-		promotion = models.GetReferralPromotionTemplate(promotionConfig.ReferralCouponCode)
-	}
-
-	// 如果指定的优惠券模板不存在，使用默认模板
-	if promotion == nil {
-		promotion = models.GetOrCreateDefaultReferralPromotion()
-		if promotion == nil {
-			return fmt.Errorf("failed to get or create referral promotion template")
-		}
-		logger.Infof("Using default referral promotion template: %s", promotion.GetCode())
-	}
-
-	// 7. 创建用户优惠券实例
-	userPromotion := models.CreateReferralPromoForUser(inviter.UserID, invitedUserID, promotion)
-
-	// 8. 设置过期时间
-	if promotionConfig.ReferralCouponValidDays > 0 {
-		expiredAt := utils.TimeNowMilli() + int64(promotionConfig.ReferralCouponValidDays*24*3600*1000)
-		userPromotion.ExpiredAt = &expiredAt
-	}
-
-	// 9. 保存到数据库
-	if err := models.CreateUserPromotionInDB(userPromotion); err != nil {
-		return fmt.Errorf("failed to create referral promotion: %v", err)
-	}
-
-	// 10. 更新邀请统计
-	if err := models.IncrementUserInviteCount(inviter.UserID); err != nil {
-		logger.Warnf("Failed to increment invite count: %v", err)
-		// 不影响优惠券发放，继续执行
-	}
-
-	logger.Infof("Referral coupon issued successfully to inviter %s: %s",
-		inviter.UserID, userPromotion.GetCode())
-	return nil
-}
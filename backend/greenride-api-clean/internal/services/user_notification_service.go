@@ -0,0 +1,197 @@
+package services
+
+import (
+	"sync"
+
+	"greenride/internal/log"
+	"greenride/internal/models"
+	"greenride/internal/protocol"
+	"greenride/internal/utils"
+
+	"github.com/spf13/cast"
+)
+
+// 推送消息类型对应的通知分类，未列出的类型归为系统通知
+var notificationCategoryByMsgType = map[string]string{
+	protocol.MsgTypePassengerOrderAccepted:    protocol.NotificationCategoryTrip,
+	protocol.MsgTypePassengerDriverArrived:    protocol.NotificationCategoryTrip,
+	protocol.MsgTypePassengerTripStarted:      protocol.NotificationCategoryTrip,
+	protocol.MsgTypePassengerTripEnded:        protocol.NotificationCategoryTrip,
+	protocol.MsgTypePassengerOrderCancelled:   protocol.NotificationCategoryTrip,
+	protocol.MsgTypeDriverNewOrder:            protocol.NotificationCategoryTrip,
+	protocol.MsgTypeDriverTripEnded:           protocol.NotificationCategoryTrip,
+	protocol.MsgTypeDriverOrderCancelled:      protocol.NotificationCategoryTrip,
+	protocol.MsgTypePassengerPaymentConfirmed: protocol.NotificationCategoryPayment,
+	protocol.MsgTypeDriverPaymentConfirmed:    protocol.NotificationCategoryPayment,
+	protocol.MsgTypeDriverTipReceived:         protocol.NotificationCategoryPayment,
+	protocol.MsgTypePassengerPaymentFailed:    protocol.NotificationCategoryPayment,
+	protocol.MsgTypePassengerPaymentOverdue:   protocol.NotificationCategoryPayment,
+	protocol.MsgTypePassengerCouponIssued:     protocol.NotificationCategoryMarketing,
+}
+
+// notificationCategory 推送消息类型对应的通知分类
+func notificationCategory(msgType string) string {
+	if category, ok := notificationCategoryByMsgType[msgType]; ok {
+		return category
+	}
+	return protocol.NotificationCategorySystem
+}
+
+// notificationRelated 从推送参数中取关联对象，供App点击通知后跳转
+func notificationRelated(params map[string]any) (string, string) {
+	if orderID := cast.ToString(params["OrderID"]); orderID != "" {
+		return "order", orderID
+	}
+	if promotionID := cast.ToString(params["promotion_id"]); promotionID != "" {
+		return "promotion", promotionID
+	}
+	return "", ""
+}
+
+// UserNotificationService 乘客和司机的站内通知及推送偏好
+type UserNotificationService struct {
+	push func(message *Message) error // 发送推送，默认走消息服务
+}
+
+var (
+	userNotificationServiceInstance *UserNotificationService
+	userNotificationServiceOnce     sync.Once
+)
+
+// GetUserNotificationService 获取用户通知服务实例
+func GetUserNotificationService() *UserNotificationService {
+	userNotificationServiceOnce.Do(func() {
+		userNotificationServiceInstance = &UserNotificationService{
+			push: func(message *Message) error { return GetMessageService().SendMessage(message) },
+		}
+	})
+	return userNotificationServiceInstance
+}
+
+// Deliver 将推送写入用户站内通知，再按用户的分类偏好决定是否推送
+func (s *UserNotificationService) Deliver(user *models.User, message *Message) error {
+	category := notificationCategory(message.Type)
+	if notificationID := s.recordInbox(user, category, message); notificationID != "" {
+		// 推送数据带上通知ID，App点击推送时可直接标记已读
+		if message.Params == nil {
+			message.Params = make(map[string]any)
+		}
+		message.Params["notification_id"] = notificationID
+	}
+
+	if !models.GetNotificationPreference(user.UserID).PushEnabled(category) {
+		log.Get().Infof("用户 %s 已关闭 %s 类推送，仅保存站内通知: %s", user.UserID, category, message.Type)
+		return nil
+	}
+	return s.push(message)
+}
+
+// recordInbox 按推送模板渲染标题和内容并保存为站内通知，失败不影响推送
+func (s *UserNotificationService) recordInbox(user *models.User, category string, message *Message) string {
+	prepared := GetMessageService().PrepareMessage(message, protocol.MsgChannelFcm)
+	if prepared == nil {
+		log.Get().Warnf("站内通知模板不存在, type=%s, language=%s", message.Type, message.Language)
+		return ""
+	}
+	content := cast.ToString(prepared.Params["content"])
+	if content == "" {
+		return ""
+	}
+
+	notification := models.NewNotificationV2()
+	notification.SetUserID(user.UserID)
+	notification.UserType = utils.StringPtr(user.GetUserType())
+	notification.SetType(message.Type)
+	notification.Category = utils.StringPtr(category)
+	notification.SetTitle(cast.ToString(prepared.Params["title"]))
+	notification.SetContent(content)
+	_ = notification.SetChannels([]string{"push"})
+	_ = notification.MarkAsSent()
+	if relatedType, relatedID := notificationRelated(message.Params); relatedID != "" {
+		notification.SetRelated(relatedType, relatedID)
+	}
+	if notificationType := cast.ToString(message.Params["notification_type"]); notificationType != "" {
+		if metadata, err := utils.ToJSON(map[string]string{"notification_type": notificationType}); err == nil {
+			notification.Metadata = &metadata
+		}
+	}
+
+	if err := models.GetDB().Create(notification).Error; err != nil {
+		log.Get().Errorf("保存站内通知失败, user=%s, type=%s: %v", user.UserID, message.Type, err)
+		return ""
+	}
+	return notification.NotificationID
+}
+
+// ListNotifications 分页获取用户的站内通知
+func (s *UserNotificationService) ListNotifications(userID string, req *protocol.UserNotificationListRequest) ([]*protocol.UserNotification, int64, protocol.ErrorCode) {
+	notifications, total, err := models.SearchUserNotifications(userID, req.Category, req.UnreadOnly, req.Page, req.Limit)
+	if err != nil {
+		log.Get().Errorf("查询用户 %s 站内通知失败: %v", userID, err)
+		return nil, 0, protocol.DatabaseError
+	}
+	list := make([]*protocol.UserNotification, 0, len(notifications))
+	for _, n := range notifications {
+		list = append(list, n.Protocol())
+	}
+	return list, total, protocol.Success
+}
+
+// GetUnreadCount 获取用户未读通知数，按分类统计
+func (s *UserNotificationService) GetUnreadCount(userID string) (*protocol.NotificationUnreadCount, protocol.ErrorCode) {
+	counts, err := models.CountUserUnreadNotifications(userID)
+	if err != nil {
+		log.Get().Errorf("统计用户 %s 未读通知失败: %v", userID, err)
+		return nil, protocol.DatabaseError
+	}
+	result := &protocol.NotificationUnreadCount{ByCategory: counts}
+	for _, count := range counts {
+		result.Total += count
+	}
+	return result, protocol.Success
+}
+
+// MarkRead 标记已读，未指定通知ID时标记全部（可限定分类）
+func (s *UserNotificationService) MarkRead(userID string, req *protocol.UserNotificationActionRequest) protocol.ErrorCode {
+	if _, err := models.MarkUserNotificationsRead(userID, req.NotificationIDs, req.Category); err != nil {
+		log.Get().Errorf("标记用户 %s 通知已读失败: %v", userID, err)
+		return protocol.DatabaseError
+	}
+	return protocol.Success
+}
+
+// DeleteNotifications 删除用户自己的通知
+func (s *UserNotificationService) DeleteNotifications(userID string, req *protocol.UserNotificationActionRequest) protocol.ErrorCode {
+	if len(req.NotificationIDs) == 0 {
+		return protocol.InvalidParams
+	}
+	if _, err := models.DeleteUserNotifications(userID, req.NotificationIDs); err != nil {
+		log.Get().Errorf("删除用户 %s 通知失败: %v", userID, err)
+		return protocol.DatabaseError
+	}
+	return protocol.Success
+}
+
+// GetPreferences 获取用户推送偏好
+func (s *UserNotificationService) GetPreferences(userID string) *protocol.NotificationPreferences {
+	return models.GetNotificationPreference(userID).Protocol()
+}
+
+// UpdatePreferences 更新用户推送偏好，未传的分类保持不变
+func (s *UserNotificationService) UpdatePreferences(userID string, req *protocol.UpdateNotificationPreferencesRequest) (*protocol.NotificationPreferences, protocol.ErrorCode) {
+	pref := models.GetNotificationPreference(userID)
+	if req.Trip != nil {
+		pref.TripPush = utils.BoolPtr(*req.Trip)
+	}
+	if req.Payment != nil {
+		pref.PaymentPush = utils.BoolPtr(*req.Payment)
+	}
+	if req.Marketing != nil {
+		pref.MarketingPush = utils.BoolPtr(*req.Marketing)
+	}
+	if err := models.SaveNotificationPreference(pref); err != nil {
+		log.Get().Errorf("保存用户 %s 推送偏好失败: %v", userID, err)
+		return nil, protocol.DatabaseError
+	}
+	return pref.Protocol(), protocol.Success
+}
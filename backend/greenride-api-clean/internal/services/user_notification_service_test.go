@@ -0,0 +1,105 @@
+package services
+
+import (
+	"html/template"
+	"testing"
+
+	"greenride/internal/models"
+	"greenride/internal/protocol"
+	"greenride/internal/utils"
+)
+
+func TestNotificationCategory(t *testing.T) {
+	cases := map[string]string{
+		protocol.MsgTypePassengerTripEnded:        protocol.NotificationCategoryTrip,
+		protocol.MsgTypeDriverOrderCancelled:      protocol.NotificationCategoryTrip,
+		protocol.MsgTypePassengerPaymentConfirmed: protocol.NotificationCategoryPayment,
+		protocol.MsgTypePassengerCouponIssued:     protocol.NotificationCategoryMarketing,
+		protocol.MsgTypeDriverDocumentExpired:     protocol.NotificationCategorySystem,
+	}
+	for msgType, want := range cases {
+		if got := notificationCategory(msgType); got != want {
+			t.Errorf("%s: category = %s, want %s", msgType, got, want)
+		}
+	}
+
+	if relatedType, relatedID := notificationRelated(map[string]any{"OrderID": "O1", "promotion_id": "P1"}); relatedType != "order" || relatedID != "O1" {
+		t.Errorf("order related = %s/%s", relatedType, relatedID)
+	}
+	if relatedType, relatedID := notificationRelated(map[string]any{"promotion_id": "P1"}); relatedType != "promotion" || relatedID != "P1" {
+		t.Errorf("promotion related = %s/%s", relatedType, relatedID)
+	}
+}
+
+func TestNotificationPreferencePushEnabled(t *testing.T) {
+	pref := models.NewNotificationPreference("U1")
+	for _, category := range []string{protocol.NotificationCategoryTrip, protocol.NotificationCategoryPayment, protocol.NotificationCategoryMarketing, protocol.NotificationCategorySystem} {
+		if !pref.PushEnabled(category) {
+			t.Errorf("%s should be enabled by default", category)
+		}
+	}
+
+	pref.MarketingPush = utils.BoolPtr(false)
+	pref.TripPush = utils.BoolPtr(false)
+	if pref.PushEnabled(protocol.NotificationCategoryMarketing) || pref.PushEnabled(protocol.NotificationCategoryTrip) {
+		t.Error("disabled categories should not push")
+	}
+	if !pref.PushEnabled(protocol.NotificationCategoryPayment) || !pref.PushEnabled(protocol.NotificationCategorySystem) {
+		t.Error("payment and system should still push")
+	}
+	if got := pref.Protocol(); got.Trip || !got.Payment || got.Marketing {
+		t.Errorf("protocol = %+v", got)
+	}
+}
+
+func TestDeliverSkipsPushForDisabledCategory(t *testing.T) {
+	setupTestDB(t, &models.Notification{}, &models.NotificationPreference{})
+	previous := messageService
+	t.Cleanup(func() { messageService = previous })
+	messageService = &MessageService{TemplateService: &MessageTemplateService{TemplateLib: map[string]*protocol.MessageTemplate{
+		"coupon": {
+			Type:    protocol.MsgTypePassengerCouponIssued,
+			Channel: protocol.MsgChannelFcm,
+			Title:   template.Must(template.New("title").Parse("New coupon")),
+			Content: template.Must(template.New("content").Parse("You received a coupon")),
+		},
+	}}}
+
+	var pushed []*Message
+	service := &UserNotificationService{push: func(message *Message) error {
+		pushed = append(pushed, message)
+		return nil
+	}}
+	user := models.NewUser()
+	pref := models.NewNotificationPreference(user.UserID)
+	pref.MarketingPush = utils.BoolPtr(false)
+	if err := models.SaveNotificationPreference(pref); err != nil {
+		t.Fatalf("save preference: %v", err)
+	}
+
+	// 未设置Params的消息不能panic
+	message := &Message{Type: protocol.MsgTypePassengerCouponIssued, Channels: []string{protocol.MsgChannelFcm}}
+	if err := service.Deliver(user, message); err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+	if len(pushed) != 0 {
+		t.Fatalf("marketing push disabled, got %d pushes", len(pushed))
+	}
+
+	var inbox []*models.Notification
+	models.GetDB().Where("user_id = ?", user.UserID).Find(&inbox)
+	if len(inbox) != 1 || inbox[0].GetCategory() != protocol.NotificationCategoryMarketing {
+		t.Fatalf("inbox rows = %d, want 1 marketing notification", len(inbox))
+	}
+	if message.Params["notification_id"] != inbox[0].NotificationID {
+		t.Fatalf("notification_id = %v, want %s", message.Params["notification_id"], inbox[0].NotificationID)
+	}
+
+	// 未关闭的分类照常推送
+	if err := service.Deliver(user, &Message{Type: protocol.MsgTypePassengerTripEnded, Params: map[string]any{"OrderID": "O1"}}); err != nil {
+		t.Fatalf("deliver trip: %v", err)
+	}
+	if len(pushed) != 1 {
+		t.Fatalf("trip push should be sent, got %d pushes", len(pushed))
+	}
+}
@@ -125,6 +125,10 @@ func (s *UserService) UserOnline(req protocol.UserOnlineRequest) protocol.ErrorC
 	if !user.IsDriver() || user.GetStatus() != protocol.StatusActive || user.IsDeleted() {
 		return protocol.PermissionDenied
 	}
+	// 必需证件审核通过且未过期才能上线
+	if errCode := GetDriverDocumentService().CheckDriverCanGoOnline(req.UserID); errCode != protocol.Success {
+		return errCode
+	}
 
 	vehicle := models.GetVehicleByID(req.VehicleID) // 预加载车辆缓存
 	if vehicle == nil {
@@ -448,6 +452,14 @@ func (s *UserService) UpdateUserLocation(req *protocol.UpdateLocationRequest) pr
 	if req.OnlineStatus != "" {
 		onlineStatus = req.OnlineStatus
 	}
+	// 离线司机通过位置上报上线时同样需要证件齐全
+	var onlineErr protocol.ErrorCode
+	if user.IsDriver() && onlineStatus == protocol.StatusOnline && user.GetOnlineStatus() != protocol.StatusOnline {
+		if errCode := GetDriverDocumentService().CheckDriverCanGoOnline(req.UserID); errCode != protocol.Success {
+			onlineStatus = protocol.StatusOffline
+			onlineErr = errCode
+		}
+	}
 
 	// 1. 更新用户表中的最新位置
 	values := &models.UserValues{}
@@ -485,6 +497,9 @@ func (s *UserService) UpdateUserLocation(req *protocol.UpdateLocationRequest) pr
 	if user.IsDriver() {
 		go s.RefreshDriverLocationRuntimeCache(req.UserID)
 	}
+	if onlineErr != "" {
+		return onlineErr
+	}
 	return protocol.Success
 }
 
@@ -550,6 +565,10 @@ func (s *UserService) RefreshDriverRuntimeCache(driverID string) (data *protocol
 	if vehicle != nil {
 		data.VehicleID = vehicle.VehicleID
 	}
+	if pool := models.GetOpenRidePoolByDriver(user.UserID); pool != nil {
+		data.PoolID = pool.PoolID
+		data.AvailableSeats = max(pool.GetSeatCapacity()-pool.GetBookedSeats(), 0)
+	}
 
 	orderIds := []string{}
 	if user.GetCurrentOrderID() != "" {
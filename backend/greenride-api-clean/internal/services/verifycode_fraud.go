@@ -0,0 +1,365 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"math/bits"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"greenride/internal/config"
+	"greenride/internal/log"
+	"greenride/internal/models"
+	"greenride/internal/protocol"
+	"greenride/internal/utils"
+)
+
+const (
+	otpFraudDayFormat    = "20060102"
+	otpFraudStatsTTL     = 90 * 24 * time.Hour
+	otpFraudCounterDay   = 24 * time.Hour
+	otpFraudCounterHour  = time.Hour
+	otpSpendKey          = "otp:spend:%s"   // hash: provider:count / provider:cost
+	otpSignalKey         = "otp:signals:%s" // hash: signal -> count
+	otpChallengeKey      = "otp:challenge:%s"
+	otpPhoneSendsKey     = "otp:sends:phone:%s"
+	otpIPSendsKey        = "otp:sends:ip:%s"
+	otpIPHourKey         = "otp:velocity:ip:%s"
+	otpDeviceHourKey     = "otp:velocity:device:%s"
+	otpPrefixBudgetKey   = "otp:budget:prefix:%s:%s"
+	otpCountryBudgetKey  = "otp:budget:country:%s:%s"
+	otpSpendFieldCount   = "%s:count"
+	otpSpendFieldCost    = "%s:cost"
+	otpDefaultSpendDays  = 7
+	otpMaxSpendDays      = 90
+	otpMaxChallengeNonce = 64
+)
+
+// OTPSendContext 发送验证码时的请求上下文，用于设备/IP风控和工作量证明
+type OTPSendContext struct {
+	IP             string
+	DeviceID       string
+	ChallengeID    string
+	ChallengeNonce string
+}
+
+// shortCallingCodes 1位和2位的国际区号，其余均为3位
+var shortCallingCodes = map[string]bool{
+	"1": true, "7": true,
+	"20": true, "27": true, "30": true, "31": true, "32": true, "33": true, "34": true, "36": true, "39": true,
+	"40": true, "41": true, "43": true, "44": true, "45": true, "46": true, "47": true, "48": true, "49": true,
+	"51": true, "52": true, "53": true, "54": true, "55": true, "56": true, "57": true, "58": true,
+	"60": true, "61": true, "62": true, "63": true, "64": true, "65": true, "66": true,
+	"81": true, "82": true, "84": true, "86": true,
+	"90": true, "91": true, "92": true, "93": true, "94": true, "95": true, "98": true,
+}
+
+// callingCodeOf 从E.164号码中解析国际区号（不含+）
+func callingCodeOf(phone string) string {
+	digits := strings.TrimPrefix(phone, "+")
+	for _, n := range []int{1, 2} {
+		if len(digits) > n && shortCallingCodes[digits[:n]] {
+			return digits[:n]
+		}
+	}
+	if len(digits) >= 3 {
+		return digits[:3]
+	}
+	return digits
+}
+
+// phonePrefixOf 获取号码的号段（前N位数字）
+func phonePrefixOf(phone string, length int) string {
+	digits := strings.TrimPrefix(phone, "+")
+	if len(digits) > length {
+		return digits[:length]
+	}
+	return digits
+}
+
+// verifyProofOfWork 校验 sha256(challenge + ":" + nonce) 是否具有足够的前导零比特
+func verifyProofOfWork(challenge, nonce string, difficulty int) bool {
+	if challenge == "" || nonce == "" || len(nonce) > otpMaxChallengeNonce {
+		return false
+	}
+	sum := sha256.Sum256([]byte(challenge + ":" + nonce))
+	zeros := 0
+	for _, b := range sum {
+		if b == 0 {
+			zeros += 8
+			continue
+		}
+		zeros += bits.LeadingZeros8(b)
+		break
+	}
+	return zeros >= difficulty
+}
+
+// fraudConfig 获取防刷配置（带默认值）
+func (s *VerifyCodeService) fraudConfig() *config.OTPFraudConfig {
+	if s.config != nil && s.config.Fraud != nil {
+		return s.config.Fraud
+	}
+	cfg := &config.OTPFraudConfig{}
+	cfg.Validate()
+	return cfg
+}
+
+// checkSMSBudget 检查号码段黑名单及号段、国家的每日短信预算，验证码以外的系统短信（如配送取件码）同样适用
+func (s *VerifyCodeService) checkSMSBudget(ctx *OTPSendContext, phone string) protocol.ErrorCode {
+	cfg := s.fraudConfig()
+	today := time.Now().Format(otpFraudDayFormat)
+
+	for _, prefix := range cfg.BlockedPrefixes {
+		if prefix != "" && strings.HasPrefix(phone, prefix) {
+			s.recordOTPSignal(protocol.OTPSignalBlockedPrefix, phone, ctx)
+			return protocol.PhoneNumberBlocked
+		}
+	}
+	if s.otpCount(fmt.Sprintf(otpPrefixBudgetKey, today, phonePrefixOf(phone, cfg.PrefixLength))) >= int64(cfg.PrefixDailyBudget) {
+		s.recordOTPSignal(protocol.OTPSignalPrefixBudget, phone, ctx)
+		return protocol.VerificationBudgetExceeded
+	}
+	callingCode := callingCodeOf(phone)
+	if s.otpCount(fmt.Sprintf(otpCountryBudgetKey, today, callingCode)) >= int64(cfg.GetCountryDailyBudget(callingCode)) {
+		s.recordOTPSignal(protocol.OTPSignalCountryBudget, phone, ctx)
+		return protocol.VerificationBudgetExceeded
+	}
+	return protocol.Success
+}
+
+// recordSMSBudget 短信发送成功后累加号段和国家的每日预算计数
+func (s *VerifyCodeService) recordSMSBudget(phone string) {
+	cfg := s.fraudConfig()
+	today := time.Now().Format(otpFraudDayFormat)
+	otpIncr(fmt.Sprintf(otpPrefixBudgetKey, today, phonePrefixOf(phone, cfg.PrefixLength)), otpFraudCounterDay)
+	otpIncr(fmt.Sprintf(otpCountryBudgetKey, today, callingCodeOf(phone)), otpFraudCounterDay)
+}
+
+// checkOTPFraud 发送短信验证码前的防刷检查
+func (s *VerifyCodeService) checkOTPFraud(ctx *OTPSendContext, phone string) protocol.ErrorCode {
+	cfg := s.fraudConfig()
+
+	if ctx != nil && ctx.IP != "" && s.otpCount(fmt.Sprintf(otpIPHourKey, ctx.IP)) >= int64(cfg.IPHourlyLimit) {
+		s.recordOTPSignal(protocol.OTPSignalIPVelocity, phone, ctx)
+		return protocol.TooManyAttempts
+	}
+	if ctx != nil && ctx.DeviceID != "" && s.otpCount(fmt.Sprintf(otpDeviceHourKey, ctx.DeviceID)) >= int64(cfg.DeviceHourlyLimit) {
+		s.recordOTPSignal(protocol.OTPSignalDeviceVelocity, phone, ctx)
+		return protocol.TooManyAttempts
+	}
+
+	if errCode := s.checkSMSBudget(ctx, phone); errCode != protocol.Success {
+		return errCode
+	}
+
+	// 同一号码或IP 24小时内发送次数过多时，要求客户端完成工作量证明
+	sends := s.otpCount(fmt.Sprintf(otpPhoneSendsKey, phone))
+	if ctx != nil && ctx.IP != "" {
+		sends = max(sends, s.otpCount(fmt.Sprintf(otpIPSendsKey, ctx.IP)))
+	}
+	if sends >= int64(cfg.ChallengeAfterSends) {
+		if ctx == nil || ctx.ChallengeID == "" {
+			s.recordOTPSignal(protocol.OTPSignalChallenge, phone, ctx)
+			return protocol.VerificationChallenge
+		}
+		if !s.consumeOTPChallenge(ctx.ChallengeID, ctx.ChallengeNonce, phone) {
+			s.recordOTPSignal(protocol.OTPSignalChallengeFail, phone, ctx)
+			return protocol.VerificationChallengeFail
+		}
+	}
+
+	return protocol.Success
+}
+
+// recordOTPSend 验证码发送成功后累加各维度计数
+func (s *VerifyCodeService) recordOTPSend(ctx *OTPSendContext, phone string) {
+	otpIncr(fmt.Sprintf(otpPhoneSendsKey, phone), otpFraudCounterDay)
+	s.recordSMSBudget(phone)
+	if ctx == nil {
+		return
+	}
+	if ctx.IP != "" {
+		otpIncr(fmt.Sprintf(otpIPSendsKey, ctx.IP), otpFraudCounterDay)
+		otpIncr(fmt.Sprintf(otpIPHourKey, ctx.IP), otpFraudCounterHour)
+	}
+	if ctx.DeviceID != "" {
+		otpIncr(fmt.Sprintf(otpDeviceHourKey, ctx.DeviceID), otpFraudCounterHour)
+	}
+}
+
+// IssueOTPChallenge 为号码签发一次性工作量证明挑战
+func (s *VerifyCodeService) IssueOTPChallenge(phone string) (*protocol.OTPChallenge, protocol.ErrorCode) {
+	normalized, ok := normalizeSMSPhone(phone)
+	if !ok {
+		return nil, protocol.InvalidParams
+	}
+	cfg := s.fraudConfig()
+	challenge := &protocol.OTPChallenge{
+		ChallengeID: utils.GenerateUUID(),
+		Difficulty:  cfg.ChallengeDifficulty,
+		ExpiresIn:   cfg.ChallengeTTL,
+	}
+	key := models.FormatCacheKey(otpChallengeKey, challenge.ChallengeID)
+	if err := models.SetCache(key, normalized, time.Duration(cfg.ChallengeTTL)*time.Second); err != nil {
+		log.Get().Errorf("[OTP] Failed to store challenge for %s: %v", normalized, err)
+		return nil, protocol.CacheError
+	}
+	return challenge, protocol.Success
+}
+
+// consumeOTPChallenge 校验并销毁挑战（一次性）
+func (s *VerifyCodeService) consumeOTPChallenge(challengeID, nonce, phone string) bool {
+	key := models.FormatCacheKey(otpChallengeKey, challengeID)
+	boundPhone, err := models.GetCache(key)
+	if err != nil || boundPhone != phone {
+		return false
+	}
+	_ = models.DelCache(key)
+	return verifyProofOfWork(challengeID, nonce, s.fraudConfig().ChallengeDifficulty)
+}
+
+// recordOTPSignal 记录防刷拦截信号
+func (s *VerifyCodeService) recordOTPSignal(signal, phone string, ctx *OTPSendContext) {
+	ip, deviceID := "", ""
+	if ctx != nil {
+		ip, deviceID = ctx.IP, ctx.DeviceID
+	}
+	log.Get().Warnf("[OTP] Fraud signal %s: phone=%s ip=%s device=%s", signal, utils.MaskPhone(phone), ip, deviceID)
+
+	rdb := models.GetRedis()
+	if rdb == nil {
+		return
+	}
+	key := models.FormatCacheKey(otpSignalKey, time.Now().Format(otpFraudDayFormat))
+	c := context.Background()
+	pipe := rdb.TxPipeline()
+	pipe.HIncrBy(c, key, signal, 1)
+	pipe.Expire(c, key, otpFraudStatsTTL)
+	if _, err := pipe.Exec(c); err != nil {
+		log.Get().Warnf("[OTP] Failed to record fraud signal %s: %v", signal, err)
+	}
+}
+
+// recordOTPSpend 记录验证码短信的发送量与成本（按服务商、按天）
+func recordOTPSpend(provider string) {
+	rdb := models.GetRedis()
+	if rdb == nil || provider == "" {
+		return
+	}
+	cost := GetVerifyCodeService().fraudConfig().GetProviderCost(provider)
+	key := models.FormatCacheKey(otpSpendKey, time.Now().Format(otpFraudDayFormat))
+	c := context.Background()
+	pipe := rdb.TxPipeline()
+	pipe.HIncrBy(c, key, fmt.Sprintf(otpSpendFieldCount, provider), 1)
+	pipe.HIncrByFloat(c, key, fmt.Sprintf(otpSpendFieldCost, provider), cost)
+	pipe.Expire(c, key, otpFraudStatsTTL)
+	if _, err := pipe.Exec(c); err != nil {
+		log.Get().Warnf("[OTP] Failed to record spend for %s: %v", provider, err)
+	}
+}
+
+// GetOTPSpendStats 获取最近N天验证码短信的发送量、成本和防刷信号统计
+func (s *VerifyCodeService) GetOTPSpendStats(days int) ([]*protocol.OTPSpendDay, protocol.ErrorCode) {
+	if days <= 0 {
+		days = otpDefaultSpendDays
+	}
+	if days > otpMaxSpendDays {
+		days = otpMaxSpendDays
+	}
+
+	result := make([]*protocol.OTPSpendDay, 0, days)
+	rdb := models.GetRedis()
+	now := time.Now()
+	c := context.Background()
+	for i := 0; i < days; i++ {
+		day := now.AddDate(0, 0, -i)
+		item := &protocol.OTPSpendDay{
+			Date:      day.Format("2006-01-02"),
+			Providers: make([]*protocol.OTPProviderSpend, 0),
+			Signals:   make(map[string]int64),
+		}
+		result = append(result, item)
+		if rdb == nil {
+			continue
+		}
+
+		spend, err := rdb.HGetAll(c, models.FormatCacheKey(otpSpendKey, day.Format(otpFraudDayFormat))).Result()
+		if err != nil {
+			log.Get().Errorf("[OTP] Failed to load spend stats: %v", err)
+			return nil, protocol.CacheError
+		}
+		providers := make(map[string]*protocol.OTPProviderSpend)
+		for field, value := range spend {
+			provider, metric, ok := strings.Cut(field, ":")
+			if !ok {
+				continue
+			}
+			p, exists := providers[provider]
+			if !exists {
+				p = &protocol.OTPProviderSpend{Provider: provider}
+				providers[provider] = p
+			}
+			switch metric {
+			case "count":
+				p.Count, _ = strconv.ParseInt(value, 10, 64)
+				item.Count += p.Count
+			case "cost":
+				p.Cost, _ = strconv.ParseFloat(value, 64)
+				item.Cost += p.Cost
+			}
+		}
+		for _, p := range providers {
+			item.Providers = append(item.Providers, p)
+		}
+		sort.Slice(item.Providers, func(i, j int) bool { return item.Providers[i].Provider < item.Providers[j].Provider })
+
+		signals, err := rdb.HGetAll(c, models.FormatCacheKey(otpSignalKey, day.Format(otpFraudDayFormat))).Result()
+		if err != nil {
+			log.Get().Errorf("[OTP] Failed to load fraud signals: %v", err)
+			return nil, protocol.CacheError
+		}
+		for signal, value := range signals {
+			item.Signals[signal], _ = strconv.ParseInt(value, 10, 64)
+		}
+	}
+	return result, protocol.Success
+}
+
+// otpCounter 读取计数器，Redis不可用时返回0
+func otpCounter(key string) int64 {
+	value, err := models.GetInt(models.FormatCacheKey("%s", key))
+	if err != nil {
+		return 0
+	}
+	return value
+}
+
+// otpCount 读取防刷计数器
+func (s *VerifyCodeService) otpCount(key string) int64 {
+	if s.counter != nil {
+		return s.counter(key)
+	}
+	return otpCounter(key)
+}
+
+// otpIncr 累加计数器（首次写入时设置过期时间）
+func otpIncr(key string, expiration time.Duration) {
+	rdb := models.GetRedis()
+	if rdb == nil {
+		return
+	}
+	c := context.Background()
+	fullKey := models.FormatCacheKey("%s", key)
+	count, err := rdb.Incr(c, fullKey).Result()
+	if err != nil {
+		log.Get().Warnf("[OTP] Failed to increment %s: %v", fullKey, err)
+		return
+	}
+	if count == 1 {
+		rdb.Expire(c, fullKey, expiration)
+	}
+}
@@ -18,6 +18,7 @@ import (
 type VerifyCodeService struct {
 	config     *config.VerifyCodeConfig
 	msgService *MessageService
+	counter    func(key string) int64 // 防刷计数读取，默认读Redis
 }
 
 var (
@@ -93,6 +94,11 @@ func (s *VerifyCodeService) setInt64ToCache(key string, value int64, expiration
 
 // SendVerifyCode sends verification code via email or SMS
 func (s *VerifyCodeService) SendVerifyCode(contactType, contact, user_type, purpose, language string) (protocol.ErrorCode, int) {
+	return s.SendVerifyCodeWithContext(nil, contactType, contact, user_type, purpose, language)
+}
+
+// SendVerifyCodeWithContext sends verification code with request context (IP/device/challenge) for SMS abuse checks
+func (s *VerifyCodeService) SendVerifyCodeWithContext(ctx *OTPSendContext, contactType, contact, user_type, purpose, language string) (protocol.ErrorCode, int) {
 	// CRITICAL: Check if service itself is nil (happens if setup failed)
 	if s == nil {
 		log.Get().Error("VerifyCodeService is nil - service not initialized")
@@ -147,6 +153,13 @@ func (s *VerifyCodeService) SendVerifyCode(contactType, contact, user_type, purp
 		sandboxReason = "bypass_otp=true"
 	}
 
+	// SMS pumping defenses: only real SMS cost money
+	if contactType == protocol.MsgChannelSms && !isSandbox {
+		if errCode := s.checkOTPFraud(ctx, contact); errCode != protocol.Success {
+			return errCode, 0
+		}
+	}
+
 	log.Get().Infof("[OTP] Preparing code for %s via %s (purpose=%s, user_type=%s, sandbox=%v reason=%s, env=%s)",
 		contact, contactType, purpose, user_type, isSandbox, sandboxReason, config.Get().Env)
 
@@ -173,9 +186,10 @@ func (s *VerifyCodeService) SendVerifyCode(contactType, contact, user_type, purp
 			Language: language,
 			To:       contact,
 			Params: map[string]any{
-				"to":         contact,
-				"code":       code,
-				"expiration": s.config.Expiration,
+				"to":            contact,
+				"code":          code,
+				smsParamCodeKey: codeKey,
+				"expiration":    s.config.Expiration,
 			},
 		}
 		if err := s.msgService.SendMessage(msg); err != nil {
@@ -183,6 +197,9 @@ func (s *VerifyCodeService) SendVerifyCode(contactType, contact, user_type, purp
 			return protocol.VerificationCodeSendFailed, 0
 		}
 		log.Get().Infof("[OTP] Code sent successfully to %s via %s", contact, contactType)
+		if contactType == protocol.MsgChannelSms {
+			s.recordOTPSend(ctx, contact)
+		}
 	} else {
 		log.Get().Infof("[OTP] Sandbox mode — code %s stored for %s but NOT sent via SMS", code, contact)
 	}
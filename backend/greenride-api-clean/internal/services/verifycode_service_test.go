@@ -1,6 +1,15 @@
 package services
 
-import "testing"
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"greenride/internal/config"
+	"greenride/internal/protocol"
+)
 
 func TestNormalizeSMSPhone(t *testing.T) {
 	tests := []struct {
@@ -57,3 +66,169 @@ func TestNormalizeSMSPhone(t *testing.T) {
 	}
 }
 
+func TestCallingCodeOf(t *testing.T) {
+	tests := map[string]string{
+		"+250784928786": "250",
+		"+14155550123":  "1",
+		"+447700900123": "44",
+		"+8821234567":   "882",
+		"+79161234567":  "7",
+	}
+	for phone, want := range tests {
+		if got := callingCodeOf(phone); got != want {
+			t.Fatalf("calling code mismatch for %s: got=%q want=%q", phone, got, want)
+		}
+	}
+}
+
+func TestVerifyProofOfWork(t *testing.T) {
+	challenge := "01JTESTCHALLENGE"
+	difficulty := 8
+
+	nonce := ""
+	for i := 0; i < 1<<16; i++ {
+		candidate := strconv.Itoa(i)
+		if verifyProofOfWork(challenge, candidate, difficulty) {
+			nonce = candidate
+			break
+		}
+	}
+	if nonce == "" {
+		t.Fatalf("no nonce found for difficulty %d", difficulty)
+	}
+	if verifyProofOfWork(challenge, nonce, 256) {
+		t.Fatalf("nonce %q should not satisfy maximum difficulty", nonce)
+	}
+	if verifyProofOfWork(challenge, "", difficulty) {
+		t.Fatalf("empty nonce must be rejected")
+	}
+}
+
+func newFraudTestService(t *testing.T, counters map[string]int64) *VerifyCodeService {
+	t.Helper()
+	if config.Get() == nil {
+		config.Set(&config.Config{Log: &config.LogConfig{Path: os.TempDir()}})
+	}
+	cfg := &config.VerifyCodeConfig{Fraud: &config.OTPFraudConfig{
+		PrefixLength:        6,
+		PrefixDailyBudget:   50,
+		CountryDailyBudget:  500,
+		CountryDailyBudgets: map[string]int{"250": 200},
+		IPHourlyLimit:       10,
+		DeviceHourlyLimit:   5,
+		ChallengeAfterSends: 3,
+		ChallengeDifficulty: 8,
+	}}
+	cfg.Validate()
+	return &VerifyCodeService{config: cfg, counter: func(key string) int64 { return counters[key] }}
+}
+
+func TestCheckOTPFraud(t *testing.T) {
+	const phone = "+250784928786"
+	today := time.Now().Format(otpFraudDayFormat)
+	ctx := &OTPSendContext{IP: "10.0.0.1", DeviceID: "device-1"}
+
+	tests := []struct {
+		name     string
+		phone    string
+		ctx      *OTPSendContext
+		counters map[string]int64
+		want     protocol.ErrorCode
+	}{
+		{
+			name: "fresh phone allowed",
+			ctx:  ctx,
+			want: protocol.Success,
+		},
+		{
+			name:  "blocked premium prefix",
+			phone: "+8821234567",
+			ctx:   ctx,
+			want:  protocol.PhoneNumberBlocked,
+		},
+		{
+			name:     "ip hourly velocity",
+			ctx:      ctx,
+			counters: map[string]int64{fmt.Sprintf(otpIPHourKey, "10.0.0.1"): 10},
+			want:     protocol.TooManyAttempts,
+		},
+		{
+			name:     "device hourly velocity",
+			ctx:      ctx,
+			counters: map[string]int64{fmt.Sprintf(otpDeviceHourKey, "device-1"): 5},
+			want:     protocol.TooManyAttempts,
+		},
+		{
+			name:     "prefix daily budget exceeded",
+			ctx:      ctx,
+			counters: map[string]int64{fmt.Sprintf(otpPrefixBudgetKey, today, "250784"): 50},
+			want:     protocol.VerificationBudgetExceeded,
+		},
+		{
+			name:     "country daily budget exceeded",
+			ctx:      ctx,
+			counters: map[string]int64{fmt.Sprintf(otpCountryBudgetKey, today, "250"): 200},
+			want:     protocol.VerificationBudgetExceeded,
+		},
+		{
+			name:     "phone sends below challenge threshold",
+			ctx:      ctx,
+			counters: map[string]int64{fmt.Sprintf(otpPhoneSendsKey, phone): 2},
+			want:     protocol.Success,
+		},
+		{
+			name:     "phone sends require proof of work",
+			ctx:      ctx,
+			counters: map[string]int64{fmt.Sprintf(otpPhoneSendsKey, phone): 3},
+			want:     protocol.VerificationChallenge,
+		},
+		{
+			name:     "ip sends require proof of work for new phone",
+			ctx:      ctx,
+			counters: map[string]int64{fmt.Sprintf(otpIPSendsKey, "10.0.0.1"): 3},
+			want:     protocol.VerificationChallenge,
+		},
+		{
+			name:     "proof of work required without request context",
+			counters: map[string]int64{fmt.Sprintf(otpPhoneSendsKey, phone): 3},
+			want:     protocol.VerificationChallenge,
+		},
+		{
+			name:     "unknown challenge rejected",
+			ctx:      &OTPSendContext{IP: "10.0.0.1", ChallengeID: "missing", ChallengeNonce: "1"},
+			counters: map[string]int64{fmt.Sprintf(otpPhoneSendsKey, phone): 3},
+			want:     protocol.VerificationChallengeFail,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := tt.phone
+			if target == "" {
+				target = phone
+			}
+			s := newFraudTestService(t, tt.counters)
+			if got := s.checkOTPFraud(tt.ctx, target); got != tt.want {
+				t.Fatalf("checkOTPFraud() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckSMSBudget(t *testing.T) {
+	const phone = "+250784928786"
+	today := time.Now().Format(otpFraudDayFormat)
+
+	// 非验证码短信只受预算和黑名单限制，不要求工作量证明
+	s := newFraudTestService(t, map[string]int64{fmt.Sprintf(otpPhoneSendsKey, phone): 10})
+	if got := s.checkSMSBudget(nil, phone); got != protocol.Success {
+		t.Fatalf("checkSMSBudget() with many phone sends = %s, want success", got)
+	}
+	if got := s.checkSMSBudget(nil, "+8821234567"); got != protocol.PhoneNumberBlocked {
+		t.Fatalf("checkSMSBudget() for blocked prefix = %s, want %s", got, protocol.PhoneNumberBlocked)
+	}
+	s = newFraudTestService(t, map[string]int64{fmt.Sprintf(otpCountryBudgetKey, today, "250"): 200})
+	if got := s.checkSMSBudget(nil, phone); got != protocol.VerificationBudgetExceeded {
+		t.Fatalf("checkSMSBudget() over country budget = %s, want %s", got, protocol.VerificationBudgetExceeded)
+	}
+}
@@ -70,3 +70,17 @@ func CalculateCoordinateRange(lat, lng, radiusKm float64) (float64, float64, flo
 
 	return minLat, maxLat, minLng, maxLng
 }
+
+// CalculateBearing 计算从起点指向终点的方位角
+// 返回角度，单位：度，正北为0，顺时针方向取值[0, 360)
+func CalculateBearing(lat1, lng1, lat2, lng2 float64) float64 {
+	lat1Rad := lat1 * math.Pi / 180
+	lat2Rad := lat2 * math.Pi / 180
+	deltaLng := (lng2 - lng1) * math.Pi / 180
+
+	y := math.Sin(deltaLng) * math.Cos(lat2Rad)
+	x := math.Cos(lat1Rad)*math.Sin(lat2Rad) - math.Sin(lat1Rad)*math.Cos(lat2Rad)*math.Cos(deltaLng)
+	bearing := math.Atan2(y, x) * 180 / math.Pi
+
+	return math.Mod(bearing+360, 360)
+}
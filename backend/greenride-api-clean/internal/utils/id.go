@@ -45,6 +45,19 @@ const (
 	ID_PREFIX_PRICE_SNAPSHOT      = "PS"
 	ID_PREFIX_RULE_VERSION        = "RV"
 	ID_PREFIX_CHECKOUT            = "CO"
+	ID_PREFIX_SMS_DELIVERY        = "SMS"
+	ID_PREFIX_PROMOTION_CAMPAIGN  = "PC"
+	ID_PREFIX_EXPERIMENT          = "EXP"
+	ID_PREFIX_REFERRAL            = "RF"
+	ID_PREFIX_MESSAGE_TEMPLATE    = "MT"
+	ID_PREFIX_TEMPLATE_VERSION    = "MTV"
+	ID_PREFIX_USER_DEBT           = "UD"
+	ID_PREFIX_RIDE_STOP           = "RS"
+	ID_PREFIX_RIDE_POOL           = "RP"
+	ID_PREFIX_ORDER_TIP           = "TIP"
+	ID_PREFIX_SAFETY_INCIDENT     = "SOS"
+	ID_PREFIX_TRIP_SHARE          = "TS"
+	ID_PREFIX_TRUSTED_CONTACT     = "TC"
 )
 
 // GenerateUUID 生成UUID
@@ -236,6 +249,71 @@ func GenerateAnnouncementID() string {
 	return fmt.Sprintf("%v%v", ID_PREFIX_ANNOUNCEMENT, GenerateID())
 }
 
+// GenerateSMSDeliveryID 生成短信投递记录ID
+func GenerateSMSDeliveryID() string {
+	return fmt.Sprintf("%v%v", ID_PREFIX_SMS_DELIVERY, GenerateID())
+}
+
+// GeneratePromotionCampaignID 生成发券活动ID
+func GeneratePromotionCampaignID() string {
+	return fmt.Sprintf("%v%v", ID_PREFIX_PROMOTION_CAMPAIGN, GenerateID())
+}
+
+// GeneratePromotionExperimentID 生成优惠实验ID
+func GeneratePromotionExperimentID() string {
+	return fmt.Sprintf("%v%v", ID_PREFIX_EXPERIMENT, GenerateID())
+}
+
+// GenerateReferralID 生成邀请记录ID
+func GenerateReferralID() string {
+	return fmt.Sprintf("%v%v", ID_PREFIX_REFERRAL, GenerateID())
+}
+
+// GenerateMessageTemplateID 生成消息模板ID
+func GenerateMessageTemplateID() string {
+	return fmt.Sprintf("%v%v", ID_PREFIX_MESSAGE_TEMPLATE, GenerateID())
+}
+
+// GenerateMessageTemplateVersionID 生成消息模板版本ID
+func GenerateMessageTemplateVersionID() string {
+	return fmt.Sprintf("%v%v", ID_PREFIX_TEMPLATE_VERSION, GenerateID())
+}
+
+// GenerateUserDebtID 生成乘客欠款ID
+func GenerateUserDebtID() string {
+	return fmt.Sprintf("%v%v", ID_PREFIX_USER_DEBT, GenerateID())
+}
+
+// GenerateRideStopID 生成行程途经点ID
+func GenerateRideStopID() string {
+	return fmt.Sprintf("%v%v", ID_PREFIX_RIDE_STOP, GenerateID())
+}
+
+// GenerateRidePoolID 生成拼车ID
+func GenerateRidePoolID() string {
+	return fmt.Sprintf("%v%v", ID_PREFIX_RIDE_POOL, GenerateID())
+}
+
+// GenerateOrderTipID 生成行程小费ID
+func GenerateOrderTipID() string {
+	return fmt.Sprintf("%v%v", ID_PREFIX_ORDER_TIP, GenerateID())
+}
+
+// GenerateSafetyIncidentID 生成安全事件ID
+func GenerateSafetyIncidentID() string {
+	return fmt.Sprintf("%v%v", ID_PREFIX_SAFETY_INCIDENT, GenerateID())
+}
+
+// GenerateTripShareID 生成行程分享ID
+func GenerateTripShareID() string {
+	return fmt.Sprintf("%v%v", ID_PREFIX_TRIP_SHARE, GenerateID())
+}
+
+// GenerateTrustedContactID 生成紧急联系人ID
+func GenerateTrustedContactID() string {
+	return fmt.Sprintf("%v%v", ID_PREFIX_TRUSTED_CONTACT, GenerateID())
+}
+
 // GeneratePriceSnapshotID 生成价格快照ID
 func GenerateSnapshotID() string {
 	return fmt.Sprintf("%v%v", ID_PREFIX_PRICE_SNAPSHOT, GenerateID())
@@ -261,6 +339,15 @@ func GenerateSalt() string {
 	return hex.EncodeToString(salt)
 }
 
+// GenerateShareToken 生成行程分享链接的随机令牌（32位十六进制）
+func GenerateShareToken() string {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16) + GenerateShortID()
+	}
+	return hex.EncodeToString(token)
+}
+
 // GenerateInviteCode 生成10位随机字母数字组合的邀请码
 func GenerateInviteCode() string {
 	const charset = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
@@ -283,6 +370,11 @@ func GenerateVerifyCode() string {
 	return generateRandomNumber(6)
 }
 
+// GenerateDeliveryCode 生成包裹收件人取件码
+func GenerateDeliveryCode(length int) string {
+	return generateRandomNumber(length)
+}
+
 // generateRandomNumber 生成指定长度的随机数字字符串
 func generateRandomNumber(length int) string {
 	const charset = "0123456789"